@@ -0,0 +1,106 @@
+package bin_parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaklang/yaklang/common/bin-parser/parser"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// testRoundTrip parse the sample, check the decoded field and generate the sample again with the decoded tree
+func testRoundTrip(t *testing.T, rule string, data string, key string, value string) {
+	t.Helper()
+	payload, err := codec.DecodeHex(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := parser.ParseBinary(bytes.NewReader(payload), rule, key)
+	if err != nil {
+		t.Fatalf("parse %s.%s failed: %v", rule, key, err)
+	}
+	tree := NodeToMap(res)
+	var found bool
+	for _, v := range (&DissectResult{Tree: tree}).Fields() {
+		if v == value {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("%s.%s field %q not found: %v", rule, key, value, (&DissectResult{Tree: tree}).Fields())
+	}
+
+	res, err = parser.GenerateBinary(tree, rule, key)
+	if err != nil {
+		t.Fatalf("generate %s.%s failed: %v", rule, key, err)
+	}
+	assert.Equal(t, data, codec.EncodeToHex(NodeToBytes(res)))
+}
+
+func TestMySQL(t *testing.T) {
+	// server greeting of mysql 5.7
+	greeting := "4e0000000a352e372e32392d6c6f67000b0000003b2f45166b477a3d00ffff210200ffc115000000000000000000001a0c6279556e14345f336305006d7973716c5f6e61746976655f70617373776f726400"
+	// login request with database and connect attributes
+	login := "760000010da21e0000000001210000000000000000000000000000000000000000000000726f6f7400140102030405060708090a0b0c0d0e0f101112131474657374006d7973716c5f6e61746976655f70617373776f72640020035f6f73054c696e75780c5f636c69656e745f6e616d65086c69626d7973716c"
+	query := "210000000373656c65637420404076657273696f6e5f636f6d6d656e74206c696d69742031"
+
+	testRoundTrip(t, "application-layer.mysql", greeting, "HandshakeV10", "5.7.29-log")
+	testRoundTrip(t, "application-layer.mysql", login, "HandshakeResponse41", "root")
+	testRoundTrip(t, "application-layer.mysql", query, "Command", "select @@version_comment limit 1")
+	testRoundTrip(t, "application-layer.mysql", greeting+login+query, "Packets", "33")
+}
+
+func TestPostgreSQL(t *testing.T) {
+	startup := "0000003f000300007573657200706f73746772657300646174616261736500706f737467726573006170706c69636174696f6e5f6e616d65007073716c0000"
+	// AuthenticationOk, ParameterStatus, ReadyForQuery and a simple query
+	messages := "52000000080000000053000000187365727665725f76657273696f6e0031342e35005a0000000549510000000e73656c65637420313b00"
+
+	testRoundTrip(t, "application-layer.postgresql", startup, "StartupMessage", "application_name")
+	testRoundTrip(t, "application-layer.postgresql", "0000000804d2162f", "SSLRequest", "80877103")
+	testRoundTrip(t, "application-layer.postgresql", messages, "Messages", "Q")
+}
+
+func TestRedis(t *testing.T) {
+	// SET key value, +OK, null bulk string, integer and nested array
+	messages := "2a330d0a24330d0a5345540d0a24330d0a6b65790d0a24350d0a76616c75650d0a2b4f4b0d0a242d310d0a3a34320d0a2a320d0a2a310d0a2d45525220780d0a24300d0a0d0a"
+	testRoundTrip(t, "application-layer.redis", messages, "Messages", "SET")
+	testRoundTrip(t, "application-layer.redis", messages, "Messages", "ERR x")
+}
+
+func TestMQTT(t *testing.T) {
+	// CONNECT, CONNACK, PUBLISH (QoS 1), SUBSCRIBE and PINGREQ of mqtt 3.1.1
+	v311 := "102700044d51545404c2003c000a79616b2d636c69656e74000561646d696e000870617373776f72642002000032ad01001373656e736f72732f74656d7065726174757265000a7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d7b2276616c7565223a2032332e357d8217000b000973656e736f72732f23010006616c6572747300c000"
+	// CONNECT and PUBLISH with properties of mqtt 5.0
+	v5 := "101b00044d5154540502001e05110000000a000976352d636c69656e74300b0003612f620068656c6c6f"
+
+	testRoundTrip(t, "application-layer.mqtt", v311, "Packets", "sensors/temperature")
+	testRoundTrip(t, "application-layer.mqtt", v311, "Packets", "alerts")
+	testRoundTrip(t, "application-layer.mqtt", v5, "Packets", "v5-client")
+}
+
+func TestModbus(t *testing.T) {
+	// read holding registers, response, write multiple registers and an exception response
+	messages := "0001000000060103006b0003000100000009010306022b0000006400020000000b01100001000204000a0102000300000003018302"
+	testRoundTrip(t, "application-layer.modbus", messages, "Messages", "006b0003")
+	testRoundTrip(t, "application-layer.modbus", messages, "Messages", "131")
+}
+
+func TestSMB2(t *testing.T) {
+	// negotiate request with smb 3.1.1 negotiate contexts, negotiate response and session setup request
+	request := "000000a0fe534d4240000000000000000000010000000000000000000000000000000000fffe00000000000000000000000000000000000000000000000000000000000024000500010000007f000000000102030405060708090a0b0c0d0e0f70000000010000000202100200030203110300000100260000000000010020000100000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f0000"
+	response := "000000cafe534d4240000000000000000000010001000000000000000000000000000000fffe0000000000000000000000000000000000000000000000000000000000004100010011030100101112131415161718191a1b1c1d1e1f2f0000000000800000008000000080000080209bcb82d801000000000000000080004a0000000000604806062b0601050502a03e303ca00e300c060a2b06010401823702020aa32a3028a0261b246e6f745f646566696e65645f696e5f5246433431373840706c656173655f69676e6f7265"
+	sessionSetup := "0000005cfe534d4240000000000000000100010000000000000000000100000000000000fffe00000000000000000000000000000000000000000000000000000000000019000001010000000000000058000400000000000000000054455354"
+
+	testRoundTrip(t, "application-layer.smb2", request, "Messages", "785")
+	testRoundTrip(t, "application-layer.smb2", response, "Messages", "74")
+	testRoundTrip(t, "application-layer.smb2", request+response+sessionSetup, "Messages", "92")
+}
+
+func TestKerberos(t *testing.T) {
+	// AS-REQ of administrator@YAK.LOCAL with PA-PAC-REQUEST
+	asReq := "6a81b73081b4a103020105a20302010aa31530133011a10402020080a20904073005a0030101ffa4819030818da00703050040810010a11a3018a003020101a111300f1b0d61646d696e6973747261746f72a20b1b0959414b2e4c4f43414ca31e301ca003020102a11530131b066b72627467741b0959414b2e4c4f43414ca511180f32303337303931333032343830355aa611180f32303337303931333032343830355aa7060204732e7ee7a80b3009020112020111020117"
+
+	testRoundTrip(t, "application-layer.kerberos", asReq, "UDP", "administrator")
+	testRoundTrip(t, "application-layer.kerberos", "000000ba"+asReq, "TCP", "YAK.LOCAL")
+}
@@ -0,0 +1,62 @@
+package binfuzz
+
+import (
+	"hash/crc32"
+)
+
+// ChecksumFunc compute the checksum of data, the result is truncated to the size of checksum field
+type ChecksumFunc func(data []byte) uint64
+
+// ChecksumInternet is the ones' complement checksum of ip, icmp, tcp and udp (RFC 1071)
+func ChecksumInternet(data []byte) uint64 {
+	var sum uint32
+	for i := 0; i+1 < len(data); i += 2 {
+		sum += uint32(data[i])<<8 | uint32(data[i+1])
+	}
+	if len(data)%2 == 1 {
+		sum += uint32(data[len(data)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return uint64(^uint16(sum))
+}
+
+// ChecksumCRC32 is the IEEE crc32
+func ChecksumCRC32(data []byte) uint64 {
+	return uint64(crc32.ChecksumIEEE(data))
+}
+
+// ChecksumCRC16Modbus is the crc16 of modbus rtu, the bytes are swapped to be the order on wire
+func ChecksumCRC16Modbus(data []byte) uint64 {
+	crc := uint16(0xffff)
+	for _, b := range data {
+		crc ^= uint16(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = crc>>1 ^ 0xa001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return uint64(crc>>8 | crc<<8)
+}
+
+// ChecksumSum8 is the sum of bytes
+func ChecksumSum8(data []byte) uint64 {
+	var sum uint8
+	for _, b := range data {
+		sum += b
+	}
+	return uint64(sum)
+}
+
+// ChecksumXor8 is the xor of bytes
+func ChecksumXor8(data []byte) uint64 {
+	var sum uint8
+	for _, b := range data {
+		sum ^= b
+	}
+	return uint64(sum)
+}
@@ -0,0 +1,161 @@
+package binfuzz
+
+import (
+	"time"
+)
+
+const (
+	defaultTimeout         = 3 * time.Second
+	defaultMaxStringLength = 64 * 1024
+	defaultMaxResponseSize = 64 * 1024
+)
+
+type Config struct {
+	// Keys is the sub node of rule to parse the seed, such as "HandshakeV10" in "application-layer.mysql"
+	Keys []string
+	// Fields is the glob patterns of field path to mutate, empty means all fields
+	Fields        []string
+	ExcludeFields []string
+
+	// AutoRelation infer the length fields from the seed
+	AutoRelation bool
+	Relations    []*Relation
+
+	FuzzTags        []string
+	Dictionary      []string
+	MaxStringLength int
+	MaxCases        int
+
+	// Network is "tcp" or "udp"
+	Network         string
+	Timeout         time.Duration
+	Interval        time.Duration
+	MaxResponseSize int
+	StopOnCrash     bool
+}
+
+type Option func(*Config)
+
+func NewConfig(opts ...Option) *Config {
+	c := &Config{
+		AutoRelation:    true,
+		Dictionary:      defaultDictionary,
+		MaxStringLength: defaultMaxStringLength,
+		Network:         "tcp",
+		Timeout:         defaultTimeout,
+		MaxResponseSize: defaultMaxResponseSize,
+		StopOnCrash:     true,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithKeys set the sub node of rule to parse the seed
+func WithKeys(keys ...string) Option {
+	return func(c *Config) {
+		c.Keys = keys
+	}
+}
+
+// WithFields only mutate the fields matched by the glob patterns, the path is joined by "/", and the index of list is "#n"
+func WithFields(patterns ...string) Option {
+	return func(c *Config) {
+		c.Fields = append(c.Fields, patterns...)
+	}
+}
+
+// WithExcludeFields don't mutate the fields matched by the glob patterns
+func WithExcludeFields(patterns ...string) Option {
+	return func(c *Config) {
+		c.ExcludeFields = append(c.ExcludeFields, patterns...)
+	}
+}
+
+// WithAutoRelation set whether to infer the length fields from the seed, default is true
+func WithAutoRelation(b bool) Option {
+	return func(c *Config) {
+		c.AutoRelation = b
+	}
+}
+
+// WithLengthField declare field is the byte length of targets, it is recomputed when the targets are mutated
+func WithLengthField(field string, targets ...string) Option {
+	return func(c *Config) {
+		c.Relations = append(c.Relations, &Relation{Kind: RelationLength, Field: field, Targets: targets, Unit: 1})
+	}
+}
+
+// WithLengthFieldUnit is like WithLengthField, but the length is counted in unit bytes, such as 4 for the header length of ipv4
+func WithLengthFieldUnit(field string, unit int, targets ...string) Option {
+	return func(c *Config) {
+		if unit <= 0 {
+			unit = 1
+		}
+		c.Relations = append(c.Relations, &Relation{Kind: RelationLength, Field: field, Targets: targets, Unit: unit})
+	}
+}
+
+// WithChecksumField declare field is the checksum of targets, it is recomputed for every generated message
+func WithChecksumField(field string, checksum ChecksumFunc, targets ...string) Option {
+	return func(c *Config) {
+		c.Relations = append(c.Relations, &Relation{Kind: RelationChecksum, Field: field, Targets: targets, Checksum: checksum})
+	}
+}
+
+// WithFuzzTag add the payloads rendered from fuzztag templates to the string fields, such as "{{int(1-10)}}"
+func WithFuzzTag(templates ...string) Option {
+	return func(c *Config) {
+		c.FuzzTags = append(c.FuzzTags, templates...)
+	}
+}
+
+// WithDictionary replace the builtin string payloads
+func WithDictionary(payloads ...string) Option {
+	return func(c *Config) {
+		c.Dictionary = payloads
+	}
+}
+
+// WithMaxStringLength limit the length of overflow payloads of string fields
+func WithMaxStringLength(n int) Option {
+	return func(c *Config) {
+		c.MaxStringLength = n
+	}
+}
+
+// WithMaxCases limit the count of generated cases, zero means no limit
+func WithMaxCases(n int) Option {
+	return func(c *Config) {
+		c.MaxCases = n
+	}
+}
+
+// WithNetwork set the transport to send cases, "tcp" or "udp"
+func WithNetwork(network string) Option {
+	return func(c *Config) {
+		c.Network = network
+	}
+}
+
+// WithTimeout set the timeout of connecting and waiting response
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.Timeout = timeout
+	}
+}
+
+// WithInterval wait between two cases
+func WithInterval(interval time.Duration) Option {
+	return func(c *Config) {
+		c.Interval = interval
+	}
+}
+
+// WithStopOnCrash set whether to stop fuzzing when the target crashed, default is true
+func WithStopOnCrash(b bool) Option {
+	return func(c *Config) {
+		c.StopOnCrash = b
+	}
+}
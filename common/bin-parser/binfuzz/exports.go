@@ -0,0 +1,49 @@
+package binfuzz
+
+import (
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// _newFuzzer parse the seed (bytes or string) with bin-parser rule, such as "application-layer.mysql"
+func _newFuzzer(seed interface{}, rule string, opts ...Option) (*Fuzzer, error) {
+	return NewFuzzer(utils.InterfaceToBytes(seed), rule, opts...)
+}
+
+func _withTimeout(seconds float64) Option {
+	return WithTimeout(utils.FloatSecondDuration(seconds))
+}
+
+func _withInterval(seconds float64) Option {
+	return WithInterval(utils.FloatSecondDuration(seconds))
+}
+
+var Exports = map[string]interface{}{
+	"NewFuzzer": _newFuzzer,
+
+	"keys":            WithKeys,
+	"fields":          WithFields,
+	"excludeFields":   WithExcludeFields,
+	"autoRelation":    WithAutoRelation,
+	"lengthField":     WithLengthField,
+	"lengthFieldUnit": WithLengthFieldUnit,
+	"checksumField":   WithChecksumField,
+	"fuzztag":         WithFuzzTag,
+	"dictionary":      WithDictionary,
+	"maxStringLength": WithMaxStringLength,
+	"maxCases":        WithMaxCases,
+	"network":         WithNetwork,
+	"timeout":         _withTimeout,
+	"interval":        _withInterval,
+	"stopOnCrash":     WithStopOnCrash,
+
+	"CHECKSUM_INTERNET":     ChecksumFunc(ChecksumInternet),
+	"CHECKSUM_CRC32":        ChecksumFunc(ChecksumCRC32),
+	"CHECKSUM_CRC16_MODBUS": ChecksumFunc(ChecksumCRC16Modbus),
+	"CHECKSUM_SUM8":         ChecksumFunc(ChecksumSum8),
+	"CHECKSUM_XOR8":         ChecksumFunc(ChecksumXor8),
+
+	"STATUS_OK":          StatusOK,
+	"STATUS_NO_RESPONSE": StatusNoResponse,
+	"STATUS_CLOSED":      StatusClosed,
+	"STATUS_CRASH":       StatusCrash,
+}
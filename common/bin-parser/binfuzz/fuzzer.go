@@ -0,0 +1,633 @@
+package binfuzz
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+
+	bin_parser "github.com/yaklang/yaklang/common/bin-parser"
+	"github.com/yaklang/yaklang/common/bin-parser/parser"
+	"github.com/yaklang/yaklang/common/bin-parser/parser/base"
+	"github.com/yaklang/yaklang/common/bin-parser/parser/stream_parser"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/mutate"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	RelationLength   = "length"
+	RelationChecksum = "checksum"
+)
+
+// Relation describe a field depends on other fields
+type Relation struct {
+	// Kind is RelationLength or RelationChecksum
+	Kind  string
+	Field string
+	// Targets is the path of nodes, the length or checksum is computed from the first to the last byte of them
+	Targets []string
+	// Unit is the byte count of a unit of length field
+	Unit     int
+	Checksum ChecksumFunc
+	// Auto means the relation is inferred from the seed
+	Auto bool
+}
+
+func (r *Relation) contains(path string) bool {
+	for _, target := range r.Targets {
+		if path == target || strings.HasPrefix(path, target+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Field is a terminal node of the seed
+type Field struct {
+	// Path is joined by "/", and the index of list is "#n"
+	Path    string
+	Value   any
+	Numeric bool
+	// Bits is the size of field in the seed
+	Bits uint64
+
+	// start and end is the bit position in the seed, end contains the delimiter
+	start, end uint64
+	relation   *Relation
+}
+
+func (f *Field) uint64Value() uint64 {
+	return stream_parser.AnyToUint64(f.Value)
+}
+
+func (f *Field) bytesValue() []byte {
+	switch ret := f.Value.(type) {
+	case []byte:
+		return ret
+	case string:
+		return []byte(ret)
+	default:
+		return utils.InterfaceToBytes(ret)
+	}
+}
+
+// convert value to the type of field
+func (f *Field) convert(value any) any {
+	if f.Numeric {
+		v := stream_parser.AnyToUint64(value) & numberMask(f.Bits)
+		return reflect.ValueOf(v).Convert(reflect.TypeOf(f.Value)).Interface()
+	}
+	if _, ok := f.Value.(string); ok {
+		return utils.InterfaceToString(value)
+	}
+	return utils.InterfaceToBytes(value)
+}
+
+// Case is a mutated message
+type Case struct {
+	Index    int
+	Field    string
+	Mutation string
+	Value    any
+	Payload  []byte
+}
+
+type fieldNode struct {
+	path     string
+	parent   *fieldNode
+	children []*fieldNode
+	isList   bool
+	field    *Field
+	// start and end is the bit position in the seed
+	start, end uint64
+}
+
+// Fuzzer mutate the fields of seed parsed by bin-parser rule and regenerate the message
+type Fuzzer struct {
+	config *Config
+	rule   string
+	seed   []byte
+	tree   any
+
+	root            *fieldNode
+	nodes           map[string]*fieldNode
+	fields          []*Field
+	relations       []*Relation
+	fuzzTagPayloads []string
+}
+
+// NewFuzzer parse the seed with rule, such as "application-layer.mysql"
+func NewFuzzer(seed []byte, rule string, opts ...Option) (*Fuzzer, error) {
+	config := NewConfig(opts...)
+	node, err := parser.ParseBinary(bytes.NewReader(seed), rule, config.Keys...)
+	if err != nil {
+		return nil, utils.Errorf("parse seed failed: %v", err)
+	}
+	if node == nil {
+		return nil, utils.Error("parse seed failed: empty node")
+	}
+	tree := bin_parser.NodeToMap(node)
+	if tree == nil {
+		return nil, utils.Error("parse seed failed: empty result")
+	}
+
+	f := &Fuzzer{
+		config: config,
+		rule:   rule,
+		seed:   seed,
+		tree:   tree,
+		nodes:  make(map[string]*fieldNode),
+	}
+	f.root = f.indexNode(node, "", nil)
+
+	for _, r := range config.Relations {
+		if err := f.addRelation(r); err != nil {
+			return nil, err
+		}
+	}
+	if config.AutoRelation {
+		for _, field := range f.fields {
+			if r := f.inferLength(field); r != nil {
+				_ = f.addRelation(r)
+			}
+		}
+	}
+
+	for _, template := range config.FuzzTags {
+		payloads, err := mutate.FuzzTagExec(template)
+		if err != nil {
+			return nil, utils.Errorf("render fuzztag %s failed: %v", template, err)
+		}
+		f.fuzzTagPayloads = append(f.fuzzTagPayloads, payloads...)
+	}
+	return f, nil
+}
+
+func hasResult(node *base.Node) bool {
+	if stream_parser.NodeHasResult(node) {
+		return true
+	}
+	for _, child := range node.Children {
+		if hasResult(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// walkResultNode walk the nodes with result like NodeToMap, handle return false to skip the children
+func walkResultNode(node *base.Node, path string, handle func(path string, node *base.Node) bool) {
+	if !handle(path, node) || stream_parser.NodeHasResult(node) {
+		return
+	}
+	isList := node.Cfg.GetBool(stream_parser.CfgIsList)
+	index := 0
+	for _, child := range node.Children {
+		if !hasResult(child) {
+			continue
+		}
+		name := child.Name
+		if isList {
+			name = "#" + strconv.Itoa(index)
+			index++
+		}
+		if path != "" {
+			name = path + "/" + name
+		}
+		walkResultNode(child, name, handle)
+	}
+}
+
+func (f *Fuzzer) indexNode(node *base.Node, path string, parent *fieldNode) *fieldNode {
+	n := &fieldNode{path: path, parent: parent, isList: node.Cfg.GetBool(stream_parser.CfgIsList)}
+	f.nodes[path] = n
+	if stream_parser.NodeHasResult(node) {
+		pos := stream_parser.GetNodeResultPos(node)
+		value := stream_parser.GetResultByNode(node)
+		field := &Field{
+			Path:    path,
+			Value:   value,
+			Numeric: stream_parser.IsNumber(value),
+			Bits:    pos[1] - pos[0],
+			start:   pos[0],
+			end:     pos[1],
+		}
+		if stream_parser.NodeIsDelimiter(node) {
+			field.end += uint64(len(node.Cfg.GetString(stream_parser.CfgDelimiter))) * 8
+		}
+		n.field = field
+		n.start, n.end = field.start, field.end
+		f.fields = append(f.fields, field)
+		return n
+	}
+
+	index := 0
+	for _, child := range node.Children {
+		if !hasResult(child) {
+			continue
+		}
+		name := child.Name
+		if n.isList {
+			name = "#" + strconv.Itoa(index)
+			index++
+		}
+		if path != "" {
+			name = path + "/" + name
+		}
+		sub := f.indexNode(child, name, n)
+		if len(n.children) == 0 || sub.start < n.start {
+			n.start = sub.start
+		}
+		if sub.end > n.end {
+			n.end = sub.end
+		}
+		n.children = append(n.children, sub)
+	}
+	return n
+}
+
+func (f *Fuzzer) addRelation(r *Relation) error {
+	node, ok := f.nodes[r.Field]
+	if !ok || node.field == nil || !node.field.Numeric {
+		return utils.Errorf("relation field %s is not a numeric field", r.Field)
+	}
+	if node.field.relation != nil {
+		return utils.Errorf("field %s already has a relation", r.Field)
+	}
+	if len(r.Targets) == 0 {
+		return utils.Errorf("relation of field %s has no target", r.Field)
+	}
+	for _, target := range r.Targets {
+		if _, ok := f.nodes[target]; !ok {
+			return utils.Errorf("relation target %s not found", target)
+		}
+	}
+	if r.Kind == RelationChecksum && r.Checksum == nil {
+		return utils.Errorf("checksum field %s has no checksum function", r.Field)
+	}
+	if r.Unit <= 0 {
+		r.Unit = 1
+	}
+	node.field.relation = r
+	f.relations = append(f.relations, r)
+	return nil
+}
+
+func spanOf(nodes []*fieldNode) (start, end uint64) {
+	for i, n := range nodes {
+		if i == 0 || n.start < start {
+			start = n.start
+		}
+		if n.end > end {
+			end = n.end
+		}
+	}
+	return start, end
+}
+
+func hasBytesField(nodes []*fieldNode) bool {
+	for _, n := range nodes {
+		if n.field != nil && !n.field.Numeric {
+			return true
+		}
+		if hasBytesField(n.children) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *fieldNode) following() []*fieldNode {
+	if n.parent == nil {
+		return nil
+	}
+	for i, sibling := range n.parent.children {
+		if sibling == n {
+			return n.parent.children[i+1:]
+		}
+	}
+	return nil
+}
+
+// inferLength find the nodes whose byte length equal to the value of numeric field,
+// the candidates are the following siblings of field and its parents, stop at the element of list
+func (f *Fuzzer) inferLength(field *Field) *Relation {
+	n := f.nodes[field.Path]
+	v := field.uint64Value()
+	if !field.Numeric || field.relation != nil || v == 0 {
+		return nil
+	}
+
+	var candidates [][]*fieldNode
+	var levels []*fieldNode
+	for cur := n; cur.parent != nil && !cur.parent.isList && len(levels) < 3; cur = cur.parent {
+		levels = append(levels, cur)
+	}
+	// a following sibling
+	for i, cur := range levels {
+		if i >= 2 {
+			break
+		}
+		for _, sibling := range cur.following() {
+			candidates = append(candidates, []*fieldNode{sibling})
+		}
+	}
+	// the rest after the field, with or without the field itself
+	var rest []*fieldNode
+	for _, cur := range levels {
+		rest = append(rest, cur.following()...)
+		candidates = append(candidates, append([]*fieldNode{}, rest...))
+	}
+	rest = []*fieldNode{n}
+	for _, cur := range levels {
+		rest = append(rest, cur.following()...)
+		candidates = append(candidates, append([]*fieldNode{}, rest...))
+	}
+	// the parents contain the field
+	for _, cur := range levels {
+		candidates = append(candidates, []*fieldNode{cur.parent})
+	}
+
+	for _, nodes := range candidates {
+		if len(nodes) == 0 || !hasBytesField(nodes) {
+			continue
+		}
+		start, end := spanOf(nodes)
+		if (end-start)%8 != 0 || (end-start)/8 != v {
+			continue
+		}
+		targets := make([]string, 0, len(nodes))
+		for _, node := range nodes {
+			targets = append(targets, node.path)
+		}
+		return &Relation{Kind: RelationLength, Field: field.Path, Targets: targets, Unit: 1, Auto: true}
+	}
+	return nil
+}
+
+// Fields return the terminal fields of seed
+func (f *Fuzzer) Fields() []*Field {
+	return f.fields
+}
+
+// Relations return the length and checksum relations, contains the inferred ones
+func (f *Fuzzer) Relations() []*Relation {
+	return f.relations
+}
+
+// Tree return the decoded seed, converted by NodeToMap
+func (f *Fuzzer) Tree() any {
+	return f.tree
+}
+
+func (f *Fuzzer) selected(field *Field) bool {
+	if len(f.config.Fields) > 0 && !utils.MatchAnyOfGlob(field.Path, f.config.Fields...) {
+		return false
+	}
+	if len(f.config.ExcludeFields) > 0 && utils.MatchAnyOfGlob(field.Path, f.config.ExcludeFields...) {
+		return false
+	}
+	return true
+}
+
+// Generate mutate the selected fields one by one, the dependent length and checksum fields are recomputed
+func (f *Fuzzer) Generate(ctx context.Context) <-chan *Case {
+	ch := make(chan *Case)
+	go func() {
+		defer close(ch)
+		index := 0
+		for _, field := range f.fields {
+			if !f.selected(field) {
+				continue
+			}
+			for _, m := range f.mutations(field) {
+				if f.config.MaxCases > 0 && index >= f.config.MaxCases {
+					return
+				}
+				payload, err := f.build(field, m.value)
+				if err != nil {
+					log.Debugf("generate case %s of %s failed: %v", m.name, field.Path, err)
+					continue
+				}
+				index++
+				select {
+				case ch <- &Case{Index: index, Field: field.Path, Mutation: m.name, Value: m.value, Payload: payload}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+// Mutate set the field to value and regenerate the message
+func (f *Fuzzer) Mutate(path string, value any) ([]byte, error) {
+	n, ok := f.nodes[path]
+	if !ok || n.field == nil {
+		return nil, utils.Errorf("field %s not found", path)
+	}
+	return f.build(n.field, value)
+}
+
+func (f *Fuzzer) build(field *Field, value any) (payload []byte, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = utils.Errorf("generate panic: %v", e)
+		}
+	}()
+
+	value = field.convert(value)
+	tree := copyTree(f.tree)
+	// the length and checksum field are patched after generated, or the generator cut the message by the wrong length
+	if field.relation == nil {
+		if !setTreeValue(tree, field.Path, value) {
+			return nil, utils.Errorf("set field %s failed", field.Path)
+		}
+		var delta int64
+		switch ret := value.(type) {
+		case []byte:
+			delta = int64(len(ret)) - int64(len(field.bytesValue()))
+		case string:
+			delta = int64(len(ret)) - int64(len(field.bytesValue()))
+		}
+		for _, r := range f.relations {
+			if r.Kind != RelationLength || delta == 0 || !r.contains(field.Path) {
+				continue
+			}
+			lengthField := f.nodes[r.Field].field
+			l := int64(lengthField.uint64Value()) + delta/int64(r.Unit)
+			if l < 0 {
+				l = 0
+			}
+			setTreeValue(tree, r.Field, lengthField.convert(uint64(l)))
+		}
+	}
+
+	node, err := parser.GenerateBinary(tree, f.rule, f.config.Keys...)
+	if err != nil {
+		return nil, err
+	}
+	payload = append([]byte{}, bin_parser.NodeToBytes(node)...)
+	if field.relation != nil {
+		if err := patchField(payload, node, field.Path, stream_parser.AnyToUint64(value)); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range f.relations {
+		if r.Kind != RelationChecksum || r == field.relation {
+			continue
+		}
+		if err := f.fixChecksum(payload, node, r); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+func (f *Fuzzer) fixChecksum(payload []byte, node *base.Node, r *Relation) error {
+	var start, end uint64
+	for i, target := range r.Targets {
+		targetNode := findNode(node, target)
+		if targetNode == nil {
+			return utils.Errorf("checksum target %s not found", target)
+		}
+		s, e, ok := nodeSpan(targetNode)
+		if !ok {
+			continue
+		}
+		if i == 0 || s < start {
+			start = s
+		}
+		if e > end {
+			end = e
+		}
+	}
+	if err := patchField(payload, node, r.Field, 0); err != nil {
+		return err
+	}
+	if end/8 > uint64(len(payload)) || start > end {
+		return utils.Errorf("invalid checksum range of %s", r.Field)
+	}
+	return patchField(payload, node, r.Field, r.Checksum(payload[start/8:end/8]))
+}
+
+func findNode(node *base.Node, path string) *base.Node {
+	var ret *base.Node
+	walkResultNode(node, "", func(p string, n *base.Node) bool {
+		if ret != nil {
+			return false
+		}
+		if p == path {
+			ret = n
+			return false
+		}
+		return p == "" || strings.HasPrefix(path, p+"/")
+	})
+	return ret
+}
+
+func nodeSpan(node *base.Node) (start, end uint64, ok bool) {
+	walkResultNode(node, "", func(_ string, n *base.Node) bool {
+		if stream_parser.NodeHasResult(n) {
+			pos := stream_parser.GetNodeResultPos(n)
+			if !ok || pos[0] < start {
+				start = pos[0]
+			}
+			if pos[1] > end {
+				end = pos[1]
+			}
+			ok = true
+		}
+		return true
+	})
+	return
+}
+
+// patchField overwrite the numeric field in the generated message
+func patchField(payload []byte, node *base.Node, path string, value uint64) error {
+	fieldNode := findNode(node, path)
+	if fieldNode == nil || !stream_parser.NodeHasResult(fieldNode) {
+		return utils.Errorf("field %s not found in generated message", path)
+	}
+	pos := stream_parser.GetNodeResultPos(fieldNode)
+	bits := pos[1] - pos[0]
+	if bits == 0 || bits > 64 || pos[1] > uint64(len(payload))*8 {
+		return utils.Errorf("invalid position of field %s", path)
+	}
+	value &= numberMask(bits)
+	if bits%8 == 0 && fieldNode.Cfg.GetString(stream_parser.CfgEndian) == "little" {
+		var swapped uint64
+		for i := uint64(0); i < bits/8; i++ {
+			swapped = swapped<<8 | (value>>(8*i))&0xff
+		}
+		value = swapped
+	}
+	for i := uint64(0); i < bits; i++ {
+		bit := (value >> (bits - 1 - i)) & 1
+		offset := pos[0] + i
+		mask := byte(0x80 >> (offset % 8))
+		if bit == 1 {
+			payload[offset/8] |= mask
+		} else {
+			payload[offset/8] &^= mask
+		}
+	}
+	return nil
+}
+
+func copyTree(d any) any {
+	switch ret := d.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(ret))
+		for k, v := range ret {
+			m[k] = copyTree(v)
+		}
+		return m
+	case []any:
+		l := make([]any, len(ret))
+		for i, v := range ret {
+			l[i] = copyTree(v)
+		}
+		return l
+	default:
+		return d
+	}
+}
+
+func setTreeValue(tree any, path string, value any) bool {
+	keys := strings.Split(path, "/")
+	d := tree
+	for i, key := range keys {
+		last := i == len(keys)-1
+		switch ret := d.(type) {
+		case map[string]any:
+			if _, ok := ret[key]; !ok {
+				return false
+			}
+			if last {
+				ret[key] = value
+				return true
+			}
+			d = ret[key]
+		case []any:
+			if !strings.HasPrefix(key, "#") {
+				return false
+			}
+			index, err := strconv.Atoi(key[1:])
+			if err != nil || index < 0 || index >= len(ret) {
+				return false
+			}
+			if last {
+				ret[index] = value
+				return true
+			}
+			d = ret[index]
+		default:
+			return false
+		}
+	}
+	return false
+}
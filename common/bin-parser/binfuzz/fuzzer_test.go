@@ -0,0 +1,156 @@
+package binfuzz
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/bin-parser/parser"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// read holding registers of modbus/tcp
+var modbusSeed, _ = codec.DecodeHex("0001000000060103006b0003")
+
+func TestInferLength(t *testing.T) {
+	f, err := NewFuzzer(modbusSeed, "application-layer.modbus", WithKeys("Messages"))
+	require.NoError(t, err)
+	require.Len(t, f.Relations(), 1)
+	r := f.Relations()[0]
+	assert.Equal(t, "#0/MBAP Header/Length", r.Field)
+	assert.Equal(t, []string{"#0/MBAP Header/Unit ID", "#0/Function Code", "#0/Data"}, r.Targets)
+	assert.True(t, r.Auto)
+
+	// the length of mysql packet doesn't contain the header
+	greeting, _ := codec.DecodeHex("4e0000000a352e372e32392d6c6f67000b0000003b2f45166b477a3d00ffff210200ffc115000000000000000000001a0c6279556e14345f336305006d7973716c5f6e61746976655f70617373776f726400")
+	f, err = NewFuzzer(greeting, "application-layer.mysql", WithKeys("Packets"))
+	require.NoError(t, err)
+	require.Len(t, f.Relations(), 1)
+	assert.Equal(t, "#0/Header/Payload Length", f.Relations()[0].Field)
+	assert.Equal(t, []string{"#0/Payload"}, f.Relations()[0].Targets)
+}
+
+func TestMutate(t *testing.T) {
+	f, err := NewFuzzer(modbusSeed, "application-layer.modbus", WithKeys("Messages"))
+	require.NoError(t, err)
+
+	// the length is recomputed
+	payload, err := f.Mutate("#0/Data", "AAAAAAAAAA")
+	require.NoError(t, err)
+	assert.Equal(t, "00010000000c0103", codec.EncodeToHex(payload[:8]))
+	assert.Equal(t, "AAAAAAAAAA", string(payload[8:]))
+	node, err := parser.ParseBinary(bytes.NewReader(payload), "application-layer.modbus", "Messages")
+	require.NoError(t, err)
+	assert.Len(t, node.Children, 1)
+
+	// the length field itself is inconsistent
+	payload, err = f.Mutate("#0/MBAP Header/Length", 0xffff)
+	require.NoError(t, err)
+	assert.Equal(t, "00010000ffff0103006b0003", codec.EncodeToHex(payload))
+}
+
+func TestChecksumField(t *testing.T) {
+	f, err := NewFuzzer(modbusSeed, "application-layer.modbus", WithKeys("Messages"), WithChecksumField("#0/MBAP Header/Transaction ID", ChecksumSum8, "#0/Data"))
+	require.NoError(t, err)
+	payload, err := f.Mutate("#0/Data", []byte{1, 2, 3})
+	require.NoError(t, err)
+	assert.Equal(t, "0006000000050103010203", codec.EncodeToHex(payload))
+
+	assert.Equal(t, uint64(0xb861), ChecksumInternet([]byte{0x45, 0x00, 0x00, 0x73, 0x00, 0x00, 0x40, 0x00, 0x40, 0x11, 0x00, 0x00, 0xc0, 0xa8, 0x00, 0x01, 0xc0, 0xa8, 0x00, 0xc7}))
+	assert.Equal(t, uint64(0xcbf43926), ChecksumCRC32([]byte("123456789")))
+	assert.Equal(t, uint64(0x840a), ChecksumCRC16Modbus([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01}))
+}
+
+func TestGenerate(t *testing.T) {
+	f, err := NewFuzzer(modbusSeed, "application-layer.modbus", WithKeys("Messages"), WithFields("*/Data"), WithDictionary("%n"), WithFuzzTag("{{int(1-3)}}"), WithMaxStringLength(1024))
+	require.NoError(t, err)
+	var cases []*Case
+	for c := range f.Generate(context.Background()) {
+		cases = append(cases, c)
+	}
+	mutations := map[string]int{}
+	for _, c := range cases {
+		assert.Equal(t, "#0/Data", c.Field)
+		mutations[c.Mutation]++
+		value := c.Value.([]byte)
+		assert.Equal(t, len(value)+2, int(binary.BigEndian.Uint16(c.Payload[4:6])))
+		assert.Equal(t, value, c.Payload[8:])
+	}
+	assert.Equal(t, 1, mutations["empty"])
+	assert.Equal(t, 1, mutations["dictionary"])
+	assert.Equal(t, 3, mutations["fuzztag"])
+	assert.Equal(t, 1, mutations["overflow:1024"])
+	assert.Zero(t, mutations["overflow:4096"])
+
+	cases = cases[:0]
+	f, err = NewFuzzer(modbusSeed, "application-layer.modbus", WithKeys("Messages"), WithFields("*/Function Code"), WithMaxCases(3))
+	require.NoError(t, err)
+	for c := range f.Generate(context.Background()) {
+		cases = append(cases, c)
+	}
+	require.Len(t, cases, 3)
+	assert.Equal(t, "boundary:0x0", cases[0].Mutation)
+	assert.Equal(t, "000100000006010000", codec.EncodeToHex(cases[0].Payload[:9]))
+}
+
+func TestFuzz(t *testing.T) {
+	port := utils.GetRandomAvailableTCPPort()
+	lis, err := net.Listen("tcp", utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	defer lis.Close()
+
+	// the server crashes when the data is larger than 1024 bytes, and closes the connection when the length is wrong
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 70000)
+			var n int
+			for {
+				conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+				m, err := conn.Read(buf[n:])
+				n += m
+				if err != nil || n >= len(buf) {
+					break
+				}
+			}
+			if n < 8 || int(binary.BigEndian.Uint16(buf[4:6])) != n-6 {
+				conn.Close()
+				continue
+			}
+			if n-8 > 1024 {
+				conn.Close()
+				lis.Close()
+				return
+			}
+			conn.Write(buf[:8])
+			conn.Close()
+		}
+	}()
+
+	f, err := NewFuzzer(modbusSeed, "application-layer.modbus", WithKeys("Messages"), WithFields("*/Data", "*/Length"), WithTimeout(time.Second))
+	require.NoError(t, err)
+	results, err := f.Fuzz(context.Background(), utils.HostPort("127.0.0.1", port))
+	require.NoError(t, err)
+	var last *Result
+	statuses := map[string]int{}
+	for result := range results {
+		statuses[result.Status]++
+		last = result
+	}
+	require.NotNil(t, last)
+	assert.Equal(t, StatusCrash, last.Status)
+	assert.Equal(t, "#0/Data", last.Case.Field)
+	assert.Equal(t, "overflow:4096", last.Case.Mutation)
+	// the wrong length is closed by server
+	assert.NotZero(t, statuses[StatusClosed])
+	assert.NotZero(t, statuses[StatusOK])
+}
@@ -0,0 +1,105 @@
+package binfuzz
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+)
+
+var defaultDictionary = []string{
+	"%s%s%s%s%s%s%s%s",
+	"%n%n%n%n%n%n%n%n",
+	"%x%x%x%x%x%x%x%x",
+	"%99999999s",
+	"\x00",
+	"\xff\xff\xff\xff",
+	"\r\n\r\n",
+	"../../../../../../../../etc/passwd",
+	`..\..\..\..\..\..\windows\win.ini`,
+	"' or '1'='1",
+	`" or "1"="1`,
+	"<script>alert(1)</script>",
+	"${jndi:ldap://127.0.0.1/a}",
+	"{{7*7}}${7*7}",
+	";id;",
+	"|id",
+	"$(id)",
+	"-1",
+	"4294967295",
+	"18446744073709551616",
+}
+
+var overflowLengths = []int{1, 255, 256, 1024, 4096, 65535, 65536}
+
+type mutation struct {
+	name  string
+	value any
+}
+
+func numberMask(bits uint64) uint64 {
+	if bits >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<bits - 1
+}
+
+// numberMutations return the boundary values of numeric field, the original value is excluded
+func numberMutations(field *Field) []*mutation {
+	mask := numberMask(field.Bits)
+	orig := field.uint64Value()
+	candidates := []uint64{0, 1, mask, mask - 1, mask >> 1, mask>>1 + 1, orig + 1, orig - 1}
+
+	kind := "boundary"
+	if field.relation != nil {
+		// the value of length and checksum field is inconsistent with the message
+		kind = field.relation.Kind
+	}
+	var ret []*mutation
+	seen := map[uint64]struct{}{orig: {}}
+	for _, v := range candidates {
+		v &= mask
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		ret = append(ret, &mutation{name: fmt.Sprintf("%s:%#x", kind, v), value: v})
+	}
+	return ret
+}
+
+// bytesMutations return the empty, overflow and dictionary payloads of string field
+func (f *Fuzzer) bytesMutations(field *Field) []*mutation {
+	orig := field.bytesValue()
+	var ret []*mutation
+	seen := map[string]struct{}{string(orig): {}}
+	add := func(name string, v []byte) {
+		if len(v) > f.config.MaxStringLength {
+			return
+		}
+		if _, ok := seen[string(v)]; ok {
+			return
+		}
+		seen[string(v)] = struct{}{}
+		ret = append(ret, &mutation{name: name, value: v})
+	}
+
+	add("empty", []byte{})
+	lengths := append([]int{len(orig) + 1, len(orig) * 2}, overflowLengths...)
+	for _, l := range lengths {
+		add(fmt.Sprintf("overflow:%d", l), bytes.Repeat([]byte("A"), l))
+	}
+	for _, payload := range f.config.Dictionary {
+		add("dictionary", []byte(payload))
+	}
+	for _, payload := range f.fuzzTagPayloads {
+		add("fuzztag", []byte(payload))
+	}
+	return ret
+}
+
+func (f *Fuzzer) mutations(field *Field) []*mutation {
+	if field.Numeric {
+		return numberMutations(field)
+	}
+	return f.bytesMutations(field)
+}
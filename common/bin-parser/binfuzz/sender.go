@@ -0,0 +1,173 @@
+package binfuzz
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	// StatusOK means the target responded
+	StatusOK = "ok"
+	// StatusNoResponse means the target responded the seed but not the case
+	StatusNoResponse = "no-response"
+	// StatusClosed means the connection is closed or reset by the target without response
+	StatusClosed = "closed"
+	// StatusCrash means the target doesn't respond the seed any more after the case
+	StatusCrash = "crash"
+)
+
+// the idle time to wait for the rest of response after the first read
+const responseIdleTimeout = 300 * time.Millisecond
+
+// Result is the behavior of target for a case
+type Result struct {
+	Case     *Case
+	Status   string
+	Response []byte
+	Duration time.Duration
+	Reason   string
+}
+
+// IsAnomaly return true if the target behaves differently from the seed
+func (r *Result) IsAnomaly() bool {
+	return r.Status != StatusOK
+}
+
+type exchange struct {
+	response []byte
+	dialErr  error
+	writeErr error
+	readErr  error
+}
+
+func (e *exchange) timeout() bool {
+	return e.readErr != nil && utils.IsErrorNetOpTimeout(e.readErr)
+}
+
+func (e *exchange) reason() string {
+	for _, err := range []error{e.dialErr, e.writeErr, e.readErr} {
+		if err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+func (f *Fuzzer) dial(target string) (net.Conn, error) {
+	if strings.ToLower(f.config.Network) == "udp" {
+		return netx.DialTimeoutWithoutProxy(f.config.Timeout, "udp", target)
+	}
+	return netx.DialTCPTimeout(f.config.Timeout, target)
+}
+
+func (f *Fuzzer) exchange(target string, payload []byte) *exchange {
+	ex := &exchange{}
+	conn, err := f.dial(target)
+	if err != nil {
+		ex.dialErr = err
+		return ex
+	}
+	defer conn.Close()
+
+	conn.SetWriteDeadline(time.Now().Add(f.config.Timeout))
+	if _, err := conn.Write(payload); err != nil {
+		ex.writeErr = err
+		return ex
+	}
+	conn.SetReadDeadline(time.Now().Add(f.config.Timeout))
+	buf := make([]byte, 4096)
+	for len(ex.response) < f.config.MaxResponseSize {
+		n, err := conn.Read(buf)
+		ex.response = append(ex.response, buf[:n]...)
+		if err != nil {
+			// the error after response is the end of response
+			if len(ex.response) == 0 {
+				ex.readErr = err
+			}
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(responseIdleTimeout))
+	}
+	return ex
+}
+
+// alive send the seed to check whether the target still works
+func (f *Fuzzer) alive(target string, respond bool) bool {
+	ex := f.exchange(target, f.seed)
+	if ex.dialErr != nil || ex.writeErr != nil {
+		return false
+	}
+	if respond {
+		return len(ex.response) > 0
+	}
+	return ex.readErr == nil || ex.timeout()
+}
+
+func (f *Fuzzer) check(target string, c *Case, respond bool) *Result {
+	start := time.Now()
+	ex := f.exchange(target, c.Payload)
+	result := &Result{Case: c, Response: ex.response, Duration: time.Since(start), Reason: ex.reason()}
+	switch {
+	case ex.dialErr != nil:
+		result.Status = StatusCrash
+		result.Reason = "connect failed, the target may be crashed by the previous case: " + result.Reason
+		return result
+	case len(ex.response) > 0:
+		result.Status = StatusOK
+		return result
+	case ex.writeErr == nil && ex.timeout():
+		if !respond {
+			result.Status = StatusOK
+			return result
+		}
+		result.Status = StatusNoResponse
+	default:
+		result.Status = StatusClosed
+	}
+	if !f.alive(target, respond) {
+		result.Status = StatusCrash
+		result.Reason = "the target doesn't respond the seed after the case: " + result.Reason
+	}
+	return result
+}
+
+// Fuzz send the cases to target one by one and detect the crash and timeout of target,
+// the seed is sent first to learn whether the target responds
+func (f *Fuzzer) Fuzz(ctx context.Context, target string) (<-chan *Result, error) {
+	baseline := f.exchange(target, f.seed)
+	if baseline.dialErr != nil || baseline.writeErr != nil {
+		return nil, utils.Errorf("send seed to %s failed: %s", target, baseline.reason())
+	}
+	respond := len(baseline.response) > 0
+
+	ch := make(chan *Result)
+	go func() {
+		defer close(ch)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		for c := range f.Generate(ctx) {
+			result := f.check(target, c, respond)
+			select {
+			case ch <- result:
+			case <-ctx.Done():
+				return
+			}
+			if result.Status == StatusCrash && f.config.StopOnCrash {
+				return
+			}
+			if f.config.Interval > 0 {
+				select {
+				case <-time.After(f.config.Interval):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
@@ -0,0 +1,526 @@
+package bin_parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/yaklang/yaklang/common/bin-parser/parser"
+	"github.com/yaklang/yaklang/common/bin-parser/parser/base"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+const (
+	// payload larger than it will not be dissected
+	maxDissectSize = 64 * 1024
+
+	maxDissectFields     = 256
+	maxDissectFieldValue = 512
+)
+
+// Dissector describe how to detect and decode an application layer protocol with bin-parser rule
+type Dissector struct {
+	Protocol string
+	// Rule is the bin-parser rule name, such as "application-layer.dns"
+	Rule string
+	// Keys return the sub node of rule to parse, nil means the whole rule
+	Keys func(payload []byte) []string
+	// Ports is the well-known ports, dissector with matched port is tried first
+	Ports []int
+	// RequirePort means the payload is dissected only when the port is matched
+	RequirePort bool
+	// Transport is "tcp" or "udp", empty means both
+	Transport string
+	// Match is a quick check before parsing
+	Match func(payload []byte) bool
+}
+
+func (d *Dissector) hitPort(ports ...int) bool {
+	for _, p := range ports {
+		for _, port := range d.Ports {
+			if p == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DissectHint is the context of payload to dissect
+type DissectHint struct {
+	// Transport is "tcp" or "udp"
+	Transport string
+	SrcPort   int
+	DstPort   int
+	// Protocol is the protocol detected before (e.g. the previous frame of the same flow), only this protocol will be tried
+	Protocol string
+}
+
+// DissectResult is the decoded message of payload
+type DissectResult struct {
+	Protocol string
+	Node     *base.Node
+	// Tree is the decoded message tree, converted by NodeToMap
+	Tree any
+}
+
+// Fields flatten the leaves of tree, the path is joined by "/", and the index of list is "#n"
+func (r *DissectResult) Fields() map[string]string {
+	fields := make(map[string]string)
+	var walk func(prefix string, d any)
+	walk = func(prefix string, d any) {
+		if len(fields) >= maxDissectFields {
+			return
+		}
+		join := func(key string) string {
+			if prefix == "" {
+				return key
+			}
+			return prefix + "/" + key
+		}
+		switch ret := d.(type) {
+		case nil:
+		case map[string]any:
+			keys := make([]string, 0, len(ret))
+			for k := range ret {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(join(k), ret[k])
+			}
+		case []any:
+			for i, v := range ret {
+				walk(join("#"+strconv.Itoa(i)), v)
+			}
+		default:
+			fields[prefix] = dissectValueToString(ret)
+		}
+	}
+	walk("", r.Tree)
+	return fields
+}
+
+func dissectValueToString(d any) string {
+	var s string
+	switch ret := d.(type) {
+	case []byte:
+		// the trailing NUL is the padding of fixed-length string (e.g. ntlm signature "NTLMSSP\x00"), keep it as text
+		text := bytes.TrimRight(ret, "\x00")
+		if utf8.Valid(text) && bytes.IndexFunc(text, func(r rune) bool { return r < 0x20 && r != '\r' && r != '\n' && r != '\t' }) < 0 {
+			s = string(ret)
+		} else {
+			s = codec.EncodeToHex(ret)
+		}
+	case string:
+		s = ret
+	default:
+		s = fmt.Sprint(ret)
+	}
+	if len(s) > maxDissectFieldValue {
+		s = s[:maxDissectFieldValue]
+	}
+	return s
+}
+
+var (
+	dissectorLock sync.RWMutex
+	dissectors    []*Dissector
+)
+
+// RegisterDissector register a dissector, the dissector registered later with the same protocol replace the former
+func RegisterDissector(d *Dissector) {
+	if d == nil || d.Protocol == "" || d.Rule == "" {
+		return
+	}
+	dissectorLock.Lock()
+	defer dissectorLock.Unlock()
+	for i, existed := range dissectors {
+		if existed.Protocol == d.Protocol {
+			dissectors[i] = d
+			return
+		}
+	}
+	dissectors = append(dissectors, d)
+}
+
+// GetDissectors return the dissectors can be tried for payload, the dissectors with matched port come first
+func GetDissectors(payload []byte, hint *DissectHint) []*Dissector {
+	if hint == nil {
+		hint = &DissectHint{}
+	}
+	dissectorLock.RLock()
+	defer dissectorLock.RUnlock()
+
+	var hit, others []*Dissector
+	for _, d := range dissectors {
+		if hint.Protocol != "" && d.Protocol != hint.Protocol {
+			continue
+		}
+		if d.Transport != "" && hint.Transport != "" && !strings.EqualFold(d.Transport, hint.Transport) {
+			continue
+		}
+		portHit := d.hitPort(hint.SrcPort, hint.DstPort)
+		if d.RequirePort && !portHit {
+			continue
+		}
+		if d.Match != nil && !d.Match(payload) {
+			continue
+		}
+		if portHit {
+			hit = append(hit, d)
+		} else {
+			others = append(others, d)
+		}
+	}
+	return append(hit, others...)
+}
+
+// Dissect detect the application layer protocol of payload and decode it with bin-parser rules
+func Dissect(payload []byte, hint *DissectHint) (*DissectResult, error) {
+	if len(payload) <= 0 {
+		return nil, utils.Error("empty payload")
+	}
+	if len(payload) > maxDissectSize {
+		return nil, utils.Errorf("payload too large: %d", len(payload))
+	}
+	for _, d := range GetDissectors(payload, hint) {
+		result, err := d.dissect(payload)
+		if err == nil {
+			return result, nil
+		}
+	}
+	return nil, utils.Error("no dissector matched")
+}
+
+func (d *Dissector) dissect(payload []byte) (result *DissectResult, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = utils.Errorf("dissect %s panic: %v", d.Protocol, e)
+		}
+	}()
+
+	var keys []string
+	if d.Keys != nil {
+		keys = d.Keys(payload)
+	}
+	node, err := parser.ParseBinary(bytes.NewReader(payload), d.Rule, keys...)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, utils.Errorf("dissect %s failed: empty node", d.Protocol)
+	}
+	tree := NodeToMap(node)
+	if tree == nil {
+		return nil, utils.Errorf("dissect %s failed: empty result", d.Protocol)
+	}
+	return &DissectResult{Protocol: d.Protocol, Node: node, Tree: tree}, nil
+}
+
+// berSequenceContent return the content of ber sequence at the beginning of payload
+func berSequenceContent(payload []byte) ([]byte, bool) {
+	if len(payload) < 2 || payload[0] != 0x30 {
+		return nil, false
+	}
+	l, offset := int(payload[1]), 2
+	if l&0x80 != 0 {
+		n := l & 0x7f
+		if n == 0 || n > 4 || len(payload) < 2+n {
+			return nil, false
+		}
+		l = 0
+		for _, b := range payload[2 : 2+n] {
+			l = l<<8 | int(b)
+		}
+		offset += n
+	}
+	if offset+l > len(payload) || l <= 0 {
+		return nil, false
+	}
+	return payload[offset : offset+l], true
+}
+
+// lengthPrefixedMessages check the payload is a sequence of complete messages,
+// size return the whole size of the message at the beginning of data, false if the header is invalid
+func lengthPrefixedMessages(payload []byte, headerSize int, size func(header []byte) (int, bool)) bool {
+	if len(payload) < headerSize {
+		return false
+	}
+	for len(payload) > 0 {
+		if len(payload) < headerSize {
+			return false
+		}
+		n, ok := size(payload[:headerSize])
+		if !ok || n < headerSize || n > len(payload) {
+			return false
+		}
+		payload = payload[n:]
+	}
+	return true
+}
+
+func mysqlPacketSize(header []byte) (int, bool) {
+	return 4 + (int(header[0]) | int(header[1])<<8 | int(header[2])<<16), true
+}
+
+func mysqlKeys(payload []byte) []string {
+	switch seq := payload[3]; {
+	case seq == 0 && payload[4] == 0x0a:
+		return []string{"HandshakeV10"}
+	case seq == 1 && len(payload) >= 36 && binary.LittleEndian.Uint32(payload[4:8])&0x200 != 0: // CLIENT_PROTOCOL_41
+		return []string{"HandshakeResponse41"}
+	case seq == 0 && int(payload[0])|int(payload[1])<<8|int(payload[2])<<16 == len(payload)-4:
+		return []string{"Command"}
+	}
+	return []string{"Packets"}
+}
+
+const (
+	postgresProtocolVersion3 = 196608
+	postgresSSLRequestCode   = 80877103
+)
+
+func postgresqlKeys(payload []byte) []string {
+	if len(payload) >= 8 && int(binary.BigEndian.Uint32(payload[:4])) == len(payload) {
+		switch binary.BigEndian.Uint32(payload[4:8]) {
+		case postgresProtocolVersion3:
+			return []string{"StartupMessage"}
+		case postgresSSLRequestCode:
+			return []string{"SSLRequest"}
+		}
+	}
+	return []string{"Messages"}
+}
+
+// mqttPackets check the payload is a sequence of complete mqtt control packets
+func mqttPackets(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+	for len(payload) > 0 {
+		if typ := payload[0] >> 4; typ == 0 || typ == 15 {
+			return false
+		}
+		length, offset, multiplier := 0, 1, 1
+		for {
+			if offset >= len(payload) || offset > 4 {
+				return false
+			}
+			b := payload[offset]
+			offset++
+			length += int(b&0x7f) * multiplier
+			multiplier *= 128
+			if b&0x80 == 0 {
+				break
+			}
+		}
+		if offset+length > len(payload) {
+			return false
+		}
+		payload = payload[offset+length:]
+	}
+	return true
+}
+
+// respMessage check the payload starts with a resp type and a complete line
+func respMessage(payload []byte) bool {
+	if len(payload) < 3 || !strings.ContainsRune("+-:$*", rune(payload[0])) {
+		return false
+	}
+	end := bytes.Index(payload, []byte("\r\n"))
+	if end < 0 || !bytes.HasSuffix(payload, []byte("\r\n")) {
+		return false
+	}
+	switch payload[0] {
+	case ':', '$', '*':
+		_, err := strconv.Atoi(string(payload[1:end]))
+		return err == nil
+	}
+	return true
+}
+
+// kerberos messages are the application tags of ber: AS-REQ(10), AS-REP(11), TGS-REQ(12), TGS-REP(13), AP-REQ(14), AP-REP(15), KRB-ERROR(30)
+func isKerberosTag(b byte) bool {
+	return (b >= 0x6a && b <= 0x6f) || b == 0x7e
+}
+
+func kerberosKeys(payload []byte) []string {
+	if len(payload) > 4 && int(binary.BigEndian.Uint32(payload[:4])) == len(payload)-4 {
+		return []string{"TCP"}
+	}
+	return []string{"UDP"}
+}
+
+var ntlmMessages = map[uint32]string{
+	1: "NegotiateMessage",
+	2: "ChallengeMessage",
+	3: "AuthenticationMessage",
+}
+
+func init() {
+	RegisterDissector(&Dissector{
+		Protocol:    "dns",
+		Rule:        "application-layer.dns",
+		Ports:       []int{53, 5353, 5355},
+		RequirePort: true,
+		Transport:   "udp",
+		Match: func(payload []byte) bool {
+			if len(payload) < 12 {
+				return false
+			}
+			opcode := (payload[2] >> 3) & 0x0f
+			questions := binary.BigEndian.Uint16(payload[4:6])
+			return opcode <= 6 && questions <= 32
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:  "tls",
+		Rule:      "application-layer.tls",
+		Ports:     []int{443, 465, 636, 853, 993, 995, 8443},
+		Transport: "tcp",
+		Match: func(payload []byte) bool {
+			return len(payload) >= 5 && payload[0] >= 0x14 && payload[0] <= 0x18 && payload[1] == 0x03 && payload[2] <= 0x04
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:  "ldap",
+		Rule:      "application-layer.ldap",
+		Ports:     []int{389, 3268},
+		Transport: "tcp",
+		Match: func(payload []byte) bool {
+			// LDAPMessage ::= SEQUENCE { messageID INTEGER, protocolOp ... }
+			content, ok := berSequenceContent(payload)
+			return ok && len(content) > 2 && content[0] == 0x02
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol: "ntlm",
+		Rule:     "application-layer.ntlm",
+		Keys: func(payload []byte) []string {
+			return []string{ntlmMessages[binary.LittleEndian.Uint32(payload[8:12])]}
+		},
+		Match: func(payload []byte) bool {
+			if len(payload) < 12 || !bytes.HasPrefix(payload, []byte("NTLMSSP\x00")) {
+				return false
+			}
+			_, ok := ntlmMessages[binary.LittleEndian.Uint32(payload[8:12])]
+			return ok
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:  "t3",
+		Rule:      "application-layer.t3",
+		Ports:     []int{7001, 7002},
+		Transport: "tcp",
+		Match: func(payload []byte) bool {
+			// the total length contains itself
+			return len(payload) > 24 && int(binary.BigEndian.Uint32(payload[:4])) == len(payload)
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:    "mysql",
+		Rule:        "application-layer.mysql",
+		Keys:        mysqlKeys,
+		Ports:       []int{3306},
+		RequirePort: true,
+		Transport:   "tcp",
+		Match: func(payload []byte) bool {
+			return len(payload) >= 5 && lengthPrefixedMessages(payload, 4, mysqlPacketSize)
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:    "postgresql",
+		Rule:        "application-layer.postgresql",
+		Keys:        postgresqlKeys,
+		Ports:       []int{5432},
+		RequirePort: true,
+		Transport:   "tcp",
+		Match: func(payload []byte) bool {
+			if postgresqlKeys(payload)[0] != "Messages" {
+				return true
+			}
+			// the type of message is an ascii letter, the length contains itself
+			return lengthPrefixedMessages(payload, 5, func(header []byte) (int, bool) {
+				typ := header[0]
+				return 1 + int(binary.BigEndian.Uint32(header[1:5])), (typ >= 'A' && typ <= 'Z') || (typ >= 'a' && typ <= 'z')
+			})
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:    "redis",
+		Rule:        "application-layer.redis",
+		Keys:        func([]byte) []string { return []string{"Messages"} },
+		Ports:       []int{6379},
+		RequirePort: true,
+		Transport:   "tcp",
+		Match:       respMessage,
+	})
+	RegisterDissector(&Dissector{
+		Protocol:    "mqtt",
+		Rule:        "application-layer.mqtt",
+		Keys:        func([]byte) []string { return []string{"Packets"} },
+		Ports:       []int{1883},
+		RequirePort: true,
+		Transport:   "tcp",
+		Match:       mqttPackets,
+	})
+	RegisterDissector(&Dissector{
+		Protocol:    "modbus",
+		Rule:        "application-layer.modbus",
+		Keys:        func([]byte) []string { return []string{"Messages"} },
+		Ports:       []int{502},
+		RequirePort: true,
+		Transport:   "tcp",
+		Match: func(payload []byte) bool {
+			// MBAP header: transaction id, protocol id (always 0), length of unit id and pdu
+			return lengthPrefixedMessages(payload, 8, func(header []byte) (int, bool) {
+				return 6 + int(binary.BigEndian.Uint16(header[4:6])), binary.BigEndian.Uint16(header[2:4]) == 0
+			})
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:  "smb2",
+		Rule:      "application-layer.smb2",
+		Keys:      func([]byte) []string { return []string{"Messages"} },
+		Ports:     []int{445},
+		Transport: "tcp",
+		Match: func(payload []byte) bool {
+			// netbios session message with the protocol id of smb2
+			if len(payload) < 8 || !bytes.Equal(payload[4:8], []byte("\xfeSMB")) {
+				return false
+			}
+			return lengthPrefixedMessages(payload, 4, func(header []byte) (int, bool) {
+				return 4 + (int(header[1])<<16 | int(header[2])<<8 | int(header[3])), header[0] == 0
+			})
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:    "kerberos",
+		Rule:        "application-layer.kerberos",
+		Keys:        kerberosKeys,
+		Ports:       []int{88},
+		RequirePort: true,
+		Match: func(payload []byte) bool {
+			if kerberosKeys(payload)[0] == "TCP" {
+				return isKerberosTag(payload[4])
+			}
+			return len(payload) > 2 && isKerberosTag(payload[0])
+		},
+	})
+	RegisterDissector(&Dissector{
+		Protocol:  "iiop",
+		Rule:      "application-layer.iiop",
+		Ports:     []int{683, 2809, 7001},
+		Transport: "tcp",
+		Match: func(payload []byte) bool {
+			return len(payload) >= 12 && bytes.HasPrefix(payload, []byte("GIOP"))
+		},
+	})
+}
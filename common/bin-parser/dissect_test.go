@@ -0,0 +1,112 @@
+package bin_parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+func TestDissect(t *testing.T) {
+	// query www.example.com A
+	dnsQuery, _ := codec.DecodeHex("abcd0100000100000000000003777777076578616d706c6503636f6d0000010001")
+	ntlmNegotiate, _ := codec.DecodeHex("4e544c4d535350000100000035820860000000000000000000000000000000000000000000000000")
+	iiopReply, _ := codec.DecodeHex("47494f5001020001000000580000000200000002000000010000000f000000180000000100000000000000000000000101000000000000000000001e49444c3a6f6d672e6f72672f434f5242412f4d41525348414c3a312e300000000000000000000001")
+
+	for _, c := range []struct {
+		payload  []byte
+		hint     *DissectHint
+		protocol string
+		value    string
+	}{
+		{dnsQuery, &DissectHint{Transport: "udp", SrcPort: 40000, DstPort: 53}, "dns", "example"},
+		{ntlmNegotiate, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 445}, "ntlm", "NTLMSSP\x00"},
+		{iiopReply, &DissectHint{Transport: "tcp", SrcPort: 7001, DstPort: 40000}, "iiop", "GIOP"},
+	} {
+		result, err := Dissect(c.payload, c.hint)
+		if err != nil {
+			t.Fatalf("dissect %s failed: %v", c.protocol, err)
+		}
+		assert.Equal(t, c.protocol, result.Protocol)
+
+		var found bool
+		for _, v := range result.Fields() {
+			if v == c.value {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s field %q not found: %v", c.protocol, c.value, result.Fields())
+		}
+	}
+
+	// dns is only dissected on the well-known ports
+	if _, err := Dissect(dnsQuery, &DissectHint{Transport: "udp", SrcPort: 40000, DstPort: 40001}); err == nil {
+		t.Fatal("dns should not be dissected without port matched")
+	}
+	// the protocol of flow is fixed
+	if _, err := Dissect(ntlmNegotiate, &DissectHint{Protocol: "tls"}); err == nil {
+		t.Fatal("ntlm should not be dissected when the protocol is tls")
+	}
+}
+
+func TestDissect_ApplicationLayer(t *testing.T) {
+	decode := func(s string) []byte {
+		raw, err := codec.DecodeHex(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	mysqlGreeting := decode("4e0000000a352e372e32392d6c6f67000b0000003b2f45166b477a3d00ffff210200ffc115000000000000000000001a0c6279556e14345f336305006d7973716c5f6e61746976655f70617373776f726400")
+	mysqlQuery := decode("210000000373656c65637420404076657273696f6e5f636f6d6d656e74206c696d69742031")
+	postgresStartup := decode("0000003f000300007573657200706f73746772657300646174616261736500706f737467726573006170706c69636174696f6e5f6e616d65007073716c0000")
+	postgresMessages := decode("52000000080000000053000000187365727665725f76657273696f6e0031342e35005a0000000549510000000e73656c65637420313b00")
+	redisSet := decode("2a330d0a24330d0a5345540d0a24330d0a6b65790d0a24350d0a76616c75650d0a")
+	mqttConnect := decode("101b00044d5154540502001e05110000000a000976352d636c69656e74300b0003612f620068656c6c6f")
+	modbus := decode("0001000000060103006b0003")
+	smb2SessionSetup := decode("0000005cfe534d4240000000000000000100010000000000000000000100000000000000fffe00000000000000000000000000000000000000000000000000000000000019000001010000000000000058000400000000000000000054455354")
+	asReq := "6a81b73081b4a103020105a20302010aa31530133011a10402020080a20904073005a0030101ffa4819030818da00703050040810010a11a3018a003020101a111300f1b0d61646d696e6973747261746f72a20b1b0959414b2e4c4f43414ca31e301ca003020102a11530131b066b72627467741b0959414b2e4c4f43414ca511180f32303337303931333032343830355aa611180f32303337303931333032343830355aa7060204732e7ee7a80b3009020112020111020117"
+
+	for _, c := range []struct {
+		payload  []byte
+		hint     *DissectHint
+		protocol string
+		value    string
+	}{
+		{mysqlGreeting, &DissectHint{Transport: "tcp", SrcPort: 3306, DstPort: 40000}, "mysql", "5.7.29-log"},
+		{mysqlQuery, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 3306}, "mysql", "select @@version_comment limit 1"},
+		{postgresStartup, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 5432}, "postgresql", "application_name"},
+		{postgresMessages, &DissectHint{Transport: "tcp", SrcPort: 5432, DstPort: 40000}, "postgresql", "Q"},
+		{redisSet, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 6379}, "redis", "SET"},
+		{mqttConnect, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 1883}, "mqtt", "v5-client"},
+		{modbus, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 502}, "modbus", "006b0003"},
+		// smb2 is detected by its protocol id on any port
+		{smb2SessionSetup, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 40001}, "smb2", "92"},
+		{decode(asReq), &DissectHint{Transport: "udp", SrcPort: 40000, DstPort: 88}, "kerberos", "administrator"},
+		{decode("000000ba" + asReq), &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 88}, "kerberos", "YAK.LOCAL"},
+	} {
+		result, err := Dissect(c.payload, c.hint)
+		if err != nil {
+			t.Fatalf("dissect %s failed: %v", c.protocol, err)
+		}
+		assert.Equal(t, c.protocol, result.Protocol)
+
+		var found bool
+		for _, v := range result.Fields() {
+			if v == c.value {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("%s field %q not found: %v", c.protocol, c.value, result.Fields())
+		}
+	}
+
+	// the protocols with weak heuristics are only dissected on their well-known ports
+	for _, payload := range [][]byte{mysqlQuery, redisSet, mqttConnect, modbus} {
+		if result, err := Dissect(payload, &DissectHint{Transport: "tcp", SrcPort: 40000, DstPort: 40001}); err == nil {
+			t.Fatalf("%s should not be dissected without port matched", result.Protocol)
+		}
+	}
+}
@@ -586,7 +586,7 @@ func TestTLSSession(t *testing.T) {
 	assert.Equal(t, string(dict1Bytes), string(dict2Bytes))
 }
 func TestReassembled(t *testing.T) {
-	host, port := utils.DebugMockHTTPServerWithContextWithAddress(context.Background(), "127.0.0.1:9099", true, false, false, false, func(i []byte) []byte {
+	host, port := utils.DebugMockHTTPServerWithContextWithAddress(context.Background(), "127.0.0.1:9099", true, false, false, false, false, func(i []byte) []byte {
 		return []byte("HTTP/1.1 200 OK\r\n\r\nHello, world!")
 	})
 	payload := []byte{}
@@ -636,8 +636,8 @@ func TestNegotiateMessage(t *testing.T) {
 	DumpNode(res)
 	mapData := map[string]any{
 		"Signature":         "NTLMSSP\x00",
-		"MessageType":       16777216,
-		"NegotiateFlags":    897714272,
+		"MessageType":       1,
+		"NegotiateFlags":    1611170357,
 		"DomainNameFields":  "\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000",
 		"WorkstationFields": "\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000",
 	}
@@ -649,6 +649,70 @@ func TestNegotiateMessage(t *testing.T) {
 	assert.Equal(t, "4e544c4d53535000010000003582086000000000000000000000000000000000", codec.EncodeToHex(NodeToBytes(res)))
 }
 
+var berElementExpect = `Type:
+  Class: 0
+  Constructed: 1
+  Tag: 16
+Length: 13
+Children:
+  Value:
+    Type:
+      Class: 0
+      Constructed: 0
+      Tag: 2
+    Length: 1
+    Integer: 1
+  Value:
+    Type:
+      Class: 0
+      Constructed: 1
+      Tag: 16
+    Length: 4
+    Children:
+      Value:
+        Type:
+          Class: 0
+          Constructed: 0
+          Tag: 4
+        Length: 2
+        Value: "6162"
+  Value:
+    Type:
+      Class: 0
+      Constructed: 0
+      Tag: 4
+    Length: 2
+    Value: "6364"
+`
+
+func TestBERElement(t *testing.T) {
+	// SEQUENCE { INTEGER 1, SEQUENCE { OCTET STRING "ab" }, OCTET STRING "cd" }, the element after the nested sequence should stay in the outer list
+	data := "300d02010130040402616204026364"
+	payload, err := codec.DecodeHex(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := parser.ParseBinary(bytes.NewReader(payload), "application-layer.ber", "BER Element")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resMap, err := res.Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resYaml, err := DumpNodeValueYaml(resMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, berElementExpect, resYaml)
+
+	res, err = parser.GenerateBinary(NodeToMap(res), "application-layer.ber", "BER Element")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, data, codec.EncodeToHex(NodeToBytes(res)))
+}
+
 func TestNTLM(t *testing.T) {
 	//data := `TlRMTVNTUAABAAAAB4IIAAAAAAAgAAAAAAAAACAAAAA=`
 	//payload, err := codec.DecodeBase64(data)
@@ -129,6 +129,10 @@ func (d *DefParser) Operate(operator *Operator, node *base.Node) error {
 			return fmt.Errorf("new node by type error: %w", err)
 		}
 		*node = *typeNode
+		// the children still point to the type node, relink them to get the right path and remaining length
+		for _, child := range node.Children {
+			child.Cfg.SetItem(CfgParent, node)
+		}
 		return d.Operate(operator, node)
 	}
 	if node.Name == "root" {
@@ -184,6 +188,9 @@ func (d *DefParser) Operate(operator *Operator, node *base.Node) error {
 		//rootNode.Cfg.SetItem(CfgNodeResult, nodeResult)
 		*node = *rootNode
 		node.Name = name
+		for _, child := range node.Children {
+			child.Cfg.SetItem(CfgParent, node)
+		}
 		//InitNode(node)
 		//node.Cfg.SetItem("unpack", true)
 		return operator.NodeParse(node)
@@ -225,6 +232,12 @@ func (d *DefParser) Operate(operator *Operator, node *base.Node) error {
 				return err
 			}
 		}
+		// the context is shared with the nested list, restore the state of outer list when finished
+		if node.Ctx.Has(CfgInList) {
+			defer node.Ctx.SetItem(CfgInList, node.Ctx.GetItem(CfgInList))
+		} else {
+			defer node.Ctx.DeleteItem(CfgInList)
+		}
 		node.Ctx.SetItem(CfgInList, true)
 		if len(node.Children) == 0 {
 			return errors.New("get node element type error")
@@ -301,7 +314,6 @@ func (d *DefParser) Operate(operator *Operator, node *base.Node) error {
 			return fmt.Errorf("parse list node error: %w", err)
 		}
 		operator.PopBackup()
-		node.Ctx.DeleteItem(CfgInList)
 		return nil
 	}
 	if node.Cfg.GetBool(CfgIsTerminal) {
@@ -359,6 +371,10 @@ func (d *DefParser) Generate(data any, node *base.Node) error {
 				return false, nil
 			}
 			data, ok := getSubData(rootData, GetNodePath(node))
+			if !ok && node.Cfg.GetString(CfgExceptionPlan) == "skip" {
+				// the optional node is not given, nothing to generate
+				return true, nil
+			}
 			if ok {
 				switch ret := data.(type) {
 				case []byte, string:
@@ -419,6 +435,9 @@ func (d *DefParser) Generate(data any, node *base.Node) error {
 						typeName = "raw"
 					}
 					buf := ConvertToBytes(data, length)
+					if IsNumber(data) && length%8 == 0 && node.Cfg.GetString(CfgEndian) == "little" {
+						buf = reverseBytes(buf)
+					}
 					rawRes, err := d.write(buf, length)
 					if err != nil {
 						return fmt.Errorf("write error: %w", err)
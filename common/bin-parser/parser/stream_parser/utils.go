@@ -295,6 +295,13 @@ func ConvertToBytes(v any, length uint64) []byte {
 		return []byte{}
 	}
 }
+func reverseBytes(v []byte) []byte {
+	res := make([]byte, len(v))
+	for i, b := range v {
+		res[len(v)-1-i] = b
+	}
+	return res
+}
 func appendNode(parent *base.Node, child *base.Node) error {
 	err := parent.AppendNode(child)
 	if err != nil {
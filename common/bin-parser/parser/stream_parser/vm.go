@@ -5,11 +5,14 @@ import (
 	"context"
 	"fmt"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/golang/groupcache/lru"
 	"github.com/yaklang/yaklang/common/bin-parser/parser/base"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yak/antlr4yak"
+	"github.com/yaklang/yaklang/common/yak/antlr4yak/yakvm"
 	"reflect"
+	"sync"
 )
 
 type YakNode struct {
@@ -417,9 +420,7 @@ func ExecOperator(node *base.Node, code string, operator func(node *base.Node) (
 	}
 	engine := antlr4yak.New()
 	engine.ImportLibs(engineLib)
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	return engine.SafeEval(ctx, code)
+	return evalCode(engine, code, false)
 }
 func getMulti(node *base.Node, uints ...string) uint64 {
 	var uint string
@@ -468,7 +469,7 @@ func ExecParser(node *base.Node) (res any, err error) {
 	}
 	engine := antlr4yak.New()
 	engine.ImportLibs(engineLib)
-	returnV, err := engine.ExecuteAsExpression(code, nil)
+	returnV, err := evalExpression(engine, code)
 	if err != nil {
 		return nil, err
 	}
@@ -497,14 +498,26 @@ func ExecOut(node *base.Node) (res *base.NodeValue, err error) {
 		"len": func(i interface{}) int {
 			return reflect.ValueOf(i).Len()
 		},
-		"data":           res,
-		"newStructValue": newStructNodeValue,
-		"newListValue":   newListNodeValue,
-		"newValue":       newNodeValue,
+		"data": res,
+		"newStructValue": func(name string, children ...*base.NodeValue) *base.NodeValue {
+			v := newStructNodeValue(node, children...)
+			v.Name = name
+			return v
+		},
+		"newListValue": func(name string, children ...*base.NodeValue) *base.NodeValue {
+			v := newListNodeValue(node, children...)
+			v.Name = name
+			return v
+		},
+		"newValue": func(name string, d any) *base.NodeValue {
+			v := newNodeValue(node, d)
+			v.Name = name
+			return v
+		},
 	}
 	engine := antlr4yak.New()
 	engine.ImportLibs(engineLib)
-	returnV, err := engine.ExecuteAsExpression(code, nil)
+	returnV, err := evalExpression(engine, code)
 	if err != nil {
 		return nil, err
 	}
@@ -535,9 +548,74 @@ func ExecInput(node *base.Node) (res any, err error) {
 	}
 	engine := antlr4yak.New()
 	engine.ImportLibs(engineLib)
-	res, err = engine.ExecuteAsExpression(code, nil)
+	res, err = evalExpression(engine, code)
 	if err != nil {
 		return nil, err
 	}
 	return res, nil
 }
+
+// compiledCodeCacheMaxEntries is the max count of compiled codes cached, the rules loaded are limited but the codes may be generated
+const compiledCodeCacheMaxEntries = 512
+
+var (
+	// compiledCodes cache the yakc of the operator / out / input code, the same code is executed for every node parsed by the rule
+	compiledCodes     = lru.New(compiledCodeCacheMaxEntries)
+	compiledCodesLock sync.Mutex
+)
+
+func compileCode(engine *antlr4yak.Engine, code string) ([]byte, error) {
+	compiledCodesLock.Lock()
+	yakc, ok := compiledCodes.Get(code)
+	compiledCodesLock.Unlock()
+	if ok {
+		return yakc.([]byte), nil
+	}
+	raw, err := engine.Marshal(code, nil)
+	if err != nil {
+		return nil, utils.Errorf("compile error: \n%s", err)
+	}
+	compiledCodesLock.Lock()
+	compiledCodes.Add(code, raw)
+	compiledCodesLock.Unlock()
+	return raw, nil
+}
+
+func evalCode(engine *antlr4yak.Engine, code string, inline bool) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = utils.Error(fmt.Sprint(e))
+		}
+	}()
+	yakc, err := compileCode(engine, code)
+	if err != nil {
+		return err
+	}
+	symbolTable, codes, err := engine.UnMarshal(yakc, nil, code)
+	if err != nil {
+		return err
+	}
+	flag := yakvm.None
+	if inline {
+		flag = yakvm.Inline
+	}
+	vm := engine.GetVM()
+	vm.SetSymboltable(symbolTable)
+	return vm.ExecYakCode(context.Background(), code, codes, flag)
+}
+
+// evalExpression eval the code and return the last value, like ExecuteAsExpression of engine
+func evalExpression(engine *antlr4yak.Engine, code string) (any, error) {
+	err := evalCode(engine, code, true)
+	if err != nil {
+		return nil, err
+	}
+	val, err := engine.GetLastStackValue()
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		return nil, nil
+	}
+	return val.Value, nil
+}
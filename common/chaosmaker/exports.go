@@ -53,6 +53,15 @@ func LoadSuricataToDatabase(raw string) error {
 	return nil
 }
 
+// RuleSelfTest run the self test for the suricata rules in database filtered by keywords
+func RuleSelfTest(keywords string, protos ...string) *SelfTestReport {
+	maker := NewChaosMaker()
+	for r := range YieldSuricataRulesByKeywords(keywords, protos...) {
+		maker.FeedRule(r)
+	}
+	return maker.SelfTest()
+}
+
 var (
 	ChaosMakerExports = map[string]any{
 		"SuricataMatcher":              match.New,
@@ -62,6 +71,14 @@ var (
 		"YieldSuricataRulesByKeywords": YieldRulesByKeywords,
 		"LoadSuricataToDatabase":       LoadSuricataToDatabase,
 		"TrafficGenerator":             NewChaosMaker,
+		"RuleSelfTest":                 RuleSelfTest,
+
+		// options for ChaosMaker.ExportPcapng
+		"pcapngClient":    WithPcapngClient,
+		"pcapngServer":    WithPcapngServer,
+		"pcapngStartTime": WithPcapngStartTime,
+		"pcapngInterval":  WithPcapngInterval,
+		"pcapngMSS":       WithPcapngMSS,
 	}
 )
 
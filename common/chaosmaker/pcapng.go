@@ -0,0 +1,322 @@
+package chaosmaker
+
+import (
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/yaklang/yaklang/common/chaosmaker/rule"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx"
+	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type pcapngConfig struct {
+	client   net.IP
+	server   net.IP
+	start    time.Time
+	interval time.Duration
+	mss      int
+}
+
+type PcapngOption func(*pcapngConfig)
+
+// WithPcapngClient rewrite the client address of generated traffic
+func WithPcapngClient(ip string) PcapngOption {
+	return func(c *pcapngConfig) {
+		c.client = net.ParseIP(utils.FixForParseIP(ip))
+	}
+}
+
+// WithPcapngServer rewrite the server address of generated traffic
+func WithPcapngServer(ip string) PcapngOption {
+	return func(c *pcapngConfig) {
+		c.server = net.ParseIP(utils.FixForParseIP(ip))
+	}
+}
+
+// WithPcapngStartTime set the timestamp of the first packet
+func WithPcapngStartTime(t time.Time) PcapngOption {
+	return func(c *pcapngConfig) {
+		c.start = t
+	}
+}
+
+// WithPcapngInterval set the interval (seconds) between packets
+func WithPcapngInterval(seconds float64) PcapngOption {
+	return func(c *pcapngConfig) {
+		c.interval = utils.FloatSecondDuration(seconds)
+	}
+}
+
+// WithPcapngMSS set the max payload size of tcp packets
+func WithPcapngMSS(mss int) PcapngOption {
+	return func(c *pcapngConfig) {
+		c.mss = mss
+	}
+}
+
+// pendingSession collect the payloads of a generated tcp flow
+type pendingSession struct {
+	clientMAC, serverMAC   net.HardwareAddr
+	clientIP, serverIP     net.IP
+	clientPort, serverPort int
+	session                *pcapx.TCPSession
+}
+
+type pcapngExporter struct {
+	config   *pcapngConfig
+	writer   *pcapgo.NgWriter
+	count    int
+	sessions map[string]*pendingSession
+	order    []string
+	flows    map[*rule.Storage]*surirule.FlowRule
+}
+
+func (e *pcapngExporter) write(raw []byte) error {
+	ts := e.config.start.Add(time.Duration(e.count) * e.config.interval)
+	err := e.writer.WritePacket(gopacket.CaptureInfo{
+		Timestamp:     ts,
+		CaptureLength: len(raw),
+		Length:        len(raw),
+	}, raw)
+	if err != nil {
+		return utils.Errorf("write pcapng packet failed: %s", err)
+	}
+	e.count++
+	return nil
+}
+
+// senderIsServer check the direction of generated packet by the flow option of rule
+func (e *pcapngExporter) senderIsServer(r *rule.Storage) bool {
+	flow, ok := e.flows[r]
+	if !ok {
+		if rules, err := surirule.Parse(r.SuricataRaw); err == nil && len(rules) > 0 && rules[0].ContentRuleConfig != nil {
+			flow = rules[0].ContentRuleConfig.Flow
+		}
+		e.flows[r] = flow
+	}
+	return flow != nil && flow.ToClient
+}
+
+func (e *pcapngExporter) address(ip net.IP, isServer bool) net.IP {
+	if isServer && e.config.server != nil {
+		return e.config.server
+	}
+	if !isServer && e.config.client != nil {
+		return e.config.client
+	}
+	return ip
+}
+
+func (e *pcapngExporter) flush(key string) error {
+	pending, ok := e.sessions[key]
+	if !ok {
+		return nil
+	}
+	delete(e.sessions, key)
+	for i, k := range e.order {
+		if k == key {
+			e.order = append(e.order[:i], e.order[i+1:]...)
+			break
+		}
+	}
+	if len(pending.session.Segments) <= 0 {
+		return nil
+	}
+
+	s := pending.session
+	s.ClientMAC, s.ServerMAC = pending.clientMAC, pending.serverMAC
+	s.ClientIP, s.ServerIP = e.address(pending.clientIP, false), e.address(pending.serverIP, true)
+	s.ClientPort, s.ServerPort = pending.clientPort, pending.serverPort
+	s.MSS = e.config.mss
+	flow, err := s.Packets()
+	if err != nil {
+		return err
+	}
+	for _, raw := range flow {
+		if err := e.write(raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *pcapngExporter) flushAll() error {
+	for len(e.order) > 0 {
+		if err := e.flush(e.order[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *pcapngExporter) feedTCP(r *rule.Storage, eth *layers.Ethernet, src, dst net.IP, tcp *layers.TCP) error {
+	srcAddr := utils.HostPort(src.String(), int(tcp.SrcPort))
+	dstAddr := utils.HostPort(dst.String(), int(tcp.DstPort))
+	key := srcAddr + "-" + dstAddr
+	if srcAddr > dstAddr {
+		key = dstAddr + "-" + srcAddr
+	}
+
+	// the handshake and fin generated are dropped, the session is rebuilt when flushing
+	if (tcp.SYN && !tcp.ACK) || tcp.FIN || tcp.RST {
+		if err := e.flush(key); err != nil {
+			return err
+		}
+		if !tcp.SYN {
+			return nil
+		}
+	}
+
+	pending, ok := e.sessions[key]
+	if !ok {
+		if len(tcp.Payload) <= 0 && !tcp.SYN {
+			return nil
+		}
+		pending = &pendingSession{
+			clientMAC: eth.SrcMAC, serverMAC: eth.DstMAC,
+			clientIP: src, serverIP: dst,
+			clientPort: int(tcp.SrcPort), serverPort: int(tcp.DstPort),
+			session: &pcapx.TCPSession{},
+		}
+		if e.senderIsServer(r) {
+			pending.clientMAC, pending.serverMAC = pending.serverMAC, pending.clientMAC
+			pending.clientIP, pending.serverIP = pending.serverIP, pending.clientIP
+			pending.clientPort, pending.serverPort = pending.serverPort, pending.clientPort
+		}
+		e.sessions[key] = pending
+		e.order = append(e.order, key)
+	}
+	toServer := int(tcp.SrcPort) == pending.clientPort && src.Equal(pending.clientIP)
+	pending.session.AddSegment(toServer, tcp.Payload)
+	return nil
+}
+
+// rewrite the addresses of non-tcp packets, the packet is kept if it cannot be rewritten
+func (e *pcapngExporter) rewrite(r *rule.Storage, pk gopacket.Packet, raw []byte) []byte {
+	if e.config.client == nil && e.config.server == nil {
+		return raw
+	}
+	eth, ok := pk.LinkLayer().(*layers.Ethernet)
+	if !ok || pk.NetworkLayer() == nil {
+		return raw
+	}
+	src, dst := net.IP(pk.NetworkLayer().NetworkFlow().Src().Raw()), net.IP(pk.NetworkLayer().NetworkFlow().Dst().Raw())
+	senderIsServer := e.senderIsServer(r)
+	src, dst = e.address(src, senderIsServer), e.address(dst, !senderIsServer)
+	isV4 := src.To4() != nil
+	if isV4 != (dst.To4() != nil) {
+		log.Warnf("client and server are not in the same family, keep the origin address")
+		return raw
+	}
+
+	var (
+		transport gopacket.SerializableLayer
+		payload   []byte
+		proto     layers.IPProtocol
+	)
+	switch ret := pk.TransportLayer().(type) {
+	case *layers.UDP:
+		transport, payload, proto = ret, ret.LayerPayload(), layers.IPProtocolUDP
+	default:
+		icmp, ok := pk.Layer(layers.LayerTypeICMPv4).(*layers.ICMPv4)
+		if !ok || !isV4 {
+			return raw
+		}
+		transport, payload, proto = icmp, icmp.LayerPayload(), layers.IPProtocolICMPv4
+	}
+
+	var network gopacket.NetworkLayer
+	if isV4 {
+		eth.EthernetType = layers.EthernetTypeIPv4
+		network = &layers.IPv4{Version: 4, TTL: 64, Protocol: proto, SrcIP: src.To4(), DstIP: dst.To4()}
+	} else {
+		eth.EthernetType = layers.EthernetTypeIPv6
+		network = &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: proto, SrcIP: src.To16(), DstIP: dst.To16()}
+	}
+	if udp, ok := transport.(*layers.UDP); ok {
+		_ = udp.SetNetworkLayerForChecksum(network)
+	}
+	buf := gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true},
+		eth, network.(gopacket.SerializableLayer), transport, gopacket.Payload(payload))
+	if err != nil {
+		log.Warnf("rewrite packet address failed: %s", err)
+		return raw
+	}
+	return buf.Bytes()
+}
+
+func (e *pcapngExporter) feed(t *TrafficRule) error {
+	pk := gopacket.NewPacket(t.Raw, layers.LayerTypeEthernet, gopacket.Default)
+	eth, _ := pk.LinkLayer().(*layers.Ethernet)
+	if tcp, ok := pk.TransportLayer().(*layers.TCP); ok && eth != nil && pk.NetworkLayer() != nil {
+		flow := pk.NetworkLayer().NetworkFlow()
+		return e.feedTCP(t.Rule, eth, net.IP(flow.Src().Raw()), net.IP(flow.Dst().Raw()), tcp)
+	}
+	return e.write(e.rewrite(t.Rule, pk, t.Raw))
+}
+
+// ExportPcapng write the generated traffic to w as pcapng, the tcp payloads are wrapped in complete sessions.
+// the count of written packets is returned.
+func (c *ChaosMaker) ExportPcapng(w io.Writer, opts ...PcapngOption) (int, error) {
+	config := &pcapngConfig{
+		start:    time.Now(),
+		interval: time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.client != nil && config.server != nil && (config.client.To4() != nil) != (config.server.To4() != nil) {
+		return 0, utils.Errorf("client %v and server %v are not in the same family", config.client, config.server)
+	}
+
+	writer, err := pcapgo.NewNgWriter(w, layers.LinkTypeEthernet)
+	if err != nil {
+		return 0, utils.Errorf("create pcapng writer failed: %s", err)
+	}
+	e := &pcapngExporter{
+		config:   config,
+		writer:   writer,
+		sessions: make(map[string]*pendingSession),
+		flows:    make(map[*rule.Storage]*surirule.FlowRule),
+	}
+
+	var last *rule.Storage
+	for t := range c.GenerateWithRule() {
+		if err != nil {
+			// drain the generator
+			continue
+		}
+		if last != nil && last != t.Rule {
+			err = e.flushAll()
+		}
+		last = t.Rule
+		if err == nil {
+			err = e.feed(t)
+		}
+	}
+	if err == nil {
+		err = e.flushAll()
+	}
+	if flushErr := writer.Flush(); err == nil && flushErr != nil {
+		err = utils.Errorf("flush pcapng failed: %s", flushErr)
+	}
+	return e.count, err
+}
+
+// ExportPcapngFile is like ExportPcapng, but write to file
+func (c *ChaosMaker) ExportPcapngFile(path string, opts ...PcapngOption) (int, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, utils.Errorf("create pcapng file failed: %s", err)
+	}
+	defer f.Close()
+	return c.ExportPcapng(f, opts...)
+}
@@ -0,0 +1,90 @@
+package chaosmaker
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/yaklang/yaklang/common/chaosmaker/rule"
+	"github.com/yaklang/yaklang/common/suricata/match"
+	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func TestChaosMaker_ExportPcapng(t *testing.T) {
+	var storages []*rule.Storage
+	var rules []*surirule.Rule
+	for _, raw := range []string{
+		`alert tcp any 3306 -> any any (msg:"pcapng mysql denied"; flow:from_server,established; content:"is not allowed to connect"; sid:1000001; rev:1;)`,
+		`alert udp any any -> any 514 (msg:"pcapng udp hello"; content:"hello"; sid:1000002; rev:1;)`,
+	} {
+		ret, err := surirule.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rules = append(rules, ret[0])
+		storages = append(storages, rule.NewRuleFromSuricata(ret[0]))
+	}
+
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	count, err := NewChaosMakerWithRules(storages).ExportPcapng(&buf,
+		WithPcapngClient("192.168.1.10"),
+		WithPcapngServer("192.168.1.20"),
+		WithPcapngStartTime(start),
+		WithPcapngInterval(0.01),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := pcapgo.NewNgReader(&buf, pcapgo.DefaultNgReaderOptions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tcpMatched, udpMatched, total int
+	for {
+		data, ci, err := reader.ReadPacketData()
+		if err != nil {
+			break
+		}
+		if want := start.Add(time.Duration(total) * 10 * time.Millisecond); !ci.Timestamp.Equal(want) {
+			t.Fatalf("timestamp error: %v, want %v", ci.Timestamp, want)
+		}
+		total++
+
+		pk := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.Default)
+		ip, ok := pk.Layer(layers.LayerTypeIPv4).(*layers.IPv4)
+		if !ok {
+			t.Fatal("ipv4 layer not found")
+		}
+		switch transport := pk.TransportLayer().(type) {
+		case *layers.TCP:
+			fromServer := transport.SrcPort == 3306
+			if fromServer != (ip.SrcIP.String() == "192.168.1.20") {
+				t.Fatalf("tcp address error: %v:%v -> %v:%v", ip.SrcIP, transport.SrcPort, ip.DstIP, transport.DstPort)
+			}
+			if transport.SYN && !transport.ACK && transport.DstPort != 3306 {
+				t.Fatal("client should connect to the server port")
+			}
+			if match.New(rules[0]).MatchPackage(pk) {
+				tcpMatched++
+			}
+		case *layers.UDP:
+			if ip.SrcIP.String() != "192.168.1.10" || ip.DstIP.String() != "192.168.1.20" || transport.DstPort != 514 {
+				t.Fatalf("udp address error: %v -> %v", ip.SrcIP, ip.DstIP)
+			}
+			if match.New(rules[1]).MatchPackage(pk) {
+				udpMatched++
+			}
+		}
+	}
+	if total != count || total == 0 {
+		t.Fatalf("packets count error: %d, want %d", total, count)
+	}
+	if tcpMatched == 0 || udpMatched == 0 {
+		t.Fatalf("the exported traffic should be matched: tcp %d, udp %d", tcpMatched, udpMatched)
+	}
+}
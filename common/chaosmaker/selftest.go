@@ -0,0 +1,219 @@
+package chaosmaker
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/chaosmaker/rule"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/suricata/match"
+	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+const (
+	SelfTestPassed        = "passed"
+	SelfTestUngeneratable = "ungeneratable"
+	SelfTestUnmatched     = "unmatched"
+	SelfTestSkipped       = "skipped"
+)
+
+// SelfTestResult is the result of replaying the traffic generated by a rule
+type SelfTestResult struct {
+	Rule     *rule.Storage `json:"-"`
+	Name     string        `json:"name"`
+	RuleType string        `json:"rule_type"`
+	Protocol string        `json:"protocol"`
+	Sid      []int         `json:"sid,omitempty"`
+	Status   string        `json:"status"`
+	Reason   string        `json:"reason,omitempty"`
+
+	// Generated is the count of packets generated by the rule, Matched is the count of them matched by the rule itself
+	Generated int `json:"generated"`
+	Matched   int `json:"matched"`
+
+	// TriggeredBy is the names of other rules whose traffic triggered this rule
+	TriggeredBy []string `json:"triggered_by,omitempty"`
+
+	matchers  []*match.Matcher
+	triggered map[string]struct{}
+}
+
+// SelfTestReport is the report of ChaosMaker.SelfTest
+type SelfTestReport struct {
+	Results []*SelfTestResult `json:"results"`
+}
+
+func (r *SelfTestReport) filter(f func(*SelfTestResult) bool) []*SelfTestResult {
+	var ret []*SelfTestResult
+	for _, result := range r.Results {
+		if f(result) {
+			ret = append(ret, result)
+		}
+	}
+	return ret
+}
+
+func (r *SelfTestReport) withStatus(status string) []*SelfTestResult {
+	return r.filter(func(result *SelfTestResult) bool {
+		return result.Status == status
+	})
+}
+
+func (r *SelfTestReport) Passed() []*SelfTestResult {
+	return r.withStatus(SelfTestPassed)
+}
+
+// Ungeneratable return the rules which cannot generate any traffic
+func (r *SelfTestReport) Ungeneratable() []*SelfTestResult {
+	return r.withStatus(SelfTestUngeneratable)
+}
+
+// Unmatched return the rules whose traffic is not matched by themselves
+func (r *SelfTestReport) Unmatched() []*SelfTestResult {
+	return r.withStatus(SelfTestUnmatched)
+}
+
+func (r *SelfTestReport) Skipped() []*SelfTestResult {
+	return r.withStatus(SelfTestSkipped)
+}
+
+// CrossTriggered return the rules triggered by the traffic of other rules
+func (r *SelfTestReport) CrossTriggered() []*SelfTestResult {
+	return r.filter(func(result *SelfTestResult) bool {
+		return len(result.TriggeredBy) > 0
+	})
+}
+
+func (r *SelfTestReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "total: %d, passed: %d, ungeneratable: %d, unmatched: %d, cross-triggered: %d, skipped: %d\n",
+		len(r.Results), len(r.Passed()), len(r.Ungeneratable()), len(r.Unmatched()), len(r.CrossTriggered()), len(r.Skipped()),
+	)
+	section := func(title string, results []*SelfTestResult, detail func(*SelfTestResult) string) {
+		if len(results) <= 0 {
+			return
+		}
+		fmt.Fprintf(&buf, "\n[%s]\n", title)
+		for _, result := range results {
+			fmt.Fprintf(&buf, "  %v %s: %s\n", result.Sid, result.Name, detail(result))
+		}
+	}
+	section("ungeneratable", r.Ungeneratable(), func(result *SelfTestResult) string {
+		return result.Reason
+	})
+	section("unmatched", r.Unmatched(), func(result *SelfTestResult) string {
+		return fmt.Sprintf("0/%d packets matched", result.Generated)
+	})
+	section("cross-triggered", r.CrossTriggered(), func(result *SelfTestResult) string {
+		return "triggered by " + strings.Join(result.TriggeredBy, ", ")
+	})
+	return buf.String()
+}
+
+func newSelfTestResult(r *rule.Storage) *SelfTestResult {
+	result := &SelfTestResult{
+		Rule:      r,
+		Name:      strings.Trim(r.Name, `"`),
+		RuleType:  r.RuleType,
+		Protocol:  r.Protocol,
+		triggered: make(map[string]struct{}),
+	}
+	if !strings.EqualFold(r.RuleType, "suricata") {
+		result.Status = SelfTestSkipped
+		result.Reason = fmt.Sprintf("rule type %s has no matcher", r.RuleType)
+		return result
+	}
+	rules, err := surirule.Parse(r.SuricataRaw)
+	if err != nil {
+		result.Status = SelfTestUngeneratable
+		result.Reason = fmt.Sprintf("parse suricata rule failed: %v", err)
+		return result
+	}
+	for _, sr := range rules {
+		if result.Name == "" {
+			result.Name = sr.Message
+		}
+		result.Sid = append(result.Sid, sr.Sid)
+		result.matchers = append(result.matchers, match.New(sr))
+	}
+	return result
+}
+
+func (r *SelfTestResult) match(pk gopacket.Packet) bool {
+	for _, m := range r.matchers {
+		if m.MatchPackage(pk) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelfTest generate the traffic of every rule and replay it to the matchers of all rules,
+// the flow state (flowbits / thresholds) is ignored, so each rule can be verified alone.
+func (c *ChaosMaker) SelfTest() *SelfTestReport {
+	report := &SelfTestReport{}
+	for _, r := range c.ChaosRules {
+		report.Results = append(report.Results, newSelfTestResult(r))
+	}
+
+	for _, result := range report.Results {
+		if result.Status != "" {
+			continue
+		}
+		if c.ctx != nil {
+			select {
+			case <-c.ctx.Done():
+				result.Status = SelfTestSkipped
+				result.Reason = "canceled"
+				continue
+			default:
+			}
+		}
+
+		ch, err := c.generate(result.Rule)
+		if err != nil {
+			result.Status = SelfTestUngeneratable
+			result.Reason = err.Error()
+			continue
+		}
+		if ch != nil {
+			for raw := range ch {
+				result.Generated++
+				pk := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.NoCopy)
+				for _, other := range report.Results {
+					if len(other.matchers) <= 0 || !other.match(pk) {
+						continue
+					}
+					if other == result {
+						result.Matched++
+					} else {
+						other.triggered[result.Name] = struct{}{}
+					}
+				}
+			}
+		}
+
+		switch {
+		case result.Generated <= 0:
+			result.Status = SelfTestUngeneratable
+			result.Reason = "no traffic generated"
+		case result.Matched <= 0:
+			result.Status = SelfTestUnmatched
+		default:
+			result.Status = SelfTestPassed
+		}
+		log.Debugf("self test rule %v: %v (%d/%d)", result.Name, result.Status, result.Matched, result.Generated)
+	}
+
+	for _, result := range report.Results {
+		for name := range result.triggered {
+			result.TriggeredBy = append(result.TriggeredBy, name)
+		}
+		sort.Strings(result.TriggeredBy)
+	}
+	return report
+}
@@ -0,0 +1,53 @@
+package chaosmaker
+
+import (
+	"testing"
+
+	"github.com/yaklang/yaklang/common/chaosmaker/rule"
+	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func TestChaosMaker_SelfTest(t *testing.T) {
+	var storages []*rule.Storage
+	for _, raw := range []string{
+		`alert tcp any any -> any 8080 (msg:"self test hello world"; content:"hello world"; sid:1000001; rev:1;)`,
+		`alert tcp any any -> any 8080 (msg:"self test hello"; content:"hello"; sid:1000002; rev:1;)`,
+	} {
+		rules, err := surirule.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		storages = append(storages, rule.NewRuleFromSuricata(rules[0]))
+	}
+	unknown := *storages[0]
+	unknown.Name = "self test unknown protocol"
+	unknown.Protocol = "sctp"
+	storages = append(storages, &unknown, rule.NewHTTPRequestRule("self test http request", []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n")))
+
+	report := NewChaosMakerWithRules(storages).SelfTest()
+	t.Log(report.String())
+	if len(report.Results) != 4 {
+		t.Fatalf("results count error: %d", len(report.Results))
+	}
+
+	helloWorld, hello := report.Results[0], report.Results[1]
+	if helloWorld.Status != SelfTestPassed || helloWorld.Generated <= 0 || helloWorld.Matched <= 0 || helloWorld.Sid[0] != 1000001 {
+		t.Fatalf("rule should pass self test: %+v", helloWorld)
+	}
+	if hello.Status != SelfTestPassed {
+		t.Fatalf("rule should pass self test: %+v", hello)
+	}
+	if len(hello.TriggeredBy) != 1 || hello.TriggeredBy[0] != "self test hello world" {
+		t.Fatalf("rule should be cross triggered: %v", hello.TriggeredBy)
+	}
+	if len(helloWorld.TriggeredBy) != 0 {
+		t.Fatalf("rule should not be cross triggered: %v", helloWorld.TriggeredBy)
+	}
+
+	if ret := report.Ungeneratable(); len(ret) != 1 || ret[0].Name != "self test unknown protocol" || ret[0].Reason == "" {
+		t.Fatalf("ungeneratable rules error: %+v", ret)
+	}
+	if ret := report.Skipped(); len(ret) != 1 || ret[0].RuleType != "http-request" {
+		t.Fatalf("skipped rules error: %+v", ret)
+	}
+}
@@ -0,0 +1,54 @@
+package chaosmaker
+
+import (
+	"github.com/yaklang/yaklang/common/chaosmaker/rule"
+	"github.com/yaklang/yaklang/common/suricata/data/protocol"
+	surirule "github.com/yaklang/yaklang/common/suricata/rule"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func init() {
+	chaosMap.Store("suricata-tls", &tlsHandler{
+		GenCountPerRule: 5,
+	})
+	chaosMap.Store("suricata-ssh", &tlsHandler{
+		GenCountPerRule: 5,
+	})
+}
+
+// tlsHandler generate client hello for tls rules and banner for ssh rules, both of them are sent in tcp flow
+type tlsHandler struct {
+	GenCountPerRule int
+}
+
+var _ chaosHandler = (*tlsHandler)(nil)
+
+func (h *tlsHandler) Generator(maker *ChaosMaker, chaosRule *rule.Storage, originRule *surirule.Rule) chan []byte {
+	if originRule == nil {
+		return nil
+	}
+
+	if originRule.Protocol != protocol.TLS && originRule.Protocol != protocol.SSH {
+		return nil
+	}
+
+	count := h.GenCountPerRule
+	if originRule.ContentRuleConfig != nil && originRule.ContentRuleConfig.Thresholding != nil {
+		count = utils.Max(h.GenCountPerRule, originRule.ContentRuleConfig.Thresholding.Count)
+	}
+
+	ch := make(chan []byte)
+	go (&tcpGenerator{
+		chaosRule:  chaosRule,
+		originRule: originRule,
+		maker:      maker,
+		out:        ch,
+	}).generator(count)
+
+	return ch
+}
+
+func (h *tlsHandler) MatchBytes(i any) bool {
+	//todo: implement
+	return false
+}
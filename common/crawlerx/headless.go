@@ -0,0 +1,362 @@
+// Package crawlerx
+// headless actions, used to run nuclei-like headless steps on a single page
+package crawlerx
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// HeadlessAction is one step on the page
+//
+//	navigate     url
+//	script       code / hook
+//	click        selector / xpath
+//	rightclick   selector / xpath
+//	text         selector / xpath, value
+//	select       selector / xpath, value
+//	files        selector / xpath, value
+//	keyboard     keys
+//	waitload
+//	waitvisible  selector / xpath
+//	sleep        duration(seconds)
+//	extract      selector / xpath, target(text/attribute), attribute
+//	screenshot
+//	setheader / addheader / deleteheader  part(request/response), key, value
+//	setmethod    part, method
+//	setbody      part, body
+type HeadlessAction struct {
+	Name   string
+	Action string
+	Args   map[string]string
+}
+
+func (a *HeadlessAction) arg(keys ...string) string {
+	for _, key := range keys {
+		if v, ok := a.Args[key]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// HeadlessTraffic is a request sent by the browser and the response loaded by lowhttp
+type HeadlessTraffic struct {
+	Url      string
+	Request  []byte
+	Response *lowhttp.LowhttpResponse
+}
+
+type HeadlessResult struct {
+	// Url is the page url after all actions
+	Url string
+	// DOM is the outer html of the page after all actions
+	DOM string
+	// Extracted saves the named results of extract / script actions
+	Extracted  map[string]string
+	Traffic    []*HeadlessTraffic
+	Screenshot string
+}
+
+type headlessRule struct {
+	action string
+	part   string
+	key    string
+	value  string
+}
+
+type headlessRunner struct {
+	starter *BrowserStarter
+	page    *rod.Page
+	timeout time.Duration
+
+	mutex     *sync.Mutex
+	rules     []*headlessRule
+	result    *HeadlessResult
+	opts      []lowhttp.LowhttpOpt
+	targetUrl string
+}
+
+// RunHeadlessActions open a page and execute the actions one by one, all the traffic will be recorded
+func RunHeadlessActions(targetUrl string, actions []*HeadlessAction, opts ...ConfigOpt) (*HeadlessResult, error) {
+	config := NewConfig()
+	opts = append([]ConfigOpt{WithFullTimeout(60)}, opts...)
+	opts = append(opts, WithTargetUrl(targetUrl))
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.baseConfig.ctx == nil {
+		config.baseConfig.ctx = context.Background()
+	}
+	var browserConfig *BrowserConfig
+	if len(config.browsers) > 0 {
+		browserConfig = config.browsers[0]
+	} else {
+		browserConfig = &BrowserConfig{}
+	}
+	starter := NewBrowserStarter(browserConfig, config.baseConfig)
+	defer starter.cancel()
+	err := starter.baseBrowserStarter()
+	if err != nil {
+		return nil, err
+	}
+	defer starter.browser.Close()
+
+	runner := &headlessRunner{
+		starter: starter,
+		timeout: time.Duration(config.baseConfig.pageTimeout) * time.Second,
+		mutex:   new(sync.Mutex),
+		result: &HeadlessResult{
+			Extracted: make(map[string]string),
+		},
+		targetUrl: targetUrl,
+	}
+	runner.opts = []lowhttp.LowhttpOpt{
+		lowhttp.WithTimeout(30 * time.Second),
+		lowhttp.WithSaveHTTPFlow(starter.saveToDB),
+		lowhttp.WithSource("crawlerx-headless"),
+	}
+	if browserConfig.proxyAddress != nil {
+		runner.opts = append(runner.opts, lowhttp.WithProxy(browserConfig.proxyAddress.String()))
+	}
+	if starter.runtimeID != "" {
+		runner.opts = append(runner.opts, lowhttp.WithRuntimeId(starter.runtimeID))
+	}
+	if err := runner.hijack(); err != nil {
+		return nil, err
+	}
+
+	runner.page, err = starter.browser.Page(proto.TargetCreateTarget{URL: "about:blank"})
+	if err != nil {
+		return nil, utils.Errorf("create page error: %v", err)
+	}
+
+	for index, action := range actions {
+		if err := runner.do(action); err != nil {
+			return runner.result, utils.Errorf("headless action[%d] %v error: %v", index, action.Action, err)
+		}
+	}
+
+	if info, err := runner.page.Info(); err == nil {
+		runner.result.Url = info.URL
+	}
+	html, err := runner.page.HTML()
+	if err != nil {
+		return runner.result, utils.Errorf("get page html error: %v", err)
+	}
+	runner.result.DOM = html
+	return runner.result, nil
+}
+
+func (r *headlessRunner) hijack() error {
+	router := NewBrowserHijackRequests(r.starter.browser)
+	err := router.Add("*", "", func(hijack *CrawlerHijack) {
+		req := hijack.Request.Req()
+		for _, header := range r.starter.headers {
+			req.Header.Set(header.Key, header.Value)
+		}
+		r.mutex.Lock()
+		rules := make([]*headlessRule, len(r.rules))
+		copy(rules, r.rules)
+		r.mutex.Unlock()
+
+		for _, rule := range rules {
+			if rule.part != "" && rule.part != "request" {
+				continue
+			}
+			switch rule.action {
+			case "setheader":
+				req.Header.Set(rule.key, rule.value)
+			case "addheader":
+				req.Header.Add(rule.key, rule.value)
+			case "deleteheader":
+				req.Header.Del(rule.key)
+			case "setmethod":
+				req.Method = rule.value
+			case "setbody":
+				hijack.Request.SetBody(rule.value)
+			}
+		}
+
+		rsp, err := hijack.LoadResponseEx(r.opts, true)
+		if err != nil {
+			if !strings.Contains(err.Error(), "context canceled") {
+				log.Errorf("headless load response error: %s", err)
+			}
+			hijack.Response.SetHeader()
+			hijack.Response.SetBody("")
+			return
+		}
+
+		for _, rule := range rules {
+			if rule.part != "response" {
+				continue
+			}
+			switch rule.action {
+			case "setheader", "addheader":
+				hijack.Response.SetHeader(rule.key, rule.value)
+			case "setbody":
+				hijack.Response.SetBody(rule.value)
+			}
+		}
+
+		result := &RequestResult{request: hijack.Request, response: hijack.Response}
+		reqRaw, _ := result.RequestRaw()
+		r.mutex.Lock()
+		r.result.Traffic = append(r.result.Traffic, &HeadlessTraffic{
+			Url:      hijack.Request.URL().String(),
+			Request:  reqRaw,
+			Response: rsp,
+		})
+		r.mutex.Unlock()
+	})
+	if err != nil {
+		return utils.Errorf("create hijack router error: %v", err)
+	}
+	go func() {
+		router.Run()
+	}()
+	return nil
+}
+
+func (r *headlessRunner) element(action *HeadlessAction) (*rod.Element, error) {
+	page := r.page.Timeout(r.timeout)
+	if xpath := action.arg("xpath"); xpath != "" {
+		return page.ElementX(xpath)
+	}
+	if selector := action.arg("selector"); selector != "" {
+		return page.Element(selector)
+	}
+	return nil, utils.Errorf("action %v need `selector` or `xpath`", action.Action)
+}
+
+func (r *headlessRunner) save(action *HeadlessAction, value string) {
+	if action.Name == "" {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.result.Extracted[action.Name] = value
+}
+
+func (r *headlessRunner) do(action *HeadlessAction) error {
+	switch strings.ToLower(action.Action) {
+	case "navigate":
+		u := action.arg("url")
+		if u == "" {
+			u = r.targetUrl
+		}
+		if err := r.page.Timeout(r.timeout).Navigate(u); err != nil {
+			return err
+		}
+		return r.page.Timeout(r.timeout).WaitLoad()
+	case "waitload":
+		return r.page.Timeout(r.timeout).WaitLoad()
+	case "sleep", "time":
+		seconds := codec.Atof(action.arg("duration", "value"))
+		if seconds <= 0 {
+			seconds = 1
+		}
+		time.Sleep(utils.FloatSecondDuration(seconds))
+	case "script":
+		code := action.arg("code")
+		if utils.InterfaceToBoolean(action.arg("hook")) {
+			_, err := r.page.EvalOnNewDocument(code)
+			return err
+		}
+		obj, err := r.page.Timeout(r.timeout).Eval(code)
+		if err != nil {
+			return err
+		}
+		r.save(action, obj.Value.String())
+	case "click", "rightclick":
+		el, err := r.element(action)
+		if err != nil {
+			return err
+		}
+		button := proto.InputMouseButtonLeft
+		if strings.ToLower(action.Action) == "rightclick" {
+			button = proto.InputMouseButtonRight
+		}
+		return el.Click(button, 1)
+	case "text":
+		el, err := r.element(action)
+		if err != nil {
+			return err
+		}
+		return el.Input(action.arg("value"))
+	case "select":
+		el, err := r.element(action)
+		if err != nil {
+			return err
+		}
+		return el.Select([]string{action.arg("value")}, true, rod.SelectorTypeText)
+	case "files":
+		el, err := r.element(action)
+		if err != nil {
+			return err
+		}
+		return el.SetFiles(utils.PrettifyListFromStringSplitEx(action.arg("value"), ","))
+	case "keyboard":
+		return r.page.InsertText(action.arg("keys"))
+	case "waitvisible":
+		el, err := r.element(action)
+		if err != nil {
+			return err
+		}
+		return el.Timeout(r.timeout).WaitVisible()
+	case "extract":
+		el, err := r.element(action)
+		if err != nil {
+			return err
+		}
+		var value string
+		if strings.ToLower(action.arg("target")) == "attribute" {
+			attr, err := el.Attribute(action.arg("attribute"))
+			if err != nil {
+				return err
+			}
+			if attr != nil {
+				value = *attr
+			}
+		} else {
+			value, err = el.Text()
+			if err != nil {
+				return err
+			}
+		}
+		r.save(action, value)
+	case "screenshot":
+		pngBytes, err := r.page.Screenshot(true, &proto.PageCaptureScreenshot{
+			Format: proto.PageCaptureScreenshotFormatPng,
+		})
+		if err != nil {
+			return err
+		}
+		r.result.Screenshot = "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngBytes)
+	case "setheader", "addheader", "deleteheader", "setmethod", "setbody":
+		rule := &headlessRule{
+			action: strings.ToLower(action.Action),
+			part:   strings.ToLower(action.arg("part")),
+			key:    http.CanonicalHeaderKey(action.arg("key")),
+			value:  action.arg("value", "method", "body"),
+		}
+		r.mutex.Lock()
+		r.rules = append(r.rules, rule)
+		r.mutex.Unlock()
+	default:
+		log.Warnf("headless action %v is not supported, skipped", action.Action)
+	}
+	return nil
+}
@@ -197,16 +197,22 @@ func (hijack *CrawlerHijack) ContinueRequest(cq *proto.FetchContinueRequest) {
 }
 
 func (hijack *CrawlerHijack) LoadResponse(opts []lowhttp.LowhttpOpt, loadBody bool) error {
+	_, err := hijack.LoadResponseEx(opts, loadBody)
+	return err
+}
+
+// LoadResponseEx works like LoadResponse and returns the raw lowhttp response for traffic recording
+func (hijack *CrawlerHijack) LoadResponseEx(opts []lowhttp.LowhttpOpt, loadBody bool) (*lowhttp.LowhttpResponse, error) {
 	opts = append(opts, lowhttp.WithRequest(hijack.Request.req))
 	lowHttpResponse, err := lowhttp.HTTP(
 		opts...,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	res, err := lowhttp.ParseBytesToHTTPResponse(lowHttpResponse.RawPacket)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	hijack.Response.payload.ResponseCode = res.StatusCode
 	list := []string{}
@@ -219,11 +225,11 @@ func (hijack *CrawlerHijack) LoadResponse(opts []lowhttp.LowhttpOpt, loadBody bo
 	if loadBody {
 		b, err := io.ReadAll(res.Body)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		hijack.Response.payload.Body = b
 	}
-	return nil
+	return lowHttpResponse, nil
 }
 
 type CrawlerHijackRequest struct {
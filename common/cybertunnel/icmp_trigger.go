@@ -14,7 +14,6 @@ import (
 	"github.com/ReneKroon/ttlcache"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 )
 
 type remoteICMPIPDesc struct {
@@ -70,7 +69,7 @@ func (p *ICMPTrigger) run(ctx context.Context) error {
 	if err != nil {
 		return utils.Errorf("convert iface name failed: %s", err)
 	}
-	handler, err := pcap.OpenLive(ifaceName, 65535, false, pcap.BlockForever)
+	handler, err := pcaputil.OpenLive(ifaceName, 65535, false)
 	if err != nil {
 		return utils.Errorf("open [%v] failed: %s", ifaceName, err)
 	}
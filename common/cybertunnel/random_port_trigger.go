@@ -14,7 +14,6 @@ import (
 	"github.com/ReneKroon/ttlcache"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 )
 
 type remoteIPDesc struct {
@@ -178,7 +177,7 @@ func (p *RandomPortTrigger) run(ctx context.Context) error {
 		return utils.Errorf("convert iface name failed: %s", err)
 	}
 
-	handler, err := pcap.OpenLive(ifaceName, 65535, false, pcap.BlockForever)
+	handler, err := pcaputil.OpenLive(ifaceName, 65535, false)
 	if err != nil {
 		return utils.Errorf("open [%v] failed: %s", ifaceName, err)
 	}
@@ -190,7 +189,7 @@ func (p *RandomPortTrigger) run(ctx context.Context) error {
 		handler.Close()
 	}()
 
-	err = handler.SetBPFFilter("(tcp[tcpflags] & (tcp-syn)) != 0")
+	err = handler.SetBPFFilter("tcp[tcpflags] & (tcp-syn) != 0")
 	if err != nil {
 		return utils.Errorf("compile bpf failed: %s", err)
 	}
@@ -13,4 +13,5 @@ var FacadesExports = map[string]interface{}{
 	"ldapResourceAddr":  SetLdapResourceAddr,
 	"rmiResourceAddr":   SetRmiResourceAddr,
 	"evilClassResource": SetRmiResourceAddr,
+	"jrmpPayload":       SetJRMPPayload,
 }
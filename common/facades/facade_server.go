@@ -8,6 +8,7 @@ import (
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yserx"
 	"net"
 	"sync"
 
@@ -25,6 +26,7 @@ type FacadeServer struct {
 
 	rmiResourceAddrs           map[string]string
 	ldapResourceAddrs          map[string]string
+	jrmpPayload                yserx.JavaSerializable
 	httpResource               map[string]*HttpResource
 	handlers                   []func(notification *Notification)
 	RemoteAddrConvertorHandler func(string) string
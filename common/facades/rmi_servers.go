@@ -171,6 +171,9 @@ func (f *FacadeServer) rmiServe(peekConn *utils.BufferedPeekableConn) error {
 	case rmiCommandCall:
 		log.Infof("conn[%s]'s call command received", conn.RemoteAddr())
 		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if f.jrmpPayload != nil {
+			return f.jrmpServeCall(peekConn, buf[1:])
+		}
 		//_, _ = yserx.ParseJavaSerializedFromReader(bufio.NewReader(conn), func(obj yserx.JavaSerializable) {
 		//	log.Infof("java-serializable typeof: %v", reflect.TypeOf(obj))
 		//	target, _ := obj.(*yserx.JavaString)
@@ -255,6 +258,8 @@ func (f *FacadeServer) rmiServe(peekConn *utils.BufferedPeekableConn) error {
 		//return utils.Errorf("call not implemented")
 	case rmiCommandPing:
 		log.Infof("conn[%s]'s ping command received", conn.RemoteAddr())
+		_, err := conn.Write([]byte{rmiCommandPingACK})
+		return err
 	case rmiCommandReturn:
 		log.Infof("conn[%s]'s return command received", conn.RemoteAddr())
 	case rmiCommandPingACK:
@@ -4,7 +4,6 @@ import (
 	"context"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 	"github.com/pkg/errors"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
@@ -24,9 +23,9 @@ type Scanner struct {
 	config *Config
 
 	handlerWriteChan      chan []byte
-	handler               *pcap.Handle
+	handler               pcaputil.Handle
 	localHandlerWriteChan chan []byte
-	localHandler          *pcap.Handle
+	localHandler          pcaputil.Handle
 
 	opts gopacket.SerializeOptions
 
@@ -152,7 +151,7 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 	// initializes the local port, used to scan the local loopback address
 	log.Debug("start to create local network dev")
 	var localIfaceName string
-	devs, err := pcap.FindAllDevs()
+	devs, err := pcaputil.FindAllDevices()
 	if err != nil {
 		return nil, utils.Errorf("cannot find pcap ifaceDevs: %v", err)
 	}
@@ -162,12 +161,12 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 DEVICE: %v
 DESC: %v
 FLAGS: %v
-`, d.Name, d.Description, net.Flags(d.Flags).String())
+`, d.Name, d.Description, d.Flags.String())
 		})
 
 		// Get the address loopback first
 		for _, addr := range d.Addresses {
-			if addr.IP.IsLoopback() {
+			if addr.IsLoopback() {
 				localIfaceName = d.Name
 				log.Debugf("fetch loopback by addr: %v", d.Name)
 				break
@@ -185,7 +184,7 @@ FLAGS: %v
 		}
 
 		// Get flags
-		if net.Flags(uint(d.Flags))&net.FlagLoopback == 1 {
+		if d.Flags&net.FlagLoopback != 0 {
 			log.Infof("found loopback by flag: %v", d.Name)
 			localIfaceName = d.Name
 			break
@@ -197,13 +196,13 @@ FLAGS: %v
 	if isLoopback {
 		ifaceName = localIfaceName
 	}
-	handler, err := pcap.OpenLive(ifaceName, 65535, false, pcap.BlockForever)
+	handler, err := pcaputil.OpenLive(ifaceName, 65535, false)
 	if err != nil {
 		return nil, errors.Errorf("open device[%v-%v] failed: %s", iface.Name, strconv.QuoteToASCII(iface.Name), err)
 	}
 
 	log.Infof("fetch local loopback pcapDev:[%v]", localIfaceName)
-	localHandler, err := pcap.OpenLive(localIfaceName, 65535, false, pcap.BlockForever)
+	localHandler, err := pcaputil.OpenLive(localIfaceName, 65535, false)
 	if err != nil {
 		return nil, utils.Errorf("open local iface failed: %s", err)
 	}
@@ -1,5 +1,7 @@
 package javaclassparser
 
+import "github.com/yaklang/yaklang/common/utils"
+
 /*
 *
 attribute Table, which stores the bytecode and other information of the method
@@ -104,6 +106,182 @@ func (self *LineNumberTableAttribute) readInfo(cp *ClassParser) {
 	}
 }
 
+/*
+*
+stores the local variables of the method, which is debugging information.
+
+	LOCAL_VARIABLE_TABLE_ATTRIBUTE {
+		u2 attribute_name_index;
+		u4 attribute_length;
+		u2 local_variable_table_length;
+		{
+			u2 start_pc;
+			u2 length;
+			u2 name_index;
+			u2 descriptor_index;
+			u2 index;
+		} local_variable_table[local_variable_table_length];
+	}
+*/
+type LocalVariableTableAttribute struct {
+	Type               string
+	AttrLen            uint32
+	LocalVariableTable []*LocalVariableTableEntry
+}
+
+type LocalVariableTableEntry struct {
+	StartPc         uint16
+	Length          uint16
+	NameIndex       uint16
+	DescriptorIndex uint16
+	Index           uint16
+}
+
+func (self *LocalVariableTableAttribute) readInfo(cp *ClassParser) {
+	self.LocalVariableTable = readLocalVariableTable(cp.reader)
+}
+
+/*
+*
+the same as LocalVariableTable, but the descriptor is the generic signature
+*/
+type LocalVariableTypeTableAttribute struct {
+	Type                   string
+	AttrLen                uint32
+	LocalVariableTypeTable []*LocalVariableTableEntry
+}
+
+func (self *LocalVariableTypeTableAttribute) readInfo(cp *ClassParser) {
+	self.LocalVariableTypeTable = readLocalVariableTable(cp.reader)
+}
+
+func readLocalVariableTable(reader *ClassReader) []*LocalVariableTableEntry {
+	table := make([]*LocalVariableTableEntry, reader.readUint16())
+	for i := range table {
+		table[i] = &LocalVariableTableEntry{
+			StartPc:         reader.readUint16(),
+			Length:          reader.readUint16(),
+			NameIndex:       reader.readUint16(),
+			DescriptorIndex: reader.readUint16(),
+			Index:           reader.readUint16(),
+		}
+	}
+	return table
+}
+
+const (
+	VerificationTop               = 0
+	VerificationInteger           = 1
+	VerificationFloat             = 2
+	VerificationDouble            = 3
+	VerificationLong              = 4
+	VerificationNull              = 5
+	VerificationUninitializedThis = 6
+	VerificationObject            = 7
+	VerificationUninitialized     = 8
+)
+
+/*
+*
+the types of locals and operand stack used by the type checker.
+
+	STACK_MAP_TABLE_ATTRIBUTE {
+		u2 attribute_name_index;
+		u4 attribute_length;
+		u2 number_of_entries;
+		stack_map_frame entries[number_of_entries];
+	}
+
+the offset delta of frames is decoded to the absolute offset in code
+*/
+type StackMapTableAttribute struct {
+	Type    string
+	AttrLen uint32
+	Entries []*StackMapFrame
+}
+
+type StackMapFrame struct {
+	FrameType uint8
+	Offset    int
+	Locals    []*VerificationTypeInfo
+	Stack     []*VerificationTypeInfo
+}
+
+/*
+*
+Index is the constant pool index of Object, or the offset of new instruction of Uninitialized
+*/
+type VerificationTypeInfo struct {
+	Tag   uint8
+	Index uint16
+}
+
+// FrameTypeVerbose return the name of frame type used by javap
+func (self *StackMapFrame) FrameTypeVerbose() string {
+	switch t := self.FrameType; {
+	case t < 64:
+		return "same_frame"
+	case t < 128:
+		return "same_locals_1_stack_item_frame"
+	case t == 247:
+		return "same_locals_1_stack_item_frame_extended"
+	case t >= 248 && t <= 250:
+		return "chop_frame"
+	case t == 251:
+		return "same_frame_extended"
+	case t >= 252 && t <= 254:
+		return "append_frame"
+	case t == 255:
+		return "full_frame"
+	}
+	return "reserved"
+}
+
+func readVerificationTypes(reader *ClassReader, n int) []*VerificationTypeInfo {
+	types := make([]*VerificationTypeInfo, n)
+	for i := range types {
+		types[i] = &VerificationTypeInfo{Tag: reader.readUint8()}
+		if types[i].Tag == VerificationObject || types[i].Tag == VerificationUninitialized {
+			types[i].Index = reader.readUint16()
+		}
+	}
+	return types
+}
+
+func (self *StackMapTableAttribute) readInfo(cp *ClassParser) {
+	reader := cp.reader
+	self.Entries = make([]*StackMapFrame, reader.readUint16())
+	offset := -1
+	for i := range self.Entries {
+		frame := &StackMapFrame{FrameType: reader.readUint8()}
+		var delta int
+		switch t := frame.FrameType; {
+		case t < 64:
+			delta = int(t)
+		case t < 128:
+			delta = int(t) - 64
+			frame.Stack = readVerificationTypes(reader, 1)
+		case t == 247:
+			delta = int(reader.readUint16())
+			frame.Stack = readVerificationTypes(reader, 1)
+		case t >= 248 && t <= 251:
+			delta = int(reader.readUint16())
+		case t >= 252 && t <= 254:
+			delta = int(reader.readUint16())
+			frame.Locals = readVerificationTypes(reader, int(t)-251)
+		case t == 255:
+			delta = int(reader.readUint16())
+			frame.Locals = readVerificationTypes(reader, int(reader.readUint16()))
+			frame.Stack = readVerificationTypes(reader, int(reader.readUint16()))
+		default:
+			panic(utils.Errorf("invalid stack map frame type %d", t))
+		}
+		offset += delta + 1
+		frame.Offset = offset
+		self.Entries[i] = frame
+	}
+}
+
 /*
 *
 
@@ -201,8 +379,12 @@ func newAttributeInfo(attrName string, attrLen uint32) AttributeInfo {
 		return &ExceptionsAttribute{AttrLen: attrLen}
 	case "LineNumberTable":
 		return &LineNumberTableAttribute{AttrLen: attrLen}
-	//case "LocalVariableTable":
-	//	return &LocalVariableTableAttribute{}
+	case "LocalVariableTable":
+		return &LocalVariableTableAttribute{AttrLen: attrLen}
+	case "LocalVariableTypeTable":
+		return &LocalVariableTypeTableAttribute{AttrLen: attrLen}
+	case "StackMapTable":
+		return &StackMapTableAttribute{AttrLen: attrLen}
 	case "SourceFile":
 		return &SourceFileAttribute{AttrLen: attrLen}
 	case "Synthetic":
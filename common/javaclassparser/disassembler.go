@@ -0,0 +1,277 @@
+package javaclassparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+var methodHandleKinds = map[uint8]string{
+	1: "REF_getField", 2: "REF_getStatic", 3: "REF_putField", 4: "REF_putStatic", 5: "REF_invokeVirtual",
+	6: "REF_invokeStatic", 7: "REF_invokeSpecial", 8: "REF_newInvokeSpecial", 9: "REF_invokeInterface",
+}
+
+func quoteMemberName(name string) string {
+	if strings.HasPrefix(name, "<") {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+// getConstantVerbose resolve the constant pool entry like javap, such as Method java/lang/Runtime.exec:(Ljava/lang/String;)Ljava/lang/Process;
+func (this *ClassObject) getConstantVerbose(index uint16) string {
+	info, err := this.getConstantInfo(index)
+	if err != nil || info == nil {
+		return fmt.Sprintf("invalid #%d", index)
+	}
+	nameAndType := func(index uint16) string {
+		info, err := this.getConstantInfo(index)
+		if err != nil {
+			return fmt.Sprintf("invalid #%d", index)
+		}
+		nt, ok := info.(*ConstantNameAndTypeInfo)
+		if !ok {
+			return fmt.Sprintf("invalid #%d", index)
+		}
+		name, _ := this.getUtf8(nt.NameIndex)
+		descriptor, _ := this.getUtf8(nt.DescriptorIndex)
+		return quoteMemberName(name) + ":" + descriptor
+	}
+	member := func(kind string, ref *ConstantMemberrefInfo) string {
+		class, _ := this.getUtf8(ref.ClassIndex)
+		return fmt.Sprintf("%s %s.%s", kind, class, nameAndType(ref.NameAndTypeIndex))
+	}
+	switch ret := info.(type) {
+	case *ConstantIntegerInfo:
+		return fmt.Sprintf("int %d", ret.Value)
+	case *ConstantFloatInfo:
+		return fmt.Sprintf("float %vf", ret.Value)
+	case *ConstantLongInfo:
+		return fmt.Sprintf("long %dl", ret.Value)
+	case *ConstantDoubleInfo:
+		return fmt.Sprintf("double %vd", ret.Value)
+	case *ConstantUtf8Info:
+		return "utf8 " + ret.Value
+	case *ConstantStringInfo:
+		s, _ := this.getUtf8(ret.StringIndex)
+		return "String " + s
+	case *ConstantClassInfo:
+		name, _ := this.getUtf8(ret.NameIndex)
+		return "class " + name
+	case *ConstantFieldrefInfo:
+		return member("Field", &ret.ConstantMemberrefInfo)
+	case *ConstantMethodrefInfo:
+		return member("Method", &ret.ConstantMemberrefInfo)
+	case *ConstantInterfaceMethodrefInfo:
+		return member("InterfaceMethod", &ret.ConstantMemberrefInfo)
+	case *ConstantNameAndTypeInfo:
+		return "NameAndType " + nameAndType(index)
+	case *ConstantMethodTypeInfo:
+		descriptor, _ := this.getUtf8(ret.DescriptorIndex)
+		return "MethodType " + descriptor
+	case *ConstantMethodHandleInfo:
+		ref := this.getConstantVerbose(ret.ReferenceIndex)
+		if _, after, ok := strings.Cut(ref, " "); ok {
+			ref = after
+		}
+		return fmt.Sprintf("MethodHandle %s %s", methodHandleKinds[ret.ReferenceKind], ref)
+	case *ConstantInvokeDynamicInfo:
+		return fmt.Sprintf("InvokeDynamic #%d:%s", ret.BootstrapMethodAttrIndex, nameAndType(ret.NameAndTypeIndex))
+	}
+	return fmt.Sprintf("unknown #%d", index)
+}
+
+// DisassembleInstruction format the instruction like javap -c, the constants are resolved in comment
+func (this *ClassObject) DisassembleInstruction(inst *Instruction) string {
+	name := inst.Name
+	if inst.Wide {
+		name = "wide " + name
+	}
+	var args, comment string
+	switch inst.operandKind() {
+	case operandNone:
+	case operandUByte:
+		if inst.Opcode == OP_newarray {
+			args = newArrayTypes[inst.Operands[0]]
+		} else {
+			args = fmt.Sprintf("#%d", inst.Operands[0])
+			comment = this.getConstantVerbose(uint16(inst.Operands[0]))
+		}
+	case operandConstant, operandInvokeDynamic:
+		args = fmt.Sprintf("#%d", inst.Operands[0])
+		comment = this.getConstantVerbose(uint16(inst.Operands[0]))
+	case operandInvokeInterface, operandMultiANewArray:
+		args = fmt.Sprintf("#%d,  %d", inst.Operands[0], inst.Operands[1])
+		comment = this.getConstantVerbose(uint16(inst.Operands[0]))
+	case operandIinc:
+		args = fmt.Sprintf("%d, %d", inst.Operands[0], inst.Operands[1])
+	case operandTableSwitch:
+		var buf strings.Builder
+		buf.WriteString(fmt.Sprintf("{ // %d to %d\n", inst.Operands[1], inst.Operands[2]))
+		for n, target := range inst.Operands[3:] {
+			buf.WriteString(fmt.Sprintf("%24d: %d\n", inst.Operands[1]+n, target))
+		}
+		buf.WriteString(fmt.Sprintf("%24s: %d\n%12s}", "default", inst.Operands[0], ""))
+		args = buf.String()
+	case operandLookupSwitch:
+		var buf strings.Builder
+		buf.WriteString(fmt.Sprintf("{ // %d\n", inst.Operands[1]))
+		for n := 2; n+1 < len(inst.Operands); n += 2 {
+			buf.WriteString(fmt.Sprintf("%24d: %d\n", inst.Operands[n], inst.Operands[n+1]))
+		}
+		buf.WriteString(fmt.Sprintf("%24s: %d\n%12s}", "default", inst.Operands[0], ""))
+		args = buf.String()
+	default:
+		var values []string
+		for _, v := range inst.Operands {
+			values = append(values, strconv.Itoa(v))
+		}
+		args = strings.Join(values, ", ")
+	}
+	if comment != "" {
+		return fmt.Sprintf("%-13s %-18s // %s", name, args, comment)
+	}
+	if args != "" {
+		return fmt.Sprintf("%-13s %s", name, args)
+	}
+	return name
+}
+
+func (this *ClassObject) verificationTypesVerbose(types []*VerificationTypeInfo) string {
+	var ret []string
+	for _, t := range types {
+		switch t.Tag {
+		case VerificationTop:
+			ret = append(ret, "top")
+		case VerificationInteger:
+			ret = append(ret, "int")
+		case VerificationFloat:
+			ret = append(ret, "float")
+		case VerificationDouble:
+			ret = append(ret, "double")
+		case VerificationLong:
+			ret = append(ret, "long")
+		case VerificationNull:
+			ret = append(ret, "null")
+		case VerificationUninitializedThis:
+			ret = append(ret, "uninitialized_this")
+		case VerificationObject:
+			ret = append(ret, this.getConstantVerbose(t.Index))
+		case VerificationUninitialized:
+			ret = append(ret, fmt.Sprintf("uninitialized %d", t.Index))
+		}
+	}
+	return "[ " + strings.Join(ret, ", ") + " ]"
+}
+
+// DisassembleCode format the Code attribute like javap -c -l -v
+func (this *ClassObject) DisassembleCode(code *CodeAttribute) (string, error) {
+	insts, err := code.GetInstructions()
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	buf.WriteString("Code:\n")
+	buf.WriteString(fmt.Sprintf("  stack=%d, locals=%d\n", code.MaxStack, code.MaxLocals))
+	for _, inst := range insts {
+		buf.WriteString(fmt.Sprintf("%8d: %s\n", inst.Offset, this.DisassembleInstruction(inst)))
+	}
+	if len(code.ExceptionTable) > 0 {
+		buf.WriteString("Exception table:\n")
+		buf.WriteString("   from    to  target type\n")
+		for _, entry := range code.ExceptionTable {
+			catchType := "any"
+			if entry.CatchType != 0 {
+				catchType = this.getConstantVerbose(entry.CatchType)
+				catchType = strings.ToUpper(catchType[:1]) + catchType[1:]
+			}
+			buf.WriteString(fmt.Sprintf("  %5d %5d %5d   %s\n", entry.StartPc, entry.EndPc, entry.HandlerPc, catchType))
+		}
+	}
+	localVariables := func(title, column string, table []*LocalVariableTableEntry) {
+		buf.WriteString(title + ":\n")
+		buf.WriteString(fmt.Sprintf("  Start  Length  Slot  Name   %s\n", column))
+		for _, entry := range table {
+			name, _ := this.getUtf8(entry.NameIndex)
+			descriptor, _ := this.getUtf8(entry.DescriptorIndex)
+			buf.WriteString(fmt.Sprintf("  %5d  %6d  %4d  %-5s  %s\n", entry.StartPc, entry.Length, entry.Index, name, descriptor))
+		}
+	}
+	for _, attr := range code.Attributes {
+		switch ret := attr.(type) {
+		case *LineNumberTableAttribute:
+			buf.WriteString("LineNumberTable:\n")
+			for _, entry := range ret.LineNumberTable {
+				buf.WriteString(fmt.Sprintf("  line %d: %d\n", entry.LineNumber, entry.StartPc))
+			}
+		case *LocalVariableTableAttribute:
+			localVariables("LocalVariableTable", "Signature", ret.LocalVariableTable)
+		case *LocalVariableTypeTableAttribute:
+			localVariables("LocalVariableTypeTable", "Signature", ret.LocalVariableTypeTable)
+		case *StackMapTableAttribute:
+			buf.WriteString(fmt.Sprintf("StackMapTable: number_of_entries = %d\n", len(ret.Entries)))
+			for _, frame := range ret.Entries {
+				buf.WriteString(fmt.Sprintf("  frame_type = %d /* %s */\n", frame.FrameType, frame.FrameTypeVerbose()))
+				buf.WriteString(fmt.Sprintf("    offset = %d\n", frame.Offset))
+				if frame.FrameType >= 248 && frame.FrameType <= 250 {
+					buf.WriteString(fmt.Sprintf("    chop = %d\n", 251-int(frame.FrameType)))
+				}
+				if len(frame.Locals) > 0 || frame.FrameType == 255 {
+					buf.WriteString(fmt.Sprintf("    locals = %s\n", this.verificationTypesVerbose(frame.Locals)))
+				}
+				if len(frame.Stack) > 0 || frame.FrameType == 255 {
+					buf.WriteString(fmt.Sprintf("    stack = %s\n", this.verificationTypesVerbose(frame.Stack)))
+				}
+			}
+		}
+	}
+	return buf.String(), nil
+}
+
+// GetCodeAttribute return the Code attribute of method, nil for abstract and native methods
+func (this *MemberInfo) GetCodeAttribute() *CodeAttribute {
+	for _, attr := range this.Attributes {
+		if code, ok := attr.(*CodeAttribute); ok {
+			return code
+		}
+	}
+	return nil
+}
+
+// Disassemble dump the methods of class with bytecode like javap -c
+func (this *ClassObject) Disassemble() (string, error) {
+	methods, err := NewClassObjectDumper(this).DumpMethodsWithCode()
+	if err != nil {
+		return "", err
+	}
+	header := strings.TrimSpace(strings.Join(this.AccessFlagsVerbose, " ") + " class " + strings.ReplaceAll(this.GetClassName(), "/", "."))
+	if super := this.GetSupperClassName(); super != "" && super != "java/lang/Object" {
+		header += " extends " + strings.ReplaceAll(super, "/", ".")
+	}
+	if interfaces := this.GetInterfacesName(); len(interfaces) > 0 {
+		header += " implements " + strings.ReplaceAll(strings.Join(interfaces, ","), "/", ".")
+	}
+	return header + " {\n" + strings.Join(methods, "\n") + "}\n", nil
+}
+
+func indent(s string, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func (this *ClassObject) methodName(method *MemberInfo) (string, string, error) {
+	name, err := this.getUtf8(method.NameIndex)
+	if err != nil {
+		return "", "", utils.Errorf("get method name failed: %v", err)
+	}
+	descriptor, err := this.getUtf8(method.DescriptorIndex)
+	if err != nil {
+		return "", "", utils.Errorf("get method descriptor failed: %v", err)
+	}
+	return name, descriptor, nil
+}
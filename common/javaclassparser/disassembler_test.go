@@ -0,0 +1,188 @@
+package javaclassparser
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadTestClasses(t *testing.T) map[string][]byte {
+	ret := map[string][]byte{}
+	for _, name := range []string{"sleep.class", "autosearch.class"} {
+		data, err := os.ReadFile("../yso/templates/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret[name] = data
+	}
+	return ret
+}
+
+func TestInstructionsRoundTrip(t *testing.T) {
+	for name, data := range loadTestClasses(t) {
+		obj, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// StackMapTable and LocalVariableTable are decoded and encoded again
+		if !bytes.Equal(obj.Bytes(), data) {
+			t.Fatalf("%s: marshal result is different from the original class", name)
+		}
+		for _, method := range obj.Methods {
+			code := method.GetCodeAttribute()
+			if code == nil {
+				continue
+			}
+			insts, err := code.GetInstructions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, _, err := assembleInstructions(insts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(raw, code.Code) {
+				t.Fatalf("%s: assemble result is different from the original code", name)
+			}
+		}
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	obj, err := Parse(loadTestClasses(t)["sleep.class"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := obj.Disassemble()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keyword := range []string{"Code:", "stack=", "aload_0", "invokespecial", `// Method java/lang/Thread.sleep:(J)V`, "LineNumberTable:", "return"} {
+		if !strings.Contains(result, keyword) {
+			t.Fatalf("disassemble result should contain %s:\n%s", keyword, result)
+		}
+	}
+}
+
+func TestSetInstructions(t *testing.T) {
+	for name, data := range loadTestClasses(t) {
+		obj, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var exceptions, frames int
+		for _, method := range obj.Methods {
+			code := method.GetCodeAttribute()
+			if code == nil {
+				continue
+			}
+			insts, err := code.GetInstructions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var branches []int
+			for _, inst := range insts {
+				if inst.IsBranch() {
+					branches = append(branches, inst.Operands[0])
+				}
+			}
+			var oldFrames []int
+			for _, attr := range code.Attributes {
+				if smt, ok := attr.(*StackMapTableAttribute); ok {
+					for _, frame := range smt.Entries {
+						oldFrames = append(oldFrames, frame.Offset)
+					}
+				}
+			}
+			var oldHandlers []uint16
+			for _, entry := range code.ExceptionTable {
+				oldHandlers = append(oldHandlers, entry.HandlerPc)
+			}
+
+			nop, err := NewInstruction("nop")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := code.SetInstructions(append([]*Instruction{nop}, insts...)); err != nil {
+				t.Fatal(err)
+			}
+
+			newInsts, err := code.GetInstructions()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if newInsts[0].Name != "nop" || len(newInsts) != len(insts)+1 {
+				t.Fatalf("%s: unexpected instructions after insert", name)
+			}
+			var n int
+			for _, inst := range newInsts {
+				if inst.IsBranch() {
+					if inst.Operands[0] != branches[n]+1 {
+						t.Fatalf("%s: branch target is not shifted", name)
+					}
+					n++
+				}
+			}
+			for i, entry := range code.ExceptionTable {
+				if entry.HandlerPc != oldHandlers[i]+1 {
+					t.Fatalf("%s: exception handler is not shifted", name)
+				}
+				exceptions++
+			}
+			for _, attr := range code.Attributes {
+				if smt, ok := attr.(*StackMapTableAttribute); ok {
+					for i, frame := range smt.Entries {
+						if frame.Offset != oldFrames[i]+1 {
+							t.Fatalf("%s: frame offset is not shifted", name)
+						}
+						frames++
+					}
+				}
+			}
+		}
+		if name == "autosearch.class" && (exceptions == 0 || frames == 0) {
+			t.Fatalf("%s: expect exception table and stack map frames", name)
+		}
+
+		// the modified class can be parsed again
+		reparsed, err := Parse(obj.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := reparsed.Disassemble(); err != nil {
+			t.Fatal(err)
+		}
+		jsonStr, err := reparsed.Json()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromJson, err := ParseFromJson(jsonStr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(fromJson.Bytes(), reparsed.Bytes()) {
+			t.Fatalf("%s: json round trip failed", name)
+		}
+	}
+}
+
+func TestNewInstructionWide(t *testing.T) {
+	ldc, _ := NewInstruction("ldc", 300)
+	load, _ := NewInstruction("aload", 256)
+	ret, _ := NewInstruction("areturn")
+	raw, _, err := assembleInstructions([]*Instruction{ldc, load, ret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	insts, err := ParseInstructions(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if insts[0].Name != "ldc_w" || insts[0].Operands[0] != 300 {
+		t.Fatalf("ldc should be converted to ldc_w: %v", insts[0])
+	}
+	if !insts[1].Wide || insts[1].Name != "aload" || insts[1].Operands[0] != 256 || insts[1].Offset != 3 {
+		t.Fatalf("aload should be prefixed by wide: %v", insts[1])
+	}
+}
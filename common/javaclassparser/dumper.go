@@ -93,53 +93,78 @@ func (c *ClassObjectDumper) DumpFields() ([]string, error) {
 func (c *ClassObjectDumper) DumpMethods() ([]string, error) {
 	result := []string{}
 	for _, method := range c.obj.Methods {
-		accessFlagsVerbose := getAccessFlagsVerbose(method.AccessFlags)
-		if len(accessFlagsVerbose) < 1 {
+		if len(getAccessFlagsVerbose(method.AccessFlags)) < 1 {
 			return nil, utils.Error("method accessFlagsVerbose is empty")
 		}
-		accessFlags := strings.Join(accessFlagsVerbose, " ")
-		name, err := c.obj.getUtf8(method.NameIndex)
+		signature, err := c.dumpMethodSignature(method)
 		if err != nil {
 			return nil, err
 		}
-		descriptor, err := c.obj.getUtf8(method.DescriptorIndex)
+		result = append(result, signature)
+	}
+	return result, nil
+}
+
+// DumpMethodsWithCode dump the method signatures with the disassembled bytecode
+func (c *ClassObjectDumper) DumpMethodsWithCode() ([]string, error) {
+	result := []string{}
+	for _, method := range c.obj.Methods {
+		signature, err := c.dumpMethodSignature(method)
 		if err != nil {
 			return nil, err
 		}
-		r, err := regexp.Compile("\\((.*)\\)(.+?)")
+		signature = strings.TrimSpace(signature)
+		code := method.GetCodeAttribute()
+		if code == nil {
+			result = append(result, indent(signature, "  "))
+			continue
+		}
+		disassembled, err := c.obj.DisassembleCode(code)
 		if err != nil {
-			return nil, err
+			return nil, utils.Errorf("disassemble method %s failed: %v", signature, err)
 		}
+		result = append(result, indent(signature, "  ")+indent(disassembled, "    "))
+	}
+	return result, nil
+}
 
-		matchRes := r.FindAllStringSubmatch(descriptor, -1)
-		if len(matchRes) != 1 {
-			return nil, utils.Error("method descriptor is invalid")
-		}
-		matchResOne := matchRes[0]
-		if len(matchResOne) != 3 {
-			return nil, utils.Error("method descriptor is invalid")
-		}
-		paramsStr := matchResOne[1]
-		params := strings.Split(paramsStr, ";")
-		paramsNewStrList := []string{}
-		returnType := matchResOne[2]
-		if returnType == "V" {
-			returnType = "void"
-		}
-		for i, param := range params {
-			array := ""
-			if param == "" {
-				continue
-			}
-			lastPacket := c.parseImportCLass(param)
-			paramsNewStrList = append(paramsNewStrList, fmt.Sprintf("%s%s var%d", lastPacket, array, i))
-		}
-		paramsNewStr := strings.Join(paramsNewStrList, ", ")
-		name = fmt.Sprintf("%s(%s)", name, paramsNewStr)
-		result = append(result, fmt.Sprintf(attrTemplate, accessFlags, returnType, name))
+func (c *ClassObjectDumper) dumpMethodSignature(method *MemberInfo) (string, error) {
+	accessFlags := strings.Join(getAccessFlagsVerbose(method.AccessFlags), " ")
+	name, descriptor, err := c.obj.methodName(method)
+	if err != nil {
+		return "", err
+	}
+	r, err := regexp.Compile("\\((.*)\\)(.+?)")
+	if err != nil {
+		return "", err
+	}
 
+	matchRes := r.FindAllStringSubmatch(descriptor, -1)
+	if len(matchRes) != 1 {
+		return "", utils.Error("method descriptor is invalid")
 	}
-	return result, nil
+	matchResOne := matchRes[0]
+	if len(matchResOne) != 3 {
+		return "", utils.Error("method descriptor is invalid")
+	}
+	paramsStr := matchResOne[1]
+	params := strings.Split(paramsStr, ";")
+	paramsNewStrList := []string{}
+	returnType := matchResOne[2]
+	if returnType == "V" {
+		returnType = "void"
+	}
+	for i, param := range params {
+		array := ""
+		if param == "" {
+			continue
+		}
+		lastPacket := c.parseImportCLass(param)
+		paramsNewStrList = append(paramsNewStrList, fmt.Sprintf("%s%s var%d", lastPacket, array, i))
+	}
+	paramsNewStr := strings.Join(paramsNewStrList, ", ")
+	name = fmt.Sprintf("%s(%s)", name, paramsNewStr)
+	return fmt.Sprintf(attrTemplate, accessFlags, returnType, name), nil
 }
 func (c *ClassObjectDumper) parseImportCLass(name string) string {
 	if name[len(name)-1] == ';' {
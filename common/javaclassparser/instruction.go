@@ -0,0 +1,415 @@
+package javaclassparser
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+/*
+*
+Instruction is a decoded jvm instruction of the Code attribute
+
+Operands are the values after opcode, the branch targets are absolute offsets in the code:
+
+	bipush / sipush / ldc / newarray / xload / xstore / ret: [value]
+	ldc_w / field / method / new / checkcast ...: [constant pool index]
+	iinc: [local index, const]
+	branch: [target]
+	invokeinterface: [constant pool index, count]
+	invokedynamic: [constant pool index]
+	multianewarray: [constant pool index, dimensions]
+	tableswitch: [default, low, high, target...]
+	lookupswitch: [default, npairs, key, target, key, target...]
+
+Wide means the instruction is prefixed by wide, the instructions created by NewInstruction use wide and ldc_w automatically if the index is too large.
+*/
+type Instruction struct {
+	// Offset is the offset in the code, -1 means the instruction is not in the original code
+	Offset   int
+	Opcode   uint8
+	Name     string
+	Wide     bool
+	Operands []int
+}
+
+// NewInstruction create an instruction by mnemonic, it can be inserted into the result of CodeAttribute.GetInstructions
+func NewInstruction(name string, operands ...int) (*Instruction, error) {
+	op, ok := GetOpcodeByName(name)
+	if !ok || op == OP_wide {
+		return nil, utils.Errorf("unknown opcode: %s", name)
+	}
+	return &Instruction{Offset: -1, Opcode: op, Name: name, Operands: operands}, nil
+}
+
+func (i *Instruction) operandKind() int {
+	if info := opcodeTable[i.Opcode]; info != nil {
+		return info.operand
+	}
+	return operandNone
+}
+
+// IsBranch return true if the operands contain branch targets
+func (i *Instruction) IsBranch() bool {
+	switch i.operandKind() {
+	case operandBranch, operandBranchWide, operandTableSwitch, operandLookupSwitch:
+		return true
+	}
+	return false
+}
+
+// targets return the index of operands which are branch targets
+func (i *Instruction) targets() []int {
+	switch i.operandKind() {
+	case operandBranch, operandBranchWide:
+		return []int{0}
+	case operandTableSwitch:
+		ret := []int{0}
+		for n := 3; n < len(i.Operands); n++ {
+			ret = append(ret, n)
+		}
+		return ret
+	case operandLookupSwitch:
+		ret := []int{0}
+		for n := 3; n < len(i.Operands); n += 2 {
+			ret = append(ret, n)
+		}
+		return ret
+	}
+	return nil
+}
+
+func switchPadding(offset int) int {
+	return (4 - (offset+1)%4) % 4
+}
+
+// size return the length of instruction at offset
+func (i *Instruction) size(offset int) (int, error) {
+	n := 1
+	if i.Wide {
+		n = 2
+	}
+	switch i.operandKind() {
+	case operandNone:
+	case operandByte, operandUByte:
+		n += 1
+	case operandShort, operandConstant, operandBranch:
+		n += 2
+	case operandLocal:
+		if i.Wide {
+			n += 2
+		} else {
+			n += 1
+		}
+	case operandIinc:
+		if i.Wide {
+			n += 4
+		} else {
+			n += 2
+		}
+	case operandBranchWide, operandInvokeInterface, operandInvokeDynamic:
+		n += 4
+	case operandMultiANewArray:
+		n += 3
+	case operandTableSwitch:
+		if len(i.Operands) < 3 || len(i.Operands) != 3+(i.Operands[2]-i.Operands[1]+1) {
+			return 0, utils.Errorf("invalid tableswitch operands at %d", offset)
+		}
+		n += switchPadding(offset) + 4*len(i.Operands)
+	case operandLookupSwitch:
+		if len(i.Operands) < 2 || len(i.Operands) != 2+2*i.Operands[1] {
+			return 0, utils.Errorf("invalid lookupswitch operands at %d", offset)
+		}
+		n += switchPadding(offset) + 4*len(i.Operands)
+	default:
+		return 0, utils.Errorf("unsupported opcode %#x at %d", i.Opcode, offset)
+	}
+	return n, nil
+}
+
+// ParseInstructions decode the bytecode of Code attribute
+func ParseInstructions(code []byte) (_ []*Instruction, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = utils.Errorf("parse instructions failed: %v", r)
+		}
+	}()
+	var insts []*Instruction
+	u1 := func(pos int) int { return int(code[pos]) }
+	s1 := func(pos int) int { return int(int8(code[pos])) }
+	u2 := func(pos int) int { return int(binary.BigEndian.Uint16(code[pos:])) }
+	s2 := func(pos int) int { return int(int16(binary.BigEndian.Uint16(code[pos:]))) }
+	s4 := func(pos int) int { return int(int32(binary.BigEndian.Uint32(code[pos:]))) }
+
+	for pc := 0; pc < len(code); {
+		inst := &Instruction{Offset: pc, Opcode: code[pc]}
+		pos := pc + 1
+		if inst.Opcode == OP_wide {
+			inst.Wide = true
+			inst.Opcode = code[pos]
+			pos++
+			if k := inst.operandKind(); k != operandLocal && k != operandIinc {
+				return nil, utils.Errorf("invalid opcode %#x after wide at %d", inst.Opcode, pc)
+			}
+		}
+		info := opcodeTable[inst.Opcode]
+		if info == nil {
+			return nil, utils.Errorf("unknown opcode %#x at %d", inst.Opcode, pc)
+		}
+		inst.Name = info.name
+
+		switch info.operand {
+		case operandByte:
+			inst.Operands = []int{s1(pos)}
+		case operandUByte:
+			inst.Operands = []int{u1(pos)}
+		case operandShort:
+			inst.Operands = []int{s2(pos)}
+		case operandConstant:
+			inst.Operands = []int{u2(pos)}
+		case operandLocal:
+			if inst.Wide {
+				inst.Operands = []int{u2(pos)}
+			} else {
+				inst.Operands = []int{u1(pos)}
+			}
+		case operandIinc:
+			if inst.Wide {
+				inst.Operands = []int{u2(pos), s2(pos + 2)}
+			} else {
+				inst.Operands = []int{u1(pos), s1(pos + 1)}
+			}
+		case operandBranch:
+			inst.Operands = []int{pc + s2(pos)}
+		case operandBranchWide:
+			inst.Operands = []int{pc + s4(pos)}
+		case operandInvokeInterface:
+			inst.Operands = []int{u2(pos), u1(pos + 2)}
+		case operandInvokeDynamic:
+			inst.Operands = []int{u2(pos)}
+		case operandMultiANewArray:
+			inst.Operands = []int{u2(pos), u1(pos + 2)}
+		case operandTableSwitch:
+			pos += switchPadding(pc)
+			low, high := s4(pos+4), s4(pos+8)
+			if high < low || high-low > len(code) {
+				return nil, utils.Errorf("invalid tableswitch at %d", pc)
+			}
+			inst.Operands = []int{pc + s4(pos), low, high}
+			for n := 0; n <= high-low; n++ {
+				inst.Operands = append(inst.Operands, pc+s4(pos+12+n*4))
+			}
+		case operandLookupSwitch:
+			pos += switchPadding(pc)
+			npairs := s4(pos + 4)
+			if npairs < 0 || npairs > len(code) {
+				return nil, utils.Errorf("invalid lookupswitch at %d", pc)
+			}
+			inst.Operands = []int{pc + s4(pos), npairs}
+			for n := 0; n < npairs; n++ {
+				inst.Operands = append(inst.Operands, s4(pos+8+n*8), pc+s4(pos+12+n*8))
+			}
+		}
+		size, err := inst.size(pc)
+		if err != nil {
+			return nil, err
+		}
+		if pc+size > len(code) {
+			return nil, utils.Errorf("truncated instruction %s at %d", inst.Name, pc)
+		}
+		insts = append(insts, inst)
+		pc += size
+	}
+	return insts, nil
+}
+
+// offsetMapper map the offset in original code to the offset in the assembled code
+type offsetMapper struct {
+	// the original offsets and new offsets of the instructions in original code, sorted by original offset
+	olds, news []int
+	// the length of assembled code
+	length int
+}
+
+// get return the new offset of original offset, the removed instruction is mapped to the next one
+func (m *offsetMapper) get(offset int) int {
+	i := sort.SearchInts(m.olds, offset)
+	if i >= len(m.olds) {
+		return m.length
+	}
+	return m.news[i]
+}
+
+// fix the wide and ldc_w of instruction by the size of operands
+func (i *Instruction) fix() {
+	switch i.operandKind() {
+	case operandLocal, operandIinc:
+		if len(i.Operands) > 0 && i.Operands[0] > 0xff {
+			i.Wide = true
+		}
+		if i.operandKind() == operandIinc && len(i.Operands) > 1 && (i.Operands[1] > 127 || i.Operands[1] < -128) {
+			i.Wide = true
+		}
+	case operandUByte:
+		if i.Opcode == OP_ldc && len(i.Operands) > 0 && i.Operands[0] > 0xff {
+			i.Opcode, i.Name = OP_ldc_w, GetOpcodeName(OP_ldc_w)
+		}
+	}
+}
+
+// assembleInstructions encode the instructions to bytecode, the branch targets are the offsets in original code,
+// they are mapped to the new offsets, the mapper is used to fix the tables referring the offsets
+func assembleInstructions(insts []*Instruction) ([]byte, *offsetMapper, error) {
+	offsets := make([]int, len(insts))
+	pc := 0
+	for n, inst := range insts {
+		if inst == nil {
+			return nil, nil, utils.Errorf("instruction %d is nil", n)
+		}
+		inst.fix()
+		offsets[n] = pc
+		size, err := inst.size(pc)
+		if err != nil {
+			return nil, nil, err
+		}
+		pc += size
+	}
+
+	mapper := &offsetMapper{length: pc}
+	type pair struct{ old, new int }
+	var pairs []pair
+	for n, inst := range insts {
+		if inst.Offset >= 0 {
+			pairs = append(pairs, pair{inst.Offset, offsets[n]})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].old < pairs[j].old })
+	for _, p := range pairs {
+		mapper.olds = append(mapper.olds, p.old)
+		mapper.news = append(mapper.news, p.new)
+	}
+
+	writer := NewJavaBufferWrite()
+	for n, inst := range insts {
+		pc := offsets[n]
+		operands := append([]int(nil), inst.Operands...)
+		for _, t := range inst.targets() {
+			if t >= len(operands) {
+				return nil, nil, utils.Errorf("missing branch target of %s at %d", inst.Name, pc)
+			}
+			operands[t] = mapper.get(operands[t]) - pc
+		}
+		need := 0
+		switch inst.operandKind() {
+		case operandByte, operandUByte, operandShort, operandConstant, operandLocal, operandBranch, operandBranchWide, operandInvokeDynamic:
+			need = 1
+		case operandIinc, operandInvokeInterface, operandMultiANewArray:
+			need = 2
+		}
+		if len(operands) < need {
+			return nil, nil, utils.Errorf("missing operands of %s at %d", inst.Name, pc)
+		}
+
+		if inst.Wide {
+			writer.Write1Byte(OP_wide)
+		}
+		writer.Write1Byte(inst.Opcode)
+		switch inst.operandKind() {
+		case operandByte, operandUByte:
+			writer.Write1Byte(operands[0])
+		case operandShort, operandConstant:
+			writer.Write2Byte(operands[0])
+		case operandBranch:
+			if operands[0] > 32767 || operands[0] < -32768 {
+				return nil, nil, utils.Errorf("branch offset of %s at %d is out of range, use goto_w instead", inst.Name, pc)
+			}
+			writer.Write2Byte(operands[0])
+		case operandLocal:
+			if inst.Wide {
+				writer.Write2Byte(operands[0])
+			} else {
+				writer.Write1Byte(operands[0])
+			}
+		case operandIinc:
+			if inst.Wide {
+				writer.Write2Byte(operands[0])
+				writer.Write2Byte(operands[1])
+			} else {
+				writer.Write1Byte(operands[0])
+				writer.Write1Byte(operands[1])
+			}
+		case operandBranchWide:
+			writer.Write4Byte(operands[0])
+		case operandInvokeInterface:
+			writer.Write2Byte(operands[0])
+			writer.Write1Byte(operands[1])
+			writer.Write1Byte(0)
+		case operandInvokeDynamic:
+			writer.Write2Byte(operands[0])
+			writer.Write2Byte(0)
+		case operandMultiANewArray:
+			writer.Write2Byte(operands[0])
+			writer.Write1Byte(operands[1])
+		case operandTableSwitch, operandLookupSwitch:
+			writer.Write(make([]byte, switchPadding(pc)))
+			for _, v := range operands {
+				writer.Write4Byte(v)
+			}
+		}
+	}
+	return writer.Bytes(), mapper, nil
+}
+
+// GetInstructions decode the bytecode of the method
+func (self *CodeAttribute) GetInstructions() ([]*Instruction, error) {
+	return ParseInstructions(self.Code)
+}
+
+// SetInstructions assemble the instructions to the bytecode of the method, the offsets in exception table,
+// LineNumberTable, LocalVariableTable and StackMapTable are fixed, but MaxStack and MaxLocals are not computed
+func (self *CodeAttribute) SetInstructions(insts []*Instruction) error {
+	code, mapper, err := assembleInstructions(insts)
+	if err != nil {
+		return err
+	}
+	get := func(offset uint16) uint16 {
+		return uint16(mapper.get(int(offset)))
+	}
+	for _, entry := range self.ExceptionTable {
+		entry.StartPc, entry.EndPc, entry.HandlerPc = get(entry.StartPc), get(entry.EndPc), get(entry.HandlerPc)
+	}
+	fixLocalVariables := func(table []*LocalVariableTableEntry) {
+		for _, entry := range table {
+			start, end := get(entry.StartPc), get(entry.StartPc+entry.Length)
+			entry.StartPc, entry.Length = start, end-start
+		}
+	}
+	fixVerificationTypes := func(types []*VerificationTypeInfo) {
+		for _, t := range types {
+			if t.Tag == VerificationUninitialized {
+				t.Index = get(t.Index)
+			}
+		}
+	}
+	for _, attr := range self.Attributes {
+		switch ret := attr.(type) {
+		case *LineNumberTableAttribute:
+			for _, entry := range ret.LineNumberTable {
+				entry.StartPc = get(entry.StartPc)
+			}
+		case *LocalVariableTableAttribute:
+			fixLocalVariables(ret.LocalVariableTable)
+		case *LocalVariableTypeTableAttribute:
+			fixLocalVariables(ret.LocalVariableTypeTable)
+		case *StackMapTableAttribute:
+			for _, frame := range ret.Entries {
+				frame.Offset = mapper.get(frame.Offset)
+				fixVerificationTypes(frame.Locals)
+				fixVerificationTypes(frame.Stack)
+			}
+		}
+	}
+	self.Code = code
+	return nil
+}
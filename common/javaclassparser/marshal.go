@@ -10,30 +10,33 @@ import (
 )
 
 const (
-	ClassObjectType              = "ClassObject"
-	MemberInfoType               = "MemberInfo"
-	ConstantInteger              = "ConstantInteger"
-	ConstantFloat                = "ConstantFloat"
-	ConstantLong                 = "ConstantLong"
-	ConstantDouble               = "ConstantDouble"
-	ConstantUtf8                 = "ConstantUtf8"
-	ConstantString               = "ConstantString"
-	ConstantClass                = "ConstantClass"
-	ConstantFieldref             = "ConstantFieldref"
-	ConstantMethodref            = "ConstantMethodref"
-	ConstantInterfaceMethodref   = "ConstantInterfaceMethodref"
-	ConstantNameAndType          = "ConstantNameAndType"
-	ConstantMethodType           = "ConstantMethodType"
-	ConstantMethodHandle         = "ConstantMethodHandle"
-	ConstantInvokeDynamic        = "ConstantInvokeDynamic"
-	CodeAttributeType            = "CodeAttribute"
-	ConstantValueAttributeType   = "ConstantValueAttribute"
-	DeprecatedAttributeType      = "DeprecatedAttribute"
-	ExceptionsAttributeType      = "ExceptionsAttribute"
-	LineNumberTableAttributeType = "LineNumberTableAttribute"
-	SourceFileAttributeType      = "SourceFileAttribute"
-	SyntheticAttributeType       = "SyntheticAttribute"
-	UnparsedAttributeType        = "UnparsedAttribute"
+	ClassObjectType                     = "ClassObject"
+	MemberInfoType                      = "MemberInfo"
+	ConstantInteger                     = "ConstantInteger"
+	ConstantFloat                       = "ConstantFloat"
+	ConstantLong                        = "ConstantLong"
+	ConstantDouble                      = "ConstantDouble"
+	ConstantUtf8                        = "ConstantUtf8"
+	ConstantString                      = "ConstantString"
+	ConstantClass                       = "ConstantClass"
+	ConstantFieldref                    = "ConstantFieldref"
+	ConstantMethodref                   = "ConstantMethodref"
+	ConstantInterfaceMethodref          = "ConstantInterfaceMethodref"
+	ConstantNameAndType                 = "ConstantNameAndType"
+	ConstantMethodType                  = "ConstantMethodType"
+	ConstantMethodHandle                = "ConstantMethodHandle"
+	ConstantInvokeDynamic               = "ConstantInvokeDynamic"
+	CodeAttributeType                   = "CodeAttribute"
+	ConstantValueAttributeType          = "ConstantValueAttribute"
+	DeprecatedAttributeType             = "DeprecatedAttribute"
+	ExceptionsAttributeType             = "ExceptionsAttribute"
+	LineNumberTableAttributeType        = "LineNumberTableAttribute"
+	LocalVariableTableAttributeType     = "LocalVariableTableAttribute"
+	LocalVariableTypeTableAttributeType = "LocalVariableTypeTableAttribute"
+	StackMapTableAttributeType          = "StackMapTableAttribute"
+	SourceFileAttributeType             = "SourceFileAttribute"
+	SyntheticAttributeType              = "SyntheticAttribute"
+	UnparsedAttributeType               = "UnparsedAttribute"
 )
 
 func _MarshalJavaClass(cp *ClassObject) []byte {
@@ -153,22 +156,26 @@ func writeAttributes(writer *JavaBufferWriter, info []AttributeInfo, classObj *C
 			codeAttr := info[j].(*CodeAttribute)
 			n := classObj.findUtf8IndexFromPool("Code") + 1
 			writer.Write2Byte(n)
-			writer.Write4Byte(codeAttr.AttrLen)
-			writer.Write2Byte(codeAttr.MaxStack)
-			writer.Write2Byte(codeAttr.MaxLocals)
+			// the length is changed when the instructions are modified
+			body := NewJavaBufferWrite()
+			body.Write2Byte(codeAttr.MaxStack)
+			body.Write2Byte(codeAttr.MaxLocals)
 			codel := len(codeAttr.Code)
-			writer.Write4Byte(codel)
-			writer.Write(codeAttr.Code)
+			body.Write4Byte(codel)
+			body.Write(codeAttr.Code)
 
 			exceptionTable := codeAttr.ExceptionTable
-			writer.Write2Byte(len(exceptionTable))
+			body.Write2Byte(len(exceptionTable))
 			for exceptionTableIndex := 0; exceptionTableIndex < len(exceptionTable); exceptionTableIndex++ {
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].StartPc)
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].EndPc)
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].HandlerPc)
-				writer.Write2Byte(exceptionTable[exceptionTableIndex].CatchType)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].StartPc)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].EndPc)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].HandlerPc)
+				body.Write2Byte(exceptionTable[exceptionTableIndex].CatchType)
 			}
-			writeAttributes(writer, codeAttr.Attributes, classObj)
+			writeAttributes(body, codeAttr.Attributes, classObj)
+			codeAttr.AttrLen = uint32(len(body.Bytes()))
+			writer.Write4Byte(codeAttr.AttrLen)
+			writer.Write(body.Bytes())
 		case *ConstantValueAttribute:
 			n := classObj.findUtf8IndexFromPool("ConstantValue") + 1
 			writer.Write2Byte(n)
@@ -191,13 +198,30 @@ func writeAttributes(writer *JavaBufferWriter, info []AttributeInfo, classObj *C
 		case *LineNumberTableAttribute:
 			n := classObj.findUtf8IndexFromPool("LineNumberTable") + 1
 			writer.Write2Byte(n)
-			writer.Write4Byte(info[j].(*LineNumberTableAttribute).AttrLen)
 			l := len(info[j].(*LineNumberTableAttribute).LineNumberTable)
+			writer.Write4Byte(2 + 4*l)
 			writer.Write2Byte(l)
 			for t := 0; t < l; t++ {
 				writer.Write2Byte(info[j].(*LineNumberTableAttribute).LineNumberTable[t].StartPc)
 				writer.Write2Byte(info[j].(*LineNumberTableAttribute).LineNumberTable[t].LineNumber)
 			}
+		case *LocalVariableTableAttribute:
+			n := classObj.findUtf8IndexFromPool("LocalVariableTable") + 1
+			writer.Write2Byte(n)
+			writeLocalVariableTable(writer, info[j].(*LocalVariableTableAttribute).LocalVariableTable)
+		case *LocalVariableTypeTableAttribute:
+			n := classObj.findUtf8IndexFromPool("LocalVariableTypeTable") + 1
+			writer.Write2Byte(n)
+			writeLocalVariableTable(writer, info[j].(*LocalVariableTypeTableAttribute).LocalVariableTypeTable)
+		case *StackMapTableAttribute:
+			stackMapTable := info[j].(*StackMapTableAttribute)
+			n := classObj.findUtf8IndexFromPool("StackMapTable") + 1
+			writer.Write2Byte(n)
+			body := NewJavaBufferWrite()
+			writeStackMapFrames(body, stackMapTable.Entries)
+			stackMapTable.AttrLen = uint32(len(body.Bytes()))
+			writer.Write4Byte(stackMapTable.AttrLen)
+			writer.Write(body.Bytes())
 		case *SourceFileAttribute:
 			n := classObj.findUtf8IndexFromPool("SourceFile") + 1
 			writer.Write2Byte(n)
@@ -217,6 +241,68 @@ func writeAttributes(writer *JavaBufferWriter, info []AttributeInfo, classObj *C
 
 }
 
+func writeLocalVariableTable(writer *JavaBufferWriter, table []*LocalVariableTableEntry) {
+	writer.Write4Byte(2 + 10*len(table))
+	writer.Write2Byte(len(table))
+	for _, entry := range table {
+		writer.Write2Byte(entry.StartPc)
+		writer.Write2Byte(entry.Length)
+		writer.Write2Byte(entry.NameIndex)
+		writer.Write2Byte(entry.DescriptorIndex)
+		writer.Write2Byte(entry.Index)
+	}
+}
+
+func writeVerificationTypes(writer *JavaBufferWriter, types []*VerificationTypeInfo) {
+	for _, t := range types {
+		writer.Write1Byte(t.Tag)
+		if t.Tag == VerificationObject || t.Tag == VerificationUninitialized {
+			writer.Write2Byte(t.Index)
+		}
+	}
+}
+
+// writeStackMapFrames encode the frames with offset delta, the frame type is kept if the delta can be encoded
+func writeStackMapFrames(writer *JavaBufferWriter, frames []*StackMapFrame) {
+	writer.Write2Byte(len(frames))
+	last := -1
+	for _, frame := range frames {
+		delta := frame.Offset - last - 1
+		last = frame.Offset
+		switch t := frame.FrameType; {
+		case t < 64 || t == 251:
+			if t < 64 && delta < 64 {
+				writer.Write1Byte(delta)
+			} else {
+				writer.Write1Byte(251)
+				writer.Write2Byte(delta)
+			}
+		case t < 128 || t == 247:
+			if t < 128 && delta < 64 {
+				writer.Write1Byte(64 + delta)
+			} else {
+				writer.Write1Byte(247)
+				writer.Write2Byte(delta)
+			}
+			writeVerificationTypes(writer, frame.Stack)
+		case t >= 248 && t <= 250:
+			writer.Write1Byte(t)
+			writer.Write2Byte(delta)
+		case t >= 252 && t <= 254:
+			writer.Write1Byte(251 + len(frame.Locals))
+			writer.Write2Byte(delta)
+			writeVerificationTypes(writer, frame.Locals)
+		default:
+			writer.Write1Byte(255)
+			writer.Write2Byte(delta)
+			writer.Write2Byte(len(frame.Locals))
+			writeVerificationTypes(writer, frame.Locals)
+			writer.Write2Byte(len(frame.Stack))
+			writeVerificationTypes(writer, frame.Stack)
+		}
+	}
+}
+
 func _MarshalToJson(classObj *ClassObject) (string, error) {
 	AddVerboseAndType(classObj, classObj)
 	byteBuf := bytes.NewBuffer([]byte{})
@@ -282,6 +368,12 @@ func AddVerboseAndType(classObj *ClassObject, obj interface{}) {
 		ret.Type = ExceptionsAttributeType
 	case *LineNumberTableAttribute:
 		ret.Type = LineNumberTableAttributeType
+	case *LocalVariableTableAttribute:
+		ret.Type = LocalVariableTableAttributeType
+	case *LocalVariableTypeTableAttribute:
+		ret.Type = LocalVariableTypeTableAttributeType
+	case *StackMapTableAttribute:
+		ret.Type = StackMapTableAttributeType
 	case *SourceFileAttribute:
 		ret.Type = SourceFileAttributeType
 		ret.SourceFileIndexVerbose, _ = classObj.getUtf8(ret.SourceFileIndex)
@@ -452,6 +544,27 @@ func mapToClassObject(objData map[string]interface{}) (_ *ClassObject, err error
 			if err != nil {
 				return err
 			}
+		case LocalVariableTableAttributeType:
+			d := &LocalVariableTableAttribute{}
+			Attributes = append(Attributes, d)
+			err := mapstructure.Decode(objData, d)
+			if err != nil {
+				return err
+			}
+		case LocalVariableTypeTableAttributeType:
+			d := &LocalVariableTypeTableAttribute{}
+			Attributes = append(Attributes, d)
+			err := mapstructure.Decode(objData, d)
+			if err != nil {
+				return err
+			}
+		case StackMapTableAttributeType:
+			d := &StackMapTableAttribute{}
+			Attributes = append(Attributes, d)
+			err := mapstructure.Decode(objData, d)
+			if err != nil {
+				return err
+			}
 		case SourceFileAttributeType:
 			d := &SourceFileAttribute{}
 			Attributes = append(Attributes, d)
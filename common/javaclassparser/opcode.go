@@ -0,0 +1,133 @@
+package javaclassparser
+
+import "fmt"
+
+// the operand format of opcodes
+const (
+	operandNone = iota
+	// s1 immediate, bipush
+	operandByte
+	// s2 immediate, sipush
+	operandShort
+	// u1 unsigned, ldc constant index and newarray type
+	operandUByte
+	// u1 local variable index, u2 after wide
+	operandLocal
+	// u2 constant pool index
+	operandConstant
+	// s2 branch offset
+	operandBranch
+	// s4 branch offset
+	operandBranchWide
+	// local variable index and s1 const, u2 and s2 after wide
+	operandIinc
+	// u2 constant pool index, u1 count and u1 zero
+	operandInvokeInterface
+	// u2 constant pool index and two zero bytes
+	operandInvokeDynamic
+	// u2 constant pool index and u1 dimensions
+	operandMultiANewArray
+	operandTableSwitch
+	operandLookupSwitch
+	operandWide
+)
+
+const (
+	OP_ldc             = 0x12
+	OP_ldc_w           = 0x13
+	OP_iinc            = 0x84
+	OP_tableswitch     = 0xaa
+	OP_lookupswitch    = 0xab
+	OP_invokeinterface = 0xb9
+	OP_invokedynamic   = 0xba
+	OP_newarray        = 0xbc
+	OP_wide            = 0xc4
+	OP_multianewarray  = 0xc5
+)
+
+type opcodeInfo struct {
+	name    string
+	operand int
+}
+
+var (
+	opcodeTable  [256]*opcodeInfo
+	opcodeByName = map[string]uint8{}
+)
+
+// the types of newarray
+var newArrayTypes = map[int]string{4: "boolean", 5: "char", 6: "float", 7: "double", 8: "byte", 9: "short", 10: "int", 11: "long"}
+
+func init() {
+	set := func(op int, name string, operand int) {
+		opcodeTable[op] = &opcodeInfo{name: name, operand: operand}
+		opcodeByName[name] = uint8(op)
+	}
+	names := func(op int, operand int, names ...string) {
+		for i, name := range names {
+			set(op+i, name, operand)
+		}
+	}
+	// xload_0 ... xstore_3
+	shortcut := func(op int, prefixes string, suffix string) {
+		for i, prefix := range prefixes {
+			for n := 0; n < 4; n++ {
+				set(op+i*4+n, fmt.Sprintf("%c%s_%d", prefix, suffix, n), operandNone)
+			}
+		}
+	}
+
+	names(0x00, operandNone, "nop", "aconst_null", "iconst_m1", "iconst_0", "iconst_1", "iconst_2", "iconst_3", "iconst_4", "iconst_5",
+		"lconst_0", "lconst_1", "fconst_0", "fconst_1", "fconst_2", "dconst_0", "dconst_1")
+	set(0x10, "bipush", operandByte)
+	set(0x11, "sipush", operandShort)
+	set(0x12, "ldc", operandUByte)
+	set(0x13, "ldc_w", operandConstant)
+	set(0x14, "ldc2_w", operandConstant)
+	names(0x15, operandLocal, "iload", "lload", "fload", "dload", "aload")
+	shortcut(0x1a, "ilfda", "load")
+	names(0x2e, operandNone, "iaload", "laload", "faload", "daload", "aaload", "baload", "caload", "saload")
+	names(0x36, operandLocal, "istore", "lstore", "fstore", "dstore", "astore")
+	shortcut(0x3b, "ilfda", "store")
+	names(0x4f, operandNone, "iastore", "lastore", "fastore", "dastore", "aastore", "bastore", "castore", "sastore",
+		"pop", "pop2", "dup", "dup_x1", "dup_x2", "dup2", "dup2_x1", "dup2_x2", "swap",
+		"iadd", "ladd", "fadd", "dadd", "isub", "lsub", "fsub", "dsub", "imul", "lmul", "fmul", "dmul",
+		"idiv", "ldiv", "fdiv", "ddiv", "irem", "lrem", "frem", "drem", "ineg", "lneg", "fneg", "dneg",
+		"ishl", "lshl", "ishr", "lshr", "iushr", "lushr", "iand", "land", "ior", "lor", "ixor", "lxor")
+	set(0x84, "iinc", operandIinc)
+	names(0x85, operandNone, "i2l", "i2f", "i2d", "l2i", "l2f", "l2d", "f2i", "f2l", "f2d", "d2i", "d2l", "d2f", "i2b", "i2c", "i2s",
+		"lcmp", "fcmpl", "fcmpg", "dcmpl", "dcmpg")
+	names(0x99, operandBranch, "ifeq", "ifne", "iflt", "ifge", "ifgt", "ifle",
+		"if_icmpeq", "if_icmpne", "if_icmplt", "if_icmpge", "if_icmpgt", "if_icmple", "if_acmpeq", "if_acmpne", "goto", "jsr")
+	set(0xa9, "ret", operandLocal)
+	set(0xaa, "tableswitch", operandTableSwitch)
+	set(0xab, "lookupswitch", operandLookupSwitch)
+	names(0xac, operandNone, "ireturn", "lreturn", "freturn", "dreturn", "areturn", "return")
+	names(0xb2, operandConstant, "getstatic", "putstatic", "getfield", "putfield", "invokevirtual", "invokespecial", "invokestatic")
+	set(0xb9, "invokeinterface", operandInvokeInterface)
+	set(0xba, "invokedynamic", operandInvokeDynamic)
+	set(0xbb, "new", operandConstant)
+	set(0xbc, "newarray", operandUByte)
+	set(0xbd, "anewarray", operandConstant)
+	names(0xbe, operandNone, "arraylength", "athrow")
+	names(0xc0, operandConstant, "checkcast", "instanceof")
+	names(0xc2, operandNone, "monitorenter", "monitorexit")
+	set(0xc4, "wide", operandWide)
+	set(0xc5, "multianewarray", operandMultiANewArray)
+	names(0xc6, operandBranch, "ifnull", "ifnonnull")
+	names(0xc8, operandBranchWide, "goto_w", "jsr_w")
+}
+
+// GetOpcodeName return the mnemonic of opcode
+func GetOpcodeName(op uint8) string {
+	if info := opcodeTable[op]; info != nil {
+		return info.name
+	}
+	return fmt.Sprintf("unknown_%#x", op)
+}
+
+// GetOpcodeByName return the opcode of mnemonic
+func GetOpcodeByName(name string) (uint8, bool) {
+	op, ok := opcodeByName[name]
+	return op, ok
+}
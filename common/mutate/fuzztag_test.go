@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"github.com/yaklang/yaklang/common/phpggc"
+	"github.com/yaklang/yaklang/common/yso"
+	"github.com/yaklang/yaklang/common/ysonet"
 	"sort"
 	"strconv"
 	"strings"
@@ -151,6 +154,53 @@ func TestYsoFuzzTag(t *testing.T) {
 	spew.Dump(result)
 }
 
+func TestYsoFormatFuzzTag(t *testing.T) {
+	for _, format := range []string{"hessian", "xstream", "fastjson", "jackson"} {
+		result := MutateQuick(fmt.Sprintf(`{{yso:%s(whoami)}}`, format))
+		if len(result) != len(yso.GetAllFormatGadget(format)) {
+			t.Fatalf("yso:%s generate %d payloads", format, len(result))
+		}
+	}
+	result := MutateQuick(`{{yso:jndi(ldap://127.0.0.1:1389/a)}}`)
+	if len(result) == 0 || !strings.Contains(result[len(result)-1], "ldap://127.0.0.1:1389/a") {
+		t.Fatalf("yso:jndi generate failed: %v", result)
+	}
+	if result := MutateQuick(`{{yso:jndi()}}`); len(result) != 1 || result[0] != "" {
+		t.Fatalf("yso:jndi should fallback to the input: %v", result)
+	}
+}
+
+func TestPHPGGCFuzzTag(t *testing.T) {
+	result := MutateQuick(`{{phpggc:exec(whoami)}}`)
+	if len(result) != len(phpggc.GetAllRCEChains()) {
+		t.Fatalf("phpggc:exec generate %d payloads", len(result))
+	}
+	for _, r := range result {
+		if !strings.Contains(r, `s:6:"whoami"`) {
+			t.Fatalf("phpggc:exec payload missing command: %v", r)
+		}
+	}
+	result = MutateQuick(`{{phpggc:write(/tmp/a.php|<?php phpinfo();?>)}}`)
+	if len(result) != len(phpggc.GetAllFileWriteChains()) || !strings.Contains(result[0], `s:10:"/tmp/a.php"`) {
+		t.Fatalf("phpggc:write generate failed: %v", result)
+	}
+	result = MutateQuick(`{{phpggc:phar(whoami)}}`)
+	if len(result) == 0 || !strings.Contains(result[0], "__HALT_COMPILER();") {
+		t.Fatalf("phpggc:phar generate failed: %v", result)
+	}
+}
+
+func TestYsoNetFuzzTag(t *testing.T) {
+	result := MutateQuick(`{{ysonet:exec(calc)}}`)
+	if len(result) != len(ysonet.GetAllGadgets()) {
+		t.Fatalf("ysonet:exec generate %d payloads", len(result))
+	}
+	result = MutateQuick(`{{ysonet:losformatter(calc)}}`)
+	if len(result) != len(ysonet.GetAllFormatGadgets(ysonet.FormatBinaryFormatter)) || !strings.HasPrefix(result[0], "/wEy") {
+		t.Fatalf("ysonet:losformatter generate failed: %v", result)
+	}
+}
+
 func TestRegenTag(t *testing.T) {
 	result := MutateQuick(`{{regen(aa*)}}`)
 	println(len(result))
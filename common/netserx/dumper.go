@@ -0,0 +1,247 @@
+package netserx
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dump format the BinaryFormatter records as readable text
+func Dump(records ...NRBFRecord) string {
+	var buf strings.Builder
+	for _, record := range records {
+		dumpRecord(&buf, record, 0)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// DumpBinaryFormatter parse and format the BinaryFormatter stream
+func DumpBinaryFormatter(raw []byte) (string, error) {
+	records, err := ParseBinaryFormatter(raw)
+	if err != nil {
+		return "", err
+	}
+	return Dump(records...), nil
+}
+
+func dumpPrimitive(p *NRBFPrimitive) string {
+	switch ret := p.Value.(type) {
+	case nil:
+		return primitiveName(p.PrimitiveType)
+	case string:
+		return fmt.Sprintf("%s(%s)", primitiveName(p.PrimitiveType), strconv.Quote(ret))
+	default:
+		return fmt.Sprintf("%s(%v)", primitiveName(p.PrimitiveType), ret)
+	}
+}
+
+func dumpMemberType(t *NRBFMemberType) string {
+	switch t.BinaryType {
+	case BinaryTypePrimitive:
+		return primitiveName(t.PrimitiveType)
+	case BinaryTypeString:
+		return "string"
+	case BinaryTypeObject:
+		return "object"
+	case BinaryTypeSystemClass:
+		return t.ClassName
+	case BinaryTypeClass:
+		return fmt.Sprintf("%s (library #%d)", t.ClassName, t.LibraryId)
+	case BinaryTypeObjectArray:
+		return "object[]"
+	case BinaryTypeStringArray:
+		return "string[]"
+	case BinaryTypePrimitiveArray:
+		return primitiveName(t.PrimitiveType) + "[]"
+	}
+	return fmt.Sprintf("binary(%d)", t.BinaryType)
+}
+
+func dumpRecord(buf *strings.Builder, record NRBFRecord, level int) {
+	indent := strings.Repeat("  ", level+1)
+	end := strings.Repeat("  ", level)
+	switch ret := record.(type) {
+	case nil:
+		buf.WriteString("null")
+	case *NRBFHeader:
+		buf.WriteString(fmt.Sprintf("SerializedStreamHeader root: #%d, header: %d, version: %d.%d", ret.RootId, ret.HeaderId, ret.MajorVersion, ret.MinorVersion))
+	case *NRBFLibrary:
+		buf.WriteString(fmt.Sprintf("Library #%d %s", ret.LibraryId, strconv.Quote(ret.LibraryName)))
+	case *NRBFString:
+		buf.WriteString(fmt.Sprintf("#%d %s", ret.ObjectId, strconv.Quote(ret.Value)))
+	case *NRBFPrimitive:
+		buf.WriteString(dumpPrimitive(ret))
+	case *NRBFReference:
+		buf.WriteString(fmt.Sprintf("ref #%d", ret.IdRef))
+	case *NRBFNull:
+		if ret.Count == 1 {
+			buf.WriteString("null")
+		} else {
+			buf.WriteString(fmt.Sprintf("null x %d", ret.Count))
+		}
+	case *NRBFMessageEnd:
+		buf.WriteString("MessageEnd")
+	case *NRBFClass:
+		buf.WriteString(fmt.Sprintf("%s #%d %s", recordName(ret.RecordType), ret.ObjectId, ret.Name))
+		if ret.RecordType == RecordClassWithId {
+			buf.WriteString(fmt.Sprintf(" (metadata #%d)", ret.MetadataId))
+		}
+		if ret.LibraryId != 0 {
+			buf.WriteString(fmt.Sprintf(" (library #%d)", ret.LibraryId))
+		}
+		buf.WriteString(" {")
+		if len(ret.Values) == 0 {
+			buf.WriteString("}")
+			return
+		}
+		buf.WriteString("\n")
+		slot := 0
+		for _, value := range ret.Values {
+			buf.WriteString(indent)
+			if _, ok := value.(*NRBFLibrary); !ok && slot < len(ret.MemberNames) {
+				buf.WriteString(ret.MemberNames[slot])
+				if ret.MemberTypes != nil && slot < len(ret.MemberTypes) {
+					buf.WriteString(" <" + dumpMemberType(ret.MemberTypes[slot]) + ">")
+				}
+				buf.WriteString(": ")
+			}
+			dumpRecord(buf, value, level+1)
+			buf.WriteString("\n")
+			slot += countSlots([]NRBFRecord{value})
+		}
+		buf.WriteString(end + "}")
+	case *NRBFArray:
+		var lengths []string
+		for _, length := range ret.Lengths {
+			lengths = append(lengths, strconv.Itoa(int(length)))
+		}
+		itemType := "object"
+		if ret.ItemType != nil {
+			itemType = dumpMemberType(ret.ItemType)
+		}
+		buf.WriteString(fmt.Sprintf("%s #%d %s[%s] [", recordName(ret.RecordType), ret.ObjectId, itemType, strings.Join(lengths, ",")))
+		if len(ret.Values) == 0 {
+			buf.WriteString("]")
+			return
+		}
+		buf.WriteString("\n")
+		for _, value := range ret.Values {
+			buf.WriteString(indent)
+			dumpRecord(buf, value, level+1)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(end + "]")
+	default:
+		buf.WriteString(fmt.Sprintf("%T", record))
+	}
+}
+
+// DumpObjectState format the ObjectStateFormatter node as readable text, the BinarySerialized data is dumped as BinaryFormatter
+func DumpObjectState(node *ObjectStateNode) string {
+	var buf strings.Builder
+	dumpNode(&buf, node, 0)
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+// DumpLosFormatter parse and format the data of LosFormatter or ViewState
+// Example:
+// ```
+// println(dotnet.DumpLosFormatter(viewstate)~)
+// ```
+func DumpLosFormatter(data string) (string, error) {
+	node, rest, err := ParseLosFormatter(data)
+	if err != nil {
+		return "", err
+	}
+	ret := DumpObjectState(node)
+	if len(rest) > 0 {
+		ret += fmt.Sprintf("rest(%d) %x\n", len(rest), rest)
+	}
+	return ret, nil
+}
+
+func dumpNode(buf *strings.Builder, node *ObjectStateNode, level int) {
+	indent := strings.Repeat("  ", level+1)
+	end := strings.Repeat("  ", level)
+	if node == nil {
+		buf.WriteString("Null")
+		return
+	}
+	buf.WriteString(tokenName(node.Token))
+	if node.TypeName != "" {
+		buf.WriteString("<" + node.TypeName + ">")
+	}
+	switch node.Token {
+	case TokenNull, TokenEmptyColor, TokenEmptyUnit, TokenType, TokenTrue, TokenFalse:
+		return
+	case TokenBinarySerialized:
+		raw, _ := node.Value.([]byte)
+		buf.WriteString(fmt.Sprintf("(%d) ", len(raw)))
+		if len(node.Binary) == 0 {
+			buf.WriteString(strconv.Quote(string(raw)))
+			return
+		}
+		buf.WriteString("[\n")
+		for _, record := range node.Binary {
+			buf.WriteString(indent)
+			dumpRecord(buf, record, level+1)
+			buf.WriteString("\n")
+		}
+		buf.WriteString(end + "]")
+		return
+	case TokenUnit:
+		buf.WriteString(fmt.Sprintf("(%v, type: %d)", node.Value, node.UnitType))
+		return
+	}
+	if len(node.Items) == 0 && node.Token != TokenArray && node.Token != TokenArrayList &&
+		node.Token != TokenHashtable && node.Token != TokenHybridDictionary && node.Token != TokenSparseArray {
+		switch ret := node.Value.(type) {
+		case string:
+			buf.WriteString("(" + strconv.Quote(ret) + ")")
+		case []string:
+			raw, _ := json.Marshal(ret)
+			buf.WriteString(string(raw))
+		default:
+			buf.WriteString(fmt.Sprintf("(%v)", ret))
+		}
+		return
+	}
+	if node.Token == TokenSparseArray {
+		buf.WriteString(fmt.Sprintf("(%d)", node.Length))
+	}
+	buf.WriteString(" [")
+	if len(node.Items) == 0 {
+		buf.WriteString("]")
+		return
+	}
+	buf.WriteString("\n")
+	dictionary := node.Token == TokenHashtable || node.Token == TokenHybridDictionary
+	for i, item := range node.Items {
+		if dictionary && i%2 == 1 {
+			continue
+		}
+		buf.WriteString(indent)
+		if node.Token == TokenSparseArray && i < len(node.Indexes) {
+			buf.WriteString(fmt.Sprintf("%d: ", node.Indexes[i]))
+		}
+		dumpNode(buf, item, level+1)
+		if dictionary {
+			buf.WriteString(" => ")
+			var value *ObjectStateNode
+			if i+1 < len(node.Items) {
+				value = node.Items[i+1]
+			}
+			dumpNode(buf, value, level+1)
+		}
+		buf.WriteString(",\n")
+	}
+	buf.WriteString(end + "]")
+}
+
+// ToJson convert the BinaryFormatter records or ObjectStateFormatter node to json for inspection
+func ToJson(i any) ([]byte, error) {
+	return json.MarshalIndent(i, "", "  ")
+}
@@ -0,0 +1,66 @@
+package netserx
+
+var Exports = map[string]interface{}{
+	// BinaryFormatter
+	"ParseBinaryFormatter":   ParseBinaryFormatter,
+	"MarshalBinaryFormatter": MarshalBinaryFormatter,
+	"DumpBinaryFormatter":    DumpBinaryFormatter,
+	"Dump":                   Dump,
+	"ToJson":                 ToJson,
+
+	"NewNRBFHeader":                   NewNRBFHeader,
+	"NewNRBFLibrary":                  NewNRBFLibrary,
+	"NewNRBFClass":                    NewNRBFClass,
+	"NewNRBFClassWithId":              NewNRBFClassWithId,
+	"NewNRBFString":                   NewNRBFString,
+	"NewNRBFPrimitive":                NewNRBFPrimitive,
+	"NewNRBFInt32":                    NewNRBFInt32,
+	"NewNRBFBoolean":                  NewNRBFBoolean,
+	"NewNRBFReference":                NewNRBFReference,
+	"NewNRBFNull":                     NewNRBFNull,
+	"NewNRBFNullMultiple":             NewNRBFNullMultiple,
+	"NewNRBFStringArray":              NewNRBFStringArray,
+	"NewNRBFObjectArray":              NewNRBFObjectArray,
+	"NewNRBFPrimitiveArray":           NewNRBFPrimitiveArray,
+	"NewNRBFMessageEnd":               NewNRBFMessageEnd,
+	"NewNRBFPrimitiveMemberType":      NewNRBFPrimitiveMemberType,
+	"NewNRBFStringMemberType":         NewNRBFStringMemberType,
+	"NewNRBFObjectMemberType":         NewNRBFObjectMemberType,
+	"NewNRBFSystemClassMemberType":    NewNRBFSystemClassMemberType,
+	"NewNRBFClassMemberType":          NewNRBFClassMemberType,
+	"NewNRBFObjectArrayMemberType":    NewNRBFObjectArrayMemberType,
+	"NewNRBFStringArrayMemberType":    NewNRBFStringArrayMemberType,
+	"NewNRBFPrimitiveArrayMemberType": NewNRBFPrimitiveArrayMemberType,
+
+	// ObjectStateFormatter / LosFormatter
+	"ParseObjectStateFormatter":   ParseObjectStateFormatter,
+	"MarshalObjectStateFormatter": MarshalObjectStateFormatter,
+	"ParseLosFormatter":           ParseLosFormatter,
+	"MarshalLosFormatter":         MarshalLosFormatter,
+	"DumpObjectState":             DumpObjectState,
+	"DumpLosFormatter":            DumpLosFormatter,
+	"NewObjectStateNode":          NewObjectStateNode,
+	"NewObjectStateNull":          NewObjectStateNull,
+	"NewObjectStateString":        NewObjectStateString,
+	"NewObjectStateInt32":         NewObjectStateInt32,
+	"NewObjectStateBool":          NewObjectStateBool,
+	"NewObjectStatePair":          NewObjectStatePair,
+	"NewObjectStateTriplet":       NewObjectStateTriplet,
+	"NewObjectStateArrayList":     NewObjectStateArrayList,
+	"NewObjectStateBinary":        NewObjectStateBinary,
+
+	// ViewState
+	"SignViewState":    SignViewState,
+	"VerifyViewState":  VerifyViewState,
+	"EncryptViewState": EncryptViewState,
+	"DecryptViewState": DecryptViewState,
+
+	// viewstate options
+	"validationKey":    WithViewStateValidationKey,
+	"validationAlg":    WithViewStateValidationAlg,
+	"decryptionKey":    WithViewStateDecryptionKey,
+	"decryptionAlg":    WithViewStateDecryptionAlg,
+	"generator":        WithViewStateGenerator,
+	"page":             WithViewStatePage,
+	"viewStateUserKey": WithViewStateUserKey,
+}
@@ -0,0 +1,568 @@
+package netserx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// tokens of ObjectStateFormatter, which is the format of ViewState and LosFormatter
+const (
+	TokenInt16            byte = 1
+	TokenInt32            byte = 2
+	TokenByte             byte = 3
+	TokenChar             byte = 4
+	TokenString           byte = 5
+	TokenDateTime         byte = 6
+	TokenDouble           byte = 7
+	TokenSingle           byte = 8
+	TokenColor            byte = 9
+	TokenKnownColor       byte = 10
+	TokenIntEnum          byte = 11
+	TokenEmptyColor       byte = 12
+	TokenPair             byte = 15
+	TokenTriplet          byte = 16
+	TokenArray            byte = 20
+	TokenStringArray      byte = 21
+	TokenArrayList        byte = 22
+	TokenHashtable        byte = 23
+	TokenHybridDictionary byte = 24
+	TokenType             byte = 25
+	TokenUnit             byte = 27
+	TokenEmptyUnit        byte = 28
+	TokenIndexedStringAdd byte = 30
+	TokenIndexedString    byte = 31
+	TokenStringFormatted  byte = 40
+	TokenTypeRefAdd       byte = 41
+	TokenTypeRefAddLocal  byte = 42
+	TokenTypeRef          byte = 43
+	TokenBinarySerialized byte = 50
+	TokenSparseArray      byte = 60
+	TokenNull             byte = 100
+	TokenEmptyString      byte = 101
+	TokenZeroInt32        byte = 102
+	TokenTrue             byte = 103
+	TokenFalse            byte = 104
+)
+
+var tokenNames = map[byte]string{
+	TokenInt16:            "Int16",
+	TokenInt32:            "Int32",
+	TokenByte:             "Byte",
+	TokenChar:             "Char",
+	TokenString:           "String",
+	TokenDateTime:         "DateTime",
+	TokenDouble:           "Double",
+	TokenSingle:           "Single",
+	TokenColor:            "Color",
+	TokenKnownColor:       "KnownColor",
+	TokenIntEnum:          "IntEnum",
+	TokenEmptyColor:       "EmptyColor",
+	TokenPair:             "Pair",
+	TokenTriplet:          "Triplet",
+	TokenArray:            "Array",
+	TokenStringArray:      "StringArray",
+	TokenArrayList:        "ArrayList",
+	TokenHashtable:        "Hashtable",
+	TokenHybridDictionary: "HybridDictionary",
+	TokenType:             "Type",
+	TokenUnit:             "Unit",
+	TokenEmptyUnit:        "EmptyUnit",
+	TokenIndexedStringAdd: "IndexedStringAdd",
+	TokenIndexedString:    "IndexedString",
+	TokenStringFormatted:  "StringFormatted",
+	TokenBinarySerialized: "BinarySerialized",
+	TokenSparseArray:      "SparseArray",
+	TokenNull:             "Null",
+	TokenEmptyString:      "EmptyString",
+	TokenZeroInt32:        "ZeroInt32",
+	TokenTrue:             "True",
+	TokenFalse:            "False",
+}
+
+func tokenName(t byte) string {
+	if name, ok := tokenNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Token(%d)", t)
+}
+
+// ObjectStateNode is the value in ObjectStateFormatter stream.
+// Value is int64 (Int16, Int32, Byte, DateTime, Color, KnownColor, IntEnum), float64 (Double, Single, Unit), bool (True, False),
+// string (Char, String, IndexedString, StringFormatted), []string (StringArray) or []byte (BinarySerialized).
+// Items are the children of Pair, Triplet, Array, ArrayList and SparseArray, the keys and values of Hashtable and
+// HybridDictionary are in turn. TypeName is used by IntEnum, Array, SparseArray, StringFormatted and Type, TypeToken is
+// TypeRefAddLocal for the types in System.Web. Binary is the parsed BinaryFormatter stream of BinarySerialized, it is not used by marshal.
+type ObjectStateNode struct {
+	Type      string             `json:"type"`
+	Token     byte               `json:"token"`
+	TypeName  string             `json:"type_name,omitempty"`
+	TypeToken byte               `json:"type_token,omitempty"`
+	Value     any                `json:"value,omitempty"`
+	UnitType  int32              `json:"unit_type,omitempty"`
+	Length    int                `json:"length,omitempty"`
+	Indexes   []int              `json:"indexes,omitempty"`
+	Items     []*ObjectStateNode `json:"items,omitempty"`
+	Binary    []NRBFRecord       `json:"binary,omitempty"`
+}
+
+func NewObjectStateNode(token byte, value any, items ...*ObjectStateNode) *ObjectStateNode {
+	return &ObjectStateNode{Type: tokenName(token), Token: token, Value: value, Items: items}
+}
+
+func NewObjectStateNull() *ObjectStateNode {
+	return NewObjectStateNode(TokenNull, nil)
+}
+
+func NewObjectStateString(s string) *ObjectStateNode {
+	if s == "" {
+		return NewObjectStateNode(TokenEmptyString, "")
+	}
+	return NewObjectStateNode(TokenString, s)
+}
+
+func NewObjectStateInt32(i int) *ObjectStateNode {
+	if i == 0 {
+		return NewObjectStateNode(TokenZeroInt32, int64(0))
+	}
+	return NewObjectStateNode(TokenInt32, int64(i))
+}
+
+func NewObjectStateBool(b bool) *ObjectStateNode {
+	if b {
+		return NewObjectStateNode(TokenTrue, true)
+	}
+	return NewObjectStateNode(TokenFalse, false)
+}
+
+func NewObjectStatePair(first, second *ObjectStateNode) *ObjectStateNode {
+	return NewObjectStateNode(TokenPair, nil, first, second)
+}
+
+func NewObjectStateTriplet(first, second, third *ObjectStateNode) *ObjectStateNode {
+	return NewObjectStateNode(TokenTriplet, nil, first, second, third)
+}
+
+func NewObjectStateArrayList(items ...*ObjectStateNode) *ObjectStateNode {
+	return NewObjectStateNode(TokenArrayList, nil, items...)
+}
+
+// NewObjectStateBinary create BinarySerialized node, raw is the BinaryFormatter stream which is deserialized by ObjectStateFormatter
+func NewObjectStateBinary(raw []byte) *ObjectStateNode {
+	return NewObjectStateNode(TokenBinarySerialized, raw)
+}
+
+type objectStateParser struct {
+	*netReader
+	types   []string
+	strings []string
+}
+
+// ParseObjectStateFormatter parse the ObjectStateFormatter stream (0xff 0x01 ...), the data after the root value
+// such as the MAC of ViewState is returned as rest
+func ParseObjectStateFormatter(raw []byte) (*ObjectStateNode, []byte, error) {
+	p := &objectStateParser{netReader: &netReader{name: "ObjectStateFormatter", raw: raw}}
+	if len(raw) < 2 || raw[0] != 0xff || raw[1] != 0x01 {
+		return nil, nil, utils.Error("ObjectStateFormatter format marker 0xff 0x01 not found")
+	}
+	p.pos = 2
+	node, err := p.readNode()
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, p.raw[p.pos:], nil
+}
+
+// ParseLosFormatter parse the base64 encoded data of LosFormatter or ViewState, the url encoded ViewState is also accepted,
+// the MAC is returned as rest, the encrypted ViewState should be decrypted by DecryptViewState first
+// Example:
+// ```
+// node, mac = dotnet.ParseLosFormatter(viewstate)~
+// println(dotnet.DumpObjectState(node))
+// ```
+func ParseLosFormatter(data string) (*ObjectStateNode, []byte, error) {
+	raw, err := decodeViewState(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ParseObjectStateFormatter(raw)
+}
+
+func decodeViewState(data string) ([]byte, error) {
+	data = strings.TrimSpace(data)
+	if strings.Contains(data, "%") {
+		if unescaped, err := url.PathUnescape(data); err == nil {
+			data = unescaped
+		}
+	}
+	data = strings.TrimRight(data, "=")
+	raw, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil {
+		raw, err = base64.RawURLEncoding.DecodeString(data)
+	}
+	if err != nil {
+		return nil, utils.Errorf("decode viewstate base64 failed: %v", err)
+	}
+	return raw, nil
+}
+
+func (p *objectStateParser) readType() (string, byte, error) {
+	t, err := p.readByte()
+	if err != nil {
+		return "", 0, err
+	}
+	switch t {
+	case TokenTypeRefAdd, TokenTypeRefAddLocal:
+		name, err := p.readString()
+		if err != nil {
+			return "", 0, err
+		}
+		p.types = append(p.types, name)
+		return name, t, nil
+	case TokenTypeRef:
+		index, err := p.read7BitInt()
+		if err != nil {
+			return "", 0, err
+		}
+		if index < 0 || index >= len(p.types) {
+			return "", 0, p.errorf("type ref %d not found", index)
+		}
+		return p.types[index], t, nil
+	}
+	return "", 0, p.errorf("bad type token %d", t)
+}
+
+func (p *objectStateParser) readNodes(node *ObjectStateNode, count int) error {
+	if count < 0 || count > len(p.raw)-p.pos {
+		return p.errorf("bad item count %d", count)
+	}
+	for i := 0; i < count; i++ {
+		item, err := p.readNode()
+		if err != nil {
+			return err
+		}
+		node.Items = append(node.Items, item)
+	}
+	return nil
+}
+
+func (p *objectStateParser) readNode() (*ObjectStateNode, error) {
+	t, err := p.readByte()
+	if err != nil {
+		return nil, err
+	}
+	node := NewObjectStateNode(t, nil)
+	switch t {
+	case TokenInt16:
+		var i uint16
+		i, err = p.readUint16()
+		node.Value = int64(int16(i))
+	case TokenInt32, TokenKnownColor:
+		var i int
+		i, err = p.read7BitInt()
+		node.Value = int64(i)
+	case TokenByte:
+		var b byte
+		b, err = p.readByte()
+		node.Value = int64(b)
+	case TokenChar:
+		node.Value, err = p.readChar()
+	case TokenString, TokenIndexedStringAdd:
+		var s string
+		s, err = p.readString()
+		node.Value = s
+		if t == TokenIndexedStringAdd {
+			p.strings = append(p.strings, s)
+		}
+	case TokenIndexedString:
+		var index byte
+		if index, err = p.readByte(); err == nil {
+			if int(index) >= len(p.strings) {
+				return nil, p.errorf("indexed string %d not found", index)
+			}
+			node.Value = p.strings[index]
+		}
+	case TokenDateTime:
+		var i uint64
+		i, err = p.readUint64()
+		node.Value = int64(i)
+	case TokenDouble:
+		node.Value, err = p.readFloat64()
+	case TokenSingle:
+		var f float32
+		f, err = p.readFloat32()
+		node.Value = float64(f)
+	case TokenColor:
+		var i int32
+		i, err = p.readInt32()
+		node.Value = int64(i)
+	case TokenIntEnum:
+		if node.TypeName, node.TypeToken, err = p.readType(); err != nil {
+			return nil, err
+		}
+		var i int
+		i, err = p.read7BitInt()
+		node.Value = int64(i)
+	case TokenPair:
+		err = p.readNodes(node, 2)
+	case TokenTriplet:
+		err = p.readNodes(node, 3)
+	case TokenArray:
+		if node.TypeName, node.TypeToken, err = p.readType(); err != nil {
+			return nil, err
+		}
+		var count int
+		if count, err = p.read7BitInt(); err == nil {
+			err = p.readNodes(node, count)
+		}
+	case TokenStringArray:
+		var count int
+		if count, err = p.read7BitInt(); err != nil {
+			return nil, err
+		}
+		if count < 0 || count > len(p.raw)-p.pos {
+			return nil, p.errorf("bad string array length %d", count)
+		}
+		values := make([]string, count)
+		for i := range values {
+			if values[i], err = p.readString(); err != nil {
+				return nil, err
+			}
+		}
+		node.Value = values
+	case TokenArrayList:
+		var count int
+		if count, err = p.read7BitInt(); err == nil {
+			err = p.readNodes(node, count)
+		}
+	case TokenHashtable, TokenHybridDictionary:
+		var count int
+		if count, err = p.read7BitInt(); err == nil {
+			if count < 0 || count > len(p.raw)-p.pos {
+				return nil, p.errorf("bad dictionary size %d", count)
+			}
+			err = p.readNodes(node, count*2)
+		}
+	case TokenType:
+		node.TypeName, node.TypeToken, err = p.readType()
+	case TokenUnit:
+		if node.Value, err = p.readFloat64(); err != nil {
+			return nil, err
+		}
+		node.UnitType, err = p.readInt32()
+	case TokenStringFormatted:
+		if node.TypeName, node.TypeToken, err = p.readType(); err != nil {
+			return nil, err
+		}
+		node.Value, err = p.readString()
+	case TokenBinarySerialized:
+		var length int
+		if length, err = p.read7BitInt(); err != nil {
+			return nil, err
+		}
+		var raw []byte
+		if raw, err = p.readBytes(length); err != nil {
+			return nil, err
+		}
+		node.Value = raw
+		node.Binary, _ = ParseBinaryFormatter(raw)
+	case TokenSparseArray:
+		if node.TypeName, node.TypeToken, err = p.readType(); err != nil {
+			return nil, err
+		}
+		if node.Length, err = p.read7BitInt(); err != nil {
+			return nil, err
+		}
+		var count int
+		if count, err = p.read7BitInt(); err != nil {
+			return nil, err
+		}
+		if count < 0 || count > len(p.raw)-p.pos {
+			return nil, p.errorf("bad sparse array size %d", count)
+		}
+		for i := 0; i < count; i++ {
+			index, err := p.read7BitInt()
+			if err != nil {
+				return nil, err
+			}
+			item, err := p.readNode()
+			if err != nil {
+				return nil, err
+			}
+			node.Indexes = append(node.Indexes, index)
+			node.Items = append(node.Items, item)
+		}
+	case TokenTrue:
+		node.Value = true
+	case TokenFalse:
+		node.Value = false
+	case TokenZeroInt32:
+		node.Value = int64(0)
+	case TokenEmptyString:
+		node.Value = ""
+	case TokenNull, TokenEmptyColor, TokenEmptyUnit:
+	default:
+		return nil, p.errorf("unsupported token %d", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+type objectStateWriter struct {
+	buf     bytes.Buffer
+	types   map[string]int
+	strings map[string]int
+}
+
+// MarshalObjectStateFormatter serialize the node to ObjectStateFormatter stream, the type and indexed string tables
+// are rebuilt, so the repeated types and strings are written as references like .NET does
+func MarshalObjectStateFormatter(node *ObjectStateNode) []byte {
+	w := &objectStateWriter{types: make(map[string]int), strings: make(map[string]int)}
+	w.buf.Write([]byte{0xff, 0x01})
+	w.writeNode(node)
+	return w.buf.Bytes()
+}
+
+// MarshalLosFormatter serialize the node to the base64 encoded ObjectStateFormatter stream, which is the output of LosFormatter
+// Example:
+// ```
+// node = dotnet.NewObjectStatePair(dotnet.NewObjectStateString("a"), dotnet.NewObjectStateNull())
+// viewstate = dotnet.MarshalLosFormatter(node)
+// ```
+func MarshalLosFormatter(node *ObjectStateNode) string {
+	return base64.StdEncoding.EncodeToString(MarshalObjectStateFormatter(node))
+}
+
+func (w *objectStateWriter) writeType(node *ObjectStateNode) {
+	if index, ok := w.types[node.TypeName]; ok {
+		w.buf.WriteByte(TokenTypeRef)
+		write7BitInt(&w.buf, index)
+		return
+	}
+	w.types[node.TypeName] = len(w.types)
+	if node.TypeToken == TokenTypeRefAddLocal {
+		w.buf.WriteByte(TokenTypeRefAddLocal)
+	} else {
+		w.buf.WriteByte(TokenTypeRefAdd)
+	}
+	writeString(&w.buf, node.TypeName)
+}
+
+func (w *objectStateWriter) writeNode(node *ObjectStateNode) {
+	if node == nil {
+		w.buf.WriteByte(TokenNull)
+		return
+	}
+	switch node.Token {
+	case TokenIndexedString, TokenIndexedStringAdd:
+		s := primitiveToString(node.Value)
+		if index, ok := w.strings[s]; ok && index < 256 {
+			w.buf.WriteByte(TokenIndexedString)
+			w.buf.WriteByte(byte(index))
+			return
+		}
+		w.strings[s] = len(w.strings)
+		w.buf.WriteByte(TokenIndexedStringAdd)
+		writeString(&w.buf, s)
+		return
+	}
+	w.buf.WriteByte(node.Token)
+	switch node.Token {
+	case TokenInt16:
+		writeInt16(&w.buf, int16(primitiveToInt64(node.Value)))
+	case TokenInt32, TokenKnownColor:
+		write7BitInt(&w.buf, int(primitiveToInt64(node.Value)))
+	case TokenByte:
+		w.buf.WriteByte(byte(primitiveToInt64(node.Value)))
+	case TokenChar:
+		s := primitiveToString(node.Value)
+		if s == "" {
+			s = "\x00"
+		}
+		w.buf.WriteString(s)
+	case TokenString:
+		writeString(&w.buf, primitiveToString(node.Value))
+	case TokenDateTime:
+		writeInt64(&w.buf, primitiveToInt64(node.Value))
+	case TokenDouble:
+		writeFloat64(&w.buf, primitiveToFloat64(node.Value))
+	case TokenSingle:
+		writeFloat32(&w.buf, float32(primitiveToFloat64(node.Value)))
+	case TokenColor:
+		writeInt32(&w.buf, int32(primitiveToInt64(node.Value)))
+	case TokenIntEnum:
+		w.writeType(node)
+		write7BitInt(&w.buf, int(primitiveToInt64(node.Value)))
+	case TokenPair, TokenTriplet:
+		size := 2
+		if node.Token == TokenTriplet {
+			size = 3
+		}
+		for i := 0; i < size; i++ {
+			var item *ObjectStateNode
+			if i < len(node.Items) {
+				item = node.Items[i]
+			}
+			w.writeNode(item)
+		}
+	case TokenArray:
+		w.writeType(node)
+		write7BitInt(&w.buf, len(node.Items))
+		w.writeItems(node.Items)
+	case TokenStringArray:
+		values, _ := node.Value.([]string)
+		write7BitInt(&w.buf, len(values))
+		for _, s := range values {
+			writeString(&w.buf, s)
+		}
+	case TokenArrayList:
+		write7BitInt(&w.buf, len(node.Items))
+		w.writeItems(node.Items)
+	case TokenHashtable, TokenHybridDictionary:
+		items := node.Items
+		if len(items)%2 != 0 {
+			items = append(items, nil)
+		}
+		write7BitInt(&w.buf, len(items)/2)
+		w.writeItems(items)
+	case TokenType:
+		w.writeType(node)
+	case TokenUnit:
+		writeFloat64(&w.buf, primitiveToFloat64(node.Value))
+		writeInt32(&w.buf, node.UnitType)
+	case TokenStringFormatted:
+		w.writeType(node)
+		writeString(&w.buf, primitiveToString(node.Value))
+	case TokenBinarySerialized:
+		raw, _ := node.Value.([]byte)
+		if raw == nil && len(node.Binary) > 0 {
+			raw = MarshalBinaryFormatter(node.Binary...)
+		}
+		write7BitInt(&w.buf, len(raw))
+		w.buf.Write(raw)
+	case TokenSparseArray:
+		w.writeType(node)
+		write7BitInt(&w.buf, node.Length)
+		write7BitInt(&w.buf, len(node.Items))
+		for i, item := range node.Items {
+			index := i
+			if i < len(node.Indexes) {
+				index = node.Indexes[i]
+			}
+			write7BitInt(&w.buf, index)
+			w.writeNode(item)
+		}
+	}
+}
+
+func (w *objectStateWriter) writeItems(items []*ObjectStateNode) {
+	for _, item := range items {
+		w.writeNode(item)
+	}
+}
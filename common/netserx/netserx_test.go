@@ -0,0 +1,195 @@
+package netserx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestBinaryFormatterRoundTrip(t *testing.T) {
+	// BinaryFormatter.Serialize(stream, "hello")
+	raw := []byte("\x00\x01\x00\x00\x00\xff\xff\xff\xff\x01\x00\x00\x00\x00\x00\x00\x00\x06\x01\x00\x00\x00\x05hello\x0b")
+	records, err := ParseBinaryFormatter(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[1].(*NRBFString).Value != "hello" {
+		t.Fatalf("bad records: %s", Dump(records...))
+	}
+	if !bytes.Equal(MarshalBinaryFormatter(records...), raw) {
+		t.Fatal("marshal string stream mismatch")
+	}
+
+	class := NewNRBFClass(1, "Test.Foo", 2).
+		AddMember("a", NewNRBFPrimitiveMemberType(PrimitiveInt32), NewNRBFInt32(-1)).
+		AddMember("b", NewNRBFPrimitiveMemberType(PrimitiveDouble), NewNRBFPrimitive(PrimitiveDouble, 1.5)).
+		AddMember("c", NewNRBFPrimitiveMemberType(PrimitiveChar), NewNRBFPrimitive(PrimitiveChar, "中")).
+		AddMember("d", NewNRBFPrimitiveMemberType(PrimitiveBoolean), NewNRBFBoolean(true)).
+		AddMember("e", NewNRBFStringMemberType(), NewNRBFString(3, "e")).
+		AddMember("f", NewNRBFObjectMemberType(), NewNRBFPrimitive(PrimitiveInt64, int64(1)<<40)).
+		AddMember("g", NewNRBFClassMemberType("Test.Foo", 2), NewNRBFReference(4)).
+		AddMember("h", NewNRBFObjectArrayMemberType(), NewNRBFObjectArray(5, NewNRBFString(6, "x"), NewNRBFNullMultiple(3), NewNRBFReference(3))).
+		AddMember("i", NewNRBFPrimitiveArrayMemberType(PrimitiveInt16), NewNRBFPrimitiveArray(7, PrimitiveInt16, NewNRBFPrimitive(PrimitiveInt16, -2), NewNRBFPrimitive(PrimitiveInt16, 3)))
+	class.MemberNames = append(class.MemberNames, "j", "k")
+	class.MemberTypes = append(class.MemberTypes, NewNRBFObjectMemberType(), NewNRBFObjectMemberType())
+	class.Values = append(class.Values, NewNRBFNullMultiple(2))
+	records = []NRBFRecord{
+		NewNRBFHeader(1),
+		NewNRBFLibrary(2, "Test, Version=1.0.0.0"),
+		class,
+		NewNRBFClassWithId(4, class,
+			NewNRBFInt32(1), NewNRBFPrimitive(PrimitiveDouble, 0), NewNRBFPrimitive(PrimitiveChar, "a"), NewNRBFBoolean(false),
+			NewNRBFNull(), NewNRBFNull(), NewNRBFNull(), NewNRBFStringArray(8, NewNRBFString(9, "y")), NewNRBFNull(),
+			NewNRBFNullMultiple(2)),
+		NewNRBFMessageEnd(),
+	}
+	raw = MarshalBinaryFormatter(records...)
+	parsed, err := ParseBinaryFormatter(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(MarshalBinaryFormatter(parsed...), raw) {
+		t.Fatalf("class round trip mismatch:\n%s", Dump(parsed...))
+	}
+	foo := parsed[2].(*NRBFClass)
+	if c, ok := foo.GetMember("c"); !ok || c.(*NRBFPrimitive).Value != "中" {
+		t.Fatalf("bad member c: %v", c)
+	}
+	if k, ok := foo.GetMember("k"); !ok || k.(*NRBFNull).Count != 2 {
+		t.Fatalf("bad member k: %v", k)
+	}
+	if array := parsed[3].(*NRBFClass).Values[7].(*NRBFArray); array.Values[0].(*NRBFString).Value != "y" {
+		t.Fatalf("bad class with id: %s", Dump(parsed[3]))
+	}
+	dump := Dump(parsed...)
+	for _, keyword := range []string{"ClassWithMembersAndTypes #1 Test.Foo (library #2)", `c <char>: char("中")`, "null x 3", "ClassWithId #4 Test.Foo (metadata #1)"} {
+		if !strings.Contains(dump, keyword) {
+			t.Fatalf("dump missing %q:\n%s", keyword, dump)
+		}
+	}
+	if _, err := ParseBinaryFormatter(raw[:len(raw)-10]); err == nil {
+		t.Fatal("truncated stream should fail")
+	}
+}
+
+func TestObjectStateFormatter(t *testing.T) {
+	// the viewstate of an empty page: Pair(Pair("-126645944", Null), Null)
+	node, rest, err := ParseLosFormatter("/wEPDwUKLTEyNjY0NTk0NGRk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 || node.Token != TokenPair || node.Items[0].Items[0].Value != "-126645944" {
+		t.Fatalf("bad viewstate: %s", DumpObjectState(node))
+	}
+	if MarshalLosFormatter(node) != "/wEPDwUKLTEyNjY0NTk0NGRk" {
+		t.Fatalf("marshal viewstate mismatch: %s", MarshalLosFormatter(node))
+	}
+
+	binary := MarshalBinaryFormatter(NewNRBFHeader(1), NewNRBFString(1, "bin"), NewNRBFMessageEnd())
+	typed := func(token byte, typeName string, value any) *ObjectStateNode {
+		n := NewObjectStateNode(token, value)
+		n.TypeName = typeName
+		return n
+	}
+	sparse := typed(TokenSparseArray, "System.Object", nil)
+	sparse.Length, sparse.Indexes, sparse.Items = 10, []int{3, 7}, []*ObjectStateNode{NewObjectStateInt32(1), NewObjectStateString("s")}
+	unit := NewObjectStateNode(TokenUnit, 12.0)
+	unit.UnitType = 1
+	hashtable := NewObjectStateNode(TokenHashtable, nil,
+		NewObjectStateNode(TokenIndexedString, "key"), NewObjectStateInt32(300),
+		NewObjectStateNode(TokenIndexedString, "key"), NewObjectStateBool(true),
+	)
+	root := NewObjectStateTriplet(
+		hashtable,
+		NewObjectStateArrayList(
+			typed(TokenIntEnum, "System.Web.UI.WebControls.TextBoxMode", int64(2)),
+			typed(TokenStringFormatted, "System.Web.UI.WebControls.TextBoxMode", "Password"),
+			typed(TokenArray, "System.String", nil),
+			NewObjectStateNode(TokenStringArray, []string{"a", ""}),
+			NewObjectStateNode(TokenInt16, int64(-3)),
+			NewObjectStateNode(TokenDouble, 0.25),
+			NewObjectStateNode(TokenChar, "c"),
+			unit,
+			sparse,
+		),
+		NewObjectStateBinary(binary),
+	)
+	raw := MarshalObjectStateFormatter(root)
+	// the second type and string are written as references
+	if !bytes.Contains(raw, []byte{TokenStringFormatted, TokenTypeRef, 0}) || !bytes.Contains(raw, []byte{TokenIndexedString, 0}) {
+		t.Fatalf("type and string tables are not used: %x", raw)
+	}
+	parsed, rest, err := ParseObjectStateFormatter(append(raw, "mac"...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "mac" {
+		t.Fatalf("bad rest: %q", rest)
+	}
+	if !bytes.Equal(MarshalObjectStateFormatter(parsed), raw) {
+		t.Fatalf("object state round trip mismatch:\n%s", DumpObjectState(parsed))
+	}
+	dump := DumpObjectState(parsed)
+	for _, keyword := range []string{`IndexedStringAdd("key") => Int32(300)`, `IndexedString("key") => True,`, "SparseArray<System.Object>(10)", "3: Int32(1)", `#1 "bin"`, `StringFormatted<System.Web.UI.WebControls.TextBoxMode>("Password")`} {
+		if !strings.Contains(dump, keyword) {
+			t.Fatalf("dump missing %q:\n%s", keyword, dump)
+		}
+	}
+	if _, err := ToJson(parsed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestViewState(t *testing.T) {
+	data := MarshalObjectStateFormatter(NewObjectStatePair(NewObjectStateString("yak"), NewObjectStateNull()))
+	validationKey := "B3C2624FF313478C1E5BB3B3ED7C21A121389C544F3E38F3AA46C51E91E1ED99E1BDF960C7AC"
+	for _, alg := range []string{"SHA1", "MD5", "HMACSHA256", "HMACSHA512"} {
+		opts := []ViewStateOption{WithViewStateValidationKey(validationKey), WithViewStateValidationAlg(alg), WithViewStateGenerator("CA0B0334"), WithViewStateUserKey("session")}
+		viewstate, err := SignViewState(data, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		verified, err := VerifyViewState(viewstate, opts...)
+		if err != nil {
+			t.Fatalf("verify %s failed: %v", alg, err)
+		}
+		if !bytes.Equal(verified, data) {
+			t.Fatalf("verify %s data mismatch", alg)
+		}
+		if _, err := VerifyViewState(viewstate, append(opts, WithViewStateGenerator("CA0B0335"))...); err == nil {
+			t.Fatalf("verify %s with wrong generator should fail", alg)
+		}
+		node, mac, err := ParseLosFormatter(viewstate)
+		if err != nil || node.Items[0].Value != "yak" || len(mac) == 0 {
+			t.Fatalf("parse signed viewstate failed: %v", err)
+		}
+	}
+
+	for alg, key := range map[string]string{
+		"AES":  "34C69D15ADD80DA4788E6E3D02694230CF8E9ADFDA2708EF43CAEF4C5BC73887",
+		"3DES": "34C69D15ADD80DA4788E6E3D02694230CF8E9ADFDA2708EF",
+		"DES":  "34C69D15ADD80DA4",
+	} {
+		opts := []ViewStateOption{WithViewStateValidationKey(validationKey), WithViewStateDecryptionKey(key), WithViewStateDecryptionAlg(alg), WithViewStatePage("/app", "default_aspx")}
+		viewstate, err := EncryptViewState(data, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := DecryptViewState(viewstate, opts...)
+		if err != nil {
+			t.Fatalf("decrypt %s failed: %v", alg, err)
+		}
+		if !bytes.Equal(decrypted, data) {
+			t.Fatalf("decrypt %s data mismatch", alg)
+		}
+		if _, err := DecryptViewState(viewstate, append(opts, WithViewStatePage("/", "default_aspx"))...); err == nil {
+			t.Fatalf("decrypt %s with wrong page should fail", alg)
+		}
+	}
+
+	raw, _ := base64.StdEncoding.DecodeString("/wEPDwUKLTEyNjY0NTk0NGRk")
+	if _, err := EncryptViewState(raw, WithViewStateValidationKey(validationKey), WithViewStateDecryptionKey("00")); err == nil {
+		t.Fatal("encrypt without page type should fail")
+	}
+}
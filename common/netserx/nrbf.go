@@ -0,0 +1,384 @@
+package netserx
+
+import (
+	"fmt"
+)
+
+// record types of .NET Remoting Binary Format (MS-NRBF), which is the format of BinaryFormatter
+const (
+	RecordSerializedStreamHeader         byte = 0
+	RecordClassWithId                    byte = 1
+	RecordSystemClassWithMembers         byte = 2
+	RecordClassWithMembers               byte = 3
+	RecordSystemClassWithMembersAndTypes byte = 4
+	RecordClassWithMembersAndTypes       byte = 5
+	RecordBinaryObjectString             byte = 6
+	RecordBinaryArray                    byte = 7
+	RecordMemberPrimitiveTyped           byte = 8
+	RecordMemberReference                byte = 9
+	RecordObjectNull                     byte = 10
+	RecordMessageEnd                     byte = 11
+	RecordBinaryLibrary                  byte = 12
+	RecordObjectNullMultiple256          byte = 13
+	RecordObjectNullMultiple             byte = 14
+	RecordArraySinglePrimitive           byte = 15
+	RecordArraySingleObject              byte = 16
+	RecordArraySingleString              byte = 17
+)
+
+var recordNames = map[byte]string{
+	RecordSerializedStreamHeader:         "SerializedStreamHeader",
+	RecordClassWithId:                    "ClassWithId",
+	RecordSystemClassWithMembers:         "SystemClassWithMembers",
+	RecordClassWithMembers:               "ClassWithMembers",
+	RecordSystemClassWithMembersAndTypes: "SystemClassWithMembersAndTypes",
+	RecordClassWithMembersAndTypes:       "ClassWithMembersAndTypes",
+	RecordBinaryObjectString:             "BinaryObjectString",
+	RecordBinaryArray:                    "BinaryArray",
+	RecordMemberPrimitiveTyped:           "MemberPrimitiveTyped",
+	RecordMemberReference:                "MemberReference",
+	RecordObjectNull:                     "ObjectNull",
+	RecordMessageEnd:                     "MessageEnd",
+	RecordBinaryLibrary:                  "BinaryLibrary",
+	RecordObjectNullMultiple256:          "ObjectNullMultiple256",
+	RecordObjectNullMultiple:             "ObjectNullMultiple",
+	RecordArraySinglePrimitive:           "ArraySinglePrimitive",
+	RecordArraySingleObject:              "ArraySingleObject",
+	RecordArraySingleString:              "ArraySingleString",
+}
+
+func recordName(t byte) string {
+	if name, ok := recordNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Record(%d)", t)
+}
+
+// binary types of the class members and array items
+const (
+	BinaryTypePrimitive      byte = 0
+	BinaryTypeString         byte = 1
+	BinaryTypeObject         byte = 2
+	BinaryTypeSystemClass    byte = 3
+	BinaryTypeClass          byte = 4
+	BinaryTypeObjectArray    byte = 5
+	BinaryTypeStringArray    byte = 6
+	BinaryTypePrimitiveArray byte = 7
+)
+
+const (
+	PrimitiveBoolean  byte = 1
+	PrimitiveByte     byte = 2
+	PrimitiveChar     byte = 3
+	PrimitiveDecimal  byte = 5
+	PrimitiveDouble   byte = 6
+	PrimitiveInt16    byte = 7
+	PrimitiveInt32    byte = 8
+	PrimitiveInt64    byte = 9
+	PrimitiveSByte    byte = 10
+	PrimitiveSingle   byte = 11
+	PrimitiveTimeSpan byte = 12
+	PrimitiveDateTime byte = 13
+	PrimitiveUInt16   byte = 14
+	PrimitiveUInt32   byte = 15
+	PrimitiveUInt64   byte = 16
+	PrimitiveNull     byte = 17
+	PrimitiveString   byte = 18
+)
+
+var primitiveNames = map[byte]string{
+	PrimitiveBoolean:  "bool",
+	PrimitiveByte:     "byte",
+	PrimitiveChar:     "char",
+	PrimitiveDecimal:  "decimal",
+	PrimitiveDouble:   "double",
+	PrimitiveInt16:    "int16",
+	PrimitiveInt32:    "int32",
+	PrimitiveInt64:    "int64",
+	PrimitiveSByte:    "sbyte",
+	PrimitiveSingle:   "single",
+	PrimitiveTimeSpan: "timespan",
+	PrimitiveDateTime: "datetime",
+	PrimitiveUInt16:   "uint16",
+	PrimitiveUInt32:   "uint32",
+	PrimitiveUInt64:   "uint64",
+	PrimitiveNull:     "null",
+	PrimitiveString:   "string",
+}
+
+func primitiveName(t byte) string {
+	if name, ok := primitiveNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("primitive(%d)", t)
+}
+
+// NRBFRecord is a record in BinaryFormatter stream, the concrete types are the NRBF* structs in this package
+type NRBFRecord interface {
+	GetRecordType() byte
+}
+
+type NRBFHeader struct {
+	Type         string `json:"type"`
+	RecordType   byte   `json:"record_type"`
+	RootId       int32  `json:"root_id"`
+	HeaderId     int32  `json:"header_id"`
+	MajorVersion int32  `json:"major_version"`
+	MinorVersion int32  `json:"minor_version"`
+}
+
+// NRBFLibrary is the assembly of the classes, it does not take a member slot when it is in the member values
+type NRBFLibrary struct {
+	Type        string `json:"type"`
+	RecordType  byte   `json:"record_type"`
+	LibraryId   int32  `json:"library_id"`
+	LibraryName string `json:"library_name"`
+}
+
+// NRBFMemberType is the type of class member or array item, PrimitiveType is used by Primitive and PrimitiveArray,
+// ClassName is used by SystemClass and Class, LibraryId is used by Class
+type NRBFMemberType struct {
+	BinaryType    byte   `json:"binary_type"`
+	PrimitiveType byte   `json:"primitive_type,omitempty"`
+	ClassName     string `json:"class_name,omitempty"`
+	LibraryId     int32  `json:"library_id,omitempty"`
+}
+
+// NRBFClass is the class records: ClassWithId, (System)ClassWithMembers and (System)ClassWithMembersAndTypes,
+// MemberTypes is nil for the untyped records, the member values of ClassWithId are described by the class of MetadataId
+type NRBFClass struct {
+	Type        string            `json:"type"`
+	RecordType  byte              `json:"record_type"`
+	ObjectId    int32             `json:"object_id"`
+	MetadataId  int32             `json:"metadata_id,omitempty"`
+	Name        string            `json:"name"`
+	MemberNames []string          `json:"member_names"`
+	MemberTypes []*NRBFMemberType `json:"member_types,omitempty"`
+	LibraryId   int32             `json:"library_id,omitempty"`
+	Values      []NRBFRecord      `json:"values"`
+}
+
+type NRBFString struct {
+	Type       string `json:"type"`
+	RecordType byte   `json:"record_type"`
+	ObjectId   int32  `json:"object_id"`
+	Value      string `json:"value"`
+}
+
+// NRBFPrimitive is MemberPrimitiveTyped, it is also the untyped value of primitive member and primitive array item.
+// Value is bool, int64, uint64 (UInt64), float64 or string (Char, Decimal and String)
+type NRBFPrimitive struct {
+	Type          string `json:"type"`
+	RecordType    byte   `json:"record_type"`
+	PrimitiveType byte   `json:"primitive_type"`
+	Value         any    `json:"value"`
+}
+
+type NRBFReference struct {
+	Type       string `json:"type"`
+	RecordType byte   `json:"record_type"`
+	IdRef      int32  `json:"id_ref"`
+}
+
+// NRBFNull is ObjectNull, ObjectNullMultiple256 and ObjectNullMultiple, it takes Count member slots
+type NRBFNull struct {
+	Type       string `json:"type"`
+	RecordType byte   `json:"record_type"`
+	Count      int32  `json:"count"`
+}
+
+// NRBFArray is BinaryArray, ArraySinglePrimitive, ArraySingleObject and ArraySingleString,
+// Lengths and LowerBounds are only serialized by BinaryArray
+type NRBFArray struct {
+	Type        string          `json:"type"`
+	RecordType  byte            `json:"record_type"`
+	ObjectId    int32           `json:"object_id"`
+	ArrayType   byte            `json:"array_type,omitempty"`
+	Lengths     []int32         `json:"lengths"`
+	LowerBounds []int32         `json:"lower_bounds,omitempty"`
+	ItemType    *NRBFMemberType `json:"item_type"`
+	Values      []NRBFRecord    `json:"values"`
+}
+
+type NRBFMessageEnd struct {
+	Type       string `json:"type"`
+	RecordType byte   `json:"record_type"`
+}
+
+func (r *NRBFHeader) GetRecordType() byte     { return r.RecordType }
+func (r *NRBFLibrary) GetRecordType() byte    { return r.RecordType }
+func (r *NRBFClass) GetRecordType() byte      { return r.RecordType }
+func (r *NRBFString) GetRecordType() byte     { return r.RecordType }
+func (r *NRBFPrimitive) GetRecordType() byte  { return r.RecordType }
+func (r *NRBFReference) GetRecordType() byte  { return r.RecordType }
+func (r *NRBFNull) GetRecordType() byte       { return r.RecordType }
+func (r *NRBFArray) GetRecordType() byte      { return r.RecordType }
+func (r *NRBFMessageEnd) GetRecordType() byte { return r.RecordType }
+
+// NewNRBFHeader create the stream header, the header id is -1 and the version is 1.0 like BinaryFormatter
+func NewNRBFHeader(rootId int32) *NRBFHeader {
+	return &NRBFHeader{Type: recordName(RecordSerializedStreamHeader), RecordType: RecordSerializedStreamHeader, RootId: rootId, HeaderId: -1, MajorVersion: 1}
+}
+
+func NewNRBFLibrary(libraryId int32, libraryName string) *NRBFLibrary {
+	return &NRBFLibrary{Type: recordName(RecordBinaryLibrary), RecordType: RecordBinaryLibrary, LibraryId: libraryId, LibraryName: libraryName}
+}
+
+// NewNRBFClass create ClassWithMembersAndTypes, it is SystemClassWithMembersAndTypes if libraryId is 0,
+// the members are added by AddMember
+func NewNRBFClass(objectId int32, name string, libraryId int32) *NRBFClass {
+	t := RecordClassWithMembersAndTypes
+	if libraryId == 0 {
+		t = RecordSystemClassWithMembersAndTypes
+	}
+	return &NRBFClass{Type: recordName(t), RecordType: t, ObjectId: objectId, Name: name, LibraryId: libraryId, MemberNames: []string{}, MemberTypes: []*NRBFMemberType{}}
+}
+
+// NewNRBFClassWithId create ClassWithId which reuses the members of metadata
+func NewNRBFClassWithId(objectId int32, metadata *NRBFClass, values ...NRBFRecord) *NRBFClass {
+	return &NRBFClass{
+		Type:        recordName(RecordClassWithId),
+		RecordType:  RecordClassWithId,
+		ObjectId:    objectId,
+		MetadataId:  metadata.ObjectId,
+		Name:        metadata.Name,
+		MemberNames: metadata.MemberNames,
+		MemberTypes: metadata.MemberTypes,
+		LibraryId:   metadata.LibraryId,
+		Values:      values,
+	}
+}
+
+// AddMember append the member to class, the value of primitive member should be NRBFPrimitive
+func (c *NRBFClass) AddMember(name string, memberType *NRBFMemberType, value NRBFRecord) *NRBFClass {
+	c.MemberNames = append(c.MemberNames, name)
+	c.MemberTypes = append(c.MemberTypes, memberType)
+	c.Values = append(c.Values, value)
+	return c
+}
+
+// GetMember return the value of member by name, the slots taken by null multiple are considered
+func (c *NRBFClass) GetMember(name string) (NRBFRecord, bool) {
+	slot := 0
+	for _, value := range c.Values {
+		switch ret := value.(type) {
+		case *NRBFLibrary:
+			continue
+		case *NRBFNull:
+			for i := 0; i < int(ret.Count); i++ {
+				if slot < len(c.MemberNames) && c.MemberNames[slot] == name {
+					return ret, true
+				}
+				slot++
+			}
+			continue
+		}
+		if slot < len(c.MemberNames) && c.MemberNames[slot] == name {
+			return value, true
+		}
+		slot++
+	}
+	return nil, false
+}
+
+func NewNRBFPrimitiveMemberType(primitiveType byte) *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypePrimitive, PrimitiveType: primitiveType}
+}
+
+func NewNRBFStringMemberType() *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypeString}
+}
+
+func NewNRBFObjectMemberType() *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypeObject}
+}
+
+func NewNRBFSystemClassMemberType(className string) *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypeSystemClass, ClassName: className}
+}
+
+func NewNRBFClassMemberType(className string, libraryId int32) *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypeClass, ClassName: className, LibraryId: libraryId}
+}
+
+func NewNRBFObjectArrayMemberType() *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypeObjectArray}
+}
+
+func NewNRBFStringArrayMemberType() *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypeStringArray}
+}
+
+func NewNRBFPrimitiveArrayMemberType(primitiveType byte) *NRBFMemberType {
+	return &NRBFMemberType{BinaryType: BinaryTypePrimitiveArray, PrimitiveType: primitiveType}
+}
+
+func NewNRBFString(objectId int32, value string) *NRBFString {
+	return &NRBFString{Type: recordName(RecordBinaryObjectString), RecordType: RecordBinaryObjectString, ObjectId: objectId, Value: value}
+}
+
+// NewNRBFPrimitive create the primitive value, it is serialized as MemberPrimitiveTyped when it is not the value of primitive member
+func NewNRBFPrimitive(primitiveType byte, value any) *NRBFPrimitive {
+	return &NRBFPrimitive{Type: primitiveName(primitiveType), RecordType: RecordMemberPrimitiveTyped, PrimitiveType: primitiveType, Value: value}
+}
+
+func NewNRBFInt32(i int32) *NRBFPrimitive {
+	return NewNRBFPrimitive(PrimitiveInt32, int64(i))
+}
+
+func NewNRBFBoolean(b bool) *NRBFPrimitive {
+	return NewNRBFPrimitive(PrimitiveBoolean, b)
+}
+
+func NewNRBFReference(idRef int32) *NRBFReference {
+	return &NRBFReference{Type: recordName(RecordMemberReference), RecordType: RecordMemberReference, IdRef: idRef}
+}
+
+func NewNRBFNull() *NRBFNull {
+	return &NRBFNull{Type: recordName(RecordObjectNull), RecordType: RecordObjectNull, Count: 1}
+}
+
+// NewNRBFNullMultiple create the null record which takes count slots
+func NewNRBFNullMultiple(count int32) *NRBFNull {
+	t := RecordObjectNullMultiple
+	if count < 256 {
+		t = RecordObjectNullMultiple256
+	}
+	return &NRBFNull{Type: recordName(t), RecordType: t, Count: count}
+}
+
+func newNRBFSingleArray(t byte, objectId int32, itemType *NRBFMemberType, values []NRBFRecord) *NRBFArray {
+	return &NRBFArray{Type: recordName(t), RecordType: t, ObjectId: objectId, Lengths: []int32{int32(countSlots(values))}, ItemType: itemType, Values: values}
+}
+
+// NewNRBFStringArray create ArraySingleString, the values are NRBFString, NRBFReference or NRBFNull
+func NewNRBFStringArray(objectId int32, values ...NRBFRecord) *NRBFArray {
+	return newNRBFSingleArray(RecordArraySingleString, objectId, NewNRBFStringMemberType(), values)
+}
+
+func NewNRBFObjectArray(objectId int32, values ...NRBFRecord) *NRBFArray {
+	return newNRBFSingleArray(RecordArraySingleObject, objectId, NewNRBFObjectMemberType(), values)
+}
+
+func NewNRBFPrimitiveArray(objectId int32, primitiveType byte, values ...NRBFRecord) *NRBFArray {
+	return newNRBFSingleArray(RecordArraySinglePrimitive, objectId, NewNRBFPrimitiveMemberType(primitiveType), values)
+}
+
+func NewNRBFMessageEnd() *NRBFMessageEnd {
+	return &NRBFMessageEnd{Type: recordName(RecordMessageEnd), RecordType: RecordMessageEnd}
+}
+
+// countSlots count the member slots taken by values, libraries take no slot and null multiple takes Count slots
+func countSlots(values []NRBFRecord) int {
+	count := 0
+	for _, value := range values {
+		switch ret := value.(type) {
+		case *NRBFLibrary:
+		case *NRBFNull:
+			count += int(ret.Count)
+		default:
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,229 @@
+package netserx
+
+import (
+	"bytes"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// MarshalBinaryFormatter serialize the records to BinaryFormatter stream, the records should start with
+// the header and end with MessageEnd
+// Example:
+// ```
+// records = dotnet.ParseBinaryFormatter(raw)~
+// raw = dotnet.MarshalBinaryFormatter(records...)
+// ```
+func MarshalBinaryFormatter(records ...NRBFRecord) []byte {
+	var buf bytes.Buffer
+	for _, record := range records {
+		marshalRecord(&buf, record)
+	}
+	return buf.Bytes()
+}
+
+func marshalRecord(buf *bytes.Buffer, record NRBFRecord) {
+	switch ret := record.(type) {
+	case *NRBFHeader:
+		buf.WriteByte(RecordSerializedStreamHeader)
+		writeInt32(buf, ret.RootId)
+		writeInt32(buf, ret.HeaderId)
+		writeInt32(buf, ret.MajorVersion)
+		writeInt32(buf, ret.MinorVersion)
+	case *NRBFLibrary:
+		buf.WriteByte(RecordBinaryLibrary)
+		writeInt32(buf, ret.LibraryId)
+		writeString(buf, ret.LibraryName)
+	case *NRBFClass:
+		marshalClass(buf, ret)
+	case *NRBFString:
+		buf.WriteByte(RecordBinaryObjectString)
+		writeInt32(buf, ret.ObjectId)
+		writeString(buf, ret.Value)
+	case *NRBFPrimitive:
+		buf.WriteByte(RecordMemberPrimitiveTyped)
+		buf.WriteByte(ret.PrimitiveType)
+		marshalPrimitive(buf, ret.PrimitiveType, ret.Value)
+	case *NRBFReference:
+		buf.WriteByte(RecordMemberReference)
+		writeInt32(buf, ret.IdRef)
+	case *NRBFNull:
+		switch {
+		case ret.Count == 1 && ret.RecordType != RecordObjectNullMultiple && ret.RecordType != RecordObjectNullMultiple256:
+			buf.WriteByte(RecordObjectNull)
+		case ret.Count < 256 && ret.RecordType != RecordObjectNullMultiple:
+			buf.WriteByte(RecordObjectNullMultiple256)
+			buf.WriteByte(byte(ret.Count))
+		default:
+			buf.WriteByte(RecordObjectNullMultiple)
+			writeInt32(buf, ret.Count)
+		}
+	case *NRBFArray:
+		marshalArray(buf, ret)
+	case *NRBFMessageEnd:
+		buf.WriteByte(RecordMessageEnd)
+	}
+}
+
+func marshalClass(buf *bytes.Buffer, c *NRBFClass) {
+	buf.WriteByte(c.RecordType)
+	writeInt32(buf, c.ObjectId)
+	if c.RecordType == RecordClassWithId {
+		writeInt32(buf, c.MetadataId)
+	} else {
+		writeString(buf, c.Name)
+		writeInt32(buf, int32(len(c.MemberNames)))
+		for _, name := range c.MemberNames {
+			writeString(buf, name)
+		}
+		if c.RecordType == RecordSystemClassWithMembersAndTypes || c.RecordType == RecordClassWithMembersAndTypes {
+			marshalMemberTypes(buf, c.MemberTypes)
+		}
+		if c.RecordType == RecordClassWithMembers || c.RecordType == RecordClassWithMembersAndTypes {
+			writeInt32(buf, c.LibraryId)
+		}
+	}
+	slot := 0
+	for _, value := range c.Values {
+		var itemType *NRBFMemberType
+		if c.MemberTypes != nil && slot < len(c.MemberTypes) {
+			itemType = c.MemberTypes[slot]
+		}
+		marshalItem(buf, itemType, value)
+		slot += countSlots([]NRBFRecord{value})
+	}
+}
+
+func marshalMemberTypes(buf *bytes.Buffer, types []*NRBFMemberType) {
+	for _, t := range types {
+		buf.WriteByte(t.BinaryType)
+	}
+	for _, t := range types {
+		marshalAdditionalInfo(buf, t)
+	}
+}
+
+func marshalAdditionalInfo(buf *bytes.Buffer, t *NRBFMemberType) {
+	switch t.BinaryType {
+	case BinaryTypePrimitive, BinaryTypePrimitiveArray:
+		buf.WriteByte(t.PrimitiveType)
+	case BinaryTypeSystemClass:
+		writeString(buf, t.ClassName)
+	case BinaryTypeClass:
+		writeString(buf, t.ClassName)
+		writeInt32(buf, t.LibraryId)
+	}
+}
+
+// marshalItem write the value of member or array item, the value of primitive type has no record header
+func marshalItem(buf *bytes.Buffer, itemType *NRBFMemberType, value NRBFRecord) {
+	if primitive, ok := value.(*NRBFPrimitive); ok && itemType != nil && itemType.BinaryType == BinaryTypePrimitive {
+		marshalPrimitive(buf, itemType.PrimitiveType, primitive.Value)
+		return
+	}
+	marshalRecord(buf, value)
+}
+
+func marshalArray(buf *bytes.Buffer, array *NRBFArray) {
+	buf.WriteByte(array.RecordType)
+	writeInt32(buf, array.ObjectId)
+	itemType := array.ItemType
+	if itemType == nil {
+		itemType = NewNRBFObjectMemberType()
+	}
+	if array.RecordType == RecordBinaryArray {
+		buf.WriteByte(array.ArrayType)
+		writeInt32(buf, int32(len(array.Lengths)))
+		for _, length := range array.Lengths {
+			writeInt32(buf, length)
+		}
+		if array.ArrayType >= 3 {
+			for i := range array.Lengths {
+				var bound int32
+				if i < len(array.LowerBounds) {
+					bound = array.LowerBounds[i]
+				}
+				writeInt32(buf, bound)
+			}
+		}
+		buf.WriteByte(itemType.BinaryType)
+		marshalAdditionalInfo(buf, itemType)
+	} else {
+		writeInt32(buf, int32(countSlots(array.Values)))
+		if array.RecordType == RecordArraySinglePrimitive {
+			buf.WriteByte(itemType.PrimitiveType)
+		}
+	}
+	for _, value := range array.Values {
+		marshalItem(buf, itemType, value)
+	}
+}
+
+func marshalPrimitive(buf *bytes.Buffer, t byte, value any) {
+	switch t {
+	case PrimitiveBoolean:
+		if b, _ := value.(bool); b {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	case PrimitiveByte, PrimitiveSByte:
+		buf.WriteByte(byte(primitiveToInt64(value)))
+	case PrimitiveChar:
+		s := primitiveToString(value)
+		if s == "" {
+			s = "\x00"
+		}
+		buf.WriteString(s)
+	case PrimitiveDecimal, PrimitiveString:
+		writeString(buf, primitiveToString(value))
+	case PrimitiveDouble:
+		writeFloat64(buf, primitiveToFloat64(value))
+	case PrimitiveSingle:
+		writeFloat32(buf, float32(primitiveToFloat64(value)))
+	case PrimitiveInt16, PrimitiveUInt16:
+		writeInt16(buf, int16(primitiveToInt64(value)))
+	case PrimitiveInt32, PrimitiveUInt32:
+		writeInt32(buf, int32(primitiveToInt64(value)))
+	case PrimitiveInt64, PrimitiveTimeSpan, PrimitiveDateTime, PrimitiveUInt64:
+		writeInt64(buf, primitiveToInt64(value))
+	}
+}
+
+func primitiveToInt64(value any) int64 {
+	switch ret := value.(type) {
+	case uint64:
+		return int64(ret)
+	case float64:
+		return int64(ret)
+	case float32:
+		return int64(ret)
+	case bool:
+		if ret {
+			return 1
+		}
+		return 0
+	}
+	return int64(utils.InterfaceToInt(value))
+}
+
+func primitiveToFloat64(value any) float64 {
+	switch ret := value.(type) {
+	case float64:
+		return ret
+	case float32:
+		return float64(ret)
+	case uint64:
+		return float64(ret)
+	}
+	return float64(primitiveToInt64(value))
+}
+
+func primitiveToString(value any) string {
+	switch ret := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return ret
+	}
+	return utils.InterfaceToString(value)
+}
@@ -0,0 +1,359 @@
+package netserx
+
+type nrbfParser struct {
+	*netReader
+	classes map[int32]*NRBFClass
+}
+
+// ParseBinaryFormatter parse the BinaryFormatter stream, the records are returned in stream order,
+// the member values and array items which are serialized inline are kept in their class and array
+// Example:
+// ```
+// records = dotnet.ParseBinaryFormatter(raw)~
+// println(dotnet.Dump(records...))
+// ```
+func ParseBinaryFormatter(raw []byte) ([]NRBFRecord, error) {
+	p := &nrbfParser{netReader: &netReader{name: "BinaryFormatter", raw: raw}, classes: make(map[int32]*NRBFClass)}
+	var ret []NRBFRecord
+	for p.pos < len(p.raw) {
+		record, err := p.readRecord()
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, record)
+		if record.GetRecordType() == RecordMessageEnd {
+			break
+		}
+	}
+	return ret, nil
+}
+
+func (p *nrbfParser) readRecord() (NRBFRecord, error) {
+	t, err := p.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch t {
+	case RecordSerializedStreamHeader:
+		header := NewNRBFHeader(0)
+		for _, v := range []*int32{&header.RootId, &header.HeaderId, &header.MajorVersion, &header.MinorVersion} {
+			if *v, err = p.readInt32(); err != nil {
+				return nil, err
+			}
+		}
+		return header, nil
+	case RecordBinaryLibrary:
+		id, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		name, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		return NewNRBFLibrary(id, name), nil
+	case RecordClassWithId, RecordSystemClassWithMembers, RecordClassWithMembers, RecordSystemClassWithMembersAndTypes, RecordClassWithMembersAndTypes:
+		return p.readClass(t)
+	case RecordBinaryObjectString:
+		id, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		return NewNRBFString(id, value), nil
+	case RecordMemberPrimitiveTyped:
+		primitiveType, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return p.readPrimitive(primitiveType)
+	case RecordMemberReference:
+		id, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		return NewNRBFReference(id), nil
+	case RecordObjectNull:
+		return NewNRBFNull(), nil
+	case RecordObjectNullMultiple256:
+		count, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return &NRBFNull{Type: recordName(t), RecordType: t, Count: int32(count)}, nil
+	case RecordObjectNullMultiple:
+		count, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		return &NRBFNull{Type: recordName(t), RecordType: t, Count: count}, nil
+	case RecordBinaryArray, RecordArraySinglePrimitive, RecordArraySingleObject, RecordArraySingleString:
+		return p.readArray(t)
+	case RecordMessageEnd:
+		return NewNRBFMessageEnd(), nil
+	default:
+		return nil, p.errorf("unsupported record type %d", t)
+	}
+}
+
+func (p *nrbfParser) readClass(t byte) (*NRBFClass, error) {
+	c := &NRBFClass{Type: recordName(t), RecordType: t}
+	var err error
+	if c.ObjectId, err = p.readInt32(); err != nil {
+		return nil, err
+	}
+	if t == RecordClassWithId {
+		if c.MetadataId, err = p.readInt32(); err != nil {
+			return nil, err
+		}
+		metadata, ok := p.classes[c.MetadataId]
+		if !ok {
+			return nil, p.errorf("class metadata %d not found", c.MetadataId)
+		}
+		c.Name, c.MemberNames, c.MemberTypes, c.LibraryId = metadata.Name, metadata.MemberNames, metadata.MemberTypes, metadata.LibraryId
+	} else {
+		if c.Name, err = p.readString(); err != nil {
+			return nil, err
+		}
+		count, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		if count < 0 || int(count) > len(p.raw)-p.pos {
+			return nil, p.errorf("bad member count %d", count)
+		}
+		c.MemberNames = make([]string, count)
+		for i := range c.MemberNames {
+			if c.MemberNames[i], err = p.readString(); err != nil {
+				return nil, err
+			}
+		}
+		if t == RecordSystemClassWithMembersAndTypes || t == RecordClassWithMembersAndTypes {
+			if c.MemberTypes, err = p.readMemberTypes(int(count)); err != nil {
+				return nil, err
+			}
+		}
+		if t == RecordClassWithMembers || t == RecordClassWithMembersAndTypes {
+			if c.LibraryId, err = p.readInt32(); err != nil {
+				return nil, err
+			}
+		}
+		p.classes[c.ObjectId] = c
+	}
+	for slot := 0; slot < len(c.MemberNames); {
+		var itemType *NRBFMemberType
+		if c.MemberTypes != nil {
+			itemType = c.MemberTypes[slot]
+		}
+		value, slots, err := p.readItem(itemType)
+		if err != nil {
+			return nil, err
+		}
+		c.Values = append(c.Values, value)
+		slot += slots
+	}
+	return c, nil
+}
+
+func (p *nrbfParser) readMemberTypes(count int) ([]*NRBFMemberType, error) {
+	types := make([]*NRBFMemberType, count)
+	for i := range types {
+		t, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		types[i] = &NRBFMemberType{BinaryType: t}
+	}
+	for _, t := range types {
+		if err := p.readAdditionalInfo(t); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+func (p *nrbfParser) readAdditionalInfo(t *NRBFMemberType) error {
+	var err error
+	switch t.BinaryType {
+	case BinaryTypePrimitive, BinaryTypePrimitiveArray:
+		t.PrimitiveType, err = p.readByte()
+	case BinaryTypeSystemClass:
+		t.ClassName, err = p.readString()
+	case BinaryTypeClass:
+		if t.ClassName, err = p.readString(); err != nil {
+			return err
+		}
+		t.LibraryId, err = p.readInt32()
+	case BinaryTypeString, BinaryTypeObject, BinaryTypeObjectArray, BinaryTypeStringArray:
+	default:
+		return p.errorf("unsupported binary type %d", t.BinaryType)
+	}
+	return err
+}
+
+// readItem read the value of member or array item, it returns the slots taken by the value
+func (p *nrbfParser) readItem(itemType *NRBFMemberType) (NRBFRecord, int, error) {
+	if itemType != nil && itemType.BinaryType == BinaryTypePrimitive {
+		value, err := p.readPrimitive(itemType.PrimitiveType)
+		return value, 1, err
+	}
+	record, err := p.readRecord()
+	if err != nil {
+		return nil, 0, err
+	}
+	switch ret := record.(type) {
+	case *NRBFLibrary:
+		return ret, 0, nil
+	case *NRBFNull:
+		if ret.Count < 0 {
+			return nil, 0, p.errorf("bad null count %d", ret.Count)
+		}
+		return ret, int(ret.Count), nil
+	case *NRBFHeader, *NRBFMessageEnd:
+		return nil, 0, p.errorf("unexpected %s in values", recordName(record.GetRecordType()))
+	}
+	return record, 1, nil
+}
+
+func (p *nrbfParser) readArray(t byte) (*NRBFArray, error) {
+	array := &NRBFArray{Type: recordName(t), RecordType: t}
+	var err error
+	if array.ObjectId, err = p.readInt32(); err != nil {
+		return nil, err
+	}
+	total := 1
+	if t == RecordBinaryArray {
+		if array.ArrayType, err = p.readByte(); err != nil {
+			return nil, err
+		}
+		rank, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		if rank <= 0 || rank > 32 {
+			return nil, p.errorf("bad array rank %d", rank)
+		}
+		array.Lengths = make([]int32, rank)
+		for i := range array.Lengths {
+			if array.Lengths[i], err = p.readInt32(); err != nil {
+				return nil, err
+			}
+			if array.Lengths[i] < 0 {
+				return nil, p.errorf("bad array length %d", array.Lengths[i])
+			}
+			total *= int(array.Lengths[i])
+		}
+		// SingleOffset, JaggedOffset and RectangularOffset
+		if array.ArrayType >= 3 {
+			array.LowerBounds = make([]int32, rank)
+			for i := range array.LowerBounds {
+				if array.LowerBounds[i], err = p.readInt32(); err != nil {
+					return nil, err
+				}
+			}
+		}
+		binaryType, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		array.ItemType = &NRBFMemberType{BinaryType: binaryType}
+		if err := p.readAdditionalInfo(array.ItemType); err != nil {
+			return nil, err
+		}
+	} else {
+		length, err := p.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		if length < 0 {
+			return nil, p.errorf("bad array length %d", length)
+		}
+		array.Lengths = []int32{length}
+		total = int(length)
+		switch t {
+		case RecordArraySinglePrimitive:
+			primitiveType, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			array.ItemType = NewNRBFPrimitiveMemberType(primitiveType)
+		case RecordArraySingleString:
+			array.ItemType = NewNRBFStringMemberType()
+		default:
+			array.ItemType = NewNRBFObjectMemberType()
+		}
+	}
+	if total > len(p.raw)-p.pos {
+		return nil, p.errorf("array length %d is larger than data", total)
+	}
+	for slot := 0; slot < total; {
+		value, slots, err := p.readItem(array.ItemType)
+		if err != nil {
+			return nil, err
+		}
+		array.Values = append(array.Values, value)
+		slot += slots
+	}
+	return array, nil
+}
+
+func (p *nrbfParser) readPrimitive(t byte) (*NRBFPrimitive, error) {
+	var value any
+	var err error
+	switch t {
+	case PrimitiveBoolean:
+		var b byte
+		b, err = p.readByte()
+		value = b != 0
+	case PrimitiveByte:
+		var b byte
+		b, err = p.readByte()
+		value = int64(b)
+	case PrimitiveSByte:
+		var b byte
+		b, err = p.readByte()
+		value = int64(int8(b))
+	case PrimitiveChar:
+		value, err = p.readChar()
+	case PrimitiveDecimal, PrimitiveString:
+		value, err = p.readString()
+	case PrimitiveDouble:
+		value, err = p.readFloat64()
+	case PrimitiveSingle:
+		var f float32
+		f, err = p.readFloat32()
+		value = float64(f)
+	case PrimitiveInt16:
+		var i uint16
+		i, err = p.readUint16()
+		value = int64(int16(i))
+	case PrimitiveUInt16:
+		var i uint16
+		i, err = p.readUint16()
+		value = int64(i)
+	case PrimitiveInt32:
+		var i uint32
+		i, err = p.readUint32()
+		value = int64(int32(i))
+	case PrimitiveUInt32:
+		var i uint32
+		i, err = p.readUint32()
+		value = int64(i)
+	case PrimitiveInt64, PrimitiveTimeSpan, PrimitiveDateTime:
+		var i uint64
+		i, err = p.readUint64()
+		value = int64(i)
+	case PrimitiveUInt64:
+		value, err = p.readUint64()
+	case PrimitiveNull:
+	default:
+		return nil, p.errorf("unsupported primitive type %d", t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewNRBFPrimitive(t, value), nil
+}
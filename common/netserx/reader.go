@@ -0,0 +1,163 @@
+package netserx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// netReader reads the little endian values written by .NET BinaryWriter
+type netReader struct {
+	name string
+	raw  []byte
+	pos  int
+}
+
+func (r *netReader) errorf(format string, args ...any) error {
+	return utils.Errorf("%s parse at %d failed: %s", r.name, r.pos, fmt.Sprintf(format, args...))
+}
+
+func (r *netReader) readBytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.raw) {
+		return nil, r.errorf("need %d bytes, but only %d left", n, len(r.raw)-r.pos)
+	}
+	ret := r.raw[r.pos : r.pos+n]
+	r.pos += n
+	return ret, nil
+}
+
+func (r *netReader) readByte() (byte, error) {
+	b, err := r.readBytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *netReader) readUint16() (uint16, error) {
+	b, err := r.readBytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint16(b), nil
+}
+
+func (r *netReader) readUint32() (uint32, error) {
+	b, err := r.readBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+func (r *netReader) readUint64() (uint64, error) {
+	b, err := r.readBytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+func (r *netReader) readInt32() (int32, error) {
+	i, err := r.readUint32()
+	return int32(i), err
+}
+
+// read7BitInt read the int written by BinaryWriter.Write7BitEncodedInt
+func (r *netReader) read7BitInt() (int, error) {
+	var ret uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.readByte()
+		if err != nil {
+			return 0, err
+		}
+		ret |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int(int32(ret)), nil
+		}
+	}
+	return 0, r.errorf("bad 7 bit encoded int")
+}
+
+// readString read the length prefixed utf-8 string
+func (r *netReader) readString() (string, error) {
+	n, err := r.read7BitInt()
+	if err != nil {
+		return "", err
+	}
+	if n < 0 {
+		return "", r.errorf("bad string length %d", n)
+	}
+	b, err := r.readBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// readChar read the utf-8 encoded char
+func (r *netReader) readChar() (string, error) {
+	if r.pos >= len(r.raw) {
+		return "", r.errorf("need char, but EOF")
+	}
+	_, size := utf8.DecodeRune(r.raw[r.pos:])
+	b, err := r.readBytes(size)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (r *netReader) readFloat32() (float32, error) {
+	i, err := r.readUint32()
+	return math.Float32frombits(i), err
+}
+
+func (r *netReader) readFloat64() (float64, error) {
+	i, err := r.readUint64()
+	return math.Float64frombits(i), err
+}
+
+func write7BitInt(buf *bytes.Buffer, i int) {
+	v := uint32(i)
+	for v >= 0x80 {
+		buf.WriteByte(byte(v) | 0x80)
+		v >>= 7
+	}
+	buf.WriteByte(byte(v))
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	write7BitInt(buf, len(s))
+	buf.WriteString(s)
+}
+
+func writeInt16(buf *bytes.Buffer, i int16) {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], uint16(i))
+	buf.Write(b[:])
+}
+
+func writeInt32(buf *bytes.Buffer, i int32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], uint32(i))
+	buf.Write(b[:])
+}
+
+func writeInt64(buf *bytes.Buffer, i int64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(i))
+	buf.Write(b[:])
+}
+
+func writeFloat32(buf *bytes.Buffer, f float32) {
+	writeInt32(buf, int32(math.Float32bits(f)))
+}
+
+func writeFloat64(buf *bytes.Buffer, f float64) {
+	writeInt64(buf, int64(math.Float64bits(f)))
+}
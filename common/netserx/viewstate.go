@@ -0,0 +1,332 @@
+package netserx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const viewStatePurpose = "WebForms.HiddenFieldPageStatePersister.ClientState"
+
+// ViewStateConfig is the machineKey and page information used to sign or encrypt ViewState
+type ViewStateConfig struct {
+	ValidationKey []byte
+	// ValidationAlg is SHA1, MD5, HMACSHA256, HMACSHA384 or HMACSHA512, 3DES and AES are treated as SHA1 like .NET
+	ValidationAlg string
+	DecryptionKey []byte
+	// DecryptionAlg is AES, 3DES or DES
+	DecryptionAlg string
+	// Generator is the __VIEWSTATEGENERATOR of the page, it is used by the legacy MAC
+	Generator uint32
+	// TemplateSourceDirectory and PageType (such as default_aspx) are used to derive the keys of .NET 4.5
+	TemplateSourceDirectory string
+	PageType                string
+	ViewStateUserKey        string
+}
+
+type ViewStateOption func(*ViewStateConfig)
+
+// WithViewStateValidationKey set the hex encoded validationKey of machineKey
+func WithViewStateValidationKey(key string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		c.ValidationKey, _ = hex.DecodeString(strings.TrimSpace(key))
+	}
+}
+
+// WithViewStateValidationAlg set the validation algorithm of machineKey, HMACSHA256 is the default
+func WithViewStateValidationAlg(alg string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		c.ValidationAlg = alg
+	}
+}
+
+// WithViewStateDecryptionKey set the hex encoded decryptionKey of machineKey
+func WithViewStateDecryptionKey(key string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		c.DecryptionKey, _ = hex.DecodeString(strings.TrimSpace(key))
+	}
+}
+
+// WithViewStateDecryptionAlg set the decryption algorithm of machineKey, AES is the default
+func WithViewStateDecryptionAlg(alg string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		c.DecryptionAlg = alg
+	}
+}
+
+// WithViewStateGenerator set the hex encoded __VIEWSTATEGENERATOR of the page
+func WithViewStateGenerator(generator string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		i, _ := strconv.ParseUint(strings.TrimSpace(generator), 16, 32)
+		c.Generator = uint32(i)
+	}
+}
+
+// WithViewStatePage set the TemplateSourceDirectory (such as / or /app) and the type name (such as default_aspx) of the page
+func WithViewStatePage(templateSourceDirectory string, pageType string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		c.TemplateSourceDirectory = templateSourceDirectory
+		c.PageType = pageType
+	}
+}
+
+// WithViewStateUserKey set the ViewStateUserKey of the page, it is usually the session id
+func WithViewStateUserKey(key string) ViewStateOption {
+	return func(c *ViewStateConfig) {
+		c.ViewStateUserKey = key
+	}
+}
+
+func NewViewStateConfig(opts ...ViewStateOption) *ViewStateConfig {
+	c := &ViewStateConfig{ValidationAlg: "HMACSHA256", DecryptionAlg: "AES", TemplateSourceDirectory: "/"}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *ViewStateConfig) validationHash() (func() hash.Hash, error) {
+	switch strings.ToUpper(c.ValidationAlg) {
+	case "SHA1", "HMACSHA1", "3DES", "AES", "":
+		return sha1.New, nil
+	case "MD5", "HMACMD5":
+		return md5.New, nil
+	case "HMACSHA256":
+		return sha256.New, nil
+	case "HMACSHA384":
+		return sha512.New384, nil
+	case "HMACSHA512":
+		return sha512.New, nil
+	}
+	return nil, utils.Errorf("unsupported viewstate validation algorithm %s", c.ValidationAlg)
+}
+
+// legacyModifier is the generator in little endian and the utf-16 ViewStateUserKey
+func (c *ViewStateConfig) legacyModifier() []byte {
+	modifier := make([]byte, 4)
+	binary.LittleEndian.PutUint32(modifier, c.Generator)
+	for _, r := range utf16.Encode([]rune(c.ViewStateUserKey)) {
+		modifier = binary.LittleEndian.AppendUint16(modifier, r)
+	}
+	return modifier
+}
+
+func (c *ViewStateConfig) legacyMAC(data []byte) ([]byte, error) {
+	if len(c.ValidationKey) == 0 {
+		return nil, utils.Error("viewstate validation key is empty")
+	}
+	modifier := c.legacyModifier()
+	// MD5 is not keyed: md5(data + modifier + key)
+	if strings.ToUpper(c.ValidationAlg) == "MD5" {
+		sum := md5.Sum(bytes.Join([][]byte{data, modifier, c.ValidationKey}, nil))
+		return sum[:], nil
+	}
+	h, err := c.validationHash()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(h, c.ValidationKey)
+	mac.Write(data)
+	mac.Write(modifier)
+	return mac.Sum(nil), nil
+}
+
+// SignViewState sign the ObjectStateFormatter data with the MAC of .NET < 4.5 (or compatibilityMode Framework20SP2),
+// the result is base64 encoded data + MAC
+// Example:
+// ```
+// viewstate = dotnet.SignViewState(data, dotnet.validationKey("..."), dotnet.validationAlg("SHA1"), dotnet.generator("CA0B0334"))~
+// ```
+func SignViewState(data []byte, opts ...ViewStateOption) (string, error) {
+	c := NewViewStateConfig(opts...)
+	mac, err := c.legacyMAC(data)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(append([]byte{}, data...), mac...)), nil
+}
+
+// VerifyViewState verify the MAC of ViewState (.NET < 4.5) and return the ObjectStateFormatter data,
+// it can be used to check whether the machineKey is right
+// Example:
+// ```
+// data = dotnet.VerifyViewState(viewstate, dotnet.validationKey("..."), dotnet.validationAlg("SHA1"), dotnet.generator("CA0B0334"))~
+// ```
+func VerifyViewState(viewstate string, opts ...ViewStateOption) ([]byte, error) {
+	c := NewViewStateConfig(opts...)
+	raw, err := decodeViewState(viewstate)
+	if err != nil {
+		return nil, err
+	}
+	size, err := c.macSize()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < size {
+		return nil, utils.Error("viewstate is shorter than MAC")
+	}
+	data, mac := raw[:len(raw)-size], raw[len(raw)-size:]
+	expected, err := c.legacyMAC(data)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, expected) {
+		return nil, utils.Error("viewstate MAC validation failed")
+	}
+	return data, nil
+}
+
+func (c *ViewStateConfig) macSize() (int, error) {
+	h, err := c.validationHash()
+	if err != nil {
+		return 0, err
+	}
+	return h().Size(), nil
+}
+
+// deriveKey is SP800-108 counter mode KDF with HMACSHA512 used by AspNetCryptoServiceProvider,
+// the label is the purpose and the context is the specific purposes written by BinaryWriter
+func (c *ViewStateConfig) deriveKey(key []byte) []byte {
+	var context bytes.Buffer
+	specificPurposes := []string{
+		"TemplateSourceDirectory: " + strings.ToUpper(c.TemplateSourceDirectory),
+		"Type: " + strings.ToUpper(c.PageType),
+	}
+	if c.ViewStateUserKey != "" {
+		specificPurposes = append(specificPurposes, "ViewStateUserKey: "+c.ViewStateUserKey)
+	}
+	for _, purpose := range specificPurposes {
+		writeString(&context, purpose)
+	}
+	input := make([]byte, 0, 4+len(viewStatePurpose)+1+context.Len()+4)
+	input = append(input, 0, 0, 0, 0)
+	input = append(input, viewStatePurpose...)
+	input = append(input, 0)
+	input = append(input, context.Bytes()...)
+	input = binary.BigEndian.AppendUint32(input, uint32(len(key)*8))
+
+	var derived []byte
+	for i := uint32(1); len(derived) < len(key); i++ {
+		binary.BigEndian.PutUint32(input, i)
+		mac := hmac.New(sha512.New, key)
+		mac.Write(input)
+		derived = append(derived, mac.Sum(nil)...)
+	}
+	return derived[:len(key)]
+}
+
+func (c *ViewStateConfig) newCipher(key []byte) (cipher.Block, error) {
+	switch strings.ToUpper(c.DecryptionAlg) {
+	case "AES", "AUTO", "":
+		return aes.NewCipher(key)
+	case "3DES":
+		return des.NewTripleDESCipher(key)
+	case "DES":
+		return des.NewCipher(key)
+	}
+	return nil, utils.Errorf("unsupported viewstate decryption algorithm %s", c.DecryptionAlg)
+}
+
+func (c *ViewStateConfig) derivedKeys() (encryptionKey []byte, validationKey []byte, err error) {
+	if len(c.DecryptionKey) == 0 || len(c.ValidationKey) == 0 {
+		return nil, nil, utils.Error("viewstate decryption key and validation key are required")
+	}
+	if c.PageType == "" {
+		return nil, nil, utils.Error("viewstate page type is required to derive the keys, such as default_aspx")
+	}
+	return c.deriveKey(c.DecryptionKey), c.deriveKey(c.ValidationKey), nil
+}
+
+// EncryptViewState encrypt and sign the ObjectStateFormatter data like .NET >= 4.5, the result is base64 encoded
+// IV + ciphertext + HMAC, the keys are derived from machineKey and the page
+// Example:
+// ```
+// viewstate = dotnet.EncryptViewState(data, dotnet.validationKey("..."), dotnet.decryptionKey("..."), dotnet.page("/", "default_aspx"))~
+// ```
+func EncryptViewState(data []byte, opts ...ViewStateOption) (string, error) {
+	c := NewViewStateConfig(opts...)
+	encryptionKey, validationKey, err := c.derivedKeys()
+	if err != nil {
+		return "", err
+	}
+	block, err := c.newCipher(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, block.BlockSize())
+	if _, err := rand.Read(iv); err != nil {
+		return "", err
+	}
+	padding := block.BlockSize() - len(data)%block.BlockSize()
+	plain := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	encrypted := make([]byte, len(iv)+len(plain))
+	copy(encrypted, iv)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted[len(iv):], plain)
+
+	h, err := c.validationHash()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(h, validationKey)
+	mac.Write(encrypted)
+	return base64.StdEncoding.EncodeToString(mac.Sum(encrypted)), nil
+}
+
+// DecryptViewState verify and decrypt the ViewState of .NET >= 4.5, the ObjectStateFormatter data is returned
+// Example:
+// ```
+// data = dotnet.DecryptViewState(viewstate, dotnet.validationKey("..."), dotnet.decryptionKey("..."), dotnet.page("/", "default_aspx"))~
+// node, _ = dotnet.ParseObjectStateFormatter(data)~
+// ```
+func DecryptViewState(viewstate string, opts ...ViewStateOption) ([]byte, error) {
+	c := NewViewStateConfig(opts...)
+	encryptionKey, validationKey, err := c.derivedKeys()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := decodeViewState(viewstate)
+	if err != nil {
+		return nil, err
+	}
+	block, err := c.newCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	h, err := c.validationHash()
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(h, validationKey)
+	blockSize := block.BlockSize()
+	if len(raw) < blockSize*2+mac.Size() || (len(raw)-mac.Size())%blockSize != 0 {
+		return nil, utils.Error("bad encrypted viewstate length")
+	}
+	encrypted, signature := raw[:len(raw)-mac.Size()], raw[len(raw)-mac.Size():]
+	mac.Write(encrypted)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, utils.Error("viewstate MAC validation failed")
+	}
+	plain := make([]byte, len(encrypted)-blockSize)
+	cipher.NewCBCDecrypter(block, encrypted[:blockSize]).CryptBlocks(plain, encrypted[blockSize:])
+	padding := int(plain[len(plain)-1])
+	if padding == 0 || padding > blockSize || padding > len(plain) {
+		return nil, utils.Error("bad viewstate padding")
+	}
+	return plain[:len(plain)-padding], nil
+}
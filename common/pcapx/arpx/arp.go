@@ -15,7 +15,6 @@ import (
 	"github.com/ReneKroon/ttlcache"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 	"github.com/mdlayher/arp"
 	"github.com/pkg/errors"
 
@@ -276,21 +275,25 @@ func newArpARPPacket(iface *net.Interface, ip string) (gopacket.SerializeBuffer,
 	return buf, nil
 }
 
+// arpHandler is the live handle to send and receive arp packets, *pcap.Handle is an arpHandler
+type arpHandler interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	LinkType() layers.LinkType
+	SetBPFFilter(expr string) error
+	WritePacketData(data []byte) error
+	Close()
+}
+
 func ARPWithPcap(ctx context.Context, ifaceName string, targets string) (map[string]net.HardwareAddr, error) {
 	ifaceIns, err := net.InterfaceByName(ifaceName)
 	if err != nil {
 		return nil, utils.Errorf("find interface by name failed: %s", ifaceName)
 	}
-	pcapName, err := _ifaceNameToPcapIfaceName(ifaceName)
-	if err != nil {
-		log.Errorf("find pcap name failed: %s", err)
-		return nil, utils.Errorf("find pcap name failed: %v", err)
-	}
-
-	handler, err := pcap.OpenLive(pcapName, 65535, true, pcap.BlockForever)
+	handler, err := openArpHandler(ifaceName)
 	if err != nil {
-		return nil, utils.Errorf("pcap open live %v failed: %s", pcapName, err)
+		return nil, err
 	}
+	defer handler.Close()
 
 	log.Infof(`Arp With Pcap in %v, LinkType: %v`, ifaceName, handler.LinkType())
 
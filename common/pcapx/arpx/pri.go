@@ -1,3 +1,5 @@
+//go:build (cgo || windows) && !nopcap
+
 package arpx
 
 import (
@@ -74,3 +76,17 @@ func _ifaceNameToPcapIfaceName(name string) (string, error) {
 	}
 	return "", newConvertIfaceNameError(name)
 }
+
+func openArpHandler(ifaceName string) (arpHandler, error) {
+	pcapName, err := _ifaceNameToPcapIfaceName(ifaceName)
+	if err != nil {
+		log.Errorf("find pcap name failed: %s", err)
+		return nil, utils.Errorf("find pcap name failed: %v", err)
+	}
+
+	handler, err := pcap.OpenLive(pcapName, 65535, true, pcap.BlockForever)
+	if err != nil {
+		return nil, utils.Errorf("pcap open live %v failed: %s", pcapName, err)
+	}
+	return handler, nil
+}
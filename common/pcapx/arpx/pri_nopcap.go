@@ -0,0 +1,11 @@
+//go:build (!cgo && !windows) || nopcap
+
+package arpx
+
+import (
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func openArpHandler(ifaceName string) (arpHandler, error) {
+	return nil, utils.Errorf("arp with pcap on %v requires libpcap, use Arp instead", ifaceName)
+}
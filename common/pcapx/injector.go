@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 	"github.com/yaklang/yaklang/common/netx"
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils"
@@ -19,22 +18,21 @@ var (
 	deviceToNet sync.Map
 )
 
-func getInjectorHandler(name string) (*pcap.Handle, error) {
+func getInjectorHandler(name string) (pcaputil.Handle, error) {
 	raw, ok := deviceToNet.Load(name)
 	if !ok {
-		var err error
-		name, err = pcaputil.IfaceNameToPcapIfaceName(name)
+		pcapName, err := pcaputil.IfaceNameToPcapIfaceName(name)
 		if err != nil {
 			return nil, utils.Errorf("fix iface name failed: %v", err)
 		}
-		handle, err := pcap.OpenLive(name, 65536, true, pcap.BlockForever)
+		handle, err := pcaputil.OpenIfaceLive(pcapName)
 		if err != nil {
 			return nil, err
 		}
 		deviceToNet.Store(name, handle)
 		return handle, nil
 	}
-	return raw.(*pcap.Handle), nil
+	return raw.(pcaputil.Handle), nil
 }
 
 func injectRaw(iface string, raw []byte) error {
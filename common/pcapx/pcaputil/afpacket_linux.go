@@ -0,0 +1,166 @@
+package pcaputil
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+// the receive timeout of socket, the handle checks whether it is closed after timeout
+const afPacketReadTimeout = 500 * time.Millisecond
+
+// afPacketHandle capture the packets by AF_PACKET socket of linux without libpcap
+type afPacketHandle struct {
+	fd       int
+	ifindex  int
+	linkType layers.LinkType
+	loopback bool
+	promisc  bool
+	snaplen  int
+	buf      []byte
+
+	// the read holds the read lock, close waits for the read returning to release fd
+	lock   sync.RWMutex
+	closed int32
+}
+
+func htons(i uint16) uint16 {
+	return i<<8 | i>>8
+}
+
+func openAFPacket(name string, snaplen int, promisc bool) (Handle, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, utils.Errorf("fetch net.Interface failed: %s", err)
+	}
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, int(htons(unix.ETH_P_ALL)))
+	if err != nil {
+		return nil, utils.Errorf("create AF_PACKET socket failed: %s", err)
+	}
+	h := &afPacketHandle{fd: fd, ifindex: iface.Index, promisc: promisc, snaplen: snaplen, buf: make([]byte, snaplen)}
+	if err := h.init(); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *afPacketHandle) init() error {
+	err := unix.Bind(h.fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: h.ifindex})
+	if err != nil {
+		return utils.Errorf("bind AF_PACKET socket failed: %s", err)
+	}
+	if h.promisc {
+		mreq := &unix.PacketMreq{Ifindex: int32(h.ifindex), Type: unix.PACKET_MR_PROMISC}
+		if err := unix.SetsockoptPacketMreq(h.fd, unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, mreq); err != nil {
+			return utils.Errorf("set promiscuous mode failed: %s", err)
+		}
+	}
+	tv := unix.NsecToTimeval(afPacketReadTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(h.fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		return utils.Errorf("set receive timeout failed: %s", err)
+	}
+
+	sa, err := unix.Getsockname(h.fd)
+	if err != nil {
+		return utils.Errorf("get AF_PACKET socket name failed: %s", err)
+	}
+	h.linkType = layers.LinkTypeRaw
+	if ll, ok := sa.(*unix.SockaddrLinklayer); ok {
+		switch ll.Hatype {
+		case unix.ARPHRD_ETHER:
+			h.linkType = layers.LinkTypeEthernet
+		case unix.ARPHRD_LOOPBACK:
+			// the loopback device of linux has a zero ethernet header
+			h.linkType, h.loopback = layers.LinkTypeEthernet, true
+		}
+	}
+	return nil
+}
+
+func (h *afPacketHandle) isClosed() bool {
+	return atomic.LoadInt32(&h.closed) != 0
+}
+
+func (h *afPacketHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	for {
+		if h.isClosed() {
+			return nil, gopacket.CaptureInfo{}, io.EOF
+		}
+		n, from, err := unix.Recvfrom(h.fd, h.buf, unix.MSG_TRUNC)
+		if err != nil {
+			if err == unix.EAGAIN || err == unix.EINTR {
+				continue
+			}
+			return nil, gopacket.CaptureInfo{}, err
+		}
+		// the packets sent by loopback are received twice
+		if ll, ok := from.(*unix.SockaddrLinklayer); ok && h.loopback && ll.Pkttype == unix.PACKET_OUTGOING {
+			continue
+		}
+		length := n
+		if n > len(h.buf) {
+			n = len(h.buf)
+		}
+		data := make([]byte, n)
+		copy(data, h.buf[:n])
+		return data, gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: n, Length: length}, nil
+	}
+}
+
+func (h *afPacketHandle) LinkType() layers.LinkType {
+	return h.linkType
+}
+
+// SetBPFFilter compile the filter and attach it to the socket, the filter runs in kernel
+func (h *afPacketHandle) SetBPFFilter(expr string) error {
+	insns, err := CompileBPF(expr, h.linkType, h.snaplen)
+	if err != nil {
+		return err
+	}
+	if insns == nil {
+		return nil
+	}
+	raws, err := bpf.Assemble(insns)
+	if err != nil {
+		return utils.Errorf("assemble bpf failed: %s", err)
+	}
+	filters := make([]unix.SockFilter, len(raws))
+	for i, raw := range raws {
+		filters[i] = unix.SockFilter{Code: raw.Op, Jt: raw.Jt, Jf: raw.Jf, K: raw.K}
+	}
+	prog := &unix.SockFprog{Len: uint16(len(filters)), Filter: &filters[0]}
+	if err := unix.SetsockoptSockFprog(h.fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, prog); err != nil {
+		return utils.Errorf("attach bpf filter failed: %s", err)
+	}
+	return nil
+}
+
+func (h *afPacketHandle) WritePacketData(data []byte) error {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+	if h.isClosed() {
+		return io.ErrClosedPipe
+	}
+	_, err := unix.Write(h.fd, data)
+	return err
+}
+
+func (h *afPacketHandle) Close() {
+	if !atomic.CompareAndSwapInt32(&h.closed, 0, 1) {
+		return
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	unix.Close(h.fd)
+}
@@ -0,0 +1,67 @@
+package pcaputil
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func TestAFPacketLoopback(t *testing.T) {
+	handle, err := openAFPacket("lo", defaultSnaplen, true)
+	if err != nil {
+		t.Skipf("open AF_PACKET socket failed (root required): %s", err)
+	}
+	defer handle.Close()
+
+	port := utils.GetRandomAvailableUDPPort()
+	if err := handle.SetBPFFilter(fmt.Sprintf("udp dst port %d", port)); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for i := 0; i < 5; i++ {
+			conn, err := net.Dial("udp", utils.HostPort("127.0.0.1", port))
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("yaklang"))
+			conn.Close()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	data, ci, err := handle.ReadPacketData()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ci.CaptureLength != len(data) {
+		t.Fatalf("capture length %d mismatch %d", ci.CaptureLength, len(data))
+	}
+	packet := gopacket.NewPacket(data, handle.LinkType(), gopacket.Default)
+	udp, ok := packet.Layer(layers.LayerTypeUDP).(*layers.UDP)
+	if !ok || int(udp.DstPort) != port || string(udp.Payload) != "yaklang" {
+		t.Fatalf("unexpected packet: %v", packet)
+	}
+
+	// close stops the blocking read
+	go handle.Close()
+	done := make(chan struct{})
+	go func() {
+		for {
+			if _, _, err := handle.ReadPacketData(); err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("read is not stopped by close")
+	}
+}
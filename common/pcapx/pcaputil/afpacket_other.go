@@ -0,0 +1,13 @@
+//go:build !linux
+
+package pcaputil
+
+import (
+	"runtime"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func openAFPacket(name string, snaplen int, promisc bool) (Handle, error) {
+	return nil, utils.Errorf("live capture without libpcap is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,813 @@
+package pcaputil
+
+import (
+	"encoding/binary"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+	"golang.org/x/net/bpf"
+)
+
+// the pure go bpf compiler supports the common subset of tcpdump filter expression:
+//
+//	[ether|ip|ip6|arp|tcp|udp|sctp|icmp|icmp6] [src|dst|src or dst|src and dst] [host|net|port|portrange] value
+//	ip proto N, ip6 proto N, ether proto N, less N, greater N
+//	ether|ip|tcp|udp|icmp [off(:size)] [& mask] =|==|!=|>|>=|<|<= value, such as "tcp[tcpflags] & (tcp-syn) != 0"
+//	and / &&, or / ||, not / !, ( )
+//
+// the transport header accessors (tcp[x], udp[x], icmp[x]) only match the ipv4 packets like libpcap,
+// and the qualifiers of previous primitive are reused when they are omitted, such as "port 80 or 443"
+
+const (
+	bpfLoadAbs = iota
+	// load from the transport header of ipv4, the header length of ipv4 is in X
+	bpfLoadIPv4Transport
+	bpfLoadLen
+)
+
+type bpfExpr interface{}
+
+type bpfAnd struct{ left, right bpfExpr }
+
+type bpfOr struct{ left, right bpfExpr }
+
+type bpfNot struct{ expr bpfExpr }
+
+type bpfConst bool
+
+// bpfCmp load a value, mask it and compare with val
+type bpfCmp struct {
+	load uint8
+	off  uint32
+	size int
+	mask uint32
+	cond bpf.JumpTest
+	val  uint32
+}
+
+func and(exprs ...bpfExpr) bpfExpr {
+	var ret bpfExpr
+	for _, e := range exprs {
+		if ret == nil {
+			ret = e
+		} else {
+			ret = &bpfAnd{ret, e}
+		}
+	}
+	if ret == nil {
+		return bpfConst(true)
+	}
+	return ret
+}
+
+func or(exprs ...bpfExpr) bpfExpr {
+	var ret bpfExpr
+	for _, e := range exprs {
+		if ret == nil {
+			ret = e
+		} else {
+			ret = &bpfOr{ret, e}
+		}
+	}
+	if ret == nil {
+		return bpfConst(false)
+	}
+	return ret
+}
+
+func cmpEq(off uint32, size int, val uint32) *bpfCmp {
+	return &bpfCmp{off: off, size: size, cond: bpf.JumpEqual, val: val}
+}
+
+// linkLayout is the offset of network layer and how to detect the network protocol
+type linkLayout struct {
+	linkType layers.LinkType
+	// network is the offset of network layer
+	network uint32
+	// etherType is the offset of ethernet type, -1 means the link has no ethernet type
+	etherType int
+}
+
+func newLinkLayout(linkType layers.LinkType) (*linkLayout, error) {
+	switch linkType {
+	case layers.LinkTypeEthernet:
+		return &linkLayout{linkType: linkType, network: 14, etherType: 12}, nil
+	case layers.LinkTypeLinuxSLL:
+		return &linkLayout{linkType: linkType, network: 16, etherType: 14}, nil
+	case layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6:
+		return &linkLayout{linkType: linkType, network: 0, etherType: -1}, nil
+	default:
+		return nil, utils.Errorf("bpf: unsupported link type %v", linkType)
+	}
+}
+
+func (l *linkLayout) etherProto(t layers.EthernetType) bpfExpr {
+	if l.etherType >= 0 {
+		return cmpEq(uint32(l.etherType), 2, uint32(t))
+	}
+	version := uint32(0)
+	switch t {
+	case layers.EthernetTypeIPv4:
+		if l.linkType == layers.LinkTypeIPv6 {
+			return bpfConst(false)
+		}
+		version = 0x40
+	case layers.EthernetTypeIPv6:
+		if l.linkType == layers.LinkTypeIPv4 {
+			return bpfConst(false)
+		}
+		version = 0x60
+	default:
+		return bpfConst(false)
+	}
+	return &bpfCmp{off: l.network, size: 1, mask: 0xf0, cond: bpf.JumpEqual, val: version}
+}
+
+func (l *linkLayout) ipv4() bpfExpr {
+	return l.etherProto(layers.EthernetTypeIPv4)
+}
+
+func (l *linkLayout) ipv6() bpfExpr {
+	return l.etherProto(layers.EthernetTypeIPv6)
+}
+
+func (l *linkLayout) arp() bpfExpr {
+	return l.etherProto(layers.EthernetTypeARP)
+}
+
+func (l *linkLayout) ipProto(proto uint32, v4, v6 bool) bpfExpr {
+	var exprs []bpfExpr
+	if v4 {
+		exprs = append(exprs, and(l.ipv4(), cmpEq(l.network+9, 1, proto)))
+	}
+	if v6 {
+		exprs = append(exprs, and(l.ipv6(), cmpEq(l.network+6, 1, proto)))
+	}
+	return or(exprs...)
+}
+
+func (l *linkLayout) ipv4Addr(off uint32, ip net.IP, mask net.IPMask) bpfExpr {
+	c := cmpEq(l.network+off, 4, binary.BigEndian.Uint32(ip.To4()))
+	if mask != nil {
+		c.mask = binary.BigEndian.Uint32(mask)
+		c.val &= c.mask
+		if c.mask == 0xffffffff {
+			c.mask = 0
+		}
+	}
+	return c
+}
+
+func (l *linkLayout) ipv6Addr(off uint32, ip net.IP, mask net.IPMask) bpfExpr {
+	ip = ip.To16()
+	var exprs []bpfExpr
+	for i := 0; i < 4; i++ {
+		m := uint32(0xffffffff)
+		if mask != nil {
+			m = binary.BigEndian.Uint32(mask[i*4:])
+		}
+		if m == 0 {
+			continue
+		}
+		c := cmpEq(l.network+off+uint32(i*4), 4, binary.BigEndian.Uint32(ip[i*4:])&m)
+		if m != 0xffffffff {
+			c.mask = m
+		}
+		exprs = append(exprs, c)
+	}
+	return and(exprs...)
+}
+
+// bpfPrimitive is the qualifiers and value of a primitive
+type bpfPrimitive struct {
+	proto string
+	dir   string
+	typ   string
+	value string
+}
+
+var (
+	bpfProtoKeywords = map[string]bool{"ether": true, "ip": true, "ip6": true, "arp": true, "tcp": true, "udp": true, "sctp": true, "icmp": true, "icmp6": true}
+	bpfTypeKeywords  = map[string]bool{"host": true, "net": true, "port": true, "portrange": true}
+	bpfIPProtocols   = map[string]uint32{"icmp": 1, "igmp": 2, "tcp": 6, "udp": 17, "icmp6": 58, "sctp": 132}
+	// the named offsets and values can be used in packet accessor
+	bpfAccessorConsts = map[string]uint32{
+		"tcpflags": 13, "tcp-fin": 0x01, "tcp-syn": 0x02, "tcp-rst": 0x04, "tcp-push": 0x08, "tcp-ack": 0x10, "tcp-urg": 0x20, "tcp-ece": 0x40, "tcp-cwr": 0x80,
+		"icmptype": 0, "icmpcode": 1, "icmp-echoreply": 0, "icmp-unreach": 3, "icmp-sourcequench": 4, "icmp-redirect": 5,
+		"icmp-echo": 8, "icmp-routeradvert": 9, "icmp-routersolicit": 10, "icmp-timxceed": 11, "icmp-paramprob": 12,
+		"icmp-tstamp": 13, "icmp-tstampreply": 14, "icmp-ireq": 15, "icmp-ireqreply": 16, "icmp-maskreq": 17, "icmp-maskreply": 18,
+	}
+	bpfRelations = map[string]bpf.JumpTest{
+		"=": bpf.JumpEqual, "==": bpf.JumpEqual, "!=": bpf.JumpNotEqual,
+		">": bpf.JumpGreaterThan, ">=": bpf.JumpGreaterOrEqual, "<": bpf.JumpLessThan, "<=": bpf.JumpLessOrEqual,
+	}
+)
+
+type bpfParser struct {
+	layout *linkLayout
+	tokens []string
+	pos    int
+	last   *bpfPrimitive
+}
+
+func tokenizeBPF(expr string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			flush()
+		case c == '!' && i+1 < len(expr) && expr[i+1] == '=':
+			flush()
+			tokens = append(tokens, "!=")
+			i++
+		case c == '(' || c == ')' || c == '!':
+			flush()
+			tokens = append(tokens, string(c))
+		case (c == '&' || c == '|') && i+1 < len(expr) && expr[i+1] == c:
+			flush()
+			tokens = append(tokens, expr[i:i+2])
+			i++
+		default:
+			word.WriteByte(c)
+		}
+	}
+	flush()
+	return tokens
+}
+
+func (p *bpfParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *bpfParser) peekAt(i int) string {
+	if p.pos+i < len(p.tokens) {
+		return p.tokens[p.pos+i]
+	}
+	return ""
+}
+
+func (p *bpfParser) next() string {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+func (p *bpfParser) parseOr() (bpfExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "or" || p.peek() == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &bpfOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *bpfParser) parseAnd() (bpfExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "and" || p.peek() == "&&" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &bpfAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *bpfParser) parseNot() (bpfExpr, error) {
+	switch p.peek() {
+	case "not", "!":
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &bpfNot{expr}, nil
+	case "(":
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ")" {
+			return nil, utils.Error("bpf: missing )")
+		}
+		return expr, nil
+	case "":
+		return nil, utils.Error("bpf: unexpected end of expression")
+	}
+	return p.parsePrimitive()
+}
+
+func isBPFEnd(t string) bool {
+	switch t {
+	case "", ")", "and", "&&", "or", "||":
+		return true
+	}
+	return false
+}
+
+func (p *bpfParser) parseNumber() (uint32, error) {
+	t := p.next()
+	n, err := strconv.ParseUint(t, 0, 32)
+	if err != nil {
+		return 0, utils.Errorf("bpf: invalid number %q", t)
+	}
+	return uint32(n), nil
+}
+
+func (p *bpfParser) parsePrimitive() (bpfExpr, error) {
+	switch p.peek() {
+	case "less", "greater":
+		op := p.next()
+		n, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+		if op == "less" {
+			return &bpfNot{&bpfCmp{load: bpfLoadLen, cond: bpf.JumpGreaterThan, val: n}}, nil
+		}
+		return &bpfCmp{load: bpfLoadLen, cond: bpf.JumpGreaterOrEqual, val: n}, nil
+	}
+
+	if strings.HasSuffix(p.peek(), "]") {
+		return p.accessor()
+	}
+
+	prim := &bpfPrimitive{}
+	if bpfProtoKeywords[p.peek()] {
+		prim.proto = p.next()
+		if p.peek() == "proto" {
+			p.next()
+			return p.protoNumber(prim.proto)
+		}
+		if isBPFEnd(p.peek()) {
+			return p.protocol(prim.proto)
+		}
+	}
+	if t := p.peek(); t == "src" || t == "dst" {
+		prim.dir = p.next()
+		if c := p.peek(); (c == "or" || c == "and") && (p.peekAt(1) == "src" || p.peekAt(1) == "dst") {
+			prim.dir = prim.dir + " " + p.next() + " " + p.next()
+		}
+	}
+	if bpfTypeKeywords[p.peek()] {
+		prim.typ = p.next()
+	}
+	if isBPFEnd(p.peek()) {
+		return nil, utils.Errorf("bpf: missing value after %q", p.tokens[p.pos-1])
+	}
+	prim.value = p.next()
+	if prim.typ == "net" && p.peek() == "mask" {
+		p.next()
+		prim.value += "/" + p.next()
+	}
+
+	if prim.proto == "" && prim.dir == "" && prim.typ == "" {
+		if p.last == nil {
+			return nil, utils.Errorf("bpf: unknown primitive %q", prim.value)
+		}
+		prim.proto, prim.dir, prim.typ = p.last.proto, p.last.dir, p.last.typ
+	}
+	if prim.typ == "" {
+		prim.typ = "host"
+	}
+	p.last = prim
+	return p.primitive(prim)
+}
+
+func (p *bpfParser) protocol(proto string) (bpfExpr, error) {
+	l := p.layout
+	switch proto {
+	case "ip":
+		return l.ipv4(), nil
+	case "ip6":
+		return l.ipv6(), nil
+	case "arp":
+		return l.arp(), nil
+	case "tcp", "udp", "sctp":
+		return l.ipProto(bpfIPProtocols[proto], true, true), nil
+	case "icmp":
+		return l.ipProto(1, true, false), nil
+	case "icmp6":
+		return l.ipProto(58, false, true), nil
+	}
+	return nil, utils.Errorf("bpf: %q is not a protocol", proto)
+}
+
+func (p *bpfParser) protoNumber(proto string) (bpfExpr, error) {
+	var n uint32
+	if v, ok := bpfIPProtocols[strings.TrimPrefix(p.peek(), "\\")]; ok {
+		p.next()
+		n = v
+	} else {
+		var err error
+		n, err = p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch proto {
+	case "ip":
+		return p.layout.ipProto(n, true, false), nil
+	case "ip6":
+		return p.layout.ipProto(n, false, true), nil
+	case "ether":
+		return p.layout.etherProto(layers.EthernetType(n)), nil
+	}
+	return nil, utils.Errorf("bpf: %s proto is not supported", proto)
+}
+
+// accessorConst parse the number or named constant, the names can be combined by "|" such as (tcp-syn|tcp-ack)
+func (p *bpfParser) accessorConst() (uint32, error) {
+	paren := p.peek() == "("
+	if paren {
+		p.next()
+	}
+	t := p.next()
+	var ret uint32
+	for _, item := range strings.Split(t, "|") {
+		if v, ok := bpfAccessorConsts[item]; ok {
+			ret |= v
+			continue
+		}
+		n, err := strconv.ParseUint(item, 0, 32)
+		if err != nil {
+			return 0, utils.Errorf("bpf: invalid value %q", t)
+		}
+		ret |= uint32(n)
+	}
+	if paren && p.next() != ")" {
+		return 0, utils.Error("bpf: missing )")
+	}
+	return ret, nil
+}
+
+// accessor parse the packet accessor: proto[off(:size)] [& mask] relation value
+func (p *bpfParser) accessor() (bpfExpr, error) {
+	t := p.next()
+	proto, index, ok := strings.Cut(strings.TrimSuffix(t, "]"), "[")
+	if !ok {
+		return nil, utils.Errorf("bpf: invalid accessor %q", t)
+	}
+	offStr, sizeStr, hasSize := strings.Cut(index, ":")
+	off, ok := bpfAccessorConsts[offStr]
+	if !ok {
+		n, err := strconv.ParseUint(offStr, 0, 32)
+		if err != nil {
+			return nil, utils.Errorf("bpf: invalid offset %q", offStr)
+		}
+		off = uint32(n)
+	}
+	size := 1
+	if hasSize {
+		size, _ = strconv.Atoi(sizeStr)
+		if size != 1 && size != 2 && size != 4 {
+			return nil, utils.Errorf("bpf: invalid size %q", sizeStr)
+		}
+	}
+
+	c := &bpfCmp{off: off, size: size}
+	if p.peek() == "&" {
+		p.next()
+		mask, err := p.accessorConst()
+		if err != nil {
+			return nil, err
+		}
+		if mask == 0 {
+			return nil, utils.Error("bpf: mask 0 is not supported")
+		}
+		c.mask = mask
+	}
+	cond, ok := bpfRelations[p.next()]
+	if !ok {
+		return nil, utils.Errorf("bpf: missing relation after %q", t)
+	}
+	c.cond = cond
+	val, err := p.accessorConst()
+	if err != nil {
+		return nil, err
+	}
+	c.val = val
+
+	l := p.layout
+	switch proto {
+	case "ether":
+		if l.linkType != layers.LinkTypeEthernet {
+			return nil, utils.Error("bpf: ether accessor is only supported on ethernet")
+		}
+		return c, nil
+	case "ip":
+		c.off += l.network
+		return and(l.ipv4(), c), nil
+	case "tcp", "udp", "icmp":
+		c.load = bpfLoadIPv4Transport
+		notFragment := &bpfNot{&bpfCmp{off: l.network + 6, size: 2, cond: bpf.JumpBitsSet, val: 0x1fff}}
+		return and(l.ipv4(), cmpEq(l.network+9, 1, bpfIPProtocols[proto]), notFragment, c), nil
+	}
+	return nil, utils.Errorf("bpf: %s accessor is not supported", proto)
+}
+
+// direction combine the src and dst expressions by the direction qualifier
+func direction(dir string, src, dst bpfExpr) bpfExpr {
+	switch dir {
+	case "src":
+		return src
+	case "dst":
+		return dst
+	case "src and dst", "dst and src":
+		return and(src, dst)
+	default:
+		return or(src, dst)
+	}
+}
+
+func (p *bpfParser) primitive(prim *bpfPrimitive) (bpfExpr, error) {
+	switch prim.typ {
+	case "host", "net":
+		if prim.proto == "ether" {
+			return p.etherHost(prim)
+		}
+		return p.host(prim)
+	case "port", "portrange":
+		return p.port(prim)
+	}
+	return nil, utils.Errorf("bpf: unknown type %q", prim.typ)
+}
+
+func (p *bpfParser) etherHost(prim *bpfPrimitive) (bpfExpr, error) {
+	if p.layout.linkType != layers.LinkTypeEthernet || prim.typ != "host" {
+		return nil, utils.Errorf("bpf: ether %s is only supported on ethernet", prim.typ)
+	}
+	mac, err := net.ParseMAC(prim.value)
+	if err != nil || len(mac) != 6 {
+		return nil, utils.Errorf("bpf: invalid mac %q", prim.value)
+	}
+	addr := func(off uint32) bpfExpr {
+		return and(cmpEq(off, 4, binary.BigEndian.Uint32(mac)), cmpEq(off+4, 2, uint32(binary.BigEndian.Uint16(mac[4:]))))
+	}
+	return direction(prim.dir, addr(6), addr(0)), nil
+}
+
+func (p *bpfParser) host(prim *bpfPrimitive) (bpfExpr, error) {
+	var ip net.IP
+	var mask net.IPMask
+	if prim.typ == "net" || strings.Contains(prim.value, "/") {
+		value := prim.value
+		if !strings.Contains(value, "/") {
+			value += "/32"
+			if strings.Contains(value, ":") {
+				value = prim.value + "/128"
+			}
+		}
+		before, after, _ := strings.Cut(value, "/")
+		if m := net.ParseIP(after); m != nil && m.To4() != nil {
+			// net 192.168.0.0 mask 255.255.0.0
+			ip, mask = net.ParseIP(before), net.IPMask(m.To4())
+		} else {
+			_, ipNet, err := net.ParseCIDR(value)
+			if err != nil {
+				return nil, utils.Errorf("bpf: invalid net %q", prim.value)
+			}
+			ip, mask = ipNet.IP, ipNet.Mask
+		}
+	} else {
+		ip = net.ParseIP(prim.value)
+	}
+	if ip == nil {
+		return nil, utils.Errorf("bpf: invalid host %q", prim.value)
+	}
+
+	l := p.layout
+	var exprs []bpfExpr
+	if ip4 := ip.To4(); ip4 != nil && len(mask) != net.IPv6len {
+		if prim.proto == "" || prim.proto == "ip" || prim.proto == "tcp" || prim.proto == "udp" || prim.proto == "sctp" || prim.proto == "icmp" {
+			exprs = append(exprs, and(l.ipv4(), direction(prim.dir, l.ipv4Addr(12, ip4, mask), l.ipv4Addr(16, ip4, mask))))
+		}
+		if prim.proto == "" || prim.proto == "arp" {
+			exprs = append(exprs, and(l.arp(), direction(prim.dir, l.ipv4Addr(14, ip4, mask), l.ipv4Addr(24, ip4, mask))))
+		}
+	} else if prim.proto == "" || prim.proto == "ip6" || prim.proto == "tcp" || prim.proto == "udp" || prim.proto == "sctp" || prim.proto == "icmp6" {
+		exprs = append(exprs, and(l.ipv6(), direction(prim.dir, l.ipv6Addr(8, ip, mask), l.ipv6Addr(24, ip, mask))))
+	}
+	if len(exprs) == 0 {
+		return nil, utils.Errorf("bpf: %s %s is not supported", prim.proto, prim.typ)
+	}
+	ret := or(exprs...)
+	switch prim.proto {
+	case "tcp", "udp", "sctp", "icmp", "icmp6":
+		proto, _ := p.protocol(prim.proto)
+		ret = and(proto, ret)
+	}
+	return ret, nil
+}
+
+func (p *bpfParser) port(prim *bpfPrimitive) (bpfExpr, error) {
+	lo, hi := prim.value, prim.value
+	if prim.typ == "portrange" {
+		var ok bool
+		lo, hi, ok = strings.Cut(prim.value, "-")
+		if !ok {
+			return nil, utils.Errorf("bpf: invalid port range %q", prim.value)
+		}
+	}
+	low, err := strconv.ParseUint(lo, 10, 16)
+	if err != nil {
+		return nil, utils.Errorf("bpf: invalid port %q", prim.value)
+	}
+	high, err := strconv.ParseUint(hi, 10, 16)
+	if err != nil || high < low {
+		return nil, utils.Errorf("bpf: invalid port %q", prim.value)
+	}
+
+	var protos []uint32
+	switch prim.proto {
+	case "":
+		protos = []uint32{6, 17, 132}
+	case "tcp", "udp", "sctp":
+		protos = []uint32{bpfIPProtocols[prim.proto]}
+	default:
+		return nil, utils.Errorf("bpf: %s port is not supported", prim.proto)
+	}
+
+	match := func(load uint8, off uint32) bpfExpr {
+		if low == high {
+			return &bpfCmp{load: load, off: off, size: 2, cond: bpf.JumpEqual, val: uint32(low)}
+		}
+		return and(
+			&bpfCmp{load: load, off: off, size: 2, cond: bpf.JumpGreaterOrEqual, val: uint32(low)},
+			&bpfNot{&bpfCmp{load: load, off: off, size: 2, cond: bpf.JumpGreaterThan, val: uint32(high)}},
+		)
+	}
+
+	l := p.layout
+	var v4Protos, v6Protos []bpfExpr
+	for _, proto := range protos {
+		v4Protos = append(v4Protos, cmpEq(l.network+9, 1, proto))
+		v6Protos = append(v6Protos, cmpEq(l.network+6, 1, proto))
+	}
+	// the ports are only in the first fragment of ipv4
+	notFragment := &bpfNot{&bpfCmp{off: l.network + 6, size: 2, cond: bpf.JumpBitsSet, val: 0x1fff}}
+	v4 := and(l.ipv4(), or(v4Protos...), notFragment, direction(prim.dir, match(bpfLoadIPv4Transport, 0), match(bpfLoadIPv4Transport, 2)))
+	v6 := and(l.ipv6(), or(v6Protos...), direction(prim.dir, match(bpfLoadAbs, l.network+40), match(bpfLoadAbs, l.network+42)))
+	return or(v4, v6), nil
+}
+
+type bpfInsn struct {
+	ins bpf.Instruction
+	// the labels of jump, -1 means not a jump
+	jt, jf int
+	// ja is the label of unconditional jump
+	ja int
+}
+
+type bpfCodegen struct {
+	layout *linkLayout
+	insns  []*bpfInsn
+	labels []int
+}
+
+func (g *bpfCodegen) newLabel() int {
+	g.labels = append(g.labels, -1)
+	return len(g.labels) - 1
+}
+
+func (g *bpfCodegen) place(label int) {
+	g.labels[label] = len(g.insns)
+}
+
+func (g *bpfCodegen) emit(ins bpf.Instruction) {
+	g.insns = append(g.insns, &bpfInsn{ins: ins, jt: -1, jf: -1, ja: -1})
+}
+
+func (g *bpfCodegen) gen(expr bpfExpr, t, f int) {
+	switch ret := expr.(type) {
+	case *bpfAnd:
+		mid := g.newLabel()
+		g.gen(ret.left, mid, f)
+		g.place(mid)
+		g.gen(ret.right, t, f)
+	case *bpfOr:
+		mid := g.newLabel()
+		g.gen(ret.left, t, mid)
+		g.place(mid)
+		g.gen(ret.right, t, f)
+	case *bpfNot:
+		g.gen(ret.expr, f, t)
+	case bpfConst:
+		target := f
+		if ret {
+			target = t
+		}
+		g.insns = append(g.insns, &bpfInsn{ins: bpf.Jump{}, jt: -1, jf: -1, ja: target})
+	case *bpfCmp:
+		switch ret.load {
+		case bpfLoadLen:
+			g.emit(bpf.LoadExtension{Num: bpf.ExtLen})
+		case bpfLoadIPv4Transport:
+			g.emit(bpf.LoadMemShift{Off: g.layout.network})
+			g.emit(bpf.LoadIndirect{Off: g.layout.network + ret.off, Size: ret.size})
+		default:
+			g.emit(bpf.LoadAbsolute{Off: ret.off, Size: ret.size})
+		}
+		if ret.mask != 0 {
+			g.emit(bpf.ALUOpConstant{Op: bpf.ALUOpAnd, Val: ret.mask})
+		}
+		g.insns = append(g.insns, &bpfInsn{ins: bpf.JumpIf{Cond: ret.cond, Val: ret.val}, jt: t, jf: f, ja: -1})
+	}
+}
+
+func (g *bpfCodegen) resolve() ([]bpf.Instruction, error) {
+	ret := make([]bpf.Instruction, 0, len(g.insns))
+	skip := func(from, label int) (int, error) {
+		s := g.labels[label] - from - 1
+		if s < 0 {
+			return 0, utils.Error("bpf: backward jump")
+		}
+		return s, nil
+	}
+	for i, insn := range g.insns {
+		switch {
+		case insn.ja >= 0:
+			s, err := skip(i, insn.ja)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, bpf.Jump{Skip: uint32(s)})
+		case insn.jt >= 0:
+			jt, err := skip(i, insn.jt)
+			if err != nil {
+				return nil, err
+			}
+			jf, err := skip(i, insn.jf)
+			if err != nil {
+				return nil, err
+			}
+			if jt > 255 || jf > 255 {
+				return nil, utils.Error("bpf: expression is too complex")
+			}
+			jump := insn.ins.(bpf.JumpIf)
+			jump.SkipTrue, jump.SkipFalse = uint8(jt), uint8(jf)
+			ret = append(ret, jump)
+		default:
+			ret = append(ret, insn.ins)
+		}
+	}
+	return ret, nil
+}
+
+// CompileBPF compile the tcpdump filter expression to classic bpf without libpcap,
+// the packet matched is truncated to snaplen, empty expression returns nil
+func CompileBPF(expr string, linkType layers.LinkType, snaplen int) ([]bpf.Instruction, error) {
+	tokens := tokenizeBPF(expr)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	layout, err := newLinkLayout(linkType)
+	if err != nil {
+		return nil, err
+	}
+	p := &bpfParser{layout: layout, tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, utils.Errorf("bpf: unexpected %q", p.peek())
+	}
+
+	g := &bpfCodegen{layout: layout}
+	accept, reject := g.newLabel(), g.newLabel()
+	g.gen(root, accept, reject)
+	g.place(accept)
+	g.emit(bpf.RetConstant{Val: uint32(snaplen)})
+	g.place(reject)
+	g.emit(bpf.RetConstant{Val: 0})
+	return g.resolve()
+}
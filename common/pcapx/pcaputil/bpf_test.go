@@ -0,0 +1,152 @@
+package pcaputil
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+var (
+	testSrcMAC = net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	testDstMAC = net.HardwareAddr{0x66, 0x77, 0x88, 0x99, 0xaa, 0xbb}
+)
+
+func testBPFPackets(t *testing.T) map[string][]byte {
+	eth := func(typ layers.EthernetType) *layers.Ethernet {
+		return &layers.Ethernet{SrcMAC: testSrcMAC, DstMAC: testDstMAC, EthernetType: typ}
+	}
+	ip4 := func(proto layers.IPProtocol) *layers.IPv4 {
+		return &layers.IPv4{Version: 4, IHL: 5, TTL: 64, Protocol: proto, SrcIP: net.ParseIP("192.168.1.10").To4(), DstIP: net.ParseIP("10.0.0.1").To4()}
+	}
+	ip6 := &layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolUDP, SrcIP: net.ParseIP("2001:db8::1"), DstIP: net.ParseIP("2001:db8::2")}
+
+	// the ipv4 header with options, the port is at the offset of ihl
+	optIP := ip4(layers.IPProtocolTCP)
+	optIP.IHL = 6
+	optIP.Options = []layers.IPv4Option{{OptionType: 1}, {OptionType: 1}, {OptionType: 1}, {OptionType: 0}}
+	fragment := ip4(layers.IPProtocolTCP)
+	fragment.FragOffset = 100
+
+	return map[string][]byte{
+		"tcp4":     serializeLayers(t, eth(layers.EthernetTypeIPv4), ip4(layers.IPProtocolTCP), &layers.TCP{SrcPort: 51234, DstPort: 80}),
+		"tcp4opt":  serializeLayers(t, eth(layers.EthernetTypeIPv4), optIP, &layers.TCP{SrcPort: 51234, DstPort: 443, SYN: true, ACK: true}, gopacket.Payload(make([]byte, 64))),
+		"udp4":     serializeLayers(t, eth(layers.EthernetTypeIPv4), ip4(layers.IPProtocolUDP), &layers.UDP{SrcPort: 5353, DstPort: 53}),
+		"icmp4":    serializeLayers(t, eth(layers.EthernetTypeIPv4), ip4(layers.IPProtocolICMPv4), &layers.ICMPv4{TypeCode: layers.CreateICMPv4TypeCode(8, 0)}),
+		"fragment": serializeLayers(t, eth(layers.EthernetTypeIPv4), fragment, gopacket.Payload([]byte{0x00, 0x50, 0x00, 0x50})),
+		"udp6":     serializeLayers(t, eth(layers.EthernetTypeIPv6), ip6, &layers.UDP{SrcPort: 546, DstPort: 8080}),
+		"arp": serializeLayers(t, eth(layers.EthernetTypeARP), &layers.ARP{
+			AddrType: layers.LinkTypeEthernet, Protocol: layers.EthernetTypeIPv4, HwAddressSize: 6, ProtAddressSize: 4, Operation: layers.ARPRequest,
+			SourceHwAddress: testSrcMAC, SourceProtAddress: net.ParseIP("192.168.1.10").To4(),
+			DstHwAddress: make([]byte, 6), DstProtAddress: net.ParseIP("192.168.1.1").To4(),
+		}),
+	}
+}
+
+func runBPF(t *testing.T, expr string, linkType layers.LinkType, data []byte) bool {
+	insns, err := CompileBPF(expr, linkType, 65535)
+	if err != nil {
+		t.Fatalf("compile %q failed: %s", expr, err)
+	}
+	vm, err := bpf.NewVM(insns)
+	if err != nil {
+		t.Fatalf("load %q failed: %s", expr, err)
+	}
+	n, err := vm.Run(data)
+	if err != nil {
+		t.Fatalf("run %q failed: %s", expr, err)
+	}
+	return n > 0
+}
+
+func TestCompileBPF(t *testing.T) {
+	packets := testBPFPackets(t)
+	for _, c := range []struct {
+		expr    string
+		matched []string
+	}{
+		{"tcp", []string{"tcp4", "tcp4opt", "fragment"}},
+		{"udp", []string{"udp4", "udp6"}},
+		{"icmp", []string{"icmp4"}},
+		{"arp", []string{"arp"}},
+		{"ip6", []string{"udp6"}},
+		{"not ip", []string{"udp6", "arp"}},
+		{"port 80", []string{"tcp4"}},
+		{"tcp port 443", []string{"tcp4opt"}},
+		{"udp port 80", nil},
+		{"dst port 53 or 8080", []string{"udp4", "udp6"}},
+		{"src port 51234 and dst port 80", []string{"tcp4"}},
+		{"portrange 50-100", []string{"tcp4", "udp4"}},
+		{"host 10.0.0.1", []string{"tcp4", "tcp4opt", "udp4", "icmp4", "fragment"}},
+		{"src host 10.0.0.1", nil},
+		{"host 192.168.1.1", []string{"arp"}},
+		{"net 192.168.0.0/16 and udp", []string{"udp4"}},
+		{"dst net 10.0.0.0 mask 255.0.0.0 && !tcp", []string{"udp4", "icmp4"}},
+		{"host 2001:db8::2", []string{"udp6"}},
+		{"src net 2001:db8::/32", []string{"udp6"}},
+		{"ip proto 17 || ether proto 0x806", []string{"udp4", "arp"}},
+		{"ip6 proto udp", []string{"udp6"}},
+		{"ether src 00:11:22:33:44:55 and (arp or icmp)", []string{"icmp4", "arp"}},
+		{"greater 100", []string{"tcp4opt"}},
+		{"tcp[tcpflags] & (tcp-syn) != 0", []string{"tcp4opt"}},
+		{"(arp) or (tcp[tcpflags] & (tcp-syn|tcp-ack) == 0x12)", []string{"tcp4opt", "arp"}},
+		{"tcp[13] = 0", []string{"tcp4"}},
+		{"icmp[icmptype] == icmp-echo", []string{"icmp4"}},
+		{"ip[9] = 17 or ether[12:2] = 0x806", []string{"udp4", "arp"}},
+		{"udp[2:2] >= 50 and udp[2:2] < 100", []string{"udp4"}},
+		// the ethernet frames are padded to 60 bytes
+		{"less 60 and not tcp", []string{"udp4", "icmp4", "arp"}},
+	} {
+		matched := map[string]bool{}
+		for _, name := range c.matched {
+			matched[name] = true
+		}
+		for name, data := range packets {
+			if got := runBPF(t, c.expr, layers.LinkTypeEthernet, data); got != matched[name] {
+				t.Errorf("%q on %s: expect %v, got %v", c.expr, name, matched[name], got)
+			}
+		}
+	}
+}
+
+func TestCompileBPFRaw(t *testing.T) {
+	for name, data := range testBPFPackets(t) {
+		if name == "arp" {
+			continue
+		}
+		// strip the ethernet header
+		data = data[14:]
+		if got := runBPF(t, "udp port 53 or ip6", layers.LinkTypeRaw, data); got != (name == "udp4" || name == "udp6") {
+			t.Errorf("raw %s: got %v", name, got)
+		}
+	}
+}
+
+func TestCompileBPFError(t *testing.T) {
+	for _, expr := range []string{
+		"80",
+		"port",
+		"host 1.2.3",
+		"tcp and (port 80",
+		"port 80 )",
+		"portrange 100-50",
+		"icmp port 80",
+		"foo bar",
+		"tcp[13] 2",
+		"tcp[0:3] = 1",
+		"tcp[tcpflags] & 0 != 0",
+		"ip6[0] = 0x60",
+	} {
+		if _, err := CompileBPF(expr, layers.LinkTypeEthernet, 65535); err == nil {
+			t.Errorf("%q should fail", expr)
+		}
+	}
+	if insns, err := CompileBPF("  ", layers.LinkTypeEthernet, 65535); err != nil || insns != nil {
+		t.Errorf("empty filter should be nil")
+	}
+	if _, err := CompileBPF("tcp", layers.LinkTypeNull, 65535); err == nil {
+		t.Errorf("unsupported link type should fail")
+	}
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"fmt"
 	"github.com/google/gopacket"
-	"github.com/google/gopacket/pcap"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 )
 
-func _open(ctx context.Context, handler *pcap.Handle, bpf string, packetEntry func(context.Context, gopacket.Packet)) error {
+func _open(ctx context.Context, handler Handle, bpf string, packetEntry func(context.Context, gopacket.Packet)) error {
 	innerCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
@@ -45,7 +44,7 @@ func Start(opt ...CaptureOption) error {
 		}
 	}
 
-	var handlers []*pcap.Handle
+	var handlers []Handle
 	if conf.Filename != "" {
 		handler, err := OpenFile(conf.Filename)
 		if err != nil {
@@ -54,9 +53,9 @@ func Start(opt ...CaptureOption) error {
 			handlers = append(handlers, handler)
 		}
 	} else if len(conf.Device) == 0 {
-		var ifs, err = pcap.FindAllDevs()
+		ifs, err := FindAllDevices()
 		if err != nil {
-			return utils.Errorf("(pcap) find all devs failed: %s", err)
+			return err
 		}
 
 		if len(ifs) > 128 {
@@ -108,7 +107,7 @@ func Start(opt ...CaptureOption) error {
 	for _, p := range conf.onPoolCreated {
 		p(conf.trafficPool)
 	}
-	utils.WaitRoutinesFromSlice(handlers, func(handler *pcap.Handle) {
+	utils.WaitRoutinesFromSlice(handlers, func(handler Handle) {
 		defer func() {
 			handler.Close()
 		}()
@@ -3,6 +3,7 @@ package pcaputil
 import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/gopacket"
+	"path/filepath"
 	"testing"
 )
 
@@ -10,7 +11,7 @@ func TestStart(t *testing.T) {
 	err := Start(
 		WithDebug(false),
 		WithDevice("WLAN"),
-		WithOutput("./output.pcap"),
+		WithOutput(filepath.Join(t.TempDir(), "output.pcap")),
 	)
 	if err != nil {
 		t.Error(err)
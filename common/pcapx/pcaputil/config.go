@@ -7,7 +7,6 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcapgo"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
@@ -20,17 +19,20 @@ import (
 type CaptureConfig struct {
 	Device    []string
 	Filename  string
-	Output    *pcapgo.Writer
+	Output    PacketWriter
 	BPFFilter string
 	Context   context.Context
 
-	trafficPool *TrafficPool
+	trafficPool  *TrafficPool
+	defragmenter *defragmenter
 
 	// output debug info
 	Debug         bool
 	onPoolCreated []func(*TrafficPool)
 	onFlowCreated func(*TrafficFlow)
 	onEveryPacket func(packet gopacket.Packet)
+	// onReassembledPacket is called with the packets after defragmentation
+	onReassembledPacket func(packet gopacket.Packet)
 }
 
 type CaptureOption func(*CaptureConfig) error
@@ -46,6 +48,36 @@ func WithEveryPacket(h func(packet gopacket.Packet)) CaptureOption {
 	}
 }
 
+// WithReassembledPacket handle the packets after defragmentation, the fragments are not delivered,
+// the datagram is delivered once when the last fragment arrived
+func WithReassembledPacket(h func(packet gopacket.Packet)) CaptureOption {
+	return func(c *CaptureConfig) error {
+		c.onReassembledPacket = h
+		return nil
+	}
+}
+
+// WithUDPFlowTimeout set the inactive timeout of udp pseudo-flows, default is 30s
+func WithUDPFlowTimeout(timeout time.Duration) CaptureOption {
+	return withPool(func(pool *TrafficPool) {
+		if timeout > 0 {
+			pool.udpTimeout = timeout
+		}
+	})
+}
+
+// WithDefragment reassemble the ipv4 / ipv6 fragments before feeding traffic flows, default is true
+func WithDefragment(b bool) CaptureOption {
+	return func(c *CaptureConfig) error {
+		if b {
+			c.defragmenter = newDefragmenter()
+		} else {
+			c.defragmenter = nil
+		}
+		return nil
+	}
+}
+
 func WithBPFFilter(bpf string) CaptureOption {
 	return func(c *CaptureConfig) error {
 		c.BPFFilter = bpf
@@ -117,14 +149,16 @@ func WithDevice(devs ...string) CaptureOption {
 	}
 }
 
+// WithOutput save the captured packets to file, the file is pcapng if the filename ends with .pcapng
 func WithOutput(filename string) CaptureOption {
 	return func(c *CaptureConfig) error {
-		file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY, 0644)
+		file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
 		if err != nil {
 			return errors.New("open file failed")
 		}
-		c.Output = pcapgo.NewWriter(file)
-		return c.Output.WriteFileHeader(65535, layers.LinkTypeEthernet)
+		pcapng := strings.HasSuffix(strings.ToLower(filename), ".pcapng")
+		c.Output, err = newPacketWriter(file, pcapng, layers.LinkTypeEthernet)
+		return err
 	}
 }
 
@@ -185,15 +219,19 @@ func WithHTTPFlow(h func(flow *TrafficFlow, req *http.Request, rsp *http.Respons
 	})
 }
 
-func (c *CaptureConfig) assemblyWithTS(flow gopacket.Packet, networkLayer gopacket.SerializableLayer, tcp *layers.TCP, ts time.Time) {
+func (c *CaptureConfig) assemblyWithTS(flow gopacket.Packet, ethernet *layers.Ethernet, networkLayer gopacket.SerializableLayer, transport gopacket.Layer, ts time.Time) {
 	defer func() {
 		if err := recover(); err != nil {
-			log.Errorf("assembly panic with: %s\n    FLOW: %v\n    TCP: \n%v\n    Payload:\n%v", err, flow.String(), spew.Sdump(tcp.LayerContents()), spew.Sdump(tcp.Payload))
+			log.Errorf("assembly panic with: %s\n    FLOW: %v\n    TRANSPORT: \n%v\n    Payload:\n%v", err, flow.String(), spew.Sdump(transport.LayerContents()), spew.Sdump(transport.LayerPayload()))
 			utils.PrintCurrentGoroutineRuntimeStack()
 		}
 	}()
-	raw, _ := flow.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
-	c.trafficPool.Feed(raw, networkLayer, tcp)
+	switch ret := transport.(type) {
+	case *layers.TCP:
+		c.trafficPool.Feed(ethernet, networkLayer, ret)
+	case *layers.UDP:
+		c.trafficPool.FeedUDP(ethernet, networkLayer, ret)
+	}
 	//if c.Assembler != nil {
 	//	if tcp.Payload == nil {
 	//		return
@@ -218,24 +256,41 @@ func (c *CaptureConfig) packetHandler(ctx context.Context, packet gopacket.Packe
 		ts = time.Now()
 	}
 
+	// every captured packet is delivered, the reassembled packet of fragments is delivered by onReassembledPacket
+	original := packet
 	defer func() {
 		if c.onEveryPacket != nil {
-			c.onEveryPacket(packet)
+			c.onEveryPacket(original)
 		}
 	}()
 
-	var matched bool
-	ret, isOk := packet.TransportLayer().(*layers.TCP)
-	if !isOk || ret == nil {
-		return
+	// the reassembled packet use the ethernet of the last fragment
+	ethernet, _ := packet.Layer(layers.LayerTypeEthernet).(*layers.Ethernet)
+	if c.defragmenter != nil {
+		reassembled, err := c.defragmenter.defrag(packet)
+		if err != nil {
+			log.Debugf("defrag failed: %s", err)
+			return
+		}
+		if reassembled == nil {
+			// wait for more fragments
+			if save {
+				c.Save(original)
+			}
+			return
+		}
+		packet = reassembled
+	}
+	if c.onReassembledPacket != nil {
+		c.onReassembledPacket(packet)
 	}
 
-	if netIPv4Layer, ipv4ok := packet.NetworkLayer().(*layers.IPv4); ipv4ok {
-		c.assemblyWithTS(packet, netIPv4Layer, ret, ts)
-	} else if netIPv6Layer, ipv6ok := packet.NetworkLayer().(*layers.IPv6); ipv6ok {
-		c.assemblyWithTS(packet, netIPv6Layer, ret, ts)
-	} else {
-		log.Warnf("unknown network layer: %v", packet.NetworkLayer())
+	var matched bool
+	transport := packet.TransportLayer()
+	switch transport.(type) {
+	case *layers.TCP, *layers.UDP:
+	default:
+		return
 	}
 
 	if c.Debug && !matched {
@@ -243,10 +298,18 @@ func (c *CaptureConfig) packetHandler(ctx context.Context, packet gopacket.Packe
 	}
 
 	if save {
-		c.Save(packet)
+		c.Save(original)
+	}
+
+	if netIPv4Layer, ipv4ok := packet.NetworkLayer().(*layers.IPv4); ipv4ok {
+		c.assemblyWithTS(packet, ethernet, netIPv4Layer, transport, ts)
+	} else if netIPv6Layer, ipv6ok := packet.NetworkLayer().(*layers.IPv6); ipv6ok {
+		c.assemblyWithTS(packet, ethernet, netIPv6Layer, transport, ts)
+	} else {
+		log.Warnf("unknown network layer: %v", packet.NetworkLayer())
 	}
 }
 
 func NewDefaultConfig() *CaptureConfig {
-	return &CaptureConfig{}
+	return &CaptureConfig{defragmenter: newDefragmenter()}
 }
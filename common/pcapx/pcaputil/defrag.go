@@ -0,0 +1,184 @@
+package pcaputil
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/ip4defrag"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	defaultFragmentTimeout = 30 * time.Second
+	fragmentGCInterval     = 1024
+	// the max size of ipv6 payload without jumbogram
+	maxIPv6FragmentedPayload = 65535
+)
+
+type ipv6Fragment struct {
+	offset int
+	data   []byte
+}
+
+type ipv6FragmentList struct {
+	fragments []*ipv6Fragment
+	// total is the size of payload, known after the last fragment is received
+	total    int
+	received int
+	lastSeen time.Time
+}
+
+// defragmenter reassemble the ipv4 / ipv6 fragments
+type defragmenter struct {
+	lock  sync.Mutex
+	ipv4  *ip4defrag.IPv4Defragmenter
+	ipv6  map[string]*ipv6FragmentList
+	count int
+}
+
+func newDefragmenter() *defragmenter {
+	return &defragmenter{
+		ipv4: ip4defrag.NewIPv4Defragmenter(),
+		ipv6: make(map[string]*ipv6FragmentList),
+	}
+}
+
+// defrag return the packet itself if it is not a fragment,
+// return the reassembled packet (ethernet is dropped) when the last fragment is received,
+// otherwise return nil
+func (d *defragmenter) defrag(packet gopacket.Packet) (gopacket.Packet, error) {
+	if packet == nil {
+		return nil, utils.Error("packet is nil")
+	}
+	ts := time.Now()
+	if md := packet.Metadata(); md != nil && !md.Timestamp.IsZero() {
+		ts = md.Timestamp
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.count++
+	if d.count%fragmentGCInterval == 0 {
+		d.gc(ts)
+	}
+
+	if ip, ok := packet.Layer(layers.LayerTypeIPv4).(*layers.IPv4); ok {
+		if ip.Flags&layers.IPv4MoreFragments == 0 && ip.FragOffset == 0 {
+			return packet, nil
+		}
+		out, err := d.ipv4.DefragIPv4WithTimestamp(ip, ts)
+		if err != nil {
+			return nil, utils.Errorf("defrag ipv4 failed: %s", err)
+		}
+		if out == nil {
+			return nil, nil
+		}
+		out.Flags &^= layers.IPv4MoreFragments
+		out.FragOffset = 0
+		return rebuildPacket(out, out.Payload, layers.LayerTypeIPv4, ts)
+	}
+
+	if frag, ok := packet.Layer(layers.LayerTypeIPv6Fragment).(*layers.IPv6Fragment); ok {
+		ip, ok := packet.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+		if !ok {
+			return nil, utils.Error("ipv6 fragment without ipv6 header")
+		}
+		if !frag.MoreFragments && frag.FragmentOffset == 0 {
+			// atomic fragment
+			return rebuildIPv6Packet(ip, frag.NextHeader, frag.Payload, ts)
+		}
+		payload, err := d.defragIPv6(ip, frag, ts)
+		if err != nil || payload == nil {
+			return nil, err
+		}
+		return rebuildIPv6Packet(ip, frag.NextHeader, payload, ts)
+	}
+	return packet, nil
+}
+
+// defragIPv6 should be called with lock
+func (d *defragmenter) defragIPv6(ip *layers.IPv6, frag *layers.IPv6Fragment, ts time.Time) ([]byte, error) {
+	key := fmt.Sprintf("%v-%v-%d", ip.SrcIP, ip.DstIP, frag.Identification)
+	list, ok := d.ipv6[key]
+	if !ok {
+		list = &ipv6FragmentList{}
+		d.ipv6[key] = list
+	}
+	list.lastSeen = ts
+
+	offset := int(frag.FragmentOffset) * 8
+	end := offset + len(frag.Payload)
+	if end > maxIPv6FragmentedPayload {
+		delete(d.ipv6, key)
+		return nil, utils.Errorf("ipv6 fragment too large: %d", end)
+	}
+	if !frag.MoreFragments {
+		list.total = end
+	}
+	for _, f := range list.fragments {
+		if f.offset == offset {
+			// duplicated fragment
+			return nil, nil
+		}
+	}
+	list.fragments = append(list.fragments, &ipv6Fragment{offset: offset, data: append([]byte(nil), frag.Payload...)})
+	list.received += len(frag.Payload)
+	if list.total <= 0 || list.received < list.total {
+		return nil, nil
+	}
+
+	sort.Slice(list.fragments, func(i, j int) bool {
+		return list.fragments[i].offset < list.fragments[j].offset
+	})
+	payload := make([]byte, 0, list.total)
+	for _, f := range list.fragments {
+		if f.offset > len(payload) {
+			// hole in the fragments, wait for more
+			return nil, nil
+		}
+		if end := f.offset + len(f.data); end > len(payload) {
+			payload = append(payload, f.data[len(payload)-f.offset:]...)
+		}
+	}
+	delete(d.ipv6, key)
+	if len(payload) > list.total {
+		payload = payload[:list.total]
+	}
+	return payload, nil
+}
+
+// gc should be called with lock
+func (d *defragmenter) gc(now time.Time) {
+	d.ipv4.DiscardOlderThan(now.Add(-defaultFragmentTimeout))
+	for key, list := range d.ipv6 {
+		if now.Sub(list.lastSeen) > defaultFragmentTimeout {
+			delete(d.ipv6, key)
+		}
+	}
+}
+
+func rebuildIPv6Packet(ip *layers.IPv6, next layers.IPProtocol, payload []byte, ts time.Time) (gopacket.Packet, error) {
+	out := *ip
+	// the extension headers before fragment header are dropped
+	out.HopByHop = nil
+	out.NextHeader = next
+	return rebuildPacket(&out, payload, layers.LayerTypeIPv6, ts)
+}
+
+func rebuildPacket(network gopacket.SerializableLayer, payload []byte, first gopacket.LayerType, ts time.Time) (gopacket.Packet, error) {
+	buf := gopacket.NewSerializeBuffer()
+	err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, network, gopacket.Payload(payload))
+	if err != nil {
+		return nil, utils.Errorf("serialize reassembled packet failed: %s", err)
+	}
+	pk := gopacket.NewPacket(buf.Bytes(), first, gopacket.Default)
+	pk.Metadata().Timestamp = ts
+	pk.Metadata().CaptureLength = len(buf.Bytes())
+	pk.Metadata().Length = len(buf.Bytes())
+	return pk, nil
+}
@@ -17,6 +17,7 @@ var Exports = map[string]any{
 	"pcap_onHTTPRequest":                WithHTTPRequest,
 	"pcap_onHTTPFlow":                   WithHTTPFlow,
 	"pcap_everyPacket":                  WithEveryPacket,
+	"pcap_reassembledPacket":            WithReassembledPacket,
 	"pcap_debug":                        WithDebug,
 }
 
@@ -0,0 +1,302 @@
+package pcaputil
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/netutil"
+	"golang.org/x/net/bpf"
+)
+
+const defaultSnaplen = 65535
+
+// Handle is the packet source of live device or pcap file, *pcap.Handle is a Handle
+type Handle interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	LinkType() layers.LinkType
+	SetBPFFilter(expr string) error
+	WritePacketData(data []byte) error
+	Close()
+}
+
+// Device is a network device which can be opened by OpenIfaceLive
+type Device struct {
+	Name        string
+	Description string
+	Flags       net.Flags
+	Addresses   []net.IP
+}
+
+type ConvertIfaceNameError struct {
+	name string
+}
+
+func (e *ConvertIfaceNameError) Error() string {
+	return fmt.Sprintf("convert iface name failed: %s", e.name)
+}
+
+func NewConvertIfaceNameError(name string) *ConvertIfaceNameError {
+	return &ConvertIfaceNameError{
+		name: name,
+	}
+}
+
+var (
+	libpcapOnce      sync.Once
+	libpcapAvailable bool
+)
+
+// IsLibpcapAvailable return true if libpcap (npcap on windows) can be used,
+// the pure go backend is used for files and live capture when it returns false.
+// The builds with cgo on linux and macos link libpcap dynamically, so the binary cannot start without it,
+// the runtime fallback only happens when libpcap cannot list the devices (e.g. npcap is not installed on windows);
+// build with CGO_ENABLED=0 or -tags nopcap to get a binary without libpcap
+func IsLibpcapAvailable() bool {
+	libpcapOnce.Do(func() {
+		libpcapAvailable = checkLibpcap()
+		if !libpcapAvailable {
+			log.Debugf("libpcap is not available, use pure go backend")
+		}
+	})
+	return libpcapAvailable
+}
+
+// FindAllDevices list the devices can be captured
+func FindAllDevices() ([]*Device, error) {
+	if IsLibpcapAvailable() {
+		return findLibpcapDevices()
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, utils.Errorf("fetch net interfaces failed: %s", err)
+	}
+	var devs []*Device
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		dev := &Device{Name: iface.Name, Flags: iface.Flags}
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ip, _, err := net.ParseCIDR(addr.String()); err == nil {
+				dev.Addresses = append(dev.Addresses, ip)
+			}
+		}
+		devs = append(devs, dev)
+	}
+	return devs, nil
+}
+
+var cachedFindAllDevices = utils.CacheFunc(60, FindAllDevices)
+
+// AllDevices list the devices can be captured, the error is logged
+func AllDevices() []*Device {
+	devs, err := FindAllDevices()
+	if err != nil {
+		log.Errorf("find pcap dev failed: %s", err)
+	}
+	return devs
+}
+
+func GetPcapInterfaceByIndex(i int) (*Device, error) {
+	devs, err := cachedFindAllDevices()
+	if err != nil {
+		return nil, utils.Errorf("find pcap dev failed: %s", err)
+	}
+	if i < 0 || i >= len(devs) {
+		return nil, utils.Errorf("index out of range: %d", i)
+	}
+	return devs[i], nil
+}
+
+func GetPublicInternetPcapHandler() (Handle, error) {
+	iface, _, _, err := netutil.GetPublicRoute()
+	if err != nil {
+		return nil, err
+	}
+	ifaceName, err := IfaceNameToPcapIfaceName(iface.Name)
+	if err != nil {
+		return nil, err
+	}
+	return OpenIfaceLive(ifaceName)
+}
+
+func OpenFile(filename string) (Handle, error) {
+	if IsLibpcapAvailable() {
+		return openLibpcapFile(filename)
+	}
+	return openPureFile(filename)
+}
+
+func OpenIfaceLive(iface string) (Handle, error) {
+	handle, err := OpenLive(iface, defaultSnaplen, true)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("open iface %s success", iface)
+	return handle, nil
+}
+
+// OpenLive open the device by libpcap, or AF_PACKET socket if libpcap is not available,
+// the reading of handle blocks until a packet arrives or the handle is closed
+func OpenLive(iface string, snaplen int, promisc bool) (Handle, error) {
+	if IsLibpcapAvailable() {
+		return openLibpcapLive(iface, snaplen, promisc)
+	}
+	handle, err := openAFPacket(iface, snaplen, promisc)
+	if err != nil {
+		return nil, utils.Errorf("open %s failed: %v", iface, err)
+	}
+	return handle, nil
+}
+
+// bpfFilter run the compiled bpf in userland
+type bpfFilter struct {
+	vm *bpf.VM
+}
+
+func newBPFFilter(expr string, linkType layers.LinkType) (*bpfFilter, error) {
+	insns, err := CompileBPF(expr, linkType, defaultSnaplen)
+	if err != nil {
+		return nil, err
+	}
+	if insns == nil {
+		return nil, nil
+	}
+	vm, err := bpf.NewVM(insns)
+	if err != nil {
+		return nil, utils.Errorf("load bpf failed: %s", err)
+	}
+	return &bpfFilter{vm: vm}, nil
+}
+
+// match return the length of packet should be kept, 0 means the packet is dropped
+func (f *bpfFilter) match(data []byte) int {
+	if f == nil {
+		return len(data)
+	}
+	n, err := f.vm.Run(data)
+	if err != nil {
+		return 0
+	}
+	if n > len(data) {
+		n = len(data)
+	}
+	return n
+}
+
+type packetReader interface {
+	ReadPacketData() ([]byte, gopacket.CaptureInfo, error)
+	LinkType() layers.LinkType
+}
+
+// fileHandle read pcap or pcapng file by pcapgo
+type fileHandle struct {
+	file   *os.File
+	reader packetReader
+	filter *bpfFilter
+}
+
+func openPureFile(filename string) (*fileHandle, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, utils.Errorf("open %s failed: %s", filename, err)
+	}
+	buf := bufio.NewReader(file)
+	magic, err := buf.Peek(4)
+	if err != nil {
+		file.Close()
+		return nil, utils.Errorf("read %s failed: %s", filename, err)
+	}
+
+	var reader packetReader
+	if magic[0] == 0x0a && magic[1] == 0x0d && magic[2] == 0x0d && magic[3] == 0x0a {
+		reader, err = pcapgo.NewNgReader(buf, pcapgo.DefaultNgReaderOptions)
+	} else {
+		reader, err = pcapgo.NewReader(buf)
+	}
+	if err != nil {
+		file.Close()
+		return nil, utils.Errorf("open %s failed: %s", filename, err)
+	}
+	return &fileHandle{file: file, reader: reader}, nil
+}
+
+func (h *fileHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	for {
+		data, ci, err := h.reader.ReadPacketData()
+		if err != nil {
+			return nil, ci, err
+		}
+		if n := h.filter.match(data); n > 0 {
+			if n < len(data) {
+				data, ci.CaptureLength = data[:n], n
+			}
+			return data, ci, nil
+		}
+	}
+}
+
+func (h *fileHandle) LinkType() layers.LinkType {
+	return h.reader.LinkType()
+}
+
+func (h *fileHandle) SetBPFFilter(expr string) error {
+	filter, err := newBPFFilter(expr, h.LinkType())
+	if err != nil {
+		return err
+	}
+	h.filter = filter
+	return nil
+}
+
+func (h *fileHandle) WritePacketData([]byte) error {
+	return utils.Error("cannot write packet to pcap file handle")
+}
+
+func (h *fileHandle) Close() {
+	h.file.Close()
+}
+
+// PacketWriter is the writer of pcap or pcapng
+type PacketWriter interface {
+	WritePacket(ci gopacket.CaptureInfo, data []byte) error
+}
+
+// ngWriter flush every packet, the capture may be stopped anytime
+type ngWriter struct {
+	*pcapgo.NgWriter
+}
+
+func (w *ngWriter) WritePacket(ci gopacket.CaptureInfo, data []byte) error {
+	// only one interface is written
+	ci.InterfaceIndex = 0
+	if err := w.NgWriter.WritePacket(ci, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func newPacketWriter(w io.Writer, pcapng bool, linkType layers.LinkType) (PacketWriter, error) {
+	if pcapng {
+		writer, err := pcapgo.NewNgWriter(w, linkType)
+		if err != nil {
+			return nil, err
+		}
+		return &ngWriter{writer}, nil
+	}
+	writer := pcapgo.NewWriter(w)
+	if err := writer.WriteFileHeader(defaultSnaplen, linkType); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
@@ -0,0 +1,101 @@
+package pcaputil
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func writeTestCapture(t *testing.T, filename string, packets map[string][]byte) {
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	w, err := newPacketWriter(file, filepath.Ext(filename) == ".pcapng", layers.LinkTypeEthernet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range packets {
+		ci := gopacket.CaptureInfo{Timestamp: time.Now(), CaptureLength: len(data), Length: len(data)}
+		if err := w.WritePacket(ci, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPureFileHandle(t *testing.T) {
+	packets := testBPFPackets(t)
+	for _, name := range []string{"test.pcap", "test.pcapng"} {
+		filename := filepath.Join(t.TempDir(), name)
+		writeTestCapture(t, filename, packets)
+
+		handle, err := openPureFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if handle.LinkType() != layers.LinkTypeEthernet {
+			t.Fatalf("%s: unexpected link type %v", name, handle.LinkType())
+		}
+		if err := handle.SetBPFFilter("udp"); err != nil {
+			t.Fatal(err)
+		}
+		var count int
+		for {
+			data, _, err := handle.ReadPacketData()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			packet := gopacket.NewPacket(data, layers.LinkTypeEthernet, gopacket.Default)
+			if packet.Layer(layers.LayerTypeUDP) == nil {
+				t.Fatalf("%s: filtered packet is not udp", name)
+			}
+			count++
+		}
+		handle.Close()
+		if count != 2 {
+			t.Fatalf("%s: expect 2 udp packets, got %d", name, count)
+		}
+	}
+}
+
+func TestStartWithPureFile(t *testing.T) {
+	dir := t.TempDir()
+	input, output := filepath.Join(dir, "input.pcapng"), filepath.Join(dir, "output.pcap")
+	writeTestCapture(t, input, testBPFPackets(t))
+
+	// every packet matched is delivered, but only the packets with tcp or udp layer are saved
+	var count int
+	err := Start(WithFile(input), WithBPFFilter("tcp"), WithOutput(output), WithEveryPacket(func(packet gopacket.Packet) {
+		count++
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Fatalf("expect 3 tcp packets, got %d", count)
+	}
+
+	saved, err := openPureFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer saved.Close()
+	var savedCount int
+	for {
+		if _, _, err := saved.ReadPacketData(); err != nil {
+			break
+		}
+		savedCount++
+	}
+	if savedCount != 2 {
+		t.Fatalf("expect 2 saved packets, got %d", savedCount)
+	}
+}
@@ -1,12 +1,12 @@
+//go:build (cgo || windows) && !nopcap
+
 package pcaputil
 
 import (
-	"fmt"
 	"github.com/google/gopacket/pcap"
 	"github.com/samber/lo"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
-	"github.com/yaklang/yaklang/common/utils/netutil"
 	"net"
 	"sort"
 	"strings"
@@ -44,23 +44,14 @@ func PcapInterfaceEqNetInterface(piface pcap.Interface, iface *net.Interface) bo
 	return utils.CalcSha1(strings.Join(pIfaceAddrs, "|")) == utils.CalcSha1(strings.Join(ifaceAddrs, "|"))
 }
 
-type ConvertIfaceNameError struct {
-	name string
-}
-
-func (e *ConvertIfaceNameError) Error() string {
-	return fmt.Sprintf("convert iface name failed: %s", e.name)
-}
-
-func NewConvertIfaceNameError(name string) *ConvertIfaceNameError {
-	return &ConvertIfaceNameError{
-		name: name,
-	}
-}
-
 var cachedFindAllDevs = utils.CacheFunc(60, pcap.FindAllDevs)
 
 func IfaceNameToPcapIfaceName(name string) (string, error) {
+	if !IsLibpcapAvailable() {
+		// the pure go backend use the name of net.Interface
+		return name, nil
+	}
+
 	iface, err := net.InterfaceByName(name)
 	if err != nil {
 		return "", utils.Errorf("fetch net.Interface failed: %s", err)
@@ -79,40 +70,40 @@ func IfaceNameToPcapIfaceName(name string) (string, error) {
 	return "", NewConvertIfaceNameError(name)
 }
 
-func AllDevices() []*pcap.Interface {
-	ifs, err := pcap.FindAllDevs()
-	if err != nil {
-		log.Errorf("find pcap dev failed: %s", err)
-	}
-	return lo.Map(ifs, func(item pcap.Interface, index int) *pcap.Interface {
-		return &item
-	})
-}
-
-func GetPcapInterfaceByIndex(i int) (*pcap.Interface, error) {
-	devs, err := cachedFindAllDevs()
-	if err != nil {
-		return nil, utils.Errorf("find pcap dev failed: %s", err)
-	}
-	if i < 0 || i >= len(devs) {
-		return nil, utils.Errorf("index out of range: %d", i)
-	}
-	return &devs[i], nil
+func checkLibpcap() bool {
+	_, err := cachedFindAllDevs()
+	return err == nil
 }
 
-func GetPublicInternetPcapHandler() (*pcap.Handle, error) {
-	iface, _, _, err := netutil.GetPublicRoute()
-	if err != nil {
-		return nil, err
-	}
-	ifaceName, err := IfaceNameToPcapIfaceName(iface.Name)
+func findLibpcapDevices() ([]*Device, error) {
+	ifs, err := pcap.FindAllDevs()
 	if err != nil {
-		return nil, err
+		return nil, utils.Errorf("(pcap) find all devs failed: %s", err)
 	}
-	return OpenIfaceLive(ifaceName)
+	return lo.Map(ifs, func(item pcap.Interface, index int) *Device {
+		var flags net.Flags
+		// PCAP_IF_LOOPBACK, PCAP_IF_UP and PCAP_IF_RUNNING
+		if item.Flags&0x1 != 0 {
+			flags |= net.FlagLoopback
+		}
+		if item.Flags&0x2 != 0 {
+			flags |= net.FlagUp
+		}
+		if item.Flags&0x4 != 0 {
+			flags |= net.FlagRunning
+		}
+		return &Device{
+			Name:        item.Name,
+			Description: item.Description,
+			Flags:       flags,
+			Addresses: lo.Map(item.Addresses, func(addr pcap.InterfaceAddress, index int) net.IP {
+				return addr.IP
+			}),
+		}
+	}), nil
 }
 
-func OpenFile(filename string) (*pcap.Handle, error) {
+func openLibpcapFile(filename string) (Handle, error) {
 	handler, err := pcap.OpenOffline(filename)
 	if err != nil {
 		return nil, utils.Errorf("pcap.OpenOffline failed: %s", err)
@@ -120,11 +111,10 @@ func OpenFile(filename string) (*pcap.Handle, error) {
 	return handler, nil
 }
 
-func OpenIfaceLive(iface string) (*pcap.Handle, error) {
-	handler, err := pcap.OpenLive(iface, 65535, true, pcap.BlockForever)
+func openLibpcapLive(iface string, snaplen int, promisc bool) (Handle, error) {
+	handler, err := pcap.OpenLive(iface, int32(snaplen), promisc, pcap.BlockForever)
 	if err != nil {
 		return nil, utils.Errorf("pcap.OpenLive %s failed: %v", iface, err)
 	}
-	log.Infof("open iface %s success", iface)
 	return handler, nil
 }
@@ -0,0 +1,30 @@
+//go:build (!cgo && !windows) || nopcap
+
+package pcaputil
+
+import (
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// the build without libpcap always use the pure go backend
+
+func checkLibpcap() bool {
+	return false
+}
+
+func findLibpcapDevices() ([]*Device, error) {
+	return nil, utils.Error("libpcap is not supported in this build")
+}
+
+func openLibpcapFile(filename string) (Handle, error) {
+	return nil, utils.Error("libpcap is not supported in this build")
+}
+
+func openLibpcapLive(iface string, snaplen int, promisc bool) (Handle, error) {
+	return nil, utils.Error("libpcap is not supported in this build")
+}
+
+// IfaceNameToPcapIfaceName return the name itself, the pure go backend use the name of net.Interface
+func IfaceNameToPcapIfaceName(name string) (string, error) {
+	return name, nil
+}
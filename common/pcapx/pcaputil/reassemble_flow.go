@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/google/gopacket/layers"
 	"github.com/yaklang/yaklang/common/log"
+	"net"
 	"net/http"
 	"sync"
 	"time"
@@ -21,7 +22,7 @@ type TrafficFrame struct {
 	Connection *TrafficConnection
 }
 
-// TrafficFlow is a tcp flow
+// TrafficFlow is a tcp flow, or a pseudo-flow of udp
 // lifecycle is created -> data-feeding -> closed(fin/rst/timeout)
 // OnFrame: frame -> flow -> connection
 // OnClosed: reason(fin/rst/timeout) -> flow
@@ -30,6 +31,7 @@ type TrafficFlow struct {
 	// ClientConn
 	IsIpv4              bool
 	IsIpv6              bool
+	IsUDP               bool // pseudo-flow of udp datagrams, every datagram is a frame
 	IsEthernetLinkLayer bool
 	HardwareSrcMac      string
 	HardwareDstMac      string
@@ -76,12 +78,20 @@ func (t *TrafficFlow) String() string {
 	return fmt.Sprintf("stream[%3d]: %v <-> %v", t.Index, t.ClientConn.localAddr, t.ServerConn.localAddr)
 }
 
-func (t *TrafficFlow) feed(packet *layers.TCP) {
-	if t != nil {
-		if t.pool != nil {
-			t.pool.flowCache.Set(t.Hash, t)
-		}
+// touch refresh the inactive timeout of flow
+func (t *TrafficFlow) touch() {
+	if t == nil || t.pool == nil {
+		return
 	}
+	if t.IsUDP {
+		t.pool.flowCache.SetWithTTL(t.Hash, t, t.pool.udpTimeout)
+		return
+	}
+	t.pool.flowCache.Set(t.Hash, t)
+}
+
+func (t *TrafficFlow) feed(packet *layers.TCP) {
+	t.touch()
 
 	if t.ClientConn.localPort == int(packet.SrcPort) {
 		t.ClientConn.FeedClient(packet)
@@ -90,11 +100,33 @@ func (t *TrafficFlow) feed(packet *layers.TCP) {
 	}
 }
 
+func (t *TrafficFlow) feedUDP(srcIP net.IP, packet *layers.UDP) {
+	t.touch()
+	if len(packet.Payload) <= 0 {
+		return
+	}
+
+	conn := t.ServerConn
+	if t.ClientConn.localPort == int(packet.SrcPort) && t.ClientConn.localIP.Equal(srcIP) {
+		conn = t.ClientConn
+	}
+	conn.Write(packet.Payload, 0)
+}
+
 func (t *TrafficFlow) onFrame(frame *TrafficFrame) {
 	if t.onDataFrameArrived != nil {
 		t.onDataFrameArrived(t, frame.Connection, frame)
 	}
 
+	if t.IsUDP {
+		// the boundary of datagram is kept, no reassembly
+		frame.Done = true
+		if t.onDataFrameReassembled != nil {
+			t.onDataFrameReassembled(t, frame.Connection, frame)
+		}
+		return
+	}
+
 	if len(t.frames) > 0 {
 		lastFrame := t.frames[len(t.frames)-1]
 		if lastFrame.ConnHash != frame.ConnHash {
@@ -10,6 +10,7 @@ import (
 	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
 	"net"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -296,11 +297,17 @@ func (p *TrafficPool) NewFlow(netType string, srcAddr, dstAddr string) (*Traffic
 	flowCtx, cancel := context.WithCancel(p.ctx)
 	_ = cancel
 
-	dst, err := net.ResolveTCPAddr(netType, dstAddr)
+	resolve := func(addr string) (net.Addr, error) {
+		if strings.HasPrefix(netType, "udp") {
+			return net.ResolveUDPAddr(netType, addr)
+		}
+		return net.ResolveTCPAddr(netType, addr)
+	}
+	dst, err := resolve(dstAddr)
 	if err != nil {
 		return nil, utils.Errorf("parse [%v] to addr failed: %s", dstAddr, err)
 	}
-	src, err := net.ResolveTCPAddr(netType, srcAddr)
+	src, err := resolve(srcAddr)
 	if err != nil {
 		return nil, utils.Errorf("parse [%v] to addr failed: %s", srcAddr, err)
 	}
@@ -332,7 +339,6 @@ func (p *TrafficPool) NewFlow(netType string, srcAddr, dstAddr string) (*Traffic
 	}
 	c2sConn.Flow = flow
 	s2cConn.Flow = flow
-	p.flowCache.Set(flow.Hash, flow)
 	log.Debugf("%v is open", flow.String())
 	return flow, nil
 }
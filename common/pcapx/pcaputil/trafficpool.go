@@ -16,13 +16,19 @@ import (
 	"time"
 )
 
+const (
+	defaultTCPFlowTimeout = 30 * time.Second
+	defaultUDPFlowTimeout = 30 * time.Second
+)
+
 type TrafficPool struct {
 	// map<string, *TrafficConnection>
 	pool               *sync.Map
 	ctx                context.Context
 	currentStreamIndex uint64
 
-	flowCache *ttlcache.Cache
+	flowCache  *ttlcache.Cache
+	udpTimeout time.Duration
 
 	onFlowCreated                   func(flow *TrafficFlow)
 	onFlowClosed                    func(reason TrafficFlowCloseReason, flow *TrafficFlow)
@@ -31,7 +37,7 @@ type TrafficPool struct {
 }
 
 func NewTrafficPool(ctx context.Context) *TrafficPool {
-	pool := &TrafficPool{pool: new(sync.Map), ctx: ctx}
+	pool := &TrafficPool{pool: new(sync.Map), ctx: ctx, udpTimeout: defaultUDPFlowTimeout}
 	fCache := ttlcache.NewCache()
 	fCache.SetExpirationCallback(func(key string, value interface{}) {
 		pool.pool.Delete(key)
@@ -44,7 +50,7 @@ func NewTrafficPool(ctx context.Context) *TrafficPool {
 		flow.ServerConn.Close()
 		flow.ClientConn.Close()
 	})
-	fCache.SetTTL(30 * time.Second)
+	fCache.SetTTL(defaultTCPFlowTimeout)
 	pool.flowCache = fCache
 	return pool
 }
@@ -53,26 +59,51 @@ func (p *TrafficPool) nextStream() uint64 {
 	return atomic.AddUint64(&p.currentStreamIndex, 1)
 }
 
-func (p *TrafficPool) Feed(ethernetLayer *layers.Ethernet, networkLayer gopacket.SerializableLayer, transportLayer *layers.TCP) {
-	var networkStr string
-	var srcIP net.IP
-	var dstIP net.IP
-	var srcPort = int(transportLayer.SrcPort)
-	var dstPort = int(transportLayer.DstPort)
-	var isIpv4 = false
-	var isIpv6 = false
+// parseNetworkLayer return the network type (tcp4 / tcp6 / udp4 / udp6) and the ips of network layer
+func parseNetworkLayer(networkLayer gopacket.SerializableLayer, transport string) (string, net.IP, net.IP, bool) {
 	switch ret := networkLayer.(type) {
 	case *layers.IPv4:
-		networkStr = "tcp4"
-		srcIP = ret.SrcIP
-		dstIP = ret.DstIP
-		isIpv4 = true
+		return transport + "4", ret.SrcIP, ret.DstIP, true
 	case *layers.IPv6:
-		networkStr = "tcp6"
-		srcIP = ret.SrcIP
-		dstIP = ret.DstIP
-		isIpv6 = true
+		return transport + "6", ret.SrcIP, ret.DstIP, true
 	default:
+		return "", nil, nil, false
+	}
+}
+
+func (p *TrafficPool) createFlow(ethernetLayer *layers.Ethernet, networkStr string, hash string, srcIP, dstIP net.IP, srcPort, dstPort int) (*TrafficFlow, error) {
+	var srcAddrString = utils.HostPort(srcIP.String(), srcPort)
+	var dstAddrString = utils.HostPort(dstIP.String(), dstPort)
+	flow, err := p.NewFlow(networkStr, srcAddrString, dstAddrString)
+	if err != nil {
+		return nil, err
+	}
+	flow.Hash = hash
+	flow.IsIpv4 = strings.HasSuffix(networkStr, "4")
+	flow.IsIpv6 = strings.HasSuffix(networkStr, "6")
+	flow.IsUDP = strings.HasPrefix(networkStr, "udp")
+	flow.ClientConn.localPort = srcPort
+	flow.ClientConn.localIP = srcIP
+	flow.ClientConn.remotePort = dstPort
+	flow.ClientConn.remoteIP = dstIP
+	flow.ServerConn.localPort = dstPort
+	flow.ServerConn.localIP = dstIP
+	flow.ServerConn.remotePort = srcPort
+	flow.ServerConn.remoteIP = srcIP
+	if ethernetLayer != nil {
+		flow.IsEthernetLinkLayer = true
+		flow.HardwareSrcMac = ethernetLayer.SrcMAC.String()
+		flow.HardwareDstMac = ethernetLayer.DstMAC.String()
+	}
+	flow.touch()
+	return flow, nil
+}
+
+func (p *TrafficPool) Feed(ethernetLayer *layers.Ethernet, networkLayer gopacket.SerializableLayer, transportLayer *layers.TCP) {
+	var srcPort = int(transportLayer.SrcPort)
+	var dstPort = int(transportLayer.DstPort)
+	networkStr, srcIP, dstIP, ok := parseNetworkLayer(networkLayer, "tcp")
+	if !ok {
 		return
 	}
 
@@ -82,44 +113,28 @@ func (p *TrafficPool) Feed(ethernetLayer *layers.Ethernet, networkLayer gopacket
 	var flow *TrafficFlow
 
 	if ret, ok := p.pool.Load(hash); !ok {
-		var fitFlow = func(flow *TrafficFlow) {
-			flow.Hash = hash
-			flow.IsIpv4 = isIpv4
-			flow.IsIpv6 = isIpv6
-			flow.ClientConn.localPort = srcPort
-			flow.ClientConn.localIP = srcIP
-			flow.ClientConn.remotePort = dstPort
-			flow.ClientConn.remoteIP = dstIP
-			if ethernetLayer != nil {
-				flow.IsEthernetLinkLayer = true
-				flow.HardwareSrcMac = ethernetLayer.SrcMAC.String()
-				flow.HardwareDstMac = ethernetLayer.DstMAC.String()
-			}
-		}
-
 		// half open
 		if transportLayer.Payload != nil && transportLayer.PSH {
-			flow, err := p.NewFlow(networkStr, srcAddrString, dstAddrString)
+			flow, err := p.createFlow(ethernetLayer, networkStr, hash, srcIP, dstIP, srcPort, dstPort)
 			if err != nil {
 				log.Errorf("create new connection failed: %s", err)
 				return
 			}
-			fitFlow(flow)
-			flow.feed(transportLayer)
 			flow.IsHalfOpen = true
 			p.pool.Store(hash, flow)
+			// init before feeding, the payload of first packet should trigger the frame callbacks
 			flow.init(p.onFlowCreated, p.onFlowFrameDataFrameReassembled, p.onFlowFrameDataFrameArrived, p.onFlowClosed)
+			flow.feed(transportLayer)
 			return
 		}
 
 		// SYN && !ACK -> start a conn
 		if transportLayer.SYN && !transportLayer.ACK {
-			flow, err := p.NewFlow(networkStr, srcAddrString, dstAddrString)
+			flow, err := p.createFlow(ethernetLayer, networkStr, hash, srcIP, dstIP, srcPort, dstPort)
 			if err != nil {
 				log.Errorf("create new connection failed: %s", err)
 				return
 			}
-			fitFlow(flow)
 			p.pool.Store(hash, flow)
 			flow.init(p.onFlowCreated, p.onFlowFrameDataFrameReassembled, p.onFlowFrameDataFrameArrived, p.onFlowClosed)
 			return
@@ -134,6 +149,33 @@ func (p *TrafficPool) Feed(ethernetLayer *layers.Ethernet, networkLayer gopacket
 	flow.feed(transportLayer)
 }
 
+// FeedUDP feed udp datagram to the pseudo-flow of its 5-tuple,
+// the sender of the first datagram is the client, the flow is closed after udpTimeout of inactivity
+func (p *TrafficPool) FeedUDP(ethernetLayer *layers.Ethernet, networkLayer gopacket.SerializableLayer, transportLayer *layers.UDP) {
+	var srcPort = int(transportLayer.SrcPort)
+	var dstPort = int(transportLayer.DstPort)
+	networkStr, srcIP, dstIP, ok := parseNetworkLayer(networkLayer, "udp")
+	if !ok {
+		return
+	}
+
+	var hash = p.flowhash(networkStr, utils.HostPort(srcIP.String(), srcPort), utils.HostPort(dstIP.String(), dstPort))
+	var flow *TrafficFlow
+	if ret, ok := p.pool.Load(hash); ok {
+		flow = ret.(*TrafficFlow)
+	} else {
+		var err error
+		flow, err = p.createFlow(ethernetLayer, networkStr, hash, srcIP, dstIP, srcPort, dstPort)
+		if err != nil {
+			log.Errorf("create new udp flow failed: %s", err)
+			return
+		}
+		p.pool.Store(hash, flow)
+		flow.init(p.onFlowCreated, p.onFlowFrameDataFrameReassembled, p.onFlowFrameDataFrameArrived, p.onFlowClosed)
+	}
+	flow.feedUDP(srcIP, transportLayer)
+}
+
 func (p *TrafficPool) flowhash(netType, srcAddr, dstAddr string) string {
 	hashMaterial := []string{netType, srcAddr, dstAddr}
 	sort.Strings(hashMaterial)
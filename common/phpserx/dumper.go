@@ -0,0 +1,93 @@
+package phpserx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dump format the php values as readable text like var_dump
+func Dump(objs ...PHPSerializable) string {
+	var buf strings.Builder
+	for _, obj := range objs {
+		dumpValue(&buf, obj, 0)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// DumpPHPSerialized parse and format the php serialized data
+func DumpPHPSerialized(raw []byte) (string, error) {
+	objs, err := ParsePHPSerialized(raw)
+	if err != nil {
+		return "", err
+	}
+	return Dump(objs...), nil
+}
+
+func dumpValue(buf *strings.Builder, obj PHPSerializable, level int) {
+	indent := strings.Repeat("  ", level+1)
+	end := strings.Repeat("  ", level)
+	switch ret := obj.(type) {
+	case nil, *PHPNull:
+		buf.WriteString("NULL")
+	case *PHPBool:
+		buf.WriteString(fmt.Sprintf("bool(%v)", ret.Value))
+	case *PHPInt:
+		buf.WriteString(fmt.Sprintf("int(%d)", ret.Value))
+	case *PHPFloat:
+		buf.WriteString(fmt.Sprintf("float(%s)", formatFloat(ret.Value)))
+	case *PHPString:
+		buf.WriteString(fmt.Sprintf("string(%d) %s", len(ret.Value), strconv.Quote(ret.Value)))
+	case *PHPCustom:
+		buf.WriteString(fmt.Sprintf("%s custom(%d) %s", ret.ClassName, len(ret.Data), strconv.Quote(ret.Data)))
+	case *PHPEnum:
+		buf.WriteString(fmt.Sprintf("enum(%s::%s)", ret.ClassName, ret.Case))
+	case *PHPRef:
+		buf.WriteString(fmt.Sprintf("ref #%d", ret.Index))
+	case *PHPReference:
+		buf.WriteString(fmt.Sprintf("&reference #%d", ret.Index))
+	case *PHPArray:
+		buf.WriteString(fmt.Sprintf("array(%d) [", len(ret.Entries)))
+		if len(ret.Entries) == 0 {
+			buf.WriteString("]")
+			return
+		}
+		buf.WriteString("\n")
+		for _, entry := range ret.Entries {
+			buf.WriteString(indent)
+			switch key := entry.Key.(type) {
+			case *PHPString:
+				buf.WriteString(strconv.Quote(key.Value))
+			case *PHPInt:
+				buf.WriteString(strconv.FormatInt(key.Value, 10))
+			default:
+				dumpValue(buf, key, level+1)
+			}
+			buf.WriteString(" => ")
+			dumpValue(buf, entry.Value, level+1)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(end + "]")
+	case *PHPObject:
+		buf.WriteString(ret.ClassName + " {")
+		if len(ret.Properties) == 0 {
+			buf.WriteString("}")
+			return
+		}
+		buf.WriteString("\n")
+		for _, property := range ret.Properties {
+			buf.WriteString(indent)
+			visibility, className, name := property.Visibility()
+			if className != "" {
+				visibility += "(" + className + ")"
+			}
+			buf.WriteString(fmt.Sprintf("%s $%s = ", visibility, name))
+			dumpValue(buf, property.Value, level+1)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(end + "}")
+	default:
+		buf.WriteString(fmt.Sprintf("unknown %T", obj))
+	}
+}
@@ -0,0 +1,35 @@
+package phpserx
+
+var Exports = map[string]interface{}{
+	"ParsePHPSerialized":   ParsePHPSerialized,
+	"MarshalPHPSerialized": MarshalPHPSerialized,
+	"ToJson":               ToJson,
+	"FromJson":             FromJson,
+	"Dump":                 Dump,
+	"DumpPHPSerialized":    DumpPHPSerialized,
+	"FindRefIndex":         FindRefIndex,
+	"BuildPhar":            BuildPhar,
+	"ParsePhar":            ParsePhar,
+
+	"NewPHPNull":              NewPHPNull,
+	"NewPHPBool":              NewPHPBool,
+	"NewPHPInt":               NewPHPInt,
+	"NewPHPFloat":             NewPHPFloat,
+	"NewPHPString":            NewPHPString,
+	"NewPHPArray":             NewPHPArray,
+	"NewPHPArrayEntry":        NewPHPArrayEntry,
+	"NewPHPList":              NewPHPList,
+	"NewPHPObject":            NewPHPObject,
+	"NewPHPProperty":          NewPHPProperty,
+	"NewPHPProtectedProperty": NewPHPProtectedProperty,
+	"NewPHPPrivateProperty":   NewPHPPrivateProperty,
+	"NewPHPCustom":            NewPHPCustom,
+	"NewPHPEnum":              NewPHPEnum,
+	"NewPHPRef":               NewPHPRef,
+	"NewPHPReference":         NewPHPReference,
+
+	// phar options
+	"pharStub":  WithPharStub,
+	"pharFile":  WithPharFile,
+	"pharAlias": WithPharAlias,
+}
@@ -0,0 +1,177 @@
+package phpserx
+
+import (
+	"encoding/json"
+	"math"
+	"unicode/utf8"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// ToJson convert the php values to json, it can be converted back by FromJson
+func ToJson(objs ...PHPSerializable) ([]byte, error) {
+	return json.MarshalIndent(objs, "", "  ")
+}
+
+// MarshalJSON write the binary string as base64 in "raw", because json string only holds utf-8
+func (s *PHPString) MarshalJSON() ([]byte, error) {
+	type phpString PHPString
+	if utf8.ValidString(s.Value) {
+		return json.Marshal((*phpString)(s))
+	}
+	return json.Marshal(struct {
+		Type    string `json:"type"`
+		Raw     []byte `json:"raw"`
+		Escaped bool   `json:"escaped,omitempty"`
+	}{s.Type, []byte(s.Value), s.Escaped})
+}
+
+// MarshalJSON write INF, -INF and NAN as string, because they are not valid json number
+func (f *PHPFloat) MarshalJSON() ([]byte, error) {
+	type phpFloat PHPFloat
+	if math.IsInf(f.Value, 0) || math.IsNaN(f.Value) {
+		return json.Marshal(struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		}{f.Type, formatFloat(f.Value)})
+	}
+	return json.Marshal((*phpFloat)(f))
+}
+
+func (c *PHPCustom) MarshalJSON() ([]byte, error) {
+	type phpCustom PHPCustom
+	if utf8.ValidString(c.Data) {
+		return json.Marshal((*phpCustom)(c))
+	}
+	return json.Marshal(struct {
+		Type      string `json:"type"`
+		ClassName string `json:"class_name"`
+		Raw       []byte `json:"raw"`
+	}{c.Type, c.ClassName, []byte(c.Data)})
+}
+
+type rawPHPValue struct {
+	Type      string          `json:"type"`
+	Value     json.RawMessage `json:"value"`
+	Raw       []byte          `json:"raw"`
+	Escaped   bool            `json:"escaped"`
+	ClassName string          `json:"class_name"`
+	Data      string          `json:"data"`
+	Case      string          `json:"case"`
+	Index     int             `json:"index"`
+	Entries   []struct {
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	} `json:"entries"`
+	Properties []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	} `json:"properties"`
+}
+
+// FromJson convert the json generated by ToJson to php values, a single value is also accepted
+func FromJson(raw []byte) ([]PHPSerializable, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		v, err := fromJsonValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		return []PHPSerializable{v}, nil
+	}
+	var ret []PHPSerializable
+	for _, item := range items {
+		v, err := fromJsonValue(item)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func fromJsonValue(raw json.RawMessage) (PHPSerializable, error) {
+	var v rawPHPValue
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, utils.Errorf("unmarshal php json failed: %v", err)
+	}
+	unmarshalValue := func(i any) error {
+		if len(v.Value) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(v.Value, i); err != nil {
+			return utils.Errorf("unmarshal php %s value failed: %v", v.Type, err)
+		}
+		return nil
+	}
+	switch v.Type {
+	case TypeNull:
+		return NewPHPNull(), nil
+	case TypeBool:
+		ret := NewPHPBool(false)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeInt:
+		ret := NewPHPInt(0)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeFloat:
+		ret := NewPHPFloat(0)
+		var special string
+		if json.Unmarshal(v.Value, &special) == nil {
+			switch special {
+			case "INF":
+				ret.Value = math.Inf(1)
+			case "-INF":
+				ret.Value = math.Inf(-1)
+			case "NAN":
+				ret.Value = math.NaN()
+			default:
+				return nil, utils.Errorf("invalid php float: %s", special)
+			}
+			return ret, nil
+		}
+		return ret, unmarshalValue(&ret.Value)
+	case TypeString:
+		ret := NewPHPString(string(v.Raw))
+		ret.Escaped = v.Escaped
+		if v.Raw != nil {
+			return ret, nil
+		}
+		return ret, unmarshalValue(&ret.Value)
+	case TypeCustom:
+		if v.Raw != nil {
+			return NewPHPCustom(v.ClassName, string(v.Raw)), nil
+		}
+		return NewPHPCustom(v.ClassName, v.Data), nil
+	case TypeEnum:
+		return NewPHPEnum(v.ClassName, v.Case), nil
+	case TypeRef:
+		return NewPHPRef(v.Index), nil
+	case TypeReference:
+		return NewPHPReference(v.Index), nil
+	case TypeArray:
+		ret := NewPHPArray()
+		for _, entry := range v.Entries {
+			key, err := fromJsonValue(entry.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := fromJsonValue(entry.Value)
+			if err != nil {
+				return nil, err
+			}
+			ret.Entries = append(ret.Entries, NewPHPArrayEntry(key, value))
+		}
+		return ret, nil
+	case TypeObject:
+		ret := NewPHPObject(v.ClassName)
+		for _, property := range v.Properties {
+			value, err := fromJsonValue(property.Value)
+			if err != nil {
+				return nil, err
+			}
+			ret.Properties = append(ret.Properties, NewPHPProperty(property.Name, value))
+		}
+		return ret, nil
+	}
+	return nil, utils.Errorf("unknown php type: %s", v.Type)
+}
@@ -0,0 +1,113 @@
+package phpserx
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// MarshalPHPSerialized serialize the values as php serialize(), the values are concatenated
+func MarshalPHPSerialized(objs ...PHPSerializable) []byte {
+	var buf bytes.Buffer
+	for _, obj := range objs {
+		marshalValue(&buf, obj)
+	}
+	return buf.Bytes()
+}
+
+func marshalQuoted(buf *bytes.Buffer, s string) {
+	buf.WriteString(fmt.Sprintf(`%d:"`, len(s)))
+	buf.WriteString(s)
+	buf.WriteString(`"`)
+}
+
+func marshalValue(buf *bytes.Buffer, obj PHPSerializable) {
+	switch ret := obj.(type) {
+	case nil, *PHPNull:
+		buf.WriteString("N;")
+	case *PHPBool:
+		if ret.Value {
+			buf.WriteString("b:1;")
+		} else {
+			buf.WriteString("b:0;")
+		}
+	case *PHPInt:
+		buf.WriteString(fmt.Sprintf("i:%d;", ret.Value))
+	case *PHPFloat:
+		buf.WriteString("d:" + formatFloat(ret.Value) + ";")
+	case *PHPString:
+		if ret.Escaped {
+			buf.WriteString(fmt.Sprintf(`S:%d:"`, len(ret.Value)))
+			for i := 0; i < len(ret.Value); i++ {
+				c := ret.Value[i]
+				if c < 0x20 || c >= 0x7f || c == '\\' || c == '"' {
+					buf.WriteString(fmt.Sprintf(`\%02x`, c))
+				} else {
+					buf.WriteByte(c)
+				}
+			}
+			buf.WriteString(`";`)
+			return
+		}
+		buf.WriteString("s:")
+		marshalQuoted(buf, ret.Value)
+		buf.WriteString(";")
+	case *PHPArray:
+		buf.WriteString(fmt.Sprintf("a:%d:{", len(ret.Entries)))
+		for _, entry := range ret.Entries {
+			marshalValue(buf, entry.Key)
+			marshalValue(buf, entry.Value)
+		}
+		buf.WriteString("}")
+	case *PHPObject:
+		buf.WriteString("O:")
+		marshalQuoted(buf, ret.ClassName)
+		buf.WriteString(fmt.Sprintf(":%d:{", len(ret.Properties)))
+		for _, property := range ret.Properties {
+			marshalValue(buf, NewPHPString(property.Name))
+			marshalValue(buf, property.Value)
+		}
+		buf.WriteString("}")
+	case *PHPCustom:
+		buf.WriteString("C:")
+		marshalQuoted(buf, ret.ClassName)
+		buf.WriteString(fmt.Sprintf(":%d:{", len(ret.Data)))
+		buf.WriteString(ret.Data)
+		buf.WriteString("}")
+	case *PHPEnum:
+		buf.WriteString("E:")
+		marshalQuoted(buf, ret.ClassName+":"+ret.Case)
+		buf.WriteString(";")
+	case *PHPRef:
+		buf.WriteString(fmt.Sprintf("r:%d;", ret.Index))
+	case *PHPReference:
+		buf.WriteString(fmt.Sprintf("R:%d;", ret.Index))
+	}
+}
+
+// formatFloat format the float as php serialize() with serialize_precision -1,
+// the exponential format is used when the decimal exponent is less than -4 or greater than 16
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "INF"
+	case math.IsInf(f, -1):
+		return "-INF"
+	case math.IsNaN(f):
+		return "NAN"
+	}
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	e, _ := strconv.Atoi(exp)
+	if e < -4 || e >= 17 {
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		if e < 0 {
+			return fmt.Sprintf("%sE-%d", mantissa, -e)
+		}
+		return fmt.Sprintf("%sE+%d", mantissa, e)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
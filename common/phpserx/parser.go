@@ -0,0 +1,297 @@
+package phpserx
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type phpParser struct {
+	raw []byte
+	pos int
+}
+
+// ParsePHPSerialized parse the data serialized by php serialize()
+func ParsePHPSerialized(raw []byte) ([]PHPSerializable, error) {
+	p := &phpParser{raw: raw}
+	var ret []PHPSerializable
+	for p.pos < len(p.raw) {
+		v, err := p.readValue()
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func (p *phpParser) errorf(format string, args ...any) error {
+	return utils.Errorf("php unserialize at %d failed: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *phpParser) expect(s string) error {
+	if !bytes.HasPrefix(p.raw[p.pos:], []byte(s)) {
+		return p.errorf("expect %#v", s)
+	}
+	p.pos += len(s)
+	return nil
+}
+
+// readUntil read the token before delim, delim is consumed
+func (p *phpParser) readUntil(delim byte) (string, error) {
+	index := bytes.IndexByte(p.raw[p.pos:], delim)
+	if index < 0 {
+		return "", p.errorf("expect %#v", string(delim))
+	}
+	token := string(p.raw[p.pos : p.pos+index])
+	p.pos += index + 1
+	return token, nil
+}
+
+func (p *phpParser) readInt(delim byte) (int64, error) {
+	token, err := p.readUntil(delim)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(token, 10, 64)
+	if err != nil {
+		return 0, p.errorf("invalid integer %#v", token)
+	}
+	return i, nil
+}
+
+func (p *phpParser) readLength(delim byte) (int, error) {
+	i, err := p.readInt(delim)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || i > int64(len(p.raw)) {
+		return 0, p.errorf("invalid length %d", i)
+	}
+	return int(i), nil
+}
+
+func (p *phpParser) readBytes(n int) (string, error) {
+	if p.pos+n > len(p.raw) {
+		return "", p.errorf("read %d bytes out of range", n)
+	}
+	s := string(p.raw[p.pos : p.pos+n])
+	p.pos += n
+	return s, nil
+}
+
+// readQuoted read the length-prefixed string: len:"..."
+func (p *phpParser) readQuoted() (string, error) {
+	n, err := p.readLength(':')
+	if err != nil {
+		return "", err
+	}
+	if err := p.expect(`"`); err != nil {
+		return "", err
+	}
+	s, err := p.readBytes(n)
+	if err != nil {
+		return "", err
+	}
+	return s, p.expect(`"`)
+}
+
+// readEscaped read the content of S:, the length is the count of decoded bytes and \xx is the hex escaped byte
+func (p *phpParser) readEscaped(n int) (string, error) {
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		if p.pos >= len(p.raw) {
+			return "", p.errorf("escaped string out of range")
+		}
+		c := p.raw[p.pos]
+		p.pos++
+		if c != '\\' {
+			buf.WriteByte(c)
+			continue
+		}
+		hex, err := p.readBytes(2)
+		if err != nil {
+			return "", err
+		}
+		b, err := strconv.ParseUint(hex, 16, 8)
+		if err != nil {
+			return "", p.errorf("invalid escaped byte %#v", hex)
+		}
+		buf.WriteByte(byte(b))
+	}
+	return buf.String(), nil
+}
+
+func (p *phpParser) readValue() (PHPSerializable, error) {
+	if p.pos+2 > len(p.raw) {
+		return nil, p.errorf("unexpected end of data")
+	}
+	flag := p.raw[p.pos]
+	if flag == 'N' {
+		return NewPHPNull(), p.expect("N;")
+	}
+	if err := p.expect(string([]byte{flag, ':'})); err != nil {
+		return nil, err
+	}
+	switch flag {
+	case 'b':
+		i, err := p.readInt(';')
+		if err != nil {
+			return nil, err
+		}
+		return NewPHPBool(i != 0), nil
+	case 'i':
+		i, err := p.readInt(';')
+		if err != nil {
+			return nil, err
+		}
+		return NewPHPInt(i), nil
+	case 'd':
+		token, err := p.readUntil(';')
+		if err != nil {
+			return nil, err
+		}
+		switch token {
+		case "INF":
+			return NewPHPFloat(math.Inf(1)), nil
+		case "-INF":
+			return NewPHPFloat(math.Inf(-1)), nil
+		case "NAN":
+			return NewPHPFloat(math.NaN()), nil
+		}
+		f, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %#v", token)
+		}
+		return NewPHPFloat(f), nil
+	case 's':
+		s, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return NewPHPString(s), p.expect(";")
+	case 'S':
+		n, err := p.readLength(':')
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(`"`); err != nil {
+			return nil, err
+		}
+		s, err := p.readEscaped(n)
+		if err != nil {
+			return nil, err
+		}
+		ret := NewPHPString(s)
+		ret.Escaped = true
+		return ret, p.expect(`";`)
+	case 'a':
+		n, err := p.readLength(':')
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		arr := NewPHPArray()
+		for i := 0; i < n; i++ {
+			key, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			switch key.(type) {
+			case *PHPInt, *PHPString:
+			default:
+				return nil, p.errorf("invalid array key type: %s", key.GetType())
+			}
+			value, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			arr.Entries = append(arr.Entries, NewPHPArrayEntry(key, value))
+		}
+		return arr, p.expect("}")
+	case 'O':
+		className, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		n, err := p.readLength(':')
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		obj := NewPHPObject(className)
+		for i := 0; i < n; i++ {
+			key, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			var name string
+			switch ret := key.(type) {
+			case *PHPString:
+				name = ret.Value
+			case *PHPInt:
+				name = strconv.FormatInt(ret.Value, 10)
+			default:
+				return nil, p.errorf("invalid property name type: %s", key.GetType())
+			}
+			value, err := p.readValue()
+			if err != nil {
+				return nil, err
+			}
+			obj.Properties = append(obj.Properties, NewPHPProperty(name, value))
+		}
+		return obj, p.expect("}")
+	case 'C':
+		className, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		n, err := p.readLength(':')
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("{"); err != nil {
+			return nil, err
+		}
+		data, err := p.readBytes(n)
+		if err != nil {
+			return nil, err
+		}
+		return NewPHPCustom(className, data), p.expect("}")
+	case 'E':
+		s, err := p.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		className, c, ok := strings.Cut(s, ":")
+		if !ok {
+			return nil, p.errorf("invalid enum %#v", s)
+		}
+		return NewPHPEnum(className, c), p.expect(";")
+	case 'r', 'R':
+		index, err := p.readLength(';')
+		if err != nil {
+			return nil, err
+		}
+		if flag == 'r' {
+			return NewPHPRef(index), nil
+		}
+		return NewPHPReference(index), nil
+	}
+	p.pos -= 2
+	return nil, p.errorf("unknown type %#v", string(flag))
+}
@@ -0,0 +1,200 @@
+package phpserx
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"hash/crc32"
+	"time"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	pharHaltCompiler   = "__HALT_COMPILER();"
+	pharDefaultStub    = "<?php __HALT_COMPILER(); ?>\r\n"
+	pharSignatureMagic = "GBMB"
+
+	pharApiVersion          = 0x1100
+	pharFlagSignature       = 0x00010000
+	pharSignatureSHA1       = 0x0002
+	pharFilePermission      = 0x000001b6
+	pharFileCompressionMask = 0x0000f000
+)
+
+// PharFile is the file entry in phar archive, the metadata of file is also unserialized when the phar is accessed
+type PharFile struct {
+	Name      string
+	Content   []byte
+	Metadata  []byte
+	Timestamp uint32
+	Flags     uint32
+}
+
+// Phar is the php archive, the Metadata is serialized and unserialized by phar:// stream wrapper
+type Phar struct {
+	Stub     []byte
+	Alias    string
+	Metadata []byte
+	Files    []*PharFile
+}
+
+type PharOption func(p *Phar)
+
+// WithPharStub set the stub of phar, it is used to disguise phar as other file type like "GIF89a",
+// "<?php __HALT_COMPILER(); ?>" is appended if the stub does not contain it
+func WithPharStub(stub string) PharOption {
+	return func(p *Phar) {
+		if !bytes.Contains([]byte(stub), []byte(pharHaltCompiler)) {
+			stub += pharDefaultStub
+		}
+		p.Stub = []byte(stub)
+	}
+}
+
+// WithPharFile add the file into phar, the default file is test.txt
+func WithPharFile(name string, content []byte) PharOption {
+	return func(p *Phar) {
+		p.Files = append(p.Files, &PharFile{Name: name, Content: content, Timestamp: uint32(time.Now().Unix()), Flags: pharFilePermission})
+	}
+}
+
+func WithPharAlias(alias string) PharOption {
+	return func(p *Phar) {
+		p.Alias = alias
+	}
+}
+
+// BuildPhar build the phar archive with the serialized metadata, the metadata is unserialized when the phar is accessed by phar://
+func BuildPhar(metadata []byte, options ...PharOption) []byte {
+	p := &Phar{Stub: []byte(pharDefaultStub), Metadata: metadata}
+	for _, option := range options {
+		option(p)
+	}
+	if len(p.Files) == 0 {
+		WithPharFile("test.txt", []byte("test"))(p)
+	}
+	return p.Bytes()
+}
+
+// Bytes marshal the phar archive with sha1 signature
+func (p *Phar) Bytes() []byte {
+	var manifest bytes.Buffer
+	write := func(i any) {
+		binary.Write(&manifest, binary.LittleEndian, i)
+	}
+	write(uint32(len(p.Files)))
+	// the api version is big endian
+	manifest.Write([]byte{pharApiVersion >> 8, pharApiVersion & 0xff})
+	write(uint32(pharFlagSignature))
+	write(uint32(len(p.Alias)))
+	manifest.WriteString(p.Alias)
+	write(uint32(len(p.Metadata)))
+	manifest.Write(p.Metadata)
+	for _, file := range p.Files {
+		write(uint32(len(file.Name)))
+		manifest.WriteString(file.Name)
+		write(uint32(len(file.Content)))
+		write(file.Timestamp)
+		write(uint32(len(file.Content)))
+		write(crc32.ChecksumIEEE(file.Content))
+		write(file.Flags &^ pharFileCompressionMask)
+		write(uint32(len(file.Metadata)))
+		manifest.Write(file.Metadata)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(p.Stub)
+	binary.Write(&buf, binary.LittleEndian, uint32(manifest.Len()))
+	buf.Write(manifest.Bytes())
+	for _, file := range p.Files {
+		buf.Write(file.Content)
+	}
+	signature := sha1.Sum(buf.Bytes())
+	buf.Write(signature[:])
+	binary.Write(&buf, binary.LittleEndian, uint32(pharSignatureSHA1))
+	buf.WriteString(pharSignatureMagic)
+	return buf.Bytes()
+}
+
+// ParsePhar parse the phar archive, the content of compressed file is kept as it is
+func ParsePhar(raw []byte) (*Phar, error) {
+	index := bytes.Index(raw, []byte(pharHaltCompiler))
+	if index < 0 {
+		return nil, utils.Error("phar stub not found: __HALT_COMPILER(); is missing")
+	}
+	offset := index + len(pharHaltCompiler)
+	for _, suffix := range []string{" ?>", "\r\n", "\n"} {
+		if bytes.HasPrefix(raw[offset:], []byte(suffix)) {
+			offset += len(suffix)
+			if suffix != " ?>" {
+				break
+			}
+		}
+	}
+	p := &Phar{Stub: raw[:offset]}
+	r := bytes.NewReader(raw[offset:])
+	var err error
+	readUint32 := func() uint32 {
+		var i uint32
+		if err == nil {
+			err = binary.Read(r, binary.LittleEndian, &i)
+		}
+		return i
+	}
+	readBytes := func(n uint32) []byte {
+		if err != nil || n == 0 {
+			return nil
+		}
+		if int64(n) > int64(r.Len()) {
+			err = utils.Errorf("read %d bytes out of range", n)
+			return nil
+		}
+		buf := make([]byte, n)
+		_, err = r.Read(buf)
+		return buf
+	}
+
+	manifestLength := readUint32()
+	if err == nil && int64(manifestLength) > int64(r.Len()) {
+		return nil, utils.Errorf("invalid phar manifest length: %d", manifestLength)
+	}
+	count := readUint32()
+	readBytes(2)
+	flags := readUint32()
+	p.Alias = string(readBytes(readUint32()))
+	p.Metadata = readBytes(readUint32())
+	var sizes []uint32
+	for i := uint32(0); i < count && err == nil; i++ {
+		file := &PharFile{}
+		file.Name = string(readBytes(readUint32()))
+		readUint32()
+		file.Timestamp = readUint32()
+		sizes = append(sizes, readUint32())
+		readUint32()
+		file.Flags = readUint32()
+		file.Metadata = readBytes(readUint32())
+		p.Files = append(p.Files, file)
+	}
+	for i, file := range p.Files {
+		file.Content = readBytes(sizes[i])
+	}
+	if err != nil {
+		return nil, utils.Errorf("parse phar manifest failed: %v", err)
+	}
+	if flags&pharFlagSignature == 0 {
+		return p, nil
+	}
+	// signature + flag(4) + GBMB, only sha1 signature is verified
+	if !bytes.HasSuffix(raw, []byte(pharSignatureMagic)) || len(raw) < 8 {
+		return p, utils.Error("phar signature magic GBMB not found")
+	}
+	signatureFlag := binary.LittleEndian.Uint32(raw[len(raw)-8:])
+	if signatureFlag == pharSignatureSHA1 && len(raw) >= 28 {
+		signature := sha1.Sum(raw[:len(raw)-28])
+		if !bytes.Equal(signature[:], raw[len(raw)-28:len(raw)-8]) {
+			return p, utils.Error("phar sha1 signature mismatch")
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,232 @@
+package phpserx
+
+import (
+	"strings"
+)
+
+const (
+	TypeNull      = "null"
+	TypeBool      = "bool"
+	TypeInt       = "int"
+	TypeFloat     = "float"
+	TypeString    = "string"
+	TypeArray     = "array"
+	TypeObject    = "object"
+	TypeCustom    = "custom"
+	TypeEnum      = "enum"
+	TypeRef       = "ref"
+	TypeReference = "reference"
+)
+
+// PHPSerializable is a value in php serialized data, the concrete types are the PHP* structs in this package
+type PHPSerializable interface {
+	GetType() string
+}
+
+type PHPNull struct {
+	Type string `json:"type"`
+}
+
+type PHPBool struct {
+	Type  string `json:"type"`
+	Value bool   `json:"value"`
+}
+
+type PHPInt struct {
+	Type  string `json:"type"`
+	Value int64  `json:"value"`
+}
+
+// PHPFloat is double, INF, -INF and NAN are supported
+type PHPFloat struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// PHPString is the binary string, Escaped means it is serialized as S:len:"\xx"; with hex escaped bytes
+type PHPString struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Escaped bool   `json:"escaped,omitempty"`
+}
+
+type PHPArrayEntry struct {
+	Key   PHPSerializable `json:"key"`
+	Value PHPSerializable `json:"value"`
+}
+
+// PHPArray is the ordered map of php, the key is PHPInt or PHPString
+type PHPArray struct {
+	Type    string           `json:"type"`
+	Entries []*PHPArrayEntry `json:"entries"`
+}
+
+// PHPProperty is the property of object, Name is mangled by visibility: "\x00*\x00name" for protected and "\x00Class\x00name" for private
+type PHPProperty struct {
+	Name  string          `json:"name"`
+	Value PHPSerializable `json:"value"`
+}
+
+// PHPObject is the object serialized by properties (O:)
+type PHPObject struct {
+	Type       string         `json:"type"`
+	ClassName  string         `json:"class_name"`
+	Properties []*PHPProperty `json:"properties"`
+}
+
+// PHPCustom is the object implements Serializable (C:), Data is the result of Serializable::serialize
+type PHPCustom struct {
+	Type      string `json:"type"`
+	ClassName string `json:"class_name"`
+	Data      string `json:"data"`
+}
+
+// PHPEnum is the case of php 8.1 enum (E:)
+type PHPEnum struct {
+	Type      string `json:"type"`
+	ClassName string `json:"class_name"`
+	Case      string `json:"case"`
+}
+
+// PHPRef (r:) is a copy of the Index-th value, PHPReference (R:) is a php reference (&) to the Index-th value,
+// the index starts from 1 and counts all values except array keys, property names and R: itself
+type PHPRef struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+type PHPReference struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+func (*PHPNull) GetType() string      { return TypeNull }
+func (*PHPBool) GetType() string      { return TypeBool }
+func (*PHPInt) GetType() string       { return TypeInt }
+func (*PHPFloat) GetType() string     { return TypeFloat }
+func (*PHPString) GetType() string    { return TypeString }
+func (*PHPArray) GetType() string     { return TypeArray }
+func (*PHPObject) GetType() string    { return TypeObject }
+func (*PHPCustom) GetType() string    { return TypeCustom }
+func (*PHPEnum) GetType() string      { return TypeEnum }
+func (*PHPRef) GetType() string       { return TypeRef }
+func (*PHPReference) GetType() string { return TypeReference }
+
+func NewPHPNull() *PHPNull {
+	return &PHPNull{Type: TypeNull}
+}
+
+func NewPHPBool(b bool) *PHPBool {
+	return &PHPBool{Type: TypeBool, Value: b}
+}
+
+func NewPHPInt(i int64) *PHPInt {
+	return &PHPInt{Type: TypeInt, Value: i}
+}
+
+func NewPHPFloat(f float64) *PHPFloat {
+	return &PHPFloat{Type: TypeFloat, Value: f}
+}
+
+func NewPHPString(s string) *PHPString {
+	return &PHPString{Type: TypeString, Value: s}
+}
+
+func NewPHPArray(entries ...*PHPArrayEntry) *PHPArray {
+	return &PHPArray{Type: TypeArray, Entries: entries}
+}
+
+func NewPHPArrayEntry(key PHPSerializable, value PHPSerializable) *PHPArrayEntry {
+	return &PHPArrayEntry{Key: key, Value: value}
+}
+
+// NewPHPList create the array with the keys from 0 to n-1
+func NewPHPList(values ...PHPSerializable) *PHPArray {
+	arr := NewPHPArray()
+	for i, value := range values {
+		arr.Entries = append(arr.Entries, NewPHPArrayEntry(NewPHPInt(int64(i)), value))
+	}
+	return arr
+}
+
+func NewPHPObject(className string, properties ...*PHPProperty) *PHPObject {
+	return &PHPObject{Type: TypeObject, ClassName: className, Properties: properties}
+}
+
+// NewPHPProperty create the public property
+func NewPHPProperty(name string, value PHPSerializable) *PHPProperty {
+	return &PHPProperty{Name: name, Value: value}
+}
+
+func NewPHPProtectedProperty(name string, value PHPSerializable) *PHPProperty {
+	return &PHPProperty{Name: "\x00*\x00" + name, Value: value}
+}
+
+// NewPHPPrivateProperty create the private property declared in className, it should be the class declaring the property rather than the class of object
+func NewPHPPrivateProperty(className string, name string, value PHPSerializable) *PHPProperty {
+	return &PHPProperty{Name: "\x00" + className + "\x00" + name, Value: value}
+}
+
+func NewPHPCustom(className string, data string) *PHPCustom {
+	return &PHPCustom{Type: TypeCustom, ClassName: className, Data: data}
+}
+
+func NewPHPEnum(className string, c string) *PHPEnum {
+	return &PHPEnum{Type: TypeEnum, ClassName: className, Case: c}
+}
+
+func NewPHPRef(index int) *PHPRef {
+	return &PHPRef{Type: TypeRef, Index: index}
+}
+
+func NewPHPReference(index int) *PHPReference {
+	return &PHPReference{Type: TypeReference, Index: index}
+}
+
+// Visibility return the visibility, declaring class (for private) and the name of property
+func (p *PHPProperty) Visibility() (visibility string, className string, name string) {
+	if !strings.HasPrefix(p.Name, "\x00") {
+		return "public", "", p.Name
+	}
+	parts := strings.SplitN(p.Name[1:], "\x00", 2)
+	if len(parts) != 2 {
+		return "public", "", p.Name
+	}
+	if parts[0] == "*" {
+		return "protected", "", parts[1]
+	}
+	return "private", parts[0], parts[1]
+}
+
+// FindRefIndex return the index of target in root which is used by PHPRef and PHPReference, -1 if not found
+func FindRefIndex(root PHPSerializable, target PHPSerializable) int {
+	index := 0
+	var walk func(v PHPSerializable) bool
+	walk = func(v PHPSerializable) bool {
+		if _, ok := v.(*PHPReference); !ok {
+			index++
+		}
+		if v == target {
+			return true
+		}
+		switch ret := v.(type) {
+		case *PHPArray:
+			for _, entry := range ret.Entries {
+				if walk(entry.Value) {
+					return true
+				}
+			}
+		case *PHPObject:
+			for _, property := range ret.Properties {
+				if walk(property.Value) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if walk(root) {
+		return index
+	}
+	return -1
+}
@@ -0,0 +1,105 @@
+package phpserx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPHPSerializedRoundTrip(t *testing.T) {
+	for _, raw := range []string{
+		`N;`,
+		`b:1;`,
+		`i:-5;`,
+		`d:0.1;`,
+		`d:1;`,
+		`d:1.0E+25;`,
+		`d:1.5E-5;`,
+		`d:INF;`,
+		`d:NAN;`,
+		`s:5:"hello";`,
+		`s:4:"` + "\xff\x00\x01\xfe" + `";`,
+		`S:4:"a\00b\5c";`,
+		`a:2:{i:0;s:1:"a";s:1:"b";d:1.5;}`,
+		`O:8:"stdClass":1:{s:1:"a";i:1;}`,
+		"O:3:\"Foo\":3:{s:3:\"pub\";N;s:6:\"\x00*\x00pro\";b:0;s:8:\"\x00Foo\x00pri\";a:0:{}}",
+		`C:11:"ArrayObject":21:{x:i:0;a:0:{};m:a:0:{}}`,
+		`a:2:{i:0;O:8:"stdClass":0:{}i:1;r:2;}`,
+		`a:2:{i:0;i:1;i:1;R:2;}`,
+		`E:11:"Suit:Hearts";`,
+	} {
+		objs, err := ParsePHPSerialized([]byte(raw))
+		if err != nil {
+			t.Fatalf("parse %#v failed: %v", raw, err)
+		}
+		if len(objs) != 1 {
+			t.Fatalf("parse %#v: expect 1 value, got %d", raw, len(objs))
+		}
+		if ret := MarshalPHPSerialized(objs...); string(ret) != raw {
+			t.Fatalf("marshal %#v: got %#v", raw, string(ret))
+		}
+		jsonRaw, err := ToJson(objs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fromJson, err := FromJson(jsonRaw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret := MarshalPHPSerialized(fromJson...); string(ret) != raw {
+			t.Fatalf("json round trip %#v: got %#v\n%s", raw, string(ret), jsonRaw)
+		}
+	}
+
+	for _, raw := range []string{`s:10:"a";`, `a:1:{i:0;}`, `O:3:"Foo":1:{i:0;N;`, `x:1;`, `a:1:{a:0:{}i:1;}`} {
+		if _, err := ParsePHPSerialized([]byte(raw)); err == nil {
+			t.Fatalf("%#v should be invalid", raw)
+		}
+	}
+}
+
+func TestPHPObjectBuilder(t *testing.T) {
+	inner := NewPHPObject("Bar")
+	obj := NewPHPObject("Foo",
+		NewPHPProperty("a", NewPHPList(NewPHPString("x"), inner)),
+		NewPHPProtectedProperty("b", NewPHPInt(1)),
+		NewPHPPrivateProperty("Base", "c", NewPHPBool(true)),
+	)
+	obj.Properties = append(obj.Properties, NewPHPProperty("d", NewPHPRef(FindRefIndex(obj, inner))))
+	expected := "O:3:\"Foo\":4:{s:1:\"a\";a:2:{i:0;s:1:\"x\";i:1;O:3:\"Bar\":0:{}}s:4:\"\x00*\x00b\";i:1;s:7:\"\x00Base\x00c\";b:1;s:1:\"d\";r:4;}"
+	if raw := MarshalPHPSerialized(obj); string(raw) != expected {
+		t.Fatalf("unexpected serialized object: %#v", string(raw))
+	}
+	dump := Dump(obj)
+	for _, keyword := range []string{"Foo {", `public $a = array(2) [`, `1 => Bar {}`, "protected $b = int(1)", "private(Base) $c = bool(true)", "public $d = ref #4"} {
+		if !strings.Contains(dump, keyword) {
+			t.Fatalf("dump should contain %s:\n%s", keyword, dump)
+		}
+	}
+}
+
+func TestPhar(t *testing.T) {
+	metadata := MarshalPHPSerialized(NewPHPObject("Foo", NewPHPProperty("a", NewPHPString("b"))))
+	raw := BuildPhar(metadata, WithPharStub("GIF89a"), WithPharFile("a.txt", []byte("hello")))
+	if !bytes.HasPrefix(raw, []byte("GIF89a<?php __HALT_COMPILER(); ?>\r\n")) || !bytes.HasSuffix(raw, []byte("GBMB")) {
+		t.Fatalf("invalid phar: %q", raw)
+	}
+	phar, err := ParsePhar(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(phar.Metadata, metadata) {
+		t.Fatalf("unexpected phar metadata: %q", phar.Metadata)
+	}
+	if len(phar.Files) != 1 || phar.Files[0].Name != "a.txt" || string(phar.Files[0].Content) != "hello" {
+		t.Fatalf("unexpected phar files: %v", phar.Files)
+	}
+	if !bytes.Equal(phar.Bytes(), raw) {
+		t.Fatal("phar is different after parsing")
+	}
+
+	raw[len(raw)-10] ^= 0xff
+	if _, err := ParsePhar(raw); err == nil {
+		t.Fatal("phar with invalid signature should be rejected")
+	}
+}
@@ -0,0 +1,159 @@
+package sarif
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/syntaxflow/sfrule"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/static_analyzer/result"
+)
+
+var ruleIdInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_./-]+`)
+
+// staticAnalyzeRuleId build rule id by the source of result, e.g. yak-static-analyzer/SSA:Value
+func staticAnalyzeRuleId(r *result.StaticAnalyzeResult) string {
+	from := strings.TrimSpace(r.From)
+	if r.Tag != "" {
+		from = strings.TrimSpace(from + "/" + r.Tag)
+	}
+	from = strings.Trim(ruleIdInvalidChars.ReplaceAllString(from, "-"), "-/")
+	if from == "" {
+		return "yak-static-analyzer"
+	}
+	return "yak-static-analyzer/" + from
+}
+
+// AddStaticAnalyzeResults add the results of yak plugin static analyzer, columns of results are 1-based
+func (r *Run) AddStaticAnalyzeResults(file string, results []*result.StaticAnalyzeResult) {
+	for _, res := range results {
+		if res == nil {
+			continue
+		}
+		ruleId := staticAnalyzeRuleId(res)
+		level := Level(res.Severity)
+		r.AddRule(&ReportingDescriptor{
+			Id:                   ruleId,
+			Name:                 ruleId,
+			ShortDescription:     &Message{Text: "yak static analyzer: " + strings.TrimPrefix(ruleId, "yak-static-analyzer/")},
+			DefaultConfiguration: &ReportingConfiguration{Level: level},
+		})
+		r.AddResult(&Result{
+			RuleId:  ruleId,
+			Level:   level,
+			Message: &Message{Text: res.Message},
+			Locations: []*Location{
+				r.NewLocation(file, res.StartLineNumber, res.StartColumn, res.EndLineNumber, res.EndColumn, ""),
+			},
+			PartialFingerprints: map[string]string{
+				"primaryLocationLineHash": utils.CalcSha1(ruleId, file, res.Message, res.StartLineNumber),
+			},
+		})
+	}
+}
+
+// AddSyntaxFlowRule add sfrule as sarif rule
+func (r *Run) AddSyntaxFlowRule(rule *sfrule.Rule) int {
+	tags := []string{"security"}
+	for _, cwe := range rule.CWE {
+		tags = append(tags, "external/cwe/"+strings.ToLower(cwe))
+	}
+	desc := &ReportingDescriptor{
+		Id:                   rule.Id,
+		Name:                 rule.Name,
+		ShortDescription:     &Message{Text: rule.Name},
+		DefaultConfiguration: &ReportingConfiguration{Level: Level(rule.Severity)},
+		Properties: map[string]any{
+			"tags":              tags,
+			"security-severity": SecuritySeverity(rule.Severity),
+		},
+	}
+	if rule.Description != "" {
+		desc.FullDescription = &Message{Text: rule.Description}
+	}
+	if rule.Solution != "" {
+		desc.Help = &Message{Text: rule.Solution}
+	}
+	return r.AddRule(desc)
+}
+
+// flowLocation ssa column is 0-based, nil is returned if the file of node is unknown
+func (r *Run) flowLocation(file string, node *sfrule.FlowNode) *Location {
+	if node.File != "" {
+		file = node.File
+	}
+	if file == "" {
+		return nil
+	}
+	return r.NewLocation(file, node.StartLine, node.StartColumn+1, node.EndLine, node.EndColumn+1, node.Source)
+}
+
+// AddSyntaxFlowFindings add the findings of sfrule in file, the data flow is converted to code flow.
+// the file of the node is used instead if the program is parsed as project (file is empty),
+// the location of node without file is left out
+func (r *Run) AddSyntaxFlowFindings(file string, findings []*sfrule.Finding) {
+	for _, f := range findings {
+		if f == nil || f.Sink == nil {
+			continue
+		}
+		if _, ok := r.ruleIndex[f.RuleId]; !ok {
+			r.AddSyntaxFlowRule(&sfrule.Rule{
+				Id:          f.RuleId,
+				Name:        f.RuleName,
+				Severity:    f.Severity,
+				CWE:         f.CWE,
+				Description: f.Description,
+				Solution:    f.Solution,
+			})
+		}
+
+		msg := fmt.Sprintf("%v: data flows from `%v` to `%v`", f.RuleName, nodeText(f.Source), nodeText(f.Sink))
+		res := &Result{
+			RuleId:    f.RuleId,
+			Level:     Level(f.Severity),
+			Message:   &Message{Text: msg},
+			Locations: []*Location{},
+		}
+		var uri string
+		if sink := r.flowLocation(file, f.Sink); sink != nil {
+			res.Locations = []*Location{sink}
+			uri = sink.PhysicalLocation.ArtifactLocation.URI
+		}
+		res.PartialFingerprints = map[string]string{
+			"primaryLocationLineHash": utils.CalcSha1(f.RuleId, uri, nodeText(f.Source), nodeText(f.Sink), f.Sink.StartLine),
+		}
+		if len(f.Path) > 0 {
+			flow := &ThreadFlow{}
+			for i, node := range f.Path {
+				loc := r.flowLocation(file, node)
+				if loc == nil {
+					continue
+				}
+				switch i {
+				case 0:
+					loc.Message = &Message{Text: "source: " + nodeText(node)}
+				case len(f.Path) - 1:
+					loc.Message = &Message{Text: "sink: " + nodeText(node)}
+				default:
+					loc.Message = &Message{Text: nodeText(node)}
+				}
+				flow.Locations = append(flow.Locations, &ThreadFlowLocation{Location: loc})
+			}
+			if len(flow.Locations) > 0 {
+				res.CodeFlows = []*CodeFlow{{ThreadFlows: []*ThreadFlow{flow}}}
+			}
+		}
+		r.AddResult(res)
+	}
+}
+
+func nodeText(node *sfrule.FlowNode) string {
+	if node == nil {
+		return ""
+	}
+	if s := strings.TrimSpace(node.Source); s != "" {
+		return s
+	}
+	return node.Code
+}
@@ -0,0 +1,267 @@
+package sarif
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// SARIF 2.1.0, only the fields used by code scanning are defined
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	LevelError   = "error"
+	LevelWarning = "warning"
+	LevelNote    = "note"
+	LevelNone    = "none"
+)
+
+type Report struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []*Run `json:"runs"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Tool struct {
+	Driver *Driver `json:"driver"`
+}
+
+type Driver struct {
+	Name           string                 `json:"name"`
+	Version        string                 `json:"version,omitempty"`
+	InformationURI string                 `json:"informationUri,omitempty"`
+	Rules          []*ReportingDescriptor `json:"rules"`
+}
+
+type ReportingConfiguration struct {
+	Level string `json:"level,omitempty"`
+}
+
+// ReportingDescriptor is the rule of results
+type ReportingDescriptor struct {
+	Id                   string                  `json:"id"`
+	Name                 string                  `json:"name,omitempty"`
+	ShortDescription     *Message                `json:"shortDescription,omitempty"`
+	FullDescription      *Message                `json:"fullDescription,omitempty"`
+	Help                 *Message                `json:"help,omitempty"`
+	DefaultConfiguration *ReportingConfiguration `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]any          `json:"properties,omitempty"`
+}
+
+type Artifact struct {
+	Location *ArtifactLocation `json:"location"`
+}
+
+type ArtifactLocation struct {
+	URI   string `json:"uri"`
+	Index int    `json:"index"`
+}
+
+// Region line and column are 1-based
+type Region struct {
+	StartLine   int64    `json:"startLine,omitempty"`
+	StartColumn int64    `json:"startColumn,omitempty"`
+	EndLine     int64    `json:"endLine,omitempty"`
+	EndColumn   int64    `json:"endColumn,omitempty"`
+	Snippet     *Message `json:"snippet,omitempty"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation *ArtifactLocation `json:"artifactLocation"`
+	Region           *Region           `json:"region,omitempty"`
+}
+
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation"`
+	Message          *Message          `json:"message,omitempty"`
+}
+
+type ThreadFlowLocation struct {
+	Location *Location `json:"location"`
+}
+
+type ThreadFlow struct {
+	Locations []*ThreadFlowLocation `json:"locations"`
+}
+
+type CodeFlow struct {
+	Message     *Message      `json:"message,omitempty"`
+	ThreadFlows []*ThreadFlow `json:"threadFlows"`
+}
+
+type Result struct {
+	RuleId              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level,omitempty"`
+	Message             *Message          `json:"message"`
+	Locations           []*Location       `json:"locations"`
+	CodeFlows           []*CodeFlow       `json:"codeFlows,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+}
+
+type Run struct {
+	Tool      *Tool       `json:"tool"`
+	Artifacts []*Artifact `json:"artifacts"`
+	Results   []*Result   `json:"results"`
+
+	ruleIndex     map[string]int
+	artifactIndex map[string]int
+}
+
+func NewReport() *Report {
+	return &Report{
+		Schema:  Schema,
+		Version: Version,
+		Runs:    make([]*Run, 0),
+	}
+}
+
+// NewRun create a run for the tool, the results of a tool should be in the same run
+func (r *Report) NewRun(name, version string) *Run {
+	run := &Run{
+		Tool: &Tool{Driver: &Driver{
+			Name:           name,
+			Version:        version,
+			InformationURI: "https://yaklang.io",
+			Rules:          make([]*ReportingDescriptor, 0),
+		}},
+		Artifacts:     make([]*Artifact, 0),
+		Results:       make([]*Result, 0),
+		ruleIndex:     make(map[string]int),
+		artifactIndex: make(map[string]int),
+	}
+	r.Runs = append(r.Runs, run)
+	return run
+}
+
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+func (r *Report) Write(w io.Writer) error {
+	raw, err := r.JSON()
+	if err != nil {
+		return utils.Errorf("marshal sarif failed: %s", err)
+	}
+	_, err = w.Write(raw)
+	return err
+}
+
+func (r *Report) WriteFile(file string) error {
+	raw, err := r.JSON()
+	if err != nil {
+		return utils.Errorf("marshal sarif failed: %s", err)
+	}
+	return os.WriteFile(file, raw, 0o644)
+}
+
+// AddRule register the rule if not existed, return the index of rule
+func (r *Run) AddRule(rule *ReportingDescriptor) int {
+	if idx, ok := r.ruleIndex[rule.Id]; ok {
+		return idx
+	}
+	idx := len(r.Tool.Driver.Rules)
+	r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule)
+	r.ruleIndex[rule.Id] = idx
+	return idx
+}
+
+// AddArtifact register the file if not existed, return the location of file
+func (r *Run) AddArtifact(uri string) *ArtifactLocation {
+	uri = ToURI(uri)
+	idx, ok := r.artifactIndex[uri]
+	if !ok {
+		idx = len(r.Artifacts)
+		r.Artifacts = append(r.Artifacts, &Artifact{Location: &ArtifactLocation{URI: uri, Index: idx}})
+		r.artifactIndex[uri] = idx
+	}
+	return &ArtifactLocation{URI: uri, Index: idx}
+}
+
+// AddResult add the result, the rule should be added before
+func (r *Run) AddResult(result *Result) {
+	if idx, ok := r.ruleIndex[result.RuleId]; ok {
+		result.RuleIndex = idx
+	} else {
+		result.RuleIndex = r.AddRule(&ReportingDescriptor{Id: result.RuleId})
+	}
+	if result.Locations == nil {
+		result.Locations = make([]*Location, 0)
+	}
+	r.Results = append(r.Results, result)
+}
+
+// NewLocation build location in file, line / column is 1-based
+func (r *Run) NewLocation(file string, startLine, startColumn, endLine, endColumn int64, snippet string) *Location {
+	region := &Region{
+		StartLine:   max64(startLine, 1),
+		StartColumn: max64(startColumn, 1),
+		EndLine:     endLine,
+		EndColumn:   endColumn,
+	}
+	if region.EndLine < region.StartLine {
+		region.EndLine = region.StartLine
+	}
+	if region.EndLine == region.StartLine && region.EndColumn <= region.StartColumn {
+		region.EndColumn = region.StartColumn + 1
+	}
+	if snippet != "" {
+		region.Snippet = &Message{Text: snippet}
+	}
+	return &Location{PhysicalLocation: &PhysicalLocation{
+		ArtifactLocation: r.AddArtifact(file),
+		Region:           region,
+	}}
+}
+
+// Level convert severity (critical / high / medium / low / info / error / warning) to sarif level
+func Level(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical", "high", "error", "fatal":
+		return LevelError
+	case "medium", "middle", "warning", "warn":
+		return LevelWarning
+	case "low", "info", "information", "deprecated", "note", "hint":
+		return LevelNote
+	default:
+		return LevelWarning
+	}
+}
+
+// SecuritySeverity is used by code scanning to rank the results
+func SecuritySeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "critical":
+		return "9.5"
+	case "high":
+		return "8.0"
+	case "medium", "middle":
+		return "5.5"
+	case "low":
+		return "3.0"
+	default:
+		return "0.0"
+	}
+}
+
+// ToURI use slash as separator, the relative path is kept
+func ToURI(file string) string {
+	return strings.ReplaceAll(file, "\\", "/")
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,124 @@
+package sarif
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/syntaxflow/sfrule"
+	"github.com/yaklang/yaklang/common/yak/ssaapi"
+	"github.com/yaklang/yaklang/common/yak/static_analyzer/result"
+)
+
+func TestStaticAnalyzeResults(t *testing.T) {
+	report := NewReport()
+	run := report.NewRun("yak", "dev")
+	run.AddStaticAnalyzeResults("a.yak", []*result.StaticAnalyzeResult{
+		{Message: "undefined variable a", Severity: "Error", StartLineNumber: 2, StartColumn: 1, EndLineNumber: 2, EndColumn: 2, From: "SSA"},
+		{Message: "undefined variable b", Severity: "Error", StartLineNumber: 3, StartColumn: 1, EndLineNumber: 3, EndColumn: 2, From: "SSA"},
+		{Message: "unused value", Severity: "Warning", From: "SSA", Tag: "Value"},
+	})
+	run.AddStaticAnalyzeResults("b.yak", []*result.StaticAnalyzeResult{
+		{Message: "unused value", Severity: "Warning", StartLineNumber: 1, StartColumn: 1, EndLineNumber: 1, EndColumn: 5, From: "SSA", Tag: "Value"},
+	})
+
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	require.Len(t, run.Results, 4)
+	require.Len(t, run.Artifacts, 2)
+	assert.Equal(t, "yak-static-analyzer/SSA", run.Results[0].RuleId)
+	assert.Equal(t, LevelError, run.Results[0].Level)
+	assert.Equal(t, 1, run.Results[2].RuleIndex)
+	assert.Equal(t, LevelWarning, run.Results[3].Level)
+	assert.Equal(t, 1, run.Results[3].Locations[0].PhysicalLocation.ArtifactLocation.Index)
+
+	// zero position should be fixed to 1-based region
+	region := run.Results[2].Locations[0].PhysicalLocation.Region
+	assert.Equal(t, int64(1), region.StartLine)
+	assert.Equal(t, int64(1), region.StartColumn)
+
+	raw, err := report.JSON()
+	require.NoError(t, err)
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(raw, &m))
+	assert.Equal(t, Version, m["version"])
+	assert.Equal(t, Schema, m["$schema"])
+}
+
+func TestSyntaxFlowFindings(t *testing.T) {
+	prog, err := ssaapi.Parse(`<?php
+$cmd = $_GET["cmd"];
+system("ls " . $cmd);
+`, ssaapi.WithLanguage(ssaapi.PHP))
+	require.NoError(t, err)
+	findings, err := sfrule.Run(prog, sfrule.GetBuiltinRules())
+	require.NoError(t, err)
+	require.NotEmpty(t, findings)
+
+	report := NewReport()
+	run := report.NewRun("yak", "dev")
+	run.AddSyntaxFlowFindings("src/index.php", findings)
+	require.Len(t, run.Results, len(findings))
+
+	res := run.Results[0]
+	assert.Equal(t, LevelError, res.Level)
+	assert.Equal(t, "src/index.php", res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, int64(3), res.Locations[0].PhysicalLocation.Region.StartLine)
+	require.Len(t, res.CodeFlows, 1)
+	locations := res.CodeFlows[0].ThreadFlows[0].Locations
+	require.GreaterOrEqual(t, len(locations), 2)
+	for _, loc := range locations {
+		assert.GreaterOrEqual(t, loc.Location.PhysicalLocation.Region.StartColumn, int64(1))
+	}
+	assert.Contains(t, locations[0].Location.Message.Text, "source")
+	assert.Contains(t, locations[len(locations)-1].Location.Message.Text, "sink")
+
+	rule := run.Tool.Driver.Rules[res.RuleIndex]
+	assert.Equal(t, res.RuleId, rule.Id)
+	assert.Contains(t, rule.Properties["tags"], "security")
+}
+
+func TestSyntaxFlowFindingsInProject(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "run.php"), []byte(`<?php
+function run($c) {
+	system("ping " . $c);
+}
+`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.php"), []byte(`<?php
+include "lib/run.php";
+run($_GET["host"]);
+`), 0o644))
+
+	prog, err := ssaapi.ParseProject(dir, ssaapi.WithLanguage(ssaapi.PHP))
+	require.NoError(t, err)
+	findings, err := sfrule.Run(prog, sfrule.GetBuiltinRules())
+	require.NoError(t, err)
+	require.NotEmpty(t, findings)
+
+	report := NewReport()
+	run := report.NewRun("yak", "dev")
+	run.AddSyntaxFlowFindings("", findings)
+	res := run.Results[0]
+	assert.Equal(t, "lib/run.php", res.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, int64(3), res.Locations[0].PhysicalLocation.Region.StartLine)
+	locations := res.CodeFlows[0].ThreadFlows[0].Locations
+	assert.Equal(t, "index.php", locations[0].Location.PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, int64(3), locations[0].Location.PhysicalLocation.Region.StartLine)
+
+	// the location of node without file is left out
+	run.AddSyntaxFlowFindings("", []*sfrule.Finding{{
+		RuleId:   "no-file",
+		RuleName: "no-file",
+		Severity: "high",
+		Source:   &sfrule.FlowNode{StartLine: 1},
+		Sink:     &sfrule.FlowNode{StartLine: 2},
+	}})
+	res = run.Results[len(run.Results)-1]
+	assert.Equal(t, "no-file", res.RuleId)
+	assert.Empty(t, res.Locations)
+	assert.Empty(t, res.CodeFlows)
+}
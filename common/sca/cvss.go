@@ -0,0 +1,132 @@
+package sca
+
+import (
+	"math"
+	"strings"
+)
+
+var (
+	cvssV3Weights = map[string]map[string]float64{
+		"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+		"AC": {"L": 0.77, "H": 0.44},
+		"UI": {"N": 0.85, "R": 0.62},
+		"C":  {"H": 0.56, "L": 0.22, "N": 0},
+		"I":  {"H": 0.56, "L": 0.22, "N": 0},
+		"A":  {"H": 0.56, "L": 0.22, "N": 0},
+	}
+	cvssV2Weights = map[string]map[string]float64{
+		"AV": {"L": 0.395, "A": 0.646, "N": 1.0},
+		"AC": {"H": 0.35, "M": 0.61, "L": 0.71},
+		"Au": {"M": 0.45, "S": 0.56, "N": 0.704},
+		"C":  {"N": 0, "P": 0.275, "C": 0.660},
+		"I":  {"N": 0, "P": 0.275, "C": 0.660},
+		"A":  {"N": 0, "P": 0.275, "C": 0.660},
+	}
+)
+
+func parseCVSSVector(vector string) map[string]string {
+	metrics := make(map[string]string)
+	for _, item := range strings.Split(strings.Trim(vector, "()"), "/") {
+		k, v, ok := strings.Cut(item, ":")
+		if !ok {
+			continue
+		}
+		metrics[k] = v
+	}
+	return metrics
+}
+
+// cvssBaseScore calculate the base score and version of cvss vector, e.g. CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H
+// or AV:N/AC:L/Au:N/C:P/I:P/A:P (v2)
+func cvssBaseScore(vector string) (float64, string, bool) {
+	metrics := parseCVSSVector(vector)
+	if version, ok := metrics["CVSS"]; ok {
+		if !strings.HasPrefix(version, "3") {
+			return 0, "", false
+		}
+		score, ok := cvssV3BaseScore(version, metrics)
+		return score, version, ok
+	}
+	score, ok := cvssV2BaseScore(metrics)
+	return score, "2.0", ok
+}
+
+func cvssWeights(weights map[string]map[string]float64, metrics map[string]string) (map[string]float64, bool) {
+	ret := make(map[string]float64, len(weights))
+	for metric, values := range weights {
+		w, ok := values[metrics[metric]]
+		if !ok {
+			return nil, false
+		}
+		ret[metric] = w
+	}
+	return ret, true
+}
+
+func cvssV3BaseScore(version string, metrics map[string]string) (float64, bool) {
+	w, ok := cvssWeights(cvssV3Weights, metrics)
+	if !ok {
+		return 0, false
+	}
+	changed := metrics["S"] == "C"
+	if !changed && metrics["S"] != "U" {
+		return 0, false
+	}
+	var pr float64
+	switch metrics["PR"] {
+	case "N":
+		pr = 0.85
+	case "L":
+		pr = 0.62
+		if changed {
+			pr = 0.68
+		}
+	case "H":
+		pr = 0.27
+		if changed {
+			pr = 0.5
+		}
+	default:
+		return 0, false
+	}
+
+	roundUp := func(f float64) float64 {
+		if version == "3.0" {
+			return math.Ceil(f*10) / 10
+		}
+		// CVSS v3.1 Appendix A, avoid the floating point error of ceil
+		i := int64(math.Round(f * 100000))
+		if i%10000 == 0 {
+			return float64(i) / 100000
+		}
+		return float64(i/10000+1) / 10
+	}
+
+	iss := 1 - (1-w["C"])*(1-w["I"])*(1-w["A"])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, true
+	}
+	exploitability := 8.22 * w["AV"] * w["AC"] * pr * w["UI"]
+	if changed {
+		return roundUp(math.Min(1.08*(impact+exploitability), 10)), true
+	}
+	return roundUp(math.Min(impact+exploitability, 10)), true
+}
+
+func cvssV2BaseScore(metrics map[string]string) (float64, bool) {
+	w, ok := cvssWeights(cvssV2Weights, metrics)
+	if !ok {
+		return 0, false
+	}
+	impact := 10.41 * (1 - (1-w["C"])*(1-w["I"])*(1-w["A"]))
+	exploitability := 20 * w["AV"] * w["AC"] * w["Au"]
+	if impact == 0 {
+		return 0, true
+	}
+	score := (0.6*impact + 0.4*exploitability - 1.5) * 1.176
+	return math.Round(score*10) / 10, true
+}
@@ -6,7 +6,10 @@ import (
 	cdx "github.com/CycloneDX/cyclonedx-go"
 	"github.com/yaklang/yaklang/common/filter"
 	"github.com/yaklang/yaklang/common/go-funk"
+	"github.com/yaklang/yaklang/common/utils"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func normalCyloneDXHashType(i string) (cdx.HashAlgorithm, bool) {
@@ -87,22 +90,171 @@ func dxPackagesToCycloneDXComponent(pkgFilter *filter.StringFilter, pkgs []*Pack
 			}
 		}
 		ret = append(ret, cdx.Component{
+			BOMRef:     id,
 			Name:       pkg.Name,
 			Version:    pkg.Version,
 			Hashes:     &hashes, // pkg.Verification
 			Licenses:   &lis,
 			CPE:        cpe,
 			Components: &sub,
+			Properties: dxPackageToCycloneDXProperties(pkg),
 		})
 	}
 	return ret
 }
 
+// the properties keep the information of dxtypes.Package which cyclonedx not support, used by ParseCycloneDXSBOM
+const (
+	cycloneDXPropertyAnalyzer  = "yak:analyzer"
+	cycloneDXPropertyFile      = "yak:file"
+	cycloneDXPropertyPotential = "yak:potential"
+)
+
+func dxPackageToCycloneDXProperties(pkg *Package) *[]cdx.Property {
+	var props []cdx.Property
+	for _, a := range pkg.FromAnalyzer {
+		props = append(props, cdx.Property{Name: cycloneDXPropertyAnalyzer, Value: a})
+	}
+	for _, f := range pkg.FromFile {
+		props = append(props, cdx.Property{Name: cycloneDXPropertyFile, Value: f})
+	}
+	if pkg.Potential {
+		props = append(props, cdx.Property{Name: cycloneDXPropertyPotential, Value: "true"})
+	}
+	if len(props) <= 0 {
+		return nil
+	}
+	return &props
+}
+
+// dxPackagesToCycloneDXDependencies build the dependencies section by upstream packages
+func dxPackagesToCycloneDXDependencies(pkgFilter *filter.StringFilter, pkgs []*Package) []cdx.Dependency {
+	var ret []cdx.Dependency
+	for _, pkg := range allPackages(pkgs) {
+		id := fmt.Sprintf("%v-%v", pkg.Name, pkg.Version)
+		if !pkgFilter.Exist(id) {
+			continue
+		}
+		var dependsOn []string
+		for _, up := range sortedPackages(pkg.UpStreamPackages) {
+			upId := fmt.Sprintf("%v-%v", up.Name, up.Version)
+			if pkgFilter.Exist(upId) {
+				dependsOn = append(dependsOn, upId)
+			}
+		}
+		if len(dependsOn) <= 0 {
+			continue
+		}
+		ret = append(ret, cdx.Dependency{Ref: id, Dependencies: &dependsOn})
+	}
+	return ret
+}
+
 func CreateCycloneDXSBOMByDXPackages(pkgs []*Package) *cdx.BOM {
 	bom := cdx.NewBOM()
 	filter := filter.NewFilter()
 	ret := dxPackagesToCycloneDXComponent(filter, pkgs)
 	bom.Components = &ret
+	if deps := dxPackagesToCycloneDXDependencies(filter, pkgs); len(deps) > 0 {
+		bom.Dependencies = &deps
+	}
+	return bom
+}
+
+func cycloneDXSeverity(severity string) cdx.Severity {
+	switch strings.ToLower(severity) {
+	case "critical", "high", "medium", "low", "info", "none":
+		return cdx.Severity(strings.ToLower(severity))
+	case "moderate":
+		return cdx.Severity("medium")
+	default:
+		return cdx.Severity("unknown")
+	}
+}
+
+func cycloneDXScoringMethod(version string) cdx.ScoringMethod {
+	switch {
+	case strings.HasPrefix(version, "2"):
+		return cdx.ScoringMethod("CVSSv2")
+	case strings.HasPrefix(version, "3.1"):
+		return cdx.ScoringMethod("CVSSv31")
+	case strings.HasPrefix(version, "3"):
+		return cdx.ScoringMethod("CVSSv3")
+	default:
+		return cdx.ScoringMethod("other")
+	}
+}
+
+func dxVulnerabilitiesToCycloneDX(vulns []*PackageVulnerability) []cdx.Vulnerability {
+	// the same cve may affect several packages
+	var (
+		ret   = make([]cdx.Vulnerability, 0)
+		index = make(map[string]int)
+	)
+	for _, pv := range vulns {
+		if pv == nil || pv.Package == nil {
+			continue
+		}
+		ref := fmt.Sprintf("%v-%v", pv.Package.Name, pv.Package.Version)
+		for _, v := range pv.Vulnerabilities {
+			affect := cdx.Affects{
+				Ref: ref,
+				Range: &[]cdx.AffectedVersions{{
+					Version: pv.Package.Version,
+					Range:   v.AffectedVersion,
+					Status:  cdx.VulnerabilityStatus("affected"),
+				}},
+			}
+			if idx, ok := index[v.CVE]; ok {
+				affects := append(*ret[idx].Affects, affect)
+				ret[idx].Affects = &affects
+				continue
+			}
+
+			score := v.Score
+			ratings := []cdx.VulnerabilityRating{{
+				Source:   &cdx.Source{Name: "NVD"},
+				Score:    &score,
+				Severity: cycloneDXSeverity(v.Severity),
+				Method:   cycloneDXScoringMethod(v.CVSSVersion),
+				Vector:   v.CVSSVector,
+			}}
+			var cwes []int
+			for _, cwe := range strings.Split(v.CWE, "|") {
+				if i, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(cwe), "CWE-")); err == nil {
+					cwes = append(cwes, i)
+				}
+			}
+			affects := []cdx.Affects{affect}
+			vuln := cdx.Vulnerability{
+				BOMRef:         v.CVE,
+				ID:             v.CVE,
+				Source:         &cdx.Source{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/" + v.CVE},
+				Ratings:        &ratings,
+				Description:    v.Description,
+				Recommendation: v.Solution,
+				Affects:        &affects,
+			}
+			if len(cwes) > 0 {
+				vuln.CWEs = &cwes
+			}
+			if !v.PublishedAt.IsZero() {
+				vuln.Published = v.PublishedAt.Format(time.RFC3339)
+			}
+			index[v.CVE] = len(ret)
+			ret = append(ret, vuln)
+		}
+	}
+	return ret
+}
+
+// CreateCycloneDXSBOMWithVulnerabilities create sbom with the vulnerabilities section, the vulnerabilities are matched by sca.MatchVulnerabilities
+func CreateCycloneDXSBOMWithVulnerabilities(pkgs []*Package, vulns []*PackageVulnerability) *cdx.BOM {
+	bom := CreateCycloneDXSBOMByDXPackages(pkgs)
+	ret := dxVulnerabilitiesToCycloneDX(vulns)
+	if len(ret) > 0 {
+		bom.Vulnerabilities = &ret
+	}
 	return bom
 }
 
@@ -114,3 +266,104 @@ func MarshalCycloneDXBomToJSON(bom *cdx.BOM) ([]byte, error) {
 	}
 	return buf.Bytes(), nil
 }
+
+func cycloneDXComponentToDXPackage(c *cdx.Component) *Package {
+	pkg := &Package{
+		Name:    c.Name,
+		Version: c.Version,
+	}
+	if c.Group != "" {
+		pkg.Name = c.Group + ":" + c.Name
+	}
+	if c.PackageURL != "" {
+		if p, err := parsePURL(c.PackageURL); err == nil {
+			pkg.Name = p.PackageName()
+			if pkg.Version == "" {
+				pkg.Version = p.Version
+			}
+			pkg.FromAnalyzer = append(pkg.FromAnalyzer, SBOMAnalyzerPrefix+p.Type)
+		}
+	}
+	pkg.IsVersionRange = pkg.HasVersionRange()
+	if c.CPE != "" {
+		pkg.AmendedCPE = append(pkg.AmendedCPE, c.CPE)
+	}
+	if c.Hashes != nil && len(*c.Hashes) > 0 {
+		h := (*c.Hashes)[0]
+		pkg.Verification = strings.ToLower(strings.ReplaceAll(string(h.Algorithm), "-", "")) + ":" + h.Value
+	}
+	if c.Licenses != nil {
+		for _, l := range *c.Licenses {
+			switch {
+			case l.License != nil && l.License.ID != "":
+				pkg.License = append(pkg.License, l.License.ID)
+			case l.License != nil && l.License.Name != "":
+				pkg.License = append(pkg.License, l.License.Name)
+			case l.Expression != "":
+				pkg.License = append(pkg.License, l.Expression)
+			}
+		}
+	}
+	if c.Properties != nil {
+		var fromAnalyzer []string
+		for _, prop := range *c.Properties {
+			switch prop.Name {
+			case cycloneDXPropertyAnalyzer:
+				fromAnalyzer = append(fromAnalyzer, prop.Value)
+			case cycloneDXPropertyFile:
+				pkg.FromFile = append(pkg.FromFile, prop.Value)
+			case cycloneDXPropertyPotential:
+				pkg.Potential = prop.Value == "true"
+			}
+		}
+		if len(fromAnalyzer) > 0 {
+			pkg.FromAnalyzer = fromAnalyzer
+		}
+	}
+	return pkg
+}
+
+// ParseCycloneDXSBOM import CycloneDX json / xml, nested components are flattened,
+// the dependency relationships are built by the dependencies section
+func ParseCycloneDXSBOM(raw []byte) ([]*Package, error) {
+	format := cdx.BOMFileFormatJSON
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("<")) {
+		format = cdx.BOMFileFormatXML
+	}
+	bom := new(cdx.BOM)
+	if err := cdx.NewBOMDecoder(bytes.NewReader(raw), format).Decode(bom); err != nil {
+		return nil, utils.Errorf("decode cyclonedx sbom failed: %s", err)
+	}
+
+	set := newSBOMPackageSet()
+	var walk func(components *[]cdx.Component)
+	walk = func(components *[]cdx.Component) {
+		if components == nil {
+			return
+		}
+		for i := range *components {
+			c := &(*components)[i]
+			if c.Name != "" {
+				id := c.BOMRef
+				if id == "" {
+					id = fmt.Sprintf("%v-%v", c.Name, c.Version)
+				}
+				set.add(id, cycloneDXComponentToDXPackage(c))
+			}
+			walk(c.Components)
+		}
+	}
+	walk(bom.Components)
+
+	if bom.Dependencies != nil {
+		for _, dep := range *bom.Dependencies {
+			if dep.Dependencies == nil {
+				continue
+			}
+			for _, up := range *dep.Dependencies {
+				set.link(dep.Ref, up)
+			}
+		}
+	}
+	return set.list(), nil
+}
@@ -0,0 +1,192 @@
+package dxtypes
+
+import (
+	"bytes"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// SBOMAnalyzerPrefix is the prefix of FromAnalyzer for the packages imported from sbom,
+// e.g. sbom-npm, the suffix is the type of purl
+const SBOMAnalyzerPrefix = "sbom-"
+
+// allPackages collect the packages and all the packages linked with them, the order is stable
+func allPackages(pkgs []*Package) []*Package {
+	var (
+		ret     []*Package
+		visited = make(map[string]struct{})
+		walk    func(pkg *Package)
+	)
+	walk = func(pkg *Package) {
+		if pkg == nil {
+			return
+		}
+		id := pkg.Identifier()
+		if _, ok := visited[id]; ok {
+			return
+		}
+		visited[id] = struct{}{}
+		ret = append(ret, pkg)
+		for _, up := range sortedPackages(pkg.UpStreamPackages) {
+			walk(up)
+		}
+		for _, down := range sortedPackages(pkg.DownStreamPackages) {
+			walk(down)
+		}
+	}
+	for _, pkg := range pkgs {
+		walk(pkg)
+	}
+	return ret
+}
+
+func sortedPackages(m map[string]*Package) []*Package {
+	ret := make([]*Package, 0, len(m))
+	for _, pkg := range m {
+		ret = append(ret, pkg)
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Name == ret[j].Name {
+			return ret[i].Version < ret[j].Version
+		}
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+type purl struct {
+	Type      string
+	Namespace string
+	Name      string
+	Version   string
+}
+
+// parsePURL parse package url: pkg:type/namespace/name@version?qualifiers#subpath
+func parsePURL(raw string) (*purl, error) {
+	if !strings.HasPrefix(raw, "pkg:") {
+		return nil, utils.Errorf("invalid purl: %v", raw)
+	}
+	s := strings.TrimPrefix(raw, "pkg:")
+	if idx := strings.Index(s, "#"); idx >= 0 {
+		s = s[:idx]
+	}
+	if idx := strings.Index(s, "?"); idx >= 0 {
+		s = s[:idx]
+	}
+	typ, rest, ok := strings.Cut(strings.TrimLeft(s, "/"), "/")
+	if !ok || rest == "" {
+		return nil, utils.Errorf("invalid purl: %v", raw)
+	}
+	p := &purl{Type: strings.ToLower(typ)}
+	if idx := strings.LastIndex(rest, "@"); idx >= 0 {
+		p.Version, _ = url.PathUnescape(rest[idx+1:])
+		rest = rest[:idx]
+	}
+	if idx := strings.LastIndex(rest, "/"); idx >= 0 {
+		p.Namespace, _ = url.PathUnescape(rest[:idx])
+		rest = rest[idx+1:]
+	}
+	p.Name, _ = url.PathUnescape(rest)
+	return p, nil
+}
+
+// PackageName return the name used by the analyzers of the ecosystem
+func (p *purl) PackageName() string {
+	if p.Namespace == "" {
+		return p.Name
+	}
+	switch p.Type {
+	case "maven":
+		return p.Namespace + ":" + p.Name
+	case "deb", "rpm", "apk", "alpm":
+		// namespace is the distribution
+		return p.Name
+	default:
+		return p.Namespace + "/" + p.Name
+	}
+}
+
+// sbomPackageSet keep the imported packages by the id in sbom
+type sbomPackageSet struct {
+	order []string
+	pkgs  map[string]*Package
+}
+
+func newSBOMPackageSet() *sbomPackageSet {
+	return &sbomPackageSet{pkgs: make(map[string]*Package)}
+}
+
+func (s *sbomPackageSet) add(id string, pkg *Package) {
+	if _, ok := s.pkgs[id]; ok {
+		return
+	}
+	s.order = append(s.order, id)
+	s.pkgs[id] = pkg
+}
+
+// link the package depends on the upstream package
+func (s *sbomPackageSet) link(id, upId string) {
+	pkg, ok := s.pkgs[id]
+	if !ok {
+		return
+	}
+	up, ok := s.pkgs[upId]
+	if !ok || pkg == up {
+		return
+	}
+	pkg.LinkDepend(up)
+}
+
+func (s *sbomPackageSet) list() []*Package {
+	ret := make([]*Package, 0, len(s.order))
+	for _, id := range s.order {
+		ret = append(ret, s.pkgs[id])
+	}
+	return ret
+}
+
+// ParseSBOM import CycloneDX (json / xml) or SPDX (json / tag-value) document as packages,
+// the dependency relationships are kept in UpStreamPackages / DownStreamPackages
+func ParseSBOM(raw []byte) ([]*Package, error) {
+	trimmed := bytes.TrimSpace(raw)
+	switch {
+	case len(trimmed) <= 0:
+		return nil, utils.Error("empty sbom")
+	case trimmed[0] == '<':
+		return ParseCycloneDXSBOM(raw)
+	case trimmed[0] == '{':
+		head := trimmed
+		if len(head) > 4096 {
+			head = head[:4096]
+		}
+		if bytes.Contains(raw, []byte(`"spdxVersion"`)) && !bytes.Contains(head, []byte(`"bomFormat"`)) {
+			return ParseSPDXJSON(raw)
+		}
+		return ParseCycloneDXSBOM(raw)
+	case bytes.HasPrefix(trimmed, []byte("SPDXVersion:")) || bytes.Contains(trimmed, []byte("\nSPDXVersion:")):
+		return ParseSPDXTagValue(raw)
+	}
+	return nil, utils.Error("unknown sbom format, only CycloneDX and SPDX are supported")
+}
+
+// ParseSBOMFile import sbom file, the file is set as FromFile of packages if it is empty
+func ParseSBOMFile(file string) ([]*Package, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, utils.Errorf("read sbom file %v failed: %s", file, err)
+	}
+	pkgs, err := ParseSBOM(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, pkg := range pkgs {
+		if len(pkg.FromFile) <= 0 {
+			pkg.FromFile = []string{file}
+		}
+	}
+	return pkgs, nil
+}
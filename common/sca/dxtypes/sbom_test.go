@@ -0,0 +1,135 @@
+package dxtypes
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func createSBOMTestPackages() []*Package {
+	app := &Package{Name: "app", Version: "1.0.0", FromAnalyzer: []string{"npm-lang"}, FromFile: []string{"/src/package-lock.json"}, License: []string{"MIT"}}
+	lib := &Package{Name: "@babel/core", Version: "7.22.0", FromAnalyzer: []string{"npm-lang"}, FromFile: []string{"/src/package-lock.json"}, License: []string{"MIT License"}, Verification: "sha1:abcdef"}
+	base := &Package{Name: "lodash", Version: "4.17.20", FromAnalyzer: []string{"npm-lang"}, AmendedCPE: []string{"cpe:2.3:a:lodash:lodash:4.17.20:*:*:*:*:node.js:*:*"}}
+	app.LinkDepend(lib)
+	app.LinkDepend(base)
+	lib.LinkDepend(base)
+	return []*Package{app, lib, base}
+}
+
+func checkSBOMPackages(t *testing.T, name string, pkgs []*Package) {
+	if len(pkgs) != 3 {
+		t.Fatalf("%s: pkgs length error: %d(got) != 3(want)", name, len(pkgs))
+	}
+	m := make(map[string]*Package)
+	for _, pkg := range pkgs {
+		m[pkg.Name] = pkg
+	}
+	upNames := func(pkg *Package) string {
+		var names []string
+		for _, up := range pkg.UpStreamPackages {
+			names = append(names, up.Name)
+		}
+		sort.Strings(names)
+		return strings.Join(names, ",")
+	}
+	for pkgName, want := range map[string]string{
+		"app":         "@babel/core,lodash",
+		"@babel/core": "lodash",
+		"lodash":      "",
+	} {
+		pkg, ok := m[pkgName]
+		if !ok {
+			t.Fatalf("%s: package %v not found", name, pkgName)
+		}
+		if got := upNames(pkg); got != want {
+			t.Fatalf("%s: upstream of %v error: %v(got) != %v(want)", name, pkgName, got, want)
+		}
+	}
+	if got := len(m["lodash"].DownStreamPackages); got != 2 {
+		t.Fatalf("%s: downstream of lodash error: %d(got) != 2(want)", name, got)
+	}
+
+	lib := m["@babel/core"]
+	if lib.Version != "7.22.0" || lib.Verification != "sha1:abcdef" || strings.Join(lib.License, ",") != "MIT License" {
+		t.Fatalf("%s: @babel/core error: %v", name, lib)
+	}
+	if strings.Join(lib.FromAnalyzer, ",") != "npm-lang" || strings.Join(lib.FromFile, ",") != "/src/package-lock.json" {
+		t.Fatalf("%s: @babel/core from error: %v %v", name, lib.FromAnalyzer, lib.FromFile)
+	}
+	if len(m["lodash"].AmendedCPE) != 1 {
+		t.Fatalf("%s: lodash cpe error: %v", name, m["lodash"].AmendedCPE)
+	}
+	if strings.Join(m["app"].License, ",") != "MIT" {
+		t.Fatalf("%s: app license error: %v", name, m["app"].License)
+	}
+}
+
+func TestSBOMRoundTrip(t *testing.T) {
+	pkgs := createSBOMTestPackages()
+
+	doc := CreateSPDXSBOMByDXPackages("test", pkgs)
+	raw, err := MarshalSPDXToJSON(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"spdxVersion": "SPDX-2.3"`) {
+		t.Fatalf("spdx json error: %s", raw)
+	}
+	got, err := ParseSBOM(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSBOMPackages(t, "spdx-json", got)
+
+	raw, err = MarshalSPDXToTagValue(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "Relationship: SPDXRef-DOCUMENT DESCRIBES SPDXRef-Package-app-") {
+		t.Fatalf("spdx tag-value error: %s", raw)
+	}
+	got, err = ParseSBOM(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSBOMPackages(t, "spdx-tv", got)
+
+	raw, err = MarshalCycloneDXBomToJSON(CreateCycloneDXSBOMByDXPackages(pkgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ParseSBOM(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSBOMPackages(t, "cyclonedx", got)
+}
+
+func TestParseSPDXWithPURL(t *testing.T) {
+	pkgs, err := ParseSBOM([]byte(`{
+  "spdxVersion": "SPDX-2.3",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "packages": [
+    {"SPDXID": "SPDXRef-a", "name": "log4j-core", "versionInfo": "2.14.1", "licenseConcluded": "Apache-2.0",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"}]},
+    {"SPDXID": "SPDXRef-b", "name": "log4j-api", "versionInfo": "2.14.1",
+     "externalRefs": [{"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:maven/org.apache.logging.log4j/log4j-api@2.14.1"}]}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-b", "relationshipType": "RUNTIME_DEPENDENCY_OF", "relatedSpdxElement": "SPDXRef-a"}
+  ]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pkgs) != 2 {
+		t.Fatalf("pkgs length error: %d", len(pkgs))
+	}
+	core := pkgs[0]
+	if core.Name != "org.apache.logging.log4j:log4j-core" || core.FromAnalyzer[0] != SBOMAnalyzerPrefix+"maven" || core.License[0] != "Apache-2.0" {
+		t.Fatalf("package error: %v", core)
+	}
+	if _, ok := core.UpStreamPackages[pkgs[1].Identifier()]; !ok {
+		t.Fatalf("log4j-core should depend on log4j-api")
+	}
+}
@@ -0,0 +1,451 @@
+package dxtypes
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// SPDX 2.3, only the package information is used
+// https://spdx.github.io/spdx-spec/v2.3/
+
+const (
+	SPDXVersion        = "SPDX-2.3"
+	SPDXDataLicense    = "CC0-1.0"
+	SPDXDocumentID     = "SPDXRef-DOCUMENT"
+	SPDXNoAssertion    = "NOASSERTION"
+	SPDXNone           = "NONE"
+	SPDXDescribes      = "DESCRIBES"
+	SPDXDependsOn      = "DEPENDS_ON"
+	SPDXDependencyOf   = "DEPENDENCY_OF"
+	spdxCreatorTool    = "Tool: yaklang-sca"
+	spdxPotentialMark  = "potential dependency"
+	spdxSourceAnalyzer = "analyzer: "
+	spdxSourceFile     = "file: "
+	spdxLicensePrefix  = "declared licenses: "
+)
+
+type SPDXDocument struct {
+	SPDXVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      *SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []*SPDXPackage      `json:"packages"`
+	Relationships     []*SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string             `json:"SPDXID"`
+	Name             string             `json:"name"`
+	VersionInfo      string             `json:"versionInfo,omitempty"`
+	DownloadLocation string             `json:"downloadLocation"`
+	FilesAnalyzed    bool               `json:"filesAnalyzed"`
+	Checksums        []*SPDXChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string             `json:"licenseConcluded,omitempty"`
+	LicenseDeclared  string             `json:"licenseDeclared,omitempty"`
+	LicenseComments  string             `json:"licenseComments,omitempty"`
+	CopyrightText    string             `json:"copyrightText,omitempty"`
+	SourceInfo       string             `json:"sourceInfo,omitempty"`
+	Comment          string             `json:"comment,omitempty"`
+	ExternalRefs     []*SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXChecksum struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"checksumValue"`
+}
+
+type SPDXExternalRef struct {
+	Category string `json:"referenceCategory"`
+	Type     string `json:"referenceType"`
+	Locator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	Element string `json:"spdxElementId"`
+	Type    string `json:"relationshipType"`
+	Related string `json:"relatedSpdxElement"`
+}
+
+var (
+	spdxIdInvalidChars = regexp.MustCompile(`[^A-Za-z0-9.\-]+`)
+	spdxLicenseId      = regexp.MustCompile(`^[A-Za-z0-9.\-+]+$`)
+)
+
+func spdxPackageId(pkg *Package) string {
+	name := strings.Trim(spdxIdInvalidChars.ReplaceAllString(pkg.Name, "-"), "-")
+	return fmt.Sprintf("SPDXRef-Package-%s-%s", name, pkg.Identifier()[:8])
+}
+
+func spdxChecksumAlgorithm(i string) (string, bool) {
+	switch strings.ToLower(i) {
+	case "md5":
+		return "MD5", true
+	case "sha1", "sha-1":
+		return "SHA1", true
+	case "sha224", "sha-224":
+		return "SHA224", true
+	case "sha256", "sha-256":
+		return "SHA256", true
+	case "sha384", "sha-384":
+		return "SHA384", true
+	case "sha512", "sha-512":
+		return "SHA512", true
+	case "sha3-256", "sha3_256":
+		return "SHA3-256", true
+	case "sha3-384", "sha3_384":
+		return "SHA3-384", true
+	case "sha3-512", "sha3_512":
+		return "SHA3-512", true
+	case "blake2b-256", "blake2b_256":
+		return "BLAKE2b-256", true
+	case "blake2b-384", "blake2b_384":
+		return "BLAKE2b-384", true
+	case "blake2b-512", "blake2b_512":
+		return "BLAKE2b-512", true
+	case "blake3":
+		return "BLAKE3", true
+	}
+	return "", false
+}
+
+func dxPackageToSPDXPackage(pkg *Package) *SPDXPackage {
+	p := &SPDXPackage{
+		SPDXID:           spdxPackageId(pkg),
+		Name:             pkg.Name,
+		VersionInfo:      pkg.Version,
+		DownloadLocation: SPDXNoAssertion,
+		FilesAnalyzed:    false,
+		LicenseConcluded: SPDXNoAssertion,
+		LicenseDeclared:  SPDXNoAssertion,
+		CopyrightText:    SPDXNoAssertion,
+	}
+
+	// license name (e.g. "GPL-2.0+ and LGPL") is not a valid spdx license expression, keep it in comments
+	if len(pkg.License) > 0 {
+		valid := true
+		for _, l := range pkg.License {
+			if !spdxLicenseId.MatchString(l) {
+				valid = false
+				break
+			}
+		}
+		if valid {
+			p.LicenseDeclared = strings.Join(pkg.License, " AND ")
+		} else {
+			p.LicenseComments = spdxLicensePrefix + strings.Join(pkg.License, "; ")
+		}
+	}
+
+	if pkg.Verification != "" {
+		schema, code, _ := strings.Cut(pkg.Verification, ":")
+		if algorithm, ok := spdxChecksumAlgorithm(schema); ok {
+			p.Checksums = []*SPDXChecksum{{Algorithm: algorithm, Value: code}}
+		}
+	}
+	for _, cpe := range pkg.AmendedCPE {
+		typ := "cpe23Type"
+		if strings.HasPrefix(cpe, "cpe:/") {
+			typ = "cpe22Type"
+		}
+		p.ExternalRefs = append(p.ExternalRefs, &SPDXExternalRef{Category: "SECURITY", Type: typ, Locator: cpe})
+	}
+
+	var source []string
+	if len(pkg.FromAnalyzer) > 0 {
+		source = append(source, spdxSourceAnalyzer+strings.Join(pkg.FromAnalyzer, ", "))
+	}
+	if len(pkg.FromFile) > 0 {
+		source = append(source, spdxSourceFile+strings.Join(pkg.FromFile, ", "))
+	}
+	p.SourceInfo = strings.Join(source, "; ")
+	if pkg.Potential {
+		p.Comment = spdxPotentialMark
+	}
+	return p
+}
+
+// CreateSPDXSBOMByDXPackages create SPDX 2.3 document, the linked packages are also included
+func CreateSPDXSBOMByDXPackages(name string, pkgs []*Package) *SPDXDocument {
+	if name == "" {
+		name = "yaklang-sca"
+	}
+	doc := &SPDXDocument{
+		SPDXVersion:       SPDXVersion,
+		DataLicense:       SPDXDataLicense,
+		SPDXID:            SPDXDocumentID,
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://yaklang.io/spdxdocs/%s-%s", spdxIdInvalidChars.ReplaceAllString(name, "-"), uuid.NewV4().String()),
+		CreationInfo: &SPDXCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{spdxCreatorTool},
+		},
+		Packages:      make([]*SPDXPackage, 0),
+		Relationships: make([]*SPDXRelationship, 0),
+	}
+
+	all := allPackages(pkgs)
+	ids := make(map[string]string, len(all))
+	for _, pkg := range all {
+		p := dxPackageToSPDXPackage(pkg)
+		ids[pkg.Identifier()] = p.SPDXID
+		doc.Packages = append(doc.Packages, p)
+	}
+	for _, pkg := range all {
+		id := ids[pkg.Identifier()]
+		// the package that no one depends on is described by the document
+		if len(pkg.DownStreamPackages) <= 0 {
+			doc.Relationships = append(doc.Relationships, &SPDXRelationship{Element: SPDXDocumentID, Type: SPDXDescribes, Related: id})
+		}
+		for _, up := range sortedPackages(pkg.UpStreamPackages) {
+			doc.Relationships = append(doc.Relationships, &SPDXRelationship{Element: id, Type: SPDXDependsOn, Related: ids[up.Identifier()]})
+		}
+	}
+	return doc
+}
+
+func MarshalSPDXToJSON(doc *SPDXDocument) ([]byte, error) {
+	raw, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, utils.Errorf("marshal spdx json failed: %s", err)
+	}
+	return raw, nil
+}
+
+func spdxTagText(s string) string {
+	if strings.ContainsAny(s, "\r\n") {
+		return "<text>" + s + "</text>"
+	}
+	return s
+}
+
+// MarshalSPDXToTagValue marshal the document as SPDX tag-value format
+func MarshalSPDXToTagValue(doc *SPDXDocument) ([]byte, error) {
+	var buf bytes.Buffer
+	write := func(tag, value string) {
+		if value == "" {
+			return
+		}
+		buf.WriteString(tag + ": " + spdxTagText(value) + "\n")
+	}
+
+	write("SPDXVersion", doc.SPDXVersion)
+	write("DataLicense", doc.DataLicense)
+	write("SPDXID", doc.SPDXID)
+	write("DocumentName", doc.Name)
+	write("DocumentNamespace", doc.DocumentNamespace)
+	if doc.CreationInfo != nil {
+		for _, creator := range doc.CreationInfo.Creators {
+			write("Creator", creator)
+		}
+		write("Created", doc.CreationInfo.Created)
+	}
+
+	for _, p := range doc.Packages {
+		buf.WriteString("\n##### Package: " + p.Name + "\n\n")
+		write("PackageName", p.Name)
+		write("SPDXID", p.SPDXID)
+		write("PackageVersion", p.VersionInfo)
+		write("PackageDownloadLocation", p.DownloadLocation)
+		write("FilesAnalyzed", fmt.Sprint(p.FilesAnalyzed))
+		for _, c := range p.Checksums {
+			write("PackageChecksum", c.Algorithm+": "+c.Value)
+		}
+		write("PackageSourceInfo", p.SourceInfo)
+		write("PackageLicenseConcluded", p.LicenseConcluded)
+		write("PackageLicenseDeclared", p.LicenseDeclared)
+		write("PackageLicenseComments", p.LicenseComments)
+		write("PackageCopyrightText", p.CopyrightText)
+		write("PackageComment", p.Comment)
+		for _, ref := range p.ExternalRefs {
+			write("ExternalRef", ref.Category+" "+ref.Type+" "+ref.Locator)
+		}
+	}
+
+	if len(doc.Relationships) > 0 {
+		buf.WriteString("\n##### Relationships\n\n")
+		for _, r := range doc.Relationships {
+			write("Relationship", r.Element+" "+r.Type+" "+r.Related)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseSPDXTagValue parse SPDX tag-value document, only package and relationship are handled
+func ParseSPDXTagValue(raw []byte) ([]*Package, error) {
+	var (
+		doc     = &SPDXDocument{}
+		current *SPDXPackage
+		scanner = bufio.NewScanner(bytes.NewReader(raw))
+	)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		tag, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		// multi-line text
+		if strings.HasPrefix(value, "<text>") {
+			text := strings.TrimPrefix(value, "<text>")
+			for !strings.Contains(text, "</text>") && scanner.Scan() {
+				text += "\n" + scanner.Text()
+			}
+			value, _, _ = strings.Cut(text, "</text>")
+		}
+
+		switch tag {
+		case "SPDXVersion":
+			doc.SPDXVersion = value
+		case "PackageName":
+			current = &SPDXPackage{Name: value}
+			doc.Packages = append(doc.Packages, current)
+		case "Relationship":
+			fields := strings.Fields(value)
+			if len(fields) >= 3 {
+				doc.Relationships = append(doc.Relationships, &SPDXRelationship{Element: fields[0], Type: fields[1], Related: fields[2]})
+			}
+		}
+		if current == nil {
+			continue
+		}
+		switch tag {
+		case "SPDXID":
+			current.SPDXID = value
+		case "PackageVersion":
+			current.VersionInfo = value
+		case "PackageChecksum":
+			algorithm, code, _ := strings.Cut(value, ":")
+			current.Checksums = append(current.Checksums, &SPDXChecksum{Algorithm: strings.TrimSpace(algorithm), Value: strings.TrimSpace(code)})
+		case "PackageSourceInfo":
+			current.SourceInfo = value
+		case "PackageLicenseConcluded":
+			current.LicenseConcluded = value
+		case "PackageLicenseDeclared":
+			current.LicenseDeclared = value
+		case "PackageLicenseComments":
+			current.LicenseComments = value
+		case "PackageComment":
+			current.Comment = value
+		case "ExternalRef":
+			fields := strings.Fields(value)
+			if len(fields) >= 3 {
+				current.ExternalRefs = append(current.ExternalRefs, &SPDXExternalRef{Category: fields[0], Type: fields[1], Locator: fields[2]})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, utils.Errorf("read spdx tag-value failed: %s", err)
+	}
+	if doc.SPDXVersion == "" {
+		return nil, utils.Error("invalid spdx tag-value document: SPDXVersion not found")
+	}
+	return spdxDocumentToDXPackages(doc), nil
+}
+
+// ParseSPDXJSON parse SPDX json document
+func ParseSPDXJSON(raw []byte) ([]*Package, error) {
+	var doc SPDXDocument
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, utils.Errorf("unmarshal spdx json failed: %s", err)
+	}
+	if doc.SPDXVersion == "" {
+		return nil, utils.Error("invalid spdx json document: spdxVersion not found")
+	}
+	return spdxDocumentToDXPackages(&doc), nil
+}
+
+func spdxLicenses(p *SPDXPackage) []string {
+	if strings.HasPrefix(p.LicenseComments, spdxLicensePrefix) {
+		return strings.Split(strings.TrimPrefix(p.LicenseComments, spdxLicensePrefix), "; ")
+	}
+	license := p.LicenseDeclared
+	if license == "" || license == SPDXNoAssertion || license == SPDXNone {
+		license = p.LicenseConcluded
+	}
+	if license == "" || license == SPDXNoAssertion || license == SPDXNone {
+		return nil
+	}
+	var ret []string
+	for _, l := range strings.Split(strings.Trim(license, "()"), " AND ") {
+		if l = strings.TrimSpace(l); l != "" {
+			ret = append(ret, l)
+		}
+	}
+	return ret
+}
+
+func spdxPackageToDXPackage(p *SPDXPackage) *Package {
+	pkg := &Package{
+		Name:      p.Name,
+		Version:   p.VersionInfo,
+		License:   spdxLicenses(p),
+		Potential: p.Comment == spdxPotentialMark,
+	}
+	pkg.IsVersionRange = pkg.HasVersionRange()
+	if len(p.Checksums) > 0 {
+		pkg.Verification = strings.ToLower(strings.ReplaceAll(p.Checksums[0].Algorithm, "-", "")) + ":" + p.Checksums[0].Value
+	}
+	for _, ref := range p.ExternalRefs {
+		switch ref.Type {
+		case "cpe23Type", "cpe22Type":
+			pkg.AmendedCPE = append(pkg.AmendedCPE, ref.Locator)
+		case "purl":
+			if purl, err := parsePURL(ref.Locator); err == nil {
+				pkg.Name = purl.PackageName()
+				if pkg.Version == "" {
+					pkg.Version = purl.Version
+				}
+				pkg.FromAnalyzer = append(pkg.FromAnalyzer, SBOMAnalyzerPrefix+purl.Type)
+			}
+		}
+	}
+	for _, source := range strings.Split(p.SourceInfo, "; ") {
+		switch {
+		case strings.HasPrefix(source, spdxSourceAnalyzer):
+			pkg.FromAnalyzer = strings.Split(strings.TrimPrefix(source, spdxSourceAnalyzer), ", ")
+		case strings.HasPrefix(source, spdxSourceFile):
+			pkg.FromFile = strings.Split(strings.TrimPrefix(source, spdxSourceFile), ", ")
+		}
+	}
+	return pkg
+}
+
+func spdxDocumentToDXPackages(doc *SPDXDocument) []*Package {
+	set := newSBOMPackageSet()
+	for _, p := range doc.Packages {
+		id := p.SPDXID
+		if id == "" {
+			id = p.Name + "@" + p.VersionInfo
+		}
+		set.add(id, spdxPackageToDXPackage(p))
+	}
+	for _, r := range doc.Relationships {
+		switch typ := strings.ToUpper(r.Type); {
+		case typ == SPDXDependsOn:
+			set.link(r.Element, r.Related)
+		case typ == SPDXDependencyOf || strings.HasSuffix(typ, "_DEPENDENCY_OF"):
+			// DEV_DEPENDENCY_OF / BUILD_DEPENDENCY_OF ...
+			set.link(r.Related, r.Element)
+		}
+	}
+	return set.list()
+}
@@ -0,0 +1,44 @@
+package dxtypes
+
+import (
+	"fmt"
+	"time"
+)
+
+type Vulnerability struct {
+	CVE         string
+	CWE         string
+	Title       string
+	Description string
+	Solution    string
+
+	// critical / high / medium / low / unknown
+	Severity    string
+	Score       float64
+	CVSSVersion string
+	CVSSVector  string
+
+	// e.g. >=2.0.0 && <2.15.0
+	AffectedVersion string
+	// the first version not affected, empty if unknown
+	FixedVersion string
+	// the cpe in cve configuration which is matched
+	MatchedCPE string
+
+	PublishedAt time.Time
+}
+
+func (v *Vulnerability) String() string {
+	return fmt.Sprintf("%s[%s] %s", v.CVE, v.Severity, v.AffectedVersion)
+}
+
+// PackageVulnerability is the match result of a package
+type PackageVulnerability struct {
+	Package         *Package
+	Ecosystem       string
+	Vulnerabilities []*Vulnerability
+}
+
+func (p *PackageVulnerability) String() string {
+	return fmt.Sprintf("%s-%s(%s): %v vulnerabilities", p.Package.Name, p.Package.Version, p.Ecosystem, len(p.Vulnerabilities))
+}
@@ -1,6 +1,9 @@
 package sca
 
-import "github.com/yaklang/yaklang/common/sca/analyzer"
+import (
+	"github.com/yaklang/yaklang/common/sca/analyzer"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+)
 
 var Exports = map[string]interface{}{
 	"ScanImageFromContext":     ScanDockerImageFromContext,
@@ -14,6 +17,17 @@ var Exports = map[string]interface{}{
 	"concurrent": _withConcurrent,
 	"analyzers":  _withAnalayzers,
 
+	// vulnerability
+	"MatchVulnerabilities": MatchVulnerabilities,
+	"CompareVersion":       CompareVersion,
+	"ExportCycloneDX":      _exportCycloneDX,
+	"cveDatabase":          _withCVEDatabasePath,
+
+	// sbom
+	"ExportSPDX":   _exportSPDX,
+	"ParseSBOM":    _parseSBOM,
+	"LoadSBOMFile": dxtypes.ParseSBOMFile,
+
 	// use prefix + type name as key
 	// e.g. "ANALYZER_TYPE_DPKG"
 	// keep friendly for completion
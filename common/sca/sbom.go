@@ -0,0 +1,32 @@
+package sca
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+func _exportCycloneDX(pkgs []*dxtypes.Package, vulns []*dxtypes.PackageVulnerability) (string, error) {
+	bom := dxtypes.CreateCycloneDXSBOMWithVulnerabilities(pkgs, vulns)
+	raw, err := dxtypes.MarshalCycloneDXBomToJSON(bom)
+	if err != nil {
+		return "", utils.Errorf("marshal cyclonedx failed: %s", err)
+	}
+	return string(raw), nil
+}
+
+// _exportSPDX export packages as SPDX 2.3, format is json or tag-value (tv)
+func _exportSPDX(pkgs []*dxtypes.Package, format ...string) (string, error) {
+	doc := dxtypes.CreateSPDXSBOMByDXPackages("", pkgs)
+	if len(format) > 0 && (strings.EqualFold(format[0], "tv") || strings.EqualFold(format[0], "tag-value")) {
+		raw, err := dxtypes.MarshalSPDXToTagValue(doc)
+		return string(raw), err
+	}
+	raw, err := dxtypes.MarshalSPDXToJSON(doc)
+	return string(raw), err
+}
+
+func _parseSBOM(raw interface{}) ([]*dxtypes.Package, error) {
+	return dxtypes.ParseSBOM(utils.InterfaceToBytes(raw))
+}
@@ -0,0 +1,325 @@
+package sca
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/sca/analyzer"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+)
+
+const (
+	EcosystemDPKG     = "dpkg"
+	EcosystemRPM      = "rpm"
+	EcosystemAPK      = "apk"
+	EcosystemNPM      = "npm"
+	EcosystemPIP      = "pip"
+	EcosystemMaven    = "maven"
+	EcosystemCargo    = "cargo"
+	EcosystemGem      = "gem"
+	EcosystemComposer = "composer"
+	EcosystemGo       = "go"
+	EcosystemConan    = "conan"
+	EcosystemUnknown  = "unknown"
+)
+
+var analyzerEcosystem = map[analyzer.TypAnalyzer]string{
+	analyzer.TypDPKG:            EcosystemDPKG,
+	analyzer.TypRPM:             EcosystemRPM,
+	analyzer.TypAPK:             EcosystemAPK,
+	analyzer.TypNodeNpm:         EcosystemNPM,
+	analyzer.TypNodePnpm:        EcosystemNPM,
+	analyzer.TypNodeYarn:        EcosystemNPM,
+	analyzer.TypPythonPIP:       EcosystemPIP,
+	analyzer.TypPythonPIPEnv:    EcosystemPIP,
+	analyzer.TypPythonPoetry:    EcosystemPIP,
+	analyzer.TypPythonPackaging: EcosystemPIP,
+	analyzer.TypJavaPom:         EcosystemMaven,
+	analyzer.TypJavaGradle:      EcosystemMaven,
+	analyzer.TypJavaJar:         EcosystemMaven,
+	analyzer.TypRustCargo:       EcosystemCargo,
+	analyzer.TypRubyBundler:     EcosystemGem,
+	analyzer.TypRubyGemSpec:     EcosystemGem,
+	analyzer.TypPHPComposer:     EcosystemComposer,
+	analyzer.TypGoMod:           EcosystemGo,
+	analyzer.TypGoBinary:        EcosystemGo,
+	analyzer.TypClangConan:      EcosystemConan,
+}
+
+// the packages imported from sbom use the type of purl
+var purlTypeEcosystem = map[string]string{
+	"deb":      EcosystemDPKG,
+	"rpm":      EcosystemRPM,
+	"apk":      EcosystemAPK,
+	"npm":      EcosystemNPM,
+	"pypi":     EcosystemPIP,
+	"maven":    EcosystemMaven,
+	"cargo":    EcosystemCargo,
+	"gem":      EcosystemGem,
+	"composer": EcosystemComposer,
+	"golang":   EcosystemGo,
+	"conan":    EcosystemConan,
+}
+
+// PackageEcosystem get the ecosystem of package by the analyzer it comes from
+func PackageEcosystem(pkg *dxtypes.Package) string {
+	for _, from := range pkg.FromAnalyzer {
+		if eco, ok := analyzerEcosystem[analyzer.TypAnalyzer(from)]; ok {
+			return eco
+		}
+		if eco, ok := purlTypeEcosystem[strings.TrimPrefix(from, dxtypes.SBOMAnalyzerPrefix)]; ok && strings.HasPrefix(from, dxtypes.SBOMAnalyzerPrefix) {
+			return eco
+		}
+	}
+	return EcosystemUnknown
+}
+
+var (
+	dpkgEpochRegexp  = regexp.MustCompile(`^\d+:`)
+	apkReleaseRegexp = regexp.MustCompile(`-r\d+$`)
+	goPseudoRegexp   = regexp.MustCompile(`-(0\.)?\d{14}-[0-9a-f]{12}$`)
+)
+
+// NormalizeVersion trim the distribution / ecosystem specific part of version,
+// the result is comparable with the upstream version in cve configurations
+func NormalizeVersion(ecosystem, version string) string {
+	version = strings.TrimSpace(version)
+	switch ecosystem {
+	case EcosystemDPKG:
+		// [epoch:]upstream[-revision], e.g. 1:1.1.1n-0+deb11u3
+		// the revision is kept, the fixes are often backported by distribution without upgrading the upstream version
+		version = dpkgEpochRegexp.ReplaceAllString(version, "")
+		var revision string
+		if idx := strings.LastIndex(version, "-"); idx > 0 {
+			version, revision = version[:idx], version[idx+1:]
+		}
+		if idx := strings.IndexAny(version, "+"); idx > 0 {
+			version = version[:idx]
+		}
+		// 2.0~rc1 is earlier than 2.0
+		version = strings.ReplaceAll(version, "~", "-")
+		version = joinRevision(version, strings.ReplaceAll(revision, "~", "-"))
+	case EcosystemRPM:
+		// [epoch:]version[-release], e.g. 1:1.1.1k-7.el8_6, the release is kept as dpkg revision
+		version = dpkgEpochRegexp.ReplaceAllString(version, "")
+		var release string
+		if idx := strings.LastIndex(version, "-"); idx > 0 {
+			version, release = version[:idx], version[idx+1:]
+		}
+		version = joinRevision(version, release)
+	case EcosystemAPK:
+		version = apkReleaseRegexp.ReplaceAllString(version, "")
+	case EcosystemGo:
+		version = strings.TrimPrefix(version, "v")
+		version = strings.TrimSuffix(version, "+incompatible")
+		version = goPseudoRegexp.ReplaceAllString(version, "")
+	case EcosystemMaven:
+		lower := strings.ToLower(version)
+		for _, suffix := range []string{".release", ".final", ".ga", "-release", "-final", "-ga"} {
+			if strings.HasSuffix(lower, suffix) {
+				version = version[:len(version)-len(suffix)]
+				break
+			}
+		}
+	default:
+		version = strings.TrimLeft(version, "v=^~ ")
+	}
+	return version
+}
+
+type versionToken struct {
+	num   int64
+	isNum bool
+	str   string
+}
+
+// the order of qualifier, release is 0
+var versionQualifierOrder = map[string]int{
+	"dev":       -6,
+	"snapshot":  -6,
+	"alpha":     -5,
+	"a":         -5,
+	"beta":      -4,
+	"b":         -4,
+	"milestone": -3,
+	"m":         -3,
+	"rc":        -2,
+	"cr":        -2,
+	"c":         -2,
+	"pre":       -2,
+	"preview":   -2,
+	"final":     0,
+	"ga":        0,
+	"release":   0,
+}
+
+func (t versionToken) qualifier() (int, bool) {
+	if t.isNum {
+		return 0, false
+	}
+	order, ok := versionQualifierOrder[t.str]
+	return order, ok
+}
+
+func tokenizeVersion(version string) []versionToken {
+	var (
+		ret     []versionToken
+		current strings.Builder
+		isNum   bool
+	)
+	flush := func() {
+		if current.Len() <= 0 {
+			return
+		}
+		s := current.String()
+		current.Reset()
+		if isNum {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err == nil {
+				ret = append(ret, versionToken{num: n, isNum: true, str: s})
+				return
+			}
+		}
+		ret = append(ret, versionToken{str: s})
+	}
+	for _, r := range strings.ToLower(version) {
+		switch {
+		case r >= '0' && r <= '9':
+			if !isNum {
+				flush()
+			}
+			isNum = true
+			current.WriteRune(r)
+		case r >= 'a' && r <= 'z':
+			if isNum {
+				flush()
+			}
+			isNum = false
+			current.WriteRune(r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return ret
+}
+
+// compareTokenWithEnd compare a token with nothing (the end of version)
+func compareTokenWithEnd(t versionToken) int {
+	if t.isNum {
+		if t.num == 0 {
+			return 0
+		}
+		return 1
+	}
+	if order, ok := t.qualifier(); ok {
+		if order < 0 {
+			return -1
+		} else if order == 0 {
+			return 0
+		}
+	}
+	// post / sp / patch letter (openssl 1.1.1k) is later than release
+	return 1
+}
+
+func compareToken(a, b versionToken) int {
+	switch {
+	case a.isNum && b.isNum:
+		if a.num > b.num {
+			return 1
+		} else if a.num < b.num {
+			return -1
+		}
+		return 0
+	case a.isNum:
+		return -compareToken(b, a)
+	case b.isNum:
+		// string is earlier than number, 1.0-rc1 < 1.0.1
+		return -1
+	}
+	orderA, okA := a.qualifier()
+	orderB, okB := b.qualifier()
+	switch {
+	case okA && okB:
+		if orderA > orderB {
+			return 1
+		} else if orderA < orderB {
+			return -1
+		}
+		return 0
+	case okA:
+		if orderA <= 0 {
+			return -1
+		}
+		return 1
+	case okB:
+		if orderB <= 0 {
+			return 1
+		}
+		return -1
+	}
+	return strings.Compare(a.str, b.str)
+}
+
+// CompareVersion compare the versions in the ecosystem, return 1 if v1 > v2, -1 if v1 < v2, 0 if equal
+func CompareVersion(ecosystem, v1, v2 string) int {
+	return compareUpstreamVersion(NormalizeVersion(ecosystem, v1), NormalizeVersion(ecosystem, v2))
+}
+
+// revisionSeparator separate the upstream version and the distribution revision in normalized version
+const revisionSeparator = "#"
+
+func joinRevision(version, revision string) string {
+	if revision == "" {
+		return version
+	}
+	return version + revisionSeparator + revision
+}
+
+func splitRevision(version string) (string, string) {
+	if idx := strings.Index(version, revisionSeparator); idx >= 0 {
+		return version[:idx], version[idx+1:]
+	}
+	return version, ""
+}
+
+// compareUpstreamVersion compare the normalized versions, the revision is only compared when the upstream versions are equal,
+// and the version with revision is later than the upstream version without revision
+func compareUpstreamVersion(v1, v2 string) int {
+	v1, r1 := splitRevision(v1)
+	v2, r2 := splitRevision(v2)
+	if ret := compareVersionTokens(v1, v2); ret != 0 {
+		return ret
+	}
+	switch {
+	case r1 == r2:
+		return 0
+	case r1 == "":
+		return -1
+	case r2 == "":
+		return 1
+	}
+	return compareVersionTokens(r1, r2)
+}
+
+func compareVersionTokens(v1, v2 string) int {
+	a := tokenizeVersion(v1)
+	b := tokenizeVersion(v2)
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var ret int
+		switch {
+		case i >= len(a):
+			ret = -compareTokenWithEnd(b[i])
+		case i >= len(b):
+			ret = compareTokenWithEnd(a[i])
+		default:
+			ret = compareToken(a[i], b[i])
+		}
+		if ret != 0 {
+			return ret
+		}
+	}
+	return 0
+}
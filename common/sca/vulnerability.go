@@ -0,0 +1,411 @@
+package sca
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/cve/cveresources"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type VulnConfig struct {
+	db     *gorm.DB
+	dbPath string
+	ctx    context.Context
+}
+
+type VulnOption func(*VulnConfig)
+
+func WithVulnDatabase(db *gorm.DB) VulnOption {
+	return func(c *VulnConfig) {
+		c.db = db
+	}
+}
+
+func WithVulnContext(ctx context.Context) VulnOption {
+	return func(c *VulnConfig) {
+		c.ctx = ctx
+	}
+}
+
+// _withCVEDatabasePath the database is opened when matching and closed after finished
+func _withCVEDatabasePath(path string) VulnOption {
+	return func(c *VulnConfig) {
+		c.dbPath = path
+	}
+}
+
+type productCandidate struct {
+	vendor  string // * means any vendor
+	product string
+}
+
+// the packages in distribution are often split or renamed, map them to the upstream product
+var distroProductAlias = map[string]productCandidate{
+	"libssl":       {"openssl", "openssl"},
+	"libcrypto":    {"openssl", "openssl"},
+	"openssl-libs": {"openssl", "openssl"},
+	"libcurl":      {"haxx", "curl"},
+	"curl":         {"haxx", "curl"},
+	"libc":         {"gnu", "glibc"},
+	"libc-bin":     {"gnu", "glibc"},
+	"glibc":        {"gnu", "glibc"},
+	"zlib":         {"zlib", "zlib"},
+	"libz":         {"zlib", "zlib"},
+	"libxml":       {"xmlsoft", "libxml2"},
+	"libexpat":     {"libexpat_project", "libexpat"},
+	"expat":        {"libexpat_project", "libexpat"},
+	"libsqlite":    {"sqlite", "sqlite"},
+	"sqlite-libs":  {"sqlite", "sqlite"},
+	"libpcre":      {"pcre", "pcre"},
+	"libgnutls":    {"gnu", "gnutls"},
+	"libnghttp":    {"nghttp2", "nghttp2"},
+	"nghttp-libs":  {"nghttp2", "nghttp2"},
+	"libssh":       {"libssh", "libssh"},
+	"libtasn":      {"gnu", "libtasn1"},
+	"busybox":      {"busybox", "busybox"},
+	"musl":         {"musl-libc", "musl"},
+}
+
+var (
+	distroSuffixRegexp  = regexp.MustCompile(`(-(dev|devel|libs|common|bin|data|utils|tools|doc))+$`)
+	distroVersionRegexp = regexp.MustCompile(`[\d.]*(-?\d+)?(g|t64)?$`)
+	goMajorRegexp       = regexp.MustCompile(`/v\d+$`)
+)
+
+func (c productCandidate) key() string {
+	return c.vendor + ":" + c.product
+}
+
+// packageProducts map the package to the vendor / product of cpe by ecosystem
+func packageProducts(ecosystem string, pkg *dxtypes.Package) []productCandidate {
+	var ret []productCandidate
+	add := func(vendor, product string) {
+		vendor, product = strings.ToLower(vendor), strings.ToLower(product)
+		if product == "" {
+			return
+		}
+		if vendor == "" {
+			vendor = "*"
+		}
+		ret = append(ret, productCandidate{vendor: vendor, product: product})
+	}
+
+	// amended cpe is more accurate than the name
+	for _, raw := range pkg.AmendedCPE {
+		if cpe, err := parseCPE(raw); err == nil {
+			add(cpe.Vendor, cpe.Product)
+		}
+	}
+	if len(ret) > 0 {
+		return ret
+	}
+
+	name := strings.TrimSpace(pkg.Name)
+	switch ecosystem {
+	case EcosystemDPKG, EcosystemRPM, EcosystemAPK:
+		base := distroSuffixRegexp.ReplaceAllString(strings.ToLower(name), "")
+		for _, n := range []string{base, distroVersionRegexp.ReplaceAllString(base, "")} {
+			if alias, ok := distroProductAlias[n]; ok {
+				add(alias.vendor, alias.product)
+			}
+		}
+		add("*", base)
+	case EcosystemNPM:
+		if strings.HasPrefix(name, "@") {
+			scope, pkgName, _ := strings.Cut(strings.TrimPrefix(name, "@"), "/")
+			add(scope, pkgName)
+			add("*", strings.TrimPrefix(name, "@"))
+		} else {
+			add("*", name)
+		}
+	case EcosystemPIP:
+		lower := strings.ToLower(name)
+		add("*", lower)
+		add("*", strings.ReplaceAll(lower, "-", "_"))
+		add("*", strings.ReplaceAll(strings.ReplaceAll(lower, "_", "-"), ".", "-"))
+	case EcosystemMaven:
+		group, artifact, ok := strings.Cut(name, ":")
+		if !ok {
+			add("*", name)
+			break
+		}
+		// org.apache.logging.log4j -> apache
+		vendor := "*"
+		if parts := strings.Split(group, "."); len(parts) >= 2 {
+			vendor = parts[1]
+		}
+		add(vendor, artifact)
+		add(vendor, strings.ReplaceAll(artifact, "-", "_"))
+		for _, suffix := range []string{"-core", "-api", "-impl", "-java", "-all"} {
+			if strings.HasSuffix(artifact, suffix) {
+				add(vendor, strings.TrimSuffix(artifact, suffix))
+			}
+		}
+	case EcosystemComposer:
+		vendor, product, ok := strings.Cut(name, "/")
+		if ok {
+			add(vendor, product)
+		} else {
+			add("*", name)
+		}
+	case EcosystemGo:
+		parts := strings.Split(goMajorRegexp.ReplaceAllString(name, ""), "/")
+		switch {
+		case len(parts) >= 3 && (parts[0] == "github.com" || parts[0] == "gitlab.com" || parts[0] == "bitbucket.org"):
+			add(parts[1], parts[2])
+		case len(parts) >= 3 && parts[0] == "golang.org" && parts[1] == "x":
+			add("golang", parts[2])
+		default:
+			add("*", parts[len(parts)-1])
+		}
+	default:
+		add("*", name)
+	}
+
+	if fix, ok := cveresources.CommonFix[strings.ToLower(name)]; ok {
+		add(fix.Vendor, fix.ProductName)
+	}
+	return uniqCandidates(ret)
+}
+
+func uniqCandidates(c []productCandidate) []productCandidate {
+	var (
+		ret  = make([]productCandidate, 0, len(c))
+		seen = make(map[string]struct{})
+	)
+	for _, i := range c {
+		if _, ok := seen[i.key()]; ok {
+			continue
+		}
+		seen[i.key()] = struct{}{}
+		ret = append(ret, i)
+	}
+	return ret
+}
+
+func parseCPE(raw string) (*cveresources.CPE, error) {
+	if !strings.HasPrefix(raw, "cpe:") || strings.Count(raw, ":") < 4 {
+		return nil, utils.Errorf("invalid cpe: %v", raw)
+	}
+	return cveresources.ParseToCPE(raw)
+}
+
+type cachedCVE struct {
+	cve     *cveresources.CVE
+	matches []cveresources.CpeMatch
+}
+
+func flatCpeMatch(nodes []cveresources.Nodes) []cveresources.CpeMatch {
+	var ret []cveresources.CpeMatch
+	for _, node := range nodes {
+		for _, match := range node.CpeMatch {
+			if match.Vulnerable {
+				ret = append(ret, match)
+			}
+		}
+		ret = append(ret, flatCpeMatch(node.Children)...)
+	}
+	return ret
+}
+
+type vulnMatcher struct {
+	config *VulnConfig
+	cache  map[string][]*cachedCVE
+}
+
+func (m *vulnMatcher) queryProduct(product string) []*cachedCVE {
+	if ret, ok := m.cache[product]; ok {
+		return ret
+	}
+
+	var cves []*cveresources.CVE
+	db := m.config.db.Model(&cveresources.CVE{}).Where(
+		"product = ? OR product LIKE ? OR product LIKE ? OR product LIKE ?",
+		product, product+",%", "%,"+product, "%,"+product+",%",
+	)
+	if db := db.Find(&cves); db.Error != nil {
+		log.Warnf("query cve by product %v failed: %s", product, db.Error)
+	}
+
+	var ret []*cachedCVE
+	for _, c := range cves {
+		var config cveresources.Configurations
+		if err := json.Unmarshal(c.CPEConfigurations, &config); err != nil {
+			continue
+		}
+		ret = append(ret, &cachedCVE{cve: c, matches: flatCpeMatch(config.Nodes)})
+	}
+	m.cache[product] = ret
+	return ret
+}
+
+func cleanCPEVersion(v string) string {
+	return strings.ReplaceAll(v, "\\", "")
+}
+
+// matchCPE check the package (normalized version) is affected by the cpe match, return the affected version range
+func matchCPE(candidate productCandidate, version string, match cveresources.CpeMatch) (string, bool) {
+	cpe, err := parseCPE(match.Cpe23URI)
+	if err != nil {
+		return "", false
+	}
+	if !strings.EqualFold(cpe.Product, candidate.product) {
+		return "", false
+	}
+	if candidate.vendor != "*" && cpe.Vendor != "*" && !strings.EqualFold(cpe.Vendor, candidate.vendor) {
+		return "", false
+	}
+
+	switch cpeVersion := cleanCPEVersion(cpe.Version); cpeVersion {
+	case "-":
+		return "", false
+	case "*", "":
+		var conds []string
+		check := func(bound, op string, ok func(int) bool) bool {
+			if bound == "" {
+				return true
+			}
+			bound = cleanCPEVersion(bound)
+			conds = append(conds, op+bound)
+			return ok(compareUpstreamVersion(version, bound))
+		}
+		if !check(match.VersionStartIncluding, ">=", func(i int) bool { return i >= 0 }) ||
+			!check(match.VersionStartExcluding, ">", func(i int) bool { return i > 0 }) ||
+			!check(match.VersionEndIncluding, "<=", func(i int) bool { return i <= 0 }) ||
+			!check(match.VersionEndExcluding, "<", func(i int) bool { return i < 0 }) {
+			return "", false
+		}
+		if len(conds) <= 0 {
+			return "*", true
+		}
+		return strings.Join(conds, " && "), true
+	default:
+		if compareUpstreamVersion(version, cpeVersion) == 0 {
+			return "=" + cpeVersion, true
+		}
+		return "", false
+	}
+}
+
+// newVulnerability the score is calculated from the cvss vector to match its version,
+// the base score stored in database is used if the vector is unknown
+func newVulnerability(c *cveresources.CVE) *dxtypes.Vulnerability {
+	vuln := &dxtypes.Vulnerability{
+		CVE:         c.CVE,
+		CWE:         c.CWE,
+		Title:       c.TitleZh,
+		Description: c.DescriptionMain,
+		Solution:    c.Solution,
+		Severity:    strings.ToLower(c.Severity),
+		Score:       c.BaseCVSSv2Score,
+		CVSSVersion: c.CVSSVersion,
+		CVSSVector:  c.CVSSVectorString,
+		PublishedAt: c.PublishedDate,
+	}
+	if score, version, ok := cvssBaseScore(c.CVSSVectorString); ok {
+		vuln.Score = score
+		vuln.CVSSVersion = version
+	}
+	return vuln
+}
+
+func (m *vulnMatcher) matchPackage(pkg *dxtypes.Package) *dxtypes.PackageVulnerability {
+	ecosystem := PackageEcosystem(pkg)
+	result := &dxtypes.PackageVulnerability{
+		Package:         pkg,
+		Ecosystem:       ecosystem,
+		Vulnerabilities: make([]*dxtypes.Vulnerability, 0),
+	}
+	seen := make(map[string]struct{})
+
+	// forced correlation, no version check
+	for _, id := range pkg.AssociatedCVE {
+		c, err := cveresources.GetCVE(m.config.db, id)
+		if err != nil {
+			log.Warnf("get associated cve %v of %v failed: %s", id, pkg.Name, err)
+			continue
+		}
+		seen[c.CVE] = struct{}{}
+		result.Vulnerabilities = append(result.Vulnerabilities, newVulnerability(c))
+	}
+
+	if pkg.HasVersionRange() {
+		log.Debugf("skip version range package %v %v", pkg.Name, pkg.Version)
+		return result
+	}
+
+	version := NormalizeVersion(ecosystem, pkg.Version)
+	for _, candidate := range packageProducts(ecosystem, pkg) {
+		for _, c := range m.queryProduct(candidate.product) {
+			if _, ok := seen[c.cve.CVE]; ok {
+				continue
+			}
+			for _, match := range c.matches {
+				affected, ok := matchCPE(candidate, version, match)
+				if !ok {
+					continue
+				}
+				vuln := newVulnerability(c.cve)
+				vuln.AffectedVersion = affected
+				vuln.FixedVersion = cleanCPEVersion(match.VersionEndExcluding)
+				vuln.MatchedCPE = match.Cpe23URI
+				seen[c.cve.CVE] = struct{}{}
+				result.Vulnerabilities = append(result.Vulnerabilities, vuln)
+				break
+			}
+		}
+	}
+
+	sort.SliceStable(result.Vulnerabilities, func(i, j int) bool {
+		return result.Vulnerabilities[i].Score > result.Vulnerabilities[j].Score
+	})
+	return result
+}
+
+// MatchVulnerabilities match the packages with the local cve database, only the vulnerable packages are returned
+func MatchVulnerabilities(pkgs []*dxtypes.Package, opts ...VulnOption) ([]*dxtypes.PackageVulnerability, error) {
+	config := &VulnConfig{ctx: context.Background()}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.db == nil && config.dbPath != "" {
+		db, err := gorm.Open("sqlite3", config.dbPath)
+		if err != nil {
+			return nil, utils.Errorf("open cve database %v failed: %s", config.dbPath, err)
+		}
+		defer db.Close()
+		config.db = db
+	}
+	if config.db == nil {
+		config.db = consts.GetGormCVEDatabase()
+	}
+	if config.db == nil {
+		return nil, utils.Error("no cve database found, you can download it via yakit or cve.Download")
+	}
+
+	matcher := &vulnMatcher{config: config, cache: make(map[string][]*cachedCVE)}
+	var ret []*dxtypes.PackageVulnerability
+	for _, pkg := range pkgs {
+		select {
+		case <-config.ctx.Done():
+			return ret, config.ctx.Err()
+		default:
+		}
+		if pkg == nil {
+			continue
+		}
+		result := matcher.matchPackage(pkg)
+		if len(result.Vulnerabilities) > 0 {
+			ret = append(ret, result)
+		}
+	}
+	return ret, nil
+}
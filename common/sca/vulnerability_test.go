@@ -0,0 +1,204 @@
+package sca
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/cve/cveresources"
+	"github.com/yaklang/yaklang/common/sca/analyzer"
+	"github.com/yaklang/yaklang/common/sca/dxtypes"
+)
+
+func TestCompareVersion(t *testing.T) {
+	for _, tc := range []struct {
+		ecosystem string
+		v1, v2    string
+		want      int
+	}{
+		{EcosystemMaven, "2.14.1", "2.15.0", -1},
+		{EcosystemMaven, "5.3.18.RELEASE", "5.3.18", 0},
+		{EcosystemMaven, "2.0.0-rc1", "2.0.0", -1},
+		{EcosystemMaven, "1.0-SNAPSHOT", "1.0-alpha", -1},
+		{EcosystemPIP, "1.0.dev1", "1.0a1", -1},
+		{EcosystemPIP, "1.0.post1", "1.0", 1},
+		{EcosystemNPM, "v4.17.21", "4.17.20", 1},
+		{EcosystemDPKG, "1:1.1.1n-0+deb11u3", "1.1.1n", 1},
+		{EcosystemDPKG, "1.1.1n-0+deb11u3", "1.1.1n-0+deb11u4", -1},
+		{EcosystemDPKG, "1.1.1n-0+deb11u3", "1.1.1o", -1},
+		{EcosystemDPKG, "1.2-3", "1.2.3", -1},
+		{EcosystemDPKG, "2.0~rc1-1", "2.0-1", -1},
+		{EcosystemRPM, "1:1.1.1k-7.el8_6", "1.1.1k", 1},
+		{EcosystemRPM, "1.1.1k-7.el8_6", "1.1.1k-7.el8_6", 0},
+		{EcosystemAPK, "3.0.8-r0", "3.0.10", -1},
+		{EcosystemGo, "v0.0.0-20220722155237-a158d28d115b", "0.0.0", 0},
+		{EcosystemGo, "v1.2.3+incompatible", "1.2.3", 0},
+		{EcosystemUnknown, "1.1.1k", "1.1.1", 1},
+		{EcosystemUnknown, "1.1.1k", "1.1.1l", -1},
+		{EcosystemUnknown, "1.10", "1.9", 1},
+	} {
+		if got := CompareVersion(tc.ecosystem, tc.v1, tc.v2); got != tc.want {
+			t.Fatalf("compare %v %v with %v: %d(got) != %d(want)", tc.ecosystem, tc.v1, tc.v2, got, tc.want)
+		}
+	}
+}
+
+func TestCVSSBaseScore(t *testing.T) {
+	for _, tc := range []struct {
+		vector  string
+		score   float64
+		version string
+	}{
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", 9.8, "3.1"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", 10, "3.1"},
+		{"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N", 5.3, "3.1"},
+		{"CVSS:3.0/AV:L/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H", 7.8, "3.0"},
+		{"AV:N/AC:L/Au:N/C:P/I:P/A:P", 7.5, "2.0"},
+		{"AV:N/AC:M/Au:N/C:C/I:C/A:C", 9.3, "2.0"},
+	} {
+		score, version, ok := cvssBaseScore(tc.vector)
+		if !ok || score != tc.score || version != tc.version {
+			t.Fatalf("cvss %v: %v %v(got) != %v %v(want)", tc.vector, score, version, tc.score, tc.version)
+		}
+	}
+	if _, _, ok := cvssBaseScore("CVSS:3.1/AV:N"); ok {
+		t.Fatal("incomplete vector should not be calculated")
+	}
+}
+
+func TestMatchCPEWithRevision(t *testing.T) {
+	candidate := productCandidate{"openssl", "openssl"}
+	// the fix is backported to the revision of distribution
+	version := NormalizeVersion(EcosystemDPKG, "1.1.1k-1+deb11u1")
+	if _, ok := matchCPE(candidate, version, cveresources.CpeMatch{Cpe23URI: "cpe:2.3:a:openssl:openssl:1.1.1k:*:*:*:*:*:*:*"}); ok {
+		t.Fatal("the revision of distribution should not match the upstream version")
+	}
+	if _, ok := matchCPE(candidate, version, cveresources.CpeMatch{Cpe23URI: "cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*", VersionEndIncluding: "1.1.1k"}); ok {
+		t.Fatal("the revision of distribution should be later than the upstream version")
+	}
+	if _, ok := matchCPE(candidate, version, cveresources.CpeMatch{Cpe23URI: "cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*", VersionEndExcluding: "1.1.1l"}); !ok {
+		t.Fatal("the revision of distribution should be earlier than the next upstream version")
+	}
+}
+
+func newTestCVEDatabase(t *testing.T) *gorm.DB {
+	f, err := os.CreateTemp("", "sca-cve-*.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	t.Cleanup(func() {
+		os.Remove(f.Name())
+	})
+	db, err := gorm.Open("sqlite3", f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	db.AutoMigrate(&cveresources.CVE{})
+
+	save := func(id, vendor, product, severity string, score float64, vector string, match cveresources.CpeMatch) {
+		match.Vulnerable = true
+		config, _ := json.Marshal(cveresources.Configurations{Nodes: []cveresources.Nodes{
+			{Operator: "OR", CpeMatch: []cveresources.CpeMatch{match}},
+		}})
+		if err := db.Save(&cveresources.CVE{
+			CVE:               id,
+			CWE:               "CWE-502",
+			Vendor:            vendor,
+			Product:           product,
+			Severity:          severity,
+			BaseCVSSv2Score:   score,
+			CVSSVersion:       "3.1",
+			CVSSVectorString:  vector,
+			CPEConfigurations: config,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("CVE-2021-44228", "apache", "log4j", "CRITICAL", 9.3, "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H", cveresources.CpeMatch{
+		Cpe23URI:              "cpe:2.3:a:apache:log4j:*:*:*:*:*:*:*:*",
+		VersionStartIncluding: "2.0.1",
+		VersionEndExcluding:   "2.15.0",
+	})
+	save("CVE-2021-3711", "openssl", "openssl", "CRITICAL", 9.8, "", cveresources.CpeMatch{
+		Cpe23URI:              "cpe:2.3:a:openssl:openssl:*:*:*:*:*:*:*:*",
+		VersionStartIncluding: "1.1.1",
+		VersionEndExcluding:   "1.1.1l",
+	})
+	save("CVE-2020-28500", "lodash", "lodash", "MEDIUM", 5.3, "", cveresources.CpeMatch{
+		Cpe23URI: "cpe:2.3:a:lodash:lodash:4.17.20:*:*:*:*:node.js:*:*",
+	})
+	return db
+}
+
+func TestMatchVulnerabilities(t *testing.T) {
+	db := newTestCVEDatabase(t)
+
+	newPkg := func(name, version string, typ analyzer.TypAnalyzer) *dxtypes.Package {
+		return &dxtypes.Package{Name: name, Version: version, FromAnalyzer: []string{string(typ)}}
+	}
+	pkgs := []*dxtypes.Package{
+		newPkg("org.apache.logging.log4j:log4j-core", "2.14.1", analyzer.TypJavaPom),
+		newPkg("org.apache.logging.log4j:log4j-api", "2.17.1", analyzer.TypJavaPom),
+		newPkg("libssl1.1", "1.1.1k-1+deb11u1", analyzer.TypDPKG),
+		newPkg("openssl", "1.1.1n-r0", analyzer.TypAPK),
+		newPkg("lodash", "4.17.20", analyzer.TypNodeNpm),
+		newPkg("lodash", "4.17.21", analyzer.TypNodeYarn),
+	}
+	results, err := MatchVulnerabilities(pkgs, WithVulnDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	for _, r := range results {
+		for _, v := range r.Vulnerabilities {
+			got[r.Package.Name+"@"+r.Package.Version] = v.CVE
+		}
+	}
+	want := map[string]string{
+		"org.apache.logging.log4j:log4j-core@2.14.1": "CVE-2021-44228",
+		"libssl1.1@1.1.1k-1+deb11u1":                 "CVE-2021-3711",
+		"lodash@4.17.20":                             "CVE-2020-28500",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("matched packages error: %v(got) != %v(want)", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("%v: %v(got) != %v(want)", k, got[k], v)
+		}
+	}
+
+	for _, r := range results {
+		if r.Package.Name == "libssl1.1" {
+			v := r.Vulnerabilities[0]
+			if r.Ecosystem != EcosystemDPKG || v.FixedVersion != "1.1.1l" || v.AffectedVersion != ">=1.1.1 && <1.1.1l" {
+				t.Fatalf("libssl1.1 result error: %v %v %v", r.Ecosystem, v.FixedVersion, v.AffectedVersion)
+			}
+		}
+		if r.Package.Name == "org.apache.logging.log4j:log4j-core" {
+			// the score follows the version of vector
+			v := r.Vulnerabilities[0]
+			if v.Score != 10 || v.CVSSVersion != "3.1" {
+				t.Fatalf("log4j score error: %v %v", v.Score, v.CVSSVersion)
+			}
+		}
+	}
+
+	bom := dxtypes.CreateCycloneDXSBOMWithVulnerabilities(pkgs, results)
+	raw, err := dxtypes.MarshalCycloneDXBomToJSON(bom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`"vulnerabilities"`, `"CVE-2021-44228"`, `"org.apache.logging.log4j:log4j-core-2.14.1"`, `"critical"`} {
+		if !strings.Contains(string(raw), s) {
+			t.Fatalf("cyclonedx not contains %v", s)
+		}
+	}
+}
@@ -55,6 +55,19 @@ const (
 	// ICMP
 	ICMPV4HDR
 	ICMPV6HDR
+
+	// TLS
+	TLSSNI
+	TLSCertSubject
+	TLSCertIssuer
+	JA3Hash
+	JA3String
+	JA3SHash
+	JA3SString
+
+	// SSH
+	SSHProto
+	SSHSoftware
 )
 
 var HTTP_REQ_ONLY = []Modifier{
@@ -84,3 +97,11 @@ var HTTP_RESP_ONLY = []Modifier{
 func IsHTTPModifier(mdf Modifier) bool {
 	return mdf >= HTTPUri && mdf <= HTTPHeaderNames
 }
+
+func IsTLSModifier(mdf Modifier) bool {
+	return mdf >= TLSSNI && mdf <= JA3SString
+}
+
+func IsSSHModifier(mdf Modifier) bool {
+	return mdf == SSHProto || mdf == SSHSoftware
+}
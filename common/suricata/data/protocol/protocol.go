@@ -6,4 +6,6 @@ const (
 	ICMP = "icmp"
 	DNS  = "dns"
 	HTTP = "http"
+	TLS  = "tls"
+	SSH  = "ssh"
 )
@@ -0,0 +1,164 @@
+package eve
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/match"
+)
+
+func dnsRData(rr layers.DNSResourceRecord) string {
+	switch rr.Type {
+	case layers.DNSTypeA, layers.DNSTypeAAAA:
+		return rr.IP.String()
+	case layers.DNSTypeCNAME:
+		return string(rr.CNAME)
+	case layers.DNSTypeNS:
+		return string(rr.NS)
+	case layers.DNSTypePTR:
+		return string(rr.PTR)
+	case layers.DNSTypeMX:
+		return string(rr.MX.Name)
+	case layers.DNSTypeTXT:
+		var txts []string
+		for _, txt := range rr.TXTs {
+			txts = append(txts, string(txt))
+		}
+		return strings.Join(txts, "")
+	}
+	return ""
+}
+
+var dnsRCodes = map[layers.DNSResponseCode]string{
+	layers.DNSResponseCodeNoErr:    "NOERROR",
+	layers.DNSResponseCodeFormErr:  "FORMERR",
+	layers.DNSResponseCodeServFail: "SERVFAIL",
+	layers.DNSResponseCodeNXDomain: "NXDOMAIN",
+	layers.DNSResponseCodeNotImp:   "NOTIMP",
+	layers.DNSResponseCodeRefused:  "REFUSED",
+}
+
+func dnsRCode(code layers.DNSResponseCode) string {
+	if ret, ok := dnsRCodes[code]; ok {
+		return ret
+	}
+	return fmt.Sprint(uint8(code))
+}
+
+// dnsEvents return a query event for every question, or an answer event for response
+func dnsEvents(dns *layers.DNS) []*DNS {
+	if !dns.QR {
+		var ret []*DNS
+		for _, q := range dns.Questions {
+			ret = append(ret, &DNS{
+				Version: 2,
+				Type:    "query",
+				ID:      dns.ID,
+				RRName:  string(q.Name),
+				RRType:  q.Type.String(),
+			})
+		}
+		return ret
+	}
+
+	var flags uint16 = 1 << 15
+	flags |= uint16(dns.OpCode&0xf) << 11
+	if dns.AA {
+		flags |= 1 << 10
+	}
+	if dns.TC {
+		flags |= 1 << 9
+	}
+	if dns.RD {
+		flags |= 1 << 8
+	}
+	if dns.RA {
+		flags |= 1 << 7
+	}
+	flags |= uint16(dns.ResponseCode & 0xf)
+
+	e := &DNS{
+		Version: 2,
+		Type:    "answer",
+		ID:      dns.ID,
+		Flags:   fmt.Sprintf("%x", flags),
+		QR:      true,
+		RD:      dns.RD,
+		RA:      dns.RA,
+		RCode:   dnsRCode(dns.ResponseCode),
+	}
+	if len(dns.Questions) > 0 {
+		e.RRName = string(dns.Questions[0].Name)
+		e.RRType = dns.Questions[0].Type.String()
+	}
+	for _, rr := range dns.Answers {
+		e.Answers = append(e.Answers, &DNSAnswer{
+			RRName: string(rr.Name),
+			RRType: rr.Type.String(),
+			TTL:    rr.TTL,
+			RData:  dnsRData(rr),
+		})
+	}
+	return []*DNS{e}
+}
+
+func tlsVersionString(version uint16) string {
+	switch version {
+	case 0x0300:
+		return "SSLv3"
+	case 0x0301:
+		return "TLS 1.0"
+	case 0x0302:
+		return "TLS 1.1"
+	case 0x0303:
+		return "TLS 1.2"
+	case 0x0304:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("0x%04x", version)
+}
+
+func tlsEvent(info *match.TLSInfo) *TLS {
+	e := &TLS{
+		Subject:  info.CertSubject,
+		IssuerDN: info.CertIssuer,
+		SNI:      info.SNI,
+	}
+	if info.Version > 0 {
+		e.Version = tlsVersionString(info.Version)
+	}
+	if info.JA3 != "" {
+		e.JA3 = &JA3{Hash: info.JA3Hash(), String: info.JA3}
+	}
+	if info.JA3S != "" {
+		e.JA3S = &JA3{Hash: info.JA3SHash(), String: info.JA3S}
+	}
+	return e
+}
+
+func httpEvent(req *http.Request, rsp *http.Response) *HTTP {
+	e := &HTTP{
+		Hostname:      req.Host,
+		URL:           req.RequestURI,
+		HTTPUserAgent: req.UserAgent(),
+		HTTPRefer:     req.Referer(),
+		HTTPMethod:    req.Method,
+		Protocol:      req.Proto,
+	}
+	if e.URL == "" && req.URL != nil {
+		e.URL = req.URL.RequestURI()
+	}
+	if rsp != nil {
+		e.Status = rsp.StatusCode
+		e.HTTPContentType = rsp.Header.Get("Content-Type")
+		if idx := strings.IndexByte(e.HTTPContentType, ';'); idx >= 0 {
+			e.HTTPContentType = strings.TrimSpace(e.HTTPContentType[:idx])
+		}
+		if rsp.ContentLength > 0 {
+			e.Length = rsp.ContentLength
+		}
+	}
+	return e
+}
@@ -0,0 +1,191 @@
+package eve
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/match"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func buildPacket(t *testing.T, src, dst string, srcPort, dstPort int, payload string) gopacket.Packet {
+	raw, err := pcapx.PacketBuilder(
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP(src),
+		pcapx.WithIPv4_DstIP(dst),
+		pcapx.WithTCP_SrcPort(srcPort),
+		pcapx.WithTCP_DstPort(dstPort),
+		pcapx.WithTCP_Flags("psh|ack"),
+		pcapx.WithPayload([]byte(payload)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.NoCopy)
+}
+
+func readEvents(t *testing.T, path string) []*Event {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var events []*Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid eve json: %v", scanner.Text())
+		}
+		events = append(events, &e)
+	}
+	return events
+}
+
+func TestLogger_Alert(t *testing.T) {
+	dir := t.TempDir()
+	evePath, fastPath := filepath.Join(dir, "eve.json"), filepath.Join(dir, "fast.log")
+
+	var alerted *Event
+	logger, err := NewLogger(
+		WithEveOutput(evePath),
+		WithFastLogOutput(fastPath),
+		WithOnAlert(func(event *Event, r *rule.Rule) {
+			alerted = event
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := rule.Parse(`alert tcp any any -> any any (msg:"test trojan"; content:"hello"; classtype:trojan-activity; sid:1000001; rev:2;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := buildPacket(t, "10.0.0.1", "10.0.0.2", 40000, 8080, "hello")
+	logger.Packet(req)
+	logger.Packet(buildPacket(t, "10.0.0.2", "10.0.0.1", 8080, 40000, "world"))
+	logger.Alert(req, rules[0])
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if alerted == nil || alerted.Alert.Severity != 1 {
+		t.Fatalf("alert callback error: %+v", alerted)
+	}
+
+	events := readEvents(t, evePath)
+	if len(events) != 2 {
+		t.Fatalf("events count error: %d", len(events))
+	}
+	alert, flow := events[0], events[1]
+	if alert.EventType != EventTypeAlert || alert.Alert.SignatureID != 1000001 || alert.Alert.Rev != 2 ||
+		alert.Alert.Category != "A Network Trojan was detected" || alert.SrcPort != 40000 {
+		t.Fatalf("alert event error: %+v %+v", alert, alert.Alert)
+	}
+	if flow.EventType != EventTypeFlow || flow.FlowID != alert.FlowID || !flow.Flow.Alerted ||
+		flow.Flow.PktsToServer != 1 || flow.Flow.PktsToClient != 1 || flow.Flow.Reason != "shutdown" {
+		t.Fatalf("flow event error: %+v %+v", flow, flow.Flow)
+	}
+
+	raw, err := os.ReadFile(fastPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), "[**] [1:1000001:2] test trojan [**] [Classification: A Network Trojan was detected] [Priority: 1] {TCP} 10.0.0.1:40000 -> 10.0.0.2:8080") {
+		t.Fatalf("fast.log error: %s", raw)
+	}
+}
+
+func TestLogger_SharedFlowTable(t *testing.T) {
+	evePath := filepath.Join(t.TempDir(), "eve.json")
+	flows := match.NewFlowTable()
+	logger, err := NewLogger(WithEveOutput(evePath), WithFlowTable(flows))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules, err := rule.Parse(`alert tcp any any -> any any (msg:"test shared flow"; content:"hello"; sid:1000002;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group := match.NewGroup(
+		match.WithGroupFlowTable(flows),
+		match.WithGroupOnMatchedCallback(func(packet gopacket.Packet, r *rule.Rule) {
+			logger.Alert(packet, r)
+		}),
+	)
+	group.LoadRules(rules...)
+	for _, pk := range []gopacket.Packet{
+		buildPacket(t, "10.0.0.1", "10.0.0.2", 40000, 8080, "hello"),
+		buildPacket(t, "10.0.0.2", "10.0.0.1", 8080, 40000, "world"),
+	} {
+		logger.Packet(pk)
+		group.FeedPacket(pk)
+	}
+	group.Wait()
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the packets are tracked by the group only
+	events := readEvents(t, evePath)
+	if len(events) != 2 {
+		t.Fatalf("events count error: %d", len(events))
+	}
+	alert, flow := events[0], events[1]
+	if alert.EventType != EventTypeAlert || alert.Alert.SignatureID != 1000002 {
+		t.Fatalf("alert event error: %+v", alert)
+	}
+	if flow.EventType != EventTypeFlow || flow.FlowID != alert.FlowID || !flow.Flow.Alerted ||
+		flow.Flow.PktsToServer != 1 || flow.Flow.PktsToClient != 1 {
+		t.Fatalf("flow event error: %+v %+v", flow, flow.Flow)
+	}
+	if flows.Len() != 0 {
+		t.Fatal("the flows should be flushed when logger closed")
+	}
+}
+
+func TestTuple_FlowID(t *testing.T) {
+	tuple := TupleFromPacket(buildPacket(t, "10.0.0.1", "10.0.0.2", 40000, 80, "a"))
+	if tuple.FlowID() != tuple.Reverse().FlowID() {
+		t.Fatal("flow id should be the same for both directions")
+	}
+	other := TupleFromPacket(buildPacket(t, "10.0.0.1", "10.0.0.2", 40001, 80, "a"))
+	if tuple.FlowID() == other.FlowID() || tuple.FlowID() <= 0 {
+		t.Fatal("flow id error")
+	}
+}
+
+func TestRotateWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "eve.json")
+	w, err := NewRotateWriter(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := w.Write([]byte("0123456\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	for _, name := range []string{path, path + ".1", path + ".2"} {
+		if raw, err := os.ReadFile(name); err != nil || string(raw) != "0123456\n" {
+			t.Fatalf("rotated file %v error: %q %v", name, raw, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); err == nil {
+		t.Fatal("too many rotated files")
+	}
+}
@@ -0,0 +1,175 @@
+package eve
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/match"
+)
+
+// timestampLayout is the timestamp format of suricata, e.g. 2009-11-24T21:27:09.534255+0100
+const timestampLayout = "2006-01-02T15:04:05.000000-0700"
+
+// Event is a record of suricata eve json
+// see https://docs.suricata.io/en/latest/output/eve/eve-json-format.html
+type Event struct {
+	Timestamp string `json:"timestamp"`
+	FlowID    int64  `json:"flow_id,omitempty"`
+	InIface   string `json:"in_iface,omitempty"`
+	EventType string `json:"event_type"`
+	SrcIP     string `json:"src_ip,omitempty"`
+	SrcPort   int    `json:"src_port,omitempty"`
+	DestIP    string `json:"dest_ip,omitempty"`
+	DestPort  int    `json:"dest_port,omitempty"`
+	Proto     string `json:"proto,omitempty"`
+	AppProto  string `json:"app_proto,omitempty"`
+
+	Alert *Alert `json:"alert,omitempty"`
+	HTTP  *HTTP  `json:"http,omitempty"`
+	DNS   *DNS   `json:"dns,omitempty"`
+	TLS   *TLS   `json:"tls,omitempty"`
+	Flow  *Flow  `json:"flow,omitempty"`
+}
+
+type Alert struct {
+	Action      string `json:"action"`
+	Gid         int    `json:"gid"`
+	SignatureID int    `json:"signature_id"`
+	Rev         int    `json:"rev"`
+	Signature   string `json:"signature"`
+	Category    string `json:"category"`
+	Severity    int    `json:"severity"`
+}
+
+type HTTP struct {
+	Hostname        string `json:"hostname,omitempty"`
+	URL             string `json:"url,omitempty"`
+	HTTPUserAgent   string `json:"http_user_agent,omitempty"`
+	HTTPContentType string `json:"http_content_type,omitempty"`
+	HTTPRefer       string `json:"http_refer,omitempty"`
+	HTTPMethod      string `json:"http_method,omitempty"`
+	Protocol        string `json:"protocol,omitempty"`
+	Status          int    `json:"status,omitempty"`
+	Length          int64  `json:"length"`
+}
+
+type DNSAnswer struct {
+	RRName string `json:"rrname"`
+	RRType string `json:"rrtype"`
+	TTL    uint32 `json:"ttl"`
+	RData  string `json:"rdata,omitempty"`
+}
+
+type DNS struct {
+	Version int          `json:"version"`
+	Type    string       `json:"type"`
+	ID      uint16       `json:"id"`
+	Flags   string       `json:"flags,omitempty"`
+	QR      bool         `json:"qr,omitempty"`
+	RD      bool         `json:"rd,omitempty"`
+	RA      bool         `json:"ra,omitempty"`
+	RRName  string       `json:"rrname,omitempty"`
+	RRType  string       `json:"rrtype,omitempty"`
+	RCode   string       `json:"rcode,omitempty"`
+	Answers []*DNSAnswer `json:"answers,omitempty"`
+}
+
+type JA3 struct {
+	Hash   string `json:"hash"`
+	String string `json:"string"`
+}
+
+type TLS struct {
+	Subject  string `json:"subject,omitempty"`
+	IssuerDN string `json:"issuerdn,omitempty"`
+	SNI      string `json:"sni,omitempty"`
+	Version  string `json:"version,omitempty"`
+	JA3      *JA3   `json:"ja3,omitempty"`
+	JA3S     *JA3   `json:"ja3s,omitempty"`
+}
+
+type Flow struct {
+	PktsToServer  int    `json:"pkts_toserver"`
+	PktsToClient  int    `json:"pkts_toclient"`
+	BytesToServer int    `json:"bytes_toserver"`
+	BytesToClient int    `json:"bytes_toclient"`
+	Start         string `json:"start"`
+	End           string `json:"end"`
+	Age           int64  `json:"age"`
+	State         string `json:"state"`
+	Reason        string `json:"reason"`
+	Alerted       bool   `json:"alerted"`
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(timestampLayout)
+}
+
+// Tuple is the 5-tuple of a packet
+type Tuple struct {
+	Proto    string
+	SrcIP    string
+	SrcPort  int
+	DestIP   string
+	DestPort int
+}
+
+// TupleFromPacket return nil if the packet has no network layer
+func TupleFromPacket(pk gopacket.Packet) *Tuple {
+	if pk == nil || pk.NetworkLayer() == nil {
+		return nil
+	}
+	nw := pk.NetworkLayer().NetworkFlow()
+	t := &Tuple{
+		Proto:  match.FlowProtocol(pk),
+		SrcIP:  nw.Src().String(),
+		DestIP: nw.Dst().String(),
+	}
+	switch ret := pk.TransportLayer().(type) {
+	case *layers.TCP:
+		t.SrcPort, t.DestPort = int(ret.SrcPort), int(ret.DstPort)
+	case *layers.UDP:
+		t.SrcPort, t.DestPort = int(ret.SrcPort), int(ret.DstPort)
+	}
+	return t
+}
+
+// tupleFromFlow return the tuple from the client to the server of flow
+func tupleFromFlow(flow *match.FlowState) *Tuple {
+	t := &Tuple{Proto: flow.Protocol, SrcIP: flow.ClientIP, DestIP: flow.ServerIP}
+	if _, port, err := net.SplitHostPort(flow.Client); err == nil {
+		t.SrcPort, _ = strconv.Atoi(port)
+	}
+	if _, port, err := net.SplitHostPort(flow.Server); err == nil {
+		t.DestPort, _ = strconv.Atoi(port)
+	}
+	return t
+}
+
+// Reverse return the tuple of the other direction
+func (t *Tuple) Reverse() *Tuple {
+	return &Tuple{Proto: t.Proto, SrcIP: t.DestIP, SrcPort: t.DestPort, DestIP: t.SrcIP, DestPort: t.SrcPort}
+}
+
+func (t *Tuple) src() string {
+	return net.JoinHostPort(t.SrcIP, strconv.Itoa(t.SrcPort))
+}
+
+func (t *Tuple) dst() string {
+	return net.JoinHostPort(t.DestIP, strconv.Itoa(t.DestPort))
+}
+
+// FlowID is the same for both directions of a flow, like the flow_id of suricata
+func (t *Tuple) FlowID() int64 {
+	return match.FlowID(t.Proto, t.src(), t.dst())
+}
+
+func (t *Tuple) fill(e *Event) {
+	e.FlowID = t.FlowID()
+	e.Proto = t.Proto
+	e.SrcIP, e.SrcPort = t.SrcIP, t.SrcPort
+	e.DestIP, e.DestPort = t.DestIP, t.DestPort
+}
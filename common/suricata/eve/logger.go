@@ -0,0 +1,361 @@
+package eve
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/suricata/match"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+const (
+	EventTypeAlert = "alert"
+	EventTypeHTTP  = "http"
+	EventTypeDNS   = "dns"
+	EventTypeTLS   = "tls"
+	EventTypeFlow  = "flow"
+)
+
+const fastLogTimestampLayout = "01/02/2006-15:04:05.000000"
+
+type Config struct {
+	EvePath     string
+	FastLogPath string
+	MaxSize     int64
+	MaxBackups  int
+	Interface   string
+	EventTypes  map[string]bool
+
+	onAlert func(event *Event, r *rule.Rule)
+	flows   *match.FlowTable
+}
+
+type Option func(*Config)
+
+// WithEveOutput write eve json to path, "-" is stdout
+func WithEveOutput(path string) Option {
+	return func(c *Config) {
+		c.EvePath = path
+	}
+}
+
+// WithFastLogOutput write fast.log to path, "-" is stdout
+func WithFastLogOutput(path string) Option {
+	return func(c *Config) {
+		c.FastLogPath = path
+	}
+}
+
+// WithRotate rotate the outputs when their size exceed maxSize (bytes)
+func WithRotate(maxSize int64, maxBackups int) Option {
+	return func(c *Config) {
+		c.MaxSize = maxSize
+		c.MaxBackups = maxBackups
+	}
+}
+
+func WithInterface(iface string) Option {
+	return func(c *Config) {
+		c.Interface = iface
+	}
+}
+
+// WithEventTypes set the event types of eve json, default is alert, http, dns, tls and flow
+func WithEventTypes(types ...string) Option {
+	return func(c *Config) {
+		c.EventTypes = make(map[string]bool)
+		for _, t := range types {
+			c.EventTypes[strings.ToLower(strings.TrimSpace(t))] = true
+		}
+	}
+}
+
+func WithOnAlert(h func(event *Event, r *rule.Rule)) Option {
+	return func(c *Config) {
+		c.onAlert = h
+	}
+}
+
+// WithFlowTable share the flow table of the rules group (match.WithGroupFlowTable),
+// the packets are tracked by the group, Logger.Packet doesn't track them again
+func WithFlowTable(table *match.FlowTable) Option {
+	return func(c *Config) {
+		c.flows = table
+	}
+}
+
+// Logger output the events of traffic like suricata: eve json and fast.log
+type Logger struct {
+	config *Config
+
+	lock  sync.Mutex
+	eve   io.WriteCloser
+	fast  io.WriteCloser
+	flows *match.FlowTable
+	// track is false if the flows are tracked by the owner of flow table
+	track bool
+	// appProtos / alerted are the states of flows written in flow events, guarded by lock
+	appProtos map[int64]string
+	alerted   map[int64]bool
+}
+
+func NewLogger(opts ...Option) (*Logger, error) {
+	config := &Config{}
+	WithEventTypes(EventTypeAlert, EventTypeHTTP, EventTypeDNS, EventTypeTLS, EventTypeFlow)(config)
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	l := &Logger{
+		config:    config,
+		flows:     config.flows,
+		appProtos: make(map[int64]string),
+		alerted:   make(map[int64]bool),
+	}
+	if l.flows == nil {
+		l.flows = match.NewFlowTable()
+		l.track = true
+	}
+	// the flows end in Track with the lock of flow table held,
+	// so the logger never calls the flow table with its lock held
+	l.flows.SetOnFlowEnd(func(flow *match.FlowState) {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.writeFlow(flow, "timeout")
+	})
+	var err error
+	if config.EvePath != "" {
+		l.eve, err = NewRotateWriter(config.EvePath, config.MaxSize, config.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if config.FastLogPath != "" {
+		l.fast, err = NewRotateWriter(config.FastLogPath, config.MaxSize, config.MaxBackups)
+		if err != nil {
+			if l.eve != nil {
+				l.eve.Close()
+			}
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func packetTime(pk gopacket.Packet) time.Time {
+	if md := pk.Metadata(); md != nil && !md.Timestamp.IsZero() {
+		return md.Timestamp
+	}
+	return time.Now()
+}
+
+// writeEvent should be called with lock
+func (l *Logger) writeEvent(e *Event) {
+	if l.eve == nil || !l.config.EventTypes[e.EventType] {
+		return
+	}
+	raw, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("marshal eve event failed: %s", err)
+		return
+	}
+	if _, err := l.eve.Write(append(raw, '\n')); err != nil {
+		log.Errorf("write eve event failed: %s", err)
+	}
+}
+
+func (l *Logger) newEvent(typ string, ts time.Time, tuple *Tuple) *Event {
+	e := &Event{Timestamp: formatTime(ts), EventType: typ, InIface: l.config.Interface}
+	if tuple != nil {
+		tuple.fill(e)
+	}
+	return e
+}
+
+// Packet track the flow of packet, and output the dns / tls events in it
+func (l *Logger) Packet(pk gopacket.Packet) {
+	tuple := TupleFromPacket(pk)
+	if tuple == nil {
+		return
+	}
+	ts := packetTime(pk)
+	if l.track {
+		l.flows.Track(pk)
+	}
+	id := tuple.FlowID()
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if dns, ok := pk.Layer(layers.LayerTypeDNS).(*layers.DNS); ok {
+		l.appProtos[id] = EventTypeDNS
+		for _, e := range dnsEvents(dns) {
+			event := l.newEvent(EventTypeDNS, ts, tuple)
+			event.DNS = e
+			l.writeEvent(event)
+		}
+	}
+	if tcp, ok := pk.TransportLayer().(*layers.TCP); ok && len(tcp.Payload) > 0 {
+		if info := match.ParseTLSInfo(tcp.Payload); info != nil {
+			l.appProtos[id] = EventTypeTLS
+			event := l.newEvent(EventTypeTLS, ts, tuple)
+			event.TLS = tlsEvent(info)
+			l.writeEvent(event)
+		}
+	}
+}
+
+// writeFlow should be called with lock
+func (l *Logger) writeFlow(flow *match.FlowState, reason string) {
+	state := "new"
+	if flow.Closed {
+		state = "closed"
+	} else if flow.Established {
+		state = "established"
+	}
+	event := l.newEvent(EventTypeFlow, flow.LastSeen, tupleFromFlow(flow))
+	event.AppProto = l.appProtos[flow.ID]
+	alerted := l.alerted[flow.ID]
+	delete(l.appProtos, flow.ID)
+	delete(l.alerted, flow.ID)
+	event.Flow = &Flow{
+		PktsToServer:  flow.ToServerPackets,
+		PktsToClient:  flow.ToClientPackets,
+		BytesToServer: flow.ToServerBytes,
+		BytesToClient: flow.ToClientBytes,
+		Start:         formatTime(flow.StartTime),
+		End:           formatTime(flow.LastSeen),
+		Age:           int64(flow.LastSeen.Sub(flow.StartTime).Seconds()),
+		State:         state,
+		Reason:        reason,
+		Alerted:       alerted,
+	}
+	l.writeEvent(event)
+}
+
+func isAppProto(protocol string) bool {
+	switch strings.ToLower(protocol) {
+	case "", "ip", "tcp", "udp", "icmp", "icmpv4", "icmpv6", "tcp-pkt", "tcp-stream":
+		return false
+	}
+	return true
+}
+
+// Alert output the alert event and fast.log of the rule matched by packet
+func (l *Logger) Alert(pk gopacket.Packet, r *rule.Rule) *Event {
+	if r == nil {
+		return nil
+	}
+	ts := time.Now()
+	var tuple *Tuple
+	if pk != nil {
+		ts = packetTime(pk)
+		tuple = TupleFromPacket(pk)
+	}
+
+	category, severity := Classify(r.ClassType)
+	if r.Priority > 0 {
+		severity = r.Priority
+	}
+	gid := r.Gid
+	if gid <= 0 {
+		gid = 1
+	}
+	event := l.newEvent(EventTypeAlert, ts, tuple)
+	if isAppProto(r.Protocol) {
+		event.AppProto = strings.ToLower(r.Protocol)
+	}
+	event.Alert = &Alert{
+		Action:      "allowed",
+		Gid:         gid,
+		SignatureID: r.Sid,
+		Rev:         r.Rev,
+		Signature:   r.Message,
+		Category:    category,
+		Severity:    severity,
+	}
+
+	// the states of flows not tracked are dropped, they would never be written
+	tracked := tuple != nil && l.flows.Lookup(event.FlowID) != nil
+	l.lock.Lock()
+	if tracked {
+		l.alerted[event.FlowID] = true
+	}
+	l.writeEvent(event)
+	l.writeFastLog(event)
+	l.lock.Unlock()
+
+	if l.config.onAlert != nil {
+		l.config.onAlert(event, r)
+	}
+	return event
+}
+
+// writeFastLog should be called with lock
+func (l *Logger) writeFastLog(e *Event) {
+	if l.fast == nil {
+		return
+	}
+	ts, err := time.Parse(timestampLayout, e.Timestamp)
+	if err != nil {
+		ts = time.Now()
+	}
+	line := fmt.Sprintf(
+		"%s  [**] [%d:%d:%d] %s [**] [Classification: %s] [Priority: %d] {%s} %s -> %s\n",
+		ts.Format(fastLogTimestampLayout),
+		e.Alert.Gid, e.Alert.SignatureID, e.Alert.Rev, e.Alert.Signature,
+		e.Alert.Category, e.Alert.Severity, e.Proto,
+		net.JoinHostPort(e.SrcIP, fmt.Sprint(e.SrcPort)), net.JoinHostPort(e.DestIP, fmt.Sprint(e.DestPort)),
+	)
+	if _, err := l.fast.Write([]byte(line)); err != nil {
+		log.Errorf("write fast.log failed: %s", err)
+	}
+}
+
+// HTTP output the http event of a request and its response, tuple is the direction of request
+func (l *Logger) HTTP(tuple *Tuple, req *http.Request, rsp *http.Response) {
+	if req == nil {
+		return
+	}
+	event := l.newEvent(EventTypeHTTP, time.Now(), tuple)
+	event.AppProto = EventTypeHTTP
+	event.HTTP = httpEvent(req, rsp)
+
+	tracked := tuple != nil && l.flows.Lookup(event.FlowID) != nil
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if tracked {
+		l.appProtos[event.FlowID] = EventTypeHTTP
+	}
+	l.writeEvent(event)
+}
+
+// Close output the flows not closed, then close the outputs
+func (l *Logger) Close() error {
+	flows := l.flows.Flush()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for _, flow := range flows {
+		l.writeFlow(flow, "shutdown")
+	}
+	var err error
+	for _, w := range []io.WriteCloser{l.eve, l.fast} {
+		if w == nil {
+			continue
+		}
+		if e := w.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package eve
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type stdoutWriter struct{}
+
+func (stdoutWriter) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+func (stdoutWriter) Close() error {
+	return nil
+}
+
+// RotateWriter write to file, when the size of file exceeds maxSize,
+// it is renamed to file.1 (file.1 to file.2 ...) and a new file is created.
+// no more than maxBackups rotated files are kept.
+type RotateWriter struct {
+	lock       sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// NewRotateWriter create writer of path, "-" is stdout; maxSize <= 0 means never rotate
+func NewRotateWriter(path string, maxSize int64, maxBackups int) (io.WriteCloser, error) {
+	if path == "" || path == "-" {
+		return stdoutWriter{}, nil
+	}
+	w := &RotateWriter{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *RotateWriter) open() error {
+	file, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return utils.Errorf("open %v failed: %s", w.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return utils.Errorf("stat %v failed: %s", w.path, err)
+	}
+	w.file, w.size = file, info.Size()
+	return nil
+}
+
+func (w *RotateWriter) backup(i int) string {
+	return fmt.Sprintf("%s.%d", w.path, i)
+}
+
+func (w *RotateWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+	if w.maxBackups <= 0 {
+		os.Remove(w.path)
+	} else {
+		os.Remove(w.backup(w.maxBackups))
+		for i := w.maxBackups - 1; i > 0; i-- {
+			os.Rename(w.backup(i), w.backup(i+1))
+		}
+		if err := os.Rename(w.path, w.backup(1)); err != nil {
+			// keep writing to the current file
+			log.Warnf("rotate %v failed: %s", w.path, err)
+		}
+	}
+	return w.open()
+}
+
+func (w *RotateWriter) Write(p []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *RotateWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
@@ -26,6 +26,10 @@ func New(r *rule.Rule) (Generator, error) {
 		return newDNSGen(r)
 	case protocol.ICMP:
 		return newICMPGen(r)
+	case protocol.TLS:
+		return newTLSGen(r)
+	case protocol.SSH:
+		return newSSHGen(r)
 	}
 	return nil, errors.New("not support protocol")
 }
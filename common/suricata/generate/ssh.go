@@ -0,0 +1,69 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+var _ Generator = (*SSHGen)(nil)
+
+type SSHGen struct {
+	r        *rule.Rule
+	proto    ModifierGenerator
+	software ModifierGenerator
+}
+
+func newSSHGen(r *rule.Rule) (Generator, error) {
+	if r.ContentRuleConfig == nil {
+		return nil, errors.New("empty content rule config")
+	}
+
+	g := &SSHGen{
+		r: r,
+	}
+
+	for mdf, rr := range contentRuleMap(r.ContentRuleConfig.ContentRules) {
+		switch mdf {
+		case modifier.SSHProto:
+			g.proto = parse2ContentGen(rr, WithNoise(noiseDigit))
+		case modifier.SSHSoftware:
+			g.software = parse2ContentGen(rr, WithNoise(noiseDigitChar))
+		default:
+			log.Warnf("not support modifier %v in ssh generator", mdf)
+		}
+	}
+	return g, nil
+}
+
+func (g *SSHGen) Gen() []byte {
+	proto, software := "2.0", "OpenSSH_8.9p1"
+	if g.proto != nil {
+		proto = string(g.proto.Gen())
+	}
+	if g.software != nil {
+		software = string(g.software.Gen())
+	}
+
+	var opts []any
+	opts = append(opts, pcapx.WithTCP_Flags("psh|ack"))
+	opts = append(opts, pcapx.WithTCP_Seq(uint32(rand.Uint32())))
+	opts = append(opts, pcapx.WithTCP_Ack(uint32(rand.Uint32())))
+	opts = append(opts, pcapx.WithIPv4_SrcIP(g.r.SourceAddress.Generate()))
+	opts = append(opts, pcapx.WithIPv4_DstIP(g.r.DestinationAddress.Generate()))
+	opts = append(opts, pcapx.WithTCP_SrcPort(g.r.SourcePort.GetAvailablePort()))
+	opts = append(opts, pcapx.WithTCP_DstPort(g.r.DestinationPort.GenerateWithDefault(22)))
+	opts = append(opts, pcapx.WithPayload([]byte(fmt.Sprintf("SSH-%s-%s\r\n", proto, software))))
+
+	raw, err := pcapx.PacketBuilder(opts...)
+	if err != nil {
+		log.Errorf("generate ssh packet failed: %s", err)
+		return nil
+	}
+	return raw
+}
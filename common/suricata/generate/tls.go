@@ -0,0 +1,228 @@
+package generate
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+	"golang.org/x/exp/slices"
+)
+
+var _ Generator = (*TLSGen)(nil)
+
+// ClientHelloSpec is the fields of client hello, the same as the fields of ja3
+type ClientHelloSpec struct {
+	Version      uint16
+	CipherSuites []uint16
+	Extensions   []uint16
+	Curves       []uint16
+	PointFormats []uint8
+}
+
+func defaultClientHelloSpec() *ClientHelloSpec {
+	return &ClientHelloSpec{
+		Version:      0x0303,
+		CipherSuites: []uint16{0xc02b, 0xc02f, 0xc02c, 0xc030, 0xcca9, 0xcca8, 0xc013, 0xc014, 0x009c, 0x009d, 0x002f, 0x0035},
+		Extensions:   []uint16{0, 23, 65281, 10, 11, 35, 13},
+		Curves:       []uint16{29, 23, 24},
+		PointFormats: []uint8{0},
+	}
+}
+
+func parseUint16List(s string) ([]uint16, error) {
+	var ret []uint16
+	for _, item := range strings.Split(s, "-") {
+		if item == "" {
+			continue
+		}
+		i, err := strconv.ParseUint(item, 10, 16)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, uint16(i))
+	}
+	return ret, nil
+}
+
+// ParseJA3ToClientHelloSpec parse ja3 string, e.g. 771,4865-4866,0-23-65281,29-23-24,0
+func ParseJA3ToClientHelloSpec(ja3 string) (*ClientHelloSpec, error) {
+	fields := strings.Split(strings.TrimSpace(ja3), ",")
+	if len(fields) != 5 {
+		return nil, errors.New("not a valid ja3 string")
+	}
+	version, err := strconv.ParseUint(fields[0], 10, 16)
+	if err != nil {
+		return nil, errors.New("not a valid ja3 version")
+	}
+	spec := &ClientHelloSpec{Version: uint16(version)}
+	lists := make([][]uint16, 4)
+	for i := range lists {
+		lists[i], err = parseUint16List(fields[i+1])
+		if err != nil {
+			return nil, errors.New("not a valid ja3 string")
+		}
+	}
+	spec.CipherSuites, spec.Extensions, spec.Curves = lists[0], lists[1], lists[2]
+	for _, p := range lists[3] {
+		spec.PointFormats = append(spec.PointFormats, uint8(p))
+	}
+	return spec, nil
+}
+
+func appendUint16(buf []byte, v ...uint16) []byte {
+	for _, i := range v {
+		buf = binary.BigEndian.AppendUint16(buf, i)
+	}
+	return buf
+}
+
+func appendVector16(buf []byte, data []byte) []byte {
+	return append(appendUint16(buf, uint16(len(data))), data...)
+}
+
+func randomBytes(n int) []byte {
+	ret := make([]byte, n)
+	rand.Read(ret)
+	return ret
+}
+
+// BuildClientHello build a tls record of client hello, the supported_versions extension is added for tls 1.3
+func BuildClientHello(spec *ClientHelloSpec, sni string, version uint16) []byte {
+	if spec == nil {
+		spec = defaultClientHelloSpec()
+	}
+	exts := spec.Extensions
+	if version >= 0x0304 && !slices.Contains(exts, 43) {
+		exts = append(append([]uint16{}, exts...), 43)
+	}
+
+	var extBuf []byte
+	for _, typ := range exts {
+		var data []byte
+		switch typ {
+		case 0:
+			if sni == "" {
+				sni = "www." + string(randomDomainLabel(8)) + ".com"
+			}
+			name := append([]byte{0}, appendVector16(nil, []byte(sni))...)
+			data = appendVector16(nil, name)
+		case 10:
+			data = appendVector16(nil, appendUint16(nil, spec.Curves...))
+		case 11:
+			data = append([]byte{byte(len(spec.PointFormats))}, spec.PointFormats...)
+		case 13:
+			data = appendVector16(nil, appendUint16(nil, 0x0403, 0x0804, 0x0401, 0x0503, 0x0805, 0x0501, 0x0806, 0x0601))
+		case 16:
+			data = appendVector16(nil, []byte("\x02h2\x08http/1.1"))
+		case 43:
+			versions := []uint16{0x0303}
+			if version >= 0x0304 {
+				versions = []uint16{version, 0x0303}
+			} else if version > 0 {
+				versions = []uint16{version}
+			}
+			list := appendUint16(nil, versions...)
+			data = append([]byte{byte(len(list))}, list...)
+		}
+		extBuf = appendUint16(extBuf, typ)
+		extBuf = appendVector16(extBuf, data)
+	}
+
+	legacyVersion := spec.Version
+	if version > 0 && version < 0x0304 {
+		legacyVersion = version
+	}
+	body := appendUint16(nil, legacyVersion)
+	body = append(body, randomBytes(32)...)
+	body = append(body, 32)
+	body = append(body, randomBytes(32)...)
+	body = appendVector16(body, appendUint16(nil, spec.CipherSuites...))
+	body = append(body, 1, 0) // compression methods: null
+	body = appendVector16(body, extBuf)
+
+	handshake := []byte{0x01, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	handshake = append(handshake, body...)
+	return appendVector16([]byte{0x16, 0x03, 0x01}, handshake)
+}
+
+func randomDomainLabel(n int) []byte {
+	ret := make([]byte, n)
+	for i := range ret {
+		ret[i] = byte('a' + rand.Intn(26))
+	}
+	return ret
+}
+
+type TLSGen struct {
+	r       *rule.Rule
+	sni     ModifierGenerator
+	spec    *ClientHelloSpec
+	version uint16
+}
+
+func newTLSGen(r *rule.Rule) (Generator, error) {
+	if r.ContentRuleConfig == nil {
+		return nil, errors.New("empty content rule config")
+	}
+
+	g := &TLSGen{
+		r: r,
+	}
+	if r.ContentRuleConfig.TLS != nil {
+		g.version = r.ContentRuleConfig.TLS.Version
+	}
+
+	for mdf, rr := range contentRuleMap(r.ContentRuleConfig.ContentRules) {
+		switch mdf {
+		case modifier.TLSSNI:
+			g.sni = parse2ContentGen(rr, WithNoise(noiseDigitChar))
+		case modifier.JA3String:
+			for _, cr := range rr {
+				if cr.Negative {
+					continue
+				}
+				spec, err := ParseJA3ToClientHelloSpec(string(cr.Content))
+				if err != nil {
+					return nil, errors.New("ja3.string should be a complete ja3 string in tls generator")
+				}
+				g.spec = spec
+			}
+		case modifier.JA3Hash:
+			log.Warnf("ja3.hash can't be reversed in tls generator, use ja3.string instead")
+		default:
+			// cert / ja3s are sent by server
+			log.Warnf("not support modifier %v in tls generator", mdf)
+		}
+	}
+	return g, nil
+}
+
+func (g *TLSGen) Gen() []byte {
+	var sni string
+	if g.sni != nil {
+		sni = string(g.sni.Gen())
+	}
+
+	var opts []any
+	opts = append(opts, pcapx.WithTCP_Flags("psh|ack"))
+	opts = append(opts, pcapx.WithTCP_Seq(uint32(rand.Uint32())))
+	opts = append(opts, pcapx.WithTCP_Ack(uint32(rand.Uint32())))
+	opts = append(opts, pcapx.WithIPv4_SrcIP(g.r.SourceAddress.Generate()))
+	opts = append(opts, pcapx.WithIPv4_DstIP(g.r.DestinationAddress.Generate()))
+	opts = append(opts, pcapx.WithTCP_SrcPort(g.r.SourcePort.GetAvailablePort()))
+	opts = append(opts, pcapx.WithTCP_DstPort(g.r.DestinationPort.GenerateWithDefault(443)))
+	opts = append(opts, pcapx.WithPayload(BuildClientHello(g.spec, sni, g.version)))
+
+	raw, err := pcapx.PacketBuilder(opts...)
+	if err != nil {
+		log.Errorf("generate tls packet failed: %s", err)
+		return nil
+	}
+	return raw
+}
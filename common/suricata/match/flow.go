@@ -0,0 +1,574 @@
+package match
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+const (
+	defaultFlowTimeout = 10 * time.Minute
+	closedFlowTimeout  = 10 * time.Second
+	flowGCInterval     = 1024
+)
+
+// FlowState is the state of a flow (5-tuple), shared by both directions
+type FlowState struct {
+	// ID is the same for both directions of a flow, like the flow_id of suricata
+	ID       int64
+	Protocol string
+	// Client / Server is ip:port
+	Client   string
+	Server   string
+	ClientIP string
+	ServerIP string
+
+	Established bool
+	Closed      bool
+	synSeen     bool
+	synAckSeen  bool
+
+	ToServerPackets int
+	ToClientPackets int
+	ToServerBytes   int
+	ToClientBytes   int
+	StartTime       time.Time
+	LastSeen        time.Time
+
+	Bits map[string]struct{}
+	Ints map[string]int
+}
+
+// PacketFlow is the flow of a packet with its direction
+type PacketFlow struct {
+	*FlowState
+	ToServer bool
+	SrcIP    string
+	DstIP    string
+	Time     time.Time
+
+	table *FlowTable
+}
+
+type xbitsKey struct {
+	name  string
+	track string
+}
+
+type thresholdState struct {
+	count int
+	start time.Time
+	// window is the seconds of threshold, zero means the count is never reset
+	window time.Duration
+}
+
+// FlowTable keep the state of flows, xbits and thresholds for the stateful keywords:
+// flow, flowbits, flowint, xbits, threshold and detection_filter.
+// feed every packet to Track in order, then match the rules with the returned PacketFlow.
+type FlowTable struct {
+	lock    sync.Mutex
+	timeout time.Duration
+	now     time.Time
+	tracked int
+
+	flows      map[int64]*FlowState
+	xbits      map[xbitsKey]time.Time
+	thresholds map[string]*thresholdState
+
+	onFlowEnd func(flow *FlowState)
+}
+
+func NewFlowTable() *FlowTable {
+	return NewFlowTableWithTimeout(defaultFlowTimeout)
+}
+
+// NewFlowTableWithTimeout create flow table, the flows inactive for timeout are removed
+func NewFlowTableWithTimeout(timeout time.Duration) *FlowTable {
+	if timeout <= 0 {
+		timeout = defaultFlowTimeout
+	}
+	return &FlowTable{
+		timeout:    timeout,
+		flows:      make(map[int64]*FlowState),
+		xbits:      make(map[xbitsKey]time.Time),
+		thresholds: make(map[string]*thresholdState),
+	}
+}
+
+// Len return the count of tracked flows
+func (t *FlowTable) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.flows)
+}
+
+// SetOnFlowEnd set the callback of the flows removed by timeout or replaced by a new connection with the same tuple,
+// it is called in Track with the lock of table held
+func (t *FlowTable) SetOnFlowEnd(h func(flow *FlowState)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.onFlowEnd = h
+}
+
+// Lookup return the flow by its ID, nil if the flow is not tracked
+func (t *FlowTable) Lookup(id int64) *FlowState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.flows[id]
+}
+
+// Flush remove all the flows and return them
+func (t *FlowTable) Flush() []*FlowState {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	flows := make([]*FlowState, 0, len(t.flows))
+	for id, flow := range t.flows {
+		flows = append(flows, flow)
+		delete(t.flows, id)
+	}
+	return flows
+}
+
+// FlowProtocol return the protocol of the flow of packet: TCP, UDP, ICMP, IPv6-ICMP or the network layer
+func FlowProtocol(pk gopacket.Packet) string {
+	switch {
+	case pk.Layer(layers.LayerTypeTCP) != nil:
+		return "TCP"
+	case pk.Layer(layers.LayerTypeUDP) != nil:
+		return "UDP"
+	case pk.Layer(layers.LayerTypeICMPv4) != nil:
+		return "ICMP"
+	case pk.Layer(layers.LayerTypeICMPv6) != nil:
+		return "IPv6-ICMP"
+	case pk.NetworkLayer() != nil:
+		return pk.NetworkLayer().LayerType().String()
+	}
+	return ""
+}
+
+// FlowID is the same for both directions of a flow, src and dst are ip:port
+func FlowID(proto, src, dst string) int64 {
+	if src > dst {
+		src, dst = dst, src
+	}
+	h := fnv.New64a()
+	h.Write([]byte(proto))
+	h.Write([]byte{0})
+	h.Write([]byte(src))
+	h.Write([]byte{0})
+	h.Write([]byte(dst))
+	// flow_id of suricata is a positive int64
+	return int64(binary.BigEndian.Uint64(h.Sum(nil)) & 0x7fffffffffffffff)
+}
+
+func packetTime(pk gopacket.Packet) time.Time {
+	if md := pk.Metadata(); md != nil && !md.Timestamp.IsZero() {
+		return md.Timestamp
+	}
+	return time.Now()
+}
+
+// Track update the flow of packet, return nil if the packet has no network layer
+func (t *FlowTable) Track(pk gopacket.Packet) *PacketFlow {
+	if pk == nil || pk.NetworkLayer() == nil {
+		return nil
+	}
+
+	nw := pk.NetworkLayer().NetworkFlow()
+	srcIP, dstIP := nw.Src().String(), nw.Dst().String()
+	proto := FlowProtocol(pk)
+	var srcPort, dstPort int
+	var tcp *layers.TCP
+	if tl := pk.TransportLayer(); tl != nil {
+		switch ret := tl.(type) {
+		case *layers.TCP:
+			tcp = ret
+			srcPort, dstPort = int(ret.SrcPort), int(ret.DstPort)
+		case *layers.UDP:
+			srcPort, dstPort = int(ret.SrcPort), int(ret.DstPort)
+		}
+	}
+	src := net.JoinHostPort(srcIP, strconv.Itoa(srcPort))
+	dst := net.JoinHostPort(dstIP, strconv.Itoa(dstPort))
+	id := FlowID(proto, src, dst)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := packetTime(pk)
+	if now.After(t.now) {
+		t.now = now
+	}
+	t.tracked++
+	if t.tracked%flowGCInterval == 0 {
+		t.gc()
+	}
+
+	flow, ok := t.flows[id]
+	if ok && tcp != nil && tcp.SYN && !tcp.ACK && (flow.Closed || flow.Established) {
+		// port reused by a new connection
+		ok = false
+		if t.onFlowEnd != nil {
+			t.onFlowEnd(flow)
+		}
+	}
+	if !ok {
+		flow = &FlowState{
+			ID:        id,
+			Protocol:  proto,
+			Client:    src,
+			Server:    dst,
+			ClientIP:  srcIP,
+			ServerIP:  dstIP,
+			StartTime: now,
+			Bits:      make(map[string]struct{}),
+			Ints:      make(map[string]int),
+		}
+		switch {
+		case tcp != nil && tcp.SYN && !tcp.ACK:
+		case tcp != nil && tcp.SYN && tcp.ACK:
+			flow.Client, flow.Server, flow.ClientIP, flow.ServerIP = dst, src, dstIP, srcIP
+		case srcPort < dstPort && srcPort < 1024:
+			// picked up midstream, the well-known port is the server
+			flow.Client, flow.Server, flow.ClientIP, flow.ServerIP = dst, src, dstIP, srcIP
+		}
+		if tcp != nil && !tcp.SYN {
+			// picked up midstream, e.g. the packets of HttpFlow
+			flow.Established = true
+		}
+		t.flows[id] = flow
+	}
+
+	size := len(pk.Data())
+	toServer := src == flow.Client
+	if toServer {
+		flow.ToServerPackets++
+		flow.ToServerBytes += size
+	} else {
+		flow.ToClientPackets++
+		flow.ToClientBytes += size
+	}
+	flow.LastSeen = now
+
+	if tcp != nil {
+		switch {
+		case tcp.RST:
+			flow.Closed = true
+			flow.Established = false
+		case tcp.FIN:
+			flow.Closed = true
+		case tcp.SYN && !tcp.ACK:
+			flow.synSeen = true
+		case tcp.SYN && tcp.ACK:
+			if !toServer {
+				flow.synAckSeen = true
+			}
+		case tcp.ACK:
+			if toServer && flow.synAckSeen && !flow.Closed {
+				flow.Established = true
+			}
+		}
+	} else if flow.ToServerPackets > 0 && flow.ToClientPackets > 0 {
+		flow.Established = true
+	}
+
+	return &PacketFlow{
+		FlowState: flow,
+		ToServer:  toServer,
+		SrcIP:     srcIP,
+		DstIP:     dstIP,
+		Time:      now,
+		table:     t,
+	}
+}
+
+func (t *FlowTable) gc() {
+	for id, flow := range t.flows {
+		timeout := t.timeout
+		if flow.Closed && closedFlowTimeout < timeout {
+			timeout = closedFlowTimeout
+		}
+		if t.now.Sub(flow.LastSeen) > timeout {
+			delete(t.flows, id)
+			if t.onFlowEnd != nil {
+				t.onFlowEnd(flow)
+			}
+		}
+	}
+	for key, expire := range t.xbits {
+		if !expire.IsZero() && t.now.After(expire) {
+			delete(t.xbits, key)
+		}
+	}
+	for key, state := range t.thresholds {
+		if state.window > 0 && t.now.Sub(state.start) >= state.window {
+			delete(t.thresholds, key)
+		}
+	}
+}
+
+func xbitsTrack(x *rule.XBitsRule, flow *PacketFlow) string {
+	switch x.Track {
+	case "ip_dst":
+		return flow.DstIP
+	case "ip_pair":
+		if flow.SrcIP > flow.DstIP {
+			return flow.DstIP + "|" + flow.SrcIP
+		}
+		return flow.SrcIP + "|" + flow.DstIP
+	default:
+		return flow.SrcIP
+	}
+}
+
+func (t *FlowTable) isXBitSet(x *rule.XBitsRule, flow *PacketFlow) bool {
+	expire, ok := t.xbits[xbitsKey{name: x.Name, track: xbitsTrack(x, flow)}]
+	if !ok {
+		return false
+	}
+	return expire.IsZero() || !flow.Time.After(expire)
+}
+
+func (f *PacketFlow) isBitSet(name string) bool {
+	_, ok := f.Bits[name]
+	return ok
+}
+
+func (f *PacketFlow) flowIntValue(value string) (int, bool) {
+	if i, err := strconv.Atoi(value); err == nil {
+		return i, true
+	}
+	i, ok := f.Ints[value]
+	return i, ok
+}
+
+// Check return true if the flow satisfy the flow / flowbits / flowint / xbits conditions of rule
+func (f *PacketFlow) Check(r *rule.Rule) bool {
+	if f == nil || r == nil || r.ContentRuleConfig == nil {
+		return true
+	}
+	cfg := r.ContentRuleConfig
+
+	if fr := cfg.Flow; fr != nil && !fr.Stateless {
+		if fr.ToServer && !f.ToServer {
+			return false
+		}
+		if fr.ToClient && f.ToServer {
+			return false
+		}
+		if fr.Established && !f.Established {
+			return false
+		}
+		if fr.NotEstablished && f.Established {
+			return false
+		}
+	}
+
+	f.table.lock.Lock()
+	defer f.table.lock.Unlock()
+
+	for _, fb := range cfg.FlowBits {
+		if !fb.IsCondition() {
+			continue
+		}
+		want := fb.Command == "isset"
+		matched := !fb.Or
+		for _, name := range fb.Names {
+			if fb.Or {
+				matched = matched || f.isBitSet(name) == want
+			} else {
+				matched = matched && f.isBitSet(name) == want
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, fi := range cfg.FlowInts {
+		if !fi.IsCondition() {
+			continue
+		}
+		current, ok := f.Ints[fi.Name]
+		switch fi.Modifier {
+		case "isset":
+			if !ok {
+				return false
+			}
+			continue
+		case "notset":
+			if ok {
+				return false
+			}
+			continue
+		}
+		value, valueOk := f.flowIntValue(fi.Value)
+		if !ok || !valueOk {
+			return false
+		}
+		var matched bool
+		switch fi.Modifier {
+		case "==":
+			matched = current == value
+		case "!=":
+			matched = current != value
+		case "<":
+			matched = current < value
+		case ">":
+			matched = current > value
+		case "<=":
+			matched = current <= value
+		case ">=":
+			matched = current >= value
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, x := range cfg.XBits {
+		if !x.IsCondition() {
+			continue
+		}
+		if f.table.isXBitSet(x, f) != (x.Command == "isset") {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply update the flowbits / flowint / xbits of flow by the matched rule
+func (f *PacketFlow) Apply(r *rule.Rule) {
+	if f == nil || r == nil || r.ContentRuleConfig == nil {
+		return
+	}
+	cfg := r.ContentRuleConfig
+
+	f.table.lock.Lock()
+	defer f.table.lock.Unlock()
+
+	for _, fb := range cfg.FlowBits {
+		for _, name := range fb.Names {
+			switch fb.Command {
+			case "set":
+				f.Bits[name] = struct{}{}
+			case "unset":
+				delete(f.Bits, name)
+			case "toggle":
+				if f.isBitSet(name) {
+					delete(f.Bits, name)
+				} else {
+					f.Bits[name] = struct{}{}
+				}
+			}
+		}
+	}
+
+	for _, fi := range cfg.FlowInts {
+		if fi.IsCondition() {
+			continue
+		}
+		value, ok := f.flowIntValue(fi.Value)
+		if !ok {
+			continue
+		}
+		switch fi.Modifier {
+		case "=":
+			f.Ints[fi.Name] = value
+		case "+":
+			f.Ints[fi.Name] += value
+		case "-":
+			f.Ints[fi.Name] -= value
+		}
+	}
+
+	for _, x := range cfg.XBits {
+		key := xbitsKey{name: x.Name, track: xbitsTrack(x, f)}
+		var expire time.Time
+		if x.Expire > 0 {
+			expire = f.Time.Add(time.Duration(x.Expire) * time.Second)
+		}
+		switch x.Command {
+		case "set":
+			f.table.xbits[key] = expire
+		case "unset":
+			delete(f.table.xbits, key)
+		case "toggle":
+			if f.table.isXBitSet(x, f) {
+				delete(f.table.xbits, key)
+			} else {
+				f.table.xbits[key] = expire
+			}
+		}
+	}
+}
+
+func thresholdTrack(th *rule.ThresholdingConfig, flow *PacketFlow) string {
+	switch th.Track {
+	case "by_dst":
+		return flow.DstIP
+	case "by_rule":
+		return ""
+	case "by_both":
+		if flow.SrcIP > flow.DstIP {
+			return flow.DstIP + "|" + flow.SrcIP
+		}
+		return flow.SrcIP + "|" + flow.DstIP
+	default:
+		return flow.SrcIP
+	}
+}
+
+// Alert return true if the matched rule should raise an alert,
+// noalert rules never alert and threshold / limit / both / detection_filter are applied by the track.
+func (t *FlowTable) Alert(r *rule.Rule, flow *PacketFlow) bool {
+	if r == nil || r.ContentRuleConfig == nil {
+		return true
+	}
+	if r.ContentRuleConfig.NoAlert {
+		return false
+	}
+	th := r.ContentRuleConfig.Thresholding
+	if th == nil || th.Count <= 0 || flow == nil {
+		return true
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	key := fmt.Sprintf("%d:%d:%s", r.Gid, r.Sid, thresholdTrack(th, flow))
+	if r.Sid == 0 {
+		key += "|" + r.Raw
+	}
+	window := time.Duration(th.Seconds) * time.Second
+	state, ok := t.thresholds[key]
+	if !ok || (window > 0 && flow.Time.Sub(state.start) >= window) {
+		state = &thresholdState{start: flow.Time, window: window}
+		t.thresholds[key] = state
+	}
+	state.count++
+
+	switch {
+	case th.DetectionFilter:
+		return state.count > th.Count
+	case th.ThresholdMode && th.LimitMode:
+		return state.count == th.Count
+	case th.ThresholdMode:
+		if state.count >= th.Count {
+			state.count = 0
+			return true
+		}
+		return false
+	case th.LimitMode:
+		return state.count <= th.Count
+	}
+	return true
+}
@@ -0,0 +1,201 @@
+package match
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+func buildTCPPacket(t *testing.T, src, dst string, srcPort, dstPort int, flags string, payload string) gopacket.Packet {
+	opts := []any{
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP(src),
+		pcapx.WithIPv4_DstIP(dst),
+		pcapx.WithTCP_SrcPort(srcPort),
+		pcapx.WithTCP_DstPort(dstPort),
+		pcapx.WithTCP_Flags(flags),
+	}
+	if payload != "" {
+		opts = append(opts, pcapx.WithPayload([]byte(payload)))
+	}
+	raw, err := pcapx.PacketBuilder(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gopacket.NewPacket(raw, layers.LayerTypeEthernet, gopacket.NoCopy)
+}
+
+func parseRules(t *testing.T, raw string) []*Matcher {
+	rules, err := rule.Parse(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var matchers []*Matcher
+	for _, r := range rules {
+		matchers = append(matchers, New(r))
+	}
+	return matchers
+}
+
+func TestFlowTable_Established(t *testing.T) {
+	m := parseRules(t, `alert tcp any any -> any any (msg:"established"; flow:established,to_server; content:"hello"; sid:1;)`)[0]
+	table := NewFlowTable()
+
+	syn := buildTCPPacket(t, "192.168.1.2", "192.168.1.3", 34567, 8080, "syn", "hello")
+	if m.MatchPackageWithFlow(syn, table.Track(syn)) {
+		t.Fatal("syn should not match established")
+	}
+	if !m.MatchPackage(syn) {
+		t.Fatal("isolated packet should ignore flow")
+	}
+	table.Track(buildTCPPacket(t, "192.168.1.3", "192.168.1.2", 8080, 34567, "syn|ack", ""))
+	table.Track(buildTCPPacket(t, "192.168.1.2", "192.168.1.3", 34567, 8080, "ack", ""))
+
+	data := buildTCPPacket(t, "192.168.1.2", "192.168.1.3", 34567, 8080, "psh|ack", "hello")
+	flow := table.Track(data)
+	if !flow.Established || !flow.ToServer {
+		t.Fatalf("flow state error: %+v", flow)
+	}
+	if !m.MatchPackageWithFlow(data, flow) {
+		t.Fatal("data should match established,to_server")
+	}
+
+	rsp := buildTCPPacket(t, "192.168.1.3", "192.168.1.2", 8080, 34567, "psh|ack", "hello")
+	if m.MatchPackageWithFlow(rsp, table.Track(rsp)) {
+		t.Fatal("response should not match to_server")
+	}
+	if table.Len() != 1 {
+		t.Fatalf("flow count error: %d", table.Len())
+	}
+}
+
+func TestFlowTable_FlowEnd(t *testing.T) {
+	table := NewFlowTable()
+	var ended []*FlowState
+	table.SetOnFlowEnd(func(flow *FlowState) {
+		ended = append(ended, flow)
+	})
+	track := func(pk gopacket.Packet, ts time.Time) *PacketFlow {
+		pk.Metadata().Timestamp = ts
+		return table.Track(pk)
+	}
+
+	start := time.Unix(1700000000, 0)
+	flow := track(buildTCPPacket(t, "192.168.1.2", "192.168.1.3", 34567, 8080, "psh|ack", "hello"), start)
+	track(buildTCPPacket(t, "192.168.1.3", "192.168.1.2", 8080, 34567, "rst", ""), start)
+	if flow.ID != FlowID("TCP", "192.168.1.3:8080", "192.168.1.2:34567") || table.Lookup(flow.ID) != flow.FlowState {
+		t.Fatalf("flow id error: %v", flow.ID)
+	}
+	if flow.ToServerBytes <= 0 || flow.ToClientBytes <= 0 {
+		t.Fatalf("flow bytes error: %+v", flow.FlowState)
+	}
+	active := track(buildTCPPacket(t, "192.168.1.2", "192.168.1.3", 34568, 8080, "psh|ack", "hello"), start)
+
+	// the closed flow is removed earlier than the active one
+	track(buildTCPPacket(t, "192.168.1.2", "192.168.1.3", 34568, 8080, "psh|ack", "hello"), start.Add(time.Minute))
+	table.lock.Lock()
+	table.gc()
+	table.lock.Unlock()
+	if len(ended) != 1 || ended[0] != flow.FlowState || table.Lookup(active.ID) == nil {
+		t.Fatalf("closed flow should be ended: %v", ended)
+	}
+
+	if flows := table.Flush(); len(flows) != 1 || table.Len() != 0 {
+		t.Fatalf("flush error: %v", flows)
+	}
+}
+
+func TestGroup_FlowBits(t *testing.T) {
+	rules, err := rule.Parse(`alert http any any -> any any (msg:"request"; flow:established,to_server; content:"/shell.php"; http_uri; flowbits:set,webshell; flowbits:noalert; sid:1;)
+alert http any any -> any any (msg:"response"; flow:established,to_client; content:"200"; http_stat_code; flowbits:isset,webshell; sid:2;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alerts []int
+	group := NewGroup(
+		WithGroupFlowTable(NewFlowTable()),
+		WithGroupOnMatchedCallback(func(_ gopacket.Packet, r *rule.Rule) {
+			alerts = append(alerts, r.Sid)
+		}),
+	)
+	group.LoadRules(rules...)
+
+	rsp := []byte("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+	group.FeedHTTPFlowBytes([]byte("GET /index.php HTTP/1.1\r\nHost: example.com\r\n\r\n"), rsp)
+	group.FeedHTTPResponseBytes(rsp)
+	group.Wait()
+	if len(alerts) != 0 {
+		t.Fatalf("flowbits not set, but got alerts: %v", alerts)
+	}
+
+	group.FeedHTTPFlowBytes([]byte("GET /shell.php HTTP/1.1\r\nHost: example.com\r\n\r\n"), rsp)
+	group.Wait()
+	if len(alerts) != 1 || alerts[0] != 2 {
+		t.Fatalf("flowbits alerts error: %v", alerts)
+	}
+}
+
+func TestFlowTable_ThresholdAndFlowInt(t *testing.T) {
+	matchers := parseRules(t, `alert tcp any any -> any any (msg:"limit"; content:"hello"; threshold:type limit, track by_src, count 1, seconds 60; sid:1;)
+alert tcp any any -> any any (msg:"count"; content:"hello"; flowint:hello,+,1; noalert; sid:2;)
+alert tcp any any -> any any (msg:"flowint"; content:"hello"; flowint:hello,>=,3; sid:3;)
+alert tcp any any -> any any (msg:"threshold"; content:"hello"; threshold:type threshold, track by_dst, count 2, seconds 60; sid:4;)`)
+	table := NewFlowTable()
+
+	alerts := make(map[int]int)
+	for i := 0; i < 4; i++ {
+		pk := buildTCPPacket(t, "10.0.0.1", "10.0.0.2", 40000, 80, "psh|ack", "hello")
+		flow := table.Track(pk)
+		for _, m := range matchers {
+			if m.MatchPackageWithFlow(pk, flow) && table.Alert(m.matcher.Rule, flow) {
+				alerts[m.matcher.Rule.Sid]++
+			}
+		}
+	}
+
+	for sid, want := range map[int]int{1: 1, 2: 0, 3: 2, 4: 2} {
+		if alerts[sid] != want {
+			t.Fatalf("sid %d alerts error: %d(got) != %d(want)", sid, alerts[sid], want)
+		}
+	}
+}
+
+func TestFlowTable_ThresholdLongerThanFlowTimeout(t *testing.T) {
+	m := parseRules(t, `alert tcp any any -> any any (msg:"limit"; content:"hello"; threshold:type limit, track by_src, count 1, seconds 3600; sid:1;)`)[0]
+	table := NewFlowTableWithTimeout(time.Minute)
+
+	start := time.Unix(1700000000, 0)
+	var alerts int
+	for i := 0; i < 3; i++ {
+		pk := buildTCPPacket(t, "10.0.0.1", "10.0.0.2", 40000+i, 80, "psh|ack", "hello")
+		pk.Metadata().Timestamp = start.Add(time.Duration(i) * 10 * time.Minute)
+		flow := table.Track(pk)
+		// the flows are removed by timeout, the window of threshold is kept
+		table.lock.Lock()
+		table.gc()
+		table.lock.Unlock()
+		if m.MatchPackageWithFlow(pk, flow) && table.Alert(m.matcher.Rule, flow) {
+			alerts++
+		}
+	}
+	if alerts != 1 {
+		t.Fatalf("limit in 3600 seconds should alert once, got %d", alerts)
+	}
+
+	// the window is over
+	pk := buildTCPPacket(t, "10.0.0.1", "10.0.0.2", 40010, 80, "psh|ack", "hello")
+	pk.Metadata().Timestamp = start.Add(time.Hour)
+	flow := table.Track(pk)
+	table.lock.Lock()
+	table.gc()
+	table.lock.Unlock()
+	if !m.MatchPackageWithFlow(pk, flow) || !table.Alert(m.matcher.Rule, flow) {
+		t.Fatal("limit should alert again in the next window")
+	}
+}
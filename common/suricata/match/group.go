@@ -50,6 +50,9 @@ type Group struct {
 
 	onMatchedCallback func(packet gopacket.Packet, match *rule.Rule)
 
+	// flowTable is nil if the packets are matched in isolation
+	flowTable *FlowTable
+
 	// control waitgroup
 	wg *sync.WaitGroup
 }
@@ -229,6 +232,14 @@ func (g *Group) FeedFrame(raw []byte) {
 	g.feedPacket(pk)
 }
 
+// FeedPacket match the decoded packet, such as the packets from pcaputil
+func (g *Group) FeedPacket(pk gopacket.Packet) {
+	if pk == nil {
+		return
+	}
+	g.feedPacket(pk)
+}
+
 func (g *Group) FeedHTTPRequestBytes(reqBytes []byte) {
 	g.feedHTTPFlow(&HttpFlow{
 		Req: reqBytes,
@@ -248,6 +259,18 @@ func (g *Group) FeedHTTPFlowBytes(req, rsp []byte) {
 	})
 }
 
+// FeedHTTPFlowBytesWithAddress is like FeedHTTPFlowBytes, but the packets keep the ipv4 addresses of flow
+func (g *Group) FeedHTTPFlowBytesWithAddress(src string, srcPort int, dst string, dstPort int, req, rsp []byte) {
+	g.feedHTTPFlow(&HttpFlow{
+		Src:     src,
+		SrcPort: srcPort,
+		Dst:     dst,
+		DstPort: dstPort,
+		Req:     req,
+		Rsp:     rsp,
+	})
+}
+
 func (g *Group) FeedHTTPFlow(src, dst string, srcPort, dstPort int, req *http.Request, rsp *http.Response) {
 	flow := &HttpFlow{
 		ReqInstance: req,
@@ -284,13 +307,7 @@ func (g *Group) consumeMain() {
 			for {
 				select {
 				case packetFrame := <-g.frameChan:
-					for _, matcherpool := range g.OrdinaryMatcher {
-						matcher := matcherpool.Get().(*Matcher)
-						if matcher.MatchPackage(packetFrame) {
-							g.onMatchedCallback(packetFrame, matcher.matcher.Rule)
-						}
-						matcherpool.Put(matcher)
-					}
+					g.matchPacket(g.OrdinaryMatcher, packetFrame)
 					g.wg.Done()
 				case httpFlowInstance := <-g.httpRequest:
 					_ = httpFlowInstance
@@ -299,14 +316,12 @@ func (g *Group) consumeMain() {
 						g.wg.Done()
 						continue
 					}
-					for _, matcherpool := range g.HTTPMatcher {
-						for _, pkg := range pkgs {
-							matcher := matcherpool.Get().(*Matcher)
-							if matcher.MatchPackage(pkg) {
-								g.onMatchedCallback(pkg, matcher.matcher.Rule)
-							}
-							matcherpool.Put(matcher)
+					// request and response share the same flow, keep their order for flowbits
+					for _, pkg := range pkgs {
+						if pkg == nil {
+							continue
 						}
+						g.matchPacket(g.HTTPMatcher, pkg)
 					}
 					g.wg.Done()
 				case <-g.ctx.Done():
@@ -316,3 +331,19 @@ func (g *Group) consumeMain() {
 		}()
 	})
 }
+
+func (g *Group) matchPacket(pools []*sync.Pool, pk gopacket.Packet) {
+	var flow *PacketFlow
+	if g.flowTable != nil {
+		flow = g.flowTable.Track(pk)
+	}
+	for _, matcherpool := range pools {
+		matcher := matcherpool.Get().(*Matcher)
+		if matcher.MatchPackageWithFlow(pk, flow) {
+			if g.flowTable == nil || g.flowTable.Alert(matcher.matcher.Rule, flow) {
+				g.onMatchedCallback(pk, matcher.matcher.Rule)
+			}
+		}
+		matcherpool.Put(matcher)
+	}
+}
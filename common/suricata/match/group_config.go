@@ -12,3 +12,11 @@ func WithGroupOnMatchedCallback(cb func(packet gopacket.Packet, match *rule.Rule
 		c.onMatchedCallback = cb
 	}
 }
+
+// WithGroupFlowTable match the packets with the state of their flows,
+// the flow / flowbits / flowint / xbits / threshold keywords take effect
+func WithGroupFlowTable(table *FlowTable) GroupOption {
+	return func(c *Group) {
+		c.flowTable = table
+	}
+}
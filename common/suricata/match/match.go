@@ -50,6 +50,22 @@ func (m *Matcher) MatchPackage(pk gopacket.Packet) bool {
 	return m.matcher.Match(pk)
 }
 
+// MatchPackageWithFlow match the packet with the state of its flow tracked by FlowTable,
+// the flowbits / flowint / xbits are updated if the rule matched
+func (m *Matcher) MatchPackageWithFlow(pk gopacket.Packet, flow *PacketFlow) bool {
+	if flow == nil {
+		return m.MatchPackage(pk)
+	}
+	if pk == nil || !flow.Check(m.matcher.Rule) {
+		return false
+	}
+	if !m.matcher.Match(pk) {
+		return false
+	}
+	flow.Apply(m.matcher.Rule)
+	return true
+}
+
 type matchHandler func(*matchContext) error
 
 type bufferProvider func(modifier modifier.Modifier) []byte
@@ -187,6 +203,15 @@ func matchMutex(c *matchContext) error {
 		attachFastPattern(c)
 		c.Attach(tcpCfgMatch)
 		attachPayloadMatcher(c)
+	case protocol.TLS:
+		c.Attach(ipMatcher, portMatcher, tlsParser)
+		attachFastPattern(c)
+		c.Attach(tlsCfgMatch)
+		attachPayloadMatcher(c)
+	case protocol.SSH:
+		c.Attach(ipMatcher, portMatcher, sshParser)
+		attachFastPattern(c)
+		attachPayloadMatcher(c)
 	case protocol.UDP:
 		c.Attach(ipMatcher, portMatcher, udpParser)
 		attachFastPattern(c)
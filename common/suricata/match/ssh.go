@@ -0,0 +1,74 @@
+package match
+
+import (
+	"bytes"
+
+	"github.com/google/gopacket"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+)
+
+// SSHBanner is the identification string of ssh, e.g. SSH-2.0-OpenSSH_8.9p1 Ubuntu-3
+type SSHBanner struct {
+	Proto    string
+	Software string
+}
+
+// ParseSSHBanner return nil if payload is not start with ssh identification string
+func ParseSSHBanner(payload []byte) *SSHBanner {
+	if !bytes.HasPrefix(payload, []byte("SSH-")) {
+		return nil
+	}
+	line := payload[4:]
+	if idx := bytes.IndexAny(line, "\r\n"); idx >= 0 {
+		line = line[:idx]
+	}
+	proto, software, ok := bytes.Cut(line, []byte("-"))
+	if !ok || len(proto) <= 0 {
+		return nil
+	}
+	// comments is split by space
+	if idx := bytes.IndexByte(software, ' '); idx >= 0 {
+		software = software[:idx]
+	}
+	return &SSHBanner{Proto: string(proto), Software: string(software)}
+}
+
+func (s *SSHBanner) Get(mdf modifier.Modifier) []byte {
+	switch mdf {
+	case modifier.SSHProto:
+		return []byte(s.Proto)
+	case modifier.SSHSoftware:
+		if s.Software == "" {
+			return nil
+		}
+		return []byte(s.Software)
+	}
+	return nil
+}
+
+func parsePacketSSHBanner(pk gopacket.Packet) *SSHBanner {
+	if pk.TransportLayer() == nil {
+		return nil
+	}
+	return ParseSSHBanner(pk.TransportLayer().LayerPayload())
+}
+
+func sshParser(c *matchContext) error {
+	if !c.Must(c.Rule.ContentRuleConfig != nil) {
+		return nil
+	}
+
+	banner := parsePacketSSHBanner(c.PK)
+	if !c.Must(banner != nil) {
+		return nil
+	}
+
+	payload := c.PK.TransportLayer().LayerPayload()
+	c.SetBufferProvider(func(mdf modifier.Modifier) []byte {
+		if mdf == modifier.Default {
+			return payload
+		}
+		return banner.Get(mdf)
+	})
+	return nil
+}
@@ -78,11 +78,20 @@ func newTCPProvider(pk gopacket.Packet) func(modifier modifier.Modifier) []byte
 		return nil
 	}
 	return func(mdf modifier.Modifier) []byte {
-		switch mdf {
-		case modifier.TCPHDR:
+		switch {
+		case mdf == modifier.TCPHDR:
 			return tcp.Contents
-		case modifier.Default:
+		case mdf == modifier.Default:
 			return tcp.Payload
+		case modifier.IsTLSModifier(mdf):
+			// tls / ssh sticky buffers used in tcp rules
+			if info := ParseTLSInfo(tcp.Payload); info != nil {
+				return info.Get(mdf)
+			}
+		case modifier.IsSSHModifier(mdf):
+			if banner := ParseSSHBanner(tcp.Payload); banner != nil {
+				return banner.Get(mdf)
+			}
 		}
 		return nil
 	}
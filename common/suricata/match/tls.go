@@ -0,0 +1,326 @@
+package match
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/gopacket"
+	"github.com/yaklang/yaklang/common/suricata/data/modifier"
+	"github.com/yaklang/yaklang/common/utils/tlsutils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+const (
+	tlsRecordHandshake = 0x16
+
+	tlsHandshakeClientHello = 0x01
+	tlsHandshakeServerHello = 0x02
+	tlsHandshakeCertificate = 0x0b
+
+	tlsExtSupportedGroups   = 10
+	tlsExtECPointFormats    = 11
+	tlsExtSupportedVersions = 43
+)
+
+// TLSInfo is the fields of tls handshake in a payload, the messages split into multiple segments are ignored
+type TLSInfo struct {
+	ClientHello bool
+	ServerHello bool
+
+	// Version is the offered version in client hello or the selected version in server hello
+	Version uint16
+	SNI     string
+
+	JA3  string
+	JA3S string
+
+	CertSubject string
+	CertIssuer  string
+}
+
+func (t *TLSInfo) JA3Hash() string {
+	if t.JA3 == "" {
+		return ""
+	}
+	return codec.Md5(t.JA3)
+}
+
+func (t *TLSInfo) JA3SHash() string {
+	if t.JA3S == "" {
+		return ""
+	}
+	return codec.Md5(t.JA3S)
+}
+
+func (t *TLSInfo) Get(mdf modifier.Modifier) []byte {
+	var ret string
+	switch mdf {
+	case modifier.TLSSNI:
+		ret = t.SNI
+	case modifier.TLSCertSubject:
+		ret = t.CertSubject
+	case modifier.TLSCertIssuer:
+		ret = t.CertIssuer
+	case modifier.JA3Hash:
+		ret = t.JA3Hash()
+	case modifier.JA3String:
+		ret = t.JA3
+	case modifier.JA3SHash:
+		ret = t.JA3SHash()
+	case modifier.JA3SString:
+		ret = t.JA3S
+	}
+	if ret == "" {
+		return nil
+	}
+	return []byte(ret)
+}
+
+// isGREASE check the reserved values (RFC 8701) which are ignored by ja3
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+type tlsReader struct {
+	buf []byte
+	err bool
+}
+
+func (r *tlsReader) next(n int) []byte {
+	if r.err || n < 0 || len(r.buf) < n {
+		r.err = true
+		return nil
+	}
+	ret := r.buf[:n]
+	r.buf = r.buf[n:]
+	return ret
+}
+
+func (r *tlsReader) uint8() int {
+	if b := r.next(1); b != nil {
+		return int(b[0])
+	}
+	return 0
+}
+
+func (r *tlsReader) uint16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.BigEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *tlsReader) uint24() int {
+	if b := r.next(3); b != nil {
+		return int(b[0])<<16 | int(b[1])<<8 | int(b[2])
+	}
+	return 0
+}
+
+func joinUint16(list []uint16) string {
+	var items []string
+	for _, v := range list {
+		if isGREASE(v) {
+			continue
+		}
+		items = append(items, strconv.Itoa(int(v)))
+	}
+	return strings.Join(items, "-")
+}
+
+// ParseTLSInfo parse the tls handshake records in payload, return nil if payload is not tls handshake
+func ParseTLSInfo(payload []byte) *TLSInfo {
+	var handshake []byte
+	for len(payload) >= 5 {
+		if payload[1] != 0x03 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(payload[3:5]))
+		data := payload[5:]
+		if len(data) > length {
+			data = data[:length]
+		}
+		if payload[0] == tlsRecordHandshake {
+			handshake = append(handshake, data...)
+		} else if payload[0] < 0x14 || payload[0] > 0x18 {
+			break
+		}
+		payload = payload[5+len(data):]
+	}
+	if len(handshake) <= 0 {
+		return nil
+	}
+
+	info := &TLSInfo{}
+	r := &tlsReader{buf: handshake}
+	for len(r.buf) >= 4 {
+		msg := r.buf
+		typ := r.uint8()
+		body := r.next(r.uint24())
+		if body == nil {
+			break
+		}
+		switch typ {
+		case tlsHandshakeClientHello:
+			// the message with its header is parsed by tlsutils
+			info.parseClientHello(msg[:4+len(body)])
+		case tlsHandshakeServerHello:
+			info.parseServerHello(body)
+		case tlsHandshakeCertificate:
+			info.parseCertificate(body)
+		}
+	}
+	if !info.ClientHello && !info.ServerHello && info.CertSubject == "" {
+		return nil
+	}
+	return info
+}
+
+func (t *TLSInfo) parseClientHello(msg []byte) {
+	hello, err := tlsutils.ParseClientHello(msg)
+	if err != nil {
+		return
+	}
+	var ciphers, exts, curves []uint16
+	var points []string
+	for i := 0; i+1 < len(hello.CipherSuite); i += 2 {
+		ciphers = append(ciphers, binary.BigEndian.Uint16(hello.CipherSuite[i:]))
+	}
+
+	t.Version = hello.Version
+	t.SNI = hello.SNI()
+	for _, ext := range hello.Extensions {
+		exts = append(exts, ext.TypeInt)
+		data := &tlsReader{buf: ext.RawData}
+		switch ext.TypeInt {
+		case tlsExtSupportedGroups:
+			list := &tlsReader{buf: data.next(int(data.uint16()))}
+			for len(list.buf) >= 2 {
+				curves = append(curves, list.uint16())
+			}
+		case tlsExtECPointFormats:
+			for _, p := range data.next(data.uint8()) {
+				points = append(points, strconv.Itoa(int(p)))
+			}
+		case tlsExtSupportedVersions:
+			list := &tlsReader{buf: data.next(data.uint8())}
+			for len(list.buf) >= 2 {
+				if v := list.uint16(); !isGREASE(v) && v > t.Version {
+					t.Version = v
+				}
+			}
+		}
+	}
+	t.ClientHello = true
+	t.JA3 = fmt.Sprintf("%d,%s,%s,%s,%s", hello.Version, joinUint16(ciphers), joinUint16(exts), joinUint16(curves), strings.Join(points, "-"))
+}
+
+func (t *TLSInfo) parseServerHello(body []byte) {
+	r := &tlsReader{buf: body}
+	version := r.uint16()
+	r.next(32)        // random
+	r.next(r.uint8()) // session id
+	cipher := r.uint16()
+	r.uint8() // compression method
+	if r.err {
+		return
+	}
+	var exts []uint16
+	t.Version = version
+	ext := &tlsReader{buf: r.next(int(r.uint16()))}
+	for len(ext.buf) >= 4 {
+		typ := ext.uint16()
+		data := &tlsReader{buf: ext.next(int(ext.uint16()))}
+		exts = append(exts, typ)
+		if typ == tlsExtSupportedVersions {
+			if v := data.uint16(); !data.err {
+				t.Version = v
+			}
+		}
+	}
+	t.ServerHello = true
+	t.JA3S = fmt.Sprintf("%d,%d,%s", version, cipher, joinUint16(exts))
+}
+
+func (t *TLSInfo) parseCertificate(body []byte) {
+	r := &tlsReader{buf: body}
+	list := &tlsReader{buf: r.next(r.uint24())}
+	// the first one is the certificate of server
+	der := list.next(list.uint24())
+	if list.err {
+		return
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return
+	}
+	t.CertSubject = formatX509Name(cert.Subject)
+	t.CertIssuer = formatX509Name(cert.Issuer)
+}
+
+var x509AttributeNames = map[string]string{
+	"2.5.4.3":              "CN",
+	"2.5.4.5":              "serialNumber",
+	"2.5.4.6":              "C",
+	"2.5.4.7":              "L",
+	"2.5.4.8":              "ST",
+	"2.5.4.9":              "street",
+	"2.5.4.10":             "O",
+	"2.5.4.11":             "OU",
+	"1.2.840.113549.1.9.1": "emailAddress",
+}
+
+// formatX509Name format the name in the order of certificate like suricata, e.g. C=US, O=Let's Encrypt, CN=R3
+func formatX509Name(name pkix.Name) string {
+	var items []string
+	for _, atv := range name.Names {
+		key, ok := x509AttributeNames[atv.Type.String()]
+		if !ok {
+			key = atv.Type.String()
+		}
+		items = append(items, fmt.Sprintf("%s=%v", key, atv.Value))
+	}
+	return strings.Join(items, ", ")
+}
+
+func tlsParser(c *matchContext) error {
+	if !c.Must(c.Rule.ContentRuleConfig != nil) {
+		return nil
+	}
+
+	info := parsePacketTLSInfo(c.PK)
+	if !c.Must(info != nil) {
+		return nil
+	}
+
+	payload := c.PK.TransportLayer().LayerPayload()
+	c.SetBufferProvider(func(mdf modifier.Modifier) []byte {
+		if mdf == modifier.Default {
+			return payload
+		}
+		return info.Get(mdf)
+	})
+	c.Value["tls"] = info
+	return nil
+}
+
+func tlsCfgMatch(c *matchContext) error {
+	cf := c.Rule.ContentRuleConfig.TLS
+	if cf == nil || cf.Version == 0 {
+		return nil
+	}
+	info, ok := c.Value["tls"].(*TLSInfo)
+	c.Must(ok && info.Version == cf.Version)
+	return nil
+}
+
+func parsePacketTLSInfo(pk gopacket.Packet) *TLSInfo {
+	if pk.TransportLayer() == nil {
+		return nil
+	}
+	return ParseTLSInfo(pk.TransportLayer().LayerPayload())
+}
@@ -0,0 +1,112 @@
+package match
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/suricata/generate"
+	"github.com/yaklang/yaklang/common/suricata/rule"
+)
+
+// client hello of www.yaklang.com
+const testClientHello = `16030101f6010001f2030348125381d97807a47c6904b372c7a5299d0af761af513e8f26a84bcbddaab12220a860c121b41e59963500b04b0b56153f5a5a20ae91a2e442cf89b93a4fc2d04c003e130213031301c02cc030009fcca9cca8ccaac02bc02f009ec024c028006bc023c0270067c00ac0140039c009c0130033009d009c003d003c0035002f00ff0100016b00000014001200000f7777772e79616b6c616e672e636f6d000b000403000102000a000c000a001d0017001e00190018002300c0863e514bba3e3be7a1721e6e24f223fecdb65a7c81c8f1da6890bcaa0784293796e31accdddb89718dc05f0d86a81c95ebc713024fa71a762b93d9b75a8f54fc29f1a292637fb3a4b8c00b2c51f5c7cecae08fd250ddbcfe93db751e11152015e3c269482030bb71e53e847aa65a46b9c13aab63e38d676e324aa47d79e0378720cd87e9a7fe349921cda037c2f10a3051d0c478cb19e94663167e650b92a2a479d6e33f7c735a63964056e47b66b5f1d6195072cdcb1935457f8976b756a0230016000000170000000d0030002e040305030603080708080809080a080b080408050806040105010601030302030301020103020202040205020602002b000706030403030302002d00020101003300260024001d0020183a409f1c7d85c1ba764861fee43fe08aa687af361b6c415b5eb796b169d351`
+
+func buildPayloadFrame(t *testing.T, dstPort int, payload []byte) []byte {
+	raw, err := pcapx.PacketBuilder(
+		pcapx.WithEthernet_NextLayerType("ip"),
+		pcapx.WithEthernet_SrcMac("00:00:00:00:00:00"),
+		pcapx.WithEthernet_DstMac("00:00:00:00:00:00"),
+		pcapx.WithIPv4_SrcIP("192.168.1.2"),
+		pcapx.WithIPv4_DstIP("192.168.1.3"),
+		pcapx.WithTCP_SrcPort(45678),
+		pcapx.WithTCP_DstPort(dstPort),
+		pcapx.WithTCP_Flags("psh|ack"),
+		pcapx.WithPayload(payload),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestParseTLSInfo(t *testing.T) {
+	payload, _ := hex.DecodeString(testClientHello)
+	info := ParseTLSInfo(payload)
+	if info == nil || !info.ClientHello {
+		t.Fatal("parse client hello failed")
+	}
+	if info.SNI != "www.yaklang.com" || info.Version != 0x0304 {
+		t.Fatalf("client hello error: %v %x", info.SNI, info.Version)
+	}
+	if info.JA3Hash() != "40adfd923eb82b89d8836ba37a19bca1" {
+		t.Fatalf("ja3 error: %v", info.JA3)
+	}
+	if ParseTLSInfo([]byte("GET / HTTP/1.1\r\n\r\n")) != nil {
+		t.Fatal("http should not be parsed as tls")
+	}
+}
+
+func TestTLSStickyBuffers(t *testing.T) {
+	payload, _ := hex.DecodeString(testClientHello)
+	frame := buildPayloadFrame(t, 443, payload)
+
+	for _, c := range []struct {
+		rule  string
+		match bool
+	}{
+		{`alert tls any any -> any any (msg:"sni"; tls.sni; content:"yaklang.com"; endswith; sid:1;)`, true},
+		{`alert tls any any -> any any (msg:"sni"; tls.sni; content:"example.com"; sid:1;)`, false},
+		{`alert tls any any -> any any (msg:"ja3"; ja3.hash; content:"40adfd923eb82b89d8836ba37a19bca1"; sid:1;)`, true},
+		{`alert tcp any any -> any any (msg:"ja3 in tcp"; ja3.hash; content:"40adfd923eb82b89d8836ba37a19bca1"; sid:1;)`, true},
+		{`alert tls any any -> any any (msg:"version"; tls.version:1.3; sid:1;)`, true},
+		{`alert tls any any -> any any (msg:"version"; tls.version:1.0; sid:1;)`, false},
+		{`alert ssh any any -> any any (msg:"ssh"; ssh.proto; content:"2.0"; sid:1;)`, false},
+	} {
+		rs, err := rule.Parse(c.rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := New(rs[0]).Match(frame); got != c.match {
+			t.Fatalf("rule %v: %v(got) != %v(want)", c.rule, got, c.match)
+		}
+	}
+}
+
+func TestSSHStickyBuffers(t *testing.T) {
+	banner := ParseSSHBanner([]byte("SSH-2.0-OpenSSH_8.9p1 Ubuntu-3ubuntu0.1\r\n"))
+	if banner == nil || banner.Proto != "2.0" || banner.Software != "OpenSSH_8.9p1" {
+		t.Fatalf("ssh banner error: %v", banner)
+	}
+
+	frame := buildPayloadFrame(t, 22, []byte("SSH-2.0-libssh_0.9.6\r\n"))
+	rs, err := rule.Parse(`alert ssh any any -> any any (msg:"libssh"; ssh.proto; content:"2.0"; ssh.software; content:"libssh"; startswith; sid:1;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !New(rs[0]).Match(frame) {
+		t.Fatal("ssh rule should match")
+	}
+}
+
+func TestTLSGenerate(t *testing.T) {
+	for _, raw := range []string{
+		`alert tls any any -> any 443 (msg:"sni"; tls.sni; content:"evil.example.com"; tls.version:1.3; sid:1;)`,
+		`alert tls any any -> any any (msg:"ja3"; ja3.string; content:"771,4865-4866-4867-49195,0-23-65281-10-11-35-16-5-13-18-51-45-43-27-17513,29-23-24,0"; sid:2;)`,
+		`alert ssh any any -> any any (msg:"ssh"; ssh.software; content:"libssh"; sid:3;)`,
+	} {
+		rs, err := rule.Parse(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gen, err := generate.New(rs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 5; i++ {
+			if !New(rs[0]).Match(gen.Gen()) {
+				t.Fatalf("generated traffic not match: %v", raw)
+			}
+		}
+	}
+}
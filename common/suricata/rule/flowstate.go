@@ -0,0 +1,179 @@
+package rule
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// FlowBitsRule e.g. flowbits:set,name / flowbits:isset,a|b / flowbits:isnotset,a&b
+type FlowBitsRule struct {
+	// set / unset / toggle / isset / isnotset
+	Command string
+	Names   []string
+	// Or is true if the names are joined by '|', otherwise all of them are required
+	Or bool
+}
+
+func (f *FlowBitsRule) IsCondition() bool {
+	return f.Command == "isset" || f.Command == "isnotset"
+}
+
+// FlowIntRule e.g. flowint:name,+,1 / flowint:name,>,10 / flowint:name,isset
+type FlowIntRule struct {
+	Name string
+	// = + - == != < > <= >= isset notset
+	Modifier string
+	// Value is a number or the name of another flowint
+	Value string
+}
+
+func (f *FlowIntRule) IsCondition() bool {
+	switch f.Modifier {
+	case "=", "+", "-":
+		return false
+	}
+	return true
+}
+
+// XBitsRule e.g. xbits:set,name,track ip_src,expire 60
+type XBitsRule struct {
+	// set / unset / toggle / isset / isnotset
+	Command string
+	Name    string
+	// ip_src / ip_dst / ip_pair
+	Track  string
+	Expire int
+}
+
+func (x *XBitsRule) IsCondition() bool {
+	return x.Command == "isset" || x.Command == "isnotset"
+}
+
+func splitParams(vStr string) []string {
+	var ret []string
+	for _, item := range strings.Split(vStr, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// ParseFlowBits parse flowbits setting, noalert is returned as the command without names
+func ParseFlowBits(vStr string) (*FlowBitsRule, error) {
+	items := splitParams(vStr)
+	if len(items) <= 0 {
+		return nil, utils.Error("empty flowbits")
+	}
+	ret := &FlowBitsRule{Command: strings.ToLower(items[0])}
+	switch ret.Command {
+	case "noalert":
+		return ret, nil
+	case "set", "unset", "toggle", "isset", "isnotset":
+	default:
+		return nil, utils.Errorf("unknown flowbits command: %v", items[0])
+	}
+	if len(items) != 2 {
+		return nil, utils.Errorf("flowbits %v need a name", ret.Command)
+	}
+	sep := "&"
+	if strings.Contains(items[1], "|") {
+		ret.Or = true
+		sep = "|"
+	}
+	for _, name := range strings.Split(items[1], sep) {
+		if name = strings.TrimSpace(name); name != "" {
+			ret.Names = append(ret.Names, name)
+		}
+	}
+	if len(ret.Names) <= 0 {
+		return nil, utils.Errorf("flowbits %v need a name", ret.Command)
+	}
+	return ret, nil
+}
+
+// ParseFlowInt parse flowint setting
+func ParseFlowInt(vStr string) (*FlowIntRule, error) {
+	items := splitParams(vStr)
+	if len(items) < 2 {
+		return nil, utils.Errorf("invalid flowint: %v", vStr)
+	}
+	ret := &FlowIntRule{Name: items[0], Modifier: strings.ToLower(items[1])}
+	switch ret.Modifier {
+	case "isset", "notset", "isnotset":
+		if ret.Modifier == "isnotset" {
+			ret.Modifier = "notset"
+		}
+		return ret, nil
+	case "=", "+", "-", "==", "!=", "<", ">", "<=", ">=":
+	default:
+		return nil, utils.Errorf("unknown flowint modifier: %v", items[1])
+	}
+	if len(items) != 3 {
+		return nil, utils.Errorf("flowint %v need a value", ret.Modifier)
+	}
+	ret.Value = items[2]
+	return ret, nil
+}
+
+// ParseXBits parse xbits setting
+func ParseXBits(vStr string) (*XBitsRule, error) {
+	items := splitParams(vStr)
+	if len(items) <= 0 {
+		return nil, utils.Error("empty xbits")
+	}
+	ret := &XBitsRule{Command: strings.ToLower(items[0])}
+	switch ret.Command {
+	case "noalert":
+		return ret, nil
+	case "set", "unset", "toggle", "isset", "isnotset":
+	default:
+		return nil, utils.Errorf("unknown xbits command: %v", items[0])
+	}
+	if len(items) < 3 {
+		return nil, utils.Errorf("xbits %v need a name and track", ret.Command)
+	}
+	ret.Name = items[1]
+	for _, item := range items[2:] {
+		k, v, _ := strings.Cut(item, " ")
+		v = strings.TrimSpace(v)
+		switch strings.ToLower(k) {
+		case "track":
+			ret.Track = strings.ToLower(v)
+		case "expire":
+			expire, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, utils.Errorf("invalid xbits expire: %v", v)
+			}
+			ret.Expire = expire
+		}
+	}
+	switch ret.Track {
+	case "ip_src", "ip_dst", "ip_pair":
+	default:
+		return nil, utils.Errorf("invalid xbits track: %v", ret.Track)
+	}
+	return ret, nil
+}
+
+// parseFlowRule parse flow setting, e.g. established,to_server
+func parseFlowRule(vStr string) *FlowRule {
+	ret := &FlowRule{}
+	for _, item := range splitParams(strings.ToLower(vStr)) {
+		switch item {
+		case "to_client", "from_server":
+			ret.ToClient = true
+		case "to_server", "from_client":
+			ret.ToServer = true
+		case "established":
+			ret.Established = true
+		case "not_established":
+			ret.NotEstablished = true
+		case "stateless":
+			ret.Stateless = true
+		}
+	}
+	return ret
+}
@@ -1,6 +1,12 @@
 package rule
 
-import "github.com/yaklang/yaklang/common/suricata/data/numrange"
+import (
+	"strconv"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/suricata/data/numrange"
+	"github.com/yaklang/yaklang/common/utils"
+)
 
 type ICMPLayerRule struct {
 	IType     *numrange.NumRange // itype
@@ -40,6 +46,33 @@ type DNSRule struct {
 	Opcode         int
 }
 
+type TLSRule struct {
+	// Version e.g. 0x0303 for tls.version:1.2
+	Version uint16
+}
+
+// ParseTLSVersion parse tls.version setting, e.g. 1.2 / ssl3 / 0x0303
+func ParseTLSVersion(vStr string) (uint16, error) {
+	switch v := strings.ToLower(strings.TrimSpace(vStr)); v {
+	case "ssl3", "3.0":
+		return 0x0300, nil
+	case "1.0", "tls1.0":
+		return 0x0301, nil
+	case "1.1", "tls1.1":
+		return 0x0302, nil
+	case "1.2", "tls1.2":
+		return 0x0303, nil
+	case "1.3", "tls1.3":
+		return 0x0304, nil
+	default:
+		version, err := strconv.ParseUint(v, 0, 16)
+		if err != nil {
+			return 0, utils.Errorf("unknown tls version: %v", vStr)
+		}
+		return uint16(version), nil
+	}
+}
+
 type HTTPConfig struct {
 	// deprecated and not implemented
 	Uricontent string
@@ -35,6 +35,9 @@ type ContentRuleConfig struct {
 	/* DNS Config*/
 	DNS *DNSRule
 
+	/* TLS Config */
+	TLS *TLSRule
+
 	/* HTTP Config */
 	HTTPConfig *HTTPConfig
 
@@ -53,15 +56,24 @@ type ContentRuleConfig struct {
 	/* Payload Match */
 	ContentRules []*ContentRule
 
+	/* Flow State */
+	FlowBits []*FlowBitsRule
+	FlowInts []*FlowIntRule
+	XBits    []*XBitsRule
+	// NoAlert is set by noalert / flowbits:noalert, the rule only update the flow state
+	NoAlert bool
+
 	// PrefilterRule is a contentRuleConfig with no more than single config.
 	// not implement yet
 	PrefilterRule *ContentRuleConfig
 }
 
 type FlowRule struct {
-	ToClient    bool
-	Established bool
-	ToServer    bool
+	ToClient       bool
+	Established    bool
+	ToServer       bool
+	NotEstablished bool
+	Stateless      bool
 }
 
 type ContentRule struct {
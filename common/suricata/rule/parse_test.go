@@ -183,3 +183,39 @@ func TestMUSTPASS_Parse_AddrSyntaxAndMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestParseFlowState(t *testing.T) {
+	rules, err := Parse(`alert http any any -> any any (msg:"request"; flow:not_established,from_client; flowbits:isset,a|b; flowbits:set,c; flowbits:noalert; flowint:count,+,1; xbits:set,scan,track ip_pair,expire 60; threshold: type both, track by_src, count 5, seconds 60; sid:1;)
+alert tcp any any -> any any (msg:"filter"; flowint:count,!=,3; detection_filter:track by_dst, count 10, seconds 30; sid:2;)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("rules length error: %d", len(rules))
+	}
+
+	cfg := rules[0].ContentRuleConfig
+	if !cfg.Flow.NotEstablished || cfg.Flow.Established || !cfg.Flow.ToServer {
+		t.Fatalf("flow error: %+v", cfg.Flow)
+	}
+	if len(cfg.FlowBits) != 2 || !cfg.FlowBits[0].Or || len(cfg.FlowBits[0].Names) != 2 || cfg.FlowBits[1].Command != "set" || !cfg.NoAlert {
+		t.Fatalf("flowbits error: %v %v", cfg.FlowBits, cfg.NoAlert)
+	}
+	if len(cfg.FlowInts) != 1 || cfg.FlowInts[0].Modifier != "+" || cfg.FlowInts[0].Value != "1" {
+		t.Fatalf("flowint error: %v", cfg.FlowInts)
+	}
+	if len(cfg.XBits) != 1 || cfg.XBits[0].Track != "ip_pair" || cfg.XBits[0].Expire != 60 {
+		t.Fatalf("xbits error: %v", cfg.XBits)
+	}
+	if th := cfg.Thresholding; !th.ThresholdMode || !th.LimitMode || th.Track != "by_src" || th.Count != 5 || th.Seconds != 60 {
+		t.Fatalf("threshold error: %+v", th)
+	}
+
+	cfg = rules[1].ContentRuleConfig
+	if len(cfg.FlowInts) != 1 || cfg.FlowInts[0].Modifier != "!=" || cfg.FlowInts[0].Value != "3" {
+		t.Fatalf("flowint error: %v", cfg.FlowInts)
+	}
+	if th := cfg.Thresholding; !th.DetectionFilter || th.Track != "by_dst" || th.Count != 10 || th.Seconds != 30 {
+		t.Fatalf("detection_filter error: %+v", th)
+	}
+}
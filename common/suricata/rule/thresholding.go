@@ -1,11 +1,16 @@
 package rule
 
+import "strings"
+
 type ThresholdingConfig struct {
 	ThresholdMode bool
 	LimitMode     bool
-	Count         int
-	Seconds       int
-	Track         string
+	// DetectionFilter is set by detection_filter, alert on every hit after the count is reached
+	DetectionFilter bool
+	Count           int
+	Seconds         int
+	// by_src / by_dst / by_rule / by_both
+	Track string
 }
 
 func (t *ThresholdingConfig) Repeat() int {
@@ -19,3 +24,25 @@ func (t *ThresholdingConfig) Repeat() int {
 
 	return 1
 }
+
+// parseThresholding parse threshold / detection_filter setting,
+// e.g. type limit, track by_src, count 1, seconds 60
+func parseThresholding(params map[string]string, detectionFilter bool) *ThresholdingConfig {
+	config := &ThresholdingConfig{DetectionFilter: detectionFilter}
+	config.Count = atoi(params["count"])
+	config.Seconds = atoi(params["seconds"])
+	config.Track = strings.ToLower(params["track"])
+	if detectionFilter {
+		return config
+	}
+	switch strings.ToLower(params["type"]) {
+	case "both":
+		config.ThresholdMode = true
+		config.LimitMode = true
+	case "threshold":
+		config.ThresholdMode = true
+	case "limit":
+		config.LimitMode = true
+	}
+	return config
+}
@@ -7,7 +7,6 @@ import (
 	"github.com/yaklang/yaklang/common/suricata/data/numrange"
 	"github.com/yaklang/yaklang/common/suricata/parser"
 	"github.com/yaklang/yaklang/common/suricata/pcre"
-	"github.com/yaklang/yaklang/common/utils"
 	"strconv"
 	"strings"
 )
@@ -76,6 +75,24 @@ func modifierMapping(str string) modifier.Modifier {
 		return modifier.IPv6HDR
 	case "tcp.hdr", "tcp_hdr":
 		return modifier.TCPHDR
+	case "tls.sni", "tls_sni":
+		return modifier.TLSSNI
+	case "tls.cert_subject", "tls_cert_subject":
+		return modifier.TLSCertSubject
+	case "tls.cert_issuer", "tls_cert_issuer":
+		return modifier.TLSCertIssuer
+	case "ja3.hash", "ja3_hash":
+		return modifier.JA3Hash
+	case "ja3.string", "ja3_string":
+		return modifier.JA3String
+	case "ja3s.hash", "ja3s_hash":
+		return modifier.JA3SHash
+	case "ja3s.string", "ja3s_string":
+		return modifier.JA3SString
+	case "ssh.proto", "ssh_proto":
+		return modifier.SSHProto
+	case "ssh.software", "ssh_software":
+		return modifier.SSHSoftware
 	}
 	return modifier.Default
 }
@@ -92,10 +109,11 @@ type MultipleBufferMatching struct {
 }
 
 func (m *MultipleBufferMatching) transfer(mdf modifier.Modifier) modifier.Modifier {
-	switch mdf {
-	case modifier.DNSQuery, modifier.FileData, modifier.HTTPHeader:
+	switch {
+	case mdf == modifier.DNSQuery, mdf == modifier.FileData, mdf == modifier.HTTPHeader,
+		modifier.IsTLSModifier(mdf), modifier.IsSSHModifier(mdf):
 		m.last = mdf
-	case modifier.Default:
+	case mdf == modifier.Default:
 		mdf = m.last
 	default:
 		m.last = modifier.Default
@@ -131,12 +149,18 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 		var setting *parser.SettingContext
 		var ssts []parser.ISingleSettingContext
 		var vStr string
-		vParams := make(map[string]interface{})
+		vParams := make(map[string]string)
 
 		if st := paramctx.Setting(); st != nil {
 			setting = paramctx.Setting().(*parser.SettingContext)
 			vStr = setting.GetText()
 			ssts = setting.AllSingleSetting()
+			// e.g. track by_src, count 5
+			for _, sst := range ssts {
+				if k, v, ok := strings.Cut(strings.TrimSpace(sst.GetText()), " "); ok {
+					vParams[strings.ToLower(k)] = strings.TrimSpace(v)
+				}
+			}
 		}
 
 		switch STATUS {
@@ -243,14 +267,19 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 				OpcodeNegative: neg,
 				Opcode:         atoi(content),
 			}
+		case "tls.version":
+			version, err := ParseTLSVersion(vStr)
+			if err != nil {
+				log.Errorf("parse tls.version err:%v", err)
+				continue
+			}
+			if rule.ContentRuleConfig.TLS == nil {
+				rule.ContentRuleConfig.TLS = &TLSRule{}
+			}
+			rule.ContentRuleConfig.TLS.Version = version
 		case "flow":
 			if rule.ContentRuleConfig.Flow == nil {
-				lvstr := strings.ToLower(vStr)
-				rule.ContentRuleConfig.Flow = &FlowRule{
-					ToClient:    strings.Contains(lvstr, "to_client"),
-					Established: strings.Contains(lvstr, "established"),
-					ToServer:    strings.Contains(lvstr, "to_server"),
-				}
+				rule.ContentRuleConfig.Flow = parseFlowRule(vStr)
 			}
 		case "ttl":
 			if rule.ContentRuleConfig.IPConfig == nil {
@@ -343,20 +372,9 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 			window := atoi(content)
 			rule.ContentRuleConfig.TcpConfig.NegativeWindow, rule.ContentRuleConfig.TcpConfig.Window = neg, &window
 		case "threshold":
-			config := &ThresholdingConfig{}
-			config.Count = atoi(utils.MapGetString(vParams, "count"))
-			config.Track = utils.MapGetString(vParams, "track")
-			switch utils.MapGetString(vParams, "type") {
-			case "both":
-				config.ThresholdMode = true
-				config.LimitMode = true
-			case "threshold":
-				config.ThresholdMode = true
-			case "limit":
-				config.LimitMode = true
-			}
-			config.Seconds = atoi(utils.MapGetString(vParams, "seconds"))
-			rule.ContentRuleConfig.Thresholding = config
+			rule.ContentRuleConfig.Thresholding = parseThresholding(vParams, false)
+		case "detection_filter":
+			rule.ContentRuleConfig.Thresholding = parseThresholding(vParams, true)
 		case "icode":
 			/*
 				icode:min<>max;
@@ -465,16 +483,44 @@ func (v *RuleSyntaxVisitor) VisitParams(i *parser.ParamsContext, rule *Rule) {
 			contentRule.FastPattern = true
 		case "flowbits":
 			contentRule.FlowBits = vStr
+			flowBits, err := ParseFlowBits(vStr)
+			if err != nil {
+				log.Errorf("parse flowbits err:%v", err)
+				continue
+			}
+			if flowBits.Command == "noalert" {
+				contentRule.NoAlert = true
+				rule.ContentRuleConfig.NoAlert = true
+				continue
+			}
+			rule.ContentRuleConfig.FlowBits = append(rule.ContentRuleConfig.FlowBits, flowBits)
 		case "noalert":
 			contentRule.NoAlert = true
+			rule.ContentRuleConfig.NoAlert = true
 		case "base64_decode":
 			contentRule.Base64Decode = vStr
 		case "base64_data":
 			contentRule.Base64Data = true
 		case "flowint":
 			contentRule.FlowInt = vStr
+			flowInt, err := ParseFlowInt(vStr)
+			if err != nil {
+				log.Errorf("parse flowint err:%v", err)
+				continue
+			}
+			rule.ContentRuleConfig.FlowInts = append(rule.ContentRuleConfig.FlowInts, flowInt)
 		case "xbits":
 			contentRule.XBits = vStr
+			xBits, err := ParseXBits(vStr)
+			if err != nil {
+				log.Errorf("parse xbits err:%v", err)
+				continue
+			}
+			if xBits.Command == "noalert" {
+				rule.ContentRuleConfig.NoAlert = true
+				continue
+			}
+			rule.ContentRuleConfig.XBits = append(rule.ContentRuleConfig.XBits, xBits)
 		case "app-layer-event":
 			contentRule.ExtraFlags = append(contentRule.ExtraFlags, fmt.Sprintf("%v:%v", key, vStr))
 		default:
@@ -15,7 +15,6 @@ import (
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 	"github.com/pkg/errors"
 )
 
@@ -26,9 +25,9 @@ type Scanner struct {
 	config *Config
 
 	handlerWriteChan      chan []byte
-	handler               *pcap.Handle
+	handler               pcaputil.Handle
 	localHandlerWriteChan chan []byte
-	localHandler          *pcap.Handle
+	localHandler          pcaputil.Handle
 
 	opts gopacket.SerializeOptions
 
@@ -191,7 +190,7 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 	// initializes the local port, used to scan the local loopback address
 	log.Debug("start to create local network dev")
 	var localIfaceName string
-	devs, err := pcap.FindAllDevs()
+	devs, err := pcaputil.FindAllDevices()
 	if err != nil {
 		return nil, utils.Errorf("cannot find pcap ifaceDevs: %v", err)
 	}
@@ -201,12 +200,12 @@ func NewScanner(ctx context.Context, config *Config) (*Scanner, error) {
 DEVICE: %v
 DESC: %v
 FLAGS: %v
-`, d.Name, d.Description, net.Flags(d.Flags).String())
+`, d.Name, d.Description, d.Flags.String())
 		})
 
 		// Get the address loopback first
 		for _, addr := range d.Addresses {
-			if addr.IP.IsLoopback() {
+			if addr.IsLoopback() {
 				localIfaceName = d.Name
 				log.Debugf("fetch loopback by addr: %v", d.Name)
 				break
@@ -224,7 +223,7 @@ FLAGS: %v
 		}
 
 		// Get flags
-		if net.Flags(uint(d.Flags))&net.FlagLoopback == 1 {
+		if d.Flags&net.FlagLoopback != 0 {
 			log.Infof("found loopback by flag: %v", d.Name)
 			localIfaceName = d.Name
 			break
@@ -236,13 +235,13 @@ FLAGS: %v
 	if isLoopback {
 		ifaceName = localIfaceName
 	}
-	handler, err := pcap.OpenLive(ifaceName, 65535, false, pcap.BlockForever)
+	handler, err := pcaputil.OpenLive(ifaceName, 65535, false)
 	if err != nil {
 		return nil, errors.Errorf("open device[%v-%v] failed: %s", iface.Name, strconv.QuoteToASCII(iface.Name), err)
 	}
 
 	log.Infof("fetch local loopback pcapDev:[%v]", localIfaceName)
-	localHandler, err := pcap.OpenLive(localIfaceName, 65535, false, pcap.BlockForever)
+	localHandler, err := pcaputil.OpenLive(localIfaceName, 65535, false)
 	if err != nil {
 		return nil, utils.Errorf("open local iface failed: %s", err)
 	}
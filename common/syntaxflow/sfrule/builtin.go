@@ -0,0 +1,53 @@
+package sfrule
+
+import (
+	"embed"
+	"path"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/log"
+)
+
+//go:embed rules/*.yaml
+var builtinRuleFS embed.FS
+
+var (
+	builtinRulesOnce sync.Once
+	builtinRules     []*Rule
+)
+
+func loadBuiltinRules() {
+	entries, err := builtinRuleFS.ReadDir("rules")
+	if err != nil {
+		log.Errorf("read builtin syntaxflow rules failed: %s", err)
+		return
+	}
+	for _, entry := range entries {
+		raw, err := builtinRuleFS.ReadFile(path.Join("rules", entry.Name()))
+		if err != nil {
+			log.Errorf("read builtin syntaxflow rule %v failed: %s", entry.Name(), err)
+			continue
+		}
+		rules, err := ParseRule(raw)
+		if err != nil {
+			log.Errorf("load builtin syntaxflow rule %v failed: %s", entry.Name(), err)
+			continue
+		}
+		builtinRules = append(builtinRules, rules...)
+	}
+}
+
+// GetBuiltinRules return the starter rules for yak / js / php, filter by language if it is not empty
+func GetBuiltinRules(language ...string) []*Rule {
+	builtinRulesOnce.Do(loadBuiltinRules)
+	if len(language) <= 0 || language[0] == "" {
+		return builtinRules
+	}
+	var ret []*Rule
+	for _, rule := range builtinRules {
+		if rule.MatchLanguage(language[0]) {
+			ret = append(ret, rule)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,136 @@
+package sfrule
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/syntaxflow/sfvm"
+	"github.com/yaklang/yaklang/common/utils"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+Rule describe a taint flow: the source values flow to the sink arguments,
+the flow is safe when it passes through any of the sanitizers.
+
+sources / sinks / sanitizers are SyntaxFlow filter expressions, the runner
+evaluates them on the symbol table of ssaapi.Program, e.g.
+
+	id: php-command-injection
+	language: php
+	severity: high
+	sources: [_GET, _POST]
+	sinks: [system, exec, shell_exec]
+	sanitizers: [escapeshellarg]
+
+the `$` of php variables is trimmed, and `a.b` means the member `b` of `a`.
+*/
+type Rule struct {
+	Id          string   `yaml:"id" json:"id"`
+	Name        string   `yaml:"name" json:"name"`
+	Language    string   `yaml:"language" json:"language"`
+	Severity    string   `yaml:"severity" json:"severity"`
+	CWE         []string `yaml:"cwe" json:"cwe"`
+	Description string   `yaml:"description" json:"description"`
+	Solution    string   `yaml:"solution" json:"solution"`
+
+	Sources    []string `yaml:"sources" json:"sources"`
+	Sinks      []string `yaml:"sinks" json:"sinks"`
+	Sanitizers []string `yaml:"sanitizers" json:"sanitizers"`
+}
+
+// ParseRule load rule from yaml, a rule set (yaml list) is also supported
+func ParseRule(raw []byte) ([]*Rule, error) {
+	var rules []*Rule
+	if err := yaml.Unmarshal(raw, &rules); err != nil {
+		var rule Rule
+		if err := yaml.Unmarshal(raw, &rule); err != nil {
+			return nil, utils.Errorf("parse syntaxflow rule failed: %s", err)
+		}
+		rules = []*Rule{&rule}
+	}
+	for _, rule := range rules {
+		if err := rule.Verify(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// LoadRulesFromDir load all rules (*.yaml / *.yml) in dir
+func LoadRulesFromDir(dir string) ([]*Rule, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf("walk rule dir %v failed: %s", dir, err)
+	}
+	sort.Strings(files)
+
+	var rules []*Rule
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			return nil, utils.Errorf("read rule %v failed: %s", file, err)
+		}
+		results, err := ParseRule(raw)
+		if err != nil {
+			return nil, utils.Errorf("load rule %v failed: %s", file, err)
+		}
+		rules = append(rules, results...)
+	}
+	return rules, nil
+}
+
+// Verify check the required fields and compile all expressions
+func (r *Rule) Verify() error {
+	if r == nil {
+		return utils.Error("empty syntaxflow rule")
+	}
+	if r.Id == "" {
+		return utils.Error("syntaxflow rule id is required")
+	}
+	if len(r.Sources) <= 0 || len(r.Sinks) <= 0 {
+		return utils.Errorf("rule[%v] need sources and sinks", r.Id)
+	}
+	if r.Name == "" {
+		r.Name = r.Id
+	}
+	if r.Severity == "" {
+		r.Severity = "medium"
+	}
+	for _, exprs := range [][]string{r.Sources, r.Sinks, r.Sanitizers} {
+		for _, expr := range exprs {
+			if err := sfvm.NewSyntaxFlowVirtualMachine().Compile(outputExpr(expr)); err != nil {
+				return utils.Errorf("rule[%v] compile `%v` failed: %s", r.Id, expr, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchLanguage check the rule can be used for language, empty language means all
+func (r *Rule) MatchLanguage(language string) bool {
+	if r.Language == "" || language == "" {
+		return true
+	}
+	for _, l := range utils.PrettifyListFromStringSplitEx(r.Language, ",", "|") {
+		if strings.EqualFold(l, language) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package sfrule
+
+import (
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/ssaapi"
+)
+
+// FlowNode is one step of the data flow
+type FlowNode struct {
+	Id       int    `json:"id"`
+	Opcode   string `json:"opcode"`
+	Code     string `json:"code"`
+	Function string `json:"function"`
+	// File is the relative path of the node when the program is parsed as project
+	File string `json:"file,omitempty"`
+
+	// Source is the source code of the value
+	Source      string `json:"source"`
+	StartLine   int64  `json:"start_line"`
+	StartColumn int64  `json:"start_column"`
+	EndLine     int64  `json:"end_line"`
+	EndColumn   int64  `json:"end_column"`
+	StartOffset int64  `json:"start_offset"`
+	EndOffset   int64  `json:"end_offset"`
+
+	Value *ssaapi.Value `json:"-"`
+}
+
+func NewFlowNode(v *ssaapi.Value) *FlowNode {
+	node := &FlowNode{
+		Id:     v.GetId(),
+		Opcode: string(v.GetOpcode()),
+		Code:   v.String(),
+		Value:  v,
+	}
+	if fn := ssaapi.GetBareNode(v).GetFunc(); fn != nil {
+		node.Function = fn.GetName()
+	}
+	if r := v.GetRange(); r != nil {
+		node.File = r.FileName
+		if r.SourceCode != nil {
+			node.Source = *r.SourceCode
+		}
+		if r.Start != nil {
+			node.StartLine, node.StartColumn, node.StartOffset = r.Start.Line, r.Start.Column, r.Start.Offset
+		}
+		if r.End != nil {
+			node.EndLine, node.EndColumn, node.EndOffset = r.End.Line, r.End.Column, r.End.Offset
+		}
+	}
+	return node
+}
+
+func (n *FlowNode) String() string {
+	return fmt.Sprintf("%v:%v %v", n.StartLine, n.StartColumn, n.Code)
+}
+
+type Finding struct {
+	RuleId      string   `json:"rule_id"`
+	RuleName    string   `json:"rule_name"`
+	Severity    string   `json:"severity"`
+	CWE         []string `json:"cwe"`
+	Description string   `json:"description"`
+	Solution    string   `json:"solution"`
+	Language    string   `json:"language"`
+
+	Source *FlowNode `json:"source"`
+	Sink   *FlowNode `json:"sink"`
+	// Path is the data flow from source to sink
+	Path []*FlowNode `json:"path"`
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("[%v] %v: %v -> %v", f.Severity, f.RuleId, f.Source, f.Sink)
+}
+
+type Config struct {
+	maxDepth int
+	callback func(*Finding)
+}
+
+type Option func(*Config)
+
+// WithMaxDepth limit the length of data flow path, default is 128
+func WithMaxDepth(i int) Option {
+	return func(config *Config) {
+		config.maxDepth = i
+	}
+}
+
+// WithFindingCallback will be called when a finding is found
+func WithFindingCallback(f func(*Finding)) Option {
+	return func(config *Config) {
+		config.callback = f
+	}
+}
+
+func NewConfig(opts ...Option) *Config {
+	c := &Config{maxDepth: 128}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run evaluate the rules on program, the rules for other languages are skipped
+func Run(prog *ssaapi.Program, rules []*Rule, opts ...Option) ([]*Finding, error) {
+	if prog == nil || prog.IsNil() {
+		return nil, utils.Error("empty ssa program")
+	}
+	config := NewConfig(opts...)
+	language := string(prog.GetLanguage())
+	table := newSymbolTable(prog)
+
+	var findings []*Finding
+	for _, rule := range rules {
+		if rule == nil || !rule.MatchLanguage(language) {
+			continue
+		}
+		results, err := runRule(table, rule, config)
+		if err != nil {
+			log.Warnf("run syntaxflow rule[%v] failed: %s", rule.Id, err)
+			continue
+		}
+		for _, finding := range results {
+			finding.Language = language
+			if config.callback != nil {
+				config.callback(finding)
+			}
+		}
+		findings = append(findings, results...)
+	}
+	return findings, nil
+}
+
+func queryAll(table *symbolTable, exprs []string) (ssaapi.Values, error) {
+	var ret ssaapi.Values
+	for _, expr := range exprs {
+		values, err := table.Query(expr)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, values...)
+	}
+	return ret, nil
+}
+
+func runRule(table *symbolTable, rule *Rule, config *Config) ([]*Finding, error) {
+	sources, err := queryAll(table, rule.Sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(sources) <= 0 {
+		return nil, nil
+	}
+	sinks, err := queryAll(table, rule.Sinks)
+	if err != nil {
+		return nil, err
+	}
+	sanitizers, err := queryAll(table, rule.Sanitizers)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []*Finding
+	existed := make(map[string]struct{})
+	for _, sink := range sinks {
+		for _, site := range sinkSites(sink) {
+			tracer := newTaintTracer(sources, sanitizers, config.maxDepth, func(path []*ssaapi.Value) {
+				source := path[len(path)-1]
+				key := fmt.Sprintf("%d-%d", source.GetId(), site.call.GetId())
+				if _, ok := existed[key]; ok {
+					return
+				}
+				existed[key] = struct{}{}
+
+				finding := &Finding{
+					RuleId:      rule.Id,
+					RuleName:    rule.Name,
+					Severity:    rule.Severity,
+					CWE:         rule.CWE,
+					Description: rule.Description,
+					Solution:    rule.Solution,
+					Source:      NewFlowNode(source),
+					Sink:        NewFlowNode(site.call),
+				}
+				for i := len(path) - 1; i >= 0; i-- {
+					finding.Path = append(finding.Path, NewFlowNode(path[i]))
+				}
+				findings = append(findings, finding)
+			})
+			for _, target := range site.targets {
+				tracer.Trace(site.call, target)
+			}
+		}
+	}
+	return findings, nil
+}
+
+type sinkSite struct {
+	// call is the sink call, or the sink itself when it is assigned (e.g. `a.innerHTML = b`)
+	call    *ssaapi.Value
+	targets ssaapi.Values
+}
+
+func sinkSites(sink *ssaapi.Value) []*sinkSite {
+	var sites []*sinkSite
+	for _, call := range sink.GetCalledBy() {
+		if callee := call.GetCallee(); callee == nil || callee.GetId() != sink.GetId() {
+			continue
+		}
+		sites = append(sites, &sinkSite{call: call, targets: call.GetCallArgs()})
+	}
+	if len(sites) > 0 {
+		return sites
+	}
+	if sink.IsField() {
+		var targets ssaapi.Values
+		sink.GetUsers().ForEach(func(user *ssaapi.Value) {
+			if user.IsUpdate() && user.GetOperand(1) != nil {
+				targets = append(targets, user.GetOperand(1))
+			}
+		})
+		if len(targets) > 0 {
+			sites = append(sites, &sinkSite{call: sink, targets: targets})
+		}
+	}
+	return sites
+}
@@ -0,0 +1,167 @@
+package sfrule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/yak/ssaapi"
+)
+
+func runBuiltin(t *testing.T, code string, language ssaapi.Language) []*Finding {
+	prog, err := ssaapi.Parse(code, ssaapi.WithLanguage(language))
+	require.NoError(t, err)
+	findings, err := Run(prog, GetBuiltinRules())
+	require.NoError(t, err)
+	for _, f := range findings {
+		t.Log(f.String())
+		for _, node := range f.Path {
+			t.Logf("\t%v", node)
+		}
+	}
+	return findings
+}
+
+func findRule(findings []*Finding, id string) *Finding {
+	for _, f := range findings {
+		if f.RuleId == id {
+			return f
+		}
+	}
+	return nil
+}
+
+func TestBuiltinRules(t *testing.T) {
+	rules := GetBuiltinRules()
+	require.NotEmpty(t, rules)
+	for _, language := range []string{"yak", "js", "php"} {
+		assert.NotEmptyf(t, GetBuiltinRules(language), "no builtin rule for %v", language)
+	}
+	for _, rule := range rules {
+		assert.NoError(t, rule.Verify())
+	}
+}
+
+func TestParseRule(t *testing.T) {
+	rules, err := ParseRule([]byte(`
+id: test-rule
+language: php
+sources: [_GET]
+sinks: [system]
+`))
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, "test-rule", rules[0].Name)
+	assert.Equal(t, "medium", rules[0].Severity)
+	assert.True(t, rules[0].MatchLanguage("PHP"))
+	assert.False(t, rules[0].MatchLanguage("js"))
+
+	_, err = ParseRule([]byte(`id: no-sink
+sources: [_GET]`))
+	assert.Error(t, err)
+}
+
+func TestPHP_CommandInjection(t *testing.T) {
+	findings := runBuiltin(t, `<?php
+$cmd = $_GET["cmd"];
+system("ls " . $cmd);
+`, ssaapi.PHP)
+	f := findRule(findings, "php-command-injection")
+	require.NotNil(t, f)
+	require.GreaterOrEqual(t, len(f.Path), 2)
+	assert.Equal(t, f.Source.Id, f.Path[0].Id)
+	assert.Equal(t, f.Sink.Id, f.Path[len(f.Path)-1].Id)
+	assert.Equal(t, int64(3), f.Sink.StartLine)
+	assert.Equal(t, "php", f.Language)
+}
+
+func TestPHP_Sanitizer(t *testing.T) {
+	findings := runBuiltin(t, `<?php
+$cmd = escapeshellarg($_GET["cmd"]);
+system("ls " . $cmd);
+`, ssaapi.PHP)
+	assert.Nil(t, findRule(findings, "php-command-injection"))
+}
+
+func TestPHP_InterProcedural(t *testing.T) {
+	findings := runBuiltin(t, `<?php
+function run($c) {
+	return shell_exec($c);
+}
+function wrap($a) {
+	return "ping " . $a;
+}
+run(wrap($_POST["host"]));
+`, ssaapi.PHP)
+	assert.NotNil(t, findRule(findings, "php-command-injection"))
+}
+
+func TestPHP_SQLInjectionAndPathTraversal(t *testing.T) {
+	findings := runBuiltin(t, `<?php
+$arr = array();
+$arr["id"] = $_GET["id"];
+$sql = "select * from users where id=";
+$sql .= $arr["id"];
+mysqli_query($conn, $sql);
+echo file_get_contents("/var/www/" . $_REQUEST["f"]);
+`, ssaapi.PHP)
+	f := findRule(findings, "php-sql-injection")
+	require.NotNil(t, f)
+	assert.Equal(t, int64(6), f.Sink.StartLine)
+	f = findRule(findings, "php-path-traversal")
+	require.NotNil(t, f)
+	assert.Equal(t, int64(7), f.Sink.StartLine)
+}
+
+func TestYak_CommandInjection(t *testing.T) {
+	findings := runBuiltin(t, `
+target = cli.String("target")
+exec.System("ping " + target)
+`, ssaapi.Yak)
+	assert.NotNil(t, findRule(findings, "yak-command-injection"))
+	assert.Nil(t, findRule(findings, "php-command-injection"), "php rules should be skipped for yak")
+}
+
+func TestJS_CodeInjection(t *testing.T) {
+	findings := runBuiltin(t, `
+var a = location.hash;
+eval("x = " + a);
+eval(parseInt(location.search));
+`, ssaapi.JS)
+	f := findRule(findings, "js-code-injection")
+	require.NotNil(t, f)
+	var count int
+	for _, f := range findings {
+		if f.RuleId == "js-code-injection" {
+			count++
+		}
+	}
+	assert.Equal(t, 1, count, "parseInt should sanitize the second eval")
+}
+
+func TestCustomRule(t *testing.T) {
+	rules, err := ParseRule([]byte(`
+id: yak-custom
+language: yak
+sources: [source%]
+sinks: [sink]
+sanitizers: [clean]
+`))
+	require.NoError(t, err)
+	prog, err := ssaapi.Parse(`
+a = sourceA()
+b = sourceB()
+sink(a)
+sink(clean(b))
+`)
+	require.NoError(t, err)
+
+	var called int
+	findings, err := Run(prog, rules, WithFindingCallback(func(f *Finding) {
+		called++
+	}))
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, 1, called)
+	assert.Contains(t, findings[0].Source.Code, "sourceA")
+}
@@ -0,0 +1,164 @@
+package sfrule
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/syntaxflow/sfvm"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/omap"
+	"github.com/yaklang/yaklang/common/yak/ssa"
+	"github.com/yaklang/yaklang/common/yak/ssaapi"
+)
+
+const outputRef = "__sfrule_output__"
+
+func outputExpr(expr string) string {
+	return expr + " => $" + outputRef
+}
+
+// symbolTable is the input of SyntaxFlow, every symbol is a chain of maps: `a.b` => {a: {b: [values...]}},
+// so `a` matches the values of `a` and all of its members, `a.b` matches the member only
+type symbolTable struct {
+	data  *omap.OrderedMap[string, any]
+	cache map[string]ssaapi.Values
+}
+
+func symbolName(name string) []string {
+	var parts []string
+	for _, part := range strings.Split(name, ".") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "$")
+		if part == "" {
+			return nil
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// fieldName build the name of member, `a.b.c` for `$a->b->c` / `a.b.c` / `a["b"]["c"]`
+func fieldName(v ssa.Value, depth int) string {
+	if v == nil || depth > 8 {
+		return ""
+	}
+	field, ok := ssa.ToField(v)
+	if !ok {
+		return v.GetName()
+	}
+	key, ok := ssa.ToConst(field.Key)
+	if !ok {
+		return ""
+	}
+	obj := fieldName(field.Obj, depth+1)
+	name := utils.InterfaceToString(key.GetRawValue())
+	if obj == "" || name == "" {
+		return ""
+	}
+	return obj + "." + name
+}
+
+func newSymbolTable(prog *ssaapi.Program) *symbolTable {
+	symbols := make(map[string]ssaapi.Values)
+	existed := make(map[string]map[int]struct{})
+	add := func(name string, v *ssaapi.Value) {
+		if name == "" || v == nil {
+			return
+		}
+		if _, ok := existed[name]; !ok {
+			existed[name] = make(map[int]struct{})
+		}
+		if _, ok := existed[name][v.GetId()]; ok {
+			return
+		}
+		existed[name][v.GetId()] = struct{}{}
+		symbols[name] = append(symbols[name], v)
+	}
+
+	for name, values := range prog.GetAllSymbols() {
+		for _, v := range values {
+			add(name, v)
+		}
+	}
+	prog.Program.IdToInstructionMap.ForEach(func(id int, inst ssa.Instruction) bool {
+		field, ok := ssa.ToField(inst)
+		if !ok {
+			return true
+		}
+		add(fieldName(field, 0), ssaapi.NewValue(field))
+		return true
+	})
+
+	names := make([]string, 0, len(symbols))
+	for name := range symbols {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	data := omap.NewEmptyOrderedMap[string, any]()
+	for _, name := range names {
+		parts := symbolName(name)
+		if len(parts) <= 0 {
+			continue
+		}
+		var leaf any = valuesToList(symbols[name])
+		for i := len(parts) - 1; i >= 0; i-- {
+			leaf = map[string]any{parts[i]: leaf}
+		}
+		data.Add(leaf)
+	}
+	return &symbolTable{data: data, cache: make(map[string]ssaapi.Values)}
+}
+
+func valuesToList(values ssaapi.Values) []any {
+	ret := make([]any, 0, len(values))
+	for _, v := range values {
+		ret = append(ret, v)
+	}
+	return ret
+}
+
+// Query evaluate the SyntaxFlow expression, return the matched values
+func (s *symbolTable) Query(expr string) (ssaapi.Values, error) {
+	expr = strings.TrimSpace(expr)
+	if ret, ok := s.cache[expr]; ok {
+		return ret, nil
+	}
+	vm := sfvm.NewSyntaxFlowVirtualMachine()
+	if err := vm.Compile(outputExpr(expr)); err != nil {
+		return nil, utils.Errorf("compile syntaxflow `%v` failed: %s", expr, err)
+	}
+	result := vm.Feed(s.data)
+
+	var values ssaapi.Values
+	existed := make(map[int]struct{})
+	if output, ok := result.Get(outputRef); ok {
+		collectValues(output, func(v *ssaapi.Value) {
+			if _, ok := existed[v.GetId()]; ok {
+				return
+			}
+			existed[v.GetId()] = struct{}{}
+			values = append(values, v)
+		})
+	}
+	s.cache[expr] = values
+	return values, nil
+}
+
+func collectValues(i any, handler func(*ssaapi.Value)) {
+	switch ret := i.(type) {
+	case *ssaapi.Value:
+		handler(ret)
+	case []any:
+		for _, v := range ret {
+			collectValues(v, handler)
+		}
+	case map[string]any:
+		for _, v := range ret {
+			collectValues(v, handler)
+		}
+	case *omap.OrderedMap[string, any]:
+		for _, v := range ret.Values() {
+			collectValues(v, handler)
+		}
+	}
+}
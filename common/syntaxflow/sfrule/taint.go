@@ -0,0 +1,180 @@
+package sfrule
+
+import (
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/yak/ssa"
+	"github.com/yaklang/yaklang/common/yak/ssaapi"
+)
+
+// taintTracer walk the use-def chain from sink to sources (backward),
+// unlike GetTopDefs, the arguments of unknown functions (extern / undefined) are also traced.
+type taintTracer struct {
+	sources    map[int]struct{}
+	sanitizers map[int]struct{}
+	maxDepth   int
+
+	visited map[string]struct{}
+	// onFlow recv the path from sink to source
+	onFlow func(path []*ssaapi.Value)
+}
+
+func newTaintTracer(sources, sanitizers ssaapi.Values, maxDepth int, onFlow func([]*ssaapi.Value)) *taintTracer {
+	t := &taintTracer{
+		sources:    make(map[int]struct{}),
+		sanitizers: make(map[int]struct{}),
+		maxDepth:   maxDepth,
+		onFlow:     onFlow,
+	}
+	for _, v := range sources {
+		t.sources[v.GetId()] = struct{}{}
+	}
+	for _, v := range sanitizers {
+		t.sanitizers[v.GetId()] = struct{}{}
+	}
+	return t
+}
+
+// Trace start from the sink, every sink argument use a clean context
+func (t *taintTracer) Trace(sink *ssaapi.Value, target *ssaapi.Value) {
+	t.visited = make(map[string]struct{})
+	t.trace(target, []*ssaapi.Value{sink}, nil)
+}
+
+func (t *taintTracer) isSource(v *ssaapi.Value) bool {
+	_, ok := t.sources[v.GetId()]
+	return ok
+}
+
+func (t *taintTracer) isSanitizer(v *ssaapi.Value) bool {
+	if v == nil {
+		return false
+	}
+	_, ok := t.sanitizers[v.GetId()]
+	return ok
+}
+
+func (t *taintTracer) trace(v *ssaapi.Value, path []*ssaapi.Value, calls []*ssaapi.Value) {
+	if v == nil || ssaapi.GetBareNode(v) == nil {
+		return
+	}
+	if t.maxDepth > 0 && len(path) > t.maxDepth {
+		return
+	}
+	callId := -1
+	if len(calls) > 0 {
+		callId = calls[len(calls)-1].GetId()
+	}
+	key := fmt.Sprintf("%d:%d", v.GetId(), callId)
+	if _, ok := t.visited[key]; ok {
+		return
+	}
+	t.visited[key] = struct{}{}
+
+	current := make([]*ssaapi.Value, len(path), len(path)+1)
+	copy(current, path)
+	current = append(current, v)
+	if t.isSource(v) {
+		t.onFlow(current)
+		return
+	}
+
+	traceAll := func(values []ssa.Value) {
+		for _, value := range values {
+			if value == nil {
+				continue
+			}
+			t.trace(ssaapi.NewValue(value), current, calls)
+		}
+	}
+
+	node := ssaapi.GetBareNode(v)
+	switch ret := node.(type) {
+	case *ssa.Call:
+		callee := v.GetCallee()
+		if t.isSanitizer(callee) {
+			return
+		}
+		if fn, ok := ssa.ToFunction(ret.Method); ok && len(fn.Return) > 0 && !t.isSource(callee) {
+			// step into the function, parameters will be traced with this call
+			for _, r := range fn.Return {
+				for _, result := range r.Results {
+					t.trace(ssaapi.NewValue(result), current, append(calls, v))
+				}
+			}
+			return
+		}
+		// unknown function, the result is from the callee and arguments
+		traceAll(ret.GetValues())
+	case *ssa.Parameter:
+		t.traceParameter(v, ret, current, calls)
+	case *ssa.Field:
+		traceAll(ret.GetValues())
+		traceAll(updateValues(ret))
+		traceAll(ret.GetMask())
+	case *ssa.Make:
+		for _, user := range ret.GetUsers() {
+			if field, ok := ssa.ToField(user); ok && field.Obj == ret {
+				traceAll(updateValues(field))
+			}
+		}
+	case *ssa.Function, *ssa.ConstInst, *ssa.Undefined, *ssa.ExternLib:
+		return
+	default:
+		if value, ok := node.(ssa.Value); ok {
+			traceAll(ssa.GetValues(value))
+			traceAll(value.GetMask())
+		}
+	}
+}
+
+// traceParameter trace the arguments of the call in context, or all calls of the function
+func (t *taintTracer) traceParameter(v *ssaapi.Value, param *ssa.Parameter, path []*ssaapi.Value, calls []*ssaapi.Value) {
+	fn := param.Function
+	if fn == nil {
+		return
+	}
+
+	var callSites []*ssaapi.Value
+	var parentCalls []*ssaapi.Value
+	if len(calls) > 0 {
+		callSites = calls[len(calls)-1:]
+		parentCalls = calls[:len(calls)-1]
+	} else {
+		for _, user := range fn.GetUsers() {
+			if call, ok := ssa.ToCall(user); ok && call.Method == fn {
+				callSites = append(callSites, ssaapi.NewValue(call))
+			}
+		}
+	}
+
+	for _, site := range callSites {
+		call, ok := ssa.ToCall(ssaapi.GetBareNode(site))
+		if !ok {
+			continue
+		}
+		if param.IsFreeValue {
+			// bind by name, the binding values are after the arguments
+			values := call.GetValues()
+			for _, binding := range values[1+len(call.Args):] {
+				if _, ok := binding.GetAllVariables()[param.GetName()]; ok || binding.GetName() == param.GetName() {
+					t.trace(ssaapi.NewValue(binding), append(path, site), parentCalls)
+				}
+			}
+			continue
+		}
+		if idx := param.FormalParameterIndex; idx >= 0 && idx < len(call.Args) {
+			t.trace(ssaapi.NewValue(call.Args[idx]), append(path, site), parentCalls)
+		}
+	}
+}
+
+func updateValues(field *ssa.Field) []ssa.Value {
+	var ret []ssa.Value
+	for _, user := range field.GetUsers() {
+		if update, ok := ssa.ToUpdate(user); ok && update.Value != field {
+			ret = append(ret, update.Value)
+		}
+	}
+	return ret
+}
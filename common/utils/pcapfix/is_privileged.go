@@ -1,8 +1,8 @@
 package pcapfix
 
 import (
-	"github.com/google/gopacket/pcap"
 	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils/netutil"
 	"github.com/yaklang/yaklang/common/utils/permutil"
 	"runtime"
@@ -23,11 +23,23 @@ func IsPrivilegedForNetRaw() bool {
 			log.Errorf("cannot found net.Interface by ip: %s", err)
 			return false
 		}
-		handler, err := pcap.OpenLive(i.Name, 65536, true, 5*time.Second)
-		if err != nil {
+		// the handle of pcaputil has no read timeout, so the probe is bounded here
+		ch := make(chan bool, 1)
+		go func() {
+			handler, err := pcaputil.OpenLive(i.Name, 65536, true)
+			if err != nil {
+				ch <- false
+				return
+			}
+			handler.Close()
+			ch <- true
+		}()
+		select {
+		case ok := <-ch:
+			return ok
+		case <-time.After(5 * time.Second):
+			log.Errorf("open %s for privilege check timeout", i.Name)
 			return false
 		}
-		handler.Close()
-		return true
 	}
 }
@@ -4,11 +4,12 @@ import (
 	"bytes"
 	"encoding/binary"
 	"github.com/yaklang/yaklang/common/utils"
-	"io"
 	"strings"
 )
 
 type HandshakeClientHello struct {
+	// Version is the legacy version field of client hello, the versions offered by supported_versions extension are in Extensions
+	Version            uint16
 	Random             []byte
 	Session            []byte
 	CipherSuite        []byte
@@ -123,6 +124,9 @@ func (h *HandshakeClientHello) SNI() string {
 // It returns the parsed message and the number of bytes consumed.
 func ParseClientHello(data []byte) (*HandshakeClientHello, error) {
 	var helloInfo []byte
+	if len(data) <= 0 {
+		return nil, utils.Error("empty tls handshake client hello")
+	}
 	if data[0] != 0x16 {
 		if data[0] != 0x01 {
 			return nil, utils.Error("not a tls handshake client hello")
@@ -154,41 +158,74 @@ func ParseClientHello(data []byte) (*HandshakeClientHello, error) {
 	}
 	utils.ReadN(buf, 3) // total len
 
-	utils.ReadN(buf, 2)                    // version
-	hello.Random, _ = utils.ReadN(buf, 32) // random
+	versionRaw, err := utils.ReadN(buf, 2) // version
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
+	hello.Version = binary.BigEndian.Uint16(versionRaw)
+	hello.Random, err = utils.ReadN(buf, 32) // random
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 
 	// parse session
-	sessionLengthRaw, _ := utils.ReadN(buf, 1)
+	sessionLengthRaw, err := utils.ReadN(buf, 1)
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 	sessionLength := int(sessionLengthRaw[0])
-	hello.Session, _ = utils.ReadN(buf, sessionLength)
+	hello.Session, err = utils.ReadN(buf, sessionLength)
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 
 	// parse cipher suites
-	cipherSuitesLengthRaw, _ := utils.ReadN(buf, 2)
+	cipherSuitesLengthRaw, err := utils.ReadN(buf, 2)
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 	cipherSuitesLength := int(binary.BigEndian.Uint16(cipherSuitesLengthRaw))
-	hello.CipherSuite, _ = utils.ReadN(buf, cipherSuitesLength)
+	hello.CipherSuite, err = utils.ReadN(buf, cipherSuitesLength)
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 
 	// parse compression methods
-	compressionMethodsLengthRaw, _ := utils.ReadN(buf, 1)
+	compressionMethodsLengthRaw, err := utils.ReadN(buf, 1)
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 	compressionMethodsLength := int(compressionMethodsLengthRaw[0])
-	hello.CompressionMethods, _ = utils.ReadN(buf, compressionMethodsLength)
+	hello.CompressionMethods, err = utils.ReadN(buf, compressionMethodsLength)
+	if err != nil {
+		return nil, utils.Errorf("tls handshake client hello truncated: %v", err)
+	}
 
-	// parse extensions
-	extensionsLengthRaw, _ := utils.ReadN(buf, 2)
+	// parse extensions, the extensions are optional
+	extensionsLengthRaw, err := utils.ReadN(buf, 2)
+	if err != nil {
+		return hello, nil
+	}
 	extensionsLength := int(binary.BigEndian.Uint16(extensionsLengthRaw))
 	hello.ExtensionsRaw, _ = utils.ReadN(buf, extensionsLength)
 
 	var extBuf = bytes.NewBufferString(string(hello.ExtensionsRaw))
-	var err error
 	for {
 		ext := &HandshakeClientHelloExt{}
-		ext.RawData, err = utils.ReadN(extBuf, 2) // extension type
-		if err == io.EOF {
+		ext.TypeRaw, err = utils.ReadN(extBuf, 2) // extension type
+		if err != nil {
+			break
+		}
+		ext.TypeInt = binary.BigEndian.Uint16(ext.TypeRaw)
+		lenRaw, err := utils.ReadN(extBuf, 2) // extension length
+		if err != nil {
 			break
 		}
-		ext.TypeInt = binary.BigEndian.Uint16(ext.RawData)
-		lenRaw, _ := utils.ReadN(extBuf, 2) // extension length
 		ext.Length = binary.BigEndian.Uint16(lenRaw)
-		ext.RawData, _ = utils.ReadN(extBuf, int(ext.Length))
+		ext.RawData, err = utils.ReadN(extBuf, int(ext.Length))
+		if err != nil {
+			break
+		}
 		hello.Extensions = append(hello.Extensions, ext)
 	}
 	return hello, nil
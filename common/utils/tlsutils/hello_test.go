@@ -32,6 +32,9 @@ func TestClientHello2(t *testing.T) {
 		spew.Dump(ret)
 		panic("SNI PANIC: " + ret)
 	}
+	if data.Version != 0x0303 {
+		t.Fatalf("version error: %x", data.Version)
+	}
 }
 
 func TestHandshakeClientHello_ALPN(t *testing.T) {
@@ -43,3 +46,14 @@ func TestHandshakeClientHello_ALPN(t *testing.T) {
 	}
 	spew.Dump(data.ALPN())
 }
+
+func TestClientHello_Truncated(t *testing.T) {
+	rawStr := `16030101f6010001f2030348125381d97807a47c6904b372c7a5299d0af761af513e8f26a84bcbddaab12220a860c121b41e59963500b04b0b56153f5a5a20ae91a2e442cf89b93a4fc2d04c003e1302`
+	raw, _ := codec.DecodeHex(rawStr)
+	for i := 0; i <= len(raw); i++ {
+		ParseClientHello(raw[:i])
+	}
+	if _, err := ParseClientHello(raw); err == nil {
+		t.Fatal("truncated client hello should not be parsed")
+	}
+}
@@ -68,6 +68,20 @@ func (c *Client) StepInRequest(thread int) {
 	c.send(request)
 }
 
+func (c *Client) NextInstructionRequest(thread int) {
+	request := &dap.NextRequest{Request: *c.newRequest("next")}
+	request.Arguments.ThreadId = thread
+	request.Arguments.Granularity = "instruction"
+	c.send(request)
+}
+
+func (c *Client) StepInInstructionRequest(thread int) {
+	request := &dap.StepInRequest{Request: *c.newRequest("stepIn")}
+	request.Arguments.ThreadId = thread
+	request.Arguments.Granularity = "instruction"
+	c.send(request)
+}
+
 func (c *Client) StepOutRequest(thread int) {
 	request := &dap.StepOutRequest{Request: *c.newRequest("stepOut")}
 	request.Arguments.ThreadId = thread
@@ -185,6 +199,36 @@ func (c *Client) SetBreakpointsRequestWithArgs(file string, lines []int, conditi
 	c.send(request)
 }
 
+func (c *Client) SetFunctionBreakpointsRequest(names []string) {
+	request := &dap.SetFunctionBreakpointsRequest{Request: *c.newRequest("setFunctionBreakpoints")}
+	request.Arguments.Breakpoints = make([]dap.FunctionBreakpoint, len(names))
+	for i, name := range names {
+		request.Arguments.Breakpoints[i].Name = name
+	}
+	c.send(request)
+}
+
+func (c *Client) BreakpointLocationsRequest(file string, line, endLine int) {
+	request := &dap.BreakpointLocationsRequest{Request: *c.newRequest("breakpointLocations")}
+	request.Arguments = &dap.BreakpointLocationsArguments{
+		Source:  dap.Source{Name: filepath.Base(file), Path: file},
+		Line:    line,
+		EndLine: endLine,
+	}
+	c.send(request)
+}
+
+func (c *Client) DisassembleRequest(memoryReference string, instructionOffset, instructionCount int) {
+	request := &dap.DisassembleRequest{Request: *c.newRequest("disassemble")}
+	request.Arguments = dap.DisassembleArguments{
+		MemoryReference:   memoryReference,
+		InstructionOffset: instructionOffset,
+		InstructionCount:  instructionCount,
+		ResolveSymbols:    true,
+	}
+	c.send(request)
+}
+
 func (c *Client) ExceptionInfoRequest(threadID int) {
 	request := &dap.ExceptionInfoRequest{Request: *c.newRequest("exceptionInfo")}
 	request.Arguments.ThreadId = threadID
@@ -3,6 +3,7 @@ package dap
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -82,6 +83,23 @@ func (d *DAPDebugger) StepNext() error {
 	return nil
 }
 
+func (d *DAPDebugger) StepInstruction(stepIn bool) error {
+	err := d.debugger.StepInstruction(stepIn)
+	if err != nil {
+		return err
+	}
+	d.Continue()
+	return nil
+}
+
+func (d *DAPDebugger) Disassemble(addr uint64, instructionOffset, count int) ([]*yakvm.Instruction, error) {
+	return d.debugger.Disassemble(addr, instructionOffset, count)
+}
+
+func (d *DAPDebugger) StateFunctionName(state string) string {
+	return d.debugger.StateFunctionName(state)
+}
+
 func (d *DAPDebugger) VMPanic() *yakvm.VMPanic {
 	return d.debugger.VMPanic()
 }
@@ -110,14 +128,37 @@ func (d *DAPDebugger) ExistBreakPoint(path string, lineIndex int) (*yakvm.Breakp
 	return d.debugger.ExistBreakPointInLineWithSource(path, lineIndex)
 }
 
-func (d *DAPDebugger) SetBreakPoint(path string, lineIndex int, condition, hitCondition string) (int, error) {
-	return d.debugger.SetBreakPointWithSource(path, lineIndex, condition, hitCondition)
+func (d *DAPDebugger) SetBreakPoint(path string, lineIndex int, condition, hitCondition, logMessage string) (int, error) {
+	ref, err := d.debugger.SetBreakPointWithSource(path, lineIndex, condition, hitCondition)
+	if err != nil {
+		return ref, err
+	}
+	if bp, ok := d.debugger.ExistBreakPointInLineWithSource(path, lineIndex); ok {
+		bp.LogMessage = logMessage
+	}
+	return ref, nil
 }
 
 func (d *DAPDebugger) ClearOtherBreakPoints(path string, existLines []int) {
 	d.debugger.ClearOtherBreakpointsWithSource(path, existLines)
 }
 
+func (d *DAPDebugger) SetFunctionBreakPoint(name, condition, hitCondition string) (int, error) {
+	return d.debugger.SetFunctionBreakPoint(name, condition, hitCondition)
+}
+
+func (d *DAPDebugger) ClearFunctionBreakPoints() {
+	d.debugger.ClearFunctionBreakPoints()
+}
+
+func (d *DAPDebugger) HasFunction(name string) bool {
+	return d.debugger.HasFunction(name)
+}
+
+func (d *DAPDebugger) BreakPointLocations(path string, startLine, endLine int) []int {
+	return d.debugger.BreakPointLocations(path, startLine, endLine)
+}
+
 func (d *DAPDebugger) EvalExpression(expr string, frameID int) (*yakvm.Value, error) {
 	return d.debugger.EvalExpressionWithFrameID(expr, frameID)
 }
@@ -206,6 +247,7 @@ func (d *DAPDebugger) Init() func(g *yakvm.Debugger) {
 		log.Debug("[dap debugger] init")
 
 		d.debugger = g
+		g.SetLogPointCallback(d.LogPoint())
 
 		// Indicates initialization is complete
 		d.initWG.Done()
@@ -261,6 +303,23 @@ func (d *DAPDebugger) CallBack() func(g *yakvm.Debugger) {
 	}
 }
 
+func (d *DAPDebugger) LogPoint() func(g *yakvm.Debugger, msg string) {
+	return func(g *yakvm.Debugger, msg string) {
+		log.Debugf("[dap debugger] logpoint: %s", msg)
+
+		path := g.SourceFilePath()
+		d.session.send(&dap.OutputEvent{
+			Event: *newEvent("output"),
+			Body: dap.OutputEventBody{
+				Output:   msg + "\n",
+				Category: "console",
+				Source:   &dap.Source{Path: path, Name: filepath.Base(path)},
+				Line:     g.CurrentLine(),
+			},
+		})
+	}
+}
+
 func NewDAPDebugger() *DAPDebugger {
 	return &DAPDebugger{
 		continueCh: make(chan struct{}),
@@ -45,14 +45,19 @@ func (c *Client) ExpectInitializeResponseAndCapabilities(t *testing.T) *dap.Init
 	t.Helper()
 	initResp := c.ExpectInitializeResponse(t)
 	wantCapabilities := dap.Capabilities{
-		SupportsStepInTargetsRequest:      true,
-		SupportsEvaluateForHovers:         true,
-		SupportsConditionalBreakpoints:    true,
-		SupportsConfigurationDoneRequest:  true,
-		SupportTerminateDebuggee:          true,
-		SupportsSetVariable:               true,
-		SupportsSetExpression:             true,
-		SupportsHitConditionalBreakpoints: true,
+		SupportsStepInTargetsRequest:       true,
+		SupportsEvaluateForHovers:          true,
+		SupportsConditionalBreakpoints:     true,
+		SupportsConfigurationDoneRequest:   true,
+		SupportTerminateDebuggee:           true,
+		SupportsSetVariable:                true,
+		SupportsSetExpression:              true,
+		SupportsHitConditionalBreakpoints:  true,
+		SupportsFunctionBreakpoints:        true,
+		SupportsBreakpointLocationsRequest: true,
+		SupportsLogPoints:                  true,
+		SupportsDisassembleRequest:         true,
+		SupportsSteppingGranularity:        true,
 	}
 	if !reflect.DeepEqual(initResp.Body, wantCapabilities) {
 		t.Errorf("capabilities in initializeResponse: got %+v, want %v", pretty(initResp.Body), pretty(wantCapabilities))
@@ -165,6 +170,51 @@ func (c *Client) CheckSetBreakpointsResponse(t *testing.T, m dap.Message) *dap.S
 	return r
 }
 
+func (c *Client) ExpectSetFunctionBreakpointsResponse(t *testing.T) *dap.SetFunctionBreakpointsResponse {
+	t.Helper()
+	m := c.ExpectMessage(t)
+	return c.CheckSetFunctionBreakpointsResponse(t, m)
+}
+
+func (c *Client) CheckSetFunctionBreakpointsResponse(t *testing.T, m dap.Message) *dap.SetFunctionBreakpointsResponse {
+	t.Helper()
+	r, ok := m.(*dap.SetFunctionBreakpointsResponse)
+	if !ok {
+		t.Fatalf("got %#v, want *dap.SetFunctionBreakpointsResponse", m)
+	}
+	return r
+}
+
+func (c *Client) ExpectBreakpointLocationsResponse(t *testing.T) *dap.BreakpointLocationsResponse {
+	t.Helper()
+	m := c.ExpectMessage(t)
+	return c.CheckBreakpointLocationsResponse(t, m)
+}
+
+func (c *Client) CheckBreakpointLocationsResponse(t *testing.T, m dap.Message) *dap.BreakpointLocationsResponse {
+	t.Helper()
+	r, ok := m.(*dap.BreakpointLocationsResponse)
+	if !ok {
+		t.Fatalf("got %#v, want *dap.BreakpointLocationsResponse", m)
+	}
+	return r
+}
+
+func (c *Client) ExpectDisassembleResponse(t *testing.T) *dap.DisassembleResponse {
+	t.Helper()
+	m := c.ExpectMessage(t)
+	return c.CheckDisassembleResponse(t, m)
+}
+
+func (c *Client) CheckDisassembleResponse(t *testing.T, m dap.Message) *dap.DisassembleResponse {
+	t.Helper()
+	r, ok := m.(*dap.DisassembleResponse)
+	if !ok {
+		t.Fatalf("got %#v, want *dap.DisassembleResponse", m)
+	}
+	return r
+}
+
 func (c *Client) ExpectSetExceptionBreakpointsResponse(t *testing.T) *dap.SetExceptionBreakpointsResponse {
 	t.Helper()
 	m := c.ExpectMessage(t)
@@ -1224,6 +1224,152 @@ func TestStepAndNextRequest(t *testing.T) {
 	)
 }
 
+func TestFunctionBreakpointsRequest(t *testing.T) {
+	runTest(t, "FunctionBreakpoints", StepAndNExtTestcase, func(server *DAPServer, client *Client, program string) {
+		client.InitializeRequest()
+		client.ExpectInitializeResponseAndCapabilities(t)
+
+		server.config.extraLibs = TestExtraLibs
+		client.LaunchRequest("exec", program, !StopOnEntry)
+		client.ExpectInitializedEvent(t)
+		client.ExpectLaunchResponse(t)
+
+		client.SetFunctionBreakpointsRequest([]string{"square", "not_exist"})
+		resp := client.ExpectSetFunctionBreakpointsResponse(t)
+		if len(resp.Body.Breakpoints) != 2 {
+			t.Fatalf("got %#v, want len(Breakpoints)=2", resp)
+		}
+		if !resp.Body.Breakpoints[0].Verified || resp.Body.Breakpoints[1].Verified {
+			t.Errorf("got %#v, want square verified and not_exist unverified", resp.Body.Breakpoints)
+		}
+
+		// the request replaces all the previous function breakpoints
+		client.SetFunctionBreakpointsRequest([]string{"square"})
+		resp = client.ExpectSetFunctionBreakpointsResponse(t)
+		if len(resp.Body.Breakpoints) != 1 || !resp.Body.Breakpoints[0].Verified {
+			t.Fatalf("got %#v, want one verified breakpoint", resp)
+		}
+		if _, ok := server.session.debugger.debugger.ExistFunctionBreakPoint("not_exist"); ok {
+			t.Errorf("got function breakpoint not_exist, want it cleared")
+		}
+
+		client.ConfigurationDoneRequest()
+		client.ExpectOutputEventHelpInfo(t)
+		client.ExpectConfigurationDoneResponse(t)
+
+		se := client.ExpectStoppedEvent(t)
+		if se.Body.Reason != "function breakpoint" || se.Body.ThreadId != 1 || !se.Body.AllThreadsStopped {
+			t.Errorf("got %#v, want Reason=\"function breakpoint\", ThreadId=1, AllThreadsStopped=true", se)
+		}
+		checkStop(t, client, 1, "square", 1)
+
+		client.DisconnectRequest()
+		client.ExpectOutputEventDetaching(t)
+		client.ExpectDisconnectResponse(t)
+		client.ExpectTerminatedEvent(t)
+	})
+}
+
+func TestLogPointsAndBreakpointLocations(t *testing.T) {
+	runTest(t, "LogPoints", FuncCallTestcase, func(server *DAPServer, client *Client, program string) {
+		client.InitializeRequest()
+		client.ExpectInitializeResponseAndCapabilities(t)
+
+		client.LaunchRequest("exec", program, !StopOnEntry)
+		client.ExpectInitializedEvent(t)
+		client.ExpectLaunchResponse(t)
+
+		client.BreakpointLocationsRequest(program, 1, 7)
+		lResp := client.ExpectBreakpointLocationsResponse(t)
+		lines := make(map[int]bool)
+		for _, loc := range lResp.Body.Breakpoints {
+			lines[loc.Line] = true
+		}
+		for _, line := range []int{2, 5, 6, 7} {
+			if !lines[line] {
+				t.Errorf("got %#v, want line %d in breakpoint locations", lResp.Body.Breakpoints, line)
+			}
+		}
+		if lines[4] {
+			t.Errorf("got %#v, want empty line 4 not in breakpoint locations", lResp.Body.Breakpoints)
+		}
+
+		client.SetBreakpointsRequestWithArgs(program, []int{2}, nil, nil, map[int]string{2: "a = {a}, b * 2 = {b * 2}"})
+		sResp := client.ExpectSetBreakpointsResponse(t)
+		if len(sResp.Body.Breakpoints) != 1 || !sResp.Body.Breakpoints[0].Verified {
+			t.Errorf("got %#v, want one verified breakpoint", sResp)
+		}
+
+		client.ConfigurationDoneRequest()
+		client.ExpectOutputEventHelpInfo(t)
+		client.ExpectConfigurationDoneResponse(t)
+
+		// logpoint does not stop the program
+		oe := client.ExpectOutputEventRegex(t, `^a = 1, b \* 2 = 4\n$`)
+		if oe.Body.Line != 2 || oe.Body.Source == nil || oe.Body.Source.Name != filepath.Base(program) {
+			t.Errorf("got %#v, want Line=2 Source.Name=%s", oe.Body, filepath.Base(program))
+		}
+		client.ExpectTerminatedEvent(t)
+
+		client.DisconnectRequest()
+		client.ExpectOutputEventDetaching(t)
+		client.ExpectDisconnectResponse(t)
+		client.ExpectTerminatedEvent(t)
+	})
+}
+
+func TestDisassembleAndInstructionStepping(t *testing.T) {
+	runTest(t, "Disassemble", StepAndNExtTestcase, func(server *DAPServer, client *Client, program string) {
+		runDebugSessionWithBPs(t, client, func() {
+			server.config.extraLibs = TestExtraLibs
+			client.LaunchRequest("exec", program, !StopOnEntry)
+		}, program,
+			[]int{7},
+			[]onBreakpoint{{
+				execute: func() {
+					checkStop(t, client, 1, "initialize", 7)
+
+					instructionPointer := func() string {
+						t.Helper()
+						client.StackTraceRequest(1, 0, 20)
+						st := client.ExpectStackTraceResponse(t)
+						if len(st.Body.StackFrames) < 1 || st.Body.StackFrames[0].InstructionPointerReference == "" {
+							t.Fatalf("got %#v, want InstructionPointerReference", st)
+						}
+						return st.Body.StackFrames[0].InstructionPointerReference
+					}
+					ref := instructionPointer()
+
+					client.DisassembleRequest(ref, -2, 5)
+					dResp := client.ExpectDisassembleResponse(t)
+					if len(dResp.Body.Instructions) != 5 {
+						t.Fatalf("got %#v, want len(Instructions)=5", dResp)
+					}
+					current := dResp.Body.Instructions[2]
+					if current.Address != ref || current.Line != 7 || current.Instruction == "" || current.Instruction == "(invalid)" {
+						t.Errorf("got %#v, want Address=%s Line=7", current, ref)
+					}
+
+					client.DisassembleRequest("not-an-address", 0, 1)
+					client.ExpectErrorResponse(t)
+
+					client.NextInstructionRequest(1)
+					client.ExpectNextResponse(t)
+					se := client.ExpectStoppedEvent(t)
+					if se.Body.Reason != "step" || se.Body.ThreadId != 1 {
+						t.Errorf("got %#v, want Reason=\"step\", ThreadId=1", se)
+					}
+					if next := instructionPointer(); next == ref {
+						t.Errorf("got InstructionPointerReference=%s, want the next instruction", next)
+					}
+				},
+				disconnect: true,
+			}},
+		)
+	},
+	)
+}
+
 // func TestHardCodedBreakpoints(t *testing.T) {
 // 	runTest(t, "HardCodedBreakpoints", HardCodeBreakPointTestcase, func(server *DAPServer, client *Client, program string) {
 // 		runDebugSessionWithBPs(t, client, func() {
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -234,11 +235,12 @@ func (ds *DebugSession) onInitializeRequest(request *dap.InitializeRequest) {
 	response.Body.SupportsSetExpression = true             // Whether to support setting new values of expressions Whether
 	response.Body.SupportsHitConditionalBreakpoints = true // . The number of times
 
-	response.Body.SupportsDisassembleRequest = false         // Whether to support disassembly request (output opcode)
-	response.Body.SupportsDataBreakpoints = false            // Breakpoint triggered when a certain memory (variable) is read or written
-	response.Body.SupportsFunctionBreakpoints = false        // Function breakpoint (can be considered to support)
-	response.Body.SupportsBreakpointLocationsRequest = false // whether to support the client Query the debug adapter for available breakpoint locations in specific source code files (can consider supporting)
-	response.Body.SupportsLogPoints = false                  // support breakpoints without pausing, but output information at the breakpoint (support may be considered). Does
+	response.Body.SupportsDisassembleRequest = true         // Whether to support disassembly request (output opcode)
+	response.Body.SupportsSteppingGranularity = true        // Support stepping by instruction (opcode)
+	response.Body.SupportsDataBreakpoints = false           // Breakpoint triggered when a certain memory (variable) is read or written
+	response.Body.SupportsFunctionBreakpoints = true        // Function breakpoint, break when the yak function (including closure) is called
+	response.Body.SupportsBreakpointLocationsRequest = true // whether to support the client Query the debug adapter for available breakpoint locations in specific source code files
+	response.Body.SupportsLogPoints = true                  // support breakpoints without pausing, but output information at the breakpoint
 
 	response.Body.ExceptionBreakpointFilters = []dap.ExceptionBreakpointsFilter{} // Exception breakpoint filter
 	response.Body.SupportsStepBack = false                                        // . Step back
@@ -366,8 +368,6 @@ func (ds *DebugSession) onSetBreakpointsRequest(request *dap.SetBreakpointsReque
 
 	path := request.Arguments.Source.Path
 
-	// todo : supportsLogPoints, handle arguments.Breakpoints.LogMessage
-
 	responseBreakPoints := make([]dap.Breakpoint, 0)
 	existLines := make([]int, len(request.Arguments.Breakpoints))
 
@@ -378,9 +378,10 @@ func (ds *DebugSession) onSetBreakpointsRequest(request *dap.SetBreakpointsReque
 		if bp, ok := ds.debugger.ExistBreakPoint(path, line); ok {
 			bp.Condition = b.Condition
 			bp.HitCondition = b.HitCondition
+			bp.LogMessage = b.LogMessage
 		} else {
 			// . If it does not exist. The node creates and returns
-			ref, err := ds.debugger.SetBreakPoint(path, line, b.Condition, b.HitCondition)
+			ref, err := ds.debugger.SetBreakPoint(path, line, b.Condition, b.HitCondition, b.LogMessage)
 			responseBreakPoints = append(responseBreakPoints,
 				dap.Breakpoint{
 					Id:       ref,
@@ -398,7 +399,37 @@ func (ds *DebugSession) onSetBreakpointsRequest(request *dap.SetBreakpointsReque
 }
 
 func (ds *DebugSession) onSetFunctionBreakpointsRequest(request *dap.SetFunctionBreakpointsRequest) {
-	ds.sendNotYetImplementedErrorResponse(request.Request)
+	// Waiting for launch to complete
+	ds.LaunchWg.Wait()
+
+	debugger := ds.debugger
+	// Wait for init to complete
+	debugger.WaitInit()
+
+	response := &dap.SetFunctionBreakpointsResponse{Response: *newResponse(request.Request)}
+
+	// the request replaces all the previous function breakpoints
+	debugger.ClearFunctionBreakPoints()
+
+	// the response contains all the function breakpoints in the same order as the request
+	responseBreakPoints := make([]dap.Breakpoint, 0, len(request.Arguments.Breakpoints))
+
+	for _, b := range request.Arguments.Breakpoints {
+		ref, err := debugger.SetFunctionBreakPoint(b.Name, b.Condition, b.HitCondition)
+
+		breakpoint := dap.Breakpoint{Id: ref, Verified: err == nil}
+		if err != nil {
+			breakpoint.Message = err.Error()
+		} else if !debugger.HasFunction(b.Name) {
+			// the function may be defined later (e.g. by eval), keep the breakpoint but mark it unverified
+			breakpoint.Verified = false
+			breakpoint.Message = fmt.Sprintf("function %s not found", b.Name)
+		}
+		responseBreakPoints = append(responseBreakPoints, breakpoint)
+	}
+
+	response.Body.Breakpoints = responseBreakPoints
+	ds.send(response)
 }
 
 // Unlike what DAP documentation claims, this request is always sent
@@ -446,6 +477,10 @@ func (ds *DebugSession) onNextRequest(request *dap.NextRequest) {
 	// . Wait for the program to start.
 	ds.WaitProgramStart()
 	ds.sendStepResponse(request.Arguments.ThreadId, &dap.NextResponse{Response: *newResponse(request.Request)})
+	if request.Arguments.Granularity == "instruction" {
+		ds.debugger.StepInstruction(false)
+		return
+	}
 	ds.debugger.StepNext()
 }
 
@@ -453,6 +488,10 @@ func (ds *DebugSession) onStepInRequest(request *dap.StepInRequest) {
 	// . Wait for the program to start.
 	ds.WaitProgramStart()
 	ds.sendStepResponse(request.Arguments.ThreadId, &dap.StepInResponse{Response: *newResponse(request.Request)})
+	if request.Arguments.Granularity == "instruction" {
+		ds.debugger.StepInstruction(true)
+		return
+	}
 	ds.debugger.StepIn()
 }
 
@@ -526,7 +565,7 @@ func (ds *DebugSession) onStackTraceRequest(request *dap.StackTraceRequest) {
 			for i := 0; i < levels && start+i < total; i++ {
 				stackTrace := frames[start+i]
 				source := *stackTrace.Source
-				stackFrames = append(stackFrames, dap.StackFrame{
+				stackFrame := dap.StackFrame{
 					Id:        stackTrace.ID, // stackTrace.ID is frameId
 					Name:      stackTrace.Name,
 					Source:    &dap.Source{Name: filepath.Base(source), Path: source},
@@ -534,7 +573,12 @@ func (ds *DebugSession) onStackTraceRequest(request *dap.StackTraceRequest) {
 					Column:    stackTrace.Column + 1,
 					EndLine:   stackTrace.EndLine,
 					EndColumn: stackTrace.EndColumn + 1,
-				})
+				}
+				// Used by disassemble request
+				if stackTrace.InstructionAddress > 0 {
+					stackFrame.InstructionPointerReference = formatInstructionAddress(stackTrace.InstructionAddress)
+				}
+				stackFrames = append(stackFrames, stackFrame)
 
 			}
 			break
@@ -815,7 +859,59 @@ func (ds *DebugSession) onReadMemoryRequest(request *dap.ReadMemoryRequest) {
 }
 
 func (ds *DebugSession) onDisassembleRequest(request *dap.DisassembleRequest) {
-	ds.sendNotYetImplementedErrorResponse(request.Request)
+	// . Wait for the program to start.
+	ds.WaitProgramStart()
+
+	args := request.Arguments
+	addr, err := strconv.ParseUint(args.MemoryReference, 0, 64)
+	if err != nil {
+		ds.sendErrorResponse(request.Request, UnableToDisassemble, "Unable to disassemble", fmt.Sprintf("invalid memory reference %s", args.MemoryReference))
+		return
+	}
+	// Each opcode takes one byte of address
+	addr = uint64(int64(addr) + int64(args.Offset))
+
+	instructions, err := ds.debugger.Disassemble(addr, args.InstructionOffset, args.InstructionCount)
+	if err != nil {
+		ds.sendErrorResponse(request.Request, UnableToDisassemble, "Unable to disassemble", err.Error())
+		return
+	}
+
+	disassembled := make([]dap.DisassembledInstruction, 0, len(instructions))
+	lastSource := ""
+	for _, ins := range instructions {
+		instruction := dap.DisassembledInstruction{
+			Address:     formatInstructionAddress(ins.Address),
+			Instruction: ins.Text(),
+		}
+		// out of range instruction only has the address and the "(invalid)" text
+		if !ins.Valid() {
+			disassembled = append(disassembled, instruction)
+			continue
+		}
+		instruction.InstructionBytes = ins.Bytes()
+		if ins.CodeIndex == 0 && args.ResolveSymbols {
+			instruction.Symbol = ds.debugger.StateFunctionName(ins.State)
+		}
+
+		code := ins.Code
+		startLine, startColumn, endLine, endColumn := yakvm.GetCodeNumber(code)
+		// Location can be omitted if it is the same as the previous instruction
+		if code.SourceCodeFilePath != nil && *code.SourceCodeFilePath != lastSource {
+			lastSource = *code.SourceCodeFilePath
+			instruction.Location = &dap.Source{Name: filepath.Base(lastSource), Path: lastSource}
+		}
+		instruction.Line = startLine
+		instruction.Column = startColumn + 1
+		instruction.EndLine = endLine
+		instruction.EndColumn = endColumn + 1
+
+		disassembled = append(disassembled, instruction)
+	}
+
+	response := &dap.DisassembleResponse{Response: *newResponse(request.Request)}
+	response.Body.Instructions = disassembled
+	ds.send(response)
 }
 
 func (ds *DebugSession) onCancelRequest(request *dap.CancelRequest) {
@@ -823,7 +919,22 @@ func (ds *DebugSession) onCancelRequest(request *dap.CancelRequest) {
 }
 
 func (ds *DebugSession) onBreakpointLocationsRequest(request *dap.BreakpointLocationsRequest) {
-	ds.sendNotYetImplementedErrorResponse(request.Request)
+	// Waiting for launch to complete
+	ds.LaunchWg.Wait()
+	// Wait for init to complete
+	ds.debugger.WaitInit()
+
+	args := request.Arguments
+	if args == nil {
+		ds.sendErrorResponse(request.Request, UnableToSetBreakpoints, "Unable to get breakpoint locations", "missing arguments")
+		return
+	}
+	response := &dap.BreakpointLocationsResponse{Response: *newResponse(request.Request)}
+	lines := ds.debugger.BreakPointLocations(args.Source.Path, args.Line, args.EndLine)
+	response.Body.Breakpoints = lo.Map(lines, func(line int, _ int) dap.BreakpointLocation {
+		return dap.BreakpointLocation{Line: line}
+	})
+	ds.send(response)
 }
 
 func (ds *DebugSession) sendStepResponse(threadId int, message dap.Message) {
@@ -871,6 +982,10 @@ func (ds *DebugSession) logToConsole(msg string) {
 	})
 }
 
+func formatInstructionAddress(addr uint64) string {
+	return fmt.Sprintf("0x%x", addr)
+}
+
 func newEvent(event string) *dap.Event {
 	return &dap.Event{
 		ProtocolMessage: dap.ProtocolMessage{
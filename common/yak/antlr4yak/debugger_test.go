@@ -980,3 +980,257 @@ println(1)`
 		t.Fatal("callback not called")
 	}
 }
+
+func TestDebugger_FunctionBreakPoint(t *testing.T) {
+	code := `func test(i) {
+	return i + 1
+}
+add = func(i) {
+	return test(i) * 2
+}
+for range 3 {
+	add(1)
+}`
+	init := func(g *yakvm.Debugger) {
+		if _, err := g.SetFunctionBreakPoint("test", "", ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.SetFunctionBreakPoint("add", "", "2"); err != nil {
+			t.Fatal(err)
+		}
+		if !g.HasFunction("add") || g.HasFunction("not_exist") {
+			t.Fatal("function check error")
+		}
+	}
+	hits := make(map[string]int)
+	callback := func(g *yakvm.Debugger) {
+		if g.Finished() {
+			return
+		}
+		if g.StopReason() != "function breakpoint" {
+			t.Fatalf("stop reason error: %s", g.StopReason())
+		}
+		hits[g.StateName()]++
+	}
+
+	RunTestDebugger(code, init, callback)
+	if hits["test"] != 3 {
+		t.Fatalf("function breakpoint test hit %d times, want 3", hits["test"])
+	}
+	if hits["add"] != 2 {
+		t.Fatalf("function breakpoint add hit %d times, want 2", hits["add"])
+	}
+}
+
+func TestDebugger_LogPoint(t *testing.T) {
+	code := `a = 1
+for range 3 {
+	a++
+}`
+	var messages []string
+	init := func(g *yakvm.Debugger) {
+		g.SetLogPointCallback(func(g *yakvm.Debugger, msg string) {
+			messages = append(messages, msg)
+		})
+		if _, err := g.SetBreakPoint(3, "a > 1", ""); err != nil {
+			t.Fatal(err)
+		}
+		b, ok := g.ExistBreakPointInLineWithSource("", 3)
+		if !ok {
+			t.Fatal("breakpoint not found")
+		}
+		b.LogMessage = "a = {a}, double: {a * 2}, {a"
+	}
+	callback := func(g *yakvm.Debugger) {
+		if g.Finished() {
+			return
+		}
+		t.Fatal("logpoint should not stop")
+	}
+
+	RunTestDebugger(code, init, callback)
+	want := []string{"a = 2, double: 4, {a", "a = 3, double: 6, {a"}
+	if len(messages) != len(want) {
+		t.Fatalf("logpoint messages error: %v", messages)
+	}
+	for i, msg := range messages {
+		if msg != want[i] {
+			t.Fatalf("logpoint message error: %q(got) != %q(want)", msg, want[i])
+		}
+	}
+}
+
+func TestDebugger_BreakPointLocations(t *testing.T) {
+	code := `a = 1
+
+func test() {
+	b = 2
+}
+test()`
+	init := func(g *yakvm.Debugger) {
+		lines := g.BreakPointLocations("", 1, 6)
+		for _, line := range []int{1, 4, 6} {
+			found := false
+			for _, l := range lines {
+				if l == line {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("line %d should be in breakpoint locations: %v", line, lines)
+			}
+		}
+		for _, l := range lines {
+			if l == 2 {
+				t.Fatalf("empty line should not be in breakpoint locations: %v", lines)
+			}
+		}
+	}
+	callback := func(g *yakvm.Debugger) {}
+
+	RunTestDebugger(code, init, callback)
+}
+
+func TestDebugger_StepInstruction(t *testing.T) {
+	code := `func test() {
+	a = 1
+}
+test()
+b = 2`
+	for _, stepIn := range []bool{false, true} {
+		init := func(g *yakvm.Debugger) {
+			if _, err := g.SetNormalBreakPoint(4); err != nil {
+				t.Fatal(err)
+			}
+		}
+		steps, inFunction := 0, false
+		lastIndex := -1
+		callback := func(g *yakvm.Debugger) {
+			if g.Finished() {
+				return
+			}
+			if steps > 0 {
+				if g.StopReason() != "step" {
+					t.Fatalf("stop reason error: %s", g.StopReason())
+				}
+				if g.InRootState() && g.CurrentCodeIndex() <= lastIndex {
+					t.Fatalf("step instruction error: %d -> %d", lastIndex, g.CurrentCodeIndex())
+				}
+			}
+			if !g.InRootState() {
+				inFunction = true
+			} else {
+				lastIndex = g.CurrentCodeIndex()
+			}
+			if steps < 20 {
+				steps++
+				if err := g.StepInstruction(stepIn); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		RunTestDebugger(code, init, callback)
+		if steps == 0 {
+			t.Fatal("callback not called")
+		}
+		if inFunction != stepIn {
+			t.Fatalf("step instruction (step in: %v) error, in function: %v", stepIn, inFunction)
+		}
+	}
+}
+
+func TestDebugger_StepInstruction_BreakPointInFunction(t *testing.T) {
+	code := `func test() {
+	a = 1
+}
+test()
+b = 2`
+	init := func(g *yakvm.Debugger) {
+		if _, err := g.SetNormalBreakPoint(4); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := g.SetNormalBreakPoint(2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var reasons []string
+	hitInFunction := false
+	callback := func(g *yakvm.Debugger) {
+		if g.Finished() {
+			return
+		}
+		reasons = append(reasons, g.StopReason())
+		if !g.InRootState() && g.StopReason() == "breakpoint" {
+			hitInFunction = true
+			return
+		}
+		if len(reasons) < 20 {
+			// step over the call of test
+			if err := g.StepInstruction(false); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	RunTestDebugger(code, init, callback)
+	if !hitInFunction {
+		t.Fatalf("breakpoint in function should be triggered when stepping over instruction: %v", reasons)
+	}
+}
+
+func TestDebugger_Disassemble(t *testing.T) {
+	code := `a = 1
+func test() {
+	b = 2
+}
+test()`
+	init := func(g *yakvm.Debugger) {
+		if _, err := g.SetNormalBreakPoint(3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	in := false
+	callback := func(g *yakvm.Debugger) {
+		if g.Finished() {
+			return
+		}
+		in = true
+		addr := g.CurrentInstructionAddress()
+		state, index, ok := g.ParseInstructionAddress(addr)
+		if !ok || state != g.State() || index != g.CurrentCodeIndex() {
+			t.Fatalf("parse instruction address error: %v %v %v", state, index, ok)
+		}
+
+		instructions, err := g.Disassemble(addr, -index-1, len(g.Codes())+2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(instructions) != len(g.Codes())+2 {
+			t.Fatalf("instructions length error: %d", len(instructions))
+		}
+		if instructions[0].Valid() || instructions[len(instructions)-1].Valid() {
+			t.Fatal("instructions out of function should be invalid")
+		}
+		current := instructions[index+1]
+		if current.Address != addr || current.Code != g.Codes()[index] || current.Code.StartLineNumber != 3 {
+			t.Fatalf("current instruction error: %#v", current)
+		}
+		if g.StateFunctionName(current.State) != "test" {
+			t.Fatalf("function name error: %s", g.StateFunctionName(current.State))
+		}
+
+		for _, st := range g.GetStackTraces() {
+			for _, trace := range st.StackTraces {
+				if trace.InstructionAddress == 0 {
+					t.Fatalf("stack trace instruction address error: %#v", trace)
+				}
+			}
+		}
+	}
+
+	RunTestDebugger(code, init, callback)
+	if !in {
+		t.Fatal("callback not called")
+	}
+}
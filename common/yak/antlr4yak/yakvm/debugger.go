@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/samber/lo"
+	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yak/antlr4yak/yakvm/vmstack"
 
@@ -69,8 +70,19 @@ type Debugger struct {
 	currentLinesFirstCodeStateMap LinesFirstCodeStateMap // The first opcode index of each line
 
 	// breakpoint
-	breakPointCount      int32
-	currentBreakPointMap BreakpointMap // . Line -> breakpoint
+	breakPointCount       int32
+	currentBreakPointMap  BreakpointMap          // . Line -> breakpoint
+	functionBreakPointMap map[string]*Breakpoint // function name -> breakpoint
+
+	// logpoint callback function, output the message of logpoint
+	logPointFunc func(*Debugger, string)
+
+	// all yak functions in codes, uuid -> function
+	functions map[string]*Function
+
+	// states in the order of discovery, used to build the instruction address
+	states   []string
+	stateIDs map[string]int
 
 	// is used to step over, step into, step out
 	jmpState *DebuggerState
@@ -79,6 +91,9 @@ type Debugger struct {
 	stepInState  *DebuggerState
 	stepoutState *DebuggerState
 
+	// is used to step by instruction
+	instructionState *DebuggerState
+
 	// Stop
 	halt bool
 
@@ -127,6 +142,8 @@ type StackTrace struct {
 	SourceCode         *string
 	Line, Column       int
 	EndLine, EndColumn int
+
+	InstructionAddress uint64
 }
 
 type StackTraces struct {
@@ -152,6 +169,10 @@ func NewDebugger(vm *VirtualMachine, sourceCode string, codes []*Code, init, cal
 		linePointer:      0,
 		switchBundleMap:  make(map[string]*switchBundle),
 
+		functionBreakPointMap: make(map[string]*Breakpoint),
+		functions:             make(map[string]*Function),
+		stateIDs:              make(map[string]int),
+
 		Reference:          NewReference(),
 		observeExpressions: make(map[string]*Value),
 	}
@@ -189,6 +210,8 @@ func (g *Debugger) initCode(codes []*Code, depth int) {
 			}
 
 			g.codes[funcUUID] = f.codes
+			g.functions[funcUUID] = f
+			g.addState(funcUUID)
 			g.initCode(f.codes, depth+1)
 		}
 	}
@@ -198,6 +221,7 @@ func (g *Debugger) Init(codes []*Code) {
 	g.StartWGAdd()
 
 	g.codes[""] = codes
+	g.addState("")
 	g.InitCode(codes)
 
 	hasSet := false
@@ -329,6 +353,14 @@ func (g *Debugger) SourceCodeLines() []string {
 	return g.sourceCodeLines
 }
 
+func (g *Debugger) SourceFilePath() string {
+	return g.sourceFilePath
+}
+
+func (g *Debugger) SetLogPointCallback(f func(*Debugger, string)) {
+	g.logPointFunc = f
+}
+
 func (g *Debugger) InRootState() bool {
 	return g.State() == ""
 }
@@ -519,6 +551,10 @@ func (g *Debugger) debuggerStateToStackTrace(state *DebuggerState) StackTrace {
 	}
 	code := state.code
 	startLine, startColumn, endLine, endColumn := GetCodeNumber(code)
+	funcUUID := ""
+	if frame != nil && frame.GetFunction() != nil {
+		funcUUID = frame.GetFunction().GetUUID()
+	}
 
 	return StackTrace{
 		ID:         fid,
@@ -530,6 +566,8 @@ func (g *Debugger) debuggerStateToStackTrace(state *DebuggerState) StackTrace {
 		Column:     startColumn,
 		EndLine:    endLine,
 		EndColumn:  endColumn,
+
+		InstructionAddress: g.InstructionAddress(funcUUID, state.codeIndex),
 	}
 }
 
@@ -793,6 +831,20 @@ func (g *Debugger) HandleForBreakPoint() {
 	g.Callback()
 }
 
+func (g *Debugger) HandleForFunctionBreakPoint() {
+	g.SetStopReason("function breakpoint")
+	g.Callback()
+}
+
+func (g *Debugger) HandleForLogPoint(breakpoint *Breakpoint) {
+	msg := g.FormatLogMessage(breakpoint.LogMessage)
+	if g.logPointFunc != nil {
+		g.logPointFunc(g, msg)
+	} else {
+		log.Infof("logpoint: %s", msg)
+	}
+}
+
 func (g *Debugger) HandleForNormallyFinished() {
 	g.SetStopReason("finished")
 	g.Callback()
@@ -849,6 +901,10 @@ func (g *Debugger) ShouldCallback(frame *Frame) {
 	}
 	// Back off the stack in defer processing of frame.Exec
 
+	// A new frame of function starts
+	lastState, ok := g.ThreadStackTrace[frame.ThreadID]
+	enterFunction := codeIndex == 0 && state != "" && (!ok || lastState.frame != frame)
+
 	// Update ThreadStackTrace
 	g.ThreadStackTrace[g.frame.ThreadID] = &DebuggerState{
 		code:      code,
@@ -876,6 +932,19 @@ func (g *Debugger) ShouldCallback(frame *Frame) {
 		return
 	}
 
+	// Step by instruction, the breakpoints in the called functions are still triggered when stepping over
+	if g.instructionState != nil {
+		if g.triggerBreakPoints(code, codeIndex, lineIndex, state, frame, enterFunction) {
+			g.instructionState = nil
+			return
+		}
+		// In the same thread, callback at the next opcode, skip the opcodes of called functions when stepping over
+		if g.instructionState.frame.ThreadID == frame.ThreadID && (g.instructionState.stackLen < 0 || stackTrace.Len() <= g.instructionState.stackLen) {
+			g.HandleForStepInstruction()
+		}
+		return
+	}
+
 	// . Step
 	if g.nextState != nil {
 		// If the debugger wants to step through and jmp appears, callback
@@ -921,6 +990,11 @@ func (g *Debugger) ShouldCallback(frame *Frame) {
 		return
 	}
 
+	g.triggerBreakPoints(code, codeIndex, lineIndex, state, frame, enterFunction)
+}
+
+// triggerBreakPoints check the observe, function and line breakpoints at the opcode, return true if the debugger stopped
+func (g *Debugger) triggerBreakPoints(code *Code, codeIndex, lineIndex int, state string, frame *Frame, enterFunction bool) bool {
 	if len(g.currentObserveBreakPointMap) > 0 {
 		// when the function (normal/should not be triggered when exiting due to
 		if code.Opcode != OpReturn && code.Opcode != OpPanic {
@@ -933,8 +1007,30 @@ func (g *Debugger) ShouldCallback(frame *Frame) {
 					g.currentObserveBreakPointMap[expr] = nv
 					g.description = fmt.Sprintf("Trigger observe breakpoint at line %d in %s", g.linePointer, g.StateName())
 					g.HandleForBreakPoint()
-					return
+					return true
+				}
+			}
+		}
+	}
+
+	// Function breakpoint, callback when a new frame of the function starts
+	if enterFunction && len(g.functionBreakPointMap) > 0 {
+		if f := frame.GetFunction(); f != nil {
+			for _, breakpoint := range g.functionBreakPointMap {
+				if !breakpoint.On || !breakpoint.MatchFunction(f) {
+					continue
+				}
+				cond, ok := g.checkBreakPoint(breakpoint)
+				if !ok {
+					continue
+				}
+				if cond != "" {
+					g.description = fmt.Sprintf("Trigger conditional function breakpoint [%s] [%s] at line %d", breakpoint.FunctionName, cond, g.linePointer)
+				} else {
+					g.description = fmt.Sprintf("Trigger function breakpoint [%s] at line %d", breakpoint.FunctionName, g.linePointer)
 				}
+				g.HandleForFunctionBreakPoint()
+				return true
 			}
 		}
 	}
@@ -956,50 +1052,18 @@ func (g *Debugger) ShouldCallback(frame *Frame) {
 		// line should be called back Breakpoints include ordinary breakpoints and conditional breakpoints. When the code jumps, the judgment conditions will be relaxed, and only the line numbers need to be the same.
 		//
 		if breakpoint.CodeIndex == codeIndex || (g.jmpState != nil && breakpoint.LineIndex == lineIndex) {
-			// Conditional breakpoints
-			condition, hitCondition := breakpoint.Condition, breakpoint.HitCondition
-			if condition == "" {
-				// If the number of hits is greater than 0, then the number of hits is reduced by 1. If it is still greater than 0, keep clicking
-				if g.HitCount(breakpoint) {
-					continue
-				}
+			cond, ok := g.checkBreakPoint(breakpoint)
+			if !ok {
+				continue
 			}
 
-			if condition != "" || hitCondition != "" {
-				// If condition is empty, use hitCondition
-				cond := condition
-				if condition == "" {
-					cond = hitCondition
-				}
-				value, err := g.EvalExpression(cond)
-
-				// If If the condition is not established, click
-				if err != nil || value.False() {
-					continue
-				}
-
-				// If the number of hits is greater than 0, then the number of hits is reduced by 1. If it is still greater than 0, keep clicking
-				if g.HitCount(breakpoint) {
-					continue
-				}
-
-				// If hitCondition is not empty, you also need to judge hitCondition
-				if hitCondition != "" {
-					value, err := g.EvalExpression(hitCondition)
-
-					// If the condition is not established, continue to point
-					if err != nil || value.False() {
-						continue
-					}
-
-					cond = fmt.Sprintf("%s && %s", condition, hitCondition)
-				}
-
-				// Conditions for triggering conditional breakpoints:
-				// 1. The condition is established, there is no hitCount and hitCondition
-				// 2. hitCount exists and decreases to 0
-				// 3. condition is established, hitCondition is established
+			// Logpoint, output the message and do not stop
+			if breakpoint.IsLogPoint() {
+				g.HandleForLogPoint(breakpoint)
+				break
+			}
 
+			if cond != "" {
 				g.description = fmt.Sprintf("Trigger conditional breakpoint [%s] at line %d in %s", cond, g.linePointer, g.StateName())
 			} else {
 				// Ordinary breakpoint
@@ -1015,6 +1079,58 @@ func (g *Debugger) ShouldCallback(frame *Frame) {
 	if triggered {
 		g.HandleForBreakPoint()
 	}
+	return triggered
+}
+
+// checkBreakPoint check the condition and hit condition of breakpoint, return the triggered condition
+func (g *Debugger) checkBreakPoint(breakpoint *Breakpoint) (string, bool) {
+	// Conditional breakpoints
+	condition, hitCondition := breakpoint.Condition, breakpoint.HitCondition
+	if condition == "" {
+		// If the number of hits is greater than 0, then the number of hits is reduced by 1. If it is still greater than 0, keep clicking
+		if g.HitCount(breakpoint) {
+			return "", false
+		}
+	}
+
+	if condition == "" && hitCondition == "" {
+		return "", true
+	}
+
+	// If condition is empty, use hitCondition
+	cond := condition
+	if condition == "" {
+		cond = hitCondition
+	}
+	value, err := g.EvalExpression(cond)
+
+	// If If the condition is not established, click
+	if err != nil || value == nil || value.False() {
+		return "", false
+	}
+
+	// If the number of hits is greater than 0, then the number of hits is reduced by 1. If it is still greater than 0, keep clicking
+	if g.HitCount(breakpoint) {
+		return "", false
+	}
+
+	// If hitCondition is not empty, you also need to judge hitCondition
+	if hitCondition != "" {
+		value, err := g.EvalExpression(hitCondition)
+
+		// If the condition is not established, continue to point
+		if err != nil || value == nil || value.False() {
+			return "", false
+		}
+
+		cond = fmt.Sprintf("%s && %s", condition, hitCondition)
+	}
+
+	// Conditions for triggering conditional breakpoints:
+	// 1. The condition is established, there is no hitCount and hitCondition
+	// 2. hitCount exists and decreases to 0
+	// 3. condition is established, hitCondition is established
+	return cond, true
 }
 
 func (g *Debugger) Callback() {
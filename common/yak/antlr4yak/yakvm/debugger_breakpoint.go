@@ -1,8 +1,13 @@
 package yakvm
 
 import (
+	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync/atomic"
+
+	"github.com/yaklang/yaklang/common/utils"
 )
 
 type Breakpoint struct {
@@ -13,6 +18,9 @@ type Breakpoint struct {
 	State                   string
 
 	HitCount int // Number of hits
+
+	FunctionName string // function breakpoint, the name of function
+	LogMessage   string // logpoint, the message will be output instead of stopping
 }
 
 func (g *Debugger) NewBreakPoint(codeIndex, lineIndex int, condition, hitCondition, state string) *Breakpoint {
@@ -45,3 +53,115 @@ func (bp *Breakpoint) Enable() {
 func (bp *Breakpoint) Disable() {
 	bp.On = false
 }
+
+func (bp *Breakpoint) IsLogPoint() bool {
+	return bp.LogMessage != ""
+}
+
+func (bp *Breakpoint) IsFunctionBreakPoint() bool {
+	return bp.FunctionName != ""
+}
+
+// MatchFunction check if the function breakpoint matches the function, closure is matched by its bind name
+func (bp *Breakpoint) MatchFunction(f *Function) bool {
+	if f == nil || bp.FunctionName == "" {
+		return false
+	}
+	return bp.FunctionName == f.GetActualName() || bp.FunctionName == f.GetName()
+}
+
+func (g *Debugger) SetFunctionBreakPoint(name, condition, hitCondition string) (int, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return -1, utils.Error("function name is empty")
+	}
+	if _, ok := g.functionBreakPointMap[name]; ok {
+		return -1, utils.Errorf("function breakpoint already exists in function %s", name)
+	}
+	bp := g.NewBreakPoint(-1, -1, condition, hitCondition, "")
+	bp.FunctionName = name
+	g.functionBreakPointMap[name] = bp
+	return g.AddBreakPointRef(bp), nil
+}
+
+func (g *Debugger) ExistFunctionBreakPoint(name string) (*Breakpoint, bool) {
+	bp, ok := g.functionBreakPointMap[strings.TrimSpace(name)]
+	return bp, ok
+}
+
+func (g *Debugger) FunctionBreakPoints() map[string]*Breakpoint {
+	return g.functionBreakPointMap
+}
+
+func (g *Debugger) ClearFunctionBreakPoints() {
+	g.functionBreakPointMap = make(map[string]*Breakpoint)
+}
+
+// HasFunction check if there is a yak function named name in codes
+func (g *Debugger) HasFunction(name string) bool {
+	bp := &Breakpoint{FunctionName: strings.TrimSpace(name)}
+	for _, f := range g.functions {
+		if bp.MatchFunction(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakPointLocations return the lines which breakpoint can be set in [startLine, endLine] of source
+func (g *Debugger) BreakPointLocations(path string, startLine, endLine int) []int {
+	if endLine < startLine {
+		endLine = startLine
+	}
+	bundle := g.getSwitchBundle(path)
+	if bundle == nil {
+		return nil
+	}
+	lines := make([]int, 0)
+	for line := range bundle.linesFirstCodeStateMap {
+		if line > 0 && line >= startLine && line <= endLine {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// FormatLogMessage interpolate the expressions within {} of logpoint message in the current frame
+func (g *Debugger) FormatLogMessage(msg string) string {
+	var buf strings.Builder
+	for i := 0; i < len(msg); i++ {
+		if msg[i] != '{' {
+			buf.WriteByte(msg[i])
+			continue
+		}
+		// find the matched }
+		depth, end := 0, -1
+		for j := i; j < len(msg); j++ {
+			if msg[j] == '{' {
+				depth++
+			} else if msg[j] == '}' {
+				depth--
+				if depth == 0 {
+					end = j
+					break
+				}
+			}
+		}
+		if end < 0 {
+			buf.WriteString(msg[i:])
+			break
+		}
+		expr := strings.TrimSpace(msg[i+1 : end])
+		if expr != "" {
+			value, err := g.EvalExpression(expr)
+			if err != nil {
+				buf.WriteString(fmt.Sprintf("<error: %v>", err))
+			} else {
+				buf.WriteString(value.String())
+			}
+		}
+		i = end
+	}
+	return buf.String()
+}
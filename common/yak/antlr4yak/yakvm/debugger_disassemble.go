@@ -0,0 +1,128 @@
+package yakvm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// Instruction is a disassembled opcode, Code is nil if the instruction is out of range
+type Instruction struct {
+	Address   uint64
+	State     string // function uuid, empty for the main codes
+	CodeIndex int
+	Code      *Code
+}
+
+func (ins *Instruction) Valid() bool {
+	return ins.Code != nil
+}
+
+// Text return the opcode without the OP: prefix
+func (ins *Instruction) Text() string {
+	if ins.Code == nil {
+		return "(invalid)"
+	}
+	return strings.TrimSpace(strings.TrimPrefix(ins.Code.String(), "OP:"))
+}
+
+// Bytes return the opcode number as hex
+func (ins *Instruction) Bytes() string {
+	if ins.Code == nil {
+		return ""
+	}
+	return fmt.Sprintf("%02x", int(ins.Code.Opcode))
+}
+
+func (g *Debugger) addState(state string) {
+	if _, ok := g.stateIDs[state]; ok {
+		return
+	}
+	g.stateIDs[state] = len(g.states)
+	g.states = append(g.states, state)
+}
+
+// InstructionAddress return the address of opcode, the high 32 bits is the id of function (start from 1), the low 32 bits is the index of opcode
+func (g *Debugger) InstructionAddress(state string, codeIndex int) uint64 {
+	id, ok := g.stateIDs[state]
+	if !ok {
+		return 0
+	}
+	return uint64(id+1)<<32 | uint64(uint32(codeIndex))
+}
+
+// ParseInstructionAddress return the function uuid and the index of opcode of the address
+func (g *Debugger) ParseInstructionAddress(addr uint64) (string, int, bool) {
+	id := int(addr>>32) - 1
+	if id < 0 || id >= len(g.states) {
+		return "", -1, false
+	}
+	return g.states[id], int(uint32(addr)), true
+}
+
+func (g *Debugger) CurrentInstructionAddress() uint64 {
+	return g.InstructionAddress(g.State(), g.codePointer)
+}
+
+// StateFunctionName return the function name of state
+func (g *Debugger) StateFunctionName(state string) string {
+	if f, ok := g.functions[state]; ok {
+		return f.GetActualName()
+	}
+	return "__yak_main__"
+}
+
+// Disassemble return count instructions start from the address with the instruction offset,
+// the instructions out of the function are returned as invalid instructions
+func (g *Debugger) Disassemble(addr uint64, instructionOffset, count int) ([]*Instruction, error) {
+	state, codeIndex, ok := g.ParseInstructionAddress(addr)
+	if !ok {
+		return nil, utils.Errorf("invalid instruction address: 0x%x", addr)
+	}
+	if count < 0 {
+		count = 0
+	}
+	codes := g.CodesInState(state)
+	base := int64(addr) - int64(codeIndex)
+
+	ret := make([]*Instruction, 0, count)
+	for i := 0; i < count; i++ {
+		index := codeIndex + instructionOffset + i
+		ins := &Instruction{
+			Address:   uint64(base + int64(index)),
+			State:     state,
+			CodeIndex: index,
+		}
+		if index >= 0 && index < len(codes) {
+			ins.Code = codes[index]
+		}
+		ret = append(ret, ins)
+	}
+	return ret, nil
+}
+
+// StepInstruction step to the next opcode, the opcodes of the called functions are skipped if stepIn is false
+func (g *Debugger) StepInstruction(stepIn bool) error {
+	if g.frame == nil {
+		return utils.Error("Can't step instruction: no frame")
+	}
+	stackLen := -1
+	if !stepIn {
+		if stackTrace := g.CurrentStackTrace(); stackTrace != nil {
+			stackLen = stackTrace.Len()
+		}
+	}
+	g.instructionState = &DebuggerState{
+		codeIndex: g.codePointer,
+		frame:     g.frame,
+		stackLen:  stackLen,
+	}
+	return nil
+}
+
+func (g *Debugger) HandleForStepInstruction() {
+	g.instructionState = nil
+	g.SetStopReason("step")
+	g.Callback()
+}
@@ -1309,6 +1309,7 @@ func main() {
 		yakcmds.PcapCommand,
 		yakcmds.SuricataLoaderCommand,
 		yakcmds.ChaosMakerCommand,
+		yakcmds.StaticAnalyzeCommand,
 
 		// chaosmaker
 		{
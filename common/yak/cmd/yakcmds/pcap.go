@@ -3,11 +3,11 @@ package yakcmds
 import (
 	"fmt"
 	"github.com/google/gopacket"
-	"github.com/google/gopacket/pcap"
 	"github.com/urfave/cli"
 	"github.com/yaklang/yaklang/common/consts"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
+	"github.com/yaklang/yaklang/common/suricata/eve"
 	"github.com/yaklang/yaklang/common/suricata/match"
 	"github.com/yaklang/yaklang/common/suricata/rule"
 	"github.com/yaklang/yaklang/common/utils"
@@ -15,6 +15,7 @@ import (
 	"github.com/yaklang/yaklang/common/utils/tlsutils"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"net/http"
+	"os"
 	"strings"
 )
 
@@ -50,17 +51,51 @@ var PcapCommand = cli.Command{
 			Name:  "suricata-rule-keyword,k",
 			Usage: `suricata rule keyword, multiple optional, separated by commas`,
 		},
+		cli.BoolFlag{
+			Name:  "ids",
+			Usage: "IDS mode, match every packet with suricata rules instead of http flows only",
+		},
+		cli.StringFlag{
+			Name:  "eve",
+			Usage: `suricata eve json output path, "-" is stdout`,
+		},
+		cli.StringFlag{
+			Name:  "eve-types",
+			Usage: "eve json event types, separated by commas",
+			Value: "alert,http,dns,tls,flow",
+		},
+		cli.StringFlag{
+			Name:  "fast-log",
+			Usage: `suricata fast.log output path, "-" is stdout`,
+		},
+		cli.IntFlag{
+			Name:  "rotate-size",
+			Usage: "rotate eve json / fast.log when the size exceeds (MB), 0 means never",
+		},
+		cli.IntFlag{
+			Name:  "rotate-count",
+			Usage: "the count of rotated files kept",
+			Value: 5,
+		},
+		cli.BoolFlag{
+			Name:  "no-risk",
+			Usage: "do not save the alerts as risks",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		if c.Bool("list-devices") {
-			ifaces, err := pcap.FindAllDevs()
+			ifaces, err := pcaputil.FindAllDevices()
 			if err != nil {
 				return err
 			}
 			for _, i := range ifaces {
-				fmt.Printf("%s (%s)\n", i.Name, i.Description)
+				if i.Description != "" {
+					fmt.Printf("%s (%s)\n", i.Name, i.Description)
+				} else {
+					fmt.Println(i.Name)
+				}
 				for _, addr := range i.Addresses {
-					fmt.Printf("  %s\n", addr.IP)
+					fmt.Printf("  %s\n", addr)
 				}
 			}
 			return nil
@@ -79,19 +114,60 @@ var PcapCommand = cli.Command{
 		if output := c.String("output"); output != "" {
 			opts = append(opts, pcaputil.WithOutput(output))
 		}
+
+		var rules []*rule.Rule
 		if suricata := c.String("suricata"); suricata != "" {
-			//opts = append(opts, pcaputil.WithSuricataFilter(suricata))
+			raw, err := os.ReadFile(suricata)
+			if err != nil {
+				return utils.Errorf("read suricata rule file failed: %s", err)
+			}
+			rules, err = rule.Parse(string(raw))
+			if err != nil {
+				return utils.Errorf("parse suricata rule file failed: %s", err)
+			}
+		}
+		skw := c.String("suricata-rule-keyword")
+		ids := (len(rules) > 0 || skw != "") && c.Bool("ids")
+		flows := match.NewFlowTable()
+
+		var logger *eve.Logger
+		if len(rules) > 0 || skw != "" || c.String("eve") != "" || c.String("fast-log") != "" {
+			eveOpts := []eve.Option{
+				eve.WithEveOutput(c.String("eve")),
+				eve.WithFastLogOutput(c.String("fast-log")),
+				eve.WithEventTypes(strings.Split(c.String("eve-types"), ",")...),
+				eve.WithRotate(int64(c.Int("rotate-size"))*1024*1024, c.Int("rotate-count")),
+				eve.WithInterface(c.String("device")),
+			}
+			if !c.Bool("no-risk") {
+				eveOpts = append(eveOpts, eve.WithOnAlert(saveSuricataAlertRisk))
+			}
+			if ids {
+				// every packet is tracked by the group in ids mode, otherwise the group only sees the packets of http flows
+				eveOpts = append(eveOpts, eve.WithFlowTable(flows))
+			}
+			var err error
+			logger, err = eve.NewLogger(eveOpts...)
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
 		}
 
 		var group *match.Group
-		if skw := c.String("suricata-rule-keyword"); skw != "" {
+		if len(rules) > 0 || skw != "" {
 			group = match.NewGroup(
+				match.WithGroupFlowTable(flows),
 				match.WithGroupOnMatchedCallback(func(packet gopacket.Packet, match *rule.Rule) {
 					log.Infof("matched rule: %s", match.Message)
+					logger.Alert(packet, match)
 				}))
-			err := group.LoadRulesWithQuery(skw)
-			if err != nil {
-				return err
+			group.LoadRules(rules...)
+			if skw != "" {
+				err := group.LoadRulesWithQuery(skw)
+				if err != nil {
+					return err
+				}
 			}
 			defer group.Wait()
 		}
@@ -105,6 +181,15 @@ var PcapCommand = cli.Command{
 					log.Errorf("save traffic failed: %s", err)
 				}
 			}),
+			// rules and flows see the reassembled datagrams instead of the fragments
+			pcaputil.WithReassembledPacket(func(packet gopacket.Packet) {
+				if logger != nil {
+					logger.Packet(packet)
+				}
+				if ids {
+					group.FeedPacket(packet)
+				}
+			}),
 			pcaputil.WithTLSClientHello(func(flow *pcaputil.TrafficFlow, hello *tlsutils.HandshakeClientHello) {
 				if group == nil {
 					log.Infof("%v SNI: %v", flow.String(), hello.SNI())
@@ -141,6 +226,15 @@ var PcapCommand = cli.Command{
 				if req == nil {
 					return
 				}
+				if logger != nil {
+					logger.HTTP(&eve.Tuple{
+						Proto:    "TCP",
+						SrcIP:    flow.ClientConn.LocalIP().String(),
+						SrcPort:  flow.ClientConn.LocalPort(),
+						DestIP:   flow.ServerConn.LocalIP().String(),
+						DestPort: flow.ServerConn.LocalPort(),
+					}, req, rsp)
+				}
 
 				if group == nil {
 					reqBytes, _ := utils.DumpHTTPRequest(req, true)
@@ -158,11 +252,54 @@ var PcapCommand = cli.Command{
 					yakit.SaveFromHTTPFromRaw(consts.GetGormProjectDatabase(), false, reqBytes, rspBytes, "pcap", urlStr, "")
 					return
 				}
+				if ids {
+					// the packets of http are matched already
+					return
+				}
 				reqBytes, _ := utils.DumpHTTPRequest(req, true)
 				rspBytes, _ := utils.DumpHTTPResponse(rsp, true)
+				if flow.IsIpv4 {
+					group.FeedHTTPFlowBytesWithAddress(
+						flow.ClientConn.LocalIP().String(), flow.ClientConn.LocalPort(),
+						flow.ServerConn.LocalIP().String(), flow.ServerConn.LocalPort(),
+						reqBytes, rspBytes,
+					)
+					return
+				}
 				group.FeedHTTPFlowBytes(reqBytes, rspBytes)
 			}),
 		)
 		return pcaputil.Start(opts...)
 	},
 }
+
+func saveSuricataAlertRisk(event *eve.Event, r *rule.Rule) {
+	target := event.DestIP
+	if event.DestPort > 0 {
+		target = utils.HostPort(event.DestIP, event.DestPort)
+	}
+	_, err := yakit.NewRisk(
+		target,
+		yakit.WithRiskParam_Title(fmt.Sprintf("Suricata: %s", r.Message)),
+		yakit.WithRiskParam_TitleVerbose(r.Message),
+		yakit.WithRiskParam_RiskType("ids"),
+		yakit.WithRiskParam_Severity(eve.SeverityToRisk(event.Alert.Severity)),
+		yakit.WithRiskParam_Description(event.Alert.Category),
+		yakit.WithRiskParam_Payload(r.Raw),
+		yakit.WithRiskParam_FromScript("suricata"),
+		yakit.WithRiskParam_Details(map[string]any{
+			"sid":        r.Sid,
+			"rev":        r.Rev,
+			"classtype":  r.ClassType,
+			"flow_id":    event.FlowID,
+			"proto":      event.Proto,
+			"app_proto":  event.AppProto,
+			"src":        utils.HostPort(event.SrcIP, event.SrcPort),
+			"dest":       utils.HostPort(event.DestIP, event.DestPort),
+			"event_time": event.Timestamp,
+		}),
+	)
+	if err != nil {
+		log.Errorf("save suricata alert risk failed: %s", err)
+	}
+}
@@ -0,0 +1,241 @@
+package yakcmds
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/sarif"
+	"github.com/yaklang/yaklang/common/syntaxflow/sfrule"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/ssaapi"
+	"github.com/yaklang/yaklang/common/yak/static_analyzer"
+)
+
+var staticAnalyzeLanguages = map[string]ssaapi.Language{
+	".yak": ssaapi.Yak,
+	".js":  ssaapi.JS,
+	".php": ssaapi.PHP,
+}
+
+var StaticAnalyzeCommand = cli.Command{
+	Name:    "static-analyze",
+	Aliases: []string{"sa"},
+	Usage:   "Analyze yak / js / php file or directory, output SARIF 2.1.0",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "target,t",
+			Usage: "file or directory to analyze (args is also accepted)",
+		},
+		cli.StringFlag{
+			Name:  "output,o",
+			Usage: "SARIF output file, stdout by default",
+		},
+		cli.StringFlag{
+			Name:  "language,l",
+			Usage: "force the language: yak / js / php, detected by file extension by default",
+		},
+		cli.StringFlag{
+			Name:  "rules",
+			Usage: "syntaxflow rule file or directory (*.yaml), builtin rules are used by default",
+		},
+		cli.BoolFlag{
+			Name:  "no-builtin",
+			Usage: "do not use the builtin syntaxflow rules",
+		},
+		cli.StringFlag{
+			Name:  "plugin-type",
+			Usage: "yak plugin type for yak static analyzer: yak / mitm / port-scan / codec",
+			Value: "yak",
+		},
+		cli.BoolFlag{
+			Name:  "fail-on-findings",
+			Usage: "exit with error when any result is found (for CI)",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		var targets []string
+		if t := c.String("target"); t != "" {
+			targets = append(targets, t)
+		}
+		targets = append(targets, c.Args()...)
+		if len(targets) <= 0 {
+			return utils.Error("target file or directory is required")
+		}
+
+		var rules []*sfrule.Rule
+		if !c.Bool("no-builtin") {
+			rules = append(rules, sfrule.GetBuiltinRules()...)
+		}
+		if r := c.String("rules"); r != "" {
+			loaded, err := loadStaticAnalyzeRules(r)
+			if err != nil {
+				return err
+			}
+			rules = append(rules, loaded...)
+		}
+
+		report := sarif.NewReport()
+		run := report.NewRun("yak", consts.GetYakVersion())
+		for _, rule := range rules {
+			run.AddSyntaxFlowRule(rule)
+		}
+
+		for _, target := range targets {
+			files, base, err := collectStaticAnalyzeFiles(target, c.String("language"))
+			if err != nil {
+				return err
+			}
+			if info, err := os.Stat(target); err == nil && info.IsDir() {
+				files = analyzePHPProjectToSARIF(run, target, files, c.String("language"), rules)
+			}
+			for _, file := range files {
+				analyzeFileToSARIF(run, file, base, c.String("language"), c.String("plugin-type"), rules)
+			}
+		}
+
+		if output := c.String("output"); output != "" {
+			if err := report.WriteFile(output); err != nil {
+				return err
+			}
+			log.Infof("%v results are saved in %v", len(run.Results), output)
+		} else if err := report.Write(os.Stdout); err != nil {
+			return err
+		}
+		if c.Bool("fail-on-findings") && len(run.Results) > 0 {
+			return utils.Errorf("static analyze found %v results", len(run.Results))
+		}
+		return nil
+	},
+}
+
+func loadStaticAnalyzeRules(path string) ([]*sfrule.Rule, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, utils.Errorf("load rules failed: %s", err)
+	}
+	if info.IsDir() {
+		return sfrule.LoadRulesFromDir(path)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, utils.Errorf("read rule %v failed: %s", path, err)
+	}
+	return sfrule.ParseRule(raw)
+}
+
+// collectStaticAnalyzeFiles return the files and the base dir (for relative uri)
+func collectStaticAnalyzeFiles(target string, language string) ([]string, string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, "", utils.Errorf("stat target %v failed: %s", target, err)
+	}
+	if !info.IsDir() {
+		return []string{target}, filepath.Dir(target), nil
+	}
+
+	var files []string
+	err = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			name := info.Name()
+			if path != target && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if language != "" {
+			if detectStaticAnalyzeLanguage(path, "") == ssaapi.Language(strings.ToLower(language)) {
+				files = append(files, path)
+			}
+			return nil
+		}
+		if detectStaticAnalyzeLanguage(path, "") != "" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", utils.Errorf("walk %v failed: %s", target, err)
+	}
+	sort.Strings(files)
+	return files, target, nil
+}
+
+func detectStaticAnalyzeLanguage(file string, language string) ssaapi.Language {
+	if language != "" {
+		return ssaapi.Language(strings.ToLower(language))
+	}
+	return staticAnalyzeLanguages[strings.ToLower(filepath.Ext(file))]
+}
+
+// analyzePHPProjectToSARIF parse the php files in dir as one project (include / require across files are resolved),
+// the files left to be analyzed one by one are returned
+func analyzePHPProjectToSARIF(run *sarif.Run, dir string, files []string, language string, rules []*sfrule.Rule) []string {
+	var others []string
+	for _, file := range files {
+		if detectStaticAnalyzeLanguage(file, language) != ssaapi.PHP {
+			others = append(others, file)
+		}
+	}
+	if len(others) == len(files) {
+		return files
+	}
+
+	prog, err := ssaapi.ParseProject(dir, ssaapi.WithLanguage(ssaapi.PHP), ssaapi.WithIgnoreSyntaxError())
+	if err != nil {
+		log.Warnf("parse php project %v failed: %s, analyze php files one by one", dir, err)
+		return files
+	}
+	findings, err := sfrule.Run(prog, rules)
+	if err != nil {
+		log.Warnf("syntaxflow php project %v failed: %s", dir, err)
+		return others
+	}
+	// the file of finding is relative to dir, taken from the source range
+	run.AddSyntaxFlowFindings("", findings)
+	log.Infof("php project %v: %v findings", dir, len(findings))
+	return others
+}
+
+func analyzeFileToSARIF(run *sarif.Run, file, base, language, pluginType string, rules []*sfrule.Rule) {
+	lang := detectStaticAnalyzeLanguage(file, language)
+	if lang == "" {
+		log.Warnf("skip %v: unknown language", file)
+		return
+	}
+	uri := file
+	if rel, err := filepath.Rel(base, file); err == nil {
+		uri = rel
+	}
+
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		log.Warnf("read %v failed: %s", file, err)
+		return
+	}
+	code := string(raw)
+
+	if lang == ssaapi.Yak {
+		run.AddStaticAnalyzeResults(uri, static_analyzer.StaticAnalyzeYaklang(code, pluginType))
+	}
+
+	prog, err := ssaapi.Parse(code, ssaapi.WithLanguage(lang), ssaapi.WithIgnoreSyntaxError())
+	if err != nil {
+		log.Warnf("parse %v failed: %s", file, err)
+		return
+	}
+	findings, err := sfrule.Run(prog, rules)
+	if err != nil {
+		log.Warnf("syntaxflow %v failed: %s", file, err)
+		return
+	}
+	run.AddSyntaxFlowFindings(uri, findings)
+	log.Infof("%v: %v findings", uri, len(findings))
+}
@@ -1,6 +1,7 @@
 package yakcmds
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/urfave/cli"
 	"github.com/yaklang/yaklang/common/chaosmaker"
@@ -61,12 +62,59 @@ var ChaosMakerCommand = cli.Command{
 		cli.StringFlag{
 			Name: "remote-addr",
 		},
+		cli.BoolFlag{
+			Name:  "self-test",
+			Usage: "replay the generated traffic to the suricata matchers and report the problematic rules",
+		},
+		cli.StringFlag{
+			Name:  "report",
+			Usage: "self test report (json) output path",
+		},
+		cli.StringFlag{
+			Name:  "pcapng",
+			Usage: "write the generated traffic to pcapng file instead of parsing it",
+		},
+		cli.StringFlag{
+			Name:  "pcapng-client",
+			Usage: "client ip of the traffic in pcapng",
+		},
+		cli.StringFlag{
+			Name:  "pcapng-server",
+			Usage: "server ip of the traffic in pcapng",
+		},
 	},
 	Action: func(c *cli.Context) error {
 		maker := chaosmaker.NewChaosMaker()
 		for chaosRule := range chaosmaker.YieldRulesByKeywords(c.String("search")) {
 			maker.FeedRule(chaosRule)
 		}
+		if c.Bool("self-test") {
+			report := maker.SelfTest()
+			fmt.Println(report.String())
+			if output := c.String("report"); output != "" {
+				raw, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return err
+				}
+				return os.WriteFile(output, raw, 0o644)
+			}
+			return nil
+		}
+		if output := c.String("pcapng"); output != "" {
+			var opts []chaosmaker.PcapngOption
+			if client := c.String("pcapng-client"); client != "" {
+				opts = append(opts, chaosmaker.WithPcapngClient(client))
+			}
+			if server := c.String("pcapng-server"); server != "" {
+				opts = append(opts, chaosmaker.WithPcapngServer(server))
+			}
+			count, err := maker.ExportPcapngFile(output, opts...)
+			if err != nil {
+				return err
+			}
+			log.Infof("write %d packets to %s", count, output)
+			return nil
+		}
 		for trafficBytes := range maker.GenerateWithRule() {
 			_, ipLayer, tcpLayer, payloads, err := pcapx.ParseEthernetLinkLayer(trafficBytes.Raw)
 			if err != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/crawlerx"
 	"github.com/yaklang/yaklang/common/filter"
 	"github.com/yaklang/yaklang/common/go-funk"
 	"github.com/yaklang/yaklang/common/log"
@@ -17,6 +18,7 @@ import (
 type ResultCallback func(y *YakTemplate, reqBulk any /**YakRequestBulkConfig / YakNetworkBulkConfig*/, rsp any /*[]*lowhttp.LowhttpResponse / [][]byte*/, result bool, extractor map[string]interface{})
 type HTTPResultCallback func(y *YakTemplate, reqBulk *YakRequestBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{})
 type TCPResultCallback func(y *YakTemplate, reqBulk *YakNetworkBulkConfig, rsp []*NucleiTcpResponse, result bool, extractor map[string]interface{})
+type HeadlessResultCallback func(y *YakTemplate, reqBulk *YakHeadlessBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{})
 
 func HTTPResultCallbackWrapper(callback HTTPResultCallback) ResultCallback {
 	return func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
@@ -50,6 +52,22 @@ func TCPResultCallbackWrapper(callback TCPResultCallback) ResultCallback {
 	}
 }
 
+func HeadlessResultCallbackWrapper(callback HeadlessResultCallback) ResultCallback {
+	return func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+		bulk, ok := reqBulk.(*YakHeadlessBulkConfig)
+		if !ok {
+			return
+		}
+
+		results, ok := rsp.([]*lowhttp.LowhttpResponse)
+		if !ok {
+			return
+		}
+
+		callback(y, bulk, results, result, extractor)
+	}
+}
+
 type ConfigOption func(*Config)
 
 type Config struct {
@@ -89,6 +107,17 @@ type Config struct {
 	// onTempalteLoaded
 	OnTemplateLoaded  func(*YakTemplate) bool
 	BeforeSendPackage func(data []byte, isHttps bool) []byte
+
+	// headless templates will launch a browser by crawlerx
+	EnableHeadless  bool
+	HeadlessOptions []crawlerx.ConfigOpt
+
+	// file templates read the files of local target, they are disabled by default
+	EnableFileTemplate bool
+
+	// workflows
+	WorkflowTemplates      []*YakTemplate
+	WorkflowTemplateLoader WorkflowTemplateLoader
 }
 
 func WithOOBRequireCallback(f func(...float64) (string, string, error)) ConfigOption {
@@ -209,6 +238,24 @@ func WithMode(s string) ConfigOption {
 	}
 }
 
+func WithEnableHeadless(b bool) ConfigOption {
+	return func(config *Config) {
+		config.EnableHeadless = b
+	}
+}
+
+func WithEnableFileTemplate(b bool) ConfigOption {
+	return func(config *Config) {
+		config.EnableFileTemplate = b
+	}
+}
+
+func WithHeadlessOptions(opts ...crawlerx.ConfigOpt) ConfigOption {
+	return func(config *Config) {
+		config.HeadlessOptions = append(config.HeadlessOptions, opts...)
+	}
+}
+
 func WithBeforeSendPackage(f func(data []byte, isHttps bool) []byte) ConfigOption {
 	return func(config *Config) {
 		config.BeforeSendPackage = f
@@ -254,6 +301,12 @@ func WithTCPResultCallback(f TCPResultCallback) ConfigOption {
 	}
 }
 
+func WithHeadlessResultCallback(f HeadlessResultCallback) ConfigOption {
+	return func(config *Config) {
+		config.AppendHeadlessResultCallback(f)
+	}
+}
+
 func (c *Config) ExecuteResultCallback(y *YakTemplate, bulk *YakRequestBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
 	if c == nil {
 		return
@@ -285,6 +338,36 @@ func (c *Config) ExecuteTCPResultCallback(y *YakTemplate, bulk *YakNetworkBulkCo
 	}
 }
 
+func (c *Config) ExecuteHeadlessResultCallback(y *YakTemplate, bulk *YakHeadlessBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
+	if c == nil {
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("httptpl execute result callback failed: %v", err)
+			utils.PrintCurrentGoroutineRuntimeStack()
+		}
+	}()
+	if c.Callback != nil {
+		c.Callback(y, bulk, rsp, result, extractor)
+	}
+}
+
+func (c *Config) ExecuteProtocolResultCallback(y *YakTemplate, bulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+	if c == nil {
+		return
+	}
+	defer func() {
+		if err := recover(); err != nil {
+			log.Errorf("httptpl execute result callback failed: %v", err)
+			utils.PrintCurrentGoroutineRuntimeStack()
+		}
+	}()
+	if c.Callback != nil {
+		c.Callback(y, bulk, rsp, result, extractor)
+	}
+}
+
 func NewConfig(opts ...ConfigOption) *Config {
 	var c = &Config{
 		ConcurrentInTemplates: 20,
@@ -343,6 +426,20 @@ func (c *Config) AppendTCPResultCallback(handler TCPResultCallback) {
 	}
 }
 
+func (c *Config) AppendHeadlessResultCallback(handler HeadlessResultCallback) {
+	handlerRaw := HeadlessResultCallbackWrapper(handler)
+	if c.Callback == nil {
+		c.Callback = handlerRaw
+		return
+	}
+
+	origin := c.Callback
+	c.Callback = func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+		origin(y, reqBulk, rsp, result, extractor)
+		handlerRaw(y, reqBulk, rsp, result, extractor)
+	}
+}
+
 func (c *Config) GenerateYakTemplate() (chan *YakTemplate, error) {
 	if c.IsNuclei() {
 		ch := make(chan *YakTemplate)
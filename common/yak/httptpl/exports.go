@@ -326,6 +326,8 @@ func WithOnRisk(target string, onRisk func(i *yakit.Risk)) ConfigOption {
 	return func(config *Config) {
 		_callback(i)(config)
 		_tcpCallback(i)(config)
+		_headlessCallback(i)(config)
+		_protocolCallback(i)(config)
 	}
 }
 
@@ -340,10 +342,9 @@ func processVulnerability(target any, filterVul *filter.StringFilter, vCh chan *
 				calcSha1   string
 			)
 			details := make(map[string]interface{}, 2)
-			if len(tpl.HTTPRequestSequences) > 0 {
+			if len(tpl.HTTPRequestSequences) > 0 || len(tpl.HeadlessRequestSequences) > 0 {
 				resp := i["responses"].([]*lowhttp.LowhttpResponse)
 				currTarget = resp[0].RemoteAddr
-				reqBulk := i["requests"].(*YakRequestBulkConfig)
 				// Filter based on payload, tpl name, target conditions
 				calcSha1 = utils.CalcSha1(tpl.Name, resp[0].RawRequest, target)
 				if len(resp) == 1 {
@@ -355,9 +356,11 @@ func processVulnerability(target any, filterVul *filter.StringFilter, vCh chan *
 						details[fmt.Sprintf("response_%d", idx+1)] = string(r.RawPacket)
 					}
 				}
-				payloads, err = httpPayloadsToString(reqBulk.Payloads)
-				if err != nil {
-					log.Errorf("httpPayloadsToString failed: %v", err)
+				if reqBulk, ok := i["requests"].(*YakRequestBulkConfig); ok {
+					payloads, err = httpPayloadsToString(reqBulk.Payloads)
+					if err != nil {
+						log.Errorf("httpPayloadsToString failed: %v", err)
+					}
 				}
 			}
 
@@ -377,6 +380,22 @@ func processVulnerability(target any, filterVul *filter.StringFilter, vCh chan *
 				}
 			}
 
+			if len(tpl.DNSRequestSequences) > 0 || len(tpl.SSLRequestSequences) > 0 || len(tpl.FileRequestSequences) > 0 {
+				resp := i["responses"].([]*NucleiProtocolResponse)
+				calcSha1 = utils.CalcSha1(tpl.Name, resp[0].RawRequest, resp[0].RemoteAddr, target)
+
+				currTarget = resp[0].RemoteAddr
+				if len(resp) == 1 {
+					details["request"] = string(resp[0].RawRequest)
+					details["response"] = string(resp[0].RawPacket)
+				} else {
+					for idx, r := range resp {
+						details[fmt.Sprintf("request_%d", idx+1)] = string(r.RawRequest)
+						details[fmt.Sprintf("response_%d", idx+1)] = string(r.RawPacket)
+					}
+				}
+			}
+
 			pv := &tools.PocVul{
 				Source:        "nuclei",
 				Target:        currTarget,
@@ -414,6 +433,8 @@ func ScanLegacy(target any, opt ...interface{}) (chan *tools.PocVul, error) {
 	i := processVulnerability(target, filterVul, vCh)
 	opt = append(opt, _callback(i))
 	opt = append(opt, _tcpCallback(i))
+	opt = append(opt, _headlessCallback(i))
+	opt = append(opt, _protocolCallback(i))
 
 	c, _, _ := toConfig(opt...)
 	if strings.TrimSpace(c.SingleTemplateRaw) != "" {
@@ -458,7 +479,8 @@ var Exports = map[string]interface{}{
 	"pageTimeout":             _timeout,
 	"retry":                   lowhttp.WithRetryTimes,
 	"rateLimit":               rateLimit,
-	"headless":                nucleiOptionDummy("headless"),
+	"headless":                WithEnableHeadless,
+	"fileTemplate":            WithEnableFileTemplate,
 	"showBrowser":             nucleiOptionDummy("showBrowser"),
 	"dnsResolver":             lowhttp.WithDNSServers,
 	"systemDnsResolver":       nucleiOptionDummy("systemDnsResolver"),
@@ -487,6 +509,8 @@ var Exports = map[string]interface{}{
 	"mode":                    WithMode,
 	"resultCallback":          _callback,
 	"tcpResultCallback":       _tcpCallback,
+	"headlessResultCallback":  _headlessCallback,
+	"protocolResultCallback":  _protocolCallback,
 	"https":                   lowhttp.WithHttps,
 	"http2":                   lowhttp.WithHttp2,
 	"runtimeId":               lowhttp.WithRuntimeId,
@@ -519,6 +543,32 @@ func _tcpCallback(handler func(i map[string]interface{})) ConfigOption {
 	})
 }
 
+func _headlessCallback(handler func(i map[string]interface{})) ConfigOption {
+	return WithHeadlessResultCallback(func(y *YakTemplate, reqBulk *YakHeadlessBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
+		handler(map[string]interface{}{
+			"template":  y,
+			"requests":  reqBulk,
+			"responses": rsp,
+			"response":  rsp,
+			"match":     result,
+			"extractor": extractor,
+		})
+	})
+}
+
+func _protocolCallback(handler func(i map[string]interface{})) ConfigOption {
+	return WithProtocolResultCallback(func(y *YakTemplate, reqBulk any, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{}) {
+		handler(map[string]interface{}{
+			"template":  y,
+			"requests":  reqBulk,
+			"responses": rsp,
+			"response":  rsp,
+			"match":     result,
+			"extractor": extractor,
+		})
+	})
+}
+
 func noInteractsh(b bool) ConfigOption {
 	return WithEnableReverseConnectionFeature(!b)
 }
@@ -141,10 +141,53 @@ func CreateYakTemplateFromNucleiTemplateRaw(tplRaw string) (*YakTemplate, error)
 			}
 
 			return yakTemp, nil
-		} else if utils.MapGetFirstRaw(mid, "workflows") != nil {
-			return nil, utils.Error("yakit nuclei cannot support workflows now~")
-		} else if utils.MapGetFirstRaw(mid, "headless") != nil {
-			return nil, utils.Errorf("nuclei template `headless(crawler)` is not supported (*)")
+		} else if ret := utils.MapGetFirstRaw(mid, "workflows"); ret != nil {
+			yakTemp.Variables = generateYakVariables(mid)
+			yakTemp.Workflows, err = parseNucleiWorkflows(ret)
+			if err != nil {
+				return nil, utils.Errorf("parse nuclei workflows failed: %v", err)
+			}
+			return yakTemp, nil
+		} else if ret := utils.MapGetFirstRaw(mid, "headless"); ret != nil {
+			if reflect.TypeOf(ret).Kind() != reflect.Slice {
+				return nil, utils.Error("nuclei template `headless` is not slice")
+			}
+			yakTemp.Variables = generateYakVariables(mid)
+			yakTemp.HeadlessRequestSequences, err = parseHeadlessBulk(utils.InterfaceToSliceInterface(ret))
+			if err != nil {
+				return nil, utils.Errorf("parse headless bulk failed: %v", err)
+			}
+			return yakTemp, nil
+		} else if dnsRaw, sslRaw, fileRaw := utils.MapGetRaw(mid, "dns"), utils.MapGetRaw(mid, "ssl"), utils.MapGetRaw(mid, "file"); dnsRaw != nil || sslRaw != nil || fileRaw != nil {
+			yakTemp.Variables = generateYakVariables(mid)
+			if dnsRaw != nil {
+				if reflect.TypeOf(dnsRaw).Kind() != reflect.Slice {
+					return nil, utils.Error("nuclei template `dns` is not slice")
+				}
+				yakTemp.DNSRequestSequences, err = parseDNSBulk(utils.InterfaceToSliceInterface(dnsRaw))
+				if err != nil {
+					return nil, utils.Errorf("parse dns bulk failed: %v", err)
+				}
+			}
+			if sslRaw != nil {
+				if reflect.TypeOf(sslRaw).Kind() != reflect.Slice {
+					return nil, utils.Error("nuclei template `ssl` is not slice")
+				}
+				yakTemp.SSLRequestSequences, err = parseSSLBulk(utils.InterfaceToSliceInterface(sslRaw))
+				if err != nil {
+					return nil, utils.Errorf("parse ssl bulk failed: %v", err)
+				}
+			}
+			if fileRaw != nil {
+				if reflect.TypeOf(fileRaw).Kind() != reflect.Slice {
+					return nil, utils.Error("nuclei template `file` is not slice")
+				}
+				yakTemp.FileRequestSequences, err = parseFileBulk(utils.InterfaceToSliceInterface(fileRaw))
+				if err != nil {
+					return nil, utils.Errorf("parse file bulk failed: %v", err)
+				}
+			}
+			return yakTemp, nil
 		} else {
 			log.Warnf("-----------------NUCLEI FORMATTER CANNOT FIX--------------------")
 			fmt.Println(tplRaw)
@@ -348,6 +391,11 @@ func generateYakExtractors(req map[string]interface{}) ([]*YakExtractor, error)
 }
 
 func generateYakMatcher(req map[string]interface{}) (*YakMatcher, error) {
+	return generateYakMatcherWithScope(req, nil)
+}
+
+// generateYakMatcherWithScope can map the unknown `part` to a custom scope, e.g. headless named data
+func generateYakMatcherWithScope(req map[string]interface{}, scope func(part string) string) (*YakMatcher, error) {
 	matchersRaw := utils.MapGetRaw(req, "matchers")
 	if matchersRaw == nil {
 		return nil, utils.Errorf("nuclei template matchers is nil")
@@ -365,6 +413,7 @@ func generateYakMatcher(req map[string]interface{}) (*YakMatcher, error) {
 			Group:       nil,
 		}
 		m := utils.InterfaceToMapInterface(i)
+		match.Name = utils.MapGetString(m, "name")
 		match.Negative = utils.MapGetBool(m, "negative")
 		match.Condition = utils.MapGetString(m, "condition")
 
@@ -379,6 +428,10 @@ func generateYakMatcher(req map[string]interface{}) (*YakMatcher, error) {
 			match.Scope = "raw"
 		case "interactsh_protocol", "oob_protocol":
 			match.Scope = "oob_protocol"
+		default:
+			if scope != nil {
+				match.Scope = scope(utils.MapGetString(m, "part"))
+			}
 		}
 
 		switch utils.MapGetString(m, "type") {
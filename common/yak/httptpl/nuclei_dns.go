@@ -0,0 +1,89 @@
+package httptpl
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// nuclei dns
+// https://docs.projectdiscovery.io/templates/protocols/dns
+//
+//	dns:
+//	  - name: "{{FQDN}}"
+//	    type: CNAME
+//	    class: inet
+//	    recursion: true
+//	    retries: 3
+//	    matchers:
+//	      - type: word
+//	        part: answer
+//	        words:
+//	          - "github.io"
+
+var dnsParts = []string{"answer", "ns", "extra", "question", "rcode", "raw", "all"}
+
+func protocolPartScope(parts []string) func(part string) string {
+	return func(part string) string {
+		if utils.StringArrayContains(parts, part) {
+			return part
+		}
+		return ""
+	}
+}
+
+// protocolExtractorScope set the extractor scope by nuclei `part`, only the protocol parts are accepted
+func protocolExtractorScope(data map[string]any, extractors []*YakExtractor, parts []string) {
+	raws := utils.InterfaceToSliceInterface(utils.MapGetRaw(data, "extractors"))
+	if len(raws) != len(extractors) {
+		return
+	}
+	for index, raw := range raws {
+		part := utils.MapGetString(utils.InterfaceToGeneralMap(raw), "part")
+		if extractors[index].Scope == "" && utils.StringArrayContains(parts, part) {
+			extractors[index].Scope = part
+		}
+	}
+}
+
+func parseDNSBulk(ret []any) ([]*YakDNSBulkConfig, error) {
+	var confs []*YakDNSBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		dnsReq := &YakDNSBulkConfig{
+			Name:      utils.MapGetString(data, "name"),
+			Type:      strings.ToUpper(strings.TrimSpace(utils.MapGetString(data, "type"))),
+			Class:     strings.ToLower(strings.TrimSpace(utils.MapGetString(data, "class"))),
+			Recursion: utils.MapGetBoolOr(data, "recursion", true),
+			Retries:   utils.MapGetInt(data, "retries"),
+			Resolvers: utils.InterfaceToStringSlice(utils.MapGetRaw(data, "resolvers")),
+		}
+		if dnsReq.Name == "" {
+			dnsReq.Name = "{{FQDN}}"
+		}
+		if dnsReq.Type == "" {
+			dnsReq.Type = "A"
+		}
+		matcher, err := generateYakMatcherWithScope(data, protocolPartScope(dnsParts))
+		if err != nil {
+			log.Debugf("build dns matcher failed: %s", err)
+		}
+		dnsReq.Matcher = matcher
+		extractors, err := generateYakExtractors(data)
+		if err != nil {
+			log.Warnf("build dns extractor failed: %s", err)
+		}
+		protocolExtractorScope(data, extractors, dnsParts)
+		dnsReq.Extractor = extractors
+		if len(dnsReq.Extractor) <= 0 && dnsReq.Matcher == nil {
+			log.Warn("no matcher and extractor found")
+			continue
+		}
+		confs = append(confs, dnsReq)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty dns bulk config")
+	}
+	return confs, nil
+}
@@ -0,0 +1,80 @@
+package httptpl
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// nuclei file
+// https://docs.projectdiscovery.io/templates/protocols/file
+//
+//	file:
+//	  - extensions:
+//	      - all
+//	    denylist:
+//	      - .git
+//	    max-size: 5Mb
+//	    extractors:
+//	      - type: regex
+//	        regex:
+//	          - "AKIA[0-9A-Z]{16}"
+
+const defaultFileMaxSize = 5 * 1024 * 1024
+
+var fileSizeRegexp = regexp.MustCompile(`(?i)^\s*(\d+)\s*(b|kb|mb|gb)?\s*$`)
+
+// parseFileMaxSize parse `5Mb` / `100kb` / `1024` to bytes
+func parseFileMaxSize(raw string) int64 {
+	matches := fileSizeRegexp.FindStringSubmatch(raw)
+	if len(matches) != 3 {
+		return defaultFileMaxSize
+	}
+	size := int64(codec.Atoi(matches[1]))
+	switch strings.ToLower(matches[2]) {
+	case "kb":
+		size *= 1024
+	case "mb":
+		size *= 1024 * 1024
+	case "gb":
+		size *= 1024 * 1024 * 1024
+	}
+	if size <= 0 {
+		return defaultFileMaxSize
+	}
+	return size
+}
+
+func parseFileBulk(ret []any) ([]*YakFileBulkConfig, error) {
+	var confs []*YakFileBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		fileReq := &YakFileBulkConfig{
+			Extensions: utils.InterfaceToStringSlice(utils.MapGetRaw(data, "extensions")),
+			DenyList:   utils.InterfaceToStringSlice(utils.MapGetRaw(data, "denylist")),
+			MaxSize:    parseFileMaxSize(utils.MapGetString(data, "max-size")),
+		}
+		matcher, err := generateYakMatcher(data)
+		if err != nil {
+			log.Debugf("build file matcher failed: %s", err)
+		}
+		fileReq.Matcher = matcher
+		extractors, err := generateYakExtractors(data)
+		if err != nil {
+			log.Warnf("build file extractor failed: %s", err)
+		}
+		fileReq.Extractor = extractors
+		if len(fileReq.Extractor) <= 0 && fileReq.Matcher == nil {
+			log.Warn("no matcher and extractor found")
+			continue
+		}
+		confs = append(confs, fileReq)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty file bulk config")
+	}
+	return confs, nil
+}
@@ -0,0 +1,100 @@
+package httptpl
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// nuclei headless
+// https://docs.projectdiscovery.io/templates/protocols/headless
+//
+//	headless:
+//	  - steps:
+//	      - action: navigate
+//	        args:
+//	          url: "{{BaseURL}}"
+//	      - action: waitload
+//	      - action: script
+//	        name: title
+//	        args:
+//	          code: "() => document.title"
+//	    matchers:
+//	      - type: word
+//	        part: title
+//	        words:
+//	          - "admin"
+
+func parseHeadlessSteps(data map[string]any) []*YakHeadlessStep {
+	var steps []*YakHeadlessStep
+	for _, stepRaw := range utils.InterfaceToSliceInterface(utils.MapGetRaw(data, "steps")) {
+		stepItem := utils.InterfaceToGeneralMap(stepRaw)
+		step := &YakHeadlessStep{
+			Name:   utils.MapGetString(stepItem, "name"),
+			Action: strings.ToLower(strings.TrimSpace(utils.MapGetString(stepItem, "action"))),
+			Args:   make(map[string]string),
+		}
+		if step.Action == "" {
+			log.Warn("headless step action is empty, skipped")
+			continue
+		}
+		for k, v := range utils.InterfaceToGeneralMap(utils.MapGetRaw(stepItem, "args")) {
+			step.Args[k] = toString(v)
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func parseHeadlessBulk(ret []any) ([]*YakHeadlessBulkConfig, error) {
+	var confs []*YakHeadlessBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		headless := &YakHeadlessBulkConfig{
+			Steps: parseHeadlessSteps(data),
+		}
+		if len(headless.Steps) <= 0 {
+			log.Warn("headless steps is empty")
+			continue
+		}
+
+		// named steps can be used as matcher part
+		names := make(map[string]struct{})
+		for _, step := range headless.Steps {
+			if step.Name != "" {
+				names[step.Name] = struct{}{}
+			}
+		}
+		matcher, err := generateYakMatcherWithScope(data, func(part string) string {
+			if _, ok := names[part]; ok {
+				return part
+			}
+			switch part {
+			case "history":
+				return "history"
+			case "resp", "data":
+				return "body"
+			}
+			return ""
+		})
+		if err != nil {
+			log.Debugf("build headless matcher failed: %s", err)
+		}
+		headless.Matcher = matcher
+		extractors, err := generateYakExtractors(data)
+		if err != nil {
+			log.Warnf("build headless extractor failed: %s", err)
+		}
+		headless.Extractor = extractors
+		if len(headless.Extractor) <= 0 && headless.Matcher == nil {
+			log.Warn("no matcher and extractor found")
+			continue
+		}
+		confs = append(confs, headless)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty headless bulk config")
+	}
+	return confs, nil
+}
@@ -0,0 +1,64 @@
+package httptpl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateYakTemplate_Headless(t *testing.T) {
+	raw := `
+id: headless-basic
+info:
+  name: Headless Basic
+  author: test
+  severity: info
+
+headless:
+  - steps:
+      - action: navigate
+        args:
+          url: "{{BaseURL}}/login"
+      - action: waitload
+      - action: text
+        args:
+          by: selector
+          selector: "input[name=username]"
+          value: admin
+      - action: click
+        args:
+          by: xpath
+          xpath: "/html/body/form/button"
+      - action: script
+        name: title
+        args:
+          code: "() => document.title"
+    matchers-condition: and
+    matchers:
+      - type: word
+        part: title
+        words:
+          - "Dashboard"
+      - type: word
+        part: history
+        words:
+          - "Set-Cookie"
+`
+	tpl, err := CreateYakTemplateFromNucleiTemplateRaw(raw)
+	require.NoError(t, err)
+	require.Len(t, tpl.HeadlessRequestSequences, 1)
+
+	bulk := tpl.HeadlessRequestSequences[0]
+	require.Len(t, bulk.Steps, 5)
+	assert.Equal(t, "navigate", bulk.Steps[0].Action)
+	assert.Equal(t, "{{BaseURL}}/login", bulk.Steps[0].Args["url"])
+	assert.Equal(t, "admin", bulk.Steps[2].Args["value"])
+	assert.Equal(t, "/html/body/form/button", bulk.Steps[3].Args["xpath"])
+	assert.Equal(t, "title", bulk.Steps[4].Name)
+
+	require.NotNil(t, bulk.Matcher)
+	require.Len(t, bulk.Matcher.SubMatchers, 2)
+	assert.Equal(t, "title", bulk.Matcher.SubMatchers[0].Scope)
+	assert.Equal(t, "history", bulk.Matcher.SubMatchers[1].Scope)
+}
@@ -0,0 +1,58 @@
+package httptpl
+
+import (
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// nuclei ssl
+// https://docs.projectdiscovery.io/templates/protocols/ssl
+//
+//	ssl:
+//	  - address: "{{Host}}:{{Port}}"
+//	    min_version: tls10
+//	    max_version: tls12
+//	    matchers:
+//	      - type: dsl
+//	        dsl:
+//	          - "expired == true"
+
+var sslParts = []string{"tls_version", "cipher", "subject_dn", "subject_cn", "subject_an", "issuer_dn", "issuer_cn", "serial", "fingerprint_hash", "raw", "all"}
+
+func parseSSLBulk(ret []any) ([]*YakSSLBulkConfig, error) {
+	var confs []*YakSSLBulkConfig
+	for _, i := range ret {
+		data := utils.InterfaceToGeneralMap(i)
+		sslReq := &YakSSLBulkConfig{
+			Address:      utils.MapGetString(data, "address"),
+			MinVersion:   strings.ToLower(utils.MapGetString(data, "min_version")),
+			MaxVersion:   strings.ToLower(utils.MapGetString(data, "max_version")),
+			CipherSuites: utils.InterfaceToStringSlice(utils.MapGetRaw(data, "cipher_suites")),
+		}
+		if sslReq.Address == "" {
+			sslReq.Address = "{{Host}}:{{Port}}"
+		}
+		matcher, err := generateYakMatcherWithScope(data, protocolPartScope(sslParts))
+		if err != nil {
+			log.Debugf("build ssl matcher failed: %s", err)
+		}
+		sslReq.Matcher = matcher
+		extractors, err := generateYakExtractors(data)
+		if err != nil {
+			log.Warnf("build ssl extractor failed: %s", err)
+		}
+		protocolExtractorScope(data, extractors, sslParts)
+		sslReq.Extractor = extractors
+		if len(sslReq.Extractor) <= 0 && sslReq.Matcher == nil {
+			log.Warn("no matcher and extractor found")
+			continue
+		}
+		confs = append(confs, sslReq)
+	}
+	if len(confs) <= 0 {
+		return nil, utils.Error("empty ssl bulk config")
+	}
+	return confs, nil
+}
@@ -0,0 +1,127 @@
+package httptpl
+
+import (
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// nuclei workflows
+// https://docs.projectdiscovery.io/templates/workflows/overview
+//
+//	workflows:
+//	  - template: http/technologies/tech-detect.yaml
+//	    matchers:
+//	      - name: wordpress
+//	        subtemplates:
+//	          - tags: wordpress
+//	  - tags: springboot
+//	    subtemplates:
+//	      - template: http/cves/2022/CVE-2022-22965.yaml
+
+func parseNucleiWorkflows(raw any) ([]*YakWorkflow, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if reflect.TypeOf(raw).Kind() != reflect.Slice {
+		return nil, utils.Error("nuclei template `workflows` is not slice")
+	}
+
+	var workflows []*YakWorkflow
+	for _, item := range utils.InterfaceToSliceInterface(raw) {
+		wf, err := parseNucleiWorkflow(utils.InterfaceToGeneralMap(item))
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, wf)
+	}
+	if len(workflows) <= 0 {
+		return nil, utils.Error("empty nuclei workflows")
+	}
+	return workflows, nil
+}
+
+func parseNucleiWorkflow(data map[string]any) (*YakWorkflow, error) {
+	wf := &YakWorkflow{
+		Template: normalizeWorkflowTemplateName(utils.MapGetString(data, "template")),
+		Tags:     splitWorkflowList(utils.MapGetRaw(data, "tags")),
+	}
+	if wf.Template == "" && len(wf.Tags) <= 0 {
+		return nil, utils.Error("nuclei workflow need `template` or `tags`")
+	}
+
+	var err error
+	wf.Subtemplates, err = parseNucleiSubWorkflows(utils.MapGetRaw(data, "subtemplates"))
+	if err != nil {
+		return nil, err
+	}
+
+	matchersRaw := utils.MapGetRaw(data, "matchers")
+	if matchersRaw == nil {
+		return wf, nil
+	}
+	if reflect.TypeOf(matchersRaw).Kind() != reflect.Slice {
+		return nil, utils.Error("nuclei workflow `matchers` is not slice")
+	}
+	for _, item := range utils.InterfaceToSliceInterface(matchersRaw) {
+		m := utils.InterfaceToGeneralMap(item)
+		matcher := &YakWorkflowMatcher{
+			Names:     splitWorkflowList(utils.MapGetRaw(m, "name")),
+			Condition: strings.ToLower(strings.TrimSpace(utils.MapGetString(m, "condition"))),
+		}
+		if len(matcher.Names) <= 0 {
+			return nil, utils.Error("nuclei workflow matcher need `name`")
+		}
+		matcher.Subtemplates, err = parseNucleiSubWorkflows(utils.MapGetRaw(m, "subtemplates"))
+		if err != nil {
+			return nil, err
+		}
+		wf.Matchers = append(wf.Matchers, matcher)
+	}
+	return wf, nil
+}
+
+func parseNucleiSubWorkflows(raw any) ([]*YakWorkflow, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	if reflect.TypeOf(raw).Kind() != reflect.Slice {
+		return nil, utils.Error("nuclei workflow `subtemplates` is not slice")
+	}
+	var subs []*YakWorkflow
+	for _, item := range utils.InterfaceToSliceInterface(raw) {
+		sub, err := parseNucleiWorkflow(utils.InterfaceToGeneralMap(item))
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// normalizeWorkflowTemplateName turns a nuclei template path into a template id,
+// e.g. `http/technologies/tech-detect.yaml` => `tech-detect`
+func normalizeWorkflowTemplateName(s string) string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ""
+	}
+	s = path.Base(strings.ReplaceAll(s, "\\", "/"))
+	for _, ext := range []string{".yaml", ".yml"} {
+		if strings.HasSuffix(strings.ToLower(s), ext) {
+			s = s[:len(s)-len(ext)]
+			break
+		}
+	}
+	return s
+}
+
+func splitWorkflowList(raw any) []string {
+	var result []string
+	for _, item := range utils.InterfaceToStringSlice(raw) {
+		result = append(result, utils.PrettifyListFromStringSplitEx(item, ",")...)
+	}
+	return result
+}
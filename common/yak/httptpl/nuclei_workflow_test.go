@@ -0,0 +1,176 @@
+package httptpl
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+func TestCreateYakTemplate_Workflows(t *testing.T) {
+	raw := `
+id: wordpress-workflow
+info:
+  name: WordPress Security Checks
+  author: test
+
+workflows:
+  - template: http/technologies/tech-detect.yaml
+    matchers:
+      - name: wordpress
+        subtemplates:
+          - tags: wordpress,wp-plugin
+      - name:
+          - php
+          - nginx
+        condition: and
+        subtemplates:
+          - template: php-info.yaml
+  - tags: springboot
+    subtemplates:
+      - template: http/cves/2022/CVE-2022-22965.yaml
+`
+	tpl, err := CreateYakTemplateFromNucleiTemplateRaw(raw)
+	require.NoError(t, err)
+	require.Len(t, tpl.Workflows, 2)
+
+	first := tpl.Workflows[0]
+	assert.Equal(t, "tech-detect", first.Template)
+	require.Len(t, first.Matchers, 2)
+	assert.Equal(t, []string{"wordpress"}, first.Matchers[0].Names)
+	assert.Equal(t, []string{"wordpress", "wp-plugin"}, first.Matchers[0].Subtemplates[0].Tags)
+	assert.Equal(t, "and", first.Matchers[1].Condition)
+	assert.Equal(t, "php-info", first.Matchers[1].Subtemplates[0].Template)
+
+	assert.True(t, first.Matchers[1].Match(map[string]struct{}{"php": {}, "nginx": {}}))
+	assert.False(t, first.Matchers[1].Match(map[string]struct{}{"php": {}}))
+
+	second := tpl.Workflows[1]
+	assert.Equal(t, []string{"springboot"}, second.Tags)
+	assert.Equal(t, "CVE-2022-22965", second.Subtemplates[0].Template)
+}
+
+func TestMockTest_Workflows(t *testing.T) {
+	server, port := utils.DebugMockHTTPHandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<meta name="generator" content="WordPress 6.0" /> token=abc123`))
+		case "/wp-admin/":
+			w.Write([]byte(`wordpress admin exposed`))
+		default:
+			w.Write([]byte(`not found`))
+		}
+	})
+
+	detect, err := CreateYakTemplateFromNucleiTemplateRaw(`
+id: tech-detect
+info:
+  name: tech-detect
+  author: test
+  tags: tech
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/"
+    matchers-condition: or
+    matchers:
+      - type: word
+        name: wordpress
+        words:
+          - "WordPress"
+      - type: word
+        name: drupal
+        words:
+          - "Drupal"
+    extractors:
+      - type: regex
+        name: token
+        group: 1
+        regex:
+          - "token=([a-z0-9]+)"
+`)
+	require.NoError(t, err)
+
+	wpCheck, err := CreateYakTemplateFromNucleiTemplateRaw(`
+id: wp-admin
+info:
+  name: wp-admin
+  author: test
+  tags: wordpress
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/wp-admin/"
+    matchers:
+      - type: word
+        words:
+          - "wordpress admin exposed"
+`)
+	require.NoError(t, err)
+
+	drupalCheck, err := CreateYakTemplateFromNucleiTemplateRaw(`
+id: drupal-check
+info:
+  name: drupal-check
+  author: test
+  tags: drupal
+
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}/"
+    matchers:
+      - type: word
+        words:
+          - "WordPress"
+`)
+	require.NoError(t, err)
+
+	workflow, err := CreateYakTemplateFromNucleiTemplateRaw(`
+id: cms-workflow
+info:
+  name: cms-workflow
+  author: test
+
+workflows:
+  - template: tech-detect.yaml
+    matchers:
+      - name: wordpress
+        subtemplates:
+          - tags: wordpress
+      - name: drupal
+        subtemplates:
+          - tags: drupal
+`)
+	require.NoError(t, err)
+
+	var (
+		mutex   sync.Mutex
+		matched []string
+	)
+	config := NewConfig(
+		WithWorkflowTemplates(detect, wpCheck, drupalCheck),
+		WithResultCallback(func(y *YakTemplate, reqBulk *YakRequestBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
+			if !result {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			matched = append(matched, y.Id)
+		}),
+	)
+	_, err = workflow.ExecWithUrl("http://"+utils.HostPort(server, port), config)
+	require.NoError(t, err)
+
+	joined := strings.Join(matched, ",")
+	assert.Contains(t, joined, "tech-detect")
+	assert.Contains(t, joined, "wp-admin")
+	assert.NotContains(t, joined, "drupal-check")
+}
@@ -63,6 +63,15 @@ type YakTemplate struct {
 
 	TCPRequestSequences  []*YakNetworkBulkConfig
 	HTTPRequestSequences []*YakRequestBulkConfig
+	// HeadlessRequestSequences is executed by crawlerx, see yaktpl_headless.go
+	HeadlessRequestSequences []*YakHeadlessBulkConfig
+	// DNS / SSL / File RequestSequences, see yaktpl_protocol.go
+	DNSRequestSequences  []*YakDNSBulkConfig
+	SSLRequestSequences  []*YakSSLBulkConfig
+	FileRequestSequences []*YakFileBulkConfig
+
+	// Workflows reference other templates, see nuclei_workflow.go
+	Workflows []*YakWorkflow
 
 	// placeHolderMap
 	PlaceHolderMap map[string]string
@@ -0,0 +1,175 @@
+package httptpl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+type YakDNSBulkConfig struct {
+	Name      string
+	Type      string
+	Class     string
+	Recursion bool
+	Retries   int
+	Resolvers []string
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+var dnsClassMap = map[string]uint16{
+	"inet":   dns.ClassINET,
+	"csnet":  dns.ClassCSNET,
+	"chaos":  dns.ClassCHAOS,
+	"hesiod": dns.ClassHESIOD,
+	"none":   dns.ClassNONE,
+	"any":    dns.ClassANY,
+}
+
+func (y *YakDNSBulkConfig) question(name string) (*dns.Msg, error) {
+	qType, ok := dns.StringToType[y.Type]
+	if !ok {
+		return nil, utils.Errorf("unsupported dns type: %v", y.Type)
+	}
+	qClass, ok := dnsClassMap[y.Class]
+	if !ok {
+		qClass = dns.ClassINET
+	}
+	msg := new(dns.Msg)
+	msg.Id = dns.Id()
+	msg.RecursionDesired = y.Recursion
+	msg.Question = []dns.Question{{Name: dns.Fqdn(name), Qtype: qType, Qclass: qClass}}
+	return msg, nil
+}
+
+func dnsRecordsToString(rrs []dns.RR) string {
+	var lines []string
+	for _, rr := range rrs {
+		lines = append(lines, rr.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (y *YakDNSBulkConfig) Execute(
+	config *Config, vars map[string]any, renderVars map[string]string, lowhttpConfig *lowhttp.LowhttpExecConfig,
+	callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any),
+) error {
+	runtimeVars := utils.InterfaceToMapInterface(renderVars)
+	if runtimeVars == nil {
+		runtimeVars = make(map[string]any)
+	}
+	for k, v := range vars {
+		runtimeVars[k] = v
+	}
+
+	name := y.Name
+	if strings.Contains(name, "{{") && strings.Contains(name, "}}") {
+		rendered, err := RenderNucleiTagWithVar(name, runtimeVars)
+		if err != nil {
+			return utils.Errorf("render dns name[%v] failed: %s", name, err)
+		}
+		name = rendered
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return utils.Error("empty dns name")
+	}
+
+	msg, err := y.question(name)
+	if err != nil {
+		return err
+	}
+
+	resolvers := y.Resolvers
+	if len(resolvers) <= 0 {
+		resolvers = lowhttpConfig.DNSServers
+	}
+	if len(resolvers) <= 0 {
+		resolvers = netx.DefaultCustomDNSServers
+	}
+
+	ctx := lowhttpConfig.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := lowhttpConfig.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	retries := y.Retries
+	if retries <= 0 {
+		retries = 1
+	}
+
+	if config.Debug || config.DebugRequest {
+		fmt.Println("---------------------DNS REQUEST---------------------")
+		fmt.Println(msg.String())
+		fmt.Println("-----------------------------------------------------")
+	}
+
+	client := &dns.Client{Timeout: timeout}
+	var (
+		rsp      *dns.Msg
+		resolver string
+		lastErr  error
+	)
+	for i := 0; i < retries && rsp == nil; i++ {
+		for _, server := range resolvers {
+			server = utils.ToNsServer(server)
+			r, _, err := client.ExchangeContext(ctx, msg, server)
+			if err != nil {
+				log.Debugf("query [%s] failed from [%s]: %s", name, server, err)
+				lastErr = err
+				continue
+			}
+			rsp, resolver = r, server
+			break
+		}
+	}
+	if rsp == nil {
+		return utils.Errorf("query dns[%v] failed: %v", name, lastErr)
+	}
+
+	if config.Debug || config.DebugResponse {
+		fmt.Println("---------------------DNS RESPONSE---------------------")
+		fmt.Println(rsp.String())
+		fmt.Println("------------------------------------------------------")
+	}
+
+	var questions []string
+	for _, q := range rsp.Question {
+		questions = append(questions, q.String())
+	}
+	parts := map[string][]byte{
+		"answer":   []byte(dnsRecordsToString(rsp.Answer)),
+		"ns":       []byte(dnsRecordsToString(rsp.Ns)),
+		"extra":    []byte(dnsRecordsToString(rsp.Extra)),
+		"question": []byte(strings.Join(questions, "\n")),
+		"rcode":    []byte(dns.RcodeToString[rsp.Rcode]),
+		"raw":      []byte(rsp.String()),
+		"all":      []byte(rsp.String()),
+	}
+	protocolRsp := &NucleiProtocolResponse{
+		Protocol:   "dns",
+		RawRequest: []byte(msg.String()),
+		RawPacket:  []byte(rsp.String()),
+		RemoteAddr: resolver,
+		Vars:       make(map[string]any),
+	}
+	for k, v := range parts {
+		protocolRsp.Vars[k] = string(v)
+	}
+	protocolRsp.Vars["rcode"] = rsp.Rcode
+
+	matched, extractorResults := executeProtocolResponse(config, y.Matcher, y.Extractor, protocolRsp, parts, runtimeVars)
+	callback([]*NucleiProtocolResponse{protocolRsp}, matched, extractorResults)
+	return nil
+}
@@ -101,6 +101,10 @@ func (y *YakTemplate) ExecWithUrl(u string, config *Config, opts ...lowhttp.Lowh
 		config = NewConfig()
 	}
 
+	if len(y.Workflows) > 0 {
+		return y.execWorkflows(u, config, opts...)
+	}
+
 	var count int64 = 0
 	if y.ReverseConnectionNeed {
 		var err error
@@ -225,8 +229,12 @@ func (y *YakTemplate) ExecWithUrl(u string, config *Config, opts ...lowhttp.Lowh
 		}
 		swg.Wait()
 		return int(count), nil
+	} else if len(y.HeadlessRequestSequences) > 0 {
+		return y.execHeadless(u, config, opts...)
+	} else if len(y.DNSRequestSequences) > 0 || len(y.SSLRequestSequences) > 0 || len(y.FileRequestSequences) > 0 {
+		return y.execProtocols(u, config, opts...)
 	} else {
-		return 0, utils.Errorf("[%s] tcp/http is all empty!", y.Name)
+		return 0, utils.Errorf("[%s] tcp/http/headless/dns/ssl/file is all empty!", y.Name)
 	}
 }
 func (y *YakTemplate) Exec(config *Config, isHttps bool, reqOrigin []byte, opts ...lowhttp.LowhttpOpt) (int, error) {
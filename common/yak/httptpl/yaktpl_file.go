@@ -0,0 +1,136 @@
+package httptpl
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type YakFileBulkConfig struct {
+	// Extensions `all` means all files
+	Extensions []string
+	DenyList   []string
+	MaxSize    int64
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+func (y *YakFileBulkConfig) denied(path string) bool {
+	for _, deny := range y.DenyList {
+		deny = strings.TrimSpace(deny)
+		if deny == "" {
+			continue
+		}
+		if strings.HasPrefix(deny, ".") && strings.EqualFold(filepath.Ext(path), deny) {
+			return true
+		}
+		if strings.Contains(filepath.ToSlash(path), deny) {
+			return true
+		}
+	}
+	return false
+}
+
+func (y *YakFileBulkConfig) allowed(path string) bool {
+	if y.denied(path) {
+		return false
+	}
+	if len(y.Extensions) <= 0 {
+		return true
+	}
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, allow := range y.Extensions {
+		allow = strings.TrimPrefix(strings.TrimSpace(allow), ".")
+		if strings.EqualFold(allow, "all") || strings.EqualFold(allow, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func (y *YakFileBulkConfig) readFile(path string) ([]byte, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	maxSize := y.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultFileMaxSize
+	}
+	return io.ReadAll(io.LimitReader(fp, maxSize))
+}
+
+// Execute the target is a local file or directory, every file will be matched and reported separately
+func (y *YakFileBulkConfig) Execute(
+	config *Config, target string, vars map[string]any,
+	callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any),
+) error {
+	target = strings.TrimPrefix(target, "file://")
+	info, err := os.Stat(target)
+	if err != nil {
+		return utils.Errorf("stat file target[%v] failed: %s", target, err)
+	}
+
+	var files []string
+	if info.IsDir() {
+		err = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				log.Debugf("walk %v failed: %s", path, err)
+				return nil
+			}
+			if info.IsDir() {
+				if path != target && y.denied(path+string(filepath.Separator)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if y.allowed(path) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return utils.Errorf("walk file target[%v] failed: %s", target, err)
+		}
+	} else {
+		files = append(files, target)
+	}
+
+	for _, path := range files {
+		content, err := y.readFile(path)
+		if err != nil {
+			log.Warnf("read file %v failed: %s", path, err)
+			continue
+		}
+		if config.Debug || config.DebugResponse {
+			fmt.Printf("---------------------FILE %v---------------------\n", path)
+			fmt.Println(string(content))
+		}
+		parts := map[string][]byte{
+			"raw":  content,
+			"body": content,
+			"data": content,
+			"all":  content,
+		}
+		protocolRsp := &NucleiProtocolResponse{
+			Protocol:   "file",
+			RawRequest: []byte(path),
+			RawPacket:  content,
+			RemoteAddr: path,
+			Vars: map[string]any{
+				"path": path,
+				"raw":  string(content),
+			},
+		}
+		matched, extractorResults := executeProtocolResponse(config, y.Matcher, y.Extractor, protocolRsp, parts, vars)
+		callback([]*NucleiProtocolResponse{protocolRsp}, matched, extractorResults)
+	}
+	return nil
+}
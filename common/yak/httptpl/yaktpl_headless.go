@@ -0,0 +1,205 @@
+package httptpl
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/yaklang/yaklang/common/crawlerx"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	utils2 "github.com/yaklang/yaklang/common/yak/httptpl/utils"
+)
+
+type YakHeadlessStep struct {
+	Name   string
+	Action string
+	Args   map[string]string
+}
+
+type YakHeadlessBulkConfig struct {
+	Steps []*YakHeadlessStep
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+// buildHeadlessDOMResponse wrap the DOM as a http response, so the YakMatcher / YakExtractor can use it directly
+func buildHeadlessDOMResponse(result *crawlerx.HeadlessResult) *lowhttp.LowhttpResponse {
+	var buf bytes.Buffer
+	buf.WriteString("HTTP/1.1 200 OK\r\n")
+	buf.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	buf.WriteString(fmt.Sprintf("Content-Length: %d\r\n\r\n", len(result.DOM)))
+	buf.WriteString(result.DOM)
+
+	rsp := &lowhttp.LowhttpResponse{
+		RawPacket: buf.Bytes(),
+		Url:       result.Url,
+	}
+	for _, traffic := range result.Traffic {
+		if traffic.Response == nil {
+			continue
+		}
+		rsp.RemoteAddr = traffic.Response.RemoteAddr
+		rsp.RawRequest = traffic.Request
+		break
+	}
+	return rsp
+}
+
+// headlessParts named steps and the network history can be used as matcher part
+func headlessParts(result *crawlerx.HeadlessResult) map[string][]byte {
+	parts := make(map[string][]byte)
+	for name, data := range result.Extracted {
+		parts[name] = []byte(data)
+	}
+	var history bytes.Buffer
+	for _, traffic := range result.Traffic {
+		history.Write(traffic.Request)
+		history.WriteString("\r\n")
+		if traffic.Response != nil {
+			history.Write(traffic.Response.RawPacket)
+			history.WriteString("\r\n")
+		}
+	}
+	parts["history"] = history.Bytes()
+	return parts
+}
+
+func (y *YakHeadlessBulkConfig) Execute(
+	config *Config, u string, vars map[string]any, opts []crawlerx.ConfigOpt,
+	callback func(rsp []*lowhttp.LowhttpResponse, matched bool, extractorResults map[string]any),
+) error {
+	renderVars := utils.InterfaceToMapInterface(utils2.ExtractorVarsFromUrl(u))
+	if renderVars == nil {
+		renderVars = make(map[string]any)
+	}
+	for k, v := range vars {
+		renderVars[k] = v
+	}
+
+	var actions []*crawlerx.HeadlessAction
+	for _, step := range y.Steps {
+		action := &crawlerx.HeadlessAction{
+			Name:   step.Name,
+			Action: step.Action,
+			Args:   make(map[string]string),
+		}
+		for k, v := range step.Args {
+			if strings.Contains(v, "{{") && strings.Contains(v, "}}") {
+				rendered, err := RenderNucleiTagWithVar(v, renderVars)
+				if err != nil {
+					log.Warnf("YakHeadlessBulkConfig render arg[%v] failed: %s", k, err)
+				} else {
+					v = rendered
+				}
+			}
+			action.Args[k] = v
+		}
+		actions = append(actions, action)
+	}
+
+	if config.Debug || config.DebugRequest {
+		fmt.Println("---------------------HEADLESS STEPS---------------------")
+		spew.Dump(actions)
+		fmt.Println("--------------------------------------------------------")
+	}
+
+	result, err := crawlerx.RunHeadlessActions(u, actions, opts...)
+	if result == nil {
+		return utils.Errorf("run headless actions failed: %v", err)
+	}
+	if err != nil {
+		log.Warnf("run headless actions failed: %s", err)
+	}
+
+	responses := []*lowhttp.LowhttpResponse{buildHeadlessDOMResponse(result)}
+	for _, traffic := range result.Traffic {
+		if traffic.Response != nil {
+			responses = append(responses, traffic.Response)
+		}
+	}
+	if config.Debug || config.DebugResponse {
+		fmt.Println("---------------------HEADLESS DOM---------------------")
+		fmt.Println(result.DOM)
+		fmt.Println("------------------------------------------------------")
+	}
+
+	extractorResults := make(map[string]any)
+	for k, v := range result.Extracted {
+		vars[k] = v
+		extractorResults[k] = v
+	}
+	for _, extractor := range y.Extractor {
+		for _, rsp := range responses {
+			extractorVars, err := extractor.Execute(rsp.RawPacket, vars)
+			if err != nil {
+				log.Warnf("YakHeadlessBulkConfig extractor.Execute failed: %s", err)
+				continue
+			}
+			for k, v := range extractorVars {
+				v := ExtractResultToString(v)
+				if v == "" {
+					continue
+				}
+				vars[k] = v
+				extractorResults[k] = v
+			}
+		}
+	}
+
+	var matched bool
+	if y.Matcher != nil {
+		var packets [][]byte
+		for _, rsp := range responses {
+			packets = append(packets, rsp.RawPacket)
+		}
+		matched = y.Matcher.ExecuteWithParts(config, headlessParts(result), packets, vars)
+	}
+	callback(responses, matched, extractorResults)
+	return nil
+}
+
+func (y *YakTemplate) execHeadless(u string, config *Config, opts ...lowhttp.LowhttpOpt) (int, error) {
+	if !config.EnableHeadless {
+		log.Infof("skip headless template %v, headless is not enabled", y.Name)
+		return 0, nil
+	}
+
+	lowhttpConfig := lowhttp.NewLowhttpOption()
+	for _, opt := range opts {
+		opt(lowhttpConfig)
+	}
+	crawlerOpts := append([]crawlerx.ConfigOpt{}, config.HeadlessOptions...)
+	if len(lowhttpConfig.Proxy) > 0 {
+		crawlerOpts = append(crawlerOpts, crawlerx.WithBrowserInfo(string(utils.Jsonify(map[string]string{
+			"proxy_address": lowhttpConfig.Proxy[0],
+		}))))
+	}
+	if lowhttpConfig.Ctx != nil {
+		crawlerOpts = append(crawlerOpts, crawlerx.WithContext(lowhttpConfig.Ctx))
+	}
+	if lowhttpConfig.RuntimeId != "" {
+		crawlerOpts = append(crawlerOpts, crawlerx.WithRuntimeID(lowhttpConfig.RuntimeId))
+	}
+
+	var count int64
+	for _, headlessReq := range y.HeadlessRequestSequences {
+		headlessReq := headlessReq
+		vars := y.Variables.ToMap()
+		err := headlessReq.Execute(config, u, vars, crawlerOpts, func(rsp []*lowhttp.LowhttpResponse, matched bool, extractorResults map[string]any) {
+			atomic.AddInt64(&count, 1)
+			config.ExecuteHeadlessResultCallback(y, headlessReq, rsp, matched, extractorResults)
+			if matched {
+				log.Infof("[%v]-[%v] matched", y.Name, y.Id)
+			}
+		})
+		if err != nil {
+			log.Errorf("headlessReq.Execute failed: %s", err)
+		}
+	}
+	return int(count), nil
+}
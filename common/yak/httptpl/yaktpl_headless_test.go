@@ -0,0 +1,74 @@
+package httptpl_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/vulinbox"
+	"github.com/yaklang/yaklang/common/yak/httptpl"
+)
+
+func TestMockTest_Headless_Vulinbox(t *testing.T) {
+	ctx, cancel := context.WithCancel(utils.TimeoutContextSeconds(60))
+	defer cancel()
+	addr, err := vulinbox.NewVulinServerEx(ctx, true, false, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := utils.ParseStringToHostPort(addr)
+	target := "http://" + utils.HostPort(host, port)
+
+	tpl, err := httptpl.CreateYakTemplateFromNucleiTemplateRaw(`
+id: headless-xss-echo
+info:
+  name: Headless XSS Echo
+  author: test
+  severity: medium
+
+headless:
+  - steps:
+      - action: navigate
+        args:
+          url: "{{BaseURL}}/xss/echo?name=%3Cb%20id%3D%22yakheadless%22%3Eheadless%3C%2Fb%3E"
+      - action: waitload
+      - action: extract
+        name: marker
+        args:
+          by: selector
+          selector: "#yakheadless"
+      - action: script
+        name: location
+        args:
+          code: "() => location.pathname"
+    matchers-condition: and
+    matchers:
+      - type: word
+        part: marker
+        words:
+          - "headless"
+      - type: word
+        part: location
+        words:
+          - "/xss/echo"
+      - type: word
+        part: history
+        words:
+          - "GET /xss/echo"
+`)
+	require.NoError(t, err)
+
+	var matched bool
+	config := httptpl.NewConfig(
+		httptpl.WithEnableHeadless(true),
+		httptpl.WithHeadlessResultCallback(func(y *httptpl.YakTemplate, reqBulk *httptpl.YakHeadlessBulkConfig, rsp []*lowhttp.LowhttpResponse, result bool, extractor map[string]interface{}) {
+			matched = result
+			require.Equal(t, "headless", extractor["marker"])
+		}),
+	)
+	_, err = tpl.ExecWithUrl(target, config, lowhttp.WithContext(ctx))
+	require.NoError(t, err)
+	require.True(t, matched)
+}
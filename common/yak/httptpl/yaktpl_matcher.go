@@ -29,6 +29,9 @@ func NewMatcherFromGRPCModel(m *ypb.HTTPResponseMatcher) *YakMatcher {
 }
 
 type YakMatcher struct {
+	// Name is used by workflows to choose subtemplates
+	Name string
+
 	// status
 	// content_length
 	// binary
@@ -369,3 +372,38 @@ func (y *YakMatcher) execute(config *Config, rspIns *lowhttp.LowhttpResponse, va
 	var duration = rspIns.GetDurationFloat()
 	return y.executeRaw(config, rsp, duration, vars, sufs...)
 }
+
+// ExecuteWithParts evaluate matcher on named parts (dns answer, headless named data...),
+// the matcher whose scope is not in parts will be executed on defaults, any packet matched means matched
+func (y *YakMatcher) ExecuteWithParts(config *Config, parts map[string][]byte, defaults [][]byte, vars map[string]any) bool {
+	if len(y.SubMatchers) > 0 {
+		isOr := strings.TrimSpace(strings.ToLower(y.SubMatcherCondition)) == "or"
+		for _, matcher := range y.SubMatchers {
+			matched := matcher.ExecuteWithParts(config, parts, defaults, vars)
+			if isOr && matched {
+				return true
+			}
+			if !isOr && !matched {
+				return false
+			}
+		}
+		return !isOr
+	}
+
+	leaf := *y
+	packets := defaults
+	if part, ok := parts[y.Scope]; ok {
+		leaf.Scope = "raw"
+		packets = [][]byte{part}
+	}
+	for _, packet := range packets {
+		matched, err := leaf.ExecuteRawWithConfig(config, packet, vars)
+		if err != nil {
+			log.Warnf("execute matcher with parts failed: %s", err)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package httptpl
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	utils2 "github.com/yaklang/yaklang/common/yak/httptpl/utils"
+)
+
+// NucleiProtocolResponse is the response of dns / ssl / file requests
+type NucleiProtocolResponse struct {
+	// dns / ssl / file
+	Protocol   string
+	RawRequest []byte
+	RawPacket  []byte
+	// RemoteAddr is the resolver for dns, the address for ssl and the file path for file
+	RemoteAddr string
+	// Vars can be used in nuclei-dsl matchers and extractors
+	Vars map[string]any
+}
+
+type ProtocolResultCallback func(y *YakTemplate, reqBulk any /* *YakDNSBulkConfig / *YakSSLBulkConfig / *YakFileBulkConfig */, rsp []*NucleiProtocolResponse, result bool, extractor map[string]interface{})
+
+func ProtocolResultCallbackWrapper(callback ProtocolResultCallback) ResultCallback {
+	return func(y *YakTemplate, reqBulk any, rsp any, result bool, extractor map[string]interface{}) {
+		results, ok := rsp.([]*NucleiProtocolResponse)
+		if !ok {
+			return
+		}
+		callback(y, reqBulk, results, result, extractor)
+	}
+}
+
+func WithProtocolResultCallback(f ProtocolResultCallback) ConfigOption {
+	return func(config *Config) {
+		config.AppendResultCallback(ProtocolResultCallbackWrapper(f))
+	}
+}
+
+// executeProtocolResponse run extractors and matcher on one protocol response
+func executeProtocolResponse(config *Config, matcher *YakMatcher, extractors []*YakExtractor, rsp *NucleiProtocolResponse, parts map[string][]byte, vars map[string]any) (bool, map[string]any) {
+	runtimeVars := make(map[string]any)
+	for k, v := range vars {
+		runtimeVars[k] = v
+	}
+	for k, v := range rsp.Vars {
+		runtimeVars[k] = v
+	}
+
+	extractorResults := make(map[string]any)
+	for _, extractor := range extractors {
+		material := rsp.RawPacket
+		if part, ok := parts[extractor.Scope]; ok {
+			material = part
+		}
+		extractorVars, err := extractor.Execute(material, runtimeVars)
+		if err != nil {
+			log.Warnf("%v extractor.Execute failed: %s", rsp.Protocol, err)
+			continue
+		}
+		for k, v := range extractorVars {
+			v := ExtractResultToString(v)
+			runtimeVars[k] = v
+			extractorResults[k] = v
+		}
+	}
+
+	if matcher == nil {
+		return false, extractorResults
+	}
+	return matcher.ExecuteWithParts(config, parts, [][]byte{rsp.RawPacket}, runtimeVars), extractorResults
+}
+
+func (y *YakTemplate) execProtocols(u string, config *Config, opts ...lowhttp.LowhttpOpt) (int, error) {
+	lowhttpConfig := lowhttp.NewLowhttpOption()
+	for _, opt := range opts {
+		opt(lowhttpConfig)
+	}
+
+	var count int64
+	vars := y.Variables.ToMap()
+	target := u
+	if !strings.Contains(target, "://") {
+		// dns / ssl targets are usually host or host:port
+		target = "https://" + target
+	}
+	renderVars := utils2.ExtractorVarsFromUrl(target)
+	if renderVars == nil {
+		renderVars = make(map[string]string)
+	}
+	// dns vars
+	renderVars["FQDN"] = renderVars["Host"]
+	renderVars["DN"] = renderVars["Host"]
+
+	for _, dnsReq := range y.DNSRequestSequences {
+		dnsReq := dnsReq
+		err := dnsReq.Execute(config, vars, renderVars, lowhttpConfig, func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any) {
+			atomic.AddInt64(&count, 1)
+			config.ExecuteProtocolResultCallback(y, dnsReq, rsp, matched, extractorResults)
+		})
+		if err != nil {
+			log.Errorf("dnsReq.Execute failed: %s", err)
+		}
+	}
+
+	for _, sslReq := range y.SSLRequestSequences {
+		sslReq := sslReq
+		err := sslReq.Execute(config, vars, renderVars, lowhttpConfig, func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any) {
+			atomic.AddInt64(&count, 1)
+			config.ExecuteProtocolResultCallback(y, sslReq, rsp, matched, extractorResults)
+		})
+		if err != nil {
+			log.Errorf("sslReq.Execute failed: %s", err)
+		}
+	}
+
+	if len(y.FileRequestSequences) > 0 && !config.EnableFileTemplate {
+		log.Infof("skip file requests of template %v, file template is not enabled", y.Name)
+		return int(count), nil
+	}
+	for _, fileReq := range y.FileRequestSequences {
+		fileReq := fileReq
+		err := fileReq.Execute(config, u, vars, func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any) {
+			atomic.AddInt64(&count, 1)
+			config.ExecuteProtocolResultCallback(y, fileReq, rsp, matched, extractorResults)
+		})
+		if err != nil {
+			log.Errorf("fileReq.Execute failed: %s", err)
+		}
+	}
+	return int(count), nil
+}
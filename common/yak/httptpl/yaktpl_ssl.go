@@ -0,0 +1,177 @@
+package httptpl
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/netx"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+)
+
+type YakSSLBulkConfig struct {
+	Address      string
+	MinVersion   string
+	MaxVersion   string
+	CipherSuites []string
+
+	Matcher   *YakMatcher
+	Extractor []*YakExtractor
+}
+
+var sslVersionMap = map[string]uint16{
+	"tls10": tls.VersionTLS10,
+	"tls11": tls.VersionTLS11,
+	"tls12": tls.VersionTLS12,
+	"tls13": tls.VersionTLS13,
+}
+
+var sslVersionNameMap = map[uint16]string{
+	tls.VersionTLS10: "tls10",
+	tls.VersionTLS11: "tls11",
+	tls.VersionTLS12: "tls12",
+	tls.VersionTLS13: "tls13",
+}
+
+func (y *YakSSLBulkConfig) tlsConfig(serverName string) (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         serverName,
+	}
+	if y.MinVersion != "" {
+		version, ok := sslVersionMap[y.MinVersion]
+		if !ok {
+			return nil, utils.Errorf("unsupported ssl min_version: %v", y.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	if y.MaxVersion != "" {
+		version, ok := sslVersionMap[y.MaxVersion]
+		if !ok {
+			return nil, utils.Errorf("unsupported ssl max_version: %v", y.MaxVersion)
+		}
+		config.MaxVersion = version
+	}
+	if len(y.CipherSuites) > 0 {
+		suites := make(map[string]uint16)
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			suites[suite.Name] = suite.ID
+		}
+		for _, name := range y.CipherSuites {
+			id, ok := suites[strings.TrimSpace(name)]
+			if !ok {
+				return nil, utils.Errorf("unsupported ssl cipher suite: %v", name)
+			}
+			config.CipherSuites = append(config.CipherSuites, id)
+		}
+	}
+	return config, nil
+}
+
+// sslCertificateInfo the fields are the same as nuclei ssl response
+func sslCertificateInfo(state tls.ConnectionState, host string) map[string]any {
+	info := map[string]any{
+		"tls_version": sslVersionNameMap[state.Version],
+		"cipher":      tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) <= 0 {
+		return info
+	}
+	cert := state.PeerCertificates[0]
+	fingerprint := sha256.Sum256(cert.Raw)
+	info["subject_dn"] = cert.Subject.String()
+	info["subject_cn"] = cert.Subject.CommonName
+	info["subject_an"] = cert.DNSNames
+	info["issuer_dn"] = cert.Issuer.String()
+	info["issuer_cn"] = cert.Issuer.CommonName
+	info["serial"] = cert.SerialNumber.String()
+	info["not_before"] = cert.NotBefore.Unix()
+	info["not_after"] = cert.NotAfter.Unix()
+	info["expired"] = time.Now().After(cert.NotAfter)
+	info["self_signed"] = cert.Subject.String() == cert.Issuer.String() && cert.CheckSignatureFrom(cert) == nil
+	info["mismatched"] = host != "" && cert.VerifyHostname(host) != nil
+	info["fingerprint_hash"] = hex.EncodeToString(fingerprint[:])
+	return info
+}
+
+func (y *YakSSLBulkConfig) Execute(
+	config *Config, vars map[string]any, renderVars map[string]string, lowhttpConfig *lowhttp.LowhttpExecConfig,
+	callback func(rsp []*NucleiProtocolResponse, matched bool, extractorResults map[string]any),
+) error {
+	runtimeVars := utils.InterfaceToMapInterface(renderVars)
+	if runtimeVars == nil {
+		runtimeVars = make(map[string]any)
+	}
+	for k, v := range vars {
+		runtimeVars[k] = v
+	}
+
+	address, err := RenderNucleiTagWithVar(y.Address, runtimeVars)
+	if err != nil {
+		return utils.Errorf("render ssl address[%v] failed: %s", y.Address, err)
+	}
+	host, port, err := utils.ParseStringToHostPort(address)
+	if err != nil {
+		return utils.Errorf("parse ssl address[%v] failed: %s", address, err)
+	}
+	address = utils.HostPort(host, port)
+
+	tlsConfig, err := y.tlsConfig(host)
+	if err != nil {
+		return err
+	}
+
+	timeout := lowhttpConfig.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	conn, err := netx.DialTCPTimeout(timeout, address, lowhttpConfig.Proxy...)
+	if err != nil {
+		return utils.Errorf("dial %v failed: %s", address, err)
+	}
+	defer conn.Close()
+	tlsConn := tls.Client(conn, tlsConfig)
+	_ = tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		return utils.Errorf("tls handshake with %v failed: %s", address, err)
+	}
+
+	info := sslCertificateInfo(tlsConn.ConnectionState(), host)
+	raw, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return utils.Errorf("marshal ssl response failed: %s", err)
+	}
+	if config.Debug || config.DebugResponse {
+		fmt.Println("---------------------SSL RESPONSE---------------------")
+		fmt.Println(string(raw))
+		fmt.Println("------------------------------------------------------")
+	}
+
+	parts := map[string][]byte{
+		"raw": raw,
+		"all": raw,
+	}
+	for k, v := range info {
+		switch ret := v.(type) {
+		case string:
+			parts[k] = []byte(ret)
+		case []string:
+			parts[k] = []byte(strings.Join(ret, "\n"))
+		}
+	}
+	protocolRsp := &NucleiProtocolResponse{
+		Protocol:   "ssl",
+		RawRequest: []byte(address),
+		RawPacket:  raw,
+		RemoteAddr: address,
+		Vars:       info,
+	}
+	matched, extractorResults := executeProtocolResponse(config, y.Matcher, y.Extractor, protocolRsp, parts, runtimeVars)
+	callback([]*NucleiProtocolResponse{protocolRsp}, matched, extractorResults)
+	return nil
+}
@@ -0,0 +1,361 @@
+package httptpl
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/filter"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/utils/bizhelper"
+	"github.com/yaklang/yaklang/common/utils/lowhttp"
+	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
+)
+
+// maxWorkflowDepth avoid workflows referencing each other forever
+const maxWorkflowDepth = 8
+
+type YakWorkflow struct {
+	// Template is the id of the referenced template (path and ext is trimmed)
+	Template string
+	// Tags select all templates with any of these tags
+	Tags []string
+
+	// Subtemplates will be executed when the templates above matched
+	Subtemplates []*YakWorkflow
+	// Matchers gate subtemplates by the name of parent's matchers / extractors
+	Matchers []*YakWorkflowMatcher
+}
+
+type YakWorkflowMatcher struct {
+	Names []string
+	// or
+	// and
+	Condition    string
+	Subtemplates []*YakWorkflow
+}
+
+// Match checks the matcher names (and extractor names) hit by parent templates
+func (m *YakWorkflowMatcher) Match(hitNames map[string]struct{}) bool {
+	if len(m.Names) <= 0 {
+		return false
+	}
+	has := func(name string) bool {
+		_, ok := hitNames[strings.ToLower(name)]
+		return ok
+	}
+	if m.Condition == "and" {
+		for _, name := range m.Names {
+			if !has(name) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, name := range m.Names {
+		if has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkflowTemplateLoader resolve the templates referenced by a workflow
+type WorkflowTemplateLoader func(template string, tags []string) ([]*YakTemplate, error)
+
+func WithWorkflowTemplateLoader(loader WorkflowTemplateLoader) ConfigOption {
+	return func(config *Config) {
+		config.WorkflowTemplateLoader = loader
+	}
+}
+
+// WithWorkflowTemplates register loaded templates, workflows will search them by id / tags before database
+func WithWorkflowTemplates(tpls ...*YakTemplate) ConfigOption {
+	return func(config *Config) {
+		config.WorkflowTemplates = append(config.WorkflowTemplates, tpls...)
+	}
+}
+
+func (c *Config) loadWorkflowTemplates(template string, tags []string) ([]*YakTemplate, error) {
+	if c.WorkflowTemplateLoader != nil {
+		return c.WorkflowTemplateLoader(template, tags)
+	}
+
+	var results []*YakTemplate
+	scriptFilter := filter.NewFilter()
+	feedback := func(t *YakTemplate) {
+		key := t.Id
+		if key == "" {
+			key = t.Name
+		}
+		if scriptFilter.Exist(key) {
+			return
+		}
+		scriptFilter.Insert(key)
+		results = append(results, t)
+	}
+
+	for _, tpl := range c.WorkflowTemplates {
+		if tpl == nil {
+			continue
+		}
+		if template != "" && (tpl.Id == template || tpl.Name == template) {
+			feedback(tpl)
+			continue
+		}
+		if workflowTagsMatched(tpl.Tags, tags) {
+			feedback(tpl)
+		}
+	}
+	if len(results) > 0 {
+		return results, nil
+	}
+
+	db := consts.GetGormProfileDatabase()
+	if db == nil {
+		return nil, utils.Error("cannot load workflow templates: database is not initialized")
+	}
+	if template != "" {
+		script, err := yakit.GetNucleiYakScriptByName(db, template)
+		if err != nil {
+			log.Warnf("load workflow template[%v] failed: %s", template, err)
+		} else {
+			tpl, err := CreateYakTemplateFromNucleiTemplateRaw(script.Content)
+			if err != nil {
+				log.Warnf("create workflow template[%v] failed: %s", template, err)
+			} else {
+				feedback(tpl)
+			}
+		}
+	}
+	if len(tags) > 0 {
+		query := bizhelper.FuzzSearchWithStringArrayOrEx(db.Where("type = 'nuclei'"), []string{"tags"}, tags, false)
+		for script := range yakit.YieldYakScripts(query, context.Background()) {
+			tpl, err := CreateYakTemplateFromNucleiTemplateRaw(script.Content)
+			if err != nil {
+				log.Warnf("create workflow template(tags) failed: %s", err)
+				continue
+			}
+			feedback(tpl)
+		}
+	}
+	if len(results) <= 0 {
+		return nil, utils.Errorf("no template found for workflow: template[%v] tags%v", template, tags)
+	}
+	return results, nil
+}
+
+func workflowTagsMatched(tplTags []string, tags []string) bool {
+	for _, tag := range tags {
+		for _, tplTag := range tplTags {
+			if strings.EqualFold(strings.TrimSpace(tplTag), strings.TrimSpace(tag)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type workflowRuntime struct {
+	url    string
+	config *Config
+	opts   []lowhttp.LowhttpOpt
+
+	mutex *sync.Mutex
+	// shared variables between templates in one workflow
+	vars map[string]any
+}
+
+type workflowTemplateResult struct {
+	matched  bool
+	hitNames map[string]struct{}
+}
+
+func (y *YakTemplate) execWorkflows(u string, config *Config, opts ...lowhttp.LowhttpOpt) (int, error) {
+	runtime := &workflowRuntime{
+		url:    u,
+		config: config,
+		opts:   opts,
+		mutex:  new(sync.Mutex),
+		vars:   y.Variables.ToMap(),
+	}
+	var count int
+	for _, wf := range y.Workflows {
+		count += runtime.run(wf, 0)
+	}
+	return count, nil
+}
+
+func (r *workflowRuntime) run(wf *YakWorkflow, depth int) int {
+	if depth > maxWorkflowDepth {
+		log.Warnf("workflow is too deep(>%v), template[%v] tags%v skipped", maxWorkflowDepth, wf.Template, wf.Tags)
+		return 0
+	}
+
+	tpls, err := r.config.loadWorkflowTemplates(wf.Template, wf.Tags)
+	if err != nil {
+		log.Errorf("load workflow templates failed: %s", err)
+		return 0
+	}
+
+	var (
+		count    int
+		matched  bool
+		hitNames = make(map[string]struct{})
+	)
+	for _, tpl := range tpls {
+		if len(tpl.Workflows) > 0 {
+			// nested workflow file, always continue with its own chains
+			for _, sub := range tpl.Workflows {
+				count += r.run(sub, depth+1)
+			}
+			continue
+		}
+		n, result := r.execTemplate(tpl)
+		count += n
+		if result.matched || len(result.hitNames) > 0 {
+			matched = true
+		}
+		for name := range result.hitNames {
+			hitNames[name] = struct{}{}
+		}
+	}
+	if !matched {
+		return count
+	}
+
+	if len(wf.Matchers) <= 0 {
+		for _, sub := range wf.Subtemplates {
+			count += r.run(sub, depth+1)
+		}
+		return count
+	}
+	for _, matcher := range wf.Matchers {
+		if !matcher.Match(hitNames) {
+			continue
+		}
+		for _, sub := range matcher.Subtemplates {
+			count += r.run(sub, depth+1)
+		}
+	}
+	return count
+}
+
+func (r *workflowRuntime) execTemplate(tpl *YakTemplate) (int, *workflowTemplateResult) {
+	result := &workflowTemplateResult{hitNames: make(map[string]struct{})}
+
+	// do not modify the origin template, it may be shared by other workflows
+	ins := *tpl
+	ins.Variables = NewVars()
+	if tpl.Variables != nil {
+		for k, v := range tpl.Variables.GetRaw() {
+			ins.Variables.SetWithType(k, v.Data, string(v.Type))
+		}
+	}
+	r.mutex.Lock()
+	for k, v := range r.vars {
+		ins.Variables.Set(k, utils.InterfaceToString(v))
+	}
+	r.mutex.Unlock()
+
+	subConfig := *r.config
+	subConfig.Callback = func(y *YakTemplate, reqBulk any, rsp any, matched bool, extracted map[string]any) {
+		r.mutex.Lock()
+		if matched {
+			result.matched = true
+		}
+		for k, v := range extracted {
+			r.vars[k] = v
+			if v != nil && utils.InterfaceToString(v) != "" {
+				result.hitNames[strings.ToLower(k)] = struct{}{}
+			}
+		}
+		vars := utils.MergeGeneralMap(r.vars, extracted)
+		r.mutex.Unlock()
+
+		for _, name := range hitMatcherNames(r.config, reqBulk, rsp, vars) {
+			r.mutex.Lock()
+			result.hitNames[strings.ToLower(name)] = struct{}{}
+			r.mutex.Unlock()
+		}
+
+		// all results in a workflow share the same stream
+		if r.config.Callback != nil {
+			r.config.Callback(y, reqBulk, rsp, matched, extracted)
+		}
+	}
+
+	count, err := ins.ExecWithUrl(r.url, &subConfig, r.opts...)
+	if err != nil {
+		log.Errorf("workflow exec template[%v] failed: %s", tpl.Id, err)
+	}
+	return count, result
+}
+
+// hitMatcherNames evaluate named matchers one by one, nuclei workflows use them to choose subtemplates
+func hitMatcherNames(config *Config, reqBulk any, rsp any, vars map[string]any) []string {
+	var matcher *YakMatcher
+	switch ret := reqBulk.(type) {
+	case *YakRequestBulkConfig:
+		matcher = ret.Matcher
+	case *YakNetworkBulkConfig:
+		matcher = ret.Matcher
+	case *YakHeadlessBulkConfig:
+		matcher = ret.Matcher
+	case *YakDNSBulkConfig:
+		matcher = ret.Matcher
+	case *YakSSLBulkConfig:
+		matcher = ret.Matcher
+	case *YakFileBulkConfig:
+		matcher = ret.Matcher
+	}
+	named := matcher.namedMatchers()
+	if len(named) <= 0 {
+		return nil
+	}
+
+	var names []string
+	for _, m := range named {
+		switch ret := rsp.(type) {
+		case []*lowhttp.LowhttpResponse:
+			for _, r := range ret {
+				if ok, _ := m.ExecuteWithConfig(config, r, vars); ok {
+					names = append(names, m.Name)
+					break
+				}
+			}
+		case []*NucleiTcpResponse:
+			for _, r := range ret {
+				if ok, _ := m.ExecuteRawWithConfig(config, r.RawPacket, vars); ok {
+					names = append(names, m.Name)
+					break
+				}
+			}
+		case []*NucleiProtocolResponse:
+			for _, r := range ret {
+				runtimeVars := utils.MergeGeneralMap(vars, r.Vars)
+				if ok, _ := m.ExecuteRawWithConfig(config, r.RawPacket, runtimeVars); ok {
+					names = append(names, m.Name)
+					break
+				}
+			}
+		}
+	}
+	return names
+}
+
+func (y *YakMatcher) namedMatchers() []*YakMatcher {
+	if y == nil {
+		return nil
+	}
+	var result []*YakMatcher
+	if y.Name != "" {
+		result = append(result, y)
+	}
+	for _, sub := range y.SubMatchers {
+		result = append(result, sub.namedMatchers()...)
+	}
+	return result
+}
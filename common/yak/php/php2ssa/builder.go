@@ -3,40 +3,116 @@ package php2ssa
 import (
 	"github.com/antlr/antlr4/runtime/Go/antlr/v4"
 	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yak/antlr4util"
 	phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
 	"github.com/yaklang/yaklang/common/yak/ssa"
+	"github.com/yaklang/yaklang/common/yak/ssa4analyze"
 )
 
+type Parser struct {
+}
+
+func NewParser() *Parser {
+	return &Parser{}
+}
+
+func (p *Parser) Parse(src string, must bool, callBack func(*ssa.FunctionBuilder)) (*ssa.Program, error) {
+	return parseSSA(src, must, nil, callBack)
+}
+
+func (p *Parser) Feed(src string, must bool, prog *ssa.Program) {
+	parseSSA(src, must, prog, nil)
+}
+
+// ParseProject parse all php files in the root directory, include / require is resolved in the project
+func (p *Parser) ParseProject(root string, must bool, callBack func(*ssa.FunctionBuilder)) (*ssa.Program, error) {
+	return parseProjectSSA(root, must, callBack)
+}
+
 type builder struct {
 	ast  phpparser.IHtmlDocumentContext
 	prog *ssa.Program
 	ir   *ssa.FunctionBuilder
+
+	// classes declared, used to inherit members
+	classes      map[string]*phpClass
+	classObjects map[ssa.Value]*phpClass
+	currentClass *phpClass
+
+	// project is nil when parsing a single file
+	project     *phpProject
+	currentFile string
+}
+
+func newBuilder(prog *ssa.Program, ir *ssa.FunctionBuilder) *builder {
+	return &builder{
+		prog:         prog,
+		ir:           ir,
+		classes:      make(map[string]*phpClass),
+		classObjects: make(map[ssa.Value]*phpClass),
+	}
 }
 
 func ParseSSA(src string, f func(builder *ssa.FunctionBuilder)) (prog *ssa.Program) {
-	lex := phpparser.NewPHPLexer(antlr.NewInputStream(src))
-	tokenStream := antlr.NewCommonTokenStream(lex, antlr.TokenDefaultChannel)
+	prog, err := parseSSA(src, true, nil, f)
+	if err != nil {
+		log.Errorf("parse php ssa failed: %s", err)
+	}
+	if prog == nil {
+		return ssa.NewProgram()
+	}
+	for _, r := range prog.GetErrors() {
+		log.Errorf("ssa-ir program error: %v", r)
+	}
+	return prog
+}
+
+func parseSSA(src string, force bool, prog *ssa.Program, callback func(*ssa.FunctionBuilder)) (ret *ssa.Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret = nil
+			err = utils.Errorf("parse error with panic : %v", r)
+		}
+	}()
+
+	ast, err := frontend(src, force)
+	if err != nil {
+		return nil, err
+	}
+	if prog == nil {
+		prog = ssa.NewProgram()
+	}
+	main := prog.GetAndCreateMainFunctionBuilder()
+	if callback != nil {
+		callback(main)
+	}
+	y := newBuilder(prog, main)
+	y.ast = ast
+	y.Build()
+
+	ssa4analyze.RunAnalyzer(prog)
+	return prog, nil
+}
+
+func frontend(src string, must bool) (phpparser.IHtmlDocumentContext, error) {
+	errListener := antlr4util.NewErrorListener()
+	lexer := phpparser.NewPHPLexer(antlr.NewInputStream(src))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errListener)
+	tokenStream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
 	parser := phpparser.NewPHPParser(tokenStream)
 	parser.RemoveErrorListeners()
-	parser.AddErrorListener(antlr4util.NewLegacyErrorListener())
-	program := ssa.NewProgram()
-	builder := &builder{
-		prog: program,
-		ast:  parser.HtmlDocument(),
-	}
-	builder.Build()
-	for _, r := range builder.prog.GetErrors() {
-		log.Errorf("ssa-ir program error: %v", r)
+	parser.AddErrorListener(errListener)
+	parser.SetErrorHandler(antlr.NewDefaultErrorStrategy())
+	ast := parser.HtmlDocument()
+	if must || len(errListener.GetErrors()) == 0 {
+		return ast, nil
 	}
-	return builder.prog
+	return nil, utils.Errorf("parse AST FrontEnd error : %v", errListener.GetErrors())
 }
 
 func (y *builder) Build() {
-	pkg := ssa.NewPackage("main")
-	y.prog.AddPackage(pkg)
-	main := pkg.NewFunction("main")
-	y.ir = ssa.NewBuilder(main, nil)
 	y.VisitHtmlDocument(y.ast)
 	y.ir.Finish()
 }
@@ -358,3 +358,76 @@ endif;
 
 `, nil)
 }
+
+func insts(prog *ssa.Program) []ssa.Instruction {
+	var ret []ssa.Instruction
+	var walk func(f *ssa.Function)
+	walk = func(f *ssa.Function) {
+		for _, block := range f.Blocks {
+			ret = append(ret, block.Insts...)
+		}
+		for _, child := range f.ChildFuncs {
+			walk(child)
+		}
+	}
+	for _, pkg := range prog.Packages {
+		for _, f := range pkg.Funcs {
+			walk(f)
+		}
+	}
+	return ret
+}
+
+// checkCallArgFromGet check the only argument of call is a field updated by $_GET[key]
+func checkCallArgFromGet(t *testing.T, prog *ssa.Program, method string, key string) {
+	var call *ssa.Call
+	var updates []*ssa.Update
+	for _, inst := range insts(prog) {
+		switch ret := inst.(type) {
+		case *ssa.Call:
+			if ret.Method.GetName() == method {
+				call = ret
+			}
+		case *ssa.Update:
+			updates = append(updates, ret)
+		}
+	}
+	if call == nil {
+		t.Fatalf("call of %s not found", method)
+	}
+	if len(call.Args) != 1 {
+		t.Fatalf("%s should be called with one argument, got %v", method, call.Args)
+	}
+	field, ok := call.Args[0].(*ssa.Field)
+	if !ok {
+		t.Fatalf("%s should be called with an array element, got %v", method, call.Args[0])
+	}
+
+	var value ssa.Value
+	for _, update := range updates {
+		if update.Address == ssa.Value(field) {
+			value = update.Value
+		}
+	}
+	getField, ok := value.(*ssa.Field)
+	if !ok || getField.Obj.GetName() != "$_GET" {
+		t.Fatalf("%v should be updated by a field of $_GET, got %v", field, value)
+	}
+	if c, ok := getField.Key.(*ssa.ConstInst); !ok || !c.IsString() || c.VarString() != key {
+		t.Fatalf("%v should be updated by $_GET[%s], got %v", field, key, getField)
+	}
+}
+
+func TestParseSSA_ArrayPush(t *testing.T) {
+	checkCallArgFromGet(t, ParseSSA(`<?php
+$a[] = $_GET['x'];
+system($a[0]);
+`, nil), "system", "x")
+
+	// the pushed element follows the elements of array literal
+	checkCallArgFromGet(t, ParseSSA(`<?php
+$cmd = array("ls");
+$cmd[] = $_GET["dir"];
+system($cmd[1]);
+`, nil), "system", "dir")
+}
@@ -0,0 +1,281 @@
+package php2ssa
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr/v4"
+	"github.com/yaklang/yaklang/common/log"
+	"github.com/yaklang/yaklang/common/utils"
+	phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
+	"github.com/yaklang/yaklang/common/yak/ssa"
+	"github.com/yaklang/yaklang/common/yak/ssa4analyze"
+)
+
+var phpFileExts = []string{".php", ".phtml", ".inc", ".php5", ".php7"}
+
+var includeStringRegexp = regexp.MustCompile(`'([^']*)'|"([^"]*)"`)
+
+type phpProject struct {
+	root  string
+	files map[string]string
+	asts  map[string]phpparser.IHtmlDocumentContext
+	must  bool
+
+	// included saves the files included by include_once / require_once in the building entry
+	included map[string]struct{}
+	// stack avoid including files recursively
+	stack []string
+}
+
+func loadPHPProject(root string, must bool) (*phpProject, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, utils.Errorf("get abs path of %v failed: %s", root, err)
+	}
+	p := &phpProject{
+		root:     root,
+		files:    make(map[string]string),
+		asts:     make(map[string]phpparser.IHtmlDocumentContext),
+		must:     must,
+		included: make(map[string]struct{}),
+	}
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !utils.StringArrayContains(phpFileExts, strings.ToLower(filepath.Ext(path))) {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Warnf("read php file %v failed: %s", path, err)
+			return nil
+		}
+		p.files[filepath.Clean(path)] = string(raw)
+		return nil
+	})
+	if err != nil {
+		return nil, utils.Errorf("walk php project %v failed: %s", root, err)
+	}
+	if len(p.files) <= 0 {
+		return nil, utils.Errorf("no php file found in %v", root)
+	}
+	return p, nil
+}
+
+// includePath get the path from `include __DIR__ . '/lib.php'`, only the string literals are used
+func includePath(expr string) string {
+	var buf strings.Builder
+	for _, match := range includeStringRegexp.FindAllStringSubmatch(expr, -1) {
+		buf.WriteString(match[1])
+		buf.WriteString(match[2])
+	}
+	return buf.String()
+}
+
+// resolve the included file by the current file and the project root
+func (p *phpProject) resolve(current string, target string) (string, bool) {
+	target = strings.TrimSpace(target)
+	if target == "" {
+		return "", false
+	}
+	var candidates []string
+	if filepath.IsAbs(target) {
+		candidates = append(candidates, target)
+	}
+	if current != "" {
+		candidates = append(candidates, filepath.Join(filepath.Dir(current), target))
+	}
+	candidates = append(candidates, filepath.Join(p.root, target))
+	for _, candidate := range candidates {
+		candidate = filepath.Clean(candidate)
+		if _, ok := p.files[candidate]; ok {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func (p *phpProject) ast(file string) (phpparser.IHtmlDocumentContext, error) {
+	if ast, ok := p.asts[file]; ok {
+		return ast, nil
+	}
+	ast, err := frontend(p.files[file], p.must)
+	if err != nil {
+		return nil, utils.Errorf("parse %v failed: %s", file, err)
+	}
+	p.asts[file] = ast
+	return ast, nil
+}
+
+// includeExpressions collect the path expressions of include / require in ast like the builder,
+// the includes in comments and strings are not in ast
+func includeExpressions(node antlr.Tree) []string {
+	var exprs []string
+	switch ret := node.(type) {
+	case *phpparser.SpecialWordExpressionContext:
+		if _, ok := isInclude(ret); ok && ret.Expression() != nil {
+			exprs = append(exprs, ret.Expression().GetText())
+		}
+	case *phpparser.ArithmeticExpressionContext:
+		if special, ok := ret.Expression(0).(*phpparser.SpecialWordExpressionContext); ok && ret.GetOp().GetText() == "." {
+			if _, ok := isInclude(special); ok {
+				exprs = append(exprs, ret.GetText())
+			}
+		}
+	}
+	for _, child := range node.GetChildren() {
+		exprs = append(exprs, includeExpressions(child)...)
+	}
+	return exprs
+}
+
+// entries are the files not included by others, all files are entries if they include each other
+func (p *phpProject) entries() []string {
+	includedByOthers := make(map[string]struct{})
+	for file := range p.files {
+		ast, err := p.ast(file)
+		if err != nil {
+			continue
+		}
+		for _, expr := range includeExpressions(ast) {
+			if target, ok := p.resolve(file, includePath(expr)); ok && target != file {
+				includedByOthers[target] = struct{}{}
+			}
+		}
+	}
+	var entries []string
+	for file := range p.files {
+		if _, ok := includedByOthers[file]; !ok {
+			entries = append(entries, file)
+		}
+	}
+	if len(entries) <= 0 {
+		for file := range p.files {
+			entries = append(entries, file)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
+func (p *phpProject) relative(file string) string {
+	if rel, err := filepath.Rel(p.root, file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+func parseProjectSSA(root string, must bool, callback func(*ssa.FunctionBuilder)) (ret *ssa.Program, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret = nil
+			err = utils.Errorf("parse error with panic : %v", r)
+		}
+	}()
+
+	project, err := loadPHPProject(root, must)
+	if err != nil {
+		return nil, err
+	}
+
+	prog := ssa.NewProgram()
+	main := prog.GetAndCreateMainFunctionBuilder()
+	if callback != nil {
+		callback(main)
+	}
+	y := newBuilder(prog, main)
+	y.project = project
+
+	// every entry file is built in its own function, variables are not shared between entries
+	for _, entry := range project.entries() {
+		ast, err := project.ast(entry)
+		if err != nil {
+			log.Warnf("skip php file: %s", err)
+			continue
+		}
+		// include_once in one entry doesn't affect the others
+		project.included = make(map[string]struct{})
+		funcDec, symbolTable := y.ir.NewFunc(project.relative(entry))
+		current := y.ir.CurrentBlock
+		y.ir = y.ir.PushFunction(funcDec, symbolTable, current)
+		recoverRange := y.SetRange(ast)
+		y.visitFile(entry, ast)
+		recoverRange()
+		y.ir.Finish()
+		y.ir = y.ir.PopFunction()
+	}
+	y.ir.Finish()
+
+	ssa4analyze.RunAnalyzer(prog)
+	return prog, nil
+}
+
+func (y *builder) visitFile(file string, ast phpparser.IHtmlDocumentContext) {
+	outerFile := y.currentFile
+	y.currentFile = file
+	y.project.stack = append(y.project.stack, file)
+	defer func() {
+		y.project.stack = y.project.stack[:len(y.project.stack)-1]
+		y.currentFile = outerFile
+	}()
+	y.VisitHtmlDocument(ast)
+}
+
+// isInclude check the special word is include / require, once is true for include_once / require_once
+func isInclude(special *phpparser.SpecialWordExpressionContext) (once bool, ok bool) {
+	switch {
+	case special.Include() != nil, special.Require() != nil:
+		return false, true
+	case special.IncludeOnce() != nil, special.RequireOnce() != nil:
+		return true, true
+	}
+	return false, false
+}
+
+// include build the included file in the current scope, just like php does,
+// the file is resolved by the string literals in pathExpr
+func (y *builder) include(raw phpparser.IExpressionContext, pathExpr string, once bool) ssa.Value {
+	if raw == nil {
+		return y.ir.EmitConstInst(false)
+	}
+	if y.project == nil {
+		// single file mode, the path is still a value
+		y.VisitExpression(raw)
+		return y.ir.EmitConstInst(true)
+	}
+
+	file, ok := y.project.resolve(y.currentFile, includePath(pathExpr))
+	if !ok {
+		log.Debugf("cannot resolve included file: %v", raw.GetText())
+		y.VisitExpression(raw)
+		return y.ir.EmitConstInst(false)
+	}
+	if _, ok := y.project.included[file]; ok && once {
+		return y.ir.EmitConstInst(true)
+	}
+	for _, f := range y.project.stack {
+		if f == file {
+			log.Debugf("skip recursive include: %v", file)
+			return y.ir.EmitConstInst(true)
+		}
+	}
+	ast, err := y.project.ast(file)
+	if err != nil {
+		log.Warnf("include %v failed: %s", file, err)
+		return y.ir.EmitConstInst(false)
+	}
+	y.project.included[file] = struct{}{}
+	y.visitFile(file, ast)
+	return y.ir.EmitConstInst(1)
+}
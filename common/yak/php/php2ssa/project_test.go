@@ -0,0 +1,75 @@
+package php2ssa
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestProject_EntriesIgnoreCommentedInclude(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"lib.php": `<?php
+$lib = 1;
+`,
+		"admin.php": `<?php
+// include "index.php";
+/* require_once 'index.php'; */
+echo "include 'index.php';";
+require_once __DIR__ . "/lib.php";
+`,
+		"index.php": `<?php
+include("lib.php");
+`,
+	})
+	project, err := loadPHPProject(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []string
+	for _, entry := range project.entries() {
+		entries = append(entries, project.relative(entry))
+	}
+	if len(entries) != 2 || entries[0] != "admin.php" || entries[1] != "index.php" {
+		t.Fatalf("entries should be admin.php and index.php, got %v", entries)
+	}
+}
+
+func TestProject_IncludeOncePerEntry(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"lib.php": `<?php
+$lib = 1;
+`,
+		"a.php": `<?php
+include_once "lib.php";
+`,
+		"b.php": `<?php
+require_once "lib.php";
+require_once "lib.php";
+`,
+	})
+	prog, err := parseProjectSSA(dir, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs := make(map[string]int)
+	for _, inst := range prog.GetInstructionsByName("$lib") {
+		funcs[inst.GetFunc().GetName()]++
+	}
+	if funcs["a.php"] != 1 || funcs["b.php"] != 1 {
+		t.Fatalf("lib.php should be included once in every entry, got %v", funcs)
+	}
+}
@@ -0,0 +1,23 @@
+package php2ssa
+
+import (
+	"github.com/yaklang/yaklang/common/yak/antlr4util"
+)
+
+// SetRange set the current range of the function builder, the variables and values built later use this range
+func (y *builder) SetRange(token antlr4util.CanStartStopToken) func() {
+	r := antlr4util.GetRange(token)
+	if r == nil {
+		return func() {}
+	}
+	if y.project != nil && y.currentFile != "" {
+		r.FileName = y.project.relative(y.currentFile)
+	}
+	ir := y.ir
+	backup := ir.CurrentRange
+	ir.CurrentRange = r
+
+	return func() {
+		ir.CurrentRange = backup
+	}
+}
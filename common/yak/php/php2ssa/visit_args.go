@@ -1,8 +1,11 @@
 package php2ssa
 
-import phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
+import (
+	phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
+	"github.com/yaklang/yaklang/common/yak/ssa"
+)
 
-func (y *builder) VisitArguments(raw phpparser.IArgumentsContext) interface{} {
+func (y *builder) VisitArguments(raw phpparser.IArgumentsContext) []ssa.Value {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -12,14 +15,19 @@ func (y *builder) VisitArguments(raw phpparser.IArgumentsContext) interface{} {
 		return nil
 	}
 
+	var args []ssa.Value
 	for _, arg := range i.AllActualArgument() {
-		y.VisitActualArgument(arg)
+		if v := y.VisitActualArgument(arg); v != nil {
+			args = append(args, v)
+		} else {
+			args = append(args, y.ir.EmitConstInstNil())
+		}
 	}
 
-	return nil
+	return args
 }
 
-func (y *builder) VisitActualArgument(raw phpparser.IActualArgumentContext) interface{} {
+func (y *builder) VisitActualArgument(raw phpparser.IActualArgumentContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -32,9 +40,10 @@ func (y *builder) VisitActualArgument(raw phpparser.IActualArgumentContext) inte
 	if i.Expression() != nil {
 		return y.VisitExpression(i.Expression())
 	} else if i.Ampersand() != nil {
-
+		// pass by reference
+		return y.VisitChain(i.Chain())
 	} else if i.YieldExpression() != nil {
-		return y.VisitYieldExpression(i.YieldExpression())
+		y.VisitYieldExpression(i.YieldExpression())
 	}
 	return nil
 }
@@ -1,11 +1,83 @@
 package php2ssa
 
 import (
+	"strings"
+
 	phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
 	"github.com/yaklang/yaklang/common/yak/ssa"
-	"strings"
 )
 
+type phpClass struct {
+	name   string
+	parent string
+	// members is used to inherit, the values are read from the class object
+	members map[string]struct{}
+	// methods is used to call the method directly, so the use-def chain can reach the function
+	methods map[string]*ssa.Function
+}
+
+func shortName(name string) string {
+	if idx := strings.LastIndex(name, "\\"); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// readClass read the class object by name, `self` / `static` / `parent` is handled
+func (y *builder) readClass(name string) ssa.Value {
+	switch strings.ToLower(name) {
+	case "self", "static", "class":
+		if y.currentClass != nil {
+			return y.ir.ReadVariable(y.currentClass.name, true)
+		}
+	case "parent":
+		if y.currentClass != nil && y.currentClass.parent != "" {
+			return y.ir.ReadVariable(shortName(y.currentClass.parent), true)
+		}
+	}
+	name = shortName(name)
+	if name == "" {
+		return nil
+	}
+	return y.ir.ReadVariable(name, true)
+}
+
+// classOf find the class of value, the class object and `$this` in methods are supported
+func (y *builder) classOf(v ssa.Value) *phpClass {
+	if v == nil {
+		return nil
+	}
+	if cls, ok := y.classObjects[v]; ok {
+		return cls
+	}
+	if p, ok := v.(*ssa.Parameter); ok && p.IsFreeValue {
+		if cls, ok := y.classes[strings.ToLower(p.GetName())]; ok {
+			return cls
+		}
+	}
+	return nil
+}
+
+// method find the method by name (case-insensitive as php)
+func (y *builder) method(obj ssa.Value, name string) *ssa.Function {
+	cls := y.classOf(obj)
+	if cls == nil {
+		return nil
+	}
+	return cls.methods[strings.ToLower(name)]
+}
+
+func (y *builder) setMember(cls *phpClass, obj ssa.Value, name string, value ssa.Value) {
+	if name == "" || value == nil {
+		return
+	}
+	field := y.ir.EmitFieldMust(obj, y.ir.EmitConstInst(name))
+	y.ir.EmitUpdate(field, value)
+	if cls != nil {
+		cls.members[name] = struct{}{}
+	}
+}
+
 func (y *builder) VisitNewExpr(raw phpparser.INewExprContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
@@ -16,12 +88,34 @@ func (y *builder) VisitNewExpr(raw phpparser.INewExprContext) ssa.Value {
 		return nil
 	}
 
-	t := y.VisitTypeRef(i.TypeRef())
-	_ = t
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
+	var obj ssa.Value
+	if typeRef, _ := i.TypeRef().(*phpparser.TypeRefContext); typeRef != nil {
+		if typeRef.QualifiedNamespaceName() != nil || typeRef.Static() != nil {
+			obj = y.readClass(typeRef.GetText())
+		} else {
+			y.VisitTypeRef(typeRef)
+		}
+	}
+	if obj == nil {
+		obj = y.ir.EmitInterfaceMake(func(feed func(key ssa.Value, val ssa.Value)) {})
+	}
+
+	// new Foo($a) => Foo.__construct($a)
+	var args []ssa.Value
 	if i.Arguments() != nil {
-		return nil
+		args = y.VisitArguments(i.Arguments())
 	}
-	return nil
+	var constructor ssa.Value
+	if fn := y.method(obj, "__construct"); fn != nil {
+		constructor = fn
+	} else {
+		constructor = y.ir.EmitField(obj, y.ir.EmitConstInst("__construct"))
+	}
+	y.ir.EmitCall(y.ir.NewCall(constructor, args))
+	return obj
 }
 
 func (y *builder) VisitTypeRef(raw phpparser.ITypeRefContext) ssa.Type {
@@ -87,6 +181,9 @@ func (y *builder) VisitClassDeclaration(raw phpparser.IClassDeclarationContext)
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	// notes #[...] for dec
 	if i.Attributes() != nil {
 
@@ -137,12 +234,41 @@ func (y *builder) VisitClassDeclaration(raw phpparser.IClassDeclarationContext)
 			}
 		}
 	}
-	_ = objectTemplate
 	_ = mergedTemplate
+	if objectTemplate == "" {
+		return nil
+	}
 
+	// all instances share one object, the methods are saved as the members
+	cls := &phpClass{
+		name:    objectTemplate,
+		members: make(map[string]struct{}),
+		methods: make(map[string]*ssa.Function),
+	}
+	if i.Extends() != nil && i.QualifiedStaticTypeRef() != nil {
+		cls.parent = i.QualifiedStaticTypeRef().GetText()
+	}
+	obj := y.ir.EmitInterfaceMake(func(feed func(key ssa.Value, val ssa.Value)) {})
+	y.ir.WriteVariable(cls.name, obj)
+	y.classObjects[obj] = cls
+	// register before visiting methods, `$this->foo()` can be resolved in the class body
+	y.classes[strings.ToLower(cls.name)] = cls
+	if parent, ok := y.classes[strings.ToLower(shortName(cls.parent))]; ok {
+		parentObj := y.readClass(parent.name)
+		for name := range parent.members {
+			y.setMember(cls, obj, name, y.ir.EmitField(parentObj, y.ir.EmitConstInst(name)))
+		}
+		for name, fn := range parent.methods {
+			cls.methods[name] = fn
+		}
+	}
+
+	outerClass := y.currentClass
+	y.currentClass = cls
 	for _, field := range i.AllClassStatement() {
-		y.VisitClassStatement(field)
+		y.VisitClassStatement(obj, field)
 	}
+	y.currentClass = outerClass
 
 	//// how to build a template?
 	//// y.ir is a SSA.Function
@@ -161,7 +287,7 @@ func (y *builder) VisitClassDeclaration(raw phpparser.IClassDeclarationContext)
 	return nil
 }
 
-func (y *builder) VisitClassStatement(raw phpparser.IClassStatementContext) interface{} {
+func (y *builder) VisitClassStatement(obj ssa.Value, raw phpparser.IClassStatementContext) interface{} {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -171,11 +297,15 @@ func (y *builder) VisitClassStatement(raw phpparser.IClassStatementContext) inte
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	// note: PHP8 #[...] attributes
 	if i.Attributes() != nil {
 		// handle php8
 	}
 
+	cls := y.currentClass
 	var memberDecorationVerbose string
 	if i.PropertyModifiers() != nil {
 		// handle variable
@@ -185,32 +315,47 @@ func (y *builder) VisitClassStatement(raw phpparser.IClassStatementContext) inte
 			y.VisitTypeHint(i.TypeHint())
 		}
 
-		// handle variable name
+		// handle variable name, `$this->name` use the name without `$`
 		for _, va := range i.AllVariableInitializer() {
-			y.VisitVariableInitializer(va)
+			name, value := y.VisitVariableInitializer(va)
+			if value == nil {
+				value = y.ir.EmitConstInstNil()
+			}
+			y.setMember(cls, obj, strings.TrimPrefix(name, "$"), value)
 		}
 
 		return nil
-	} else if i.MemberModifiers() != nil {
-		memberDecorationVerbose = i.MemberModifiers().GetText()
-		// const / function
-		if i.Const() != nil {
-			// handle const
-			if i.TypeHint() != nil {
-				varType := y.VisitTypeHint(i.TypeHint())
-				_ = varType
-			}
-			for _, c := range i.AllIdentifierInitializer() {
-				y.VisitIdentifierInitializer(c)
+	} else if i.Const() != nil {
+		// handle const
+		if i.TypeHint() != nil {
+			varType := y.VisitTypeHint(i.TypeHint())
+			_ = varType
+		}
+		for _, c := range i.AllIdentifierInitializer() {
+			name, value := y.VisitIdentifierInitializer(c)
+			if name != "" && value != nil {
+				y.setMember(cls, obj, name, value)
 			}
-		} else if i.Function_() != nil {
-			isFuncRef := i.Ampersand() != nil
-			var funcName = i.Identifier()
+		}
+	} else if i.Function_() != nil {
+		if i.MemberModifiers() != nil {
+			memberDecorationVerbose = i.MemberModifiers().GetText()
+		}
+		isFuncRef := i.Ampersand() != nil
+		_ = isFuncRef
+
+		funcName := y.VisitIdentifier(i.Identifier())
+		funcDec, symbolTable := y.ir.NewFunc(cls.name + "::" + funcName)
+		current := y.ir.CurrentBlock
+		{
+			y.ir = y.ir.PushFunction(funcDec, symbolTable, current)
+			recoverFuncRange := y.SetRange(raw)
+
 			if i.FormalParameterList() != nil {
 				// handle formal parameter list
 				y.VisitFormalParameterList(i.FormalParameterList())
 			}
-			_, _ = isFuncRef, funcName
+			y.ir.WriteVariable("$this", y.ir.ReadVariable(cls.name, true))
 
 			// baseCtorCall
 			if i.BaseCtorCall() != nil {
@@ -222,7 +367,13 @@ func (y *builder) VisitClassStatement(raw phpparser.IClassStatementContext) inte
 			}
 
 			y.VisitMethodBody(i.MethodBody())
+
+			recoverFuncRange()
+			y.ir.Finish()
+			y.ir = y.ir.PopFunction()
 		}
+		y.setMember(cls, obj, funcName, funcDec)
+		cls.methods[strings.ToLower(funcName)] = funcDec
 	} else if i.Use() != nil {
 		y.VisitQualifiedNamespaceNameList(i.QualifiedNamespaceNameList())
 		y.VisitTraitAdaptations(i.TraitAdaptations())
@@ -320,6 +471,10 @@ func (y *builder) VisitMethodBody(raw phpparser.IMethodBodyContext) interface{}
 		return nil
 	}
 
+	// abstract method has no body
+	if i.BlockStatement() != nil {
+		y.VisitBlockStatement(i.BlockStatement())
+	}
 	return nil
 }
 
@@ -381,6 +536,9 @@ func (y *builder) VisitFormalParameter(raw phpparser.IFormalParameterContext) in
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	// PHP8 annotation
 	if i.Attributes() != nil {
 		_ = i.Attributes().GetText()
@@ -412,17 +570,18 @@ func (y *builder) VisitFormalParameter(raw phpparser.IFormalParameterContext) in
 	return nil
 }
 
-func (y *builder) VisitIdentifierInitializer(raw phpparser.IIdentifierInitializerContext) interface{} {
+// VisitIdentifierInitializer read `A = 1` and return name and ssaValue
+func (y *builder) VisitIdentifierInitializer(raw phpparser.IIdentifierInitializerContext) (string, ssa.Value) {
 	if y == nil || raw == nil {
-		return nil
+		return "", nil
 	}
 
 	i, _ := raw.(*phpparser.IdentifierInitializerContext)
 	if i == nil {
-		return nil
+		return "", nil
 	}
 
-	return nil
+	return y.VisitIdentifier(i.Identifier()), y.VisitConstantInitializer(i.ConstantInitializer())
 }
 
 // VisitVariableInitializer read ast and return varName and ssaValue
@@ -441,6 +600,7 @@ func (y *builder) VisitVariableInitializer(raw phpparser.IVariableInitializerCon
 	return i.VarName().GetText(), val
 }
 
+// VisitClassConstant build `Foo::bar` / `self::$bar` / `parent::__construct`
 func (y *builder) VisitClassConstant(raw phpparser.IClassConstantContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
@@ -451,7 +611,40 @@ func (y *builder) VisitClassConstant(raw phpparser.IClassConstantContext) ssa.Va
 		return nil
 	}
 
-	panic("CLASS CONSTANT TODO")
-
-	return nil
+	var (
+		cls       ssa.Value
+		variables = i.AllKeyedVariable()
+	)
+	if i.Parent_() != nil {
+		cls = y.readClass("parent")
+	} else if i.Class() != nil {
+		cls = y.readClass("self")
+	} else if i.QualifiedStaticTypeRef() != nil {
+		cls = y.readClass(i.QualifiedStaticTypeRef().GetText())
+	} else if i.String_() != nil {
+		cls = y.readClass(strings.Trim(i.String_().GetText(), `'"`))
+	} else if len(variables) > 0 {
+		cls = y.VisitKeyedVariable(variables[0])
+		variables = variables[1:]
+	}
+	if cls == nil {
+		return nil
+	}
+
+	var key string
+	switch {
+	case i.Identifier() != nil:
+		key = y.VisitIdentifier(i.Identifier())
+	case i.Constructor() != nil:
+		key = "__construct"
+	case i.Get() != nil:
+		key = i.Get().GetText()
+	case i.Set() != nil:
+		key = i.Set().GetText()
+	case len(variables) > 0:
+		key = strings.TrimPrefix(variables[0].GetText(), "$")
+	default:
+		return cls
+	}
+	return y.ir.EmitField(cls, y.ir.EmitConstInst(key))
 }
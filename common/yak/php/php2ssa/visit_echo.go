@@ -12,5 +12,14 @@ func (y *builder) VisitEchoStatement(raw phpparser.IEchoStatementContext) interf
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
+	// the output is ignored, but the expressions are still built, `echo system($cmd);`
+	if list, ok := i.ExpressionList().(*phpparser.ExpressionListContext); ok {
+		for _, expr := range list.AllExpression() {
+			y.VisitExpression(expr)
+		}
+	}
 	return nil
 }
@@ -1,6 +1,8 @@
 package php2ssa
 
 import (
+	"strings"
+
 	"github.com/yaklang/yaklang/common/log"
 	phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
 	"github.com/yaklang/yaklang/common/yak/ssa"
@@ -48,6 +50,9 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	switch ret := raw.(type) {
 	case *phpparser.CloneExpressionContext:
 		// shallow copy, an object
@@ -108,26 +113,34 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 			return val
 		}
 	case *phpparser.PrefixIncDecExpressionContext:
-		val := y.VisitChain(ret.Chain())
+		leftValue := y.VisitLeftValueChain(ret.Chain())
+		if leftValue == nil {
+			return y.ir.EmitConstInstNil()
+		}
+		val := leftValue.GetValue(y.ir)
 		if ret.Inc() != nil {
 			after := y.ir.EmitBinOp(ssa.OpAdd, val, y.ir.EmitConstInst(1))
-			y.ir.EmitUpdate(val, after)
+			leftValue.Assign(after, y.ir)
 			return after
 		} else if ret.Dec() != nil {
 			after := y.ir.EmitBinOp(ssa.OpSub, val, y.ir.EmitConstInst(1))
-			y.ir.EmitUpdate(val, after)
+			leftValue.Assign(after, y.ir)
 			return after
 		}
 		return y.ir.EmitConstInstNil()
 	case *phpparser.PostfixIncDecExpressionContext:
-		val := y.VisitChain(ret.Chain())
+		leftValue := y.VisitLeftValueChain(ret.Chain())
+		if leftValue == nil {
+			return y.ir.EmitConstInstNil()
+		}
+		val := leftValue.GetValue(y.ir)
 		if ret.Inc() != nil {
 			after := y.ir.EmitBinOp(ssa.OpAdd, val, y.ir.EmitConstInst(1))
-			y.ir.EmitUpdate(val, after)
+			leftValue.Assign(after, y.ir)
 			return val
 		} else if ret.Dec() != nil {
 			after := y.ir.EmitBinOp(ssa.OpSub, val, y.ir.EmitConstInst(1))
-			y.ir.EmitUpdate(val, after)
+			leftValue.Assign(after, y.ir)
 			return val
 		}
 		return y.ir.EmitConstInstNil()
@@ -169,14 +182,8 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 
 		} else if i := ret.Exit(); i != nil {
 
-		} else if i := ret.Include(); i != nil {
-
-		} else if i := ret.IncludeOnce(); i != nil {
-
-		} else if i := ret.Require(); i != nil {
-
-		} else if i := ret.RequireOnce(); i != nil {
-
+		} else if once, ok := isInclude(ret); ok {
+			return y.include(ret.Expression(), ret.Expression().GetText(), once)
 		} else if i := ret.Throw(); i != nil {
 
 		} else {
@@ -186,6 +193,12 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 	case *phpparser.LambdaFunctionExpressionContext:
 	case *phpparser.MatchExpressionContext:
 	case *phpparser.ArithmeticExpressionContext:
+		if special, ok := ret.Expression(0).(*phpparser.SpecialWordExpressionContext); ok && ret.GetOp().GetText() == "." {
+			// `require __DIR__ . '/lib.php'` is parsed as `(require __DIR__) . '/lib.php'`
+			if once, ok := isInclude(special); ok {
+				return y.include(special.Expression(), ret.GetText(), once)
+			}
+		}
 		op1 := y.VisitExpression(ret.Expression(0))
 		op2 := y.VisitExpression(ret.Expression(1))
 		var o ssa.BinaryOpcode
@@ -342,7 +355,7 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 			// assignable assignmentOperator attributes? expression        # AssignmentExpression
 
 			// left value: chain array creation
-			leftValues := y.VisitAssignable(ret.Assignable())
+			leftValue := y.VisitAssignable(ret.Assignable())
 
 			var annotation any
 			if ret.Attributes() != nil {
@@ -351,10 +364,17 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 			}
 
 			rightValue := y.VisitExpression(ret.Expression())
+			if leftValue == nil || rightValue == nil {
+				return rightValue
+			}
 			operator := ret.AssignmentOperator()
+			if operator.GetText() == "=" {
+				leftValue.Assign(rightValue, y.ir)
+				return rightValue
+			}
+
+			leftValues := leftValue.GetValue(y.ir)
 			switch operator.GetText() {
-			case "=":
-				break
 			case "+=":
 				rightValue = y.ir.EmitBinOp(ssa.OpAdd, leftValues, rightValue)
 			case "-=":
@@ -387,30 +407,33 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 					leftValueIsEmpty = y.ir.EmitBinOp(ssa.OpEq, leftValues, y.ir.EmitConstInstNil())
 					return leftValueIsEmpty
 				}).BuildTrue(func() {
-					y.ir.EmitUpdate(leftValues, rightValue)
+					leftValue.Assign(rightValue, y.ir)
 					returnVal = rightValue
 				}).Finish()
 				return returnVal
 			default:
 				log.Errorf("unhandled assignment operator: %v", operator.GetText())
 			}
-			updateVal := y.ir.EmitUpdate(leftValues, rightValue)
-			_ = updateVal
+			leftValue.Assign(rightValue, y.ir)
 			return rightValue
 		} else if ret.Ampersand() != nil {
 			// assignable Eq attributes? '&' (chain | newExpr)
-			leftValues := y.VisitAssignable(ret.Assignable())
+			leftValue := y.VisitAssignable(ret.Assignable())
 			if ret.Attributes() != nil {
 				y.VisitAttributes(ret.Attributes())
 			}
 
 			// right val
+			var rightValue ssa.Value
 			if i := ret.Chain(); i != nil {
-				y.VisitChain(i)
+				rightValue = y.VisitChain(i)
 			} else if i := ret.NewExpr(); i != nil {
-				y.VisitNewExpr(i)
+				rightValue = y.VisitNewExpr(i)
 			}
-			_ = leftValues
+			if leftValue != nil && rightValue != nil {
+				leftValue.Assign(rightValue, y.ir)
+			}
+			return rightValue
 		}
 
 	case *phpparser.LogicalExpressionContext:
@@ -469,7 +492,8 @@ func (y *builder) VisitExpression(raw phpparser.IExpressionContext) ssa.Value {
 	return nil
 }
 
-func (y *builder) VisitAssignable(raw phpparser.IAssignableContext) ssa.Value {
+// VisitAssignable build the left value of assignment, `$a` / `$a[1]` / `$a->b` / `Foo::$bar`
+func (y *builder) VisitAssignable(raw phpparser.IAssignableContext) ssa.LeftValue {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -480,13 +504,198 @@ func (y *builder) VisitAssignable(raw phpparser.IAssignableContext) ssa.Value {
 	}
 
 	if i.Chain() != nil {
-		return y.VisitChain(i.Chain())
+		return y.VisitLeftValueChain(i.Chain())
 	} else if i.ArrayCreation() != nil {
-		return y.VisitArrayCreation(i.ArrayCreation())
+		// TODO: list($a, $b) = ...
+		log.Warnf("destructuring assignment is not supported: %v", i.ArrayCreation().GetText())
+		return nil
 	} else {
-		log.Errorf("cannot build leftValue Assignable with: %v", i.Chain().GetText())
+		log.Errorf("cannot build leftValue Assignable with: %v", i.GetText())
+		return nil
+	}
+}
+
+// VisitLeftValueChain build the chain as left value, the last member of the chain is the one to be assigned
+func (y *builder) VisitLeftValueChain(raw phpparser.IChainContext) ssa.LeftValue {
+	if y == nil || raw == nil {
+		return nil
+	}
+
+	i, _ := raw.(*phpparser.ChainContext)
+	if i == nil {
+		return nil
+	}
+
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
+	members := i.AllMemberAccess()
+	if len(members) == 0 {
+		if origin, ok := i.ChainOrigin().(*phpparser.ChainOriginContext); ok && origin.ChainBase() != nil {
+			return y.visitLeftValueChainBase(origin.ChainBase())
+		}
+		log.Errorf("cannot assign to: %v", i.GetText())
+		return nil
+	}
+
+	obj := y.VisitChainOrigin(i.ChainOrigin())
+	for _, m := range members[:len(members)-1] {
+		obj = y.VisitMemberAccess(obj, m)
+	}
+
+	last, _ := members[len(members)-1].(*phpparser.MemberAccessContext)
+	if last == nil {
+		return nil
+	}
+	if last.ActualArguments() != nil {
+		log.Errorf("cannot assign to call: %v", last.GetText())
+		return nil
+	}
+	field, _ := last.KeyedFieldName().(*phpparser.KeyedFieldNameContext)
+	if field == nil {
+		return nil
+	}
+	if ret, _ := field.KeyedSimpleFieldName().(*phpparser.KeyedSimpleFieldNameContext); ret != nil {
+		// $obj->name[...]
+		var key ssa.Value
+		if ret.Identifier() != nil {
+			key = y.ir.EmitConstInst(y.VisitIdentifier(ret.Identifier()))
+		} else if ret.Expression() != nil {
+			key = y.VisitExpression(ret.Expression())
+		}
+		if key == nil {
+			return nil
+		}
+		squares := ret.AllSquareCurlyExpression()
+		if len(squares) == 0 {
+			return y.fieldLeftValue(obj, key)
+		}
+		return y.squareCurlyLeftValue(y.ir.EmitField(obj, key), squares)
+	} else if ret := field.KeyedVariable(); ret != nil {
+		// $obj->$name
+		return y.fieldLeftValue(obj, y.VisitKeyedVariable(ret))
+	}
+	return nil
+}
+
+func (y *builder) visitLeftValueChainBase(raw phpparser.IChainBaseContext) ssa.LeftValue {
+	i, _ := raw.(*phpparser.ChainBaseContext)
+	if i == nil {
 		return nil
 	}
+
+	keyed := i.AllKeyedVariable()
+	if ret := i.QualifiedStaticTypeRef(); ret != nil {
+		// Foo::$bar
+		if len(keyed) <= 0 {
+			return nil
+		}
+		cls := y.readClass(ret.GetText())
+		name := strings.TrimPrefix(keyed[0].GetText(), "$")
+		return y.fieldLeftValue(cls, y.ir.EmitConstInst(name))
+	}
+
+	switch len(keyed) {
+	case 1:
+		return y.visitLeftValueKeyedVariable(keyed[0])
+	case 2:
+		return y.fieldLeftValue(y.VisitKeyedVariable(keyed[0]), y.VisitKeyedVariable(keyed[1]))
+	}
+	return nil
+}
+
+// visitLeftValueKeyedVariable build `$a` as identifier and `$a[1][2]` as field of `$a[1]`
+func (y *builder) visitLeftValueKeyedVariable(raw phpparser.IKeyedVariableContext) ssa.LeftValue {
+	i, _ := raw.(*phpparser.KeyedVariableContext)
+	if i == nil {
+		return nil
+	}
+
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
+	if i.VarName() == nil || len(i.AllDollar()) > 0 {
+		// TODO: $$a = ...
+		log.Warnf("dynamic variable is not supported as left value: %v", i.GetText())
+		return nil
+	}
+
+	name := i.VarName().GetText()
+	squares := i.AllSquareCurlyExpression()
+	if len(squares) == 0 {
+		return ssa.NewIdentifierLV(name, y.ir.CurrentRange)
+	}
+
+	return y.squareCurlyLeftValue(y.readVariable(name), squares)
+}
+
+// squareCurlyLeftValue read the fields of `obj[...][...]`, the field of the last square is returned to be assigned
+func (y *builder) squareCurlyLeftValue(obj ssa.Value, squares []phpparser.ISquareCurlyExpressionContext) ssa.LeftValue {
+	if obj == nil || len(squares) == 0 {
+		return nil
+	}
+	for _, a := range squares[:len(squares)-1] {
+		obj = y.ir.EmitField(obj, y.visitSquareCurlyKey(obj, a))
+	}
+	last := squares[len(squares)-1]
+	return y.ir.EmitFieldMust(obj, y.visitSquareCurlyKey(obj, last))
+}
+
+// fieldLeftValue read the fields of obj by keys, the field of the last key is returned to be assigned
+func (y *builder) fieldLeftValue(obj ssa.Value, keys ...ssa.Value) ssa.LeftValue {
+	if obj == nil || len(keys) == 0 {
+		return nil
+	}
+	for _, key := range keys[:len(keys)-1] {
+		obj = y.ir.EmitField(obj, key)
+	}
+	return y.ir.EmitFieldMust(obj, keys[len(keys)-1])
+}
+
+// visitSquareCurlyKey build the key of `obj[...]`, `obj[]` appends to the next integer index of obj,
+// `count(obj)` is used as the key when the elements of obj are unknown (e.g. parameter)
+func (y *builder) visitSquareCurlyKey(obj ssa.Value, raw phpparser.ISquareCurlyExpressionContext) ssa.Value {
+	if v := y.VisitSquareCurlyExpression(raw); v != nil {
+		return v
+	}
+	if index, ok := nextArrayIndex(obj); ok {
+		return y.ir.EmitConstInst(index)
+	}
+	var args []ssa.Value
+	if obj != nil {
+		args = append(args, obj)
+	}
+	count := y.ir.ReadVariable("count", true)
+	calling := y.ir.NewCall(count, args)
+	y.ir.EmitCall(calling)
+	return calling
+}
+
+// nextArrayIndex return the max integer key of the fields assigned to obj plus one,
+// only the array created in the program (array literal or undefined variable) is known
+func nextArrayIndex(obj ssa.Value) (int, bool) {
+	if obj == nil {
+		return 0, false
+	}
+	switch obj.(type) {
+	case *ssa.Make, *ssa.Undefined:
+	default:
+		return 0, false
+	}
+	next := 0
+	for _, field := range ssa.GetFields(obj) {
+		key, ok := ssa.ToConst(field.Key)
+		if !ok || !key.IsNumber() || int(key.Number()) < next {
+			continue
+		}
+		for _, user := range field.GetUsers() {
+			if update, ok := ssa.ToUpdate(user); ok && update.Address == field {
+				next = int(key.Number()) + 1
+				break
+			}
+		}
+	}
+	return next, true
 }
 
 func (y *builder) VisitChain(raw phpparser.IChainContext) ssa.Value {
@@ -499,15 +708,19 @@ func (y *builder) VisitChain(raw phpparser.IChainContext) ssa.Value {
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	origin := y.VisitChainOrigin(i.ChainOrigin())
 
 	for _, m := range i.AllMemberAccess() {
-		origin = y.ir.EmitField(origin, y.VisitMemberAccess(m))
+		origin = y.VisitMemberAccess(origin, m)
 	}
 	return origin
 }
 
-func (y *builder) VisitMemberAccess(raw phpparser.IMemberAccessContext) ssa.Value {
+// VisitMemberAccess build `$obj->field` and `$obj->method(...)`
+func (y *builder) VisitMemberAccess(obj ssa.Value, raw phpparser.IMemberAccessContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -517,15 +730,24 @@ func (y *builder) VisitMemberAccess(raw phpparser.IMemberAccessContext) ssa.Valu
 		return nil
 	}
 
-	y.VisitKeyedFieldName(i.KeyedFieldName())
 	if i.ActualArguments() != nil {
-		y.VisitActualArguments(i.ActualArguments())
+		// `$obj->method()` on a known class, call the method directly
+		if fn := y.method(obj, i.KeyedFieldName().GetText()); fn != nil {
+			return y.VisitActualArguments(fn, i.ActualArguments())
+		}
 	}
-
-	return nil
+	member := y.VisitKeyedFieldName(obj, i.KeyedFieldName())
+	if member == nil {
+		member = y.ir.EmitField(obj, y.ir.EmitConstInst(i.KeyedFieldName().GetText()))
+	}
+	if i.ActualArguments() != nil {
+		return y.VisitActualArguments(member, i.ActualArguments())
+	}
+	return member
 }
 
-func (y *builder) VisitActualArguments(raw phpparser.IActualArgumentsContext) interface{} {
+// VisitActualArguments call the target with arguments, `f(1)(2)` and `f(1)[0]` is also handled
+func (y *builder) VisitActualArguments(target ssa.Value, raw phpparser.IActualArgumentsContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -535,19 +757,23 @@ func (y *builder) VisitActualArguments(raw phpparser.IActualArgumentsContext) in
 		return nil
 	}
 
-	// PHP8 annotation
+	ret := target
 	for _, a := range i.AllArguments() {
-		y.VisitArguments(a)
+		c := y.ir.NewCall(ret, y.VisitArguments(a))
+		ret = y.ir.EmitCall(c)
 	}
 
 	for _, a := range i.AllSquareCurlyExpression() {
-		y.VisitSquareCurlyExpression(a)
+		if key := y.VisitSquareCurlyExpression(a); key != nil {
+			ret = y.ir.EmitField(ret, key)
+		}
 	}
 
-	return nil
+	return ret
 }
 
-func (y *builder) VisitKeyedFieldName(raw phpparser.IKeyedFieldNameContext) interface{} {
+// VisitKeyedFieldName build the member of obj, `$obj->name[0]` / `$obj->{$expr}` / `$obj->$name`
+func (y *builder) VisitKeyedFieldName(obj ssa.Value, raw phpparser.IKeyedFieldNameContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -558,14 +784,41 @@ func (y *builder) VisitKeyedFieldName(raw phpparser.IKeyedFieldNameContext) inte
 	}
 
 	if i.KeyedSimpleFieldName() != nil {
-		y.VisitKeyedSimpleFieldName(i.KeyedSimpleFieldName())
+		return y.VisitKeyedSimpleFieldName(obj, i.KeyedSimpleFieldName())
 	} else if i.KeyedVariable() != nil {
-		y.VisitKeyedVariable(i.KeyedVariable())
+		return y.ir.EmitField(obj, y.VisitKeyedVariable(i.KeyedVariable()))
 	}
 
 	return nil
 }
 
+// superGlobals are visible in all scopes, they are never captured from the parent function as free values
+var superGlobals = map[string]struct{}{
+	"$GLOBALS":  {},
+	"$_SERVER":  {},
+	"$_GET":     {},
+	"$_POST":    {},
+	"$_FILES":   {},
+	"$_COOKIE":  {},
+	"$_SESSION": {},
+	"$_REQUEST": {},
+	"$_ENV":     {},
+}
+
+func (y *builder) readVariable(name string) ssa.Value {
+	if _, ok := superGlobals[name]; !ok {
+		return y.ir.ReadVariable(name, true)
+	}
+	if v := y.ir.ReadVariable(name, false); v != nil {
+		return v
+	}
+	if un := y.ir.EmitUndefine(name); un != nil {
+		y.ir.WriteVariable(name, un)
+		return un
+	}
+	return y.ir.ReadVariable(name, true)
+}
+
 func (y *builder) VisitKeyedVariable(raw phpparser.IKeyedVariableContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
@@ -576,12 +829,15 @@ func (y *builder) VisitKeyedVariable(raw phpparser.IKeyedVariableContext) ssa.Va
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	dollarCount := len(i.AllDollar())
 	var varMain ssa.Value
 	if i.VarName() != nil {
 		// ($*)$a
 		//// {} as index [] as sliceCall
-		varMain = y.ir.ReadVariable(i.VarName().GetText(), true)
+		varMain = y.readVariable(i.VarName().GetText())
 		for i := 0; i < dollarCount; i++ {
 			//TODO: val = y.ir.ReadDynamicVariable(val)
 		}
@@ -594,20 +850,13 @@ func (y *builder) VisitKeyedVariable(raw phpparser.IKeyedVariableContext) ssa.Va
 	}
 
 	for _, a := range i.AllSquareCurlyExpression() {
-		v := y.VisitSquareCurlyExpression(a)
-		if v == nil {
-			count := y.ir.ReadVariable("count", true)
-			calling := y.ir.NewCall(count, nil)
-			y.ir.EmitCall(calling)
-			v = calling
-		}
-		varMain = y.ir.EmitField(varMain, v)
+		varMain = y.ir.EmitField(varMain, y.visitSquareCurlyKey(varMain, a))
 	}
 
 	return varMain
 }
 
-func (y *builder) VisitKeyedSimpleFieldName(raw phpparser.IKeyedSimpleFieldNameContext) interface{} {
+func (y *builder) VisitKeyedSimpleFieldName(obj ssa.Value, raw phpparser.IKeyedSimpleFieldNameContext) ssa.Value {
 	if y == nil || raw == nil {
 		return nil
 	}
@@ -617,19 +866,24 @@ func (y *builder) VisitKeyedSimpleFieldName(raw phpparser.IKeyedSimpleFieldNameC
 		return nil
 	}
 
+	var key ssa.Value
 	if i.Identifier() != nil {
-		v := y.VisitIdentifier(i.Identifier())
-		_ = v
+		key = y.ir.EmitConstInst(y.VisitIdentifier(i.Identifier()))
 	} else if i.Expression() != nil {
-		v := y.VisitExpression(i.Expression())
-		_ = v
+		key = y.VisitExpression(i.Expression())
+	}
+	if key == nil {
+		return nil
 	}
 
+	member := y.ir.EmitField(obj, key)
 	for _, sce := range i.AllSquareCurlyExpression() {
-		y.VisitSquareCurlyExpression(sce)
+		if index := y.VisitSquareCurlyExpression(sce); index != nil {
+			member = y.ir.EmitField(member, index)
+		}
 	}
 
-	return nil
+	return member
 }
 
 func (y *builder) VisitSquareCurlyExpression(raw phpparser.ISquareCurlyExpressionContext) ssa.Value {
@@ -671,8 +925,7 @@ func (y *builder) VisitFunctionCall(raw phpparser.IFunctionCallContext) ssa.Valu
 	}
 
 	v := y.VisitFunctionCallName(i.FunctionCallName())
-	c := y.ir.NewCall(v, nil)
-	return y.ir.EmitCall(c)
+	return y.VisitActualArguments(v, i.ActualArguments())
 }
 
 func (y *builder) VisitFunctionCallName(raw phpparser.IFunctionCallNameContext) ssa.Value {
@@ -739,7 +992,13 @@ func (y *builder) VisitChainBase(raw phpparser.IChainBaseContext) ssa.Value {
 		echo $$a; // world
 	*/
 	if ret := i.QualifiedStaticTypeRef(); ret != nil {
-		panic("NOT IMPL")
+		// Foo::$bar
+		cls := y.readClass(ret.GetText())
+		if len(i.AllKeyedVariable()) <= 0 {
+			return cls
+		}
+		name := strings.TrimPrefix(i.KeyedVariable(0).GetText(), "$")
+		return y.ir.EmitField(cls, y.ir.EmitConstInst(name))
 	} else {
 		var ret ssa.Value
 		for _, i := range i.AllKeyedVariable() {
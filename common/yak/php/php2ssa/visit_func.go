@@ -12,6 +12,9 @@ func (y *builder) VisitFunctionDeclaration(raw phpparser.IFunctionDeclarationCon
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	var attr string
 	if ret := i.Attributes(); ret != nil {
 		y.VisitAttributes(ret)
@@ -27,10 +30,12 @@ func (y *builder) VisitFunctionDeclaration(raw phpparser.IFunctionDeclarationCon
 
 	{
 		y.ir = y.ir.PushFunction(funcDec, symbolTable, current)
+		recoverFuncRange := y.SetRange(raw)
 
 		y.VisitFormalParameterList(i.FormalParameterList())
 		y.VisitBlockStatement(i.BlockStatement())
 
+		recoverFuncRange()
 		y.ir.Finish()
 		y.ir = y.ir.PopFunction()
 	}
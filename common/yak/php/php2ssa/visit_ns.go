@@ -1,6 +1,8 @@
 package php2ssa
 
 import (
+	"strings"
+
 	"github.com/yaklang/yaklang/common/log"
 	phpparser "github.com/yaklang/yaklang/common/yak/php/parser"
 	"github.com/yaklang/yaklang/common/yak/ssa"
@@ -36,11 +38,17 @@ func (y *builder) VisitQualifiedNamespaceName(raw phpparser.IQualifiedNamespaceN
 
 		}
 		return y.ir.EmitConstInst(nil)
-	} else {
-
 	}
 
-	return nil
+	// `\foo\bar` / `bar`, the functions and classes are saved by the short name
+	name := i.GetText()
+	if idx := strings.LastIndex(name, "\\"); idx >= 0 {
+		name = name[idx+1:]
+	}
+	if name == "" {
+		return nil
+	}
+	return y.ir.ReadVariable(name, true)
 }
 
 func (y *builder) VisitNamespaceNameTail(raw phpparser.INamespaceNameTailContext) interface{} {
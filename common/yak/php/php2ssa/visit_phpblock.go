@@ -19,8 +19,20 @@ func (y *builder) VisitPhpBlock(raw phpparser.IPhpBlockContext) interface{} {
 		// handle ImportStmt
 	}
 
+	// functions and classes can be used before they are declared
+	isDeclaration := func(raw phpparser.ITopStatementContext) bool {
+		stmt, _ := raw.(*phpparser.TopStatementContext)
+		return stmt != nil && (stmt.FunctionDeclaration() != nil || stmt.ClassDeclaration() != nil)
+	}
+	for _, stmt := range i.AllTopStatement() {
+		if isDeclaration(stmt) {
+			y.VisitTopStatement(stmt)
+		}
+	}
 	for _, stmt := range i.AllTopStatement() {
-		y.VisitTopStatement(stmt)
+		if !isDeclaration(stmt) {
+			y.VisitTopStatement(stmt)
+		}
 	}
 
 	return nil
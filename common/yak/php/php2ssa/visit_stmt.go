@@ -17,6 +17,9 @@ func (y *builder) VisitTopStatement(raw phpparser.ITopStatementContext) interfac
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	if ret := i.Statement(); ret != nil {
 		y.VisitStatement(ret)
 	} else if ret := i.UseDeclaration(); ret != nil {
@@ -100,6 +103,9 @@ func (y *builder) VisitStatement(raw phpparser.IStatementContext) interface{} {
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	if r := i.LabelStatement(); r != nil {
 		y.VisitLabelStatement(r)
 	} else if b := i.BlockStatement(); b != nil {
@@ -211,6 +217,9 @@ func (y *builder) VisitInnerStatement(raw phpparser.IInnerStatementContext) inte
 		return nil
 	}
 
+	recoverRange := y.SetRange(raw)
+	defer recoverRange()
+
 	if i.Statement() != nil {
 		y.VisitStatement(i.Statement())
 	} else if i.FunctionDeclaration() != nil {
@@ -235,7 +244,8 @@ func (y *builder) VisitTypeHint(raw phpparser.ITypeHintContext) ssa.Type {
 	}
 
 	if r := i.QualifiedStaticTypeRef(); r != nil {
-		_ = y.VisitQualifiedStaticTypeRef(r)
+		// class name as type, do not read it as a variable
+		_ = r.GetText()
 	} else if i.Callable() != nil {
 		_ = i.Callable().GetText()
 	} else if i.PrimitiveType() != nil {
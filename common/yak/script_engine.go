@@ -16,6 +16,7 @@ import (
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/yaklang/yaklang/common/authhack"
+	"github.com/yaklang/yaklang/common/bin-parser/binfuzz"
 	"github.com/yaklang/yaklang/common/chaosmaker"
 	"github.com/yaklang/yaklang/common/crawler"
 	"github.com/yaklang/yaklang/common/crawlerx"
@@ -26,8 +27,11 @@ import (
 	"github.com/yaklang/yaklang/common/ja3"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/mutate"
+	"github.com/yaklang/yaklang/common/netserx"
 	"github.com/yaklang/yaklang/common/openai"
 	"github.com/yaklang/yaklang/common/pcapx"
+	"github.com/yaklang/yaklang/common/phpggc"
+	"github.com/yaklang/yaklang/common/phpserx"
 	"github.com/yaklang/yaklang/common/rpa"
 	"github.com/yaklang/yaklang/common/sca"
 	"github.com/yaklang/yaklang/common/simulator"
@@ -47,7 +51,9 @@ import (
 	"github.com/yaklang/yaklang/common/yak/yaklib/tools"
 	"github.com/yaklang/yaklang/common/yakgrpc/yakit"
 	"github.com/yaklang/yaklang/common/yserx"
+	"github.com/yaklang/yaklang/common/yserx/hessian"
 	"github.com/yaklang/yaklang/common/yso"
+	"github.com/yaklang/yaklang/common/ysonet"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
@@ -197,6 +203,13 @@ func initYaklangLib() {
 
 	// java
 	yaklang.Import("java", yserx.Exports)
+	yaklang.Import("hessian", hessian.Exports)
+
+	// php
+	yaklang.Import("php", phpserx.Exports)
+
+	// .net
+	yaklang.Import("dotnet", netserx.Exports)
 
 	// poc
 	yaklang.Import("poc", yaklib.PoCExports)
@@ -219,11 +232,15 @@ func initYaklangLib() {
 
 	// java Deserialization generates
 	yaklang.Import("yso", yso.Exports)
+	yaklang.Import("phpggc", phpggc.Exports)
+	yaklang.Import("ysonet", ysonet.Exports)
 	yaklang.Import("facades", facades.FacadesExports)
 
 	// t3 deserialization uses
 	yaklang.Import("t3", t3.Exports)
 	yaklang.Import("iiop", iiop.Exports)
+	// bin-parser based protocol fuzzer
+	yaklang.Import("binfuzz", binfuzz.Exports)
 	yaklang.Import("js", yaklib.JSOttoExports)
 
 	yaklang.Import("db", yaklib.DatabaseExports)
@@ -3,6 +3,8 @@ package ssa
 import "fmt"
 
 type Range struct {
+	// FileName is set when the program is built from a project with multiple files
+	FileName   string
 	SourceCode *string
 	Start, End *Position
 }
@@ -0,0 +1,83 @@
+package ssaapi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func checkPHPTopDefsContains(t *testing.T, prog *Program, name string, want string) {
+	var found bool
+	prog.Ref(name).ForEach(func(value *Value) {
+		value.GetTopDefs().ForEach(func(value *Value) {
+			t.Log(value.String())
+			if strings.Contains(value.String(), want) {
+				found = true
+			}
+		})
+	})
+	assert.Truef(t, found, "%v cannot trace to %v", name, want)
+}
+
+func TestPHP_FunctionTrace(t *testing.T) {
+	prog, err := Parse(`<?php
+function filter($a) {
+	return $a;
+}
+$id = $_GET["id"];
+$b = filter($id);
+`, WithLanguage(PHP))
+	require.NoError(t, err)
+	prog.Show()
+	checkPHPTopDefsContains(t, prog, "$b", "$_GET")
+}
+
+func TestPHP_MethodTrace(t *testing.T) {
+	prog, err := Parse(`<?php
+class DB {
+	public function query($sql) {
+		return $sql;
+	}
+}
+$db = new DB();
+$result = $db->query($_GET["id"]);
+`, WithLanguage(PHP))
+	require.NoError(t, err)
+	prog.Show()
+	checkPHPTopDefsContains(t, prog, "$result", "$_GET")
+}
+
+func TestPHP_ParseProject(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"lib/db.php": `<?php
+class DB {
+	public function query($sql) {
+		return $sql;
+	}
+}
+`,
+		"index.php": `<?php
+require_once __DIR__ . "/lib/db.php";
+$db = new DB();
+$result = $db->query($_GET["id"]);
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	prog, err := ParseProject(dir, WithLanguage(PHP))
+	require.NoError(t, err)
+	prog.Show()
+	checkPHPTopDefsContains(t, prog, "$result", "$_GET")
+
+	_, err = ParseProject(dir, WithLanguage(Yak))
+	assert.Error(t, err, "yak does not support project parsing")
+}
@@ -26,6 +26,14 @@ func (p *Program) AddConfig(c *config) {
 	p.config = c
 }
 
+// GetLanguage return the language of source code, yak by default
+func (p *Program) GetLanguage() Language {
+	if p.config == nil || p.config.language == "" {
+		return Yak
+	}
+	return p.config.language
+}
+
 func (p *Program) IsNil() bool {
 	return utils.IsNil(p) || utils.IsNil(p.Program)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/ReneKroon/ttlcache"
 	"github.com/yaklang/yaklang/common/utils"
 	js2ssa "github.com/yaklang/yaklang/common/yak/JS2ssa"
+	"github.com/yaklang/yaklang/common/yak/php/php2ssa"
 	"github.com/yaklang/yaklang/common/yak/ssa"
 	"github.com/yaklang/yaklang/common/yak/yak2ssa"
 )
@@ -15,6 +16,7 @@ type Language string
 const (
 	JS  Language = "js"
 	Yak Language = "yak"
+	PHP Language = "php"
 )
 
 type LanguageParser interface {
@@ -22,10 +24,17 @@ type LanguageParser interface {
 	Feed(string, bool, *ssa.Program)
 }
 
+// ProjectLanguageParser can parse a directory, the files reference each other (include / require / import)
+type ProjectLanguageParser interface {
+	LanguageParser
+	ParseProject(string, bool, func(*ssa.FunctionBuilder)) (*ssa.Program, error)
+}
+
 var (
 	LanguageParsers = map[Language]LanguageParser{
 		Yak: yak2ssa.NewParser(),
 		JS:  js2ssa.NewParser(),
+		PHP: php2ssa.NewParser(),
 	}
 )
 
@@ -155,14 +164,36 @@ func Parse(code string, opts ...Option) (*Program, error) {
 	return ret, nil
 }
 
-func parseWithConfig(c *config) (*ssa.Program, error) {
-	callback := func(fb *ssa.FunctionBuilder) {
+// ParseProject parse all files in the directory, e.g. a php project with include / require
+func ParseProject(root string, opts ...Option) (*Program, error) {
+	config := defaultConfig("")
+	for _, opt := range opts {
+		opt(config)
+	}
+	parser, ok := config.Parser.(ProjectLanguageParser)
+	if !ok || parser == nil {
+		return nil, utils.Errorf("not support project for language %s", config.language)
+	}
+	prog, err := parser.ParseProject(root, config.ignoreSyntaxErr, config.builderCallback())
+	if err != nil {
+		return nil, utils.Wrapf(err, "parse project error")
+	}
+	ret := NewProgram(prog)
+	ret.AddConfig(config)
+	return ret, nil
+}
+
+func (c *config) builderCallback() func(fb *ssa.FunctionBuilder) {
+	return func(fb *ssa.FunctionBuilder) {
 		fb.WithExternLib(c.externLib)
 		fb.WithExternValue(c.externValue)
 		fb.WithExternMethod(c.externMethod)
 		fb.WithDefineFunction(c.defineFunc)
 	}
-	return c.Parser.Parse(c.code, c.ignoreSyntaxErr, callback)
+}
+
+func parseWithConfig(c *config) (*ssa.Program, error) {
+	return c.Parser.Parse(c.code, c.ignoreSyntaxErr, c.builderCallback())
 }
 
 func (p *Program) Feed(code string) {
@@ -173,7 +204,8 @@ func (p *Program) Feed(code string) {
 }
 
 var Exports = map[string]any{
-	"Parse": Parse,
+	"Parse":        Parse,
+	"ParseProject": ParseProject,
 
 	"withLanguage":    WithLanguage,
 	"withExternLib":   WithExternLib,
@@ -181,4 +213,5 @@ var Exports = map[string]any{
 	// language:
 	"Javascript": JS,
 	"Yak":        Yak,
+	"PHP":        PHP,
 }
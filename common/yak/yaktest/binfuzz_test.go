@@ -0,0 +1,37 @@
+package yaktest
+
+import "testing"
+
+func TestBinFuzzExports(t *testing.T) {
+	cases := []YakTestCase{
+		{
+			Name: "binfuzz mutate with the inferred length field",
+			Src: `seed = codec.DecodeHex("0001000000060103006b0003")~
+f = binfuzz.NewFuzzer(seed, "application-layer.modbus", binfuzz.keys("Messages"))~
+payload = f.Mutate("#0/Data", "AAAAAAAAAA")~
+assert codec.EncodeToHex(payload) == "00010000000c0103" + codec.EncodeToHex("AAAAAAAAAA"), codec.EncodeToHex(payload)
+`,
+		},
+		{
+			Name: "binfuzz generate cases of selected fields",
+			Src: `f = binfuzz.NewFuzzer(codec.DecodeHex("0001000000060103006b0003")~, "application-layer.modbus", binfuzz.keys("Messages"), binfuzz.fields("*/Data"), binfuzz.maxCases(5), binfuzz.timeout(1))~
+count = 0
+for c in f.Generate(context.Background()) {
+	assert c.Field == "#0/Data", c.Field
+	count++
+}
+assert count == 5, count
+`,
+		},
+		{
+			Name: "binfuzz checksum field",
+			Src: `f = binfuzz.NewFuzzer(codec.DecodeHex("0001000000060103006b0003")~, "application-layer.modbus", binfuzz.keys("Messages"), binfuzz.checksumField("#0/MBAP Header/Transaction ID", binfuzz.CHECKSUM_SUM8, "#0/Data"))~
+payload = f.Mutate("#0/Data", codec.DecodeHex("010203")~)~
+assert codec.EncodeToHex(payload) == "0006000000050103010203", codec.EncodeToHex(payload)
+assert binfuzz.STATUS_CRASH == "crash"
+`,
+		},
+	}
+
+	Run("binfuzz exports", t, cases...)
+}
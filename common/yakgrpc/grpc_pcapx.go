@@ -5,7 +5,6 @@ import (
 	"context"
 	"errors"
 	"github.com/google/gopacket"
-	"github.com/google/gopacket/pcap"
 	"github.com/samber/lo"
 	bin_parser2 "github.com/yaklang/yaklang/common/bin-parser"
 	bin_parser "github.com/yaklang/yaklang/common/bin-parser/parser"
@@ -23,18 +22,18 @@ import (
 	"strings"
 )
 
-func pcapIftoYpbIf(item *pcap.Interface, index int) *ypb.NetInterface {
+func pcapIftoYpbIf(item *pcaputil.Device, index int) *ypb.NetInterface {
 	var is4, is6 = false, false
 	var addr []string
 	var ip string
 	for _, a := range item.Addresses {
-		addr = append(addr, a.IP.String())
+		addr = append(addr, a.String())
 		if !is4 {
-			ip = a.IP.String()
-			is4 = utils.IsIPv4(a.IP.String())
+			ip = a.String()
+			is4 = utils.IsIPv4(a.String())
 		}
 		if !is6 {
-			is6 = utils.IsIPv6(a.IP.String())
+			is6 = utils.IsIPv6(a.String())
 		}
 	}
 	return &ypb.NetInterface{
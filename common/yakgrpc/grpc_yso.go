@@ -70,22 +70,51 @@ type optionInfo struct {
 
 func getAllGadgetInfo() []*yso.GadgetInfo {
 	res := []*yso.GadgetInfo{}
-	names := []string{}
-	for name, _ := range yso.AllGadgets {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-	for _, name := range names {
-		res = append(res, yso.AllGadgets[name])
+	// java serialized gadgets first, then the gadgets of hessian, xstream, fastjson and jackson
+	for _, gadgets := range []map[string]*yso.GadgetInfo{yso.AllGadgets, yso.AllFormatGadgets} {
+		names := []string{}
+		for name, _ := range gadgets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			res = append(res, gadgets[name])
+		}
 	}
 	return res
 }
 
+func getGadgetInfo(gadget string) (*yso.GadgetInfo, bool) {
+	if info, ok := yso.AllGadgets[gadget]; ok {
+		return info, true
+	}
+	info, ok := yso.AllFormatGadgets[gadget]
+	return info, ok
+}
+
+// getGadgetFileExt get the file extension of payload by the serialization format of gadget
+func getGadgetFileExt(gadget string) string {
+	info, ok := getGadgetInfo(gadget)
+	if !ok {
+		return ".ser"
+	}
+	switch info.GetFormat() {
+	case yso.FormatHessian:
+		return ".hessian"
+	case yso.FormatXStream:
+		return ".xml"
+	case yso.FormatFastjson, yso.FormatJackson:
+		return ".json"
+	default:
+		return ".ser"
+	}
+}
+
 func checkGadgetIsTemplateSupported(gadget string) bool {
 	if gadget == "None" {
 		return true
 	}
-	info, ok := yso.AllGadgets[gadget]
+	info, ok := getGadgetInfo(gadget)
 	if !ok {
 		log.Error("gadget not found")
 		return false
@@ -350,6 +379,9 @@ func generateYsoCode(req *ypb.YsoOptionsRequerstWithVerbose) (string, map[string
 	var gadget string
 	if req.Gadget != "None" {
 		gadget = fmt.Sprintf("Get%sJavaObject", req.Gadget)
+		if info, ok := getGadgetInfo(req.Gadget); ok && info.YakFun != "" {
+			gadget = info.YakFun
+		}
 	}
 	gadgetCodeTmp := `log.setLevel("info")
 gadgetObj,err = yso.$gadgetFun($options)
@@ -600,7 +632,7 @@ func (s *Server) GenerateYsoBytes(ctx context.Context, req *ypb.YsoOptionsRequer
 		fileName = fmt.Sprintf("%s.class", className)
 		code = codeRsp + "\nout(classBytes)"
 	} else {
-		fileName = fmt.Sprintf("%s_%s.ser", req.Gadget, req.Class)
+		fileName = fmt.Sprintf("%s_%s%s", req.Gadget, req.Class, getGadgetFileExt(req.Gadget))
 		code = codeRsp + "\nout(gadgetBytes)"
 	}
 	engin := yaklang.New()
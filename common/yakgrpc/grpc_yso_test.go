@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,9 +24,11 @@ func TestGRPCMUSTPASS_COMMON_yso(t *testing.T) {
 		t.Fatal(err)
 	}
 	//Test to get all yso options (ysoVerboses, t)
-	assert.Equal(t, len(ysoVerboses.GetOptions()), len(yso.AllGadgets))
+	assert.Equal(t, len(ysoVerboses.GetOptions()), len(yso.AllGadgets)+len(yso.AllFormatGadgets))
 	for _, option := range ysoVerboses.GetOptions() {
-		assert.Equal(t, yso.AllGadgets[option.GetName()].Name, option.GetName())
+		info, ok := getGadgetInfo(option.GetName())
+		assert.True(t, ok)
+		assert.Equal(t, info.Name, option.GetName())
 		classOptions, err := client.GetAllYsoClassOptions(ctx, &ypb.YsoOptionsRequerstWithVerbose{Gadget: option.GetName()})
 		if err != nil {
 			t.Fatal(err)
@@ -60,3 +63,71 @@ func TestGRPCMUSTPASS_COMMON_yso(t *testing.T) {
 		}
 	}
 }
+
+func TestGRPCMUSTPASS_COMMON_yso_FormatGadget(t *testing.T) {
+	client, err := NewLocalClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	ysoVerboses, err := client.GetAllYsoGadgetOptions(ctx, &ypb.Empty{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]struct{})
+	for _, option := range ysoVerboses.GetOptions() {
+		names[option.GetName()] = struct{}{}
+	}
+	for name := range yso.AllFormatGadgets {
+		assert.Contains(t, names, name)
+	}
+
+	for _, testcase := range []struct {
+		gadget   string
+		class    string
+		options  []*ypb.YsoClassGeneraterOptionsWithVerbose
+		fileName string
+		prefix   string
+	}{
+		{
+			gadget: yso.FastjsonTemplatesImplGadgetName,
+			class:  string(JavaBytesCodeType_RuntimeExec),
+			options: []*ypb.YsoClassGeneraterOptionsWithVerbose{
+				{Key: string(JavaClassGeneraterOption_ClassName), Value: "Exploit"},
+				{Key: string(JavaClassGeneraterOption_Command), Value: "whoami"},
+			},
+			fileName: "FastjsonTemplatesImpl_RuntimeExec.json",
+			prefix:   "{",
+		},
+		{
+			gadget: yso.XStreamEventHandlerGadgetName,
+			class:  string(JavaBytesCodeType_RuntimeExec),
+			options: []*ypb.YsoClassGeneraterOptionsWithVerbose{
+				{Key: string(JavaClassGeneraterOption_Command), Value: "whoami"},
+			},
+			fileName: "XStreamEventHandler_RuntimeExec.xml",
+			prefix:   "<",
+		},
+		{
+			gadget: yso.HessianRomeSignedObjectGadgetName,
+			class:  string(JavaBytesCodeType_DNSlog),
+			options: []*ypb.YsoClassGeneraterOptionsWithVerbose{
+				{Key: string(JavaClassGeneraterOption_ClassName), Value: "Exploit"},
+				{Key: string(JavaClassGeneraterOption_Domain), Value: "example.com"},
+			},
+			fileName: "HessianRomeSignedObject_DNSlog.hessian",
+		},
+	} {
+		rsp, err := client.GenerateYsoBytes(ctx, &ypb.YsoOptionsRequerstWithVerbose{
+			Gadget:  testcase.gadget,
+			Class:   testcase.class,
+			Options: testcase.options,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testcase.fileName, rsp.FileName)
+		assert.NotEmpty(t, rsp.Bytes, testcase.gadget)
+		assert.True(t, strings.HasPrefix(string(rsp.Bytes), testcase.prefix), testcase.gadget)
+	}
+}
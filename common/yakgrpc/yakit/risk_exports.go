@@ -64,6 +64,8 @@ func RiskTypeToVerbose(i string) string {
 		return "local File Includes (LFI)"
 	case "rfi":
 		return "Remote File contains (RFI)"
+	case "ids", "suricata":
+		return "Intrusion detection [Suricata]"
 	}
 	return strings.ToUpper(i)
 }
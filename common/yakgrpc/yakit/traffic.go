@@ -2,8 +2,10 @@ package yakit
 
 import (
 	"github.com/jinzhu/gorm"
+	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/utils/bizhelper"
 	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+	"strings"
 	"time"
 )
 
@@ -63,6 +65,12 @@ type TrafficSession struct {
 	// TLS ClientHello
 	HaveClientHello bool
 	SNI             string
+
+	// Protocol is the application layer protocol dissected by bin-parser rules
+	Protocol string `gorm:"index"`
+
+	// decodedFields is the decoded fields saved for the session, the same field in frames is saved once
+	decodedFields map[[2]string]struct{}
 }
 
 type TrafficTCPReassembledFrame struct {
@@ -74,6 +82,10 @@ type TrafficTCPReassembledFrame struct {
 	Timestamp   int64
 	Source      string
 	Destination string
+
+	// Protocol / DecodedJSON is the message tree dissected by bin-parser rules
+	Protocol    string
+	DecodedJSON string
 }
 
 type TrafficPacket struct {
@@ -102,6 +114,17 @@ type TrafficPacket struct {
 	TransportEndpointPortDst        int
 }
 
+// TrafficDecodedField is the flattened leaf of dissected message, used for filtering sessions by decoded field
+type TrafficDecodedField struct {
+	gorm.Model
+
+	SessionUuid string `gorm:"index"`
+	Protocol    string
+	// Path is joined by "/", the index of list is "#n", such as "DNS/Questions/#0/Type"
+	Path  string `gorm:"index"`
+	Value string
+}
+
 func SaveTrafficSession(db *gorm.DB, session *TrafficSession) error {
 	return db.Save(session).Error
 }
@@ -110,6 +133,57 @@ func SaveTrafficPacket(db *gorm.DB, packet *TrafficPacket) error {
 	return db.Save(packet).Error
 }
 
+// SaveTrafficDecodedFields save the decoded fields of a message in one transaction
+func SaveTrafficDecodedFields(db *gorm.DB, sessionUuid string, protocol string, fields map[string]string) error {
+	if len(fields) <= 0 {
+		return nil
+	}
+	return utils.GormTransaction(db, func(tx *gorm.DB) error {
+		for path, value := range fields {
+			err := tx.Save(&TrafficDecodedField{
+				SessionUuid: sessionUuid,
+				Protocol:    protocol,
+				Path:        path,
+				Value:       value,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TrafficSessionFilter filter the sessions by the dissected protocol and decoded field
+type TrafficSessionFilter struct {
+	Protocol string
+	// Field is the path of decoded field, Value is a literal substring of the decoded value
+	// (LIKE with % / _ escaped by "!"), empty Value means the field existed
+	Field string
+	Value string
+}
+
+// trafficLikeEscaper escape the wildcards of LIKE, the decoded value is matched literally
+var trafficLikeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+func (f *TrafficSessionFilter) apply(db *gorm.DB) *gorm.DB {
+	if f == nil {
+		return db
+	}
+	if f.Protocol != "" {
+		db = db.Where("protocol = ?", strings.ToLower(f.Protocol))
+	}
+	if f.Field != "" {
+		fields := db.NewScope(&TrafficDecodedField{}).TableName()
+		if f.Value != "" {
+			db = db.Where("uuid IN (SELECT session_uuid FROM "+fields+" WHERE path = ? AND value LIKE ? ESCAPE '!' AND deleted_at IS NULL)", f.Field, "%"+trafficLikeEscaper.Replace(f.Value)+"%")
+		} else {
+			db = db.Where("uuid IN (SELECT session_uuid FROM "+fields+" WHERE path = ? AND deleted_at IS NULL)", f.Field)
+		}
+	}
+	return db
+}
+
 func QueryTrafficTCPReassembled(db *gorm.DB, request *ypb.QueryTrafficTCPReassembledRequest) (*bizhelper.Paginator, []*TrafficTCPReassembledFrame, error) {
 	db = db.Model(&TrafficTCPReassembledFrame{})
 
@@ -144,9 +218,18 @@ func QueryTrafficSessionByUUID(db *gorm.DB, uuid string) (*TrafficSession, error
 	return &data, nil
 }
 
-func QueryTrafficSession(db *gorm.DB, request *ypb.QueryTrafficSessionRequest) (*bizhelper.Paginator, []*TrafficSession, error) {
+func QueryTrafficSession(db *gorm.DB, request *ypb.QueryTrafficSessionRequest, filters ...*TrafficSessionFilter) (*bizhelper.Paginator, []*TrafficSession, error) {
 	db = db.Model(&TrafficSession{})
 
+	filters = append(filters, &TrafficSessionFilter{
+		Protocol: request.GetProtocol(),
+		Field:    request.GetDecodedField(),
+		Value:    request.GetDecodedFieldValue(),
+	})
+	for _, filter := range filters {
+		db = filter.apply(db)
+	}
+
 	if request.GetTimestampNow() > 0 {
 		db = db.Where("created_at >= ?", time.Unix(request.GetTimestampNow(), 0))
 	}
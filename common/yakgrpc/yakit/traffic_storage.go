@@ -7,6 +7,7 @@ import (
 	"github.com/google/gopacket/layers"
 	"github.com/jinzhu/gorm"
 	uuid "github.com/satori/go.uuid"
+	bin_parser "github.com/yaklang/yaklang/common/bin-parser"
 	"github.com/yaklang/yaklang/common/log"
 	"github.com/yaklang/yaklang/common/pcapx/pcaputil"
 	"github.com/yaklang/yaklang/common/utils"
@@ -275,9 +276,13 @@ func (m *TrafficStorageManager) CreateTCPReassembledFlow(flow *pcaputil.TrafficF
 		return utils.Error("flow is nil")
 	}
 	var hash = flowHashCalc(flow.ClientConn.LocalAddr().String(), flow.ClientConn.RemoteAddr().String())
+	sessionType := "tcp"
+	if flow.IsUDP {
+		sessionType = "udp"
+	}
 	session := &TrafficSession{
 		Uuid:                  uuid.NewV4().String(),
-		SessionType:           "tcp",
+		SessionType:           sessionType,
 		IsIpv4:                flow.IsIpv4,
 		IsIpv6:                flow.IsIpv6,
 		NetworkSrcIP:          flow.ClientConn.LocalIP().String(),
@@ -285,7 +290,7 @@ func (m *TrafficStorageManager) CreateTCPReassembledFlow(flow *pcaputil.TrafficF
 		IsTcpIpStack:          true,
 		TransportLayerSrcPort: flow.ClientConn.LocalPort(),
 		TransportLayerDstPort: flow.ClientConn.RemotePort(),
-		IsTCPReassembled:      true,
+		IsTCPReassembled:      !flow.IsUDP,
 		IsHalfOpen:            flow.IsHalfOpen,
 	}
 	err := SaveTrafficSession(m.db, session)
@@ -323,9 +328,63 @@ func (m *TrafficStorageManager) SaveTCPReassembledFrame(flow *pcaputil.TrafficFl
 		Seq:         int64(frame.Seq),
 		Timestamp:   frame.Timestamp.Unix(),
 	}
+	transport := "tcp"
+	if flow.IsUDP {
+		transport = "udp"
+	}
+	storageFrame.Protocol, storageFrame.DecodedJSON = m.dissect(session, frame.Payload, &bin_parser.DissectHint{
+		Transport: transport,
+		SrcPort:   flow.ClientConn.LocalPort(),
+		DstPort:   flow.ServerConn.LocalPort(),
+	})
 	return m.db.Save(storageFrame).Error
 }
 
+// dissect decode the application layer payload of reassembled frame with bin-parser rules,
+// the protocol of session is fixed by the first dissected payload, and the new decoded fields of session are saved for filtering.
+func (m *TrafficStorageManager) dissect(session *TrafficSession, payload []byte, hint *bin_parser.DissectHint) (string, string) {
+	if len(payload) <= 0 {
+		return "", ""
+	}
+	if session != nil {
+		hint.Protocol = session.Protocol
+	}
+	result, err := bin_parser.Dissect(payload, hint)
+	if err != nil {
+		return "", ""
+	}
+	decoded, err := bin_parser.ResultToJson(result.Tree)
+	if err != nil {
+		log.Debugf("marshal %s message failed: %s", result.Protocol, err)
+	}
+	if session == nil {
+		return result.Protocol, decoded
+	}
+
+	if session.Protocol == "" {
+		session.Protocol = result.Protocol
+		if err := SaveTrafficSession(m.db, session); err != nil {
+			log.Errorf("save traffic session failed: %s", err)
+		}
+	}
+	if session.decodedFields == nil {
+		session.decodedFields = make(map[[2]string]struct{})
+	}
+	fields := make(map[string]string)
+	for path, value := range result.Fields() {
+		key := [2]string{path, value}
+		if _, ok := session.decodedFields[key]; ok {
+			continue
+		}
+		session.decodedFields[key] = struct{}{}
+		fields[path] = value
+	}
+	if err := SaveTrafficDecodedFields(m.db, session.Uuid, result.Protocol, fields); err != nil {
+		log.Errorf("save decoded fields failed: %s", err)
+	}
+	return result.Protocol, decoded
+}
+
 func (m *TrafficStorageManager) CreateHTTPFlow(flow *pcaputil.TrafficFlow, req *http.Request, rsp *http.Response) error {
 	return nil
 }
@@ -0,0 +1,115 @@
+package yakit
+
+import (
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+	bin_parser "github.com/yaklang/yaklang/common/bin-parser"
+	"github.com/yaklang/yaklang/common/consts"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+	"github.com/yaklang/yaklang/common/yakgrpc/ypb"
+)
+
+func TestTrafficSession_Dissect(t *testing.T) {
+	db := consts.GetGormProjectDatabase()
+	db.AutoMigrate(&TrafficSession{}, &TrafficDecodedField{})
+	m := NewTrafficStorageManager(db)
+
+	session := &TrafficSession{Uuid: uuid.NewV4().String(), SessionType: "udp"}
+	if err := SaveTrafficSession(db, session); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Where("uuid = ?", session.Uuid).Delete(&TrafficSession{})
+		db.Unscoped().Where("session_uuid = ?", session.Uuid).Delete(&TrafficDecodedField{})
+	})
+
+	// query yaklang-dissect-test.com A
+	domain := "yaklang-dissect-test"
+	query, _ := codec.DecodeHex("abcd01000001000000000000" + "14" + codec.EncodeToHex(domain) + "03636f6d0000010001")
+	protocol, decoded := m.dissect(session, query, &bin_parser.DissectHint{Transport: "udp", SrcPort: 40000, DstPort: 53})
+	if protocol != "dns" || decoded == "" || session.Protocol != "dns" {
+		t.Fatalf("dissect dns failed: %v %v", protocol, session.Protocol)
+	}
+
+	// the same fields in the frames of session are saved once
+	if protocol, _ := m.dissect(session, query, &bin_parser.DissectHint{Transport: "udp", SrcPort: 40000, DstPort: 53}); protocol != "dns" {
+		t.Fatalf("dissect dns failed: %v", protocol)
+	}
+	var count int
+	if err := db.Model(&TrafficDecodedField{}).Where("session_uuid = ?", session.Uuid).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != len(mustDissect(t, query).Fields()) {
+		t.Fatalf("decoded fields should be saved once, got %v", count)
+	}
+
+	var path string
+	for k, v := range mustDissect(t, query).Fields() {
+		if v == domain {
+			path = k
+		}
+	}
+	if path == "" {
+		t.Fatal("domain field not found")
+	}
+
+	for _, c := range []struct {
+		filter *TrafficSessionFilter
+		found  bool
+	}{
+		{&TrafficSessionFilter{Protocol: "dns"}, true},
+		{&TrafficSessionFilter{Protocol: "dns", Field: path, Value: "dissect-test"}, true},
+		{&TrafficSessionFilter{Field: path}, true},
+		{&TrafficSessionFilter{Protocol: "tls"}, false},
+		{&TrafficSessionFilter{Field: path, Value: "not-existed"}, false},
+		// the wildcards of LIKE are matched literally
+		{&TrafficSessionFilter{Field: path, Value: "dissect_test"}, false},
+		{&TrafficSessionFilter{Field: path, Value: "%"}, false},
+	} {
+		found := querySession(t, db, session.Uuid, &ypb.QueryTrafficSessionRequest{
+			Pagination: &ypb.Paging{Page: 1, Limit: 100},
+		}, c.filter)
+		if found != c.found {
+			t.Fatalf("filter %+v error: found %v", c.filter, found)
+		}
+	}
+
+	// the filter from grpc request
+	for _, c := range []struct {
+		request *ypb.QueryTrafficSessionRequest
+		found   bool
+	}{
+		{&ypb.QueryTrafficSessionRequest{Protocol: "DNS"}, true},
+		{&ypb.QueryTrafficSessionRequest{Protocol: "dns", DecodedField: path, DecodedFieldValue: domain}, true},
+		{&ypb.QueryTrafficSessionRequest{DecodedField: path, DecodedFieldValue: "not-existed"}, false},
+		{&ypb.QueryTrafficSessionRequest{Protocol: "http"}, false},
+	} {
+		c.request.Pagination = &ypb.Paging{Page: 1, Limit: 100}
+		if found := querySession(t, db, session.Uuid, c.request); found != c.found {
+			t.Fatalf("request %v error: found %v", c.request.String(), found)
+		}
+	}
+}
+
+func querySession(t *testing.T, db *gorm.DB, sessionUuid string, request *ypb.QueryTrafficSessionRequest, filters ...*TrafficSessionFilter) bool {
+	_, sessions, err := QueryTrafficSession(db, request, filters...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sessions {
+		if s.Uuid == sessionUuid {
+			return true
+		}
+	}
+	return false
+}
+
+func mustDissect(t *testing.T, payload []byte) *bin_parser.DissectResult {
+	result, err := bin_parser.Dissect(payload, &bin_parser.DissectHint{Transport: "udp", DstPort: 53})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package hessian
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// Dump format the hessian values as readable text
+func Dump(objs ...HessianSerializable) string {
+	var buf strings.Builder
+	for _, obj := range objs {
+		dumpValue(&buf, obj, 0)
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// DumpHessian2 parse and format the hessian 2.0 serialized bytes
+func DumpHessian2(raw []byte) (string, error) {
+	objs, err := ParseHessian2(raw)
+	if err != nil {
+		return "", err
+	}
+	return Dump(objs...), nil
+}
+
+func dumpValue(buf *strings.Builder, obj HessianSerializable, level int) {
+	indent := strings.Repeat("  ", level+1)
+	end := strings.Repeat("  ", level)
+	switch ret := obj.(type) {
+	case *HessianBool:
+		buf.WriteString(strconv.FormatBool(ret.Value))
+	case *HessianInt:
+		buf.WriteString(fmt.Sprintf("int %d", ret.Value))
+	case *HessianLong:
+		buf.WriteString(fmt.Sprintf("long %d", ret.Value))
+	case *HessianDouble:
+		buf.WriteString(fmt.Sprintf("double %v", ret.Value))
+	case *HessianDate:
+		buf.WriteString(fmt.Sprintf("date %s", time.UnixMilli(ret.Value).UTC().Format(time.RFC3339Nano)))
+	case *HessianString:
+		buf.WriteString(strconv.Quote(ret.Value))
+	case *HessianBinary:
+		buf.WriteString(fmt.Sprintf("binary(%d) %s", len(ret.Value), codec.EncodeToHex(ret.Value)))
+	case *HessianRef:
+		buf.WriteString(fmt.Sprintf("ref #%d", ret.Index))
+	case *HessianList:
+		name := ret.ClassName
+		if name == "" {
+			name = "list"
+		}
+		buf.WriteString(fmt.Sprintf("%s(%d) [", name, len(ret.Items)))
+		if len(ret.Items) == 0 {
+			buf.WriteString("]")
+			return
+		}
+		buf.WriteString("\n")
+		for _, item := range ret.Items {
+			buf.WriteString(indent)
+			dumpValue(buf, item, level+1)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(end + "]")
+	case *HessianMap:
+		name := ret.ClassName
+		if name == "" {
+			name = "map"
+		}
+		buf.WriteString(name + " {")
+		if len(ret.Entries) == 0 {
+			buf.WriteString("}")
+			return
+		}
+		buf.WriteString("\n")
+		for _, entry := range ret.Entries {
+			buf.WriteString(indent)
+			dumpValue(buf, entry.Key, level+1)
+			buf.WriteString(": ")
+			dumpValue(buf, entry.Value, level+1)
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(end + "}")
+	case *HessianObject:
+		buf.WriteString(ret.ClassName + " {")
+		if len(ret.Fields) == 0 {
+			buf.WriteString("}")
+			return
+		}
+		buf.WriteString("\n")
+		for _, field := range ret.Fields {
+			buf.WriteString(indent + field.Name + " = ")
+			dumpValue(buf, field.Value, level+1)
+			buf.WriteString("\n")
+		}
+		buf.WriteString(end + "}")
+	default:
+		buf.WriteString("null")
+	}
+}
@@ -0,0 +1,27 @@
+package hessian
+
+var Exports = map[string]interface{}{
+	"ParseHessian2":   ParseHessian2,
+	"MarshalHessian2": MarshalHessian2,
+	"MarshalHessian1": MarshalHessian1,
+	"ToJson":          ToJson,
+	"FromJson":        FromJson,
+	"Dump":            Dump,
+	"DumpHessian2":    DumpHessian2,
+
+	"NewHessianNull":     NewHessianNull,
+	"NewHessianBool":     NewHessianBool,
+	"NewHessianInt":      NewHessianInt,
+	"NewHessianLong":     NewHessianLong,
+	"NewHessianDouble":   NewHessianDouble,
+	"NewHessianDate":     NewHessianDate,
+	"NewHessianString":   NewHessianString,
+	"NewHessianBinary":   NewHessianBinary,
+	"NewHessianList":     NewHessianList,
+	"NewHessianMap":      NewHessianMap,
+	"NewHessianMapEntry": NewHessianMapEntry,
+	"NewHessianObject":   NewHessianObject,
+	"NewHessianField":    NewHessianField,
+	"NewHessianRef":      NewHessianRef,
+	"NewHessianClass":    NewHessianClass,
+}
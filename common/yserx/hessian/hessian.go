@@ -0,0 +1,175 @@
+package hessian
+
+const (
+	TypeNull   = "null"
+	TypeBool   = "bool"
+	TypeInt    = "int"
+	TypeLong   = "long"
+	TypeDouble = "double"
+	TypeDate   = "date"
+	TypeString = "string"
+	TypeBinary = "binary"
+	TypeList   = "list"
+	TypeMap    = "map"
+	TypeObject = "object"
+	TypeRef    = "ref"
+)
+
+// HessianSerializable is a value in hessian stream, the concrete types are the Hessian* structs in this package
+type HessianSerializable interface {
+	GetType() string
+}
+
+type HessianNull struct {
+	Type string `json:"type"`
+}
+
+type HessianBool struct {
+	Type  string `json:"type"`
+	Value bool   `json:"value"`
+}
+
+type HessianInt struct {
+	Type  string `json:"type"`
+	Value int32  `json:"value"`
+}
+
+type HessianLong struct {
+	Type  string `json:"type"`
+	Value int64  `json:"value"`
+}
+
+type HessianDouble struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
+// HessianDate is java.util.Date, Value is the milliseconds since epoch
+type HessianDate struct {
+	Type  string `json:"type"`
+	Value int64  `json:"value"`
+}
+
+type HessianString struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type HessianBinary struct {
+	Type  string `json:"type"`
+	Value []byte `json:"value"`
+}
+
+// HessianList is array or java.util.List, Fixed means the length is written before the items
+type HessianList struct {
+	Type      string                `json:"type"`
+	ClassName string                `json:"class_name,omitempty"`
+	Fixed     bool                  `json:"fixed"`
+	Items     []HessianSerializable `json:"items"`
+}
+
+type HessianMapEntry struct {
+	Key   HessianSerializable `json:"key"`
+	Value HessianSerializable `json:"value"`
+}
+
+// HessianMap is java.util.Map, ClassName is empty for untyped map (java.util.HashMap)
+type HessianMap struct {
+	Type      string             `json:"type"`
+	ClassName string             `json:"class_name,omitempty"`
+	Entries   []*HessianMapEntry `json:"entries"`
+}
+
+type HessianField struct {
+	Name  string              `json:"name"`
+	Value HessianSerializable `json:"value"`
+}
+
+// HessianObject is the java object serialized by fields
+type HessianObject struct {
+	Type      string          `json:"type"`
+	ClassName string          `json:"class_name"`
+	Fields    []*HessianField `json:"fields"`
+}
+
+// HessianRef refers to the Index-th list, map or object in the stream
+type HessianRef struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+}
+
+func (*HessianNull) GetType() string   { return TypeNull }
+func (*HessianBool) GetType() string   { return TypeBool }
+func (*HessianInt) GetType() string    { return TypeInt }
+func (*HessianLong) GetType() string   { return TypeLong }
+func (*HessianDouble) GetType() string { return TypeDouble }
+func (*HessianDate) GetType() string   { return TypeDate }
+func (*HessianString) GetType() string { return TypeString }
+func (*HessianBinary) GetType() string { return TypeBinary }
+func (*HessianList) GetType() string   { return TypeList }
+func (*HessianMap) GetType() string    { return TypeMap }
+func (*HessianObject) GetType() string { return TypeObject }
+func (*HessianRef) GetType() string    { return TypeRef }
+
+func NewHessianNull() *HessianNull {
+	return &HessianNull{Type: TypeNull}
+}
+
+func NewHessianBool(b bool) *HessianBool {
+	return &HessianBool{Type: TypeBool, Value: b}
+}
+
+func NewHessianInt(i int32) *HessianInt {
+	return &HessianInt{Type: TypeInt, Value: i}
+}
+
+func NewHessianLong(i int64) *HessianLong {
+	return &HessianLong{Type: TypeLong, Value: i}
+}
+
+func NewHessianDouble(f float64) *HessianDouble {
+	return &HessianDouble{Type: TypeDouble, Value: f}
+}
+
+func NewHessianDate(millis int64) *HessianDate {
+	return &HessianDate{Type: TypeDate, Value: millis}
+}
+
+func NewHessianString(s string) *HessianString {
+	return &HessianString{Type: TypeString, Value: s}
+}
+
+func NewHessianBinary(b []byte) *HessianBinary {
+	return &HessianBinary{Type: TypeBinary, Value: b}
+}
+
+// NewHessianList create a fixed length list, className is empty for untyped list
+func NewHessianList(className string, items ...HessianSerializable) *HessianList {
+	return &HessianList{Type: TypeList, ClassName: className, Fixed: true, Items: items}
+}
+
+func NewHessianMapEntry(key, value HessianSerializable) *HessianMapEntry {
+	return &HessianMapEntry{Key: key, Value: value}
+}
+
+// NewHessianMap create a map, className is empty for untyped map
+func NewHessianMap(className string, entries ...*HessianMapEntry) *HessianMap {
+	return &HessianMap{Type: TypeMap, ClassName: className, Entries: entries}
+}
+
+func NewHessianField(name string, value HessianSerializable) *HessianField {
+	return &HessianField{Name: name, Value: value}
+}
+
+func NewHessianObject(className string, fields ...*HessianField) *HessianObject {
+	return &HessianObject{Type: TypeObject, ClassName: className, Fields: fields}
+}
+
+func NewHessianRef(index int) *HessianRef {
+	return &HessianRef{Type: TypeRef, Index: index}
+}
+
+// NewHessianClass create java.lang.Class object, it is serialized by the class name
+func NewHessianClass(className string) *HessianObject {
+	return NewHessianObject("java.lang.Class", NewHessianField("name", NewHessianString(className)))
+}
@@ -0,0 +1,129 @@
+package hessian
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+func TestHessian2Encoding(t *testing.T) {
+	// test vectors from hessian 2.0 serialization protocol
+	for _, c := range []struct {
+		value HessianSerializable
+		hex   string
+	}{
+		{NewHessianNull(), "4e"},
+		{NewHessianBool(true), "54"},
+		{NewHessianInt(0), "90"},
+		{NewHessianInt(-16), "80"},
+		{NewHessianInt(47), "bf"},
+		{NewHessianInt(-2048), "c000"},
+		{NewHessianInt(2047), "cfff"},
+		{NewHessianInt(-262144), "d00000"},
+		{NewHessianInt(262143), "d7ffff"},
+		{NewHessianInt(262144), "4900040000"},
+		{NewHessianLong(0), "e0"},
+		{NewHessianLong(-8), "d8"},
+		{NewHessianLong(2047), "ffff"},
+		{NewHessianLong(262143), "3fffff"},
+		{NewHessianLong(262144), "5900040000"},
+		{NewHessianLong(1 << 40), "4c0000010000000000"},
+		{NewHessianDouble(0), "5b"},
+		{NewHessianDouble(1), "5c"},
+		{NewHessianDouble(-128), "5d80"},
+		{NewHessianDouble(32767), "5e7fff"},
+		{NewHessianDouble(12.25), "5f00002fda"},
+		{NewHessianDate(894621060000), "4b00e3838f"},
+		{NewHessianDate(894621091000), "4a000000d04b9284b8"},
+		{NewHessianString("hello"), "0568656c6c6f"},
+		{NewHessianString("\u00c3"), "01c383"},
+		{NewHessianBinary([]byte{1, 2, 3}), "23010203"},
+		{NewHessianList("[int", NewHessianInt(0), NewHessianInt(1)), "72045b696e749091"},
+		{&HessianList{Type: TypeList, Items: []HessianSerializable{NewHessianInt(0)}}, "57905a"},
+		{NewHessianMap("", NewHessianMapEntry(NewHessianInt(1), NewHessianString("fee"))), "489103666565" + "5a"},
+		{NewHessianRef(1), "5191"},
+	} {
+		raw := MarshalHessian2(c.value)
+		if codec.EncodeToHex(raw) != c.hex {
+			t.Fatalf("marshal %s: expect %s, got %x", c.value.GetType(), c.hex, raw)
+		}
+		values, err := ParseHessian2(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(values) != 1 || !bytes.Equal(MarshalHessian2(values...), raw) {
+			t.Fatalf("parse %s failed: %x", c.value.GetType(), raw)
+		}
+	}
+}
+
+func TestHessian2RoundTrip(t *testing.T) {
+	car := func(model string) *HessianObject {
+		return NewHessianObject("example.Car",
+			NewHessianField("color", NewHessianString("red")),
+			NewHessianField("model", NewHessianString(model)),
+		)
+	}
+	values := []HessianSerializable{
+		NewHessianMap("java.util.HashMap",
+			NewHessianMapEntry(NewHessianString("first"), car("corvette")),
+			NewHessianMapEntry(NewHessianString("second"), car("civic")),
+			NewHessianMapEntry(NewHessianString("types"), NewHessianList("java.util.HashMap", NewHessianMap("java.util.HashMap"))),
+			NewHessianMapEntry(NewHessianString("long"), NewHessianString(strings.Repeat("\u4e2d\U0001f600", 0x5000))),
+			NewHessianMapEntry(NewHessianString("binary"), NewHessianBinary(bytes.Repeat([]byte{0xca, 0xfe}, 0x5000))),
+			NewHessianMapEntry(NewHessianString("self"), NewHessianRef(0)),
+		),
+		NewHessianClass("java.lang.Runtime"),
+	}
+	raw := MarshalHessian2(values...)
+	// the class definition and the type are written only once
+	if bytes.Count(raw, []byte("example.Car")) != 1 || bytes.Count(raw, []byte("java.util.HashMap")) != 1 {
+		t.Fatalf("class definition or type is not reused: %x", raw)
+	}
+
+	parsed, err := ParseHessian2(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(MarshalHessian2(parsed...), raw) {
+		t.Fatal("hessian2 marshal result is different after parsing")
+	}
+	second := parsed[0].(*HessianMap).Entries[1].Value.(*HessianObject)
+	if second.ClassName != "example.Car" || second.Fields[1].Value.(*HessianString).Value != "civic" {
+		t.Fatalf("unexpected object: %s", Dump(second))
+	}
+
+	jsonRaw, err := ToJson(parsed...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJson, err := FromJson(jsonRaw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(MarshalHessian2(fromJson...), raw) {
+		t.Fatal("hessian2 json round trip failed")
+	}
+
+	dump := Dump(parsed...)
+	for _, keyword := range []string{"example.Car {", `model = "civic"`, "ref #0", "java.lang.Class {"} {
+		if !strings.Contains(dump, keyword) {
+			t.Fatalf("dump result should contain %s:\n%s", keyword, dump)
+		}
+	}
+}
+
+func TestHessian1Marshal(t *testing.T) {
+	raw := MarshalHessian1(NewHessianObject("example.Car",
+		NewHessianField("model", NewHessianString("civic")),
+		NewHessianField("year", NewHessianInt(2000)),
+	))
+	expected := "4d" + "74000b" + codec.EncodeToHex([]byte("example.Car")) +
+		"530005" + codec.EncodeToHex([]byte("model")) + "530005" + codec.EncodeToHex([]byte("civic")) +
+		"530004" + codec.EncodeToHex([]byte("year")) + "49000007d0" + "7a"
+	if codec.EncodeToHex(raw) != expected {
+		t.Fatalf("expect %s, got %x", expected, raw)
+	}
+}
@@ -0,0 +1,129 @@
+package hessian
+
+import (
+	"encoding/json"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+// ToJson convert the hessian values to json, it can be converted back by FromJson
+func ToJson(objs ...HessianSerializable) ([]byte, error) {
+	return json.MarshalIndent(objs, "", "  ")
+}
+
+type rawHessianValue struct {
+	Type      string            `json:"type"`
+	Value     json.RawMessage   `json:"value"`
+	ClassName string            `json:"class_name"`
+	Fixed     bool              `json:"fixed"`
+	Index     int               `json:"index"`
+	Items     []json.RawMessage `json:"items"`
+	Entries   []struct {
+		Key   json.RawMessage `json:"key"`
+		Value json.RawMessage `json:"value"`
+	} `json:"entries"`
+	Fields []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"`
+	} `json:"fields"`
+}
+
+// FromJson convert the json generated by ToJson to hessian values, a single value is also accepted
+func FromJson(raw []byte) ([]HessianSerializable, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		v, err := fromJsonValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		return []HessianSerializable{v}, nil
+	}
+	var ret []HessianSerializable
+	for _, item := range items {
+		v, err := fromJsonValue(item)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func fromJsonValue(raw json.RawMessage) (HessianSerializable, error) {
+	var v rawHessianValue
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, utils.Errorf("unmarshal hessian json failed: %v", err)
+	}
+	unmarshalValue := func(i any) error {
+		if len(v.Value) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(v.Value, i); err != nil {
+			return utils.Errorf("unmarshal hessian %s value failed: %v", v.Type, err)
+		}
+		return nil
+	}
+	switch v.Type {
+	case TypeNull:
+		return NewHessianNull(), nil
+	case TypeBool:
+		ret := NewHessianBool(false)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeInt:
+		ret := NewHessianInt(0)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeLong:
+		ret := NewHessianLong(0)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeDouble:
+		ret := NewHessianDouble(0)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeDate:
+		ret := NewHessianDate(0)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeString:
+		ret := NewHessianString("")
+		return ret, unmarshalValue(&ret.Value)
+	case TypeBinary:
+		ret := NewHessianBinary(nil)
+		return ret, unmarshalValue(&ret.Value)
+	case TypeRef:
+		return NewHessianRef(v.Index), nil
+	case TypeList:
+		ret := NewHessianList(v.ClassName)
+		ret.Fixed = v.Fixed
+		for _, item := range v.Items {
+			value, err := fromJsonValue(item)
+			if err != nil {
+				return nil, err
+			}
+			ret.Items = append(ret.Items, value)
+		}
+		return ret, nil
+	case TypeMap:
+		ret := NewHessianMap(v.ClassName)
+		for _, entry := range v.Entries {
+			key, err := fromJsonValue(entry.Key)
+			if err != nil {
+				return nil, err
+			}
+			value, err := fromJsonValue(entry.Value)
+			if err != nil {
+				return nil, err
+			}
+			ret.Entries = append(ret.Entries, NewHessianMapEntry(key, value))
+		}
+		return ret, nil
+	case TypeObject:
+		ret := NewHessianObject(v.ClassName)
+		for _, field := range v.Fields {
+			value, err := fromJsonValue(field.Value)
+			if err != nil {
+				return nil, err
+			}
+			ret.Fields = append(ret.Fields, NewHessianField(field.Name, value))
+		}
+		return ret, nil
+	}
+	return nil, utils.Errorf("unknown hessian type: %s", v.Type)
+}
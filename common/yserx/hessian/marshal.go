@@ -0,0 +1,365 @@
+package hessian
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+const chunkSize = 0x8000
+
+type hessianWriter struct {
+	buf     bytes.Buffer
+	types   map[string]int
+	classes map[string]int
+}
+
+func newHessianWriter() *hessianWriter {
+	return &hessianWriter{types: map[string]int{}, classes: map[string]int{}}
+}
+
+func (w *hessianWriter) writeUint(v uint64, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.buf.WriteByte(byte(v >> (8 * i)))
+	}
+}
+
+// writeChars write utf-16 chars, every char is encoded by utf-8 like java
+func (w *hessianWriter) writeChars(chars []uint16) {
+	for _, c := range chars {
+		switch {
+		case c < 0x80:
+			w.buf.WriteByte(byte(c))
+		case c < 0x800:
+			w.buf.WriteByte(byte(0xc0 | c>>6))
+			w.buf.WriteByte(byte(0x80 | c&0x3f))
+		default:
+			w.buf.WriteByte(byte(0xe0 | c>>12))
+			w.buf.WriteByte(byte(0x80 | (c>>6)&0x3f))
+			w.buf.WriteByte(byte(0x80 | c&0x3f))
+		}
+	}
+}
+
+// splitChunks split the chars to chunks, the chunk can not end with high surrogate
+func splitChunks(chars []uint16) [][]uint16 {
+	var ret [][]uint16
+	for len(chars) > chunkSize {
+		n := chunkSize
+		if utf16.IsSurrogate(rune(chars[n-1])) && chars[n-1] < 0xdc00 {
+			n--
+		}
+		ret = append(ret, chars[:n])
+		chars = chars[n:]
+	}
+	return append(ret, chars)
+}
+
+// MarshalHessian2 serialize the values by hessian 2.0, the compact encodings are used if possible
+func MarshalHessian2(objs ...HessianSerializable) []byte {
+	w := newHessianWriter()
+	for _, obj := range objs {
+		w.writeHessian2(obj)
+	}
+	return w.buf.Bytes()
+}
+
+func (w *hessianWriter) writeHessian2Int(v int32) {
+	switch {
+	case v >= -16 && v <= 47:
+		w.buf.WriteByte(byte(0x90 + v))
+	case v >= -2048 && v <= 2047:
+		w.buf.WriteByte(byte(0xc8 + v>>8))
+		w.buf.WriteByte(byte(v))
+	case v >= -262144 && v <= 262143:
+		w.buf.WriteByte(byte(0xd4 + v>>16))
+		w.writeUint(uint64(v), 2)
+	default:
+		w.buf.WriteByte('I')
+		w.writeUint(uint64(v), 4)
+	}
+}
+
+func (w *hessianWriter) writeHessian2String(s string) {
+	chunks := splitChunks(utf16.Encode([]rune(s)))
+	for _, chunk := range chunks[:len(chunks)-1] {
+		w.buf.WriteByte('R')
+		w.writeUint(uint64(len(chunk)), 2)
+		w.writeChars(chunk)
+	}
+	chunk := chunks[len(chunks)-1]
+	switch n := len(chunk); {
+	case n <= 31:
+		w.buf.WriteByte(byte(n))
+	case n <= 1023:
+		w.buf.WriteByte(byte(0x30 + n>>8))
+		w.buf.WriteByte(byte(n))
+	default:
+		w.buf.WriteByte('S')
+		w.writeUint(uint64(n), 2)
+	}
+	w.writeChars(chunk)
+}
+
+func (w *hessianWriter) writeHessian2Binary(b []byte) {
+	for len(b) > chunkSize {
+		w.buf.WriteByte('A')
+		w.writeUint(chunkSize, 2)
+		w.buf.Write(b[:chunkSize])
+		b = b[chunkSize:]
+	}
+	switch n := len(b); {
+	case n <= 15:
+		w.buf.WriteByte(byte(0x20 + n))
+	case n <= 1023:
+		w.buf.WriteByte(byte(0x34 + n>>8))
+		w.buf.WriteByte(byte(n))
+	default:
+		w.buf.WriteByte('B')
+		w.writeUint(uint64(n), 2)
+	}
+	w.buf.Write(b)
+}
+
+// writeHessian2Type write the type name at the first time, and the reference of type after that
+func (w *hessianWriter) writeHessian2Type(t string) {
+	if index, ok := w.types[t]; ok {
+		w.writeHessian2Int(int32(index))
+		return
+	}
+	w.types[t] = len(w.types)
+	w.writeHessian2String(t)
+}
+
+func (w *hessianWriter) writeHessian2(obj HessianSerializable) {
+	switch ret := obj.(type) {
+	case *HessianBool:
+		if ret.Value {
+			w.buf.WriteByte('T')
+		} else {
+			w.buf.WriteByte('F')
+		}
+	case *HessianInt:
+		w.writeHessian2Int(ret.Value)
+	case *HessianLong:
+		switch v := ret.Value; {
+		case v >= -8 && v <= 15:
+			w.buf.WriteByte(byte(0xe0 + v))
+		case v >= -2048 && v <= 2047:
+			w.buf.WriteByte(byte(0xf8 + v>>8))
+			w.buf.WriteByte(byte(v))
+		case v >= -262144 && v <= 262143:
+			w.buf.WriteByte(byte(0x3c + v>>16))
+			w.writeUint(uint64(v), 2)
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			w.buf.WriteByte(0x59)
+			w.writeUint(uint64(v), 4)
+		default:
+			w.buf.WriteByte('L')
+			w.writeUint(uint64(v), 8)
+		}
+	case *HessianDouble:
+		v := ret.Value
+		mills := v * 1000
+		switch {
+		case v == 0:
+			w.buf.WriteByte(0x5b)
+		case v == 1:
+			w.buf.WriteByte(0x5c)
+		case v == math.Trunc(v) && v >= math.MinInt8 && v <= math.MaxInt8:
+			w.buf.WriteByte(0x5d)
+			w.buf.WriteByte(byte(int8(v)))
+		case v == math.Trunc(v) && v >= math.MinInt16 && v <= math.MaxInt16:
+			w.buf.WriteByte(0x5e)
+			w.writeUint(uint64(int16(v)), 2)
+		case mills == math.Trunc(mills) && mills >= math.MinInt32 && mills <= math.MaxInt32 && float64(int32(mills))/1000 == v:
+			w.buf.WriteByte(0x5f)
+			w.writeUint(uint64(int32(mills)), 4)
+		default:
+			w.buf.WriteByte('D')
+			w.writeUint(math.Float64bits(v), 8)
+		}
+	case *HessianDate:
+		if ret.Value%60000 == 0 && ret.Value/60000 >= math.MinInt32 && ret.Value/60000 <= math.MaxInt32 {
+			w.buf.WriteByte(0x4b)
+			w.writeUint(uint64(ret.Value/60000), 4)
+		} else {
+			w.buf.WriteByte(0x4a)
+			w.writeUint(uint64(ret.Value), 8)
+		}
+	case *HessianString:
+		w.writeHessian2String(ret.Value)
+	case *HessianBinary:
+		w.writeHessian2Binary(ret.Value)
+	case *HessianList:
+		n := len(ret.Items)
+		switch {
+		case ret.Fixed && ret.ClassName != "" && n <= 7:
+			w.buf.WriteByte(byte(0x70 + n))
+			w.writeHessian2Type(ret.ClassName)
+		case ret.Fixed && ret.ClassName != "":
+			w.buf.WriteByte('V')
+			w.writeHessian2Type(ret.ClassName)
+			w.writeHessian2Int(int32(n))
+		case ret.Fixed && n <= 7:
+			w.buf.WriteByte(byte(0x78 + n))
+		case ret.Fixed:
+			w.buf.WriteByte(0x58)
+			w.writeHessian2Int(int32(n))
+		case ret.ClassName != "":
+			w.buf.WriteByte(0x55)
+			w.writeHessian2Type(ret.ClassName)
+		default:
+			w.buf.WriteByte(0x57)
+		}
+		for _, item := range ret.Items {
+			w.writeHessian2(item)
+		}
+		if !ret.Fixed {
+			w.buf.WriteByte('Z')
+		}
+	case *HessianMap:
+		if ret.ClassName != "" {
+			w.buf.WriteByte('M')
+			w.writeHessian2Type(ret.ClassName)
+		} else {
+			w.buf.WriteByte('H')
+		}
+		for _, entry := range ret.Entries {
+			w.writeHessian2(entry.Key)
+			w.writeHessian2(entry.Value)
+		}
+		w.buf.WriteByte('Z')
+	case *HessianObject:
+		var names []string
+		for _, field := range ret.Fields {
+			names = append(names, field.Name)
+		}
+		key := ret.ClassName + "\x00" + strings.Join(names, "\x00")
+		index, ok := w.classes[key]
+		if !ok {
+			index = len(w.classes)
+			w.classes[key] = index
+			w.buf.WriteByte('C')
+			w.writeHessian2String(ret.ClassName)
+			w.writeHessian2Int(int32(len(names)))
+			for _, name := range names {
+				w.writeHessian2String(name)
+			}
+		}
+		if index <= 15 {
+			w.buf.WriteByte(byte(0x60 + index))
+		} else {
+			w.buf.WriteByte('O')
+			w.writeHessian2Int(int32(index))
+		}
+		for _, field := range ret.Fields {
+			w.writeHessian2(field.Value)
+		}
+	case *HessianRef:
+		w.buf.WriteByte('Q')
+		w.writeHessian2Int(int32(ret.Index))
+	default:
+		w.buf.WriteByte('N')
+	}
+}
+
+// MarshalHessian1 serialize the values by hessian 1.0, the object is written as typed map
+func MarshalHessian1(objs ...HessianSerializable) []byte {
+	w := newHessianWriter()
+	for _, obj := range objs {
+		w.writeHessian1(obj)
+	}
+	return w.buf.Bytes()
+}
+
+func (w *hessianWriter) writeHessian1Chunks(chars []uint16, chunk, final byte) {
+	chunks := splitChunks(chars)
+	for i, c := range chunks {
+		if i == len(chunks)-1 {
+			w.buf.WriteByte(final)
+		} else {
+			w.buf.WriteByte(chunk)
+		}
+		w.writeUint(uint64(len(c)), 2)
+		w.writeChars(c)
+	}
+}
+
+func (w *hessianWriter) writeHessian1Type(t string) {
+	if t == "" {
+		return
+	}
+	w.buf.WriteByte('t')
+	w.writeUint(uint64(len(t)), 2)
+	w.buf.WriteString(t)
+}
+
+func (w *hessianWriter) writeHessian1(obj HessianSerializable) {
+	switch ret := obj.(type) {
+	case *HessianBool:
+		if ret.Value {
+			w.buf.WriteByte('T')
+		} else {
+			w.buf.WriteByte('F')
+		}
+	case *HessianInt:
+		w.buf.WriteByte('I')
+		w.writeUint(uint64(ret.Value), 4)
+	case *HessianLong:
+		w.buf.WriteByte('L')
+		w.writeUint(uint64(ret.Value), 8)
+	case *HessianDouble:
+		w.buf.WriteByte('D')
+		w.writeUint(math.Float64bits(ret.Value), 8)
+	case *HessianDate:
+		w.buf.WriteByte('d')
+		w.writeUint(uint64(ret.Value), 8)
+	case *HessianString:
+		w.writeHessian1Chunks(utf16.Encode([]rune(ret.Value)), 's', 'S')
+	case *HessianBinary:
+		b := ret.Value
+		for len(b) > chunkSize {
+			w.buf.WriteByte('b')
+			w.writeUint(chunkSize, 2)
+			w.buf.Write(b[:chunkSize])
+			b = b[chunkSize:]
+		}
+		w.buf.WriteByte('B')
+		w.writeUint(uint64(len(b)), 2)
+		w.buf.Write(b)
+	case *HessianList:
+		w.buf.WriteByte('V')
+		w.writeHessian1Type(ret.ClassName)
+		if ret.Fixed {
+			w.buf.WriteByte('l')
+			w.writeUint(uint64(len(ret.Items)), 4)
+		}
+		for _, item := range ret.Items {
+			w.writeHessian1(item)
+		}
+		w.buf.WriteByte('z')
+	case *HessianMap:
+		w.buf.WriteByte('M')
+		w.writeHessian1Type(ret.ClassName)
+		for _, entry := range ret.Entries {
+			w.writeHessian1(entry.Key)
+			w.writeHessian1(entry.Value)
+		}
+		w.buf.WriteByte('z')
+	case *HessianObject:
+		w.buf.WriteByte('M')
+		w.writeHessian1Type(ret.ClassName)
+		for _, field := range ret.Fields {
+			w.writeHessian1(NewHessianString(field.Name))
+			w.writeHessian1(field.Value)
+		}
+		w.buf.WriteByte('z')
+	case *HessianRef:
+		w.buf.WriteByte('R')
+		w.writeUint(uint64(ret.Index), 4)
+	default:
+		w.buf.WriteByte('N')
+	}
+}
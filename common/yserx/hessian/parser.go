@@ -0,0 +1,500 @@
+package hessian
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"unicode/utf16"
+
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+type classDefinition struct {
+	name   string
+	fields []string
+}
+
+type hessian2Parser struct {
+	r       *bytes.Reader
+	types   []string
+	classes []*classDefinition
+}
+
+// ParseHessian2 parse the hessian 2.0 serialized values
+func ParseHessian2(raw []byte) ([]HessianSerializable, error) {
+	p := &hessian2Parser{r: bytes.NewReader(raw)}
+	var ret []HessianSerializable
+	for p.r.Len() > 0 {
+		v, err := p.readValue()
+		if err != nil {
+			return ret, err
+		}
+		ret = append(ret, v)
+	}
+	return ret, nil
+}
+
+func (p *hessian2Parser) readByte() (byte, error) {
+	b, err := p.r.ReadByte()
+	if err != nil {
+		return 0, utils.Errorf("hessian2 read byte at %d failed: %v", p.offset(), err)
+	}
+	return b, nil
+}
+
+func (p *hessian2Parser) peekByte() (byte, error) {
+	b, err := p.readByte()
+	if err != nil {
+		return 0, err
+	}
+	return b, p.r.UnreadByte()
+}
+
+func (p *hessian2Parser) offset() int {
+	return int(p.r.Size()) - p.r.Len()
+}
+
+func (p *hessian2Parser) readBytes(n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(p.r, buf); err != nil {
+		return nil, utils.Errorf("hessian2 read %d bytes at %d failed: %v", n, p.offset(), err)
+	}
+	return buf, nil
+}
+
+func (p *hessian2Parser) readUint(n int) (uint64, error) {
+	buf, err := p.readBytes(n)
+	if err != nil {
+		return 0, err
+	}
+	var ret uint64
+	for _, b := range buf {
+		ret = ret<<8 | uint64(b)
+	}
+	return ret, nil
+}
+
+func (p *hessian2Parser) readInt() (int32, error) {
+	v, err := p.readValue()
+	if err != nil {
+		return 0, err
+	}
+	i, ok := v.(*HessianInt)
+	if !ok {
+		return 0, utils.Errorf("hessian2 expect int at %d, got %s", p.offset(), v.GetType())
+	}
+	return i.Value, nil
+}
+
+func (p *hessian2Parser) readString() (string, error) {
+	v, err := p.readValue()
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(*HessianString)
+	if !ok {
+		return "", utils.Errorf("hessian2 expect string at %d, got %s", p.offset(), v.GetType())
+	}
+	return s.Value, nil
+}
+
+// readType read the type of list and map, it is a string or a reference to the previous type
+func (p *hessian2Parser) readType() (string, error) {
+	b, err := p.peekByte()
+	if err != nil {
+		return "", err
+	}
+	if b <= 0x1f || (b >= 0x30 && b <= 0x33) || b == 'S' || b == 'R' {
+		t, err := p.readString()
+		if err != nil {
+			return "", err
+		}
+		p.types = append(p.types, t)
+		return t, nil
+	}
+	index, err := p.readInt()
+	if err != nil {
+		return "", err
+	}
+	if index < 0 || int(index) >= len(p.types) {
+		return "", utils.Errorf("hessian2 type reference %d out of range", index)
+	}
+	return p.types[index], nil
+}
+
+// readChars read n utf-16 chars encoded by utf-8, the surrogate pair is encoded as two chars
+func (p *hessian2Parser) readChars(n int) ([]uint16, error) {
+	ret := make([]uint16, 0, n)
+	for i := 0; i < n; i++ {
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		var c uint16
+		switch {
+		case b < 0x80:
+			c = uint16(b)
+		case b&0xe0 == 0xc0:
+			b1, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			c = uint16(b&0x1f)<<6 | uint16(b1&0x3f)
+		case b&0xf0 == 0xe0:
+			buf, err := p.readBytes(2)
+			if err != nil {
+				return nil, err
+			}
+			c = uint16(b&0x0f)<<12 | uint16(buf[0]&0x3f)<<6 | uint16(buf[1]&0x3f)
+		case b&0xf8 == 0xf0:
+			// standard utf-8 supplementary char is two utf-16 chars
+			buf, err := p.readBytes(3)
+			if err != nil {
+				return nil, err
+			}
+			r := rune(b&0x07)<<18 | rune(buf[0]&0x3f)<<12 | rune(buf[1]&0x3f)<<6 | rune(buf[2]&0x3f)
+			r1, r2 := utf16.EncodeRune(r)
+			ret = append(ret, uint16(r1), uint16(r2))
+			i++
+			continue
+		default:
+			return nil, utils.Errorf("hessian2 invalid utf-8 byte %#x at %d", b, p.offset())
+		}
+		ret = append(ret, c)
+	}
+	return ret, nil
+}
+
+func (p *hessian2Parser) readStringBody(code byte) (string, error) {
+	var chars []uint16
+	for {
+		var length int
+		final := true
+		switch {
+		case code <= 0x1f:
+			length = int(code)
+		case code >= 0x30 && code <= 0x33:
+			b, err := p.readByte()
+			if err != nil {
+				return "", err
+			}
+			length = int(code-0x30)<<8 | int(b)
+		case code == 'S' || code == 'R':
+			n, err := p.readUint(2)
+			if err != nil {
+				return "", err
+			}
+			length, final = int(n), code == 'S'
+		default:
+			return "", utils.Errorf("hessian2 expect string chunk at %d, got %#x", p.offset(), code)
+		}
+		chunk, err := p.readChars(length)
+		if err != nil {
+			return "", err
+		}
+		chars = append(chars, chunk...)
+		if final {
+			return string(utf16.Decode(chars)), nil
+		}
+		code, err = p.readByte()
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *hessian2Parser) readBinaryBody(code byte) ([]byte, error) {
+	var ret []byte
+	for {
+		var length int
+		final := true
+		switch {
+		case code >= 0x20 && code <= 0x2f:
+			length = int(code - 0x20)
+		case code >= 0x34 && code <= 0x37:
+			b, err := p.readByte()
+			if err != nil {
+				return nil, err
+			}
+			length = int(code-0x34)<<8 | int(b)
+		case code == 'B' || code == 'A':
+			n, err := p.readUint(2)
+			if err != nil {
+				return nil, err
+			}
+			length, final = int(n), code == 'B'
+		default:
+			return nil, utils.Errorf("hessian2 expect binary chunk at %d, got %#x", p.offset(), code)
+		}
+		chunk, err := p.readBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, chunk...)
+		if final {
+			return ret, nil
+		}
+		code, err = p.readByte()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *hessian2Parser) readItems(list *HessianList, length int) (*HessianList, error) {
+	for i := 0; length < 0 || i < length; i++ {
+		if length < 0 {
+			b, err := p.peekByte()
+			if err != nil {
+				return nil, err
+			}
+			if b == 'Z' {
+				p.r.ReadByte()
+				break
+			}
+		}
+		v, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		list.Items = append(list.Items, v)
+	}
+	return list, nil
+}
+
+func (p *hessian2Parser) readMapEntries(m *HessianMap) (*HessianMap, error) {
+	for {
+		b, err := p.peekByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == 'Z' {
+			p.r.ReadByte()
+			return m, nil
+		}
+		key, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		m.Entries = append(m.Entries, NewHessianMapEntry(key, value))
+	}
+}
+
+func (p *hessian2Parser) readObject(index int) (*HessianObject, error) {
+	if index < 0 || index >= len(p.classes) {
+		return nil, utils.Errorf("hessian2 class definition %d out of range", index)
+	}
+	def := p.classes[index]
+	obj := NewHessianObject(def.name)
+	for _, name := range def.fields {
+		v, err := p.readValue()
+		if err != nil {
+			return nil, err
+		}
+		obj.Fields = append(obj.Fields, NewHessianField(name, v))
+	}
+	return obj, nil
+}
+
+func (p *hessian2Parser) readValue() (HessianSerializable, error) {
+	code, err := p.readByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case code == 'N':
+		return NewHessianNull(), nil
+	case code == 'T' || code == 'F':
+		return NewHessianBool(code == 'T'), nil
+
+	// int
+	case code >= 0x80 && code <= 0xbf:
+		return NewHessianInt(int32(code) - 0x90), nil
+	case code >= 0xc0 && code <= 0xcf:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianInt((int32(code)-0xc8)<<8 | int32(b)), nil
+	case code >= 0xd0 && code <= 0xd7:
+		n, err := p.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianInt((int32(code)-0xd4)<<16 | int32(n)), nil
+	case code == 'I':
+		n, err := p.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianInt(int32(n)), nil
+
+	// long
+	case code >= 0xd8 && code <= 0xef:
+		return NewHessianLong(int64(code) - 0xe0), nil
+	case code >= 0xf0:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianLong((int64(code)-0xf8)<<8 | int64(b)), nil
+	case code >= 0x38 && code <= 0x3f:
+		n, err := p.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianLong((int64(code)-0x3c)<<16 | int64(n)), nil
+	case code == 0x59:
+		n, err := p.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianLong(int64(int32(n))), nil
+	case code == 'L':
+		n, err := p.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianLong(int64(n)), nil
+
+	// double
+	case code == 0x5b:
+		return NewHessianDouble(0), nil
+	case code == 0x5c:
+		return NewHessianDouble(1), nil
+	case code == 0x5d:
+		b, err := p.readByte()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(float64(int8(b))), nil
+	case code == 0x5e:
+		n, err := p.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(float64(int16(n))), nil
+	case code == 0x5f:
+		n, err := p.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(float64(int32(n)) / 1000), nil
+	case code == 'D':
+		n, err := p.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDouble(math.Float64frombits(n)), nil
+
+	// date
+	case code == 0x4a:
+		n, err := p.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDate(int64(n)), nil
+	case code == 0x4b:
+		n, err := p.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianDate(int64(int32(n)) * 60000), nil
+
+	// string and binary
+	case code <= 0x1f || (code >= 0x30 && code <= 0x33) || code == 'S' || code == 'R':
+		s, err := p.readStringBody(code)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianString(s), nil
+	case (code >= 0x20 && code <= 0x2f) || (code >= 0x34 && code <= 0x37) || code == 'B' || code == 'A':
+		b, err := p.readBinaryBody(code)
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianBinary(b), nil
+
+	// list
+	case code == 0x55 || code == 'V' || (code >= 0x70 && code <= 0x77):
+		t, err := p.readType()
+		if err != nil {
+			return nil, err
+		}
+		list := &HessianList{Type: TypeList, ClassName: t, Fixed: code != 0x55}
+		switch {
+		case code == 0x55:
+			return p.readItems(list, -1)
+		case code == 'V':
+			length, err := p.readInt()
+			if err != nil {
+				return nil, err
+			}
+			return p.readItems(list, int(length))
+		default:
+			return p.readItems(list, int(code-0x70))
+		}
+	case code == 0x57:
+		return p.readItems(&HessianList{Type: TypeList}, -1)
+	case code == 0x58:
+		length, err := p.readInt()
+		if err != nil {
+			return nil, err
+		}
+		return p.readItems(&HessianList{Type: TypeList, Fixed: true}, int(length))
+	case code >= 0x78 && code <= 0x7f:
+		return p.readItems(&HessianList{Type: TypeList, Fixed: true}, int(code-0x78))
+
+	// map
+	case code == 'M':
+		t, err := p.readType()
+		if err != nil {
+			return nil, err
+		}
+		return p.readMapEntries(NewHessianMap(t))
+	case code == 'H':
+		return p.readMapEntries(NewHessianMap(""))
+
+	// object
+	case code == 'C':
+		name, err := p.readString()
+		if err != nil {
+			return nil, err
+		}
+		count, err := p.readInt()
+		if err != nil {
+			return nil, err
+		}
+		def := &classDefinition{name: name}
+		for i := 0; i < int(count); i++ {
+			field, err := p.readString()
+			if err != nil {
+				return nil, err
+			}
+			def.fields = append(def.fields, field)
+		}
+		p.classes = append(p.classes, def)
+		// class definition is followed by the object
+		return p.readValue()
+	case code == 'O':
+		index, err := p.readInt()
+		if err != nil {
+			return nil, err
+		}
+		return p.readObject(int(index))
+	case code >= 0x60 && code <= 0x6f:
+		return p.readObject(int(code - 0x60))
+
+	case code == 'Q':
+		index, err := p.readInt()
+		if err != nil {
+			return nil, err
+		}
+		return NewHessianRef(int(index)), nil
+	}
+	return nil, utils.Errorf("hessian2 unknown code %#x at %d", code, p.offset()-1)
+}
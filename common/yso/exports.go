@@ -35,11 +35,27 @@ var Exports = map[string]interface{}{
 	"GetURLDNSJavaObject":                  GetURLDNSJavaObject,
 	"GetFindGadgetByDNSJavaObject":         GetFindGadgetByDNSJavaObject,
 
-	//"GetJRMPClientJavaObject":              GetJRMPClientJavaObject,
+	"GetJRMPClientJavaObject":     GetJRMPClientJavaObject,
 	"GetJSON1JavaObject":          GetJSON1JavaObject,
 	"GetJavassistWeld1JavaObject": GetJavassistWeld1JavaObject,
 	"GetJdk7u21JavaObject":        GetJdk7u21JavaObject,
 	"GetJdk8u20JavaObject":        GetJdk8u20JavaObject,
+	// hessian, xstream, fastjson and jackson payload
+	"GetHessianRomeSignedObjectPayload":      GetHessianRomeSignedObjectPayload,
+	"GetHessianRomeJNDIPayload":              GetHessianRomeJNDIPayload,
+	"GetHessianSpringPointcutAdvisorPayload": GetHessianSpringPointcutAdvisorPayload,
+	"GetHessianResinPayload":                 GetHessianResinPayload,
+	"GetXStreamCommonsBeanutilsPayload":      GetXStreamCommonsBeanutilsPayload,
+	"GetXStreamEventHandlerPayload":          GetXStreamEventHandlerPayload,
+	"GetFastjsonTemplatesImplPayload":        GetFastjsonTemplatesImplPayload,
+	"GetFastjsonBCELPayload":                 GetFastjsonBCELPayload,
+	"GetFastjsonJdbcRowSetImplPayload":       GetFastjsonJdbcRowSetImplPayload,
+	"GetFastjsonCacheBypassPayload":          GetFastjsonCacheBypassPayload,
+	"GetJacksonTemplatesImplPayload":         GetJacksonTemplatesImplPayload,
+	"GetJacksonJdbcRowSetImplPayload":        GetJacksonJdbcRowSetImplPayload,
+	"GetAllFormatGadget":                     GetAllFormatGadget,
+	"GetAllJNDIPayload":                      GetAllJNDIPayload,
+	"ToHessian1Bytes":                        ToHessian1Bytes,
 	//Get gadgets in batches
 	"GetAllGadget":            GetAllGadget,
 	"GetAllTemplatesGadget":   GetAllTemplatesGadget,
